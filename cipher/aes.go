@@ -0,0 +1,189 @@
+package cipher
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"github.com/ory/herodot"
+
+	"github.com/ory/kratos/driver/config"
+)
+
+const aesGCMPrefix = "$aes256gcm$"
+
+var _ Cipher = new(AESGCM)
+
+// AESGCM encrypts values with AES-256-GCM using the keys configured in `secrets.cipher`. If no keys are
+// configured, keys derived from `secrets.default` are used instead.
+//
+// Ciphertexts have the form `$aes256gcm$k=<version>$<nonce and sealed value>`. The version of a key is its
+// position counted from the end of the list, so adding a new key to the top of the list keeps the versions of
+// all other keys.
+type AESGCM struct {
+	c config.Provider
+}
+
+func NewAESGCM(c config.Provider) *AESGCM {
+	return &AESGCM{c: c}
+}
+
+func (a *AESGCM) Encrypt(ctx context.Context, plaintext []byte) (string, error) {
+	keys, versioned, err := a.keys(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	aead, err := newAEAD(keys[0])
+	if err != nil {
+		return "", err
+	}
+
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return "", errors.WithStack(err)
+	}
+
+	return fmt.Sprintf("%sk=%d$%s", aesGCMPrefix, versioned-1, base64.RawURLEncoding.EncodeToString(aead.Seal(nonce, nonce, plaintext, nil))), nil
+}
+
+// Decrypt tries the key the ciphertext is tagged with first and falls back to all other keys, for example
+// because keys were removed from the bottom of the list.
+func (a *AESGCM) Decrypt(ctx context.Context, ciphertext string) ([]byte, error) {
+	version, sealed, err := decodeAESGCM(ciphertext)
+	if err != nil {
+		return nil, err
+	}
+
+	keys, versioned, err := a.keys(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	tagged := versioned - 1 - version
+	if tagged >= 0 && tagged < versioned {
+		if plaintext, err := open(keys[tagged], sealed); err == nil {
+			return plaintext, nil
+		}
+	}
+
+	for k := range keys {
+		if k == tagged {
+			continue
+		}
+		if plaintext, err := open(keys[k], sealed); err == nil {
+			return plaintext, nil
+		}
+	}
+
+	return nil, errors.WithStack(ErrDecryptionFailed)
+}
+
+func (a *AESGCM) NeedsRotation(ctx context.Context, ciphertext string) bool {
+	_, sealed, err := decodeAESGCM(ciphertext)
+	if err != nil {
+		return true
+	}
+
+	keys, _, err := a.keys(ctx)
+	if err != nil {
+		return false
+	}
+
+	_, err = open(keys[0], sealed)
+	return err != nil
+}
+
+func (a *AESGCM) Understands(ciphertext string) bool {
+	return strings.HasPrefix(ciphertext, aesGCMPrefix)
+}
+
+// keys returns the keys of `secrets.cipher` followed by the keys derived from `secrets.default`. The first
+// versioned keys are tagged with their version. If `secrets.cipher` is set, the derived keys are only used to
+// decrypt values which were encrypted before it was set.
+func (a *AESGCM) keys(ctx context.Context) (keys [][32]byte, versioned int, err error) {
+	conf := a.c.Config(ctx)
+	keys, err = conf.SecretsCipherOrErr()
+	if err != nil {
+		return nil, 0, err
+	}
+	versioned = len(keys)
+
+	secrets, err := conf.SecretsDefaultOrErr()
+	if err != nil {
+		return nil, 0, err
+	}
+	for _, secret := range secrets {
+		keys = append(keys, sha256.Sum256(append([]byte("ory-kratos-cipher:"), secret...)))
+	}
+
+	if versioned == 0 {
+		versioned = len(keys)
+	}
+	if len(keys) == 0 {
+		return nil, 0, errors.WithStack(herodot.ErrInternalServerError.WithReason("No encryption keys are configured."))
+	}
+	return keys, versioned, nil
+}
+
+func decodeAESGCM(ciphertext string) (version int, sealed []byte, err error) {
+	if !strings.HasPrefix(ciphertext, aesGCMPrefix) {
+		return 0, nil, errors.WithStack(ErrUnknownFormat)
+	}
+
+	parts := strings.Split(strings.TrimPrefix(ciphertext, aesGCMPrefix), "$")
+	if len(parts) != 2 || !strings.HasPrefix(parts[0], "k=") {
+		return 0, nil, errors.WithStack(ErrUnknownFormat)
+	}
+
+	version, err = strconv.Atoi(strings.TrimPrefix(parts[0], "k="))
+	if err != nil {
+		return 0, nil, errors.WithStack(ErrUnknownFormat)
+	}
+
+	sealed, err = base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return 0, nil, errors.WithStack(ErrUnknownFormat)
+	}
+
+	return version, sealed, nil
+}
+
+func newAEAD(key [32]byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key[:])
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	return aead, nil
+}
+
+func open(key [32]byte, sealed []byte) ([]byte, error) {
+	aead, err := newAEAD(key)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(sealed) < aead.NonceSize() {
+		return nil, errors.WithStack(ErrDecryptionFailed)
+	}
+
+	plaintext, err := aead.Open(nil, sealed[:aead.NonceSize()], sealed[aead.NonceSize():], nil)
+	if err != nil {
+		return nil, errors.WithStack(ErrDecryptionFailed)
+	}
+	return plaintext, nil
+}
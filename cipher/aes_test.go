@@ -0,0 +1,114 @@
+package cipher_test
+
+import (
+	"context"
+	"encoding/base64"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ory/kratos/cipher"
+	"github.com/ory/kratos/driver/config"
+	"github.com/ory/kratos/internal"
+)
+
+func TestAESGCM(t *testing.T) {
+	ctx := context.Background()
+	conf, reg := internal.NewFastRegistryWithMocks(t)
+	c := cipher.NewAESGCM(reg)
+
+	const (
+		oldKey = "old-key-which-is-32-bytes-long.."
+		newKey = "new-key-which-is-32-bytes-long.."
+	)
+	plaintext := []byte("the refresh token must not be stored in plain text")
+
+	t.Run("case=falls back to keys derived from the default secrets", func(t *testing.T) {
+		conf.MustSet(config.ViperKeySecretsCipher, []string{})
+
+		encrypted, err := c.Encrypt(ctx, plaintext)
+		require.NoError(t, err)
+		assert.True(t, strings.HasPrefix(encrypted, "$aes256gcm$k=0$"), encrypted)
+		assert.True(t, c.Understands(encrypted))
+		assert.NotContains(t, encrypted, string(plaintext))
+		assert.False(t, c.NeedsRotation(ctx, encrypted))
+
+		decrypted, err := c.Decrypt(ctx, encrypted)
+		require.NoError(t, err)
+		assert.Equal(t, plaintext, decrypted)
+
+		conf.MustSet(config.ViperKeySecretsCipher, []string{oldKey})
+		decrypted, err = c.Decrypt(ctx, encrypted)
+		require.NoError(t, err, "values encrypted before secrets.cipher was set remain readable")
+		assert.Equal(t, plaintext, decrypted)
+		assert.True(t, c.NeedsRotation(ctx, encrypted))
+	})
+
+	t.Run("case=rotates keys", func(t *testing.T) {
+		conf.MustSet(config.ViperKeySecretsCipher, []string{oldKey})
+		old, err := c.Encrypt(ctx, plaintext)
+		require.NoError(t, err)
+		assert.True(t, strings.HasPrefix(old, "$aes256gcm$k=0$"), old)
+
+		conf.MustSet(config.ViperKeySecretsCipher, []string{newKey, oldKey})
+		current, err := c.Encrypt(ctx, plaintext)
+		require.NoError(t, err)
+		assert.True(t, strings.HasPrefix(current, "$aes256gcm$k=1$"), current)
+
+		for _, encrypted := range []string{old, current} {
+			decrypted, err := c.Decrypt(ctx, encrypted)
+			require.NoError(t, err)
+			assert.Equal(t, plaintext, decrypted)
+		}
+		assert.True(t, c.NeedsRotation(ctx, old))
+		assert.False(t, c.NeedsRotation(ctx, current))
+
+		conf.MustSet(config.ViperKeySecretsCipher, []string{newKey})
+		decrypted, err := c.Decrypt(ctx, current)
+		require.NoError(t, err, "values remain readable when old keys are removed")
+		assert.Equal(t, plaintext, decrypted)
+
+		_, err = c.Decrypt(ctx, old)
+		assert.ErrorIs(t, err, cipher.ErrDecryptionFailed)
+	})
+
+	t.Run("case=rejects invalid values", func(t *testing.T) {
+		conf.MustSet(config.ViperKeySecretsCipher, []string{newKey})
+		encrypted, err := c.Encrypt(ctx, plaintext)
+		require.NoError(t, err)
+
+		sealed, err := base64.RawURLEncoding.DecodeString(strings.SplitN(encrypted, "$", 4)[3])
+		require.NoError(t, err)
+		sealed[len(sealed)/2] ^= 1
+		tampered := "$aes256gcm$k=0$" + base64.RawURLEncoding.EncodeToString(sealed)
+
+		for _, tc := range []struct {
+			value    string
+			expected error
+		}{
+			{value: string(plaintext), expected: cipher.ErrUnknownFormat},
+			{value: "$aes256gcm$k=a$" + strings.SplitN(encrypted, "$", 4)[3], expected: cipher.ErrUnknownFormat},
+			{value: "$aes256gcm$k=0$not base64", expected: cipher.ErrUnknownFormat},
+			{value: "$aes256gcm$k=0$", expected: cipher.ErrDecryptionFailed},
+			{value: tampered, expected: cipher.ErrDecryptionFailed},
+		} {
+			_, err := c.Decrypt(ctx, tc.value)
+			assert.ErrorIs(t, err, tc.expected, tc.value)
+		}
+
+		assert.False(t, c.Understands(string(plaintext)))
+		assert.True(t, c.NeedsRotation(ctx, string(plaintext)))
+	})
+
+	t.Run("case=rejects keys of the wrong length", func(t *testing.T) {
+		conf.MustSet(config.ViperKeySecretsCipher, []string{"too-short-but-at-least-16"})
+		t.Cleanup(func() {
+			conf.MustSet(config.ViperKeySecretsCipher, []string{})
+		})
+
+		_, err := c.Encrypt(ctx, plaintext)
+		assert.Error(t, err)
+	})
+}
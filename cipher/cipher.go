@@ -0,0 +1,37 @@
+package cipher
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+)
+
+var (
+	// ErrUnknownFormat is returned if a value was not encrypted by a known cipher.
+	ErrUnknownFormat = errors.New("the value was not encrypted by a known cipher")
+
+	// ErrDecryptionFailed is returned if a value can not be decrypted with any of the configured keys.
+	ErrDecryptionFailed = errors.New("the value can not be decrypted with any of the configured keys")
+)
+
+// Cipher encrypts and decrypts values with versioned keys so that keys can be rotated without making existing
+// values unreadable.
+type Cipher interface {
+	// Encrypt encrypts the plaintext with the current key. The returned ciphertext is tagged with the version of
+	// the key.
+	Encrypt(ctx context.Context, plaintext []byte) (string, error)
+
+	// Decrypt decrypts a ciphertext which was encrypted with any of the configured keys.
+	Decrypt(ctx context.Context, ciphertext string) ([]byte, error)
+
+	// NeedsRotation returns true if the ciphertext was not encrypted with the current key and should be
+	// re-encrypted.
+	NeedsRotation(ctx context.Context, ciphertext string) bool
+
+	// Understands returns true if the ciphertext was encrypted by this cipher's algorithm.
+	Understands(ciphertext string) bool
+}
+
+type Provider interface {
+	Cipher() Cipher
+}
@@ -0,0 +1,69 @@
+package backup
+
+import (
+	"github.com/spf13/cobra"
+
+	"github.com/ory/kratos/cmd/cliclient"
+	"github.com/ory/x/configx"
+)
+
+// backupCmd represents the backup command
+var backupCmd = &cobra.Command{
+	Use:   "backup <file> <database-url>",
+	Short: "Back up the database while ORY Kratos is running",
+	Long: `Writes a backup of the database to the given file. The file must not exist yet.
+
+If ORY Kratos uses SQLite, a consistent snapshot of the whole database is written using "VACUUM INTO", which is
+safe while the server is running. Other databases can not be snapshotted by this command, back them up using the
+tools of the database instead, for example pg_dump or mysqldump.
+
+If the --identities-only flag is set, only identities including their credentials, verifiable addresses, and
+recovery addresses are exported as JSON lines. This works with all databases but is NOT a backup: sessions,
+self-service flows, recovery and verification tokens, courier messages, and all other data are not exported.
+
+You can read in the database URL using the -e flag, for example:
+	export DSN=...
+	kratos backup -e backup.db
+
+### WARNING ###
+
+Backups contain credentials such as password hashes. Store them as securely as the database itself!
+`,
+	Run: func(cmd *cobra.Command, args []string) {
+		cliclient.NewBackupHandler().Backup(cmd, args)
+	},
+}
+
+// restoreCmd represents the restore command
+var restoreCmd = &cobra.Command{
+	Use:   "restore <file> <database-url>",
+	Short: "Restore a backup or identity export created by kratos backup",
+	Long: `Restores a SQLite snapshot or an identity export created by "kratos backup".
+
+SQLite snapshots replace the SQLite database file. ORY Kratos must be stopped while a snapshot is restored.
+Identity exports created with --identities-only are imported into the database; identities which exist already
+are skipped.
+
+You can read in the database URL using the -e flag, for example:
+	export DSN=...
+	kratos restore -e backup.db
+`,
+	Run: func(cmd *cobra.Command, args []string) {
+		cliclient.NewBackupHandler().Restore(cmd, args)
+	},
+}
+
+func init() {
+	for _, c := range []*cobra.Command{backupCmd, restoreCmd} {
+		configx.RegisterFlags(c.PersistentFlags())
+		c.Flags().BoolP("read-from-env", "e", false, "If set, reads the database connection string from the environment variable DSN or config file key dsn.")
+	}
+
+	backupCmd.Flags().Bool("identities-only", false, "If set, only exports identities as JSON lines. Required for databases other than SQLite.")
+	restoreCmd.Flags().BoolP("yes", "y", false, "If set all confirmation requests are accepted without user interaction.")
+}
+
+func RegisterCommandRecursive(parent *cobra.Command) {
+	parent.AddCommand(backupCmd)
+	parent.AddCommand(restoreCmd)
+}
@@ -0,0 +1,195 @@
+package cliclient
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+
+	"github.com/ory/x/cmdx"
+	"github.com/ory/x/flagx"
+	"github.com/ory/x/sqlcon"
+
+	"github.com/ory/kratos/driver"
+	"github.com/ory/kratos/identity"
+)
+
+var sqliteFileHeader = []byte("SQLite format 3\x00")
+
+type (
+	BackupHandler struct{}
+
+	// identityExport is an identity including its credentials as written by `kratos backup --identities-only`.
+	identityExport struct {
+		*identity.Identity
+		Credentials map[identity.CredentialsType]identity.Credentials `json:"credentials"`
+	}
+)
+
+func NewBackupHandler() *BackupHandler {
+	return &BackupHandler{}
+}
+
+func isSQLite(ctx context.Context, d driver.Registry) bool {
+	return d.Persister().GetConnection(ctx).Dialect.Name() == "sqlite3"
+}
+
+func (h *BackupHandler) Backup(cmd *cobra.Command, args []string) {
+	if len(args) == 0 {
+		fmt.Println(cmd.UsageString())
+		os.Exit(1)
+	}
+
+	path := args[0]
+	d := registryFromArgs(cmd, args, 1)
+
+	if _, err := os.Stat(path); err == nil {
+		cmdx.Fatalf("The backup file %s exists already.", path)
+	}
+
+	if !flagx.MustGetBool(cmd, "identities-only") {
+		if !isSQLite(cmd.Context(), d) {
+			cmdx.Fatalf("Only SQLite databases can be snapshotted, use the backup tools of the database instead. To export identities only, use flag --identities-only.")
+		}
+
+		err := d.Persister().BackupSQLite(cmd.Context(), path)
+		cmdx.Must(err, "An error occurred while creating the snapshot: %s", err)
+		fmt.Printf("Successfully wrote a snapshot of the SQLite database to %s!\n", path)
+		return
+	}
+
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0600)
+	cmdx.Must(err, "Unable to create the backup file: %s", err)
+	defer f.Close()
+
+	n, err := exportIdentities(cmd.Context(), d, f)
+	cmdx.Must(err, "An error occurred while exporting identities: %s", err)
+	fmt.Printf("Successfully exported %d identities to %s! Note that the export only contains identities and is not a backup of the database.\n", n, path)
+}
+
+func exportIdentities(ctx context.Context, d driver.Registry, w io.Writer) (int, error) {
+	const perPage = 500
+
+	var n int
+	enc := json.NewEncoder(w)
+	for page := 1; ; page++ {
+		is, err := d.Persister().ListIdentities(ctx, page, perPage)
+		if err != nil {
+			return n, err
+		}
+
+		for _, i := range is {
+			ic, err := d.Persister().GetIdentityConfidential(ctx, i.ID)
+			if err != nil {
+				return n, err
+			}
+
+			if err := enc.Encode(&identityExport{Identity: ic, Credentials: ic.Credentials}); err != nil {
+				return n, errors.WithStack(err)
+			}
+			n++
+		}
+
+		if len(is) < perPage {
+			return n, nil
+		}
+	}
+}
+
+func (h *BackupHandler) Restore(cmd *cobra.Command, args []string) {
+	if len(args) == 0 {
+		fmt.Println(cmd.UsageString())
+		os.Exit(1)
+	}
+
+	path := args[0]
+	d := registryFromArgs(cmd, args, 1)
+
+	f, err := os.Open(path)
+	cmdx.Must(err, "Unable to open the backup file: %s", err)
+	defer f.Close()
+
+	header := make([]byte, len(sqliteFileHeader))
+	_, _ = io.ReadFull(f, header)
+	_, err = f.Seek(0, io.SeekStart)
+	cmdx.Must(err, "Unable to read the backup file: %s", err)
+
+	if bytes.Equal(header, sqliteFileHeader) {
+		restoreSQLite(cmd, d, f)
+		return
+	}
+
+	imported, skipped, err := importIdentities(cmd.Context(), d, f)
+	cmdx.Must(err, "An error occurred while importing identities: %s", err)
+	fmt.Printf("Successfully imported %d identities, skipped %d identities which exist already!\n", imported, skipped)
+}
+
+func importIdentities(ctx context.Context, d driver.Registry, r io.Reader) (imported, skipped int, err error) {
+	dec := json.NewDecoder(r)
+	for {
+		var ib identityExport
+		if err := dec.Decode(&ib); errors.Is(err, io.EOF) {
+			return imported, skipped, nil
+		} else if err != nil {
+			return imported, skipped, errors.WithStack(err)
+		} else if ib.Identity == nil {
+			return imported, skipped, errors.New("the backup contains an empty identity")
+		}
+
+		ib.Identity.Credentials = ib.Credentials
+		if err := d.Persister().CreateIdentity(ctx, ib.Identity); errors.Is(err, sqlcon.ErrUniqueViolation) {
+			skipped++
+			continue
+		} else if err != nil {
+			return imported, skipped, errors.Wrapf(err, "unable to import identity %s", ib.Identity.ID)
+		}
+		imported++
+	}
+}
+
+func restoreSQLite(cmd *cobra.Command, d driver.Registry, snapshot io.Reader) {
+	if !isSQLite(cmd.Context(), d) {
+		cmdx.Fatalf("The backup file is a SQLite snapshot which can only be restored into a SQLite database.")
+	}
+
+	dsn := d.Config(cmd.Context()).DSN()
+	path := dsn[strings.Index(dsn, "://")+3:]
+	if i := strings.Index(path, "?"); i >= 0 {
+		path = path[:i]
+	}
+	if len(path) == 0 || strings.Contains(path, ":memory:") || dsn == "memory" {
+		cmdx.Fatalf("Unable to restore a snapshot into an in-memory SQLite database.")
+	}
+
+	if !flagx.MustGetBool(cmd, "yes") {
+		fmt.Println("Restoring a snapshot replaces all data. ORY Kratos must not be running while the snapshot is restored.")
+		fmt.Println("To skip the next question use flag --yes (at your own risk).")
+		if !askForConfirmation(fmt.Sprintf("Do you wish to replace the database at %s?", path)) {
+			fmt.Println("Restore aborted.")
+			return
+		}
+	}
+
+	err := d.Persister().Close(cmd.Context())
+	cmdx.Must(err, "Unable to close the database: %s", err)
+
+	for _, suffix := range []string{"-wal", "-shm"} {
+		if err := os.Remove(path + suffix); err != nil && !os.IsNotExist(err) {
+			cmdx.Must(err, "Unable to remove the write-ahead log: %s", err)
+		}
+	}
+
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0600)
+	cmdx.Must(err, "Unable to open the database file: %s", err)
+	defer f.Close()
+
+	_, err = io.Copy(f, snapshot)
+	cmdx.Must(err, "Unable to write the database file: %s", err)
+	fmt.Printf("Successfully restored the snapshot to %s!\n", path)
+}
@@ -5,6 +5,7 @@ import (
 	"bytes"
 	"fmt"
 	"os"
+	"strconv"
 	"strings"
 
 	"github.com/ory/x/configx"
@@ -13,6 +14,7 @@ import (
 
 	"github.com/ory/kratos/driver"
 	"github.com/ory/kratos/driver/config"
+	"github.com/ory/kratos/persistence"
 	"github.com/ory/x/cmdx"
 	"github.com/ory/x/flagx"
 )
@@ -23,11 +25,11 @@ func NewMigrateHandler() *MigrateHandler {
 	return &MigrateHandler{}
 }
 
-func (h *MigrateHandler) MigrateSQL(cmd *cobra.Command, args []string) {
-	var d driver.Registry
-
+// registryFromArgs returns a registry connected to the database URL given as the last argument at position
+// dsnArg or, if flag -e is set, read from the environment.
+func registryFromArgs(cmd *cobra.Command, args []string, dsnArg int) driver.Registry {
 	if flagx.MustGetBool(cmd, "read-from-env") {
-		d = driver.New(
+		d := driver.New(
 			cmd.Context(),
 			configx.WithFlags(cmd.Flags()),
 			configx.SkipValidation())
@@ -36,23 +38,47 @@ func (h *MigrateHandler) MigrateSQL(cmd *cobra.Command, args []string) {
 			fmt.Println("")
 			fmt.Println("When using flag -e, environment variable DSN must be set")
 			os.Exit(1)
-			return
-		}
-	} else {
-		if len(args) != 1 {
-			fmt.Println(cmd.UsageString())
-			os.Exit(1)
-			return
 		}
-		d = driver.New(
-			cmd.Context(),
-			configx.WithFlags(cmd.Flags()),
-			configx.SkipValidation(),
-			configx.WithValue(config.ViperKeyDSN, args[0]))
+		return d
+	}
+
+	if len(args) != dsnArg+1 {
+		fmt.Println(cmd.UsageString())
+		os.Exit(1)
+	}
+	return driver.New(
+		cmd.Context(),
+		configx.WithFlags(cmd.Flags()),
+		configx.SkipValidation(),
+		configx.WithValue(config.ViperKeyDSN, args[dsnArg]))
+}
+
+func migrationPhase(cmd *cobra.Command) persistence.MigrationPhase {
+	expand, contract := flagx.MustGetBool(cmd, "expand"), flagx.MustGetBool(cmd, "contract")
+	switch {
+	case expand && contract:
+		cmdx.Fatalf("Flags --expand and --contract are mutually exclusive.")
+	case expand:
+		return persistence.MigrationPhaseExpand
+	case contract:
+		return persistence.MigrationPhaseContract
+	}
+	return persistence.MigrationPhaseAll
+}
+
+func (h *MigrateHandler) MigrateSQL(cmd *cobra.Command, args []string) {
+	phase := migrationPhase(cmd)
+	d := registryFromArgs(cmd, args, 0)
+
+	if flagx.MustGetBool(cmd, "plan") {
+		plan, err := d.Persister().MigrationPlanUp(cmd.Context(), phase)
+		cmdx.Must(err, "An error occurred planning migrations: %s", err)
+		printMigrationPlan(plan)
+		return
 	}
 
 	var plan bytes.Buffer
-	statuses, err := d.Persister().MigrationStatus(cmd.Context())
+	statuses, err := d.Persister().MigrationStatusPhase(cmd.Context(), phase)
 	cmdx.Must(err, "An error occurred planning migrations: %s", err)
 	cmdx.Must(err, "An error occurred planning migrations: %s", statuses.Write(&plan))
 
@@ -69,11 +95,79 @@ func (h *MigrateHandler) MigrateSQL(cmd *cobra.Command, args []string) {
 		}
 	}
 
-	err = d.Persister().MigrateUp(cmd.Context())
+	err = d.Persister().MigrateUpPhase(cmd.Context(), phase)
 	cmdx.Must(err, "An error occurred while connecting to SQL: %s", err)
 	fmt.Println("Successfully applied SQL migrations!")
 }
 
+func (h *MigrateHandler) MigrateSQLStatus(cmd *cobra.Command, args []string) {
+	d := registryFromArgs(cmd, args, 0)
+
+	statuses, err := d.Persister().MigrationStatus(cmd.Context())
+	cmdx.Must(err, "An error occurred reading the migration status: %s", err)
+	cmdx.Must(err, "An error occurred reading the migration status: %s", statuses.Write(os.Stdout))
+
+	if statuses.HasPending() {
+		fmt.Println("")
+		fmt.Println("There are pending migrations. Apply them using: kratos migrate sql")
+	}
+}
+
+func (h *MigrateHandler) MigrateSQLDown(cmd *cobra.Command, args []string) {
+	if len(args) == 0 {
+		fmt.Println(cmd.UsageString())
+		os.Exit(1)
+	}
+
+	steps, err := strconv.Atoi(args[0])
+	cmdx.Must(err, `Could not parse the number of steps "%s": %s`, args[0], err)
+	if steps < 1 {
+		cmdx.Fatalf("The number of steps must be greater than zero but got %d.", steps)
+	}
+
+	d := registryFromArgs(cmd, args, 1)
+
+	plan, err := d.Persister().MigrationPlanDown(cmd.Context(), steps)
+	cmdx.Must(err, "An error occurred planning migrations: %s", err)
+
+	if flagx.MustGetBool(cmd, "plan") {
+		printMigrationPlan(plan)
+		return
+	}
+
+	fmt.Println("The following migrations will be rolled back:")
+	fmt.Println("")
+	for _, m := range plan {
+		fmt.Printf("%s_%s\n", m.Version, m.Name)
+	}
+
+	if !flagx.MustGetBool(cmd, "yes") {
+		fmt.Println("")
+		fmt.Println("To skip the next question use flag --yes (at your own risk).")
+		if !askForConfirmation("Do you wish to roll back these migrations? Data stored by them will be lost.") {
+			fmt.Println("Migration aborted.")
+			return
+		}
+	}
+
+	err = d.Persister().MigrateDown(cmd.Context(), steps)
+	cmdx.Must(err, "An error occurred while rolling back SQL migrations: %s", err)
+	fmt.Printf("Successfully rolled back %d SQL migrations!\n", len(plan))
+}
+
+func printMigrationPlan(plan []persistence.PlannedMigration) {
+	if len(plan) == 0 {
+		fmt.Println("-- No migrations to execute.")
+		return
+	}
+
+	for _, m := range plan {
+		fmt.Printf("-- Migration %s_%s (%s)\n", m.Version, m.Name, m.Direction)
+		fmt.Println(strings.TrimSpace(m.SQL))
+		fmt.Println("")
+	}
+}
+
 func askForConfirmation(s string) bool {
 	reader := bufio.NewReader(os.Stdin)
 
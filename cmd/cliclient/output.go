@@ -0,0 +1,50 @@
+package cliclient
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/ghodss/yaml"
+	"github.com/spf13/cobra"
+
+	"github.com/ory/x/cmdx"
+)
+
+// FormatYAML prints the output as YAML. All other formats are handled by cmdx.
+const FormatYAML = "yaml"
+
+// PrintRow prints a single row in the format set by the --format flag.
+func PrintRow(cmd *cobra.Command, row cmdx.TableRow) {
+	if isYAML(cmd) {
+		printYAML(cmd, row.Interface())
+		return
+	}
+	cmdx.PrintRow(cmd, row)
+}
+
+// PrintTable prints a table in the format set by the --format flag.
+func PrintTable(cmd *cobra.Command, table cmdx.Table) {
+	if isYAML(cmd) {
+		printYAML(cmd, table.Interface())
+		return
+	}
+	cmdx.PrintTable(cmd, table)
+}
+
+func isYAML(cmd *cobra.Command) bool {
+	if quiet, _ := cmd.Flags().GetBool(cmdx.FlagQuiet); quiet {
+		return false
+	}
+	f, _ := cmd.Flags().GetString(cmdx.FlagFormat)
+	return f == FormatYAML
+}
+
+func printYAML(cmd *cobra.Command, v interface{}) {
+	raw, err := json.Marshal(v)
+	cmdx.Must(err, "Error encoding output: %s", err)
+
+	out, err := yaml.JSONToYAML(raw)
+	cmdx.Must(err, "Error encoding output: %s", err)
+
+	_, _ = fmt.Fprint(cmd.OutOrStdout(), string(out))
+}
@@ -9,6 +9,7 @@ import (
 	"github.com/urfave/negroni"
 
 	"github.com/ory/graceful"
+	"github.com/ory/kratos/cmd/worker"
 	"github.com/ory/kratos/driver"
 	"github.com/ory/kratos/x"
 	"github.com/ory/x/configx"
@@ -83,19 +84,5 @@ func ServeMetrics(ctx cx.Context, r driver.Registry) {
 }
 
 func Watch(ctx cx.Context, r driver.Registry) {
-
-	ctx, cancel := cx.WithCancel(ctx)
-
-	r.Logger().Println("Courier worker started.")
-	if err := graceful.Graceful(func() error {
-		return r.Courier(ctx).Work(ctx)
-	}, func(_ cx.Context) error {
-		cancel()
-		return nil
-	}); err != nil {
-		r.Logger().WithError(err).Fatalf("Failed to run courier worker.")
-	}
-
-	r.Logger().Println("Courier worker was shutdown gracefully.")
-
+	worker.Watch(ctx, r, "courier worker", r.Courier(ctx).Work)
 }
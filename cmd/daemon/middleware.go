@@ -1,7 +1,9 @@
 package daemon
 
 import (
+	"math/rand"
 	"net/http"
+	"strings"
 	"time"
 
 	"github.com/sirupsen/logrus"
@@ -11,6 +13,9 @@ import (
 
 	"github.com/ory/x/healthx"
 	"github.com/ory/x/reqlog"
+
+	"github.com/ory/kratos/driver/config"
+	"github.com/ory/kratos/x"
 )
 
 func NewNegroniLoggerMiddleware(l *logrusx.Logger, name string) *reqlog.Middleware {
@@ -34,3 +39,72 @@ func NewNegroniLoggerMiddleware(l *logrusx.Logger, name string) *reqlog.Middlewa
 	}
 	return n
 }
+
+// NewTracingMiddleware traces requests using the tracer. Requests to endpoints with a sampling override are
+// only passed to the tracer at the override's rate, all other requests are always passed to the tracer.
+func NewTracingMiddleware(tracer negroni.Handler, overrides []config.TracingSamplingOverride) negroni.Handler {
+	if len(overrides) == 0 {
+		return tracer
+	}
+
+	return negroni.HandlerFunc(func(rw http.ResponseWriter, r *http.Request, next http.HandlerFunc) {
+		if o, ok := samplingOverride(overrides, r.URL.Path); ok && rand.Float64() >= o.Rate {
+			next(rw, r)
+			return
+		}
+		tracer.ServeHTTP(rw, r, next)
+	})
+}
+
+// samplingOverride returns the first override matching the path. Override paths ending with "*" match all
+// paths with the given prefix.
+func samplingOverride(overrides []config.TracingSamplingOverride, path string) (config.TracingSamplingOverride, bool) {
+	for _, o := range overrides {
+		if prefix := strings.TrimSuffix(o.Path, "*"); prefix != o.Path {
+			if strings.HasPrefix(path, prefix) {
+				return o, true
+			}
+		} else if o.Path == path {
+			return o, true
+		}
+	}
+	return config.TracingSamplingOverride{}, false
+}
+
+// NewAdminAuditMiddleware writes every call to the admin API which may change data, that is every call which is not
+// a GET, HEAD, or OPTIONS request, to the audit log together with the principal attached to the request's context
+// using x.WithAdminPrincipal. It must be used after the middleware which authenticates the principal.
+func NewAdminAuditMiddleware(audit *logrusx.Logger) negroni.Handler {
+	return negroni.HandlerFunc(func(rw http.ResponseWriter, r *http.Request, next http.HandlerFunc) {
+		switch r.Method {
+		case http.MethodGet, http.MethodHead, http.MethodOptions:
+			next(rw, r)
+			return
+		}
+
+		res, ok := rw.(negroni.ResponseWriter)
+		if !ok {
+			res = negroni.NewResponseWriter(rw)
+		}
+
+		next(res, r)
+
+		principal, _ := x.AdminPrincipalFromContext(r.Context())
+		audit.
+			WithRequest(r).
+			WithField("api_key_id", principal.APIKeyID).
+			WithField("subject", principal.Subject).
+			WithField("status", res.Status()).
+			Info("An admin API call was made.")
+	})
+}
+
+// NewConfigSnapshotMiddleware attaches a snapshot of the configuration to the request's context, so that all
+// configuration values read while handling the request are consistent even if the configuration is reloaded. The
+// request is attached to the context as well, so that a config.ContextResolver can derive overrides from it.
+func NewConfigSnapshotMiddleware(p config.Provider) negroni.Handler {
+	return negroni.HandlerFunc(func(rw http.ResponseWriter, r *http.Request, next http.HandlerFunc) {
+		ctx := config.ContextWithRequest(r.Context(), r)
+		next(rw, r.WithContext(config.ContextWithSnapshot(ctx, p.Config(ctx).Snapshot(ctx))))
+	})
+}
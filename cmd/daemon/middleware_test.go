@@ -0,0 +1,74 @@
+package daemon
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/sirupsen/logrus/hooks/test"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/urfave/negroni"
+
+	"github.com/ory/x/logrusx"
+
+	"github.com/ory/kratos/driver/config"
+	"github.com/ory/kratos/x"
+)
+
+func TestNewTracingMiddleware(t *testing.T) {
+	var traced int
+	tracer := negroni.HandlerFunc(func(rw http.ResponseWriter, r *http.Request, next http.HandlerFunc) {
+		traced++
+		next(rw, r)
+	})
+
+	mw := NewTracingMiddleware(tracer, []config.TracingSamplingOverride{
+		{Path: "/sessions/whoami", Rate: 0},
+		{Path: "/self-service/*", Rate: 1},
+	})
+
+	for _, tc := range []struct {
+		path   string
+		traced int
+	}{
+		{path: "/sessions/whoami", traced: 0},
+		{path: "/self-service/login", traced: 1},
+		{path: "/health/alive", traced: 1},
+	} {
+		t.Run("path="+tc.path, func(t *testing.T) {
+			traced = 0
+			var called bool
+			mw.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", tc.path, nil), func(http.ResponseWriter, *http.Request) {
+				called = true
+			})
+			assert.True(t, called)
+			assert.Equal(t, tc.traced, traced)
+		})
+	}
+}
+
+func TestNewAdminAuditMiddleware(t *testing.T) {
+	l := logrusx.New("", "")
+	hook := new(test.Hook)
+	l.Logger.Hooks.Add(hook)
+
+	n := negroni.New()
+	n.UseFunc(func(rw http.ResponseWriter, r *http.Request, next http.HandlerFunc) {
+		next(rw, r.WithContext(x.WithAdminPrincipal(r.Context(), x.AdminPrincipal{APIKeyID: "1234", Subject: "alice"})))
+	})
+	n.Use(NewAdminAuditMiddleware(l))
+	n.UseHandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		rw.WriteHeader(http.StatusNoContent)
+	})
+
+	n.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/identities", nil))
+	assert.Empty(t, hook.AllEntries())
+
+	n.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("DELETE", "/identities/1", nil))
+	require.Len(t, hook.AllEntries(), 1)
+	entry := hook.LastEntry()
+	assert.Equal(t, "1234", entry.Data["api_key_id"])
+	assert.Equal(t, "alice", entry.Data["subject"])
+	assert.Equal(t, http.StatusNoContent, entry.Data["status"])
+}
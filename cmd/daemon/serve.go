@@ -1,23 +1,19 @@
 package daemon
 
 import (
+	"crypto/tls"
 	"net/http"
-	"strings"
 	"sync"
 
+	"github.com/ory/x/logrusx"
 	"github.com/ory/x/reqlog"
 
 	"github.com/ory/kratos/cmd/courier"
-	"github.com/ory/kratos/driver/config"
+	"github.com/ory/kratos/cmd/dormancy"
+	"github.com/ory/kratos/cmd/reminders"
 
 	"github.com/rs/cors"
 
-	"github.com/ory/kratos/metrics/prometheus"
-
-	"github.com/ory/analytics-go/v4"
-
-	"github.com/ory/x/healthx"
-
 	"github.com/gorilla/context"
 	"github.com/spf13/cobra"
 	"github.com/urfave/negroni"
@@ -26,18 +22,7 @@ import (
 	"github.com/ory/x/metricsx"
 
 	"github.com/ory/kratos/driver"
-	"github.com/ory/kratos/identity"
-	"github.com/ory/kratos/selfservice/errorx"
-	"github.com/ory/kratos/selfservice/flow/login"
-	"github.com/ory/kratos/selfservice/flow/logout"
-	"github.com/ory/kratos/selfservice/flow/registration"
-	"github.com/ory/kratos/selfservice/flow/settings"
-	"github.com/ory/kratos/selfservice/flow/verification"
-	"github.com/ory/kratos/selfservice/strategy/link"
-	"github.com/ory/kratos/selfservice/strategy/oidc"
-	"github.com/ory/kratos/selfservice/strategy/password"
-	"github.com/ory/kratos/selfservice/strategy/profile"
-	"github.com/ory/kratos/session"
+	"github.com/ory/kratos/driver/config"
 	"github.com/ory/kratos/x"
 )
 
@@ -73,11 +58,14 @@ func ServePublic(r driver.Registry, wg *sync.WaitGroup, cmd *cobra.Command, args
 	for _, mw := range modifiers.mwf {
 		n.UseFunc(mw)
 	}
+	n.Use(NewConfigSnapshotMiddleware(r))
+	n.UseFunc(x.StripBasePath(c.PublicBasePath()))
 
 	router := x.NewRouterPublic()
 	csrf := x.NewCSRFHandler(router, r)
 
 	n.UseFunc(x.CleanPath) // Prevent double slashes from breaking CSRF.
+	n.Use(r.MaintenanceHandler())
 	r.WithCSRFHandler(csrf)
 	n.UseHandler(r.CSRFHandler())
 
@@ -87,7 +75,7 @@ func ServePublic(r driver.Registry, wg *sync.WaitGroup, cmd *cobra.Command, args
 	n.Use(r.PrometheusManager())
 
 	if tracer := r.Tracer(ctx); tracer.IsLoaded() {
-		n.Use(tracer)
+		n.Use(NewTracingMiddleware(tracer, c.TracingSamplingOverrides()))
 	}
 
 	var handler http.Handler = n
@@ -97,12 +85,12 @@ func ServePublic(r driver.Registry, wg *sync.WaitGroup, cmd *cobra.Command, args
 	}
 
 	server := graceful.WithDefaults(&http.Server{
-		Addr:    c.PublicListenOn(),
+		Addr:    c.PublicListenOn().String(),
 		Handler: context.ClearHandler(handler),
 	})
 
 	l.Printf("Starting the public httpd on: %s", server.Addr)
-	if err := graceful.Graceful(server.ListenAndServe, server.Shutdown); err != nil {
+	if err := graceful.Graceful(listenAndServe(server, c.PublicListenOn(), c.TLS("public"), l), server.Shutdown); err != nil {
 		l.Fatalln("Failed to gracefully shutdown public httpd")
 	}
 	l.Println("Public httpd was shutdown gracefully")
@@ -119,97 +107,74 @@ func ServeAdmin(r driver.Registry, wg *sync.WaitGroup, cmd *cobra.Command, args
 	for _, mw := range modifiers.mwf {
 		n.UseFunc(mw)
 	}
+	n.Use(NewConfigSnapshotMiddleware(r))
+	n.UseFunc(x.StripBasePath(c.AdminBasePath()))
 
 	router := x.NewRouterAdmin()
 	r.RegisterAdminRoutes(ctx, router)
+	go r.MetricsGaugeRefresher().Watch(ctx)
 	n.Use(reqlog.NewMiddlewareFromLogger(l, "admin#"+c.SelfPublicURL(nil).String()))
+	n.Use(NewAdminAuditMiddleware(r.Audit()))
 	n.Use(sqa(cmd, r))
 	n.Use(r.PrometheusManager())
 
 	if tracer := r.Tracer(ctx); tracer.IsLoaded() {
-		n.Use(tracer)
+		n.Use(NewTracingMiddleware(tracer, c.TracingSamplingOverrides()))
 	}
 
 	n.UseHandler(router)
 	server := graceful.WithDefaults(&http.Server{
-		Addr:    c.AdminListenOn(),
+		Addr:    c.AdminListenOn().String(),
 		Handler: context.ClearHandler(n),
 	})
 
 	l.Printf("Starting the admin httpd on: %s", server.Addr)
-	if err := graceful.Graceful(server.ListenAndServe, server.Shutdown); err != nil {
+	if err := graceful.Graceful(listenAndServe(server, c.AdminListenOn(), c.TLS("admin"), l), server.Shutdown); err != nil {
 		l.Fatalln("Failed to gracefully shutdown admin httpd")
 	}
 	l.Println("Admin httpd was shutdown gracefully")
 }
 
-func sqa(cmd *cobra.Command, d driver.Registry) *metricsx.Service {
+// listenAndServe listens on the TCP address or UNIX domain socket and serves HTTPS if a TLS certificate is
+// configured. The certificate is reloaded when its files change.
+func listenAndServe(server *http.Server, listen config.Listener, c config.TLS, l *logrusx.Logger) func() error {
+	listener, err := x.Listen(listen)
+	if err != nil {
+		l.WithError(err).Fatalf("Unable to listen on %s.", listen)
+	}
+
+	if !c.Enabled() {
+		return func() error {
+			return server.Serve(listener)
+		}
+	}
+
+	reloader, err := x.NewCertificateReloader(c, l)
+	if err != nil {
+		l.WithError(err).Fatal("Unable to load the TLS certificate.")
+	}
+
+	server.TLSConfig = &tls.Config{GetCertificate: reloader.GetCertificate}
+	return func() error {
+		return server.ServeTLS(listener, "", "")
+	}
+}
+
+func sqa(cmd *cobra.Command, d driver.Registry) negroni.Handler {
+	c := d.Config(cmd.Context())
+	if c.IsTelemetryOffline() {
+		return negroni.HandlerFunc(func(rw http.ResponseWriter, r *http.Request, next http.HandlerFunc) {
+			next(rw, r)
+		})
+	}
+
 	// Creates only ones
 	// instance
 	return metricsx.New(
 		cmd,
 		d.Logger(),
-		d.Config(cmd.Context()).Source(),
-		&metricsx.Options{
-			Service: "ory-kratos",
-			ClusterID: metricsx.Hash(
-				strings.Join([]string{
-					d.Config(cmd.Context()).DSN(),
-					d.Config(cmd.Context()).SelfPublicURL(nil).String(),
-					d.Config(cmd.Context()).SelfAdminURL().String(),
-				}, "|"),
-			),
-			IsDevelopment: d.Config(cmd.Context()).IsInsecureDevMode(),
-			WriteKey:      "qQlI6q8Q4WvkzTjKQSor4sHYOikHIvvi",
-			WhitelistedPaths: []string{
-				"/",
-				healthx.AliveCheckPath,
-				healthx.ReadyCheckPath,
-				healthx.VersionPath,
-
-				password.RouteRegistration,
-				password.RouteLogin,
-				password.RouteSettings,
-
-				oidc.RouteBase,
-
-				login.RouteInitBrowserFlow,
-				login.RouteInitAPIFlow,
-				login.RouteGetFlow,
-
-				logout.RouteBrowser,
-
-				registration.RouteInitBrowserFlow,
-				registration.RouteInitAPIFlow,
-				registration.RouteGetFlow,
-
-				session.RouteWhoami,
-				identity.RouteBase,
-
-				settings.RouteInitBrowserFlow,
-				settings.RouteInitAPIFlow,
-				settings.RouteGetFlow,
-
-				verification.RouteInitAPIFlow,
-				verification.RouteInitBrowserFlow,
-				verification.RouteGetFlow,
-
-				profile.RouteSettings,
-
-				link.RouteAdminCreateRecoveryLink,
-				link.RouteRecovery,
-				link.RouteVerification,
-
-				errorx.RouteGet,
-				prometheus.MetricsPrometheusPath,
-			},
-			BuildVersion: config.Version,
-			BuildHash:    config.Commit,
-			BuildTime:    config.Date,
-			Config: &analytics.Config{
-				Endpoint: "https://sqa.ory.sh",
-			},
-		},
+		c.Source(),
+		NewTelemetryReport(c).options(c),
 	)
 }
 
@@ -219,6 +184,14 @@ func bgTasks(d driver.Registry, wg *sync.WaitGroup, cmd *cobra.Command, args []s
 	if d.Config(cmd.Context()).IsBackgroundCourierEnabled() {
 		go courier.Watch(cmd.Context(), d)
 	}
+
+	if d.Config(cmd.Context()).IsBackgroundDormancyEnabled() {
+		go dormancy.Watch(cmd.Context(), d)
+	}
+
+	if d.Config(cmd.Context()).IsBackgroundVerificationRemindersEnabled() {
+		go reminders.Watch(cmd.Context(), d)
+	}
 }
 
 func ServeAll(d driver.Registry, opts ...Option) func(cmd *cobra.Command, args []string) {
@@ -0,0 +1,163 @@
+package daemon
+
+import (
+	"strings"
+
+	"github.com/ory/analytics-go/v4"
+	"github.com/ory/x/healthx"
+	"github.com/ory/x/metricsx"
+
+	"github.com/ory/kratos/driver/config"
+	"github.com/ory/kratos/identity"
+	"github.com/ory/kratos/metrics/prometheus"
+	"github.com/ory/kratos/selfservice/errorx"
+	"github.com/ory/kratos/selfservice/flow/login"
+	"github.com/ory/kratos/selfservice/flow/logout"
+	"github.com/ory/kratos/selfservice/flow/registration"
+	"github.com/ory/kratos/selfservice/flow/settings"
+	"github.com/ory/kratos/selfservice/flow/verification"
+	"github.com/ory/kratos/selfservice/strategy/link"
+	"github.com/ory/kratos/selfservice/strategy/oidc"
+	"github.com/ory/kratos/selfservice/strategy/password"
+	"github.com/ory/kratos/selfservice/strategy/profile"
+	"github.com/ory/kratos/session"
+)
+
+const (
+	telemetryService  = "ory-kratos"
+	telemetryEndpoint = "https://sqa.ory.sh"
+	telemetryWriteKey = "qQlI6q8Q4WvkzTjKQSor4sHYOikHIvvi"
+)
+
+// telemetryPaths are the paths reported per telemetry category. Requests to other paths are reported
+// without their path.
+var telemetryPaths = map[string][]string{
+	config.TelemetryCategoryHealth: {
+		"/",
+		healthx.AliveCheckPath,
+		healthx.ReadyCheckPath,
+		healthx.VersionPath,
+	},
+	config.TelemetryCategorySelfService: {
+		password.RouteRegistration,
+		password.RouteLogin,
+		password.RouteSettings,
+
+		oidc.RouteBase,
+
+		login.RouteInitBrowserFlow,
+		login.RouteInitAPIFlow,
+		login.RouteGetFlow,
+
+		logout.RouteBrowser,
+
+		registration.RouteInitBrowserFlow,
+		registration.RouteInitAPIFlow,
+		registration.RouteGetFlow,
+
+		settings.RouteInitBrowserFlow,
+		settings.RouteInitAPIFlow,
+		settings.RouteGetFlow,
+
+		verification.RouteInitAPIFlow,
+		verification.RouteInitBrowserFlow,
+		verification.RouteGetFlow,
+
+		profile.RouteSettings,
+
+		link.RouteRecovery,
+		link.RouteVerification,
+
+		errorx.RouteGet,
+	},
+	config.TelemetryCategoryIdentities: {
+		identity.RouteBase,
+		link.RouteAdminCreateRecoveryLink,
+	},
+	config.TelemetryCategorySessions: {
+		session.RouteWhoami,
+	},
+	config.TelemetryCategoryMetrics: {
+		prometheus.MetricsPrometheusPath,
+	},
+}
+
+// TelemetryReport describes the anonymized telemetry ORY Kratos reports with the given configuration.
+// Operators can use it to audit what would be sent, for example using `kratos telemetry`.
+type TelemetryReport struct {
+	// Enabled is false if telemetry was disabled using `--sqa-opt-out` or offline mode.
+	Enabled bool `json:"enabled"`
+
+	// Offline is true if telemetry offline mode is enabled.
+	Offline bool `json:"offline"`
+
+	// Endpoint is the URL reports are sent to.
+	Endpoint string `json:"endpoint"`
+
+	// Service is the name of the reporting service.
+	Service string `json:"service"`
+
+	// ClusterID is the hash identifying this deployment. It is derived from the DSN and the public
+	// and admin base URLs, which are never sent themselves.
+	ClusterID string `json:"cluster_id"`
+
+	// BuildVersion, BuildHash, and BuildTime describe the running ORY Kratos build.
+	BuildVersion string `json:"build_version"`
+	BuildHash    string `json:"build_hash"`
+	BuildTime    string `json:"build_time"`
+
+	// Categories lists whether each event category is reported.
+	Categories map[string]bool `json:"categories"`
+
+	// ReportedPaths are the request paths which are reported. Requests to other paths are reported
+	// without their path.
+	ReportedPaths []string `json:"reported_paths"`
+}
+
+// NewTelemetryReport returns the telemetry ORY Kratos reports with the given configuration.
+func NewTelemetryReport(c *config.Config) *TelemetryReport {
+	r := &TelemetryReport{
+		Enabled:  !c.IsTelemetryOptedOut() && !c.IsTelemetryOffline(),
+		Offline:  c.IsTelemetryOffline(),
+		Endpoint: telemetryEndpoint,
+		Service:  telemetryService,
+		ClusterID: metricsx.Hash(
+			strings.Join([]string{
+				c.DSN(),
+				c.SelfPublicURL(nil).String(),
+				c.SelfAdminURL().String(),
+			}, "|"),
+		),
+		BuildVersion:  config.Version,
+		BuildHash:     config.Commit,
+		BuildTime:     config.Date,
+		Categories:    map[string]bool{},
+		ReportedPaths: []string{},
+	}
+
+	for _, category := range config.TelemetryCategories() {
+		enabled := c.IsTelemetryCategoryEnabled(category)
+		r.Categories[category] = enabled
+		if enabled {
+			r.ReportedPaths = append(r.ReportedPaths, telemetryPaths[category]...)
+		}
+	}
+
+	return r
+}
+
+func (r *TelemetryReport) options(c *config.Config) *metricsx.Options {
+	return &metricsx.Options{
+		Service:          r.Service,
+		ClusterID:        r.ClusterID,
+		IsDevelopment:    c.IsInsecureDevMode(),
+		WriteKey:         telemetryWriteKey,
+		WhitelistedPaths: r.ReportedPaths,
+		BuildVersion:     r.BuildVersion,
+		BuildHash:        r.BuildHash,
+		BuildTime:        r.BuildTime,
+		Config: &analytics.Config{
+			Endpoint: r.Endpoint,
+		},
+	}
+}
@@ -0,0 +1,51 @@
+package daemon
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/ory/x/healthx"
+
+	"github.com/ory/kratos/driver/config"
+	"github.com/ory/kratos/internal"
+	"github.com/ory/kratos/session"
+)
+
+func TestNewTelemetryReport(t *testing.T) {
+	conf, _ := internal.NewFastRegistryWithMocks(t)
+
+	t.Run("case=all categories are reported by default", func(t *testing.T) {
+		r := NewTelemetryReport(conf)
+		assert.True(t, r.Enabled)
+		assert.False(t, r.Offline)
+		for _, category := range config.TelemetryCategories() {
+			assert.True(t, r.Categories[category], category)
+		}
+		assert.Contains(t, r.ReportedPaths, session.RouteWhoami)
+		assert.Contains(t, r.ReportedPaths, healthx.AliveCheckPath)
+	})
+
+	t.Run("case=disabled categories are not reported", func(t *testing.T) {
+		conf.MustSet(config.ViperKeyTelemetryCategories+"."+config.TelemetryCategorySessions, false)
+		t.Cleanup(func() {
+			conf.MustSet(config.ViperKeyTelemetryCategories+"."+config.TelemetryCategorySessions, true)
+		})
+
+		r := NewTelemetryReport(conf)
+		assert.False(t, r.Categories[config.TelemetryCategorySessions])
+		assert.NotContains(t, r.ReportedPaths, session.RouteWhoami)
+		assert.Contains(t, r.ReportedPaths, healthx.AliveCheckPath)
+	})
+
+	t.Run("case=offline mode disables telemetry", func(t *testing.T) {
+		conf.MustSet(config.ViperKeyTelemetryOffline, true)
+		t.Cleanup(func() {
+			conf.MustSet(config.ViperKeyTelemetryOffline, false)
+		})
+
+		r := NewTelemetryReport(conf)
+		assert.False(t, r.Enabled)
+		assert.True(t, r.Offline)
+	})
+}
@@ -0,0 +1,16 @@
+package dormancy
+
+import (
+	"github.com/spf13/cobra"
+
+	"github.com/ory/kratos/cmd/worker"
+)
+
+func RegisterCommandRecursive(parent *cobra.Command) {
+	parent.AddCommand(worker.NewCommand(
+		"dormancy",
+		"Commands related to the ORY Kratos dormant account policy",
+		"Enforces the ORY Kratos dormant account policy in the configured interval",
+		Watch,
+	))
+}
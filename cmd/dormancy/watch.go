@@ -0,0 +1,13 @@
+package dormancy
+
+import (
+	cx "context"
+
+	"github.com/ory/kratos/cmd/worker"
+	"github.com/ory/kratos/driver"
+)
+
+// Watch enforces the dormancy policy until the process is shut down.
+func Watch(ctx cx.Context, r driver.Registry) {
+	worker.Watch(ctx, r, "dormancy worker", r.IdentityDormancyEnforcer().Watch)
+}
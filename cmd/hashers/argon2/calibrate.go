@@ -4,25 +4,40 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"io/ioutil"
 	"time"
 
 	"github.com/fatih/color"
+	"github.com/ghodss/yaml"
 	"github.com/inhies/go-bytesize"
 	"github.com/spf13/cobra"
 
 	"github.com/ory/kratos/driver/config"
 	"github.com/ory/kratos/hash"
 	"github.com/ory/x/cmdx"
+	"github.com/ory/x/configx"
+	"github.com/ory/x/logrusx"
 )
 
 type (
 	argon2Config struct {
-		c config.Argon2
+		c    config.Argon2
+		conf *config.Config
 	}
 )
 
+// Config returns the loaded configuration with the parameters currently being probed, which is how the hasher
+// picks them up.
 func (c *argon2Config) Config(_ context.Context) *config.Config {
-	panic("not supposed to be called")
+	c.conf.MustSet(config.ViperKeyHasherArgon2ConfigMemory, c.c.Memory)
+	c.conf.MustSet(config.ViperKeyHasherArgon2ConfigIterations, c.c.Iterations)
+	c.conf.MustSet(config.ViperKeyHasherArgon2ConfigParallelism, c.c.Parallelism)
+	c.conf.MustSet(config.ViperKeyHasherArgon2ConfigSaltLength, c.c.SaltLength)
+	c.conf.MustSet(config.ViperKeyHasherArgon2ConfigKeyLength, c.c.KeyLength)
+	if c.c.Variant != "" {
+		c.conf.MustSet(config.ViperKeyHasherArgon2ConfigVariant, c.c.Variant)
+	}
+	return c.conf
 }
 
 func (c *argon2Config) HasherArgon2() *config.Argon2 {
@@ -41,6 +56,7 @@ const (
 	FlagParallelism     = "parallelism"
 	FlagSaltLength      = "salt-length"
 	FlagKeyLength       = "key-length"
+	FlagOutput          = "output"
 
 	FlagQuiet = "quiet"
 	FlagRuns  = "probe-runs"
@@ -53,6 +69,7 @@ func newCalibrateCmd() *cobra.Command {
 		maxMemory, adjustMemory, startMemory bytesize.ByteSize = 0, 1 * bytesize.GB, 4 * bytesize.GB
 		quiet                                bool
 		runs                                 int
+		output                               string
 	)
 
 	aconfig := &argon2Config{
@@ -61,18 +78,59 @@ func newCalibrateCmd() *cobra.Command {
 
 	cmd := &cobra.Command{
 		Use:   "calibrate [<desired-duration>]",
-		Args:  cobra.ExactArgs(1),
+		Args:  cobra.MaximumNArgs(1),
 		Short: "Computes Optimal Argon2 Parameters.",
 		Long: `This command helps you calibrate the configuration parameters for Argon2. Password hashing is a trade-off between security, resource consumption, and user experience. Resource consumption should not be too high and the login should not take too long.
 
 We recommend that the login process takes between half a second and one second for password hashing, giving a good balance between security and user experience.
 
+If the desired duration is omitted, "hashers.argon2.expected_duration" of the configuration is used instead, and the command fails if the result deviates from it by more than "hashers.argon2.expected_deviation". Unless the respective flags are set, the memory is limited to "hashers.argon2.dedicated_memory" if configured, and the parallelism, salt length, key length, and variant are taken from the configuration.
+
+The recommended parameters are printed as JSON. Use --output to write them to a configuration file instead:
+
+	kratos hashers argon2 calibrate -c path/to/kratos.yml -o path/to/argon2.yml
+	kratos serve -c path/to/kratos.yml -c path/to/argon2.yml
+
 Please note that the values depend on the machine you run the hashing on. If you have RAM constraints please choose lower memory targets to avoid out of memory panics.`,
 		RunE: func(cmd *cobra.Command, args []string) error {
-			desiredDuration, err := time.ParseDuration(args[0])
+			// The configuration is only used for the hashing parameters, so the rest of it does not need to be valid.
+			conf, err := config.New(logrusx.New("ORY Kratos", config.Version), configx.WithFlags(cmd.Flags()), configx.SkipValidation())
 			if err != nil {
-				return err
+				_, _ = fmt.Fprintf(cmd.ErrOrStderr(), "Unable to load the configuration: %s\n", err)
+				return cmdx.FailSilently(cmd)
+			}
+
+			configured, err := conf.HasherArgon2OrErr()
+			if err != nil {
+				_, _ = fmt.Fprintf(cmd.ErrOrStderr(), "Unable to load the Argon2 configuration: %s\n", err)
+				return cmdx.FailSilently(cmd)
+			}
+
+			desiredDuration := configured.ExpectedDuration
+			if len(args) > 0 {
+				desiredDuration, err = time.ParseDuration(args[0])
+				if err != nil {
+					return err
+				}
+			}
+
+			flags := cmd.Flags()
+			if !flags.Changed(FlagMaxMemory) && conf.Source().Exists(config.ViperKeyHasherArgon2ConfigDedicatedMemory) {
+				maxMemory = configured.DedicatedMemory
+			}
+			if !flags.Changed(FlagParallelism) {
+				aconfig.c.Parallelism = configured.Parallelism
+			}
+			if !flags.Changed(FlagSaltLength) {
+				aconfig.c.SaltLength = configured.SaltLength
+			}
+			if !flags.Changed(FlagKeyLength) {
+				aconfig.c.KeyLength = configured.KeyLength
 			}
+			if conf.Source().Exists(config.ViperKeyHasherArgon2ConfigVariant) {
+				aconfig.c.Variant = configured.Variant
+			}
+			aconfig.conf = conf
 
 			aconfig.c.Memory = toKB(startMemory)
 
@@ -193,12 +251,24 @@ Please note that the values depend on the machine you run the hashing on. If you
 				_, _ = resultColor.Fprintf(cmd.ErrOrStderr(), "Settled on %d iterations.\n\n", aconfig.c.Iterations)
 			}
 
+			if len(args) == 0 && !withinExpectedDeviation(configured, currentDuration) {
+				_, _ = fmt.Fprintf(cmd.ErrOrStderr(), "Hashing a password with %s of memory and %d iterations took %s, which is not within %s of the expected duration of %s. Adjust the expected duration, the expected deviation, or the dedicated memory.\n",
+					aconfig.getMemFormat(), aconfig.c.Iterations, currentDuration, configured.ExpectedDeviation, configured.ExpectedDuration)
+				return cmdx.FailSilently(cmd)
+			}
+
+			if len(output) > 0 {
+				return writeConfig(cmd, output, &aconfig.c, quiet)
+			}
+
 			e := json.NewEncoder(cmd.OutOrStdout())
 			e.SetIndent("", "  ")
 			return e.Encode(aconfig.c)
 		},
 	}
 
+	configx.RegisterFlags(cmd.PersistentFlags())
+
 	flags := cmd.Flags()
 
 	flags.BoolVarP(&quiet, FlagQuiet, "q", false, "Quiet output.")
@@ -215,9 +285,43 @@ Please note that the values depend on the machine you run the hashing on. If you
 	flags.Uint32Var(&aconfig.c.SaltLength, FlagSaltLength, config.Argon2DefaultSaltLength, "Length of the salt in bytes.")
 	flags.Uint32Var(&aconfig.c.KeyLength, FlagKeyLength, config.Argon2DefaultKeyLength, "Length of the key in bytes.")
 
+	flags.StringVarP(&output, FlagOutput, "o", "", "Write the recommended parameters to this configuration file instead of printing them.")
+
 	return cmd
 }
 
+// withinExpectedDeviation returns true if the duration deviates from the expected duration by no more than the
+// expected deviation.
+func withinExpectedDeviation(c *config.Argon2, d time.Duration) bool {
+	deviation := d - c.ExpectedDuration
+	if deviation < 0 {
+		deviation = -deviation
+	}
+	return deviation <= c.ExpectedDeviation
+}
+
+// writeConfig writes the parameters to a configuration file which can be passed to ORY Kratos in addition to the
+// main configuration file.
+func writeConfig(cmd *cobra.Command, path string, c *config.Argon2, quiet bool) error {
+	out, err := yaml.Marshal(map[string]interface{}{
+		"hashers": map[string]interface{}{
+			"argon2": c,
+		},
+	})
+	if err != nil {
+		return err
+	}
+
+	if err := ioutil.WriteFile(path, out, 0600); err != nil {
+		_, _ = fmt.Fprintf(cmd.ErrOrStderr(), "Unable to write the configuration to %s: %s\n", path, err)
+		return cmdx.FailSilently(cmd)
+	}
+	if !quiet {
+		_, _ = resultColor.Fprintf(cmd.ErrOrStderr(), "Wrote the recommended configuration to %s.\n", path)
+	}
+	return nil
+}
+
 func toKB(b bytesize.ByteSize) uint32 {
 	return uint32(b / bytesize.KB)
 }
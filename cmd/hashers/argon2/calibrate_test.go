@@ -0,0 +1,24 @@
+package argon2
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/ory/kratos/driver/config"
+)
+
+func TestWithinExpectedDeviation(t *testing.T) {
+	c := &config.Argon2{ExpectedDuration: 500 * time.Millisecond, ExpectedDeviation: 100 * time.Millisecond}
+
+	for d, expected := range map[time.Duration]bool{
+		350 * time.Millisecond: false,
+		400 * time.Millisecond: true,
+		500 * time.Millisecond: true,
+		600 * time.Millisecond: true,
+		650 * time.Millisecond: false,
+	} {
+		assert.Equal(t, expected, withinExpectedDeviation(c, d), "%s", d)
+	}
+}
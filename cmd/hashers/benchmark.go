@@ -0,0 +1,192 @@
+package hashers
+
+import (
+	"crypto/rand"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/fatih/color"
+	"github.com/ghodss/yaml"
+	"github.com/inhies/go-bytesize"
+	"github.com/spf13/cobra"
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/bcrypt"
+
+	"github.com/ory/kratos/driver/config"
+	"github.com/ory/x/cmdx"
+)
+
+const (
+	FlagTargetDuration = "target-duration"
+	FlagMemory         = "memory"
+	FlagIterations     = "iterations"
+	FlagParallelism    = "parallelism"
+	FlagBcryptCosts    = "bcrypt-costs"
+	FlagRuns           = "probe-runs"
+	FlagQuiet          = "quiet"
+)
+
+var resultColor = color.New(color.FgGreen)
+
+type argon2Result struct {
+	config   config.Argon2
+	duration time.Duration
+}
+
+func newBenchmarkCmd() *cobra.Command {
+	var (
+		target      time.Duration
+		memory      []string
+		iterations  []uint
+		parallelism uint8
+		bcryptCosts []int
+		runs        int
+		quiet       bool
+	)
+
+	cmd := &cobra.Command{
+		Use:   "benchmark",
+		Args:  cobra.NoArgs,
+		Short: "Benchmarks password hashing on this machine and recommends Argon2 parameters.",
+		Long: `This command measures how long hashing a password takes on this machine for every combination of the given Argon2 memory and iteration settings, as well as for the given bcrypt costs.
+
+It recommends the strongest Argon2 parameters which hash a password within the target duration and prints them as a configuration snippet. We recommend that hashing takes between half a second and one second.
+
+Please note that the values depend on the machine you run the benchmark on. Run it on the same hardware ORY Kratos is deployed to. bcrypt timings are printed for comparison only.`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			memories := make([]uint32, len(memory))
+			for k, m := range memory {
+				b, err := bytesize.Parse(m)
+				if err != nil {
+					_, _ = fmt.Fprintf(cmd.ErrOrStderr(), "Could not parse memory value %s: %s\n", m, err)
+					return cmdx.FailSilently(cmd)
+				}
+				memories[k] = uint32(b / bytesize.KB)
+			}
+
+			var results []argon2Result
+			for _, m := range memories {
+				for _, i := range iterations {
+					c := config.Argon2{
+						Memory:      m,
+						Iterations:  uint32(i),
+						Parallelism: parallelism,
+						SaltLength:  config.Argon2DefaultSaltLength,
+						KeyLength:   config.Argon2DefaultKeyLength,
+					}
+
+					d, err := probe(runs, func() error {
+						salt := make([]byte, c.SaltLength)
+						if _, err := rand.Read(salt); err != nil {
+							return err
+						}
+						_ = argon2.IDKey([]byte("password"), salt, c.Iterations, c.Memory, c.Parallelism, c.KeyLength)
+						return nil
+					})
+					if err != nil {
+						_, _ = fmt.Fprintf(cmd.ErrOrStderr(), "Could not generate a hash: %s\n", err)
+						return cmdx.FailSilently(cmd)
+					}
+
+					if !quiet {
+						_, _ = fmt.Fprintf(cmd.ErrOrStderr(), "argon2id: %s of memory and %d iterations took %s\n", bytesize.ByteSize(c.Memory)*bytesize.KB, c.Iterations, d)
+					}
+					results = append(results, argon2Result{config: c, duration: d})
+				}
+			}
+
+			for _, cost := range bcryptCosts {
+				d, err := probe(runs, func() error {
+					_, err := bcrypt.GenerateFromPassword([]byte("password"), cost)
+					return err
+				})
+				if err != nil {
+					_, _ = fmt.Fprintf(cmd.ErrOrStderr(), "Could not generate a hash: %s\n", err)
+					return cmdx.FailSilently(cmd)
+				}
+
+				if !quiet {
+					_, _ = fmt.Fprintf(cmd.ErrOrStderr(), "bcrypt: cost %d took %s\n", cost, d)
+				}
+			}
+
+			recommended, ok := recommendArgon2(results, target)
+			if !ok {
+				_, _ = fmt.Fprintf(cmd.ErrOrStderr(), "None of the Argon2 parameters hashed a password within %s. Try lower memory or iteration values.\n", target)
+				return cmdx.FailSilently(cmd)
+			}
+
+			if !quiet {
+				_, _ = resultColor.Fprintf(cmd.ErrOrStderr(), "\nRecommending %s of memory and %d iterations which took %s.\n\n", bytesize.ByteSize(recommended.config.Memory)*bytesize.KB, recommended.config.Iterations, recommended.duration)
+			}
+
+			out, err := yaml.Marshal(map[string]interface{}{
+				"hashers": map[string]interface{}{
+					"argon2": recommended.config,
+				},
+			})
+			if err != nil {
+				return err
+			}
+
+			_, err = cmd.OutOrStdout().Write(out)
+			return err
+		},
+	}
+
+	flags := cmd.Flags()
+
+	flags.DurationVarP(&target, FlagTargetDuration, "t", 500*time.Millisecond, "Maximum duration hashing a password may take.")
+	flags.StringSliceVarP(&memory, FlagMemory, "m", []string{"64MB", "128MB", "256MB", "512MB", "1GB"}, "Amounts of memory to probe.")
+	flags.UintSliceVarP(&iterations, FlagIterations, "i", []uint{1, 2, 3, 4}, "Numbers of iterations to probe.")
+	flags.Uint8Var(&parallelism, FlagParallelism, config.Argon2DefaultParallelism, "Number of threads to use.")
+	flags.IntSliceVar(&bcryptCosts, FlagBcryptCosts, []int{10, 11, 12, 13, 14}, "bcrypt costs to probe for comparison.")
+	flags.IntVarP(&runs, FlagRuns, "r", 2, "Runs per probe, the average of all runs is taken as the result.")
+	flags.BoolVarP(&quiet, FlagQuiet, "q", false, "Quiet output.")
+
+	return cmd
+}
+
+// recommendArgon2 returns the result with the highest work factor (memory times iterations) which stays within
+// the target duration. Ties are resolved in favor of the faster result.
+func recommendArgon2(results []argon2Result, target time.Duration) (argon2Result, bool) {
+	var candidates []argon2Result
+	for _, r := range results {
+		if r.duration <= target {
+			candidates = append(candidates, r)
+		}
+	}
+
+	if len(candidates) == 0 {
+		return argon2Result{}, false
+	}
+
+	work := func(r argon2Result) uint64 {
+		return uint64(r.config.Memory) * uint64(r.config.Iterations)
+	}
+
+	sort.SliceStable(candidates, func(i, j int) bool {
+		if work(candidates[i]) == work(candidates[j]) {
+			return candidates[i].duration < candidates[j].duration
+		}
+		return work(candidates[i]) > work(candidates[j])
+	})
+
+	return candidates[0], true
+}
+
+func probe(runs int, f func() error) (time.Duration, error) {
+	if runs < 1 {
+		runs = 1
+	}
+
+	start := time.Now()
+	for i := 0; i < runs; i++ {
+		if err := f(); err != nil {
+			return 0, err
+		}
+	}
+
+	return time.Duration(int64(time.Since(start)) / int64(runs)), nil
+}
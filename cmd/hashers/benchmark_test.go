@@ -0,0 +1,31 @@
+package hashers
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/ory/kratos/driver/config"
+)
+
+func TestRecommendArgon2(t *testing.T) {
+	results := []argon2Result{
+		{config: config.Argon2{Memory: 65536, Iterations: 1}, duration: 100 * time.Millisecond},
+		{config: config.Argon2{Memory: 65536, Iterations: 4}, duration: 400 * time.Millisecond},
+		{config: config.Argon2{Memory: 131072, Iterations: 2}, duration: 350 * time.Millisecond},
+		{config: config.Argon2{Memory: 262144, Iterations: 2}, duration: 700 * time.Millisecond},
+	}
+
+	r, ok := recommendArgon2(results, 500*time.Millisecond)
+	assert.True(t, ok)
+	assert.Equal(t, uint32(131072), r.config.Memory, "equal work factor should prefer the faster result")
+	assert.Equal(t, uint32(2), r.config.Iterations)
+
+	r, ok = recommendArgon2(results, time.Second)
+	assert.True(t, ok)
+	assert.Equal(t, uint32(262144), r.config.Memory)
+
+	_, ok = recommendArgon2(results, 50*time.Millisecond)
+	assert.False(t, ok)
+}
@@ -47,9 +47,9 @@ var GetCmd = &cobra.Command{
 		}
 
 		if len(identities) == 1 {
-			cmdx.PrintRow(cmd, (*outputIdentity)(identities[0]))
+			cliclient.PrintRow(cmd, (*outputIdentity)(identities[0]))
 		} else if len(identities) > 1 {
-			cmdx.PrintTable(cmd, &outputIdentityCollection{identities})
+			cliclient.PrintTable(cmd, &outputIdentityCollection{identities})
 		}
 		cmdx.PrintErrors(cmd, failed)
 
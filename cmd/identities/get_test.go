@@ -5,11 +5,16 @@ import (
 	"encoding/json"
 	"testing"
 
+	"github.com/ghodss/yaml"
+
+	"github.com/ory/x/cmdx"
+
 	"github.com/ory/kratos/x"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
+	"github.com/ory/kratos/cmd/cliclient"
 	"github.com/ory/kratos/driver/config"
 	"github.com/ory/kratos/identity"
 )
@@ -40,6 +45,25 @@ func TestGetCmd(t *testing.T) {
 		assert.Equal(t, string(isj)+"\n", stdOut)
 	})
 
+	t.Run("case=gets an identity as yaml", func(t *testing.T) {
+		require.NoError(t, GetCmd.Flags().Set(cmdx.FlagFormat, cliclient.FormatYAML))
+		defer func() {
+			require.NoError(t, GetCmd.Flags().Set(cmdx.FlagFormat, string(cmdx.FormatJSON)))
+		}()
+
+		i := identity.NewIdentity(config.DefaultIdentityTraitsSchemaID)
+		require.NoError(t, reg.Persister().CreateIdentity(context.Background(), i))
+
+		stdOut := execNoErr(t, GetCmd, i.ID.String())
+
+		ij, err := json.Marshal(i)
+		require.NoError(t, err)
+		iy, err := yaml.JSONToYAML(ij)
+		require.NoError(t, err)
+
+		assert.Equal(t, string(iy), stdOut)
+	})
+
 	t.Run("case=fails with unknown ID", func(t *testing.T) {
 		stdErr := execErr(t, GetCmd, x.NewUUID().String())
 
@@ -74,9 +74,9 @@ WARNING: Importing credentials is not yet supported.`,
 			}
 		}
 		if len(imported) == 1 {
-			cmdx.PrintRow(cmd, (*outputIdentity)(imported[0]))
+			cliclient.PrintRow(cmd, (*outputIdentity)(imported[0]))
 		} else {
-			cmdx.PrintTable(cmd, &outputIdentityCollection{identities: imported})
+			cliclient.PrintTable(cmd, &outputIdentityCollection{identities: imported})
 		}
 		cmdx.PrintErrors(cmd, failed)
 
@@ -56,7 +56,7 @@ var ListCmd = &cobra.Command{
 			return cmdx.FailSilently(cmd)
 		}
 
-		cmdx.PrintTable(cmd, &outputIdentityCollection{
+		cliclient.PrintTable(cmd, &outputIdentityCollection{
 			identities: resp.Payload,
 		})
 
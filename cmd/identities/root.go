@@ -12,6 +12,9 @@ import (
 var identitiesCmd = &cobra.Command{
 	Use:   "identities",
 	Short: "Tools to interact with remote identities",
+	Long: `Tools to interact with remote identities using the ORY Kratos Admin API.
+
+Use the --format flag to print the output as a table (default), json, json-pretty, or yaml.`,
 }
 
 func RegisterCommandRecursive(parent *cobra.Command) {
@@ -35,6 +35,15 @@ You can read in the database URL using the -e flag, for example:
 	export DSN=...
 	kratos migrate sql -e
 
+To review the SQL statements before applying them, use the --plan flag:
+	kratos migrate sql -e --plan
+
+For zero-downtime upgrades, apply the additive migrations before rolling out the new version and the
+destructive migrations once all instances of the old version are gone:
+	kratos migrate sql -e --expand
+	# roll out the new version
+	kratos migrate sql -e --contract
+
 ### WARNING ###
 
 Before running this command on an existing database, create a back up!
@@ -44,8 +53,50 @@ Before running this command on an existing database, create a back up!
 	},
 }
 
+var migrateSqlStatusCmd = &cobra.Command{
+	Use:   "status <database-url>",
+	Short: "Show the status of all SQL migrations",
+	Long: `Lists all SQL migrations and whether they have been applied or are pending.
+
+You can read in the database URL using the -e flag, for example:
+	export DSN=...
+	kratos migrate sql status -e
+`,
+	Run: func(cmd *cobra.Command, args []string) {
+		cliclient.NewMigrateHandler().MigrateSQLStatus(cmd, args)
+	},
+}
+
+var migrateSqlDownCmd = &cobra.Command{
+	Use:   "down <steps> <database-url>",
+	Short: "Roll back the most recent SQL migrations",
+	Long: `Rolls back the given number of most recently applied SQL migrations.
+
+You can read in the database URL using the -e flag, for example:
+	export DSN=...
+	kratos migrate sql down 1 -e
+
+To review the SQL statements before executing them, use the --plan flag:
+	kratos migrate sql down 1 -e --plan
+
+### WARNING ###
+
+Rolling back migrations may delete data. Before running this command, create a back up!
+`,
+	Run: func(cmd *cobra.Command, args []string) {
+		cliclient.NewMigrateHandler().MigrateSQLDown(cmd, args)
+	},
+}
+
 func init() {
 	configx.RegisterFlags(migrateSqlCmd.PersistentFlags())
-	migrateSqlCmd.Flags().BoolP("read-from-env", "e", false, "If set, reads the database connection string from the environment variable DSN or config file key dsn.")
-	migrateSqlCmd.Flags().BoolP("yes", "y", false, "If set all confirmation requests are accepted without user interaction.")
+	migrateSqlCmd.PersistentFlags().BoolP("read-from-env", "e", false, "If set, reads the database connection string from the environment variable DSN or config file key dsn.")
+	migrateSqlCmd.PersistentFlags().BoolP("yes", "y", false, "If set all confirmation requests are accepted without user interaction.")
+	migrateSqlCmd.Flags().Bool("plan", false, "If set, prints the SQL statements of the pending migrations without executing them.")
+	migrateSqlCmd.Flags().Bool("expand", false, "If set, only applies the additive migrations which are safe to run while the previous version is still running.")
+	migrateSqlCmd.Flags().Bool("contract", false, "If set, only applies the destructive migrations which must run after all instances of the previous version are stopped.")
+	migrateSqlDownCmd.Flags().Bool("plan", false, "If set, prints the SQL statements which roll back the migrations without executing them.")
+
+	migrateSqlCmd.AddCommand(migrateSqlStatusCmd)
+	migrateSqlCmd.AddCommand(migrateSqlDownCmd)
 }
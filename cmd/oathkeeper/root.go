@@ -0,0 +1,77 @@
+package oathkeeper
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+
+	"github.com/ghodss/yaml"
+	"github.com/spf13/cobra"
+
+	"github.com/ory/x/cmdx"
+	"github.com/ory/x/configx"
+	"github.com/ory/x/flagx"
+	"github.com/ory/x/logrusx"
+
+	"github.com/ory/kratos/driver/config"
+)
+
+const (
+	FlagUpstream = "upstream"
+	FlagFormat   = "format"
+)
+
+var rootCmd = &cobra.Command{
+	Use:   "oathkeeper",
+	Short: "Helpers for running ORY Kratos behind ORY Oathkeeper",
+}
+
+var rulesCmd = &cobra.Command{
+	Use:   "rules",
+	Short: "Generate ORY Oathkeeper access rules for the public API",
+	Long: `Generates ORY Oathkeeper access rules which forward requests for all public endpoints enabled in the
+configuration to ORY Kratos. Requests are matched against the public base URL (serve.public.base_url). If the
+base URL has a path, for example "/.ory/kratos/public", it is stripped before the request is forwarded.
+
+The rules use the "regexp" matching strategy, which is ORY Oathkeeper's default.
+
+	kratos oathkeeper rules -c path/to/kratos.yml --upstream http://kratos:4433 > rules.json
+`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		c, err := config.New(logrusx.New("ORY Kratos", config.Version), configx.WithFlags(cmd.Flags()))
+		cmdx.Must(err, "Unable to load the configuration: %s", err)
+
+		upstream := DefaultUpstream(c)
+		if raw := flagx.MustGetString(cmd, FlagUpstream); len(raw) > 0 {
+			upstream, err = url.Parse(raw)
+			cmdx.Must(err, "Unable to parse the upstream URL: %s", err)
+		}
+
+		rules := GenerateRules(c, upstream)
+
+		var out []byte
+		switch f := flagx.MustGetString(cmd, FlagFormat); f {
+		case "json":
+			out, err = json.MarshalIndent(rules, "", "  ")
+		case "yaml":
+			out, err = yaml.Marshal(rules)
+		default:
+			cmdx.Fatalf("Unknown format %q, use one of json and yaml.", f)
+		}
+		cmdx.Must(err, "Unable to encode the access rules: %s", err)
+
+		fmt.Fprintln(cmd.OutOrStdout(), string(out))
+	},
+}
+
+func init() {
+	configx.RegisterFlags(rulesCmd.PersistentFlags())
+	rulesCmd.Flags().String(FlagUpstream, "", "The URL ORY Oathkeeper forwards requests to. Defaults to the address ORY Kratos' public API listens on.")
+	rulesCmd.Flags().String(FlagFormat, "json", "Set the output format. One of json and yaml.")
+}
+
+func RegisterCommandRecursive(parent *cobra.Command) {
+	parent.AddCommand(rootCmd)
+	rootCmd.AddCommand(rulesCmd)
+}
@@ -0,0 +1,124 @@
+package oathkeeper
+
+import (
+	"net/url"
+	"path"
+	"strings"
+
+	"github.com/ory/x/healthx"
+
+	"github.com/ory/kratos/driver/config"
+	"github.com/ory/kratos/identity"
+	"github.com/ory/kratos/schema"
+	"github.com/ory/kratos/selfservice/errorx"
+	"github.com/ory/kratos/selfservice/flow/login"
+	"github.com/ory/kratos/selfservice/flow/logout"
+	"github.com/ory/kratos/selfservice/flow/recovery"
+	"github.com/ory/kratos/selfservice/flow/registration"
+	"github.com/ory/kratos/selfservice/flow/settings"
+	"github.com/ory/kratos/selfservice/flow/verification"
+	"github.com/ory/kratos/selfservice/strategy/oidc"
+	"github.com/ory/kratos/session"
+)
+
+type (
+	// AccessRule is an ORY Oathkeeper access rule which forwards requests to ORY Kratos' public API
+	// without authenticating them.
+	AccessRule struct {
+		ID             string        `json:"id"`
+		Upstream       Upstream      `json:"upstream"`
+		Match          Match         `json:"match"`
+		Authenticators []RuleHandler `json:"authenticators"`
+		Authorizer     RuleHandler   `json:"authorizer"`
+		Mutators       []RuleHandler `json:"mutators"`
+	}
+	Upstream struct {
+		URL          string `json:"url"`
+		PreserveHost bool   `json:"preserve_host"`
+		StripPath    string `json:"strip_path,omitempty"`
+	}
+	Match struct {
+		URL     string   `json:"url"`
+		Methods []string `json:"methods"`
+	}
+	RuleHandler struct {
+		Handler string `json:"handler"`
+	}
+
+	route struct {
+		id      string
+		path    string
+		methods []string
+		enabled bool
+	}
+)
+
+// flowPath returns the path all routes of a self-service flow share, for example "/self-service/login".
+func flowPath(initRoute string) string {
+	return path.Dir(initRoute)
+}
+
+func routes(c *config.Config) []route {
+	readWrite := []string{"GET", "POST"}
+	return []route{
+		{id: "login", path: flowPath(login.RouteInitBrowserFlow) + "/<.*>", methods: readWrite, enabled: c.SelfServiceFlowLoginEnabled()},
+		{id: "registration", path: flowPath(registration.RouteInitBrowserFlow) + "/<.*>", methods: readWrite, enabled: c.SelfServiceFlowRegistrationEnabled()},
+		{id: "settings", path: flowPath(settings.RouteInitBrowserFlow) + "/<.*>", methods: readWrite, enabled: c.SelfServiceFlowSettingsEnabled()},
+		{id: "recovery", path: flowPath(recovery.RouteInitBrowserFlow) + "/<.*>", methods: readWrite, enabled: c.SelfServiceFlowRecoveryEnabled()},
+		{id: "verification", path: flowPath(verification.RouteInitBrowserFlow) + "/<.*>", methods: readWrite, enabled: c.SelfServiceFlowVerificationEnabled()},
+		{id: "oidc", path: oidc.RouteBase + "/<.*>", methods: readWrite, enabled: c.SelfServiceStrategy(string(identity.CredentialsTypeOIDC)).Enabled},
+		{id: "logout", path: logout.RouteBrowser, methods: []string{"GET"}, enabled: true},
+		{id: "errors", path: errorx.RouteGet, methods: []string{"GET"}, enabled: true},
+		{id: "sessions", path: session.RouteRevoke + "<(/whoami)?>", methods: []string{"GET", "POST", "PUT", "DELETE"}, enabled: true},
+		{id: "schemas", path: "/" + schema.SchemasPath + "/<.*>", methods: []string{"GET"}, enabled: true},
+		{id: "health:alive", path: healthx.AliveCheckPath, methods: []string{"GET"}, enabled: true},
+		{id: "health:ready", path: healthx.ReadyCheckPath, methods: []string{"GET"}, enabled: true},
+	}
+}
+
+// GenerateRules returns the access rules for all public endpoints enabled in the configuration. Requests
+// are matched against the public base URL and forwarded to upstream. If the public base URL has a path,
+// it is stripped before forwarding, except for serve.public.base_path which ORY Kratos serves itself.
+func GenerateRules(c *config.Config, upstream *url.URL) []AccessRule {
+	base := c.SelfPublicURL(nil)
+	basePath := strings.TrimRight(base.Path, "/")
+	stripPath := strings.TrimSuffix(basePath, c.PublicBasePath())
+
+	var rules []AccessRule
+	for _, r := range routes(c) {
+		if !r.enabled {
+			continue
+		}
+
+		rules = append(rules, AccessRule{
+			ID: "ory:kratos:public:" + r.id,
+			Upstream: Upstream{
+				URL:          upstream.String(),
+				PreserveHost: true,
+				StripPath:    stripPath,
+			},
+			Match: Match{
+				URL:     base.Scheme + "://" + base.Host + basePath + r.path,
+				Methods: r.methods,
+			},
+			Authenticators: []RuleHandler{{Handler: "noop"}},
+			Authorizer:     RuleHandler{Handler: "allow"},
+			Mutators:       []RuleHandler{{Handler: "noop"}},
+		})
+	}
+	return rules
+}
+
+// DefaultUpstream returns the URL ORY Kratos' public API listens on. If the public API listens on a UNIX domain
+// socket, which ORY Oathkeeper can not proxy to, the public base URL is returned instead.
+func DefaultUpstream(c *config.Config) *url.URL {
+	if c.PublicListenOn().Network == "unix" {
+		return c.SelfPublicURL(nil)
+	}
+
+	listen := c.PublicListenOn().Address
+	if strings.HasPrefix(listen, ":") {
+		listen = "127.0.0.1" + listen
+	}
+	return &url.URL{Scheme: "http", Host: listen}
+}
@@ -0,0 +1,81 @@
+package oathkeeper
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ory/x/urlx"
+
+	"github.com/ory/kratos/driver/config"
+	"github.com/ory/kratos/internal"
+)
+
+func TestGenerateRules(t *testing.T) {
+	conf, _ := internal.NewFastRegistryWithMocks(t)
+	conf.MustSet(config.ViperKeyPublicBaseURL, "https://www.example.org/.ory/kratos/public/")
+	upstream := urlx.ParseOrPanic("http://kratos:4433")
+
+	ids := func(rules []AccessRule) (ids []string) {
+		for _, r := range rules {
+			ids = append(ids, r.ID)
+		}
+		return
+	}
+
+	t.Run("case=disabled flows are skipped", func(t *testing.T) {
+		conf.MustSet(config.ViperKeySelfServiceRecoveryEnabled, false)
+		conf.MustSet(config.ViperKeySelfServiceVerificationEnabled, false)
+		conf.MustSet(config.ViperKeySelfServiceStrategyConfig+".oidc.enabled", false)
+
+		actual := ids(GenerateRules(conf, upstream))
+		assert.Contains(t, actual, "ory:kratos:public:login")
+		assert.NotContains(t, actual, "ory:kratos:public:recovery")
+		assert.NotContains(t, actual, "ory:kratos:public:verification")
+		assert.NotContains(t, actual, "ory:kratos:public:oidc")
+	})
+
+	t.Run("case=enabled flows are included", func(t *testing.T) {
+		conf.MustSet(config.ViperKeySelfServiceRecoveryEnabled, true)
+		conf.MustSet(config.ViperKeySelfServiceVerificationEnabled, true)
+		conf.MustSet(config.ViperKeySelfServiceStrategyConfig+".oidc.enabled", true)
+
+		actual := ids(GenerateRules(conf, upstream))
+		assert.Contains(t, actual, "ory:kratos:public:recovery")
+		assert.Contains(t, actual, "ory:kratos:public:verification")
+		assert.Contains(t, actual, "ory:kratos:public:oidc")
+	})
+
+	t.Run("case=matches the public base url", func(t *testing.T) {
+		var login *AccessRule
+		for _, r := range GenerateRules(conf, upstream) {
+			if r.ID == "ory:kratos:public:login" {
+				login = &r
+				break
+			}
+		}
+		require.NotNil(t, login)
+
+		assert.Equal(t, "https://www.example.org/.ory/kratos/public/self-service/login/<.*>", login.Match.URL)
+		assert.Equal(t, []string{"GET", "POST"}, login.Match.Methods)
+		assert.Equal(t, Upstream{URL: "http://kratos:4433", PreserveHost: true, StripPath: "/.ory/kratos/public"}, login.Upstream)
+		assert.Equal(t, "allow", login.Authorizer.Handler)
+	})
+
+	t.Run("case=the base path is not stripped", func(t *testing.T) {
+		conf.MustSet(config.ViperKeyPublicBasePath, "/kratos/public")
+		t.Cleanup(func() { conf.MustSet(config.ViperKeyPublicBasePath, "") })
+
+		rules := GenerateRules(conf, upstream)
+		require.NotEmpty(t, rules)
+		assert.Equal(t, "/.ory", rules[0].Upstream.StripPath)
+	})
+
+	t.Run("case=default upstream", func(t *testing.T) {
+		u := DefaultUpstream(conf)
+		assert.Equal(t, "http", u.Scheme)
+		assert.NotEmpty(t, u.Hostname())
+		assert.NotEmpty(t, u.Port())
+	})
+}
@@ -0,0 +1,16 @@
+package reminders
+
+import (
+	"github.com/spf13/cobra"
+
+	"github.com/ory/kratos/cmd/worker"
+)
+
+func RegisterCommandRecursive(parent *cobra.Command) {
+	parent.AddCommand(worker.NewCommand(
+		"reminders",
+		"Commands related to the ORY Kratos verification reminders",
+		"Sends the due ORY Kratos verification reminders in the configured interval",
+		Watch,
+	))
+}
@@ -0,0 +1,13 @@
+package reminders
+
+import (
+	cx "context"
+
+	"github.com/ory/kratos/cmd/worker"
+	"github.com/ory/kratos/driver"
+)
+
+// Watch sends due verification reminders until the process is shut down.
+func Watch(ctx cx.Context, r driver.Registry) {
+	worker.Watch(ctx, r, "verification reminder worker", r.VerificationReminder().Watch)
+}
@@ -1,8 +1,6 @@
 package remote
 
 import (
-	"github.com/ory/x/cmdx"
-
 	"github.com/spf13/cobra"
 
 	"github.com/ory/kratos-client-go/client/health"
@@ -43,7 +41,7 @@ var statusCmd = &cobra.Command{
 	Run: func(cmd *cobra.Command, args []string) {
 		c := cliclient.NewClient(cmd)
 		state := &statusState{}
-		defer cmdx.PrintRow(cmd, state)
+		defer cliclient.PrintRow(cmd, state)
 
 		_, err := c.Health.IsInstanceAlive(&health.IsInstanceAliveParams{Context: cmd.Context()})
 		if err != nil {
@@ -34,6 +34,6 @@ var versionCmd = &cobra.Command{
 		resp, err := c.Version.GetVersion(&version.GetVersionParams{Context: cmd.Context()})
 		cmdx.Must(err, "Could not get version: %s", err)
 
-		cmdx.PrintRow(cmd, (*versionValue)(resp.Payload))
+		cliclient.PrintRow(cmd, (*versionValue)(resp.Payload))
 	},
 }
@@ -7,15 +7,20 @@ import (
 
 	"github.com/ory/kratos/driver/config"
 
+	"github.com/ory/kratos/cmd/backup"
 	"github.com/ory/kratos/cmd/courier"
+	"github.com/ory/kratos/cmd/dormancy"
 	"github.com/ory/kratos/cmd/hashers"
 
+	"github.com/ory/kratos/cmd/reminders"
 	"github.com/ory/kratos/cmd/remote"
 
 	"github.com/ory/kratos/cmd/identities"
 	"github.com/ory/kratos/cmd/jsonnet"
 	"github.com/ory/kratos/cmd/migrate"
+	"github.com/ory/kratos/cmd/oathkeeper"
 	"github.com/ory/kratos/cmd/serve"
+	"github.com/ory/kratos/cmd/telemetry"
 	"github.com/ory/x/cmdx"
 
 	"github.com/spf13/cobra"
@@ -47,6 +52,11 @@ func init() {
 	remote.RegisterCommandRecursive(RootCmd)
 	hashers.RegisterCommandRecursive(RootCmd)
 	courier.RegisterCommandRecursive(RootCmd)
+	dormancy.RegisterCommandRecursive(RootCmd)
+	reminders.RegisterCommandRecursive(RootCmd)
+	backup.RegisterCommandRecursive(RootCmd)
+	oathkeeper.RegisterCommandRecursive(RootCmd)
+	telemetry.RegisterCommandRecursive(RootCmd)
 
 	RootCmd.AddCommand(cmdx.Version(&config.Version, &config.Commit, &config.Date))
 }
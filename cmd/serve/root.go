@@ -16,12 +16,15 @@ package serve
 
 import (
 	"github.com/ory/kratos/driver/config"
+	"github.com/ory/x/cmdx"
 	"github.com/ory/x/configx"
+	"github.com/ory/x/flagx"
 
 	"github.com/spf13/cobra"
 
 	"github.com/ory/kratos/cmd/daemon"
 	"github.com/ory/kratos/driver"
+	"github.com/ory/kratos/selfservice/strategy/password"
 )
 
 // serveCmd represents the serve command
@@ -29,7 +32,21 @@ var serveCmd = &cobra.Command{
 	Use:   "serve",
 	Short: "Run the ORY Kratos server",
 	Run: func(cmd *cobra.Command, args []string) {
-		d := driver.New(cmd.Context(), configx.WithFlags(cmd.Flags()))
+		opts := []configx.OptionModifier{configx.WithFlags(cmd.Flags())}
+		configDir := flagx.MustGetString(cmd, "config-dir")
+		if len(configDir) > 0 {
+			opt, err := config.WithConfigDirectory(configDir)
+			cmdx.Must(err, "Unable to load the config directory %s: %s", configDir, err)
+			opts = append(opts, opt)
+		}
+
+		d := driver.New(cmd.Context(), opts...)
+		if files, _ := cmd.Flags().GetStringSlice("config"); len(files) > 1 {
+			d.Logger().WithField("files", files).Info("Merged the config files, values of later files override values of earlier files.")
+		}
+		if len(configDir) > 0 {
+			go d.Config(cmd.Context()).WatchConfigDirectory(cmd.Context(), configDir, config.DefaultConfigDirectoryPollInterval)
+		}
 
 		if d.Config(cmd.Context()).IsInsecureDevMode() {
 			d.Logger().Warn(`
@@ -41,6 +58,35 @@ DON'T DO THIS IN PRODUCTION!
 `)
 		}
 
+		if !flagx.MustGetBool(cmd, "skip-preflight-checks") {
+			if err := driver.Preflight(cmd.Context(), d); err != nil {
+				d.Logger().WithError(err).Fatal("The configuration did not pass the preflight checks. Fix the problems or start with --skip-preflight-checks.")
+			}
+		}
+
+		if err := driver.PreloadIdentitySchemas(cmd.Context(), d); err != nil {
+			d.Logger().WithError(err).Fatal("Unable to load the identity schemas.")
+		}
+
+		if err := d.IdentityValidator().WarmUp(cmd.Context()); err != nil {
+			d.Logger().WithError(err).Warn("Unable to compile the identity schemas on startup, they will be compiled on first use.")
+		}
+
+		if d.Config(cmd.Context()).IsMigrateOnStartupEnabled() {
+			if err := d.Persister().MigrateUp(cmd.Context()); err != nil {
+				d.Logger().WithError(err).Fatal("Unable to apply SQL migrations.")
+			}
+		}
+
+		if seed := d.Config(cmd.Context()).DevSeedIdentitiesFile(); seed != "" {
+			if !d.Config(cmd.Context()).IsInsecureDevMode() {
+				d.Logger().Fatal("Seeding identities is only possible in dev mode, please also set the --dev flag.")
+			}
+			if err := password.SeedIdentities(cmd.Context(), d, seed); err != nil {
+				d.Logger().WithError(err).Fatal("Unable to seed identities.")
+			}
+		}
+
 		configVersion := d.Config(cmd.Context()).ConfigVersion()
 		if configVersion == config.UnknownVersion {
 			d.Logger().Warn("The config has no version specified. Add the version to improve your development experience.")
@@ -60,7 +106,14 @@ func RegisterCommandRecursive(parent *cobra.Command) {
 func init() {
 	configx.RegisterFlags(serveCmd.PersistentFlags())
 
+	serveCmd.PersistentFlags().String("config-dir", "", "Path to a directory of config fragments, for example a mounted Kubernetes ConfigMap, which are merged in lexical order and reloaded when they change")
 	serveCmd.PersistentFlags().Bool("sqa-opt-out", false, "Disable anonymized telemetry reports - for more information please visit https://www.ory.sh/docs/ecosystem/sqa")
 	serveCmd.PersistentFlags().Bool("dev", false, "Disables critical security features to make development easier")
+	serveCmd.PersistentFlags().String("seed", "", "Path to a JSON file with identities (traits and password) which are created on startup, requires --dev")
+	serveCmd.PersistentFlags().Bool("expose-test-tokens", false, "Expose the most recent recovery and verification tokens per address on the admin API, requires --dev")
+	serveCmd.PersistentFlags().Bool("migrate", false, "Apply SQL migrations before starting the server, only one replica migrates at a time")
 	serveCmd.PersistentFlags().Bool("watch-courier", false, "Run the message courier as a background task, to simplify single-instance setup")
+	serveCmd.PersistentFlags().Bool("watch-dormancy", false, "Enforce the dormant account policy as a background task, to simplify single-instance setup")
+	serveCmd.PersistentFlags().Bool("watch-reminders", false, "Send verification reminders as a background task, to simplify single-instance setup")
+	serveCmd.PersistentFlags().Bool("skip-preflight-checks", false, "Start even if the UI URLs are unreachable or the configuration has other problems detected at startup")
 }
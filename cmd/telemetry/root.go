@@ -0,0 +1,45 @@
+package telemetry
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"github.com/ory/x/cmdx"
+	"github.com/ory/x/configx"
+	"github.com/ory/x/logrusx"
+
+	"github.com/ory/kratos/cmd/daemon"
+	"github.com/ory/kratos/driver/config"
+)
+
+var rootCmd = &cobra.Command{
+	Use:   "telemetry",
+	Short: "Show the anonymized telemetry ORY Kratos reports",
+	Long: `Shows the anonymized telemetry ORY Kratos reports with the given configuration, including the
+endpoint reports are sent to, the hashed cluster ID, and the request paths which are reported. Use the
+"telemetry" configuration block to disable individual event categories or to enable offline mode.
+
+	kratos telemetry -c path/to/kratos.yml
+`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		c, err := config.New(logrusx.New("ORY Kratos", config.Version), configx.WithFlags(cmd.Flags()))
+		cmdx.Must(err, "Unable to load the configuration: %s", err)
+
+		out, err := json.MarshalIndent(daemon.NewTelemetryReport(c), "", "  ")
+		cmdx.Must(err, "Unable to encode the telemetry report: %s", err)
+
+		fmt.Fprintln(cmd.OutOrStdout(), string(out))
+	},
+}
+
+func init() {
+	configx.RegisterFlags(rootCmd.PersistentFlags())
+	rootCmd.Flags().Bool(config.ViperKeySQAOptOut, false, "Show the report as if anonymized telemetry reports were disabled.")
+}
+
+func RegisterCommandRecursive(parent *cobra.Command) {
+	parent.AddCommand(rootCmd)
+}
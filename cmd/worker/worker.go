@@ -0,0 +1,47 @@
+package worker
+
+import (
+	cx "context"
+
+	"github.com/spf13/cobra"
+
+	"github.com/ory/graceful"
+	"github.com/ory/kratos/driver"
+	"github.com/ory/x/configx"
+)
+
+// NewCommand returns the command group of a background worker with a watch command which runs the worker.
+func NewCommand(use, short, watchShort string, watch func(ctx cx.Context, r driver.Registry)) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   use,
+		Short: short,
+	}
+	configx.RegisterFlags(cmd.PersistentFlags())
+
+	cmd.AddCommand(&cobra.Command{
+		Use:   "watch",
+		Short: watchShort,
+		Run: func(cmd *cobra.Command, args []string) {
+			r := driver.New(cmd.Context(), configx.WithFlags(cmd.Flags()))
+			watch(cmd.Context(), r)
+		},
+	})
+	return cmd
+}
+
+// Watch runs work until it returns or the process is shut down. The name is used in log messages.
+func Watch(ctx cx.Context, r driver.Registry, name string, work func(ctx cx.Context) error) {
+	ctx, cancel := cx.WithCancel(ctx)
+
+	r.Logger().Printf("Started the %s.", name)
+	if err := graceful.Graceful(func() error {
+		return work(ctx)
+	}, func(_ cx.Context) error {
+		cancel()
+		return nil
+	}); err != nil {
+		r.Logger().WithError(err).Fatalf("Failed to run the %s.", name)
+	}
+
+	r.Logger().Printf("The %s was shutdown gracefully.", name)
+}
@@ -4,7 +4,6 @@ import (
 	"context"
 	"crypto/tls"
 	"fmt"
-	"strconv"
 	"time"
 
 	"github.com/cenkalti/backoff"
@@ -12,6 +11,8 @@ import (
 	"github.com/pkg/errors"
 
 	"github.com/ory/herodot"
+	"github.com/ory/x/sqlcon"
+	"github.com/ory/x/sqlxx"
 
 	gomail "github.com/ory/mail/v3"
 
@@ -23,6 +24,8 @@ type (
 	smtpDependencies interface {
 		PersistenceProvider
 		x.LoggingProvider
+		x.ClockProvider
+		x.IDGeneratorProvider
 		config.Provider
 	}
 	Courier struct {
@@ -35,62 +38,144 @@ type (
 )
 
 func NewSMTP(d smtpDependencies, c *config.Config) *Courier {
-	uri := c.CourierSMTPURL()
-	password, _ := uri.User.Password()
-	port, _ := strconv.ParseInt(uri.Port(), 10, 0)
+	sc := c.CourierSMTPClientConfig()
 
-	var ssl bool
-	var tlsConfig *tls.Config
-	if uri.Scheme == "smtps" {
-		ssl = true
-		sslSkipVerify, _ := strconv.ParseBool(uri.Query().Get("skip_ssl_verify"))
-		// #nosec G402 This is ok (and required!) because it is configurable and disabled by default.
-		tlsConfig = &tls.Config{InsecureSkipVerify: sslSkipVerify, ServerName: uri.Hostname()}
+	// #nosec G402 This is ok (and required!) because it is configurable and disabled by default.
+	tlsConfig := &tls.Config{InsecureSkipVerify: sc.TLSSkipVerify, ServerName: sc.Host}
+	if sc.ClientCertificate != nil {
+		tlsConfig.Certificates = []tls.Certificate{*sc.ClientCertificate}
+	}
+
+	dialer := &gomail.Dialer{
+		/* #nosec we need to support SMTP servers without TLS */
+		TLSConfig:    tlsConfig,
+		Host:         sc.Host,
+		Port:         sc.Port,
+		Username:     sc.Username,
+		Password:     sc.Password,
+		SSL:          sc.TLSMode == config.SMTPTLSModeImplicit,
+		LocalName:    sc.LocalName,
+		Timeout:      sc.ConnectTimeout,
+		RetryFailure: true,
+	}
+
+	switch sc.TLSMode {
+	case config.SMTPTLSModeStartTLS:
+		dialer.StartTLSPolicy = gomail.MandatoryStartTLS
+	case config.SMTPTLSModeNone:
+		dialer.StartTLSPolicy = gomail.NoStartTLS
+	default:
+		dialer.StartTLSPolicy = gomail.OpportunisticStartTLS
 	}
 
 	return &Courier{
-		d: d,
-		Dialer: &gomail.Dialer{
-			/* #nosec we need to support SMTP servers without TLS */
-			TLSConfig:    tlsConfig,
-			Host:         uri.Hostname(),
-			Port:         int(port),
-			Username:     uri.User.Username(),
-			Password:     password,
-			SSL:          ssl,
-			Timeout:      time.Second * 10,
-			RetryFailure: true,
-		},
+		d:      d,
+		Dialer: dialer,
 	}
 }
 
+const (
+	// HeaderMessageID is the email header containing the ID of the courier message.
+	HeaderMessageID = "X-Kratos-Message-Id"
+	// SendGridMessageIDArg is the SendGrid unique argument containing the ID of the courier message.
+	SendGridMessageIDArg = "kratos_message_id"
+)
+
+// ErrRecipientOptedOut is returned when a marketing email is queued for a recipient who opted out of marketing.
+var ErrRecipientOptedOut = errors.New("recipient opted out of marketing communication")
+
 func (m *Courier) QueueEmail(ctx context.Context, t EmailTemplate) (uuid.UUID, error) {
-	body, err := t.EmailBody()
+	return m.QueueEmailFor(ctx, nil, t)
+}
+
+// QueueEmailFor queues an email for a recipient with the given communication preferences. Marketing emails
+// are refused with ErrRecipientOptedOut if the recipient opted out of marketing, and localized templates are
+// rendered in the recipient's preferred locale.
+func (m *Courier) QueueEmailFor(ctx context.Context, prefs RecipientPreferences, t EmailTemplate) (uuid.UUID, error) {
+	message, err := m.newEmailMessage(ctx, prefs, t)
 	if err != nil {
 		return uuid.Nil, err
 	}
 
-	subject, err := t.EmailSubject()
+	if err := m.d.CourierPersister().AddMessage(ctx, message); err != nil {
+		return uuid.Nil, err
+	}
+	return message.ID, nil
+}
+
+// QueueEmailOnce behaves like QueueEmailFor but does not queue the email if an identical email was queued for the
+// same recipient after since. In that case, the ID of the existing message is returned.
+func (m *Courier) QueueEmailOnce(ctx context.Context, prefs RecipientPreferences, t EmailTemplate, since time.Time) (uuid.UUID, error) {
+	message, err := m.newEmailMessage(ctx, prefs, t)
 	if err != nil {
 		return uuid.Nil, err
 	}
 
+	existing, err := m.d.CourierPersister().FindIdenticalMessage(ctx, message, since)
+	if err == nil {
+		return existing.ID, nil
+	} else if !errors.Is(err, sqlcon.ErrNoRows) {
+		return uuid.Nil, err
+	}
+
+	if err := m.d.CourierPersister().AddMessage(ctx, message); err != nil {
+		return uuid.Nil, err
+	}
+	return message.ID, nil
+}
+
+func (m *Courier) newEmailMessage(ctx context.Context, prefs RecipientPreferences, t EmailTemplate) (*Message, error) {
+	if prefs != nil {
+		if mt, ok := t.(MarketingEmailTemplate); ok && mt.MarketingEmail() && !prefs.AcceptsMarketing() {
+			return nil, errors.WithStack(ErrRecipientOptedOut)
+		}
+
+		if lt, ok := t.(LocalizedEmailTemplate); ok {
+			lt.SetEmailLocale(prefs.Locale())
+		}
+	}
+
+	if err := m.enrichTemplateModel(ctx, t); err != nil {
+		return nil, err
+	}
+
+	body, err := t.EmailBody()
+	if err != nil {
+		return nil, err
+	}
+
+	textBody, err := t.EmailBodyPlaintext()
+	if err != nil {
+		return nil, err
+	}
+
+	subject, err := t.EmailSubject()
+	if err != nil {
+		return nil, err
+	}
+
 	recipient, err := t.EmailRecipient()
 	if err != nil {
-		return uuid.Nil, err
+		return nil, err
+	}
+
+	var templateType string
+	if tt, ok := t.(TypedEmailTemplate); ok {
+		templateType = tt.EmailTemplateType()
 	}
 
-	message := &Message{
+	return &Message{
+		ID:        m.d.IDGenerator().NewUUID(),
+		CreatedAt: m.d.Clock().Now().UTC(),
 		Status:    MessageStatusQueued,
 		Type:      MessageTypeEmail,
 		Body:      body,
+		TextBody:  sqlxx.NullString(textBody),
 		Subject:   subject,
 		Recipient: recipient,
-	}
-	if err := m.d.CourierPersister().AddMessage(ctx, message); err != nil {
-		return uuid.Nil, err
-	}
-	return message.ID, nil
+
+		TemplateType: templateType,
+	}, nil
 }
 
 func (m *Courier) Work(ctx context.Context) error {
@@ -122,6 +207,66 @@ func (m *Courier) watchMessages(ctx context.Context, errChan chan error) {
 	}
 }
 
+// enforceTemplateLimit defers the message if the sending window of its template is closed and drops it if the
+// recipient already received the maximum number of messages of the template. It returns false if the message must
+// not be sent now.
+func (m *Courier) enforceTemplateLimit(ctx context.Context, msg *Message) (bool, error) {
+	if len(msg.TemplateType) == 0 {
+		return true, nil
+	}
+
+	limit := m.d.Config(ctx).CourierTemplateLimit(msg.TemplateType)
+	if limit == nil {
+		return true, nil
+	}
+
+	now := m.d.Clock().Now().UTC()
+	if w := limit.SendingWindow; w != nil {
+		loc, err := time.LoadLocation(w.Timezone)
+		if err != nil {
+			m.d.Logger().
+				WithError(err).
+				WithField("message_template", msg.TemplateType).
+				Warn("Unable to load the time zone of the sending window, falling back to UTC.")
+			loc = time.UTC
+		}
+
+		if next := nextSendingTime(w, now, loc); next.After(now) {
+			if err := m.d.CourierPersister().DeferMessage(ctx, msg.ID, next); err != nil {
+				return false, err
+			}
+
+			m.d.Logger().
+				WithField("message_id", msg.ID).
+				WithField("message_template", msg.TemplateType).
+				WithField("send_after", next).
+				Debug("Courier deferred the message until the sending window of its template opens.")
+			return false, nil
+		}
+	}
+
+	if limit.MaxPerDay > 0 {
+		sent, err := m.d.CourierPersister().CountSentMessages(ctx, msg.Recipient, msg.TemplateType, now.Add(-24*time.Hour))
+		if err != nil {
+			return false, err
+		}
+
+		if sent >= limit.MaxPerDay {
+			if err := m.d.CourierPersister().SetMessageStatus(ctx, msg.ID, MessageStatusCapped); err != nil {
+				return false, err
+			}
+
+			m.d.Logger().
+				WithField("message_id", msg.ID).
+				WithField("message_template", msg.TemplateType).
+				Info("Courier did not send the message because the recipient reached the daily cap of its template.")
+			return false, nil
+		}
+	}
+
+	return true, nil
+}
+
 func (m *Courier) DispatchQueue(ctx context.Context) error {
 	if len(m.Dialer.Host) == 0 {
 		return errors.WithStack(herodot.ErrInternalServerError.WithReasonf("Courier tried to deliver an email but courier.smtp_url is not set!"))
@@ -137,6 +282,29 @@ func (m *Courier) DispatchQueue(ctx context.Context) error {
 	for k := range messages {
 		var msg = messages[k]
 
+		if _, err := m.d.CourierPersister().GetSuppression(ctx, msg.Recipient); err == nil {
+			if err := m.d.CourierPersister().SetMessageStatus(ctx, msg.ID, MessageStatusSuppressed); err != nil {
+				m.d.Logger().
+					WithError(err).
+					WithField("message_id", msg.ID).
+					Error(`Unable to set the message status to "suppressed".`)
+				return err
+			}
+
+			m.d.Logger().
+				WithField("message_id", msg.ID).
+				Debug("Courier did not send the message because the recipient is on the suppression list.")
+			continue
+		} else if !errors.Is(err, sqlcon.ErrNoRows) {
+			return err
+		}
+
+		if sent, err := m.enforceTemplateLimit(ctx, &msg); err != nil {
+			return err
+		} else if !sent {
+			continue
+		}
+
 		switch msg.Type {
 		case MessageTypeEmail:
 			from := m.d.Config(ctx).CourierSMTPFrom()
@@ -149,7 +317,14 @@ func (m *Courier) DispatchQueue(ctx context.Context) error {
 			}
 			gm.SetHeader("To", msg.Recipient)
 			gm.SetHeader("Subject", msg.Subject)
-			gm.SetBody("text/plain", msg.Body)
+			// The message ID is included in delivery status callbacks of email providers, see package delivery.
+			gm.SetHeader(HeaderMessageID, msg.ID.String())
+			gm.SetHeader("X-SMTPAPI", fmt.Sprintf(`{"unique_args":{"%s":"%s"}}`, SendGridMessageIDArg, msg.ID))
+			if len(msg.TextBody) > 0 {
+				gm.SetBody("text/plain", string(msg.TextBody))
+			} else {
+				gm.SetBody("text/plain", msg.Body)
+			}
 			gm.AddAlternative("text/html", msg.Body)
 
 			if err := m.Dialer.DialAndSend(ctx, gm); err != nil {
@@ -0,0 +1,121 @@
+package delivery
+
+import (
+	"strings"
+
+	"github.com/gofrs/uuid"
+	"github.com/pkg/errors"
+	"github.com/tidwall/gjson"
+
+	"github.com/ory/herodot"
+
+	"github.com/ory/kratos/courier"
+)
+
+// Event is a delivery status change of a message reported by an email provider.
+type Event struct {
+	// MessageID is the ID of the courier message or uuid.Nil if the provider did not report it.
+	MessageID uuid.UUID
+
+	// Recipient is the address the message was sent to.
+	Recipient string
+
+	// Status is the new status of the message.
+	Status courier.MessageStatus
+
+	// Permanent is true if the message bounced permanently and future messages to the recipient will bounce as well.
+	Permanent bool
+}
+
+// parseSNS parses an Amazon SNS message. If the message asks to confirm the subscription, the subscribe URL is
+// returned. Otherwise the SES notification wrapped in the message is parsed.
+func parseSNS(body []byte) (subscribeURL string, events []Event, err error) {
+	if !gjson.ValidBytes(body) {
+		return "", nil, errors.WithStack(herodot.ErrBadRequest.WithReason("The Amazon SNS message is not valid JSON."))
+	}
+
+	envelope := gjson.ParseBytes(body)
+	switch envelope.Get("Type").String() {
+	case "SubscriptionConfirmation":
+		return envelope.Get("SubscribeURL").String(), nil, nil
+	case "Notification":
+		events, err := parseSES([]byte(envelope.Get("Message").String()))
+		return "", events, err
+	}
+
+	return "", nil, nil
+}
+
+// parseSES parses an Amazon SES notification or event publishing record.
+func parseSES(body []byte) ([]Event, error) {
+	if !gjson.ValidBytes(body) {
+		return nil, errors.WithStack(herodot.ErrBadRequest.WithReason("The Amazon SES notification is not valid JSON."))
+	}
+
+	n := gjson.ParseBytes(body)
+	kind := n.Get("notificationType").String()
+	if kind == "" {
+		kind = n.Get("eventType").String()
+	}
+
+	var id uuid.UUID
+	for _, h := range n.Get("mail.headers").Array() {
+		if strings.EqualFold(h.Get("name").String(), courier.HeaderMessageID) {
+			id = uuid.FromStringOrNil(h.Get("value").String())
+		}
+	}
+
+	var events []Event
+	switch kind {
+	case "Delivery":
+		for _, r := range n.Get("delivery.recipients").Array() {
+			events = append(events, Event{MessageID: id, Recipient: r.String(), Status: courier.MessageStatusDelivered})
+		}
+	case "Bounce":
+		permanent := n.Get("bounce.bounceType").String() == "Permanent"
+		for _, r := range n.Get("bounce.bouncedRecipients").Array() {
+			events = append(events, Event{MessageID: id, Recipient: r.Get("emailAddress").String(), Status: courier.MessageStatusBounced, Permanent: permanent})
+		}
+	case "Complaint":
+		for _, r := range n.Get("complaint.complainedRecipients").Array() {
+			events = append(events, Event{MessageID: id, Recipient: r.Get("emailAddress").String(), Status: courier.MessageStatusComplained})
+		}
+	}
+
+	return events, nil
+}
+
+// parseSendGrid parses a batch of SendGrid event webhook events. Events which do not change the delivery status,
+// such as opens and clicks, are skipped.
+func parseSendGrid(body []byte) ([]Event, error) {
+	if !gjson.ValidBytes(body) || !gjson.ParseBytes(body).IsArray() {
+		return nil, errors.WithStack(herodot.ErrBadRequest.WithReason("The SendGrid events are not a valid JSON array."))
+	}
+
+	var events []Event
+	for _, e := range gjson.ParseBytes(body).Array() {
+		event := Event{
+			MessageID: uuid.FromStringOrNil(e.Get(courier.SendGridMessageIDArg).String()),
+			Recipient: e.Get("email").String(),
+		}
+
+		switch e.Get("event").String() {
+		case "delivered":
+			event.Status = courier.MessageStatusDelivered
+		case "bounce":
+			event.Status = courier.MessageStatusBounced
+			// Blocked messages are temporary failures, for example due to the recipient's spam filter.
+			event.Permanent = e.Get("type").String() != "blocked"
+		case "dropped":
+			event.Status = courier.MessageStatusBounced
+		case "spamreport":
+			event.Status = courier.MessageStatusComplained
+		default:
+			continue
+		}
+
+		events = append(events, event)
+	}
+
+	return events, nil
+}
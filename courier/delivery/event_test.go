@@ -0,0 +1,86 @@
+package delivery
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/gofrs/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ory/kratos/courier"
+)
+
+func TestParseSNS(t *testing.T) {
+	id := uuid.Must(uuid.NewV4())
+
+	t.Run("case=subscription confirmation", func(t *testing.T) {
+		u, events, err := parseSNS([]byte(`{"Type":"SubscriptionConfirmation","SubscribeURL":"https://sns.eu-central-1.amazonaws.com/?Action=ConfirmSubscription"}`))
+		require.NoError(t, err)
+		assert.Equal(t, "https://sns.eu-central-1.amazonaws.com/?Action=ConfirmSubscription", u)
+		assert.Empty(t, events)
+	})
+
+	for _, tc := range []struct {
+		d, notification string
+		expected        []Event
+	}{
+		{
+			d:            "delivery",
+			notification: `{"notificationType":"Delivery","mail":{"headers":[{"name":"X-Kratos-Message-Id","value":"` + id.String() + `"}]},"delivery":{"recipients":["foo@ory.sh"]}}`,
+			expected:     []Event{{MessageID: id, Recipient: "foo@ory.sh", Status: courier.MessageStatusDelivered}},
+		},
+		{
+			d:            "permanent bounce",
+			notification: `{"notificationType":"Bounce","bounce":{"bounceType":"Permanent","bouncedRecipients":[{"emailAddress":"foo@ory.sh"}]}}`,
+			expected:     []Event{{Recipient: "foo@ory.sh", Status: courier.MessageStatusBounced, Permanent: true}},
+		},
+		{
+			d:            "transient bounce using event publishing",
+			notification: `{"eventType":"Bounce","bounce":{"bounceType":"Transient","bouncedRecipients":[{"emailAddress":"foo@ory.sh"}]}}`,
+			expected:     []Event{{Recipient: "foo@ory.sh", Status: courier.MessageStatusBounced}},
+		},
+		{
+			d:            "complaint",
+			notification: `{"notificationType":"Complaint","complaint":{"complainedRecipients":[{"emailAddress":"foo@ory.sh"}]}}`,
+			expected:     []Event{{Recipient: "foo@ory.sh", Status: courier.MessageStatusComplained}},
+		},
+	} {
+		t.Run("case="+tc.d, func(t *testing.T) {
+			message, err := json.Marshal(tc.notification)
+			require.NoError(t, err)
+
+			u, events, err := parseSNS([]byte(`{"Type":"Notification","Message":` + string(message) + `}`))
+			require.NoError(t, err)
+			assert.Empty(t, u)
+			assert.Equal(t, tc.expected, events)
+		})
+	}
+
+	t.Run("case=invalid json", func(t *testing.T) {
+		_, _, err := parseSNS([]byte(`{`))
+		require.Error(t, err)
+	})
+}
+
+func TestParseSendGrid(t *testing.T) {
+	id := uuid.Must(uuid.NewV4())
+
+	events, err := parseSendGrid([]byte(`[
+  {"email":"foo@ory.sh","event":"delivered","kratos_message_id":"` + id.String() + `"},
+  {"email":"foo@ory.sh","event":"open"},
+  {"email":"bar@ory.sh","event":"bounce","type":"bounce"},
+  {"email":"baz@ory.sh","event":"bounce","type":"blocked"},
+  {"email":"qux@ory.sh","event":"spamreport"}
+]`))
+	require.NoError(t, err)
+	assert.Equal(t, []Event{
+		{MessageID: id, Recipient: "foo@ory.sh", Status: courier.MessageStatusDelivered},
+		{Recipient: "bar@ory.sh", Status: courier.MessageStatusBounced, Permanent: true},
+		{Recipient: "baz@ory.sh", Status: courier.MessageStatusBounced},
+		{Recipient: "qux@ory.sh", Status: courier.MessageStatusComplained},
+	}, events)
+
+	_, err = parseSendGrid([]byte(`{"event":"delivered"}`))
+	require.Error(t, err)
+}
@@ -0,0 +1,435 @@
+package delivery
+
+import (
+	"context"
+	"crypto/subtle"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/gofrs/uuid"
+	"github.com/hashicorp/go-retryablehttp"
+	"github.com/julienschmidt/httprouter"
+	"github.com/pkg/errors"
+
+	"github.com/ory/herodot"
+	"github.com/ory/x/jsonx"
+	"github.com/ory/x/sqlcon"
+	"github.com/ory/x/urlx"
+
+	"github.com/ory/kratos/courier"
+	"github.com/ory/kratos/driver/config"
+	"github.com/ory/kratos/identity"
+	"github.com/ory/kratos/x"
+)
+
+const (
+	RouteSES      = "/courier/webhooks/ses"
+	RouteSendGrid = "/courier/webhooks/sendgrid"
+
+	RouteSuppressions = "/courier/suppressions"
+)
+
+type (
+	handlerDependencies interface {
+		config.Provider
+		courier.PersistenceProvider
+		identity.PrivilegedPoolProvider
+		x.CSRFProvider
+		x.LoggingProvider
+		x.WriterProvider
+	}
+	HandlerProvider interface {
+		CourierDeliveryHandler() *Handler
+	}
+	// Handler receives delivery status callbacks from email providers and updates the status of courier messages.
+	// It also serves the admin endpoints of the suppression list.
+	Handler struct {
+		d  handlerDependencies
+		hc *retryablehttp.Client
+	}
+)
+
+func NewHandler(d handlerDependencies) *Handler {
+	return &Handler{d: d, hc: x.NewEgressHTTPClient(d, "")}
+}
+
+func (h *Handler) RegisterPublicRoutes(public *x.RouterPublic) {
+	h.d.CSRFHandler().ExemptPath(RouteSES)
+	h.d.CSRFHandler().ExemptPath(RouteSendGrid)
+
+	public.POST(RouteSES, h.authenticated(h.ses))
+	public.POST(RouteSendGrid, h.authenticated(h.sendGrid))
+}
+
+func (h *Handler) RegisterAdminRoutes(admin *x.RouterAdmin) {
+	admin.GET(RouteSuppressions, h.listSuppressions)
+	admin.GET(RouteSuppressions+"/:address", h.getSuppression)
+	admin.POST(RouteSuppressions, h.createSuppression)
+	admin.DELETE(RouteSuppressions+"/:address", h.deleteSuppression)
+}
+
+// authenticated responds with 404 if no secret is configured and with 401 if the request does not contain the
+// secret as the `secret` query parameter or the basic auth password.
+func (h *Handler) authenticated(wrap httprouter.Handle) httprouter.Handle {
+	return func(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+		secret := h.d.Config(r.Context()).CourierDeliveryWebhookSecret()
+		if len(secret) == 0 {
+			h.d.Writer().WriteError(w, r, errors.WithStack(herodot.ErrNotFound.WithReason("Delivery status webhooks are disabled.")))
+			return
+		}
+
+		given := r.URL.Query().Get("secret")
+		if _, password, ok := r.BasicAuth(); ok {
+			given = password
+		}
+
+		if subtle.ConstantTimeCompare([]byte(given), []byte(secret)) != 1 {
+			h.d.Writer().WriteError(w, r, errors.WithStack(herodot.ErrUnauthorized.WithReason("The delivery status webhook secret is invalid.")))
+			return
+		}
+
+		wrap(w, r, ps)
+	}
+}
+
+func (h *Handler) ses(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+	body, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		h.d.Writer().WriteError(w, r, errors.WithStack(err))
+		return
+	}
+
+	subscribeURL, events, err := parseSNS(body)
+	if err != nil {
+		h.d.Writer().WriteError(w, r, err)
+		return
+	}
+
+	if len(subscribeURL) > 0 {
+		if err := h.confirmSubscription(r.Context(), subscribeURL); err != nil {
+			h.d.Writer().WriteError(w, r, err)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	h.apply(w, r, events)
+}
+
+func (h *Handler) sendGrid(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+	body, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		h.d.Writer().WriteError(w, r, errors.WithStack(err))
+		return
+	}
+
+	events, err := parseSendGrid(body)
+	if err != nil {
+		h.d.Writer().WriteError(w, r, err)
+		return
+	}
+
+	h.apply(w, r, events)
+}
+
+// confirmSubscription confirms the subscription of the SNS topic. Only Amazon SNS URLs are requested.
+func (h *Handler) confirmSubscription(ctx context.Context, subscribeURL string) error {
+	u, err := url.Parse(subscribeURL)
+	if err != nil || u.Scheme != "https" || !strings.HasPrefix(u.Hostname(), "sns.") || !strings.HasSuffix(u.Hostname(), ".amazonaws.com") {
+		return errors.WithStack(herodot.ErrBadRequest.WithReason("The Amazon SNS subscribe URL is invalid."))
+	}
+
+	req, err := retryablehttp.NewRequest("GET", u.String(), nil)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	res, err := h.hc.Do(req.WithContext(ctx))
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return errors.WithStack(herodot.ErrBadRequest.WithReasonf("Amazon SNS responded with status %d when confirming the subscription.", res.StatusCode))
+	}
+
+	h.d.Logger().WithField("topic_arn", u.Query().Get("TopicArn")).Info("Confirmed the Amazon SNS subscription for delivery status notifications.")
+	return nil
+}
+
+func (h *Handler) apply(w http.ResponseWriter, r *http.Request, events []Event) {
+	for _, e := range events {
+		if err := h.applyEvent(r.Context(), e); err != nil {
+			h.d.Writer().WriteError(w, r, err)
+			return
+		}
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// applyEvent updates the status of the message. If the provider did not report the message ID, the most recent
+// message to the recipient is updated. Events for unknown messages are ignored because other applications may
+// share the email provider account.
+func (h *Handler) applyEvent(ctx context.Context, e Event) error {
+	id := e.MessageID
+	if id == uuid.Nil {
+		m, err := h.d.CourierPersister().LatestMessageByRecipient(ctx, e.Recipient)
+		if errors.Is(err, sqlcon.ErrNoRows) {
+			return nil
+		} else if err != nil {
+			return err
+		}
+		id = m.ID
+	}
+
+	if err := h.d.CourierPersister().SetMessageStatus(ctx, id, e.Status); errors.Is(err, sqlcon.ErrNoRows) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	h.d.Logger().
+		WithField("message_id", id).
+		WithField("message_status", e.Status).
+		Debug("Updated the courier message status reported by the email provider.")
+
+	if !e.Permanent {
+		return nil
+	}
+
+	if err := h.d.CourierPersister().AddSuppression(ctx, &courier.Suppression{
+		Address: e.Recipient,
+		Reason:  courier.SuppressionReasonBounce,
+	}); err != nil && !errors.Is(err, sqlcon.ErrUniqueViolation) {
+		return err
+	}
+
+	if h.d.Config(ctx).CourierDeliveryWebhookMarkUndeliverable() {
+		return h.markUndeliverable(ctx, e.Recipient)
+	}
+	return nil
+}
+
+func (h *Handler) markUndeliverable(ctx context.Context, recipient string) error {
+	address, err := h.d.PrivilegedIdentityPool().FindVerifiableAddressByValue(ctx, identity.VerifiableAddressTypeEmail, recipient)
+	if errors.Is(err, sqlcon.ErrNoRows) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	address.Status = identity.VerifiableAddressStatusUndeliverable
+	if err := h.d.PrivilegedIdentityPool().UpdateVerifiableAddress(ctx, address); err != nil {
+		return err
+	}
+
+	h.d.Audit().
+		WithField("identity_id", address.IdentityID).
+		WithSensitiveField("email_address", address.Value).
+		Info("Marked a verifiable address as undeliverable because it bounced permanently.")
+	return nil
+}
+
+// A list of suppressed addresses.
+// swagger:response courierSuppressionList
+// nolint:deadcode,unused
+type suppressionListResponse struct {
+	// in: body
+	// required: true
+	// type: array
+	Body []courier.Suppression
+}
+
+// A suppressed address.
+// swagger:response courierSuppressionResponse
+// nolint:deadcode,unused
+type suppressionResponse struct {
+	// in: body
+	// required: true
+	Body *courier.Suppression
+}
+
+// swagger:parameters listCourierSuppressions
+// nolint:deadcode,unused
+type listSuppressionsParameters struct {
+	// Items per Page
+	//
+	// This is the number of items per page.
+	//
+	// required: false
+	// in: query
+	// default: 100
+	// min: 1
+	// max: 500
+	PerPage int `json:"per_page"`
+
+	// Pagination Page
+	//
+	// required: false
+	// in: query
+	// default: 0
+	// min: 0
+	Page int `json:"page"`
+}
+
+// swagger:route GET /courier/suppressions admin listCourierSuppressions
+//
+// List Suppressed Addresses
+//
+// Lists the addresses the courier does not send messages to. Addresses are suppressed by administrators
+// or automatically when they bounce permanently.
+//
+//     Produces:
+//     - application/json
+//
+//     Schemes: http, https
+//
+//     Responses:
+//       200: courierSuppressionList
+//       500: genericError
+func (h *Handler) listSuppressions(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+	page, itemsPerPage := x.ParsePagination(r)
+	s, err := h.d.CourierPersister().ListSuppressions(r.Context(), page, itemsPerPage)
+	if err != nil {
+		h.d.Writer().WriteError(w, r, err)
+		return
+	}
+
+	total, err := h.d.CourierPersister().CountSuppressions(r.Context())
+	if err != nil {
+		h.d.Writer().WriteError(w, r, err)
+		return
+	}
+
+	x.PaginationHeader(w, urlx.AppendPaths(h.d.Config(r.Context()).SelfAdminURL(), RouteSuppressions), total, page, itemsPerPage)
+	h.d.Writer().Write(w, r, s)
+}
+
+// swagger:parameters getCourierSuppression deleteCourierSuppression
+// nolint:deadcode,unused
+type suppressionParameters struct {
+	// Address is the suppressed email address.
+	//
+	// required: true
+	// in: path
+	Address string `json:"address"`
+}
+
+// swagger:route GET /courier/suppressions/{address} admin getCourierSuppression
+//
+// Get a Suppressed Address
+//
+// Returns the suppression list entry of the address or 404 if the address is not suppressed.
+//
+//     Produces:
+//     - application/json
+//
+//     Schemes: http, https
+//
+//     Responses:
+//       200: courierSuppressionResponse
+//       404: genericError
+//       500: genericError
+func (h *Handler) getSuppression(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	s, err := h.d.CourierPersister().GetSuppression(r.Context(), ps.ByName("address"))
+	if err != nil {
+		h.d.Writer().WriteError(w, r, err)
+		return
+	}
+
+	h.d.Writer().Write(w, r, s)
+}
+
+// CreateSuppression is the request payload of suppressing an address.
+//
+// swagger:model createCourierSuppression
+type CreateSuppression struct {
+	// Address is the email address to suppress.
+	//
+	// required: true
+	Address string `json:"address"`
+}
+
+// swagger:parameters createCourierSuppression
+// nolint:deadcode,unused
+type createSuppressionParameters struct {
+	// in: body
+	Body CreateSuppression
+}
+
+// swagger:route POST /courier/suppressions admin createCourierSuppression
+//
+// Suppress an Address
+//
+// Adds the address to the suppression list. The courier does not send messages to suppressed addresses.
+//
+//     Consumes:
+//     - application/json
+//
+//     Produces:
+//     - application/json
+//
+//     Schemes: http, https
+//
+//     Responses:
+//       201: courierSuppressionResponse
+//       400: genericError
+//       409: genericError
+//       500: genericError
+func (h *Handler) createSuppression(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+	var cs CreateSuppression
+	if err := jsonx.NewStrictDecoder(r.Body).Decode(&cs); err != nil {
+		h.d.Writer().WriteErrorCode(w, r, http.StatusBadRequest, errors.WithStack(err))
+		return
+	}
+
+	if len(courier.NormalizeSuppressedAddress(cs.Address)) == 0 {
+		h.d.Writer().WriteError(w, r, errors.WithStack(herodot.ErrBadRequest.WithReason("The address must not be empty.")))
+		return
+	}
+
+	s := &courier.Suppression{Address: cs.Address, Reason: courier.SuppressionReasonManual}
+	if err := h.d.CourierPersister().AddSuppression(r.Context(), s); err != nil {
+		h.d.Writer().WriteError(w, r, err)
+		return
+	}
+
+	h.d.Writer().WriteCreated(w, r,
+		urlx.AppendPaths(
+			h.d.Config(r.Context()).SelfAdminURL(),
+			RouteSuppressions,
+			url.PathEscape(s.Address),
+		).String(),
+		s,
+	)
+}
+
+// swagger:route DELETE /courier/suppressions/{address} admin deleteCourierSuppression
+//
+// Remove a Suppressed Address
+//
+// Removes the address from the suppression list so that the courier sends messages to it again. The verification
+// status of the address is not changed.
+//
+//     Produces:
+//     - application/json
+//
+//     Schemes: http, https
+//
+//     Responses:
+//       204: emptyResponse
+//       404: genericError
+//       500: genericError
+func (h *Handler) deleteSuppression(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	if err := h.d.CourierPersister().DeleteSuppression(r.Context(), ps.ByName("address")); err != nil {
+		h.d.Writer().WriteError(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,170 @@
+package delivery_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tidwall/gjson"
+
+	"github.com/ory/kratos/courier"
+	"github.com/ory/kratos/courier/delivery"
+	"github.com/ory/kratos/driver/config"
+	"github.com/ory/kratos/identity"
+	"github.com/ory/kratos/internal"
+	"github.com/ory/kratos/x"
+)
+
+func TestHandler(t *testing.T) {
+	const secret = "a-very-secret-webhook-secret"
+	conf, reg := internal.NewFastRegistryWithMocks(t)
+	conf.MustSet(config.ViperKeyDefaultIdentitySchemaURL, "file://./stub/default.schema.json")
+
+	router := x.NewRouterPublic()
+	reg.CourierDeliveryHandler().RegisterPublicRoutes(router)
+	ts := httptest.NewServer(router)
+	t.Cleanup(ts.Close)
+
+	post := func(t *testing.T, path, body string) *http.Response {
+		res, err := ts.Client().Post(ts.URL+path, "application/json", bytes.NewBufferString(body))
+		require.NoError(t, err)
+		defer res.Body.Close()
+		return res
+	}
+
+	newMessage := func(t *testing.T, recipient string) *courier.Message {
+		m := &courier.Message{ID: x.NewUUID(), Type: courier.MessageTypeEmail, Recipient: recipient, Subject: "foo", Body: "bar"}
+		require.NoError(t, reg.CourierPersister().AddMessage(context.Background(), m))
+		require.NoError(t, reg.CourierPersister().SetMessageStatus(context.Background(), m.ID, courier.MessageStatusSent))
+		return m
+	}
+
+	status := func(t *testing.T, recipient string) courier.MessageStatus {
+		m, err := reg.CourierPersister().LatestMessageByRecipient(context.Background(), recipient)
+		require.NoError(t, err)
+		return m.Status
+	}
+
+	t.Run("case=disabled without secret", func(t *testing.T) {
+		assert.Equal(t, http.StatusNotFound, post(t, delivery.RouteSendGrid, `[]`).StatusCode)
+	})
+
+	conf.MustSet(config.ViperKeyCourierDeliveryWebhookSecret, secret)
+
+	t.Run("case=rejects invalid secret", func(t *testing.T) {
+		assert.Equal(t, http.StatusUnauthorized, post(t, delivery.RouteSendGrid, `[]`).StatusCode)
+		assert.Equal(t, http.StatusUnauthorized, post(t, delivery.RouteSendGrid+"?secret=wrong", `[]`).StatusCode)
+	})
+
+	t.Run("case=sendgrid bounce marks address undeliverable", func(t *testing.T) {
+		conf.MustSet(config.ViperKeyCourierDeliveryWebhookMarkUndeliverable, true)
+		t.Cleanup(func() {
+			conf.MustSet(config.ViperKeyCourierDeliveryWebhookMarkUndeliverable, false)
+		})
+
+		i := identity.NewIdentity(config.DefaultIdentityTraitsSchemaID)
+		i.Traits = identity.Traits(`{"email":"bounce@ory.sh"}`)
+		require.NoError(t, reg.IdentityManager().Create(context.Background(), i))
+
+		m := newMessage(t, "bounce@ory.sh")
+		res := post(t, delivery.RouteSendGrid+"?secret="+secret,
+			`[{"email":"bounce@ory.sh","event":"bounce","type":"bounce","kratos_message_id":"`+m.ID.String()+`"}]`)
+		require.Equal(t, http.StatusNoContent, res.StatusCode)
+		assert.Equal(t, courier.MessageStatusBounced, status(t, "bounce@ory.sh"))
+
+		address, err := reg.IdentityPool().FindVerifiableAddressByValue(context.Background(), identity.VerifiableAddressTypeEmail, "bounce@ory.sh")
+		require.NoError(t, err)
+		assert.Equal(t, identity.VerifiableAddressStatusUndeliverable, address.Status)
+
+		suppression, err := reg.CourierPersister().GetSuppression(context.Background(), "bounce@ory.sh")
+		require.NoError(t, err)
+		assert.Equal(t, courier.SuppressionReasonBounce, suppression.Reason)
+	})
+
+	t.Run("case=ses delivery falls back to the recipient", func(t *testing.T) {
+		newMessage(t, "delivered@ory.sh")
+
+		notification, err := json.Marshal(`{"notificationType":"Delivery","delivery":{"recipients":["delivered@ory.sh"]}}`)
+		require.NoError(t, err)
+
+		req, err := http.NewRequest("POST", ts.URL+delivery.RouteSES, bytes.NewBufferString(`{"Type":"Notification","Message":`+string(notification)+`}`))
+		require.NoError(t, err)
+		req.SetBasicAuth("sns", secret)
+		res, err := ts.Client().Do(req)
+		require.NoError(t, err)
+		defer res.Body.Close()
+
+		require.Equal(t, http.StatusNoContent, res.StatusCode)
+		assert.Equal(t, courier.MessageStatusDelivered, status(t, "delivered@ory.sh"))
+	})
+
+	t.Run("case=ignores unknown messages", func(t *testing.T) {
+		res := post(t, delivery.RouteSendGrid+"?secret="+secret, `[{"email":"unknown@ory.sh","event":"delivered","kratos_message_id":"`+x.NewUUID().String()+`"}]`)
+		assert.Equal(t, http.StatusNoContent, res.StatusCode)
+	})
+
+	t.Run("case=rejects foreign subscribe urls", func(t *testing.T) {
+		res := post(t, delivery.RouteSES+"?secret="+secret, `{"Type":"SubscriptionConfirmation","SubscribeURL":"https://attacker.example.org/"}`)
+		assert.Equal(t, http.StatusBadRequest, res.StatusCode)
+	})
+}
+
+func TestSuppressionHandler(t *testing.T) {
+	_, reg := internal.NewFastRegistryWithMocks(t)
+
+	router := x.NewRouterAdmin()
+	reg.CourierDeliveryHandler().RegisterAdminRoutes(router)
+	ts := httptest.NewServer(router)
+	t.Cleanup(ts.Close)
+
+	do := func(t *testing.T, method, path, body string) (*http.Response, []byte) {
+		req, err := http.NewRequest(method, ts.URL+path, bytes.NewBufferString(body))
+		require.NoError(t, err)
+		res, err := ts.Client().Do(req)
+		require.NoError(t, err)
+		defer res.Body.Close()
+		payload, err := ioutil.ReadAll(res.Body)
+		require.NoError(t, err)
+		return res, payload
+	}
+
+	t.Run("case=suppress an address", func(t *testing.T) {
+		res, body := do(t, "POST", delivery.RouteSuppressions, `{"address":"Manual@ory.sh"}`)
+		require.Equal(t, http.StatusCreated, res.StatusCode, "%s", body)
+		assert.Equal(t, "manual@ory.sh", gjson.GetBytes(body, "address").String())
+		assert.Equal(t, "manual", gjson.GetBytes(body, "reason").String())
+
+		res, _ = do(t, "POST", delivery.RouteSuppressions, `{"address":"manual@ory.sh"}`)
+		assert.Equal(t, http.StatusConflict, res.StatusCode)
+
+		res, _ = do(t, "POST", delivery.RouteSuppressions, `{"address":" "}`)
+		assert.Equal(t, http.StatusBadRequest, res.StatusCode)
+	})
+
+	t.Run("case=query suppressed addresses", func(t *testing.T) {
+		res, body := do(t, "GET", delivery.RouteSuppressions+"/manual@ory.sh", "")
+		require.Equal(t, http.StatusOK, res.StatusCode, "%s", body)
+		assert.Equal(t, "manual@ory.sh", gjson.GetBytes(body, "address").String())
+
+		res, body = do(t, "GET", delivery.RouteSuppressions, "")
+		require.Equal(t, http.StatusOK, res.StatusCode, "%s", body)
+		assert.EqualValues(t, 1, gjson.GetBytes(body, "#").Int())
+	})
+
+	t.Run("case=remove a suppressed address", func(t *testing.T) {
+		res, _ := do(t, "DELETE", delivery.RouteSuppressions+"/manual@ory.sh", "")
+		assert.Equal(t, http.StatusNoContent, res.StatusCode)
+
+		res, _ = do(t, "DELETE", delivery.RouteSuppressions+"/manual@ory.sh", "")
+		assert.Equal(t, http.StatusNotFound, res.StatusCode)
+
+		res, _ = do(t, "GET", delivery.RouteSuppressions+"/manual@ory.sh", "")
+		assert.Equal(t, http.StatusNotFound, res.StatusCode)
+	})
+}
@@ -0,0 +1,28 @@
+package courier
+
+import (
+	"time"
+
+	"github.com/ory/kratos/driver/config"
+)
+
+// nextSendingTime returns the time the sending window opens next or now if the window is open.
+func nextSendingTime(w *config.CourierSendingWindow, now time.Time, loc *time.Location) time.Time {
+	if w == nil || w.Start == w.End {
+		return now
+	}
+
+	local := now.In(loc)
+	hour := local.Hour()
+	if w.Start < w.End && hour >= w.Start && hour < w.End {
+		return now
+	} else if w.Start > w.End && (hour >= w.Start || hour < w.End) {
+		return now
+	}
+
+	next := time.Date(local.Year(), local.Month(), local.Day(), w.Start, 0, 0, 0, loc)
+	if !next.After(local) {
+		next = time.Date(local.Year(), local.Month(), local.Day()+1, w.Start, 0, 0, 0, loc)
+	}
+	return next
+}
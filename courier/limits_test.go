@@ -0,0 +1,45 @@
+package courier
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ory/kratos/driver/config"
+)
+
+func TestNextSendingTime(t *testing.T) {
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	require.NoError(t, err)
+
+	at := func(loc *time.Location, day, hour, minute int) time.Time {
+		return time.Date(2021, 4, day, hour, minute, 0, 0, loc)
+	}
+
+	for k, tc := range []struct {
+		w        *config.CourierSendingWindow
+		loc      *time.Location
+		now      time.Time
+		expected time.Time
+	}{
+		{w: nil, loc: time.UTC, now: at(time.UTC, 1, 3, 0), expected: at(time.UTC, 1, 3, 0)},
+		{w: &config.CourierSendingWindow{Start: 8, End: 8}, loc: time.UTC, now: at(time.UTC, 1, 3, 0), expected: at(time.UTC, 1, 3, 0)},
+		{w: &config.CourierSendingWindow{Start: 8, End: 20}, loc: time.UTC, now: at(time.UTC, 1, 8, 0), expected: at(time.UTC, 1, 8, 0)},
+		{w: &config.CourierSendingWindow{Start: 8, End: 20}, loc: time.UTC, now: at(time.UTC, 1, 19, 59), expected: at(time.UTC, 1, 19, 59)},
+		{w: &config.CourierSendingWindow{Start: 8, End: 20}, loc: time.UTC, now: at(time.UTC, 1, 3, 0), expected: at(time.UTC, 1, 8, 0)},
+		{w: &config.CourierSendingWindow{Start: 8, End: 20}, loc: time.UTC, now: at(time.UTC, 1, 20, 0), expected: at(time.UTC, 2, 8, 0)},
+		{w: &config.CourierSendingWindow{Start: 22, End: 6}, loc: time.UTC, now: at(time.UTC, 1, 23, 0), expected: at(time.UTC, 1, 23, 0)},
+		{w: &config.CourierSendingWindow{Start: 22, End: 6}, loc: time.UTC, now: at(time.UTC, 1, 5, 0), expected: at(time.UTC, 1, 5, 0)},
+		{w: &config.CourierSendingWindow{Start: 22, End: 6}, loc: time.UTC, now: at(time.UTC, 1, 12, 0), expected: at(time.UTC, 1, 22, 0)},
+		// 06:00 UTC is 08:00 in Berlin during daylight saving time.
+		{w: &config.CourierSendingWindow{Start: 9, End: 17}, loc: berlin, now: at(time.UTC, 1, 6, 0), expected: at(berlin, 1, 9, 0)},
+		{w: &config.CourierSendingWindow{Start: 9, End: 17}, loc: berlin, now: at(time.UTC, 1, 7, 0), expected: at(time.UTC, 1, 7, 0)},
+	} {
+		t.Run(fmt.Sprintf("case=%d", k), func(t *testing.T) {
+			assert.True(t, tc.expected.Equal(nextSendingTime(tc.w, tc.now, tc.loc)), "%s", nextSendingTime(tc.w, tc.now, tc.loc))
+		})
+	}
+}
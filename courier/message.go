@@ -7,6 +7,8 @@ import (
 	"github.com/ory/kratos/corp"
 
 	"github.com/gofrs/uuid"
+
+	"github.com/ory/x/sqlxx"
 )
 
 type MessageStatus int
@@ -15,6 +17,17 @@ const (
 	MessageStatusQueued MessageStatus = iota + 1
 	MessageStatusSent
 	MessageStatusProcessing
+	// MessageStatusDelivered is set when the email provider reports that the message was delivered.
+	MessageStatusDelivered
+	// MessageStatusBounced is set when the email provider reports that the message bounced.
+	MessageStatusBounced
+	// MessageStatusComplained is set when the email provider reports that the recipient marked the message as spam.
+	MessageStatusComplained
+	// MessageStatusSuppressed is set when the message was not sent because the recipient is on the suppression list.
+	MessageStatusSuppressed
+	// MessageStatusCapped is set when the message was not sent because the recipient already received the maximum
+	// number of messages of the template within 24 hours.
+	MessageStatusCapped
 )
 
 type MessageType int
@@ -30,6 +43,13 @@ type Message struct {
 	Recipient string        `json:"-" db:"recipient"`
 	Body      string        `json:"-" db:"body"`
 	Subject   string        `json:"-" db:"subject"`
+	// TextBody is the plaintext alternative of the HTML body. It is empty for messages queued before
+	// plaintext bodies were introduced.
+	TextBody sqlxx.NullString `json:"-" db:"text_body"`
+	// TemplateType is the type of the template the message was rendered from or empty if the template has no type.
+	TemplateType string `json:"-" db:"template_type"`
+	// SendAfter is set if the message was deferred until the sending window of its template opens.
+	SendAfter sqlxx.NullTime `json:"-" faker:"-" db:"send_after"`
 
 	// CreatedAt is a helper struct field for gobuffalo.pop.
 	CreatedAt time.Time `json:"-" faker:"-" db:"created_at"`
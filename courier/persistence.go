@@ -12,6 +12,10 @@ import (
 	"github.com/stretchr/testify/require"
 
 	"github.com/gofrs/uuid"
+
+	"github.com/ory/x/sqlcon"
+
+	"github.com/ory/kratos/x"
 )
 
 var ErrQueueEmpty = errors.New("queue is empty")
@@ -25,6 +29,37 @@ type (
 		SetMessageStatus(context.Context, uuid.UUID, MessageStatus) error
 
 		LatestQueuedMessage(ctx context.Context) (*Message, error)
+
+		// FindIdenticalMessage returns the most recent message with the same type, recipient, subject, and body
+		// which was created after since or sqlcon.ErrNoRows if there is none.
+		FindIdenticalMessage(ctx context.Context, m *Message, since time.Time) (*Message, error)
+
+		// LatestMessageByRecipient returns the most recent message to the recipient or sqlcon.ErrNoRows if there
+		// is none.
+		LatestMessageByRecipient(ctx context.Context, recipient string) (*Message, error)
+
+		// CountSentMessages returns the number of messages of the template type which were sent to the recipient
+		// and created after since.
+		CountSentMessages(ctx context.Context, recipient, templateType string, since time.Time) (int, error)
+
+		// DeferMessage puts the message back into the queue. It is not returned by NextMessages before until.
+		DeferMessage(ctx context.Context, id uuid.UUID, until time.Time) error
+
+		// AddSuppression adds the address to the suppression list. If the address is already suppressed,
+		// sqlcon.ErrUniqueViolation is returned.
+		AddSuppression(ctx context.Context, s *Suppression) error
+
+		// GetSuppression returns the suppression list entry of the address or sqlcon.ErrNoRows if the
+		// address is not suppressed.
+		GetSuppression(ctx context.Context, address string) (*Suppression, error)
+
+		ListSuppressions(ctx context.Context, page, itemsPerPage int) ([]Suppression, error)
+
+		CountSuppressions(ctx context.Context) (int64, error)
+
+		// DeleteSuppression removes the address from the suppression list or returns sqlcon.ErrNoRows if the
+		// address is not suppressed.
+		DeleteSuppression(ctx context.Context, address string) error
 	}
 
 	PersistenceProvider interface {
@@ -83,6 +118,33 @@ func TestPersister(ctx context.Context, p Persister) func(t *testing.T) {
 			require.EqualError(t, err, ErrQueueEmpty.Error())
 		})
 
+		t.Run("case=find identical message", func(t *testing.T) {
+			expected := messages[len(messages)-1]
+			m := expected
+			m.ID = uuid.Nil
+
+			actual, err := p.FindIdenticalMessage(ctx, &m, time.Now().Add(-time.Hour))
+			require.NoError(t, err)
+			assert.Equal(t, expected.ID, actual.ID)
+
+			_, err = p.FindIdenticalMessage(ctx, &m, time.Now().Add(time.Hour))
+			require.ErrorIs(t, err, sqlcon.ErrNoRows)
+
+			m.Body = m.Body + " changed"
+			_, err = p.FindIdenticalMessage(ctx, &m, time.Now().Add(-time.Hour))
+			require.ErrorIs(t, err, sqlcon.ErrNoRows)
+		})
+
+		t.Run("case=latest message by recipient", func(t *testing.T) {
+			expected := messages[len(messages)-1]
+			actual, err := p.LatestMessageByRecipient(ctx, expected.Recipient)
+			require.NoError(t, err)
+			assert.Equal(t, expected.ID, actual.ID)
+
+			_, err = p.LatestMessageByRecipient(ctx, "unknown-recipient@ory.sh")
+			require.ErrorIs(t, err, sqlcon.ErrNoRows)
+		})
+
 		t.Run("case=setting message status", func(t *testing.T) {
 			require.NoError(t, p.SetMessageStatus(ctx, messages[0].ID, MessageStatusQueued))
 			ms, err := p.NextMessages(ctx, 1)
@@ -94,5 +156,72 @@ func TestPersister(ctx context.Context, p Persister) func(t *testing.T) {
 			_, err = p.NextMessages(ctx, 1)
 			require.EqualError(t, err, ErrQueueEmpty.Error())
 		})
+
+		t.Run("case=count sent messages", func(t *testing.T) {
+			m := Message{Type: MessageTypeEmail, Recipient: "capped@ory.sh", TemplateType: "verification_valid", Subject: "foo", Body: "bar"}
+			require.NoError(t, p.AddMessage(ctx, &m))
+
+			since := time.Now().Add(-time.Hour)
+			count, err := p.CountSentMessages(ctx, m.Recipient, m.TemplateType, since)
+			require.NoError(t, err)
+			assert.Equal(t, 0, count)
+
+			require.NoError(t, p.SetMessageStatus(ctx, m.ID, MessageStatusSent))
+			count, err = p.CountSentMessages(ctx, m.Recipient, m.TemplateType, since)
+			require.NoError(t, err)
+			assert.Equal(t, 1, count)
+
+			count, err = p.CountSentMessages(ctx, m.Recipient, "recovery_valid", since)
+			require.NoError(t, err)
+			assert.Equal(t, 0, count)
+
+			count, err = p.CountSentMessages(ctx, m.Recipient, m.TemplateType, time.Now().Add(time.Hour))
+			require.NoError(t, err)
+			assert.Equal(t, 0, count)
+		})
+
+		t.Run("case=defer message", func(t *testing.T) {
+			m := Message{Type: MessageTypeEmail, Recipient: "deferred@ory.sh", Subject: "foo", Body: "bar"}
+			require.NoError(t, p.AddMessage(ctx, &m))
+
+			require.NoError(t, p.DeferMessage(ctx, m.ID, time.Now().Add(time.Hour)))
+			_, err := p.NextMessages(ctx, 10)
+			require.ErrorIs(t, err, ErrQueueEmpty)
+
+			require.NoError(t, p.DeferMessage(ctx, m.ID, time.Now().Add(-time.Minute)))
+			ms, err := p.NextMessages(ctx, 10)
+			require.NoError(t, err)
+			require.Len(t, ms, 1)
+			assert.Equal(t, m.ID, ms[0].ID)
+
+			require.ErrorIs(t, p.DeferMessage(ctx, x.NewUUID(), time.Now()), sqlcon.ErrNoRows)
+		})
+
+		t.Run("case=suppression list", func(t *testing.T) {
+			_, err := p.GetSuppression(ctx, "suppressed@ory.sh")
+			require.ErrorIs(t, err, sqlcon.ErrNoRows)
+
+			require.NoError(t, p.AddSuppression(ctx, &Suppression{Address: " Suppressed@ORY.sh", Reason: SuppressionReasonBounce}))
+			require.ErrorIs(t, p.AddSuppression(ctx, &Suppression{Address: "suppressed@ory.sh", Reason: SuppressionReasonManual}), sqlcon.ErrUniqueViolation)
+			require.NoError(t, p.AddSuppression(ctx, &Suppression{Address: "another-suppressed@ory.sh", Reason: SuppressionReasonManual}))
+
+			actual, err := p.GetSuppression(ctx, "SUPPRESSED@ory.sh")
+			require.NoError(t, err)
+			assert.Equal(t, "suppressed@ory.sh", actual.Address)
+			assert.Equal(t, SuppressionReasonBounce, actual.Reason)
+
+			count, err := p.CountSuppressions(ctx)
+			require.NoError(t, err)
+			assert.EqualValues(t, 2, count)
+
+			list, err := p.ListSuppressions(ctx, 0, 1)
+			require.NoError(t, err)
+			assert.Len(t, list, 1)
+
+			require.NoError(t, p.DeleteSuppression(ctx, "Suppressed@ory.sh"))
+			require.ErrorIs(t, p.DeleteSuppression(ctx, "suppressed@ory.sh"), sqlcon.ErrNoRows)
+			_, err = p.GetSuppression(ctx, "suppressed@ory.sh")
+			require.ErrorIs(t, err, sqlcon.ErrNoRows)
+		})
 	}
 }
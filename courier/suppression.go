@@ -0,0 +1,52 @@
+package courier
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/gofrs/uuid"
+
+	"github.com/ory/kratos/corp"
+)
+
+// SuppressionReason explains why an address was added to the suppression list.
+type SuppressionReason string
+
+const (
+	// SuppressionReasonManual is used for addresses added by an administrator.
+	SuppressionReasonManual SuppressionReason = "manual"
+	// SuppressionReasonBounce is used for addresses which bounced permanently.
+	SuppressionReasonBounce SuppressionReason = "bounce"
+)
+
+// A suppressed address. The courier does not send messages to suppressed addresses.
+//
+// swagger:model courierSuppression
+type Suppression struct {
+	ID uuid.UUID `json:"-" faker:"-" db:"id"`
+
+	// Address is the suppressed email address.
+	//
+	// required: true
+	Address string `json:"address" db:"address"`
+
+	// Reason is either `manual` or `bounce`.
+	//
+	// required: true
+	Reason SuppressionReason `json:"reason" db:"reason"`
+
+	// CreatedAt is the time the address was suppressed.
+	CreatedAt time.Time `json:"created_at" faker:"-" db:"created_at"`
+	// UpdatedAt is a helper struct field for gobuffalo.pop.
+	UpdatedAt time.Time `json:"-" faker:"-" db:"updated_at"`
+}
+
+func (s Suppression) TableName(ctx context.Context) string {
+	return corp.ContextualizeTableName(ctx, "courier_suppressions")
+}
+
+// NormalizeSuppressedAddress returns the address in the form stored in the suppression list.
+func NormalizeSuppressedAddress(address string) string {
+	return strings.ToLower(strings.TrimSpace(address))
+}
@@ -0,0 +1,64 @@
+package template
+
+import (
+	"path/filepath"
+	"time"
+
+	"github.com/ory/kratos/driver/config"
+)
+
+type (
+	DormancyNotification struct {
+		c *config.Config
+		m *DormancyNotificationModel
+	}
+	DormancyNotificationModel struct {
+		To           string
+		LastActiveAt time.Time
+		// DeactivateAt is zero if dormant identities are not deactivated.
+		DeactivateAt time.Time
+		// DeleteAt is zero if dormant identities are not deleted.
+		DeleteAt time.Time
+		Locale   string
+		// Identity is the identity the message is sent to, for example `.Identity.traits.email`.
+		Identity map[string]interface{}
+		// Extra contains the values returned by the template model enricher, see courier.template_models.
+		Extra map[string]interface{} `json:"-"`
+	}
+)
+
+func NewDormancyNotification(c *config.Config, m *DormancyNotificationModel) *DormancyNotification {
+	return &DormancyNotification{c: c, m: m}
+}
+
+func (t *DormancyNotification) SetEmailLocale(locale string) {
+	t.m.Locale = locale
+}
+
+func (t *DormancyNotification) EmailTemplateType() string {
+	return "dormancy_notification"
+}
+
+func (t *DormancyNotification) EmailTemplateModel() interface{} {
+	return t.m
+}
+
+func (t *DormancyNotification) SetEmailTemplateExtra(extra map[string]interface{}) {
+	t.m.Extra = extra
+}
+
+func (t *DormancyNotification) EmailRecipient() (string, error) {
+	return t.m.To, nil
+}
+
+func (t *DormancyNotification) EmailSubject() (string, error) {
+	return loadLocalizedTextTemplate(filepath.Join(t.c.CourierTemplatesRoot(), "dormancy/notification/email.subject.gotmpl"), t.m.Locale, t.m)
+}
+
+func (t *DormancyNotification) EmailBody() (string, error) {
+	return loadEmailBody(t.c.CourierTemplatesRoot(), "dormancy/notification", t.m.Locale, t.m)
+}
+
+func (t *DormancyNotification) EmailBodyPlaintext() (string, error) {
+	return loadEmailBodyPlaintext(t.c.CourierTemplatesRoot(), "dormancy/notification", t.m.Locale, t.m)
+}
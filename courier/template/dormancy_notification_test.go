@@ -0,0 +1,62 @@
+package template_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ory/kratos/courier/template"
+	"github.com/ory/kratos/internal"
+)
+
+func TestDormancyNotification(t *testing.T) {
+	conf, _ := internal.NewFastRegistryWithMocks(t)
+	lastActive := time.Date(2020, 1, 2, 0, 0, 0, 0, time.UTC)
+
+	for _, tc := range []struct {
+		name     string
+		m        *template.DormancyNotificationModel
+		contains []string
+		excludes []string
+	}{
+		{
+			name:     "notify only",
+			m:        &template.DormancyNotificationModel{LastActiveAt: lastActive},
+			contains: []string{"January 2, 2020"},
+			excludes: []string{"deactivated", "deleted"},
+		},
+		{
+			name: "deactivate and delete",
+			m: &template.DormancyNotificationModel{
+				LastActiveAt: lastActive,
+				DeactivateAt: lastActive.AddDate(0, 6, 0),
+				DeleteAt:     lastActive.AddDate(1, 0, 0),
+			},
+			contains: []string{"deactivated on July 2, 2020", "deleted on January 2, 2021"},
+		},
+	} {
+		t.Run("case="+tc.name, func(t *testing.T) {
+			tpl := template.NewDormancyNotification(conf, tc.m)
+
+			rendered, err := tpl.EmailBody()
+			require.NoError(t, err)
+			for _, c := range tc.contains {
+				assert.Contains(t, rendered, c)
+			}
+			for _, c := range tc.excludes {
+				assert.NotContains(t, rendered, c)
+			}
+
+			rendered, err = tpl.EmailBodyPlaintext()
+			require.NoError(t, err)
+			assert.NotEmpty(t, rendered)
+			assert.NotContains(t, rendered, "<p>")
+
+			rendered, err = tpl.EmailSubject()
+			require.NoError(t, err)
+			assert.NotEmpty(t, rendered)
+		})
+	}
+}
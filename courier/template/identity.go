@@ -0,0 +1,26 @@
+package template
+
+import (
+	"bytes"
+	"encoding/json"
+
+	"github.com/pkg/errors"
+)
+
+// IdentityModel converts the identity to the map templates access as `.Identity`, so that templates can use the
+// same keys as the identity's JSON representation, for example `.Identity.traits.name.first`. Returns nil if the
+// identity is nil.
+func IdentityModel(i interface{}) (map[string]interface{}, error) {
+	encoded, err := json.Marshal(i)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	var model map[string]interface{}
+	d := json.NewDecoder(bytes.NewReader(encoded))
+	d.UseNumber()
+	if err := d.Decode(&model); err != nil {
+		return nil, errors.WithStack(err)
+	}
+	return model, nil
+}
@@ -0,0 +1,31 @@
+package template_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ory/kratos/courier/template"
+	"github.com/ory/kratos/identity"
+)
+
+func TestIdentityModel(t *testing.T) {
+	i := identity.NewIdentity("default")
+	i.Traits = identity.Traits(`{"email":"ada@example.org","name":{"first":"Ada"},"age":36}`)
+
+	model, err := template.IdentityModel(i)
+	require.NoError(t, err)
+	assert.Equal(t, i.ID.String(), model["id"])
+	assert.Equal(t, map[string]interface{}{
+		"email": "ada@example.org",
+		"name":  map[string]interface{}{"first": "Ada"},
+		"age":   json.Number("36"),
+	}, model["traits"])
+
+	var nothing *identity.Identity
+	model, err = template.IdentityModel(nothing)
+	require.NoError(t, err)
+	assert.Nil(t, model)
+}
@@ -3,8 +3,13 @@ package template
 import (
 	"bytes"
 	"embed"
+	htmltemplate "html/template"
 	"io"
+	"io/fs"
 	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
 	"text/template"
 
 	_ "embed"
@@ -19,16 +24,20 @@ var templates embed.FS
 
 var cache, _ = lru.New(16)
 
-func loadTextTemplate(path string, model interface{}) (string, error) {
-	var b bytes.Buffer
+// localePattern matches BCP 47 language tags such as `en` or `de-CH`. Locales which do not match are ignored
+// as they are used to build template file paths.
+var localePattern = regexp.MustCompile(`^[a-zA-Z]{2,3}(-[a-zA-Z0-9]{2,8}){0,3}$`)
 
-	if t, found := cache.Get(path); found {
-		var tb bytes.Buffer
-		if err := t.(*template.Template).ExecuteTemplate(&tb, path, model); err != nil {
-			return "", errors.WithStack(err)
-		}
-		return tb.String(), nil
-	}
+// partialsPattern matches the layouts and partials in the templates root which are available to all email bodies.
+const partialsPattern = "partials/*.gotmpl"
+
+// executor is implemented by text/template.Template and html/template.Template.
+type executor interface {
+	ExecuteTemplate(wr io.Writer, name string, data interface{}) error
+}
+
+func readTemplate(path string) (string, error) {
+	var b bytes.Buffer
 
 	if file, err := templates.Open(path); err == nil {
 		defer file.Close()
@@ -46,16 +55,174 @@ func loadTextTemplate(path string, model interface{}) (string, error) {
 		}
 	}
 
-	t, err := template.New(path).Funcs(sprig.TxtFuncMap()).Parse(b.String())
+	return b.String(), nil
+}
+
+func templateExists(path string) bool {
+	if _, found := cache.Get(path); found {
+		return true
+	}
+	if _, err := fs.Stat(templates, path); err == nil {
+		return true
+	}
+	if _, err := os.Stat(path); err == nil {
+		return true
+	}
+	return false
+}
+
+// readPartials returns the layouts and partials of the templates root keyed by their path.
+func readPartials(root string) (map[string]string, error) {
+	pattern := filepath.Join(root, partialsPattern)
+	paths, err := fs.Glob(templates, pattern)
 	if err != nil {
-		return "", errors.WithStack(err)
+		return nil, errors.WithStack(err)
+	}
+	if len(paths) == 0 {
+		if paths, err = filepath.Glob(pattern); err != nil {
+			return nil, errors.WithStack(err)
+		}
+	}
+
+	partials := make(map[string]string, len(paths))
+	for _, path := range paths {
+		content, err := readTemplate(path)
+		if err != nil {
+			return nil, err
+		}
+		partials[path] = content
+	}
+
+	return partials, nil
+}
+
+func executeTemplate(path string, model interface{}, parse func() (executor, error)) (string, error) {
+	t, found := cache.Get(path)
+	if !found {
+		parsed, err := parse()
+		if err != nil {
+			return "", err
+		}
+		_ = cache.Add(path, parsed)
+		t = parsed
 	}
 
-	_ = cache.Add(path, t)
 	var tb bytes.Buffer
-	if err := t.ExecuteTemplate(&tb, path, model); err != nil {
+	if err := t.(executor).ExecuteTemplate(&tb, path, model); err != nil {
 		return "", errors.WithStack(err)
 	}
 
 	return tb.String(), nil
 }
+
+func loadTextTemplate(path string, model interface{}) (string, error) {
+	return loadTextTemplateWithPartials("", path, model)
+}
+
+// loadTextTemplateWithPartials renders the template using text/template. If root is not empty, the layouts and
+// partials of the templates root can be used.
+func loadTextTemplateWithPartials(root, path string, model interface{}) (string, error) {
+	return executeTemplate(path, model, func() (executor, error) {
+		content, err := readTemplate(path)
+		if err != nil {
+			return nil, err
+		}
+
+		t, err := template.New(path).Funcs(sprig.TxtFuncMap()).Parse(content)
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+
+		if len(root) > 0 {
+			partials, err := readPartials(root)
+			if err != nil {
+				return nil, err
+			}
+			for name, partial := range partials {
+				if _, err := t.New(name).Parse(partial); err != nil {
+					return nil, errors.WithStack(err)
+				}
+			}
+		}
+
+		return t, nil
+	})
+}
+
+// loadHTMLTemplate renders the template using html/template. The layouts and partials of the templates root can
+// be used.
+func loadHTMLTemplate(root, path string, model interface{}) (string, error) {
+	return executeTemplate(path, model, func() (executor, error) {
+		content, err := readTemplate(path)
+		if err != nil {
+			return nil, err
+		}
+
+		t, err := htmltemplate.New(path).Funcs(sprig.HtmlFuncMap()).Parse(content)
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+
+		partials, err := readPartials(root)
+		if err != nil {
+			return nil, err
+		}
+		for name, partial := range partials {
+			if _, err := t.New(name).Parse(partial); err != nil {
+				return nil, errors.WithStack(err)
+			}
+		}
+
+		return t, nil
+	})
+}
+
+// localizedTemplatePath returns the path of the template localized for the given locale if it exists. The
+// locale is inserted before the file extension, so `email.body.gotmpl` becomes `email.body.de-CH.gotmpl`. If
+// no template exists for the full locale, the base language (`de`) is tried before falling back to path.
+func localizedTemplatePath(path, locale string) string {
+	if !localePattern.MatchString(locale) {
+		return path
+	}
+
+	ext := filepath.Ext(path)
+	candidates := []string{locale}
+	if i := strings.IndexByte(locale, '-'); i > 0 {
+		candidates = append(candidates, locale[:i])
+	}
+
+	for _, c := range candidates {
+		localized := strings.TrimSuffix(path, ext) + "." + c + ext
+		if templateExists(localized) {
+			return localized
+		}
+	}
+
+	return path
+}
+
+func loadLocalizedTextTemplate(path, locale string, model interface{}) (string, error) {
+	return loadTextTemplate(localizedTemplatePath(path, locale), model)
+}
+
+// loadEmailBody renders the HTML body `email.body.gotmpl` in the directory of the templates root.
+func loadEmailBody(root, dir, locale string, model interface{}) (string, error) {
+	return loadHTMLTemplate(root, localizedTemplatePath(filepath.Join(root, dir, "email.body.gotmpl"), locale), model)
+}
+
+// loadEmailBodyPlaintext renders the plaintext body `email.body.plaintext.gotmpl` in the directory of the templates
+// root. If the directory does not contain a plaintext body, it is derived from the HTML body so that operators only
+// need to maintain one template per message.
+func loadEmailBodyPlaintext(root, dir, locale string, model interface{}) (string, error) {
+	path := localizedTemplatePath(filepath.Join(root, dir, "email.body.plaintext.gotmpl"), locale)
+	if templateExists(path) {
+		return loadTextTemplateWithPartials(root, path, model)
+	}
+
+	body, err := loadEmailBody(root, dir, locale, model)
+	if err != nil {
+		return "", err
+	}
+
+	return htmlToPlaintext(body), nil
+}
@@ -33,4 +33,60 @@ func TestLoadTextTemplate(t *testing.T) {
 		require.NoError(t, os.RemoveAll(fp))
 		assert.Contains(t, executeTemplate(t, fp), "cached stub body")
 	})
+
+	t.Run("method=localized", func(t *testing.T) {
+		dir := t.TempDir()
+		base := filepath.Join(dir, "email.body.gotmpl")
+		for file, content := range map[string]string{
+			"email.body.gotmpl":       "base body",
+			"email.body.de.gotmpl":    "de body",
+			"email.body.de-CH.gotmpl": "de-CH body",
+			"email.body.fr.gotmpl":    "fr body",
+		} {
+			require.NoError(t, ioutil.WriteFile(filepath.Join(dir, file), bytes.NewBufferString(content)))
+		}
+
+		for _, tc := range []struct{ locale, expected string }{
+			{locale: "", expected: "base body"},
+			{locale: "de-CH", expected: "de-CH body"},
+			{locale: "de-AT", expected: "de body"},
+			{locale: "de", expected: "de body"},
+			{locale: "fr-CH", expected: "fr body"},
+			{locale: "en-US", expected: "base body"},
+			{locale: "../de", expected: "base body"},
+			{locale: "de/../fr", expected: "base body"},
+			{locale: `de\CH`, expected: "base body"},
+		} {
+			t.Run("locale="+tc.locale, func(t *testing.T) {
+				actual, err := loadLocalizedTextTemplate(base, tc.locale, nil)
+				require.NoError(t, err)
+				assert.Equal(t, tc.expected, actual)
+			})
+		}
+	})
+
+	t.Run("method=html body with partials", func(t *testing.T) {
+		root := t.TempDir()
+		require.NoError(t, os.MkdirAll(filepath.Join(root, "partials"), 0700))
+		require.NoError(t, os.MkdirAll(filepath.Join(root, "message"), 0700))
+		require.NoError(t, ioutil.WriteFile(filepath.Join(root, "partials", "layout.gotmpl"), bytes.NewBufferString(
+			`{{ define "layout" }}<html><body>{{ template "content" . }}</body></html>{{ end }}`)))
+		require.NoError(t, ioutil.WriteFile(filepath.Join(root, "message", "email.body.gotmpl"), bytes.NewBufferString(
+			`{{ define "content" }}<p>Hi {{ .Name }},</p><p><a href="{{ .URL }}">Click here</a></p>{{ end }}{{ template "layout" . }}`)))
+
+		model := map[string]string{"Name": "<Bob>", "URL": "https://www.ory.sh/?a=b&c=d"}
+		body, err := loadEmailBody(root, "message", "", model)
+		require.NoError(t, err)
+		assert.Equal(t, `<html><body><p>Hi &lt;Bob&gt;,</p><p><a href="https://www.ory.sh/?a=b&amp;c=d">Click here</a></p></body></html>`, body)
+
+		plaintext, err := loadEmailBodyPlaintext(root, "message", "", model)
+		require.NoError(t, err)
+		assert.Equal(t, "Hi <Bob>,\nClick here (https://www.ory.sh/?a=b&c=d)", plaintext)
+
+		require.NoError(t, ioutil.WriteFile(filepath.Join(root, "message", "email.body.plaintext.gotmpl"), bytes.NewBufferString(
+			`Hi {{ .Name }}, open {{ .URL }}`)))
+		plaintext, err = loadEmailBodyPlaintext(root, "message", "", model)
+		require.NoError(t, err)
+		assert.Equal(t, "Hi <Bob>, open https://www.ory.sh/?a=b&c=d", plaintext)
+	})
 }
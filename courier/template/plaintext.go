@@ -0,0 +1,39 @@
+package template
+
+import (
+	"html"
+	"regexp"
+	"strings"
+)
+
+var (
+	invisiblePattern  = regexp.MustCompile(`(?is)<(head|style|script)[^>]*>.*?</(head|style|script)>`)
+	anchorPattern     = regexp.MustCompile(`(?is)<a\s[^>]*href\s*=\s*["']([^"']*)["'][^>]*>(.*?)</a>`)
+	lineBreakPattern  = regexp.MustCompile(`(?i)<br\s*/?>|</(p|div|h[1-6]|li|tr|table)>`)
+	tagPattern        = regexp.MustCompile(`(?s)<[^>]*>`)
+	blankLinesPattern = regexp.MustCompile(`\n{3,}`)
+)
+
+// htmlToPlaintext converts an HTML email body to plaintext. Links are rendered as `text (url)` so that they remain
+// usable in email clients which do not display HTML.
+func htmlToPlaintext(body string) string {
+	body = invisiblePattern.ReplaceAllString(body, "")
+	body = anchorPattern.ReplaceAllStringFunc(body, func(anchor string) string {
+		m := anchorPattern.FindStringSubmatch(anchor)
+		href := strings.TrimSpace(m[1])
+		text := strings.TrimSpace(tagPattern.ReplaceAllString(m[2], ""))
+		if len(text) == 0 || html.UnescapeString(text) == html.UnescapeString(href) {
+			return href
+		}
+		return text + " (" + href + ")"
+	})
+	body = lineBreakPattern.ReplaceAllString(body, "$0\n")
+	body = html.UnescapeString(tagPattern.ReplaceAllString(body, ""))
+
+	lines := strings.Split(body, "\n")
+	for i := range lines {
+		lines[i] = strings.TrimSpace(lines[i])
+	}
+
+	return strings.TrimSpace(blankLinesPattern.ReplaceAllString(strings.Join(lines, "\n"), "\n\n"))
+}
@@ -0,0 +1,24 @@
+package template
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHTMLToPlaintext(t *testing.T) {
+	for k, tc := range []struct{ in, expected string }{
+		{in: "plain text", expected: "plain text"},
+		{in: "<p>Hi,</p>\n\n<p>welcome!</p>", expected: "Hi,\n\nwelcome!"},
+		{in: "line<br>break<br/>twice", expected: "line\nbreak\ntwice"},
+		{in: `<a href="https://www.ory.sh/">https://www.ory.sh/</a>`, expected: "https://www.ory.sh/"},
+		{in: `<a class="button" href="https://www.ory.sh/?a=b&amp;c=d"><b>Verify</b></a>`, expected: "Verify (https://www.ory.sh/?a=b&c=d)"},
+		{in: "<html><head><style>p { color: red; }</style></head><body>\n    <h1>Title</h1>\n    <p>Tom &amp; Jerry</p>\n</body></html>", expected: "Title\n\nTom & Jerry"},
+		{in: "<p>a</p>\n\n\n\n<p>b</p>", expected: "a\n\nb"},
+	} {
+		t.Run(fmt.Sprintf("case=%d", k), func(t *testing.T) {
+			assert.Equal(t, tc.expected, htmlToPlaintext(tc.in))
+		})
+	}
+}
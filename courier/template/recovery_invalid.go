@@ -13,6 +13,8 @@ type (
 	}
 	RecoveryInvalidModel struct {
 		To string
+		// Extra contains the values returned by the template model enricher, see courier.template_models.
+		Extra map[string]interface{} `json:"-"`
 	}
 )
 
@@ -20,6 +22,18 @@ func NewRecoveryInvalid(c *config.Config, m *RecoveryInvalidModel) *RecoveryInva
 	return &RecoveryInvalid{c: c, m: m}
 }
 
+func (t *RecoveryInvalid) EmailTemplateType() string {
+	return "recovery_invalid"
+}
+
+func (t *RecoveryInvalid) EmailTemplateModel() interface{} {
+	return t.m
+}
+
+func (t *RecoveryInvalid) SetEmailTemplateExtra(extra map[string]interface{}) {
+	t.m.Extra = extra
+}
+
 func (t *RecoveryInvalid) EmailRecipient() (string, error) {
 	return t.m.To, nil
 }
@@ -29,5 +43,9 @@ func (t *RecoveryInvalid) EmailSubject() (string, error) {
 }
 
 func (t *RecoveryInvalid) EmailBody() (string, error) {
-	return loadTextTemplate(filepath.Join(t.c.CourierTemplatesRoot(), "recovery/invalid/email.body.gotmpl"), t.m)
+	return loadEmailBody(t.c.CourierTemplatesRoot(), "recovery/invalid", "", t.m)
+}
+
+func (t *RecoveryInvalid) EmailBodyPlaintext() (string, error) {
+	return loadEmailBodyPlaintext(t.c.CourierTemplatesRoot(), "recovery/invalid", "", t.m)
 }
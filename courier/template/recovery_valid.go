@@ -12,8 +12,15 @@ type (
 		m *RecoveryValidModel
 	}
 	RecoveryValidModel struct {
-		To          string
-		RecoveryURL string
+		To string
+		// RecoveryURL and RecoveryToken are not passed to the template model enricher.
+		RecoveryURL   string `json:"-"`
+		RecoveryToken string `json:"-"`
+		Locale        string
+		// Identity is the identity the message is sent to, for example `.Identity.traits.email`.
+		Identity map[string]interface{}
+		// Extra contains the values returned by the template model enricher, see courier.template_models.
+		Extra map[string]interface{} `json:"-"`
 	}
 )
 
@@ -21,14 +28,34 @@ func NewRecoveryValid(c *config.Config, m *RecoveryValidModel) *RecoveryValid {
 	return &RecoveryValid{c: c, m: m}
 }
 
+func (t *RecoveryValid) SetEmailLocale(locale string) {
+	t.m.Locale = locale
+}
+
+func (t *RecoveryValid) EmailTemplateType() string {
+	return "recovery_valid"
+}
+
+func (t *RecoveryValid) EmailTemplateModel() interface{} {
+	return t.m
+}
+
+func (t *RecoveryValid) SetEmailTemplateExtra(extra map[string]interface{}) {
+	t.m.Extra = extra
+}
+
 func (t *RecoveryValid) EmailRecipient() (string, error) {
 	return t.m.To, nil
 }
 
 func (t *RecoveryValid) EmailSubject() (string, error) {
-	return loadTextTemplate(filepath.Join(t.c.CourierTemplatesRoot(), "recovery/valid/email.subject.gotmpl"), t.m)
+	return loadLocalizedTextTemplate(filepath.Join(t.c.CourierTemplatesRoot(), "recovery/valid/email.subject.gotmpl"), t.m.Locale, t.m)
 }
 
 func (t *RecoveryValid) EmailBody() (string, error) {
-	return loadTextTemplate(filepath.Join(t.c.CourierTemplatesRoot(), "recovery/valid/email.body.gotmpl"), t.m)
+	return loadEmailBody(t.c.CourierTemplatesRoot(), "recovery/valid", t.m.Locale, t.m)
+}
+
+func (t *RecoveryValid) EmailBodyPlaintext() (string, error) {
+	return loadEmailBodyPlaintext(t.c.CourierTemplatesRoot(), "recovery/valid", t.m.Locale, t.m)
 }
@@ -18,6 +18,11 @@ func TestRecoverValid(t *testing.T) {
 	require.NoError(t, err)
 	assert.NotEmpty(t, rendered)
 
+	rendered, err = tpl.EmailBodyPlaintext()
+	require.NoError(t, err)
+	assert.NotEmpty(t, rendered)
+	assert.NotContains(t, rendered, "<p>")
+
 	rendered, err = tpl.EmailSubject()
 	require.NoError(t, err)
 	assert.NotEmpty(t, rendered)
@@ -30,5 +30,9 @@ func (t *TestStub) EmailSubject() (string, error) {
 }
 
 func (t *TestStub) EmailBody() (string, error) {
-	return loadTextTemplate(filepath.Join(t.c.CourierTemplatesRoot(), "test_stub/email.body.gotmpl"), t.m)
+	return loadEmailBody(t.c.CourierTemplatesRoot(), "test_stub", "", t.m)
+}
+
+func (t *TestStub) EmailBodyPlaintext() (string, error) {
+	return loadEmailBodyPlaintext(t.c.CourierTemplatesRoot(), "test_stub", "", t.m)
 }
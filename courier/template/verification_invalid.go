@@ -13,6 +13,8 @@ type (
 	}
 	VerificationInvalidModel struct {
 		To string
+		// Extra contains the values returned by the template model enricher, see courier.template_models.
+		Extra map[string]interface{} `json:"-"`
 	}
 )
 
@@ -20,6 +22,18 @@ func NewVerificationInvalid(c *config.Config, m *VerificationInvalidModel) *Veri
 	return &VerificationInvalid{c: c, m: m}
 }
 
+func (t *VerificationInvalid) EmailTemplateType() string {
+	return "verification_invalid"
+}
+
+func (t *VerificationInvalid) EmailTemplateModel() interface{} {
+	return t.m
+}
+
+func (t *VerificationInvalid) SetEmailTemplateExtra(extra map[string]interface{}) {
+	t.m.Extra = extra
+}
+
 func (t *VerificationInvalid) EmailRecipient() (string, error) {
 	return t.m.To, nil
 }
@@ -29,5 +43,9 @@ func (t *VerificationInvalid) EmailSubject() (string, error) {
 }
 
 func (t *VerificationInvalid) EmailBody() (string, error) {
-	return loadTextTemplate(filepath.Join(t.c.CourierTemplatesRoot(), "verification/invalid/email.body.gotmpl"), t.m)
+	return loadEmailBody(t.c.CourierTemplatesRoot(), "verification/invalid", "", t.m)
+}
+
+func (t *VerificationInvalid) EmailBodyPlaintext() (string, error) {
+	return loadEmailBodyPlaintext(t.c.CourierTemplatesRoot(), "verification/invalid", "", t.m)
 }
@@ -0,0 +1,65 @@
+package template
+
+import (
+	"path/filepath"
+
+	"github.com/ory/kratos/driver/config"
+)
+
+type (
+	VerificationReminder struct {
+		c *config.Config
+		m *VerificationReminderModel
+	}
+	VerificationReminderModel struct {
+		To string
+		// VerificationURL and VerificationToken are not passed to the template model enricher.
+		VerificationURL   string `json:"-"`
+		VerificationToken string `json:"-"`
+		// Reminder counts the reminders sent to the address, starting at 1.
+		Reminder int
+		// Last is true if no further reminders will be sent to the address.
+		Last   bool
+		Locale string
+		// Identity is the identity the message is sent to, for example `.Identity.traits.email`.
+		Identity map[string]interface{}
+		// Extra contains the values returned by the template model enricher, see courier.template_models.
+		Extra map[string]interface{} `json:"-"`
+	}
+)
+
+func NewVerificationReminder(c *config.Config, m *VerificationReminderModel) *VerificationReminder {
+	return &VerificationReminder{c: c, m: m}
+}
+
+func (t *VerificationReminder) SetEmailLocale(locale string) {
+	t.m.Locale = locale
+}
+
+func (t *VerificationReminder) EmailTemplateType() string {
+	return "verification_reminder"
+}
+
+func (t *VerificationReminder) EmailTemplateModel() interface{} {
+	return t.m
+}
+
+func (t *VerificationReminder) SetEmailTemplateExtra(extra map[string]interface{}) {
+	t.m.Extra = extra
+}
+
+func (t *VerificationReminder) EmailRecipient() (string, error) {
+	return t.m.To, nil
+}
+
+func (t *VerificationReminder) EmailSubject() (string, error) {
+	return loadLocalizedTextTemplate(filepath.Join(t.c.CourierTemplatesRoot(), "verification/reminder/email.subject.gotmpl"), t.m.Locale, t.m)
+}
+
+func (t *VerificationReminder) EmailBody() (string, error) {
+	return loadEmailBody(t.c.CourierTemplatesRoot(), "verification/reminder", t.m.Locale, t.m)
+}
+
+func (t *VerificationReminder) EmailBodyPlaintext() (string, error) {
+	return loadEmailBodyPlaintext(t.c.CourierTemplatesRoot(), "verification/reminder", t.m.Locale, t.m)
+}
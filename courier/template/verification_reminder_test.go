@@ -0,0 +1,56 @@
+package template_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ory/kratos/courier/template"
+	"github.com/ory/kratos/internal"
+)
+
+func TestVerificationReminder(t *testing.T) {
+	conf, _ := internal.NewFastRegistryWithMocks(t)
+
+	for _, tc := range []struct {
+		name     string
+		m        *template.VerificationReminderModel
+		contains []string
+		excludes []string
+	}{
+		{
+			name:     "first reminder",
+			m:        &template.VerificationReminderModel{VerificationURL: "https://www.ory.sh/verify?token=foo", Reminder: 1},
+			contains: []string{"https://www.ory.sh/verify?token=foo"},
+			excludes: []string{"last reminder"},
+		},
+		{
+			name:     "last reminder",
+			m:        &template.VerificationReminderModel{VerificationURL: "https://www.ory.sh/verify?token=foo", Reminder: 2, Last: true},
+			contains: []string{"https://www.ory.sh/verify?token=foo", "last reminder"},
+		},
+	} {
+		t.Run("case="+tc.name, func(t *testing.T) {
+			tpl := template.NewVerificationReminder(conf, tc.m)
+
+			rendered, err := tpl.EmailBody()
+			require.NoError(t, err)
+			for _, c := range tc.contains {
+				assert.Contains(t, rendered, c)
+			}
+			for _, c := range tc.excludes {
+				assert.NotContains(t, rendered, c)
+			}
+
+			rendered, err = tpl.EmailBodyPlaintext()
+			require.NoError(t, err)
+			assert.NotEmpty(t, rendered)
+			assert.NotContains(t, rendered, "<p>")
+
+			rendered, err = tpl.EmailSubject()
+			require.NoError(t, err)
+			assert.NotEmpty(t, rendered)
+		})
+	}
+}
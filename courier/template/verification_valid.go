@@ -12,8 +12,15 @@ type (
 		m *VerificationValidModel
 	}
 	VerificationValidModel struct {
-		To              string
-		VerificationURL string
+		To string
+		// VerificationURL and VerificationToken are not passed to the template model enricher.
+		VerificationURL   string `json:"-"`
+		VerificationToken string `json:"-"`
+		Locale            string
+		// Identity is the identity the message is sent to, for example `.Identity.traits.email`.
+		Identity map[string]interface{}
+		// Extra contains the values returned by the template model enricher, see courier.template_models.
+		Extra map[string]interface{} `json:"-"`
 	}
 )
 
@@ -21,14 +28,34 @@ func NewVerificationValid(c *config.Config, m *VerificationValidModel) *Verifica
 	return &VerificationValid{c: c, m: m}
 }
 
+func (t *VerificationValid) SetEmailLocale(locale string) {
+	t.m.Locale = locale
+}
+
+func (t *VerificationValid) EmailTemplateType() string {
+	return "verification_valid"
+}
+
+func (t *VerificationValid) EmailTemplateModel() interface{} {
+	return t.m
+}
+
+func (t *VerificationValid) SetEmailTemplateExtra(extra map[string]interface{}) {
+	t.m.Extra = extra
+}
+
 func (t *VerificationValid) EmailRecipient() (string, error) {
 	return t.m.To, nil
 }
 
 func (t *VerificationValid) EmailSubject() (string, error) {
-	return loadTextTemplate(filepath.Join(t.c.CourierTemplatesRoot(), "verification/valid/email.subject.gotmpl"), t.m)
+	return loadLocalizedTextTemplate(filepath.Join(t.c.CourierTemplatesRoot(), "verification/valid/email.subject.gotmpl"), t.m.Locale, t.m)
 }
 
 func (t *VerificationValid) EmailBody() (string, error) {
-	return loadTextTemplate(filepath.Join(t.c.CourierTemplatesRoot(), "verification/valid/email.body.gotmpl"), t.m)
+	return loadEmailBody(t.c.CourierTemplatesRoot(), "verification/valid", t.m.Locale, t.m)
+}
+
+func (t *VerificationValid) EmailBodyPlaintext() (string, error) {
+	return loadEmailBodyPlaintext(t.c.CourierTemplatesRoot(), "verification/valid", t.m.Locale, t.m)
 }
@@ -0,0 +1,102 @@
+package courier
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+
+	"github.com/google/go-jsonnet"
+	"github.com/hashicorp/go-retryablehttp"
+	"github.com/pkg/errors"
+
+	"github.com/ory/herodot"
+	"github.com/ory/x/fetcher"
+
+	"github.com/ory/kratos/driver/config"
+	"github.com/ory/kratos/x"
+)
+
+// enrichTemplateModel adds the values returned by the model enricher configured for the template's type to the
+// model. The Jsonnet code receives the model as `std.extVar('model')` and the response of the web hook, if one is
+// configured, as `std.extVar('data')`.
+func (m *Courier) enrichTemplateModel(ctx context.Context, t EmailTemplate) error {
+	et, ok := t.(EnrichableEmailTemplate)
+	if !ok {
+		return nil
+	}
+
+	c := m.d.Config(ctx)
+	enricher := c.CourierTemplateModel(et.EmailTemplateType())
+	if enricher == nil {
+		return nil
+	}
+
+	model, err := json.Marshal(et.EmailTemplateModel())
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	hc, err := x.NewHookHTTPClient(c.HTTPClient(), c.Egress(), c.EgressDependency(config.EgressDependencyWebHooks))
+	if err != nil {
+		return errors.WithStack(herodot.ErrInternalServerError.WithReasonf("Unable to configure the HTTP client of the template model enricher.").WithDebug(err.Error()))
+	}
+
+	data := []byte("null")
+	if enricher.WebHook != nil {
+		if data, err = m.fetchTemplateModelData(ctx, hc, enricher.WebHook, model); err != nil {
+			return err
+		}
+	}
+
+	jn, err := fetcher.NewFetcher(fetcher.WithClient(hc)).Fetch(enricher.MapperURL)
+	if err != nil {
+		return err
+	}
+
+	vm := jsonnet.MakeVM()
+	vm.ExtCode("model", string(model))
+	vm.ExtCode("data", string(data))
+	evaluated, err := vm.EvaluateSnippet(enricher.MapperURL, jn.String())
+	if err != nil {
+		return errors.WithStack(herodot.ErrInternalServerError.WithReasonf("Unable to evaluate the Jsonnet code of the %s template model enricher.", et.EmailTemplateType()).WithDebug(err.Error()))
+	}
+
+	var extra map[string]interface{}
+	if err := json.Unmarshal([]byte(evaluated), &extra); err != nil {
+		return errors.WithStack(herodot.ErrInternalServerError.WithReasonf("The Jsonnet code of the %s template model enricher must return an object.", et.EmailTemplateType()).WithDebug(err.Error()))
+	}
+
+	et.SetEmailTemplateExtra(extra)
+	return nil
+}
+
+// fetchTemplateModelData posts the model to the web hook and returns its JSON response.
+func (m *Courier) fetchTemplateModelData(ctx context.Context, hc *retryablehttp.Client, hook *config.CourierTemplateModelWebHook, model []byte) ([]byte, error) {
+	req, err := retryablehttp.NewRequest("POST", hook.URL, bytes.NewReader(model))
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/json")
+	for k, v := range hook.Headers {
+		req.Header.Set(k, v)
+	}
+
+	res, err := hc.Do(req.WithContext(ctx))
+	if err != nil {
+		return nil, errors.WithStack(herodot.ErrInternalServerError.WithReasonf("Unable to reach the web hook of the template model enricher.").WithDebug(err.Error()))
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return nil, errors.WithStack(herodot.ErrInternalServerError.WithReasonf("The web hook of the template model enricher responded with unexpected status code %d.", res.StatusCode))
+	}
+
+	var data json.RawMessage
+	if err := json.NewDecoder(res.Body).Decode(&data); err != nil {
+		return nil, errors.WithStack(herodot.ErrInternalServerError.WithReasonf("The web hook of the template model enricher must respond with JSON.").WithDebug(err.Error()))
+	}
+
+	return data, nil
+}
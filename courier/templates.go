@@ -1,7 +1,50 @@
 package courier
 
-type EmailTemplate interface {
-	EmailSubject() (string, error)
-	EmailBody() (string, error)
-	EmailRecipient() (string, error)
-}
+type (
+	EmailTemplate interface {
+		EmailSubject() (string, error)
+		// EmailBody returns the HTML body of the email.
+		EmailBody() (string, error)
+		// EmailBodyPlaintext returns the plaintext body of the email.
+		EmailBodyPlaintext() (string, error)
+		EmailRecipient() (string, error)
+	}
+
+	// MarketingEmailTemplate is implemented by templates which are not required for completing a
+	// self-service flow. Such messages are not queued for recipients who opted out of marketing.
+	MarketingEmailTemplate interface {
+		EmailTemplate
+		MarketingEmail() bool
+	}
+
+	// LocalizedEmailTemplate is implemented by templates which can be rendered in the recipient's
+	// preferred locale.
+	LocalizedEmailTemplate interface {
+		EmailTemplate
+		SetEmailLocale(locale string)
+	}
+
+	// TypedEmailTemplate is implemented by templates which have a type, such as `verification_valid`. Sending
+	// caps and windows are configured per template type.
+	TypedEmailTemplate interface {
+		EmailTemplate
+		EmailTemplateType() string
+	}
+
+	// EnrichableEmailTemplate is implemented by templates whose model can be extended with additional values,
+	// see courier.template_models.
+	EnrichableEmailTemplate interface {
+		TypedEmailTemplate
+		// EmailTemplateModel returns the model the template is rendered with.
+		EmailTemplateModel() interface{}
+		// SetEmailTemplateExtra sets the additional values, which templates access as `.Extra`.
+		SetEmailTemplateExtra(extra map[string]interface{})
+	}
+
+	// RecipientPreferences are the recipient's communication preferences the courier consults before
+	// queueing a message.
+	RecipientPreferences interface {
+		AcceptsMarketing() bool
+		Locale() string
+	}
+)
@@ -3,19 +3,24 @@ package config
 import (
 	"bytes"
 	"context"
+	"crypto/tls"
 	"encoding/json"
 	"fmt"
+	"io/ioutil"
 	"net"
 	"net/http"
 	"net/url"
 	"os"
 	"runtime"
+	"sort"
+	"strconv"
 	"strings"
 	"time"
 
 	"github.com/ory/x/dbal"
 
 	"github.com/ory/x/stringsx"
+	"github.com/ory/x/urlx"
 
 	"github.com/rs/cors"
 	"github.com/tidwall/gjson"
@@ -24,12 +29,12 @@ import (
 	"github.com/ory/x/jsonx"
 
 	"github.com/google/uuid"
+	"github.com/inhies/go-bytesize"
 	"github.com/pkg/errors"
 
+	"github.com/ory/herodot"
 	"github.com/ory/x/logrusx"
 	"github.com/ory/x/tracing"
-
-	kjson "github.com/knadh/koanf/parsers/json"
 )
 
 const (
@@ -42,13 +47,29 @@ const (
 	ViperKeyCourierTemplatesPath                                    = "courier.template_override_path"
 	ViperKeyCourierSMTPFrom                                         = "courier.smtp.from_address"
 	ViperKeyCourierSMTPFromName                                     = "courier.smtp.from_name"
+	ViperKeyCourierSMTPTLSMode                                      = "courier.smtp.tls_mode"
+	ViperKeyCourierSMTPTLSSkipVerify                                = "courier.smtp.tls_skip_verify"
+	ViperKeyCourierSMTPLocalName                                    = "courier.smtp.local_name"
+	ViperKeyCourierSMTPConnectTimeout                               = "courier.smtp.connect_timeout"
+	ViperKeyCourierSMTPClientCertPath                               = "courier.smtp.client_cert.cert_path"
+	ViperKeyCourierSMTPClientKeyPath                                = "courier.smtp.client_cert.key_path"
+	ViperKeyCourierDeliveryWebhookSecret                            = "courier.delivery_webhook.secret"
+	ViperKeyCourierDeliveryWebhookMarkUndeliverable                 = "courier.delivery_webhook.mark_undeliverable"
+	ViperKeyCourierTemplateLimits                                   = "courier.template_limits"
+	ViperKeyCourierTemplateModels                                   = "courier.template_models"
 	ViperKeySecretsDefault                                          = "secrets.default"
 	ViperKeySecretsCookie                                           = "secrets.cookie"
+	ViperKeySecretsPepper                                           = "secrets.pepper"
+	ViperKeySecretsCipher                                           = "secrets.cipher"
+	ViperKeySecretsEncryption                                       = "secrets.encryption"
+	ViperKeySecretsGeneratedPath                                    = "secrets.generated_path"
 	ViperKeyPublicBaseURL                                           = "serve.public.base_url"
+	ViperKeyPublicBasePath                                          = "serve.public.base_path"
 	ViperKeyPublicDomainAliases                                     = "serve.public.domain_aliases"
 	ViperKeyPublicPort                                              = "serve.public.port"
 	ViperKeyPublicHost                                              = "serve.public.host"
 	ViperKeyAdminBaseURL                                            = "serve.admin.base_url"
+	ViperKeyAdminBasePath                                           = "serve.admin.base_path"
 	ViperKeyAdminPort                                               = "serve.admin.port"
 	ViperKeyAdminHost                                               = "serve.admin.host"
 	ViperKeySessionLifespan                                         = "session.lifespan"
@@ -57,19 +78,52 @@ const (
 	ViperKeySessionName                                             = "session.cookie.name"
 	ViperKeySessionPath                                             = "session.cookie.path"
 	ViperKeySessionPersistentCookie                                 = "session.cookie.persistent"
+	ViperKeySessionEncryptCookie                                    = "session.cookie.encrypt"
+	ViperKeySessionDeviceParseUserAgent                             = "session.device.parse_user_agent"
+	ViperKeySessionDeviceLocationHeader                             = "session.device.location_header"
+	ViperKeySessionBindingMode                                      = "session.binding.mode"
+	ViperKeySessionBindingIP                                        = "session.binding.ip"
+	ViperKeySessionBindingUserAgent                                 = "session.binding.user_agent"
+	ViperKeySessionBindingIPv4PrefixLength                          = "session.binding.ipv4_prefix_length"
+	ViperKeySessionBindingIPv6PrefixLength                          = "session.binding.ipv6_prefix_length"
+	ViperKeySessionWebhookURL                                       = "session.webhook.url"
+	ViperKeyMetricsGaugesRefreshInterval                            = "serve.admin.metrics.refresh_interval"
+	ViperKeyIdempotencyKeyLifespan                                  = "serve.admin.idempotency.lifespan"
+	ViperKeyIdempotencyInProgressTimeout                            = "serve.admin.idempotency.in_progress_timeout"
+	ViperKeyKMSRefreshInterval                                      = "kms.refresh_interval"
+	ViperKeySessionRevocationRedisURL                               = "session.revocation.redis.url"
+	ViperKeySessionRevocationRedisChannel                           = "session.revocation.redis.channel"
+	ViperKeySessionWhoamiIdentityTraits                             = "session.whoami.identity.traits"
+	ViperKeySessionWhoamiIdentityVerifiableAddresses                = "session.whoami.identity.verifiable_addresses"
+	ViperKeySessionWhoamiIdentityRecoveryAddresses                  = "session.whoami.identity.recovery_addresses"
+	ViperKeySessionWhoamiIdentityCommunicationPreferences           = "session.whoami.identity.communication_preferences"
+	ViperKeySessionWhoamiDevice                                     = "session.whoami.device"
+	ViperKeySessionWhoamiCacheMaxAge                                = "session.whoami.cache.max_age"
+	ViperKeySessionPrivilegedLifespan                               = "session.privileged.lifespan"
+	ViperKeySessionPrivilegedReauthenticationMaxAge                 = "session.privileged.reauthentication_max_age"
 	ViperKeySelfServiceStrategyConfig                               = "selfservice.methods"
 	ViperKeySelfServiceBrowserDefaultReturnTo                       = "selfservice." + DefaultBrowserReturnURL
 	ViperKeyURLsWhitelistedReturnToDomains                          = "selfservice.whitelisted_return_urls"
+	ViperKeySelfServiceTermsOfServiceVersion                        = "selfservice.terms_of_service.version"
+	ViperKeySelfServiceTermsOfServiceURL                            = "selfservice.terms_of_service.url"
+	ViperKeySelfServiceRegistrationEnabled                          = "selfservice.flows.registration.enabled"
+	ViperKeySelfServiceLoginEnabled                                 = "selfservice.flows.login.enabled"
+	ViperKeySelfServiceSettingsEnabled                              = "selfservice.flows.settings.enabled"
 	ViperKeySelfServiceRegistrationUI                               = "selfservice.flows.registration.ui_url"
 	ViperKeySelfServiceRegistrationRequestLifespan                  = "selfservice.flows.registration.lifespan"
 	ViperKeySelfServiceRegistrationAfter                            = "selfservice.flows.registration.after"
 	ViperKeySelfServiceRegistrationBeforeHooks                      = "selfservice.flows.registration.before.hooks"
+	ViperKeySelfServiceRegistrationAfterOnHookFailure               = "selfservice.flows.registration.after.on_hook_failure"
 	ViperKeySelfServiceLoginUI                                      = "selfservice.flows.login.ui_url"
 	ViperKeySelfServiceLoginRequestLifespan                         = "selfservice.flows.login.lifespan"
 	ViperKeySelfServiceLoginAfter                                   = "selfservice.flows.login.after"
 	ViperKeySelfServiceLoginBeforeHooks                             = "selfservice.flows.login.before.hooks"
 	ViperKeySelfServiceErrorUI                                      = "selfservice.flows.error.ui_url"
 	ViperKeySelfServiceLogoutBrowserDefaultReturnTo                 = "selfservice.flows.logout.after." + DefaultBrowserReturnURL
+	ViperKeySelfServiceLogoutAfterHooks                             = "selfservice.flows.logout.after.hooks"
+	ViperKeySelfServiceLogoutSessions                               = "selfservice.flows.logout.sessions"
+	ViperKeySelfServiceLogoutBackChannelURLs                        = "selfservice.flows.logout.propagation.back_channel_urls"
+	ViperKeySelfServiceLogoutFrontChannelURLs                       = "selfservice.flows.logout.propagation.front_channel_urls"
 	ViperKeySelfServiceSettingsURL                                  = "selfservice.flows.settings.ui_url"
 	ViperKeySelfServiceSettingsAfter                                = "selfservice.flows.settings.after"
 	ViperKeySelfServiceSettingsRequestLifespan                      = "selfservice.flows.settings.lifespan"
@@ -78,24 +132,143 @@ const (
 	ViperKeySelfServiceRecoveryUI                                   = "selfservice.flows.recovery.ui_url"
 	ViperKeySelfServiceRecoveryRequestLifespan                      = "selfservice.flows.recovery.lifespan"
 	ViperKeySelfServiceRecoveryBrowserDefaultReturnTo               = "selfservice.flows.recovery.after." + DefaultBrowserReturnURL
+	ViperKeySelfServiceRecoveryExpireLinksOnPasswordChange          = "selfservice.flows.recovery.expire_links_on_password_change"
+	ViperKeySelfServiceRecoveryToken                                = "selfservice.flows.recovery.token"
+	ViperKeySelfServiceRecoveryDeduplicationWindow                  = "selfservice.flows.recovery.deduplication_window"
 	ViperKeySelfServiceVerificationEnabled                          = "selfservice.flows.verification.enabled"
 	ViperKeySelfServiceVerificationUI                               = "selfservice.flows.verification.ui_url"
 	ViperKeySelfServiceVerificationRequestLifespan                  = "selfservice.flows.verification.lifespan"
 	ViperKeySelfServiceVerificationBrowserDefaultReturnTo           = "selfservice.flows.verification.after." + DefaultBrowserReturnURL
+	ViperKeySelfServiceVerificationToken                            = "selfservice.flows.verification.token"
+	ViperKeySelfServiceVerificationDeduplicationWindow              = "selfservice.flows.verification.deduplication_window"
+	ViperKeySelfServiceVerificationRemindersSendAfter               = "selfservice.flows.verification.reminders.send_after"
+	ViperKeySelfServiceVerificationRemindersInterval                = "selfservice.flows.verification.reminders.interval"
+	ViperKeySelfServiceVerificationRemindersMaxPerRun               = "selfservice.flows.verification.reminders.max_per_run"
+	ViperKeySelfServiceVerificationRemindersDryRun                  = "selfservice.flows.verification.reminders.dry_run"
+	ViperKeySelfServiceVerificationRemindersCreatedAfter            = "selfservice.flows.verification.reminders.created_after"
+	ViperKeySelfServiceHostedUIEnabled                              = "selfservice.hosted_ui.enabled"
+	ViperKeySelfServiceHostedUITheme                                = "selfservice.hosted_ui.theme"
+	ViperKeySelfServiceLocalesDefault                               = "selfservice.locales.default"
+	ViperKeySelfServiceLocalesSupported                             = "selfservice.locales.supported"
 	ViperKeyDefaultIdentitySchemaURL                                = "identity.default_schema_url"
 	ViperKeyIdentitySchemas                                         = "identity.schemas"
+	ViperKeyIdentitySchemaLoadingOnFailure                          = "identity.schema_loading.on_failure"
+	ViperKeyIdentitySchemaLoadingMaxAttempts                        = "identity.schema_loading.retry.max_attempts"
+	ViperKeyIdentitySchemaLoadingInitialInterval                    = "identity.schema_loading.retry.initial_interval"
+	ViperKeyIdentitySchemaLoadingMaxInterval                        = "identity.schema_loading.retry.max_interval"
+	ViperKeyIdentifierNormalizationLowercase                        = "identity.identifier_normalization.lowercase"
+	ViperKeyIdentifierNormalizationTrim                             = "identity.identifier_normalization.trim"
+	ViperKeyIdentifierNormalizationStripGmailAliases                = "identity.identifier_normalization.strip_gmail_aliases"
+	ViperKeyUsernamePolicyReserved                                  = "identity.username_policy.reserved"
+	ViperKeyUsernamePolicyPattern                                   = "identity.username_policy.pattern"
+	ViperKeyUsernamePolicyBlockedWords                              = "identity.username_policy.blocked_words"
+	ViperKeyIdentityActivityLastSeenInterval                        = "identity.activity.last_seen_interval"
+	ViperKeyIdentityCredentialsEncrypt                              = "identity.credentials.encrypt"
+	ViperKeyIdentityDormancyDryRun                                  = "identity.dormancy.dry_run"
+	ViperKeyIdentityDormancyInterval                                = "identity.dormancy.interval"
+	ViperKeyIdentityDormancyNotifyAfter                             = "identity.dormancy.notify_after"
+	ViperKeyIdentityDormancyDeactivateAfter                         = "identity.dormancy.deactivate_after"
+	ViperKeyIdentityDormancyDeleteAfter                             = "identity.dormancy.delete_after"
+	ViperKeyIdentityDeletionRetainAnonymized                        = "identity.deletion.retain_anonymized"
+	ViperKeyIdentityDeletionRetention                               = "identity.deletion.retention"
+	ViperKeyIdentityDeletionPreventIdentifierReuse                  = "identity.deletion.prevent_identifier_reuse"
+	ViperKeyClientsHTTPTLSCertPath                                  = "clients.http.tls.cert_path"
+	ViperKeyClientsHTTPTLSKeyPath                                   = "clients.http.tls.key_path"
+	ViperKeyClientsHTTPTLSCAPath                                    = "clients.http.tls.ca_path"
+	ViperKeyClientsHTTPAllowedIPRanges                              = "clients.http.allowed_ip_ranges"
+	ViperKeyEgressProxyURL                                          = "egress.proxy_url"
+	ViperKeyEgressNoProxy                                           = "egress.no_proxy"
+	ViperKeyEgressDependencies                                      = "egress.dependencies"
+	ViperKeyEgressSSRFProtectionEnabled                             = "egress.ssrf_protection.enabled"
+	ViperKeyEgressSSRFProtectionAllowedRanges                       = "egress.ssrf_protection.allowed_ranges"
 	ViperKeyHasherArgon2ConfigMemory                                = "hashers.argon2.memory"
 	ViperKeyHasherArgon2ConfigIterations                            = "hashers.argon2.iterations"
 	ViperKeyHasherArgon2ConfigParallelism                           = "hashers.argon2.parallelism"
 	ViperKeyHasherArgon2ConfigSaltLength                            = "hashers.argon2.salt_length"
 	ViperKeyHasherArgon2ConfigKeyLength                             = "hashers.argon2.key_length"
+	ViperKeyHasherArgon2ConfigVariant                               = "hashers.argon2.variant"
+	ViperKeyHasherArgon2ConfigPepper                                = "hashers.argon2.pepper"
+	ViperKeyHasherArgon2ConfigExpectedDuration                      = "hashers.argon2.expected_duration"
+	ViperKeyHasherArgon2ConfigExpectedDeviation                     = "hashers.argon2.expected_deviation"
+	ViperKeyHasherArgon2ConfigDedicatedMemory                       = "hashers.argon2.dedicated_memory"
+	ViperKeyHasherArgon2ConfigMaxConcurrent                         = "hashers.argon2.max_concurrent"
+	ViperKeyHasherArgon2ConfigMaxWait                               = "hashers.argon2.max_wait"
+	ViperKeyHasherAlgorithm                                         = "hashers.algorithm"
+	ViperKeyHasherBcryptCost                                        = "hashers.bcrypt.cost"
+	ViperKeyHasherPBKDF2Iterations                                  = "hashers.pbkdf2.iterations"
+	ViperKeyHasherScryptCost                                        = "hashers.scrypt.cost"
+	ViperKeyHasherScryptBlockSize                                   = "hashers.scrypt.block_size"
+	ViperKeyHasherScryptParallelism                                 = "hashers.scrypt.parallelism"
 	ViperKeyPasswordMaxBreaches                                     = "selfservice.methods.password.config.max_breaches"
 	ViperKeyIgnoreNetworkErrors                                     = "selfservice.methods.password.config.ignore_network_errors"
+	ViperKeyPasswordMigrationHookURL                                = "selfservice.methods.password.config.migration_hook.url"
 	ViperKeyVersion                                                 = "version"
+	ViperKeySQAOptOut                                               = "sqa-opt-out"
+	ViperKeyTracingSamplingOverrides                                = "tracing.sampling_overrides"
+	ViperKeyPlugins                                                 = "plugins"
+	ViperKeyMaintenanceEnabled                                      = "maintenance.enabled"
+	ViperKeyMaintenanceRetryAfter                                   = "maintenance.retry_after"
+	ViperKeyMaintenanceMessage                                      = "maintenance.message"
+	ViperKeyTelemetryOffline                                        = "telemetry.offline"
+	ViperKeyTelemetryCategories                                     = "telemetry.categories"
+	TelemetryCategoryHealth                                         = "health"
+	TelemetryCategorySelfService                                    = "self_service"
+	TelemetryCategoryIdentities                                     = "identities"
+	TelemetryCategorySessions                                       = "sessions"
+	TelemetryCategoryMetrics                                        = "metrics"
 	Argon2DefaultMemory                                      uint32 = 4 * 1024 * 1024
 	Argon2DefaultIterations                                  uint32 = 4
 	Argon2DefaultSaltLength                                  uint32 = 16
 	Argon2DefaultKeyLength                                   uint32 = 32
+	Argon2DefaultExpectedDuration                                   = 500 * time.Millisecond
+	Argon2DefaultExpectedDeviation                                  = 500 * time.Millisecond
+	Argon2DefaultDedicatedMemory                                    = bytesize.GB
+	Argon2VariantID                                                 = "argon2id"
+	Argon2VariantI                                                  = "argon2i"
+	BcryptDefaultCost                                               = 12
+	PBKDF2DefaultIterations                                         = 10000
+	ScryptDefaultCost                                               = 14
+	ScryptDefaultBlockSize                                          = 8
+	ScryptDefaultParallelism                                        = 1
+	HashAlgorithmArgon2                                             = "argon2"
+	HashAlgorithmBcrypt                                             = "bcrypt"
+	SchemaLoadingFail                                               = "fail"
+	SchemaLoadingRetry                                              = "retry"
+	SchemaLoadingCache                                              = "cache"
+	FlowLogin                                                       = "login"
+	FlowRegistration                                                = "registration"
+	FlowSettings                                                    = "settings"
+	FlowRecovery                                                    = "recovery"
+	FlowVerification                                                = "verification"
+	FlowLogout                                                      = "logout"
+	SMTPTLSModeImplicit                                             = "implicit"
+	SMTPTLSModeStartTLS                                             = "starttls"
+	SMTPTLSModeOpportunisticStartTLS                                = "opportunistic_starttls"
+	SMTPTLSModeNone                                                 = "none"
+)
+
+const (
+	TokenFormatToken = "token"
+	TokenFormatCode  = "code"
+)
+
+const (
+	EgressDependencyHIBP          = "hibp"
+	EgressDependencyOIDC          = "oidc"
+	EgressDependencyWebHooks      = "web_hooks"
+	EgressDependencyRemoteSchemas = "remote_schemas"
+)
+
+const (
+	RegistrationOnHookFailureRollback       = "rollback"
+	RegistrationOnHookFailureDeleteIdentity = "delete_identity"
+	RegistrationOnHookFailureKeepIdentity   = "keep_identity"
+)
+
+const (
+	SessionBindingModeOff    = "off"
+	SessionBindingModeLax    = "lax"
+	SessionBindingModeStrict = "strict"
 )
 
 // DefaultSessionCookieName returns the default cookie name for the kratos session.
@@ -108,27 +281,274 @@ type (
 		Parallelism uint8  `json:"parallelism"`
 		SaltLength  uint32 `json:"salt_length"`
 		KeyLength   uint32 `json:"key_length"`
+		Variant     string `json:"variant,omitempty"`
+
+		// ExpectedDuration, ExpectedDeviation, and DedicatedMemory are used to calibrate the other values.
+		ExpectedDuration  time.Duration     `json:"-"`
+		ExpectedDeviation time.Duration     `json:"-"`
+		DedicatedMemory   bytesize.ByteSize `json:"-"`
+
+		// MaxConcurrent limits how many passwords are hashed at the same time. If MaxConcurrent or MaxWait is set,
+		// hashes also wait until the memory used by all running hashes stays within DedicatedMemory.
+		MaxConcurrent int `json:"-"`
+
+		// MaxWait is the longest a hash waits for the limits before it is rejected as overloaded. Zero waits
+		// until the request is canceled.
+		MaxWait time.Duration `json:"-"`
+	}
+	Bcrypt struct {
+		Cost uint32 `json:"cost"`
+	}
+	// PBKDF2 contains the parameters used to verify imported PBKDF2 hashes which do not include them.
+	PBKDF2 struct {
+		Iterations int `json:"iterations"`
+	}
+	// Scrypt contains the parameters used to verify imported scrypt hashes which do not include them. The cost is
+	// the base-2 logarithm of the CPU/memory cost N.
+	Scrypt struct {
+		Cost        uint `json:"cost"`
+		BlockSize   int  `json:"block_size"`
+		Parallelism int  `json:"parallelism"`
+	}
+	SessionWhoami struct {
+		Traits                   []string `json:"traits"`
+		VerifiableAddresses      bool     `json:"verifiable_addresses"`
+		RecoveryAddresses        bool     `json:"recovery_addresses"`
+		CommunicationPreferences bool     `json:"communication_preferences"`
+		Device                   bool     `json:"device"`
 	}
 	SelfServiceHook struct {
 		Name   string          `json:"hook"`
 		Config json.RawMessage `json:"config"`
 	}
 	SelfServiceStrategy struct {
-		Enabled bool            `json:"enabled"`
-		Config  json.RawMessage `json:"config"`
+		Enabled bool `json:"enabled"`
+		// EnabledFlows limits the strategy to these flows. If empty, the strategy is enabled for all flows.
+		EnabledFlows []string        `json:"enabled_flows"`
+		Config       json.RawMessage `json:"config"`
 	}
 	Schema struct {
 		ID  string `json:"id"`
 		URL string `json:"url"`
 	}
+	SchemaLoading struct {
+		// OnFailure is one of SchemaLoadingFail, SchemaLoadingRetry, and SchemaLoadingCache.
+		OnFailure       string
+		MaxAttempts     uint64
+		InitialInterval time.Duration
+		MaxInterval     time.Duration
+	}
+	IdentifierNormalization struct {
+		// Lowercase converts identifiers and addresses to lower case.
+		Lowercase bool
+		// Trim removes leading and trailing whitespace.
+		Trim bool
+		// StripGmailAliases removes dots and `+` suffixes from Gmail addresses.
+		StripGmailAliases bool
+	}
+	UsernamePolicy struct {
+		// Reserved are usernames which can not be registered, compared case-insensitively.
+		Reserved []string
+		// Pattern is a regular expression usernames must match.
+		Pattern string
+		// BlockedWords are words usernames must not contain, compared case-insensitively.
+		BlockedWords []string
+	}
+	IdentityDormancy struct {
+		// DryRun reports which identities would be notified, deactivated, or deleted without doing so.
+		DryRun bool
+		// Interval is how often the dormancy policy is enforced.
+		Interval time.Duration
+		// NotifyAfter, DeactivateAfter, and DeleteAfter are the periods of inactivity after which an
+		// identity is notified, deactivated, or deleted. Zero disables the step.
+		NotifyAfter     time.Duration
+		DeactivateAfter time.Duration
+		DeleteAfter     time.Duration
+	}
+	VerificationReminders struct {
+		// DryRun reports which addresses would be reminded without sending reminders.
+		DryRun bool
+		// Interval is how often due reminders are sent.
+		Interval time.Duration
+		// SendAfter are the periods, in ascending order, after which a reminder is sent to an address which is
+		// still unverified. Reminders are disabled if it is empty.
+		SendAfter []time.Duration
+		// MaxPerRun caps how many reminders are sent per run. Zero disables the cap.
+		MaxPerRun int
+		// CreatedAfter skips addresses which were added before it, unless it is zero.
+		CreatedAfter time.Time
+	}
+	IdentityDeletion struct {
+		// RetainAnonymized keeps an anonymized stub with the hashed identifiers and timestamps of deleted identities.
+		RetainAnonymized bool
+		// Retention is how long anonymized stubs are kept. Zero keeps them forever.
+		Retention time.Duration
+		// PreventIdentifierReuse rejects new identities using an identifier of a retained stub.
+		PreventIdentifierReuse bool
+	}
+	// Listener is the address an interface listens on, either a TCP host and port or a UNIX domain socket.
+	Listener struct {
+		// Network is either "tcp" or "unix".
+		Network string
+		// Address is host:port for TCP and the path of the socket for UNIX domain sockets.
+		Address string
+		// Owner, Group, and Mode are applied to the file of a UNIX domain socket. Owner and Group are names or
+		// IDs; if empty, the owner or group is not changed.
+		Owner string
+		Group string
+		Mode  os.FileMode
+	}
+	// TLS configures the certificate an interface is served with. The certificate and key are either read from
+	// files, which are reloaded when they change, or given inline as base64 encoded PEM.
+	TLS struct {
+		CertPath   string
+		KeyPath    string
+		CertBase64 string
+		KeyBase64  string
+	}
+	HTTPClient struct {
+		// CertPath and KeyPath locate the PEM encoded client certificate presented to servers requiring mutual TLS.
+		CertPath string
+		KeyPath  string
+		// CAPath locates PEM encoded certificate authorities trusted in addition to the system's.
+		CAPath string
+		// AllowedIPRanges are the CIDR ranges connections are restricted to if set.
+		AllowedIPRanges []string
+	}
+	Egress struct {
+		// ProxyURL is the proxy all outgoing requests are sent through. If nil, the proxy is read from the environment.
+		ProxyURL *url.URL
+		// NoProxy are the hosts, domains, and IP ranges reached without the proxy.
+		NoProxy []string
+		// SSRFProtection refuses connections to private IP addresses unless they are within SSRFAllowedRanges.
+		SSRFProtection    bool
+		SSRFAllowedRanges []string
+	}
+	EgressDependency struct {
+		// Timeout cancels requests to the dependency which take longer.
+		Timeout time.Duration
+		// FailureThreshold is the number of consecutive failures after which the circuit breaker opens. 0 disables
+		// the circuit breaker.
+		FailureThreshold int
+		// OpenFor is how long requests fail fast after the circuit breaker opened.
+		OpenFor time.Duration
+	}
+	TokenFormat struct {
+		// Format is either `token` or `code`.
+		Format string
+		// Length is the number of characters.
+		Length int
+		// Alphabet are the characters tokens are generated from. Codes always consist of digits.
+		Alphabet string
+	}
+	SessionBinding struct {
+		// Mode is one of SessionBindingModeOff, SessionBindingModeLax, and SessionBindingModeStrict.
+		Mode string
+		// IP binds the session to the IP range of the device it was issued to.
+		IP bool
+		// UserAgent binds the session to the browser and operating system of the device it was issued to.
+		UserAgent bool
+		// IPv4PrefixLength is the number of leading bits of IPv4 addresses which must match.
+		IPv4PrefixLength int
+		// IPv6PrefixLength is the number of leading bits of IPv6 addresses which must match.
+		IPv6PrefixLength int
+	}
+	HostedUITheme struct {
+		// LogoURL is shown above the forms of the hosted UI.
+		LogoURL string `json:"logo_url"`
+		// CSSURL is loaded after the stylesheet of the hosted UI and may override all of its styles.
+		CSSURL      string         `json:"css_url"`
+		Colors      HostedUIColors `json:"colors"`
+		FooterLinks []HostedUILink `json:"footer_links"`
+	}
+	HostedUIColors struct {
+		Primary    string `json:"primary"`
+		Background string `json:"background"`
+		Text       string `json:"text"`
+		Error      string `json:"error"`
+	}
+	HostedUILink struct {
+		Title string `json:"title"`
+		URL   string `json:"url"`
+	}
+	// SMTPClientConfig configures the connection to the SMTP server.
+	SMTPClientConfig struct {
+		Host     string
+		Port     int
+		Username string
+		Password string
+
+		// TLSMode is one of SMTPTLSModeImplicit, SMTPTLSModeStartTLS, SMTPTLSModeOpportunisticStartTLS, and
+		// SMTPTLSModeNone.
+		TLSMode string
+
+		// TLSSkipVerify disables the verification of the SMTP server's certificate.
+		TLSSkipVerify bool
+
+		// LocalName is the host name sent with HELO and EHLO.
+		LocalName string
+
+		// ConnectTimeout is the timeout for connecting to the SMTP server and for each command.
+		ConnectTimeout time.Duration
+
+		// ClientCertificate is presented to SMTP servers which require TLS client authentication.
+		ClientCertificate *tls.Certificate
+	}
+	TracingSamplingOverride struct {
+		Path string  `json:"path"`
+		Rate float64 `json:"rate"`
+	}
+	CourierTemplateLimit struct {
+		// Template is the type of the template, for example `verification_valid`.
+		Template string `json:"template"`
+		// MaxPerDay is the number of messages of the template sent to a recipient within 24 hours. Zero
+		// disables the cap.
+		MaxPerDay int `json:"max_per_day"`
+		// SendingWindow restricts the hours messages of the template are sent in.
+		SendingWindow *CourierSendingWindow `json:"sending_window"`
+	}
+	CourierSendingWindow struct {
+		// Start is the hour messages are sent from.
+		Start int `json:"start"`
+		// End is the hour messages are sent until (exclusive). If End is before Start, the window spans midnight.
+		End int `json:"end"`
+		// Timezone is the IANA name of the time zone of Start and End. Defaults to UTC.
+		Timezone string `json:"timezone"`
+	}
+	CourierTemplateModel struct {
+		// Template is the type of the template, for example `recovery_valid`.
+		Template string `json:"template"`
+		// MapperURL is the URL of the Jsonnet code returning the values templates access as `.Extra`.
+		MapperURL string `json:"mapper_url"`
+		// WebHook is called with the model before the Jsonnet code is evaluated. Its response is available to the
+		// Jsonnet code. Optional.
+		WebHook *CourierTemplateModelWebHook `json:"web_hook"`
+	}
+	CourierTemplateModelWebHook struct {
+		// URL is the endpoint which is called.
+		URL string `json:"url"`
+		// Headers are added to the request, for example to authenticate ORY Kratos.
+		Headers map[string]string `json:"headers"`
+	}
+	Plugin struct {
+		Name    string   `json:"name"`
+		Command string   `json:"command"`
+		Args    []string `json:"args"`
+	}
 	PasswordPolicy struct {
 		MaxBreaches         uint `json:"max_breaches"`
 		IgnoreNetworkErrors bool `json:"ignore_network_errors"`
 	}
 	Schemas []Schema
 	Config  struct {
-		l *logrusx.Logger
-		p *configx.Provider
+		l          *logrusx.Logger
+		p          *configx.Provider
+		resolved   *resolvedSecrets
+		subs       *subscriptions
+		marshaled  *marshaledConfig
+		snapshots  *snapshots
+		contextual *contextualConfigs
+		frozen     bool
 	}
 
 	Provider interface {
@@ -161,11 +581,13 @@ func MustNew(l *logrusx.Logger, opts ...configx.OptionModifier) *Config {
 }
 
 func New(l *logrusx.Logger, opts ...configx.OptionModifier) (*Config, error) {
+	c := &Config{l: l, resolved: &resolvedSecrets{values: map[string]resolvedSecret{}}, subs: new(subscriptions), marshaled: new(marshaledConfig), snapshots: new(snapshots), contextual: &contextualConfigs{configs: map[string]*Config{}}}
 	opts = append([]configx.OptionModifier{
 		configx.WithStderrValidationReporter(),
-		configx.OmitKeysFromTracing("dsn", "secrets.default", "secrets.cookie", "client_secret"),
-		configx.WithImmutables("serve", "profiling", "log"),
+		configx.OmitKeysFromTracing(sensitiveKeys...),
+		configx.WithImmutables(immutableKeys...),
 		configx.WithLogrusWatcher(l),
+		configx.AttachWatcher(c.watcher),
 	}, opts...)
 
 	p, err := configx.New(ValidationSchema, opts...)
@@ -174,7 +596,8 @@ func New(l *logrusx.Logger, opts ...configx.OptionModifier) (*Config, error) {
 	}
 
 	l.UseConfig(p)
-	return &Config{l: l, p: p}, nil
+	c.p = p
+	return c, nil
 }
 
 func (p *Config) Source() *configx.Provider {
@@ -202,11 +625,18 @@ func (p *Config) cors(prefix string) (cors.Options, bool) {
 }
 
 func (p *Config) Set(key string, value interface{}) error {
-	return p.p.Set(key, value)
+	if p.frozen {
+		return errors.WithStack(ErrSnapshotImmutable)
+	}
+	if err := p.p.Set(key, value); err != nil {
+		return err
+	}
+	p.notifySubscribers()
+	return nil
 }
 
 func (p *Config) MustSet(key string, value interface{}) {
-	if err := p.p.Set(key, value); err != nil {
+	if err := p.Set(key, value); err != nil {
 		p.l.WithError(err).Fatalf("Unable to set \"%s\" to \"%s\".", key, value)
 	}
 }
@@ -224,6 +654,20 @@ func (p *Config) SessionPath() string {
 }
 
 func (p *Config) HasherArgon2() *Argon2 {
+	c, err := p.HasherArgon2OrErr()
+	if err != nil {
+		p.l.WithError(err).Fatal("Unable to load the argon2 hasher configuration.")
+	}
+	return c
+}
+
+// HasherArgon2OrErr is like HasherArgon2 but returns an error if the configuration is invalid.
+func (p *Config) HasherArgon2OrErr() (*Argon2, error) {
+	dedicatedMemory, err := p.byteSize(ViperKeyHasherArgon2ConfigDedicatedMemory, Argon2DefaultDedicatedMemory)
+	if err != nil {
+		return nil, err
+	}
+
 	// warn about usage of default values and point to the docs
 	// warning will require https://github.com/ory/viper/issues/19
 	return &Argon2{
@@ -232,10 +676,68 @@ func (p *Config) HasherArgon2() *Argon2 {
 		Parallelism: uint8(p.p.IntF(ViperKeyHasherArgon2ConfigParallelism, int(Argon2DefaultParallelism))),
 		SaltLength:  uint32(p.p.IntF(ViperKeyHasherArgon2ConfigSaltLength, int(Argon2DefaultSaltLength))),
 		KeyLength:   uint32(p.p.IntF(ViperKeyHasherArgon2ConfigKeyLength, int(Argon2DefaultKeyLength))),
+		Variant:     p.p.StringF(ViperKeyHasherArgon2ConfigVariant, Argon2VariantID),
+
+		ExpectedDuration:  p.p.DurationF(ViperKeyHasherArgon2ConfigExpectedDuration, Argon2DefaultExpectedDuration),
+		ExpectedDeviation: p.p.DurationF(ViperKeyHasherArgon2ConfigExpectedDeviation, Argon2DefaultExpectedDeviation),
+		DedicatedMemory:   dedicatedMemory,
+		MaxConcurrent:     p.p.IntF(ViperKeyHasherArgon2ConfigMaxConcurrent, 0),
+		MaxWait:           p.p.DurationF(ViperKeyHasherArgon2ConfigMaxWait, 0),
+	}, nil
+}
+
+func (p *Config) byteSize(key string, fallback bytesize.ByteSize) (bytesize.ByteSize, error) {
+	if !p.p.Exists(key) {
+		return fallback, nil
 	}
+
+	b, err := bytesize.Parse(p.p.String(key))
+	if err != nil {
+		return 0, invalidValueError(key, "is not a valid byte size", err)
+	}
+	return b, nil
 }
 
-func (p *Config) listenOn(key string) string {
+func (p *Config) HasherBcrypt() *Bcrypt {
+	return &Bcrypt{
+		Cost: uint32(p.p.IntF(ViperKeyHasherBcryptCost, BcryptDefaultCost)),
+	}
+}
+
+func (p *Config) HasherPBKDF2() *PBKDF2 {
+	return &PBKDF2{
+		Iterations: p.p.IntF(ViperKeyHasherPBKDF2Iterations, PBKDF2DefaultIterations),
+	}
+}
+
+func (p *Config) HasherScrypt() *Scrypt {
+	return &Scrypt{
+		Cost:        uint(p.p.IntF(ViperKeyHasherScryptCost, ScryptDefaultCost)),
+		BlockSize:   p.p.IntF(ViperKeyHasherScryptBlockSize, ScryptDefaultBlockSize),
+		Parallelism: p.p.IntF(ViperKeyHasherScryptParallelism, ScryptDefaultParallelism),
+	}
+}
+
+// HasherAlgorithm returns the algorithm used for new password hashes, either HashAlgorithmArgon2 or
+// HashAlgorithmBcrypt.
+func (p *Config) HasherAlgorithm() string {
+	if p.p.String(ViperKeyHasherAlgorithm) == HashAlgorithmBcrypt {
+		return HashAlgorithmBcrypt
+	}
+	return HashAlgorithmArgon2
+}
+
+func (p *Config) listenOn(key string) (Listener, error) {
+	if path := p.p.String("serve." + key + ".socket.path"); len(path) > 0 {
+		return Listener{
+			Network: "unix",
+			Address: path,
+			Owner:   p.p.String("serve." + key + ".socket.owner"),
+			Group:   p.p.String("serve." + key + ".socket.group"),
+			Mode:    os.FileMode(p.p.IntF("serve."+key+".socket.mode", 0755)),
+		}, nil
+	}
+
 	fb := 4433
 	if key == "admin" {
 		fb = 4434
@@ -243,67 +745,231 @@ func (p *Config) listenOn(key string) string {
 
 	port := p.p.IntF("serve."+key+".port", fb)
 	if port < 1 {
-		p.l.Fatalf("serve.%s.port can not be zero or negative", key)
+		return Listener{}, invalidValueError("serve."+key+".port", "can not be zero or negative", nil)
 	}
 
-	return fmt.Sprintf("%s:%d", p.p.String("serve."+key+".host"), port)
+	return Listener{Network: "tcp", Address: fmt.Sprintf("%s:%d", p.p.String("serve."+key+".host"), port)}, nil
+}
+
+// String returns the address, prefixed with "unix:" for UNIX domain sockets.
+func (l Listener) String() string {
+	if l.Network == "unix" {
+		return "unix:" + l.Address
+	}
+	return l.Address
 }
 
 func (p *Config) DefaultIdentityTraitsSchemaURL() *url.URL {
 	return p.parseURIOrFail(ViperKeyDefaultIdentitySchemaURL)
 }
 
+// DefaultIdentityTraitsSchemaURLOrErr is like DefaultIdentityTraitsSchemaURL but returns an error if the value is
+// not a valid URL.
+func (p *Config) DefaultIdentityTraitsSchemaURLOrErr() (*url.URL, error) {
+	return p.parseURI(ViperKeyDefaultIdentitySchemaURL)
+}
+
 func (p *Config) IdentityTraitsSchemas() Schemas {
+	ss, err := p.IdentityTraitsSchemasOrErr()
+	if err != nil {
+		p.l.WithError(err).Fatalf("Unable to decode values from %s.", ViperKeyIdentitySchemas)
+	}
+	return ss
+}
+
+// IdentityTraitsSchemasOrErr is like IdentityTraitsSchemas but returns an error if the schemas can not be decoded.
+func (p *Config) IdentityTraitsSchemasOrErr() (Schemas, error) {
+	u, err := p.DefaultIdentityTraitsSchemaURLOrErr()
+	if err != nil {
+		return nil, err
+	}
+
 	ds := Schema{
 		ID:  DefaultIdentityTraitsSchemaID,
-		URL: p.DefaultIdentityTraitsSchemaURL().String(),
+		URL: u.String(),
 	}
 
 	if !p.p.Exists(ViperKeyIdentitySchemas) {
-		return Schemas{ds}
+		return Schemas{ds}, nil
 	}
 
 	var ss Schemas
-	out, err := p.p.Marshal(kjson.Parser())
+	out, err := p.marshal()
 	if err != nil {
-		p.l.WithError(err).Fatalf("Unable to dencode values from %s.", ViperKeyIdentitySchemas)
-		return Schemas{ds}
+		return nil, invalidValueError(ViperKeyIdentitySchemas, "can not be decoded", err)
 	}
 
 	config := gjson.GetBytes(out, ViperKeyIdentitySchemas).Raw
 	if len(config) == 0 {
-		return Schemas{ds}
+		return Schemas{ds}, nil
 	}
 
 	if err := json.NewDecoder(bytes.NewBufferString(config)).Decode(&ss); err != nil {
-		p.l.WithError(err).Fatalf("Unable to encode values from %s.", ViperKeyIdentitySchemas)
-		return Schemas{ds}
+		return nil, invalidValueError(ViperKeyIdentitySchemas, "can not be decoded", err)
+	}
+
+	return append(ss, ds), nil
+}
+
+// IdentitySchemaLoading returns what happens if a remote identity schema can not be loaded.
+func (p *Config) IdentitySchemaLoading() SchemaLoading {
+	return SchemaLoading{
+		OnFailure:       p.p.StringF(ViperKeyIdentitySchemaLoadingOnFailure, SchemaLoadingFail),
+		MaxAttempts:     uint64(p.p.IntF(ViperKeyIdentitySchemaLoadingMaxAttempts, 5)),
+		InitialInterval: p.p.DurationF(ViperKeyIdentitySchemaLoadingInitialInterval, 500*time.Millisecond),
+		MaxInterval:     p.p.DurationF(ViperKeyIdentitySchemaLoadingMaxInterval, 5*time.Second),
+	}
+}
+
+// IdentifierNormalization returns the rules which are applied to identifiers and addresses before they are
+// stored or looked up.
+func (p *Config) IdentifierNormalization() IdentifierNormalization {
+	return IdentifierNormalization{
+		Lowercase:         p.p.BoolF(ViperKeyIdentifierNormalizationLowercase, true),
+		Trim:              p.p.BoolF(ViperKeyIdentifierNormalizationTrim, false),
+		StripGmailAliases: p.p.BoolF(ViperKeyIdentifierNormalizationStripGmailAliases, false),
 	}
+}
+
+// UsernamePolicy returns the rules for password identifiers which are not email addresses.
+func (p *Config) UsernamePolicy() UsernamePolicy {
+	return UsernamePolicy{
+		Reserved:     p.p.Strings(ViperKeyUsernamePolicyReserved),
+		Pattern:      p.p.String(ViperKeyUsernamePolicyPattern),
+		BlockedWords: p.p.Strings(ViperKeyUsernamePolicyBlockedWords),
+	}
+}
+
+// IdentityActivityLastSeenInterval returns how often the time an identity was last seen is updated at most.
+func (p *Config) IdentityActivityLastSeenInterval() time.Duration {
+	return p.p.DurationF(ViperKeyIdentityActivityLastSeenInterval, time.Hour)
+}
+
+// IdentityCredentialsEncrypt returns true if the configuration of identity credentials, for example the tokens
+// of social sign in providers, should be encrypted in the database.
+func (p *Config) IdentityCredentialsEncrypt() bool {
+	return p.p.Bool(ViperKeyIdentityCredentialsEncrypt)
+}
+
+// IdentityDormancy returns the policy for identities which have been inactive for a long time.
+func (p *Config) IdentityDormancy() IdentityDormancy {
+	return IdentityDormancy{
+		DryRun:          p.p.Bool(ViperKeyIdentityDormancyDryRun),
+		Interval:        p.p.DurationF(ViperKeyIdentityDormancyInterval, 24*time.Hour),
+		NotifyAfter:     p.p.DurationF(ViperKeyIdentityDormancyNotifyAfter, 0),
+		DeactivateAfter: p.p.DurationF(ViperKeyIdentityDormancyDeactivateAfter, 0),
+		DeleteAfter:     p.p.DurationF(ViperKeyIdentityDormancyDeleteAfter, 0),
+	}
+}
+
+// TLS returns the TLS configuration of the public or admin interface.
+func (p *Config) TLS(iface string) TLS {
+	return TLS{
+		CertPath:   p.p.String("serve." + iface + ".tls.cert.path"),
+		KeyPath:    p.p.String("serve." + iface + ".tls.key.path"),
+		CertBase64: p.p.String("serve." + iface + ".tls.cert.base64"),
+		KeyBase64:  p.p.String("serve." + iface + ".tls.key.base64"),
+	}
+}
 
-	return append(ss, ds)
+// Enabled returns true if a certificate and key are configured.
+func (t TLS) Enabled() bool {
+	return (len(t.CertPath) > 0 || len(t.CertBase64) > 0) && (len(t.KeyPath) > 0 || len(t.KeyBase64) > 0)
 }
 
-func (p *Config) AdminListenOn() string {
+// HTTPClient returns the configuration of the HTTP client calling web hooks and fetching claims mappers.
+func (p *Config) HTTPClient() HTTPClient {
+	return HTTPClient{
+		CertPath:        p.p.String(ViperKeyClientsHTTPTLSCertPath),
+		KeyPath:         p.p.String(ViperKeyClientsHTTPTLSKeyPath),
+		CAPath:          p.p.String(ViperKeyClientsHTTPTLSCAPath),
+		AllowedIPRanges: p.p.Strings(ViperKeyClientsHTTPAllowedIPRanges),
+	}
+}
+
+// Egress returns the proxy and SSRF protection configuration of outgoing HTTP requests.
+func (p *Config) Egress() Egress {
+	return Egress{
+		ProxyURL:          p.p.RequestURIF(ViperKeyEgressProxyURL, nil),
+		NoProxy:           p.p.Strings(ViperKeyEgressNoProxy),
+		SSRFProtection:    p.p.Bool(ViperKeyEgressSSRFProtectionEnabled),
+		SSRFAllowedRanges: p.p.Strings(ViperKeyEgressSSRFProtectionAllowedRanges),
+	}
+}
+
+// EgressDependency returns the timeout and circuit breaker of outgoing requests to the dependency, for example
+// EgressDependencyHIBP.
+func (p *Config) EgressDependency(dependency string) EgressDependency {
+	key := ViperKeyEgressDependencies + "." + dependency
+	return EgressDependency{
+		Timeout:          p.p.DurationF(key+".timeout", 10*time.Second),
+		FailureThreshold: p.p.IntF(key+".circuit_breaker.failure_threshold", 0),
+		OpenFor:          p.p.DurationF(key+".circuit_breaker.open_for", 30*time.Second),
+	}
+}
+
+// IdentityDeletion returns what is retained when identities are deleted.
+func (p *Config) IdentityDeletion() IdentityDeletion {
+	return IdentityDeletion{
+		RetainAnonymized:       p.p.Bool(ViperKeyIdentityDeletionRetainAnonymized),
+		Retention:              p.p.DurationF(ViperKeyIdentityDeletionRetention, 8760*time.Hour),
+		PreventIdentifierReuse: p.p.BoolF(ViperKeyIdentityDeletionPreventIdentifierReuse, true),
+	}
+}
+
+// AdminListenOn returns where the admin API listens, either serve.admin.host and serve.admin.port or the UNIX domain
+// socket at serve.admin.socket.path.
+func (p *Config) AdminListenOn() Listener {
+	l, err := p.AdminListenOnOrErr()
+	if err != nil {
+		p.l.WithError(err).Fatal("Unable to determine where the admin API listens.")
+	}
+	return l
+}
+
+// AdminListenOnOrErr is like AdminListenOn but returns an error if the configuration is invalid.
+func (p *Config) AdminListenOnOrErr() (Listener, error) {
 	return p.listenOn("admin")
 }
 
-func (p *Config) PublicListenOn() string {
+// PublicListenOn returns where the public API listens, either serve.public.host and serve.public.port or the UNIX
+// domain socket at serve.public.socket.path.
+func (p *Config) PublicListenOn() Listener {
+	l, err := p.PublicListenOnOrErr()
+	if err != nil {
+		p.l.WithError(err).Fatal("Unable to determine where the public API listens.")
+	}
+	return l
+}
+
+// PublicListenOnOrErr is like PublicListenOn but returns an error if the configuration is invalid.
+func (p *Config) PublicListenOnOrErr() (Listener, error) {
 	return p.listenOn("public")
 }
 
+// DSN returns the data source name. A DSN of the form vault://<path>#<key> or kms://<provider>/<path> is
+// fetched from the secrets provider.
 func (p *Config) DSN() string {
-	dsn := p.p.String(ViperKeyDSN)
+	dsn, err := p.DSNOrErr()
+	if err != nil {
+		p.l.WithError(err).Fatal("dsn must be set")
+	}
+	return dsn
+}
+
+// DSNOrErr is like DSN but returns an error if no data source name is set.
+func (p *Config) DSNOrErr() (string, error) {
+	dsn := p.remote(ViperKeyDSN)
 
 	if dsn == "memory" {
-		return DefaultSQLiteMemoryDSN
+		return DefaultSQLiteMemoryDSN, nil
 	}
 
 	if len(dsn) > 0 {
-		return dsn
+		return dsn, nil
 	}
 
-	p.l.Fatal("dsn must be set")
-	return ""
+	return "", invalidValueError(ViperKeyDSN, "must be set", nil)
 }
 
 func (p *Config) DisableAPIFlowEnforcement() bool {
@@ -322,32 +988,62 @@ func (p *Config) SelfServiceFlowRecoveryEnabled() bool {
 	return p.p.Bool(ViperKeySelfServiceRecoveryEnabled)
 }
 
+func (p *Config) SelfServiceFlowRegistrationEnabled() bool {
+	return p.p.BoolF(ViperKeySelfServiceRegistrationEnabled, true)
+}
+
+func (p *Config) SelfServiceFlowLoginEnabled() bool {
+	return p.p.BoolF(ViperKeySelfServiceLoginEnabled, true)
+}
+
+func (p *Config) SelfServiceFlowSettingsEnabled() bool {
+	return p.p.BoolF(ViperKeySelfServiceSettingsEnabled, true)
+}
+
 func (p *Config) SelfServiceFlowLoginBeforeHooks() []SelfServiceHook {
+	return p.mustSelfServiceHooks(ViperKeySelfServiceLoginBeforeHooks)
+}
+
+// SelfServiceFlowLoginBeforeHooksOrErr is like SelfServiceFlowLoginBeforeHooks but returns an error if the hooks can not be decoded.
+func (p *Config) SelfServiceFlowLoginBeforeHooksOrErr() ([]SelfServiceHook, error) {
 	return p.selfServiceHooks(ViperKeySelfServiceLoginBeforeHooks)
 }
 
 func (p *Config) SelfServiceFlowRegistrationBeforeHooks() []SelfServiceHook {
+	return p.mustSelfServiceHooks(ViperKeySelfServiceRegistrationBeforeHooks)
+}
+
+// SelfServiceFlowRegistrationBeforeHooksOrErr is like SelfServiceFlowRegistrationBeforeHooks but returns an error if the hooks can not be decoded.
+func (p *Config) SelfServiceFlowRegistrationBeforeHooksOrErr() ([]SelfServiceHook, error) {
 	return p.selfServiceHooks(ViperKeySelfServiceRegistrationBeforeHooks)
 }
 
-func (p *Config) selfServiceHooks(key string) []SelfServiceHook {
+func (p *Config) mustSelfServiceHooks(key string) []SelfServiceHook {
+	hooks, err := p.selfServiceHooks(key)
+	if err != nil {
+		p.l.WithError(err).Fatalf("Unable to decode values from configuration key: %s", key)
+	}
+	return hooks
+}
+
+func (p *Config) selfServiceHooks(key string) ([]SelfServiceHook, error) {
 	var hooks []SelfServiceHook
 	if !p.p.Exists(key) {
-		return []SelfServiceHook{}
+		return []SelfServiceHook{}, nil
 	}
 
-	out, err := p.p.Marshal(kjson.Parser())
+	out, err := p.marshal()
 	if err != nil {
-		p.l.WithError(err).Fatalf("Unable to decode values from configuration key: %s", key)
+		return nil, invalidValueError(key, "can not be decoded", err)
 	}
 
 	config := gjson.GetBytes(out, key).Raw
 	if len(config) == 0 {
-		return []SelfServiceHook{}
+		return []SelfServiceHook{}, nil
 	}
 
 	if err := jsonx.NewStrictDecoder(bytes.NewBufferString(config)).Decode(&hooks); err != nil {
-		p.l.WithError(err).Fatalf("Unable to encode value \"%s\" from configuration key: %s", config, key)
+		return nil, invalidValueError(key, "can not be decoded", err)
 	}
 
 	for k := range hooks {
@@ -356,24 +1052,58 @@ func (p *Config) selfServiceHooks(key string) []SelfServiceHook {
 		}
 	}
 
-	return hooks
+	return hooks, nil
 }
 
 func (p *Config) SelfServiceFlowLoginAfterHooks(strategy string) []SelfServiceHook {
+	return p.mustSelfServiceHooks(HookStrategyKey(ViperKeySelfServiceLoginAfter, strategy))
+}
+
+// SelfServiceFlowLoginAfterHooksOrErr is like SelfServiceFlowLoginAfterHooks but returns an error if the hooks can not be decoded.
+func (p *Config) SelfServiceFlowLoginAfterHooksOrErr(strategy string) ([]SelfServiceHook, error) {
 	return p.selfServiceHooks(HookStrategyKey(ViperKeySelfServiceLoginAfter, strategy))
 }
 
 func (p *Config) SelfServiceFlowSettingsAfterHooks(strategy string) []SelfServiceHook {
+	return p.mustSelfServiceHooks(HookStrategyKey(ViperKeySelfServiceSettingsAfter, strategy))
+}
+
+// SelfServiceFlowSettingsAfterHooksOrErr is like SelfServiceFlowSettingsAfterHooks but returns an error if the hooks can not be decoded.
+func (p *Config) SelfServiceFlowSettingsAfterHooksOrErr(strategy string) ([]SelfServiceHook, error) {
 	return p.selfServiceHooks(HookStrategyKey(ViperKeySelfServiceSettingsAfter, strategy))
 }
 
 func (p *Config) SelfServiceFlowRegistrationAfterHooks(strategy string) []SelfServiceHook {
+	return p.mustSelfServiceHooks(HookStrategyKey(ViperKeySelfServiceRegistrationAfter, strategy))
+}
+
+// SelfServiceFlowRegistrationAfterHooksOrErr is like SelfServiceFlowRegistrationAfterHooks but returns an error if the hooks can not be decoded.
+func (p *Config) SelfServiceFlowRegistrationAfterHooksOrErr(strategy string) ([]SelfServiceHook, error) {
 	return p.selfServiceHooks(HookStrategyKey(ViperKeySelfServiceRegistrationAfter, strategy))
 }
 
+// SelfServiceFlowRegistrationOnHookFailure returns what happens to a newly registered identity if one of the
+// hooks which run after the identity was persisted fails. The identity is kept unless configured otherwise.
+func (p *Config) SelfServiceFlowRegistrationOnHookFailure() string {
+	switch v := p.p.String(ViperKeySelfServiceRegistrationAfterOnHookFailure); v {
+	case RegistrationOnHookFailureRollback, RegistrationOnHookFailureDeleteIdentity:
+		return v
+	}
+	return RegistrationOnHookFailureKeepIdentity
+}
+
+func (p *Config) SelfServiceFlowLogoutAfterHooks() []SelfServiceHook {
+	return p.mustSelfServiceHooks(ViperKeySelfServiceLogoutAfterHooks)
+}
+
+// SelfServiceFlowLogoutAfterHooksOrErr is like SelfServiceFlowLogoutAfterHooks but returns an error if the hooks can not be decoded.
+func (p *Config) SelfServiceFlowLogoutAfterHooksOrErr() ([]SelfServiceHook, error) {
+	return p.selfServiceHooks(ViperKeySelfServiceLogoutAfterHooks)
+}
+
 func (p *Config) SelfServiceStrategy(strategy string) *SelfServiceStrategy {
 	config := "{}"
-	out, err := p.p.Marshal(kjson.Parser())
+	out, err := p.marshal()
 	if err != nil {
 		p.l.WithError(err).Warn("Unable to marshal self service strategy configuration.")
 	} else if c := gjson.GetBytes(out,
@@ -383,8 +1113,9 @@ func (p *Config) SelfServiceStrategy(strategy string) *SelfServiceStrategy {
 
 	enabledKey := fmt.Sprintf("%s.%s.enabled", ViperKeySelfServiceStrategyConfig, strategy)
 	s := &SelfServiceStrategy{
-		Enabled: p.p.Bool(enabledKey),
-		Config:  json.RawMessage(config),
+		Enabled:      p.p.Bool(enabledKey),
+		EnabledFlows: p.p.Strings(fmt.Sprintf("%s.%s.enabled_flows", ViperKeySelfServiceStrategyConfig, strategy)),
+		Config:       json.RawMessage(config),
 	}
 
 	// The default value can easily be overwritten by setting e.g. `{"selfservice": "null"}` which means that
@@ -407,40 +1138,146 @@ func (p *Config) SelfServiceStrategy(strategy string) *SelfServiceStrategy {
 	return s
 }
 
+// EnabledFor returns true if the strategy is enabled and may be used in the given flow (e.g. FlowLogin).
+func (s *SelfServiceStrategy) EnabledFor(flow string) bool {
+	if !s.Enabled {
+		return false
+	}
+	if len(s.EnabledFlows) == 0 {
+		return true
+	}
+	for _, f := range s.EnabledFlows {
+		if f == flow {
+			return true
+		}
+	}
+	return false
+}
+
+// SecretsDefault returns the secrets used to sign and encrypt data. Secrets of the form kms://<provider>/<path> are
+// resolved by the SecretsProvider registered for <provider>.
 func (p *Config) SecretsDefault() [][]byte {
-	secrets := p.p.Strings(ViperKeySecretsDefault)
+	secrets, err := p.SecretsDefaultOrErr()
+	if err != nil {
+		p.l.WithError(err).Fatalf("Unable to load the secrets from %s.", ViperKeySecretsDefault)
+	}
+	return secrets
+}
 
-	if len(secrets) == 0 {
-		secrets = []string{uuid.New().String()}
-		p.MustSet(ViperKeySecretsDefault, secrets)
+// SecretsDefaultOrErr is like SecretsDefault but returns an error if no secret is set and the generated secret can
+// not be read or stored.
+func (p *Config) SecretsDefaultOrErr() ([][]byte, error) {
+	if len(p.p.Strings(ViperKeySecretsDefault)) == 0 {
+		secret, err := p.generatedSecret()
+		if err != nil {
+			return nil, err
+		}
+		if err := p.Set(ViperKeySecretsDefault, []string{secret}); err != nil {
+			return nil, invalidValueError(ViperKeySecretsDefault, "can not be set to the generated secret", err)
+		}
 	}
 
-	result := make([][]byte, len(secrets))
-	for k, v := range secrets {
-		result[k] = []byte(v)
+	return p.secrets(ViperKeySecretsDefault), nil
+}
+
+// generatedSecret returns a random secret which is used if no default secret is configured. If
+// `secrets.generated_path` is set, the secret is read from that file or, if the file does not exist yet,
+// stored in it so that the secret survives restarts.
+func (p *Config) generatedSecret() (string, error) {
+	path := p.p.String(ViperKeySecretsGeneratedPath)
+	if path == "" {
+		p.l.Warnf("Configuration key %s is not set, a random secret is used instead. All sessions and cookies become invalid when ORY Kratos restarts and are not shared between instances. Do not run ORY Kratos like this in production!", ViperKeySecretsDefault)
+		return uuid.New().String(), nil
+	}
+
+	stored, err := ioutil.ReadFile(path)
+	if err == nil && len(bytes.TrimSpace(stored)) > 0 {
+		p.l.Warnf("Configuration key %s is not set, using the generated secret stored in %s instead. Sessions and cookies are not shared with instances using a different secret.", ViperKeySecretsDefault, path)
+		return string(bytes.TrimSpace(stored)), nil
+	} else if err != nil && !os.IsNotExist(err) {
+		return "", invalidValueError(ViperKeySecretsGeneratedPath, "points to a file which can not be read", err)
 	}
 
-	return result
+	secret := uuid.New().String()
+	if err := ioutil.WriteFile(path, []byte(secret), 0600); err != nil {
+		return "", invalidValueError(ViperKeySecretsGeneratedPath, "points to a file which can not be written", err)
+	}
+
+	p.l.Warnf("Configuration key %s is not set, generated a random secret and stored it in %s. Keep this file secret and set %s when running more than one instance.", ViperKeySecretsDefault, path, ViperKeySecretsDefault)
+	return secret, nil
+}
+
+// SecretsPepper returns the peppers mixed into password hashes. The first pepper is used for new hashes
+// while all others are used to verify hashes created with older peppers. Returns nil if no pepper is set.
+func (p *Config) SecretsPepper() [][]byte {
+	return p.secrets(ViperKeySecretsPepper)
+}
+
+// HasherPepper returns the peppers mixed into password hashes of the hasher, for example HashAlgorithmArgon2, and
+// falls back to SecretsPepper if the hasher has no peppers configured. The first pepper is used for new hashes while
+// all others are used to verify hashes created with older peppers.
+func (p *Config) HasherPepper(hasher string) [][]byte {
+	if secrets := p.secrets("hashers." + hasher + ".pepper"); len(secrets) > 0 {
+		return secrets
+	}
+	return p.SecretsPepper()
 }
 
 func (p *Config) SecretsSession() [][]byte {
-	secrets := p.p.Strings(ViperKeySecretsCookie)
-	if len(secrets) == 0 {
-		return p.SecretsDefault()
+	if secrets := p.secrets(ViperKeySecretsCookie); len(secrets) > 0 {
+		return secrets
+	}
+	return p.SecretsDefault()
+}
+
+// SecretsSessionOrErr is like SecretsSession but returns an error instead of failing, see SecretsDefaultOrErr.
+func (p *Config) SecretsSessionOrErr() ([][]byte, error) {
+	if secrets := p.secrets(ViperKeySecretsCookie); len(secrets) > 0 {
+		return secrets, nil
 	}
+	return p.SecretsDefaultOrErr()
+}
 
-	result := make([][]byte, len(secrets))
-	for k, v := range secrets {
-		result[k] = []byte(v)
+// SecretsCipher returns the keys used to encrypt data at rest. The first key is used to encrypt new values while
+// all keys are used to decrypt existing values. The keys are read from `secrets.cipher` or, if it is not set, from
+// its alias `secrets.encryption`. Returns an empty list if no key is set.
+func (p *Config) SecretsCipher() [][32]byte {
+	keys, err := p.SecretsCipherOrErr()
+	if err != nil {
+		p.l.WithError(err).Fatalf("Unable to load the secrets from %s.", ViperKeySecretsCipher)
+	}
+	return keys
+}
+
+// SecretsCipherOrErr is like SecretsCipher but returns an error if a key is not exactly 32 bytes long.
+func (p *Config) SecretsCipherOrErr() ([][32]byte, error) {
+	key := ViperKeySecretsCipher
+	secrets := p.secrets(key)
+	if len(secrets) == 0 {
+		key = ViperKeySecretsEncryption
+		secrets = p.secrets(key)
 	}
 
-	return result
+	keys := make([][32]byte, len(secrets))
+	for k, secret := range secrets {
+		if len(secret) != len(keys[k]) {
+			return nil, invalidValueError(key, fmt.Sprintf("contains key #%d which is not exactly 32 bytes long", k), nil)
+		}
+		copy(keys[k][:], secret)
+	}
+	return keys, nil
 }
 
 func (p *Config) SelfServiceBrowserDefaultReturnTo() *url.URL {
 	return p.parseURIOrFail(ViperKeySelfServiceBrowserDefaultReturnTo)
 }
 
+// SelfServiceBrowserDefaultReturnToOrErr is like SelfServiceBrowserDefaultReturnTo but returns an error if the
+// value is not a valid URL.
+func (p *Config) SelfServiceBrowserDefaultReturnToOrErr() (*url.URL, error) {
+	return p.parseURI(ViperKeySelfServiceBrowserDefaultReturnTo)
+}
+
 func (p *Config) guessBaseURL(keyHost, keyPort string, defaultPort int) *url.URL {
 	port := p.p.IntF(keyPort, defaultPort)
 
@@ -487,13 +1324,46 @@ type domainAlias struct {
 	MatchDomain string `json:"match_domain"`
 }
 
+// PublicBasePath returns the path all public routes are served under, for example "/kratos", or an empty string
+// if they are served at the root.
+func (p *Config) PublicBasePath() string {
+	return p.basePath(ViperKeyPublicBasePath)
+}
+
+// AdminBasePath returns the path all admin routes are served under, for example "/kratos", or an empty string if
+// they are served at the root.
+func (p *Config) AdminBasePath() string {
+	return p.basePath(ViperKeyAdminBasePath)
+}
+
+func (p *Config) basePath(key string) string {
+	if trimmed := strings.Trim(p.p.String(key), "/"); len(trimmed) > 0 {
+		return "/" + trimmed
+	}
+	return ""
+}
+
+// withBasePath appends the base path to the URL's path unless the path already ends with it.
+func withBasePath(u *url.URL, basePath string) *url.URL {
+	trimmed := strings.TrimRight(u.Path, "/")
+	if len(basePath) == 0 || strings.HasSuffix(trimmed, basePath) {
+		return u
+	}
+
+	out := *u
+	out.Path = trimmed + basePath + "/"
+	out.RawPath = ""
+	return &out
+}
+
 func (p *Config) SelfPublicURL(r *http.Request) *url.URL {
-	primary := p.baseURL(ViperKeyPublicBaseURL, ViperKeyPublicHost, ViperKeyPublicPort, 4433)
+	basePath := p.PublicBasePath()
+	primary := withBasePath(p.baseURL(ViperKeyPublicBaseURL, ViperKeyPublicHost, ViperKeyPublicPort, 4433), basePath)
 	if r == nil {
 		return primary
 	}
 
-	out, err := p.p.Marshal(kjson.Parser())
+	out, err := p.marshal()
 	if err != nil {
 		p.l.WithError(err).Errorf("Unable to marshal configuration.")
 		return primary
@@ -523,7 +1393,7 @@ func (p *Config) SelfPublicURL(r *http.Request) *url.URL {
 				Host:   host,
 				Path:   a.BasePath,
 			}
-			return parsed
+			return withBasePath(parsed, basePath)
 		}
 	}
 
@@ -531,31 +1401,165 @@ func (p *Config) SelfPublicURL(r *http.Request) *url.URL {
 }
 
 func (p *Config) SelfAdminURL() *url.URL {
-	return p.baseURL(ViperKeyAdminBaseURL, ViperKeyAdminHost, ViperKeyAdminPort, 4434)
+	return withBasePath(p.baseURL(ViperKeyAdminBaseURL, ViperKeyAdminHost, ViperKeyAdminPort, 4434), p.AdminBasePath())
 }
 
 func (p *Config) CourierSMTPURL() *url.URL {
 	return p.parseURIOrFail(ViperKeyCourierSMTPURL)
 }
 
+// CourierSMTPURLOrErr is like CourierSMTPURL but returns an error if the value is not a valid URL.
+func (p *Config) CourierSMTPURLOrErr() (*url.URL, error) {
+	return p.parseURI(ViperKeyCourierSMTPURL)
+}
+
+// CourierSMTPClientConfig returns the options of the SMTP client. Options which are not set explicitly are derived
+// from the connection URI: smtps URIs use implicit TLS, smtp URIs STARTTLS if the server supports it, and the
+// `skip_ssl_verify` query parameter disables the certificate verification.
+func (p *Config) CourierSMTPClientConfig() *SMTPClientConfig {
+	c, err := p.CourierSMTPClientConfigOrErr()
+	if err != nil {
+		p.l.WithError(err).Fatal("Unable to load the SMTP client configuration.")
+	}
+	return c
+}
+
+// CourierSMTPClientConfigOrErr is like CourierSMTPClientConfig but returns an error if the connection URI is
+// invalid or the client certificate can not be loaded.
+func (p *Config) CourierSMTPClientConfigOrErr() (*SMTPClientConfig, error) {
+	uri, err := p.CourierSMTPURLOrErr()
+	if err != nil {
+		return nil, err
+	}
+	password, _ := uri.User.Password()
+	port, _ := strconv.Atoi(uri.Port())
+	skipVerify, _ := strconv.ParseBool(uri.Query().Get("skip_ssl_verify"))
+
+	mode := SMTPTLSModeOpportunisticStartTLS
+	if uri.Scheme == "smtps" {
+		mode = SMTPTLSModeImplicit
+	}
+
+	c := &SMTPClientConfig{
+		Host:           uri.Hostname(),
+		Port:           port,
+		Username:       uri.User.Username(),
+		Password:       password,
+		TLSMode:        p.p.StringF(ViperKeyCourierSMTPTLSMode, mode),
+		TLSSkipVerify:  p.p.BoolF(ViperKeyCourierSMTPTLSSkipVerify, skipVerify),
+		LocalName:      p.p.String(ViperKeyCourierSMTPLocalName),
+		ConnectTimeout: p.p.DurationF(ViperKeyCourierSMTPConnectTimeout, 10*time.Second),
+	}
+
+	if certPath, keyPath := p.p.String(ViperKeyCourierSMTPClientCertPath), p.p.String(ViperKeyCourierSMTPClientKeyPath); len(certPath) > 0 || len(keyPath) > 0 {
+		cert, err := tls.LoadX509KeyPair(certPath, keyPath)
+		if err != nil {
+			return nil, invalidValueError(ViperKeyCourierSMTPClientCertPath, "does not point to a valid client certificate", err)
+		}
+		c.ClientCertificate = &cert
+	}
+
+	return c, nil
+}
+
+func (p *Config) SelfServiceHostedUIEnabled() bool {
+	return p.p.Bool(ViperKeySelfServiceHostedUIEnabled)
+}
+
+// SelfServiceHostedUITheme returns the logo, colors, custom stylesheet, and footer links of the hosted UI.
+func (p *Config) SelfServiceHostedUITheme() HostedUITheme {
+	var theme HostedUITheme
+	if !p.p.Exists(ViperKeySelfServiceHostedUITheme) {
+		return theme
+	}
+
+	out, err := p.marshal()
+	if err != nil {
+		p.l.WithError(err).Errorf("Unable to decode values from %s.", ViperKeySelfServiceHostedUITheme)
+		return theme
+	}
+
+	if err := json.Unmarshal([]byte(gjson.GetBytes(out, ViperKeySelfServiceHostedUITheme).Raw), &theme); err != nil {
+		p.l.WithError(err).Errorf("Unable to decode values from %s.", ViperKeySelfServiceHostedUITheme)
+		return HostedUITheme{}
+	}
+
+	return theme
+}
+
+// SelfServiceLocalesDefault returns the locale used for message texts if the Accept-Language header does not
+// request any of the supported locales.
+func (p *Config) SelfServiceLocalesDefault() string {
+	return p.p.StringF(ViperKeySelfServiceLocalesDefault, "en")
+}
+
+// SelfServiceLocalesSupported returns the locales message texts may be translated into.
+func (p *Config) SelfServiceLocalesSupported() []string {
+	if locales := p.p.Strings(ViperKeySelfServiceLocalesSupported); len(locales) > 0 {
+		return locales
+	}
+	return []string{p.SelfServiceLocalesDefault()}
+}
+
+// selfServiceUI returns the URL of the page served by the hosted UI if it is enabled and the configured UI URL
+// otherwise.
+func (p *Config) selfServiceUI(key, page string) *url.URL {
+	if p.SelfServiceHostedUIEnabled() {
+		return urlx.AppendPaths(p.SelfPublicURL(nil), "/ui", page)
+	}
+	return p.parseURIOrFail(key)
+}
+
+func (p *Config) selfServiceUIOrErr(key, page string) (*url.URL, error) {
+	if p.SelfServiceHostedUIEnabled() {
+		return urlx.AppendPaths(p.SelfPublicURL(nil), "/ui", page), nil
+	}
+	return p.parseURI(key)
+}
+
 func (p *Config) SelfServiceFlowLoginUI() *url.URL {
-	return p.parseURIOrFail(ViperKeySelfServiceLoginUI)
+	return p.selfServiceUI(ViperKeySelfServiceLoginUI, "login")
+}
+
+// SelfServiceFlowLoginUIOrErr is like SelfServiceFlowLoginUI but returns an error if the value is not a valid URL.
+func (p *Config) SelfServiceFlowLoginUIOrErr() (*url.URL, error) {
+	return p.selfServiceUIOrErr(ViperKeySelfServiceLoginUI, "login")
 }
 
 func (p *Config) SelfServiceFlowSettingsUI() *url.URL {
-	return p.parseURIOrFail(ViperKeySelfServiceSettingsURL)
+	return p.selfServiceUI(ViperKeySelfServiceSettingsURL, "settings")
+}
+
+// SelfServiceFlowSettingsUIOrErr is like SelfServiceFlowSettingsUI but returns an error if the value is not a valid URL.
+func (p *Config) SelfServiceFlowSettingsUIOrErr() (*url.URL, error) {
+	return p.selfServiceUIOrErr(ViperKeySelfServiceSettingsURL, "settings")
 }
 
 func (p *Config) SelfServiceFlowErrorURL() *url.URL {
-	return p.parseURIOrFail(ViperKeySelfServiceErrorUI)
+	return p.selfServiceUI(ViperKeySelfServiceErrorUI, "error")
+}
+
+// SelfServiceFlowErrorURLOrErr is like SelfServiceFlowErrorURL but returns an error if the value is not a valid URL.
+func (p *Config) SelfServiceFlowErrorURLOrErr() (*url.URL, error) {
+	return p.selfServiceUIOrErr(ViperKeySelfServiceErrorUI, "error")
 }
 
 func (p *Config) SelfServiceFlowRegistrationUI() *url.URL {
-	return p.parseURIOrFail(ViperKeySelfServiceRegistrationUI)
+	return p.selfServiceUI(ViperKeySelfServiceRegistrationUI, "registration")
+}
+
+// SelfServiceFlowRegistrationUIOrErr is like SelfServiceFlowRegistrationUI but returns an error if the value is not a valid URL.
+func (p *Config) SelfServiceFlowRegistrationUIOrErr() (*url.URL, error) {
+	return p.selfServiceUIOrErr(ViperKeySelfServiceRegistrationUI, "registration")
 }
 
 func (p *Config) SelfServiceFlowRecoveryUI() *url.URL {
-	return p.parseURIOrFail(ViperKeySelfServiceRecoveryUI)
+	return p.selfServiceUI(ViperKeySelfServiceRecoveryUI, "recovery")
+}
+
+// SelfServiceFlowRecoveryUIOrErr is like SelfServiceFlowRecoveryUI but returns an error if the value is not a valid URL.
+func (p *Config) SelfServiceFlowRecoveryUIOrErr() (*url.URL, error) {
+	return p.selfServiceUIOrErr(ViperKeySelfServiceRecoveryUI, "recovery")
 }
 
 // SessionLifespan returns nil when the value is not set.
@@ -567,6 +1571,103 @@ func (p *Config) SessionPersistentCookie() bool {
 	return p.p.Bool(ViperKeySessionPersistentCookie)
 }
 
+// SessionEncryptCookie returns true if session and continuity cookies should be encrypted in addition to
+// being signed.
+func (p *Config) SessionEncryptCookie() bool {
+	return p.p.Bool(ViperKeySessionEncryptCookie)
+}
+
+// SessionDeviceParseUserAgent returns true if the browser and operating system should be parsed from the
+// user agent of the device a session is issued to.
+func (p *Config) SessionDeviceParseUserAgent() bool {
+	return p.p.Bool(ViperKeySessionDeviceParseUserAgent)
+}
+
+// SessionDeviceLocationHeader returns the name of the HTTP header set by a trusted proxy which contains the
+// location of the device a session is issued to or an empty string if no such header is configured.
+func (p *Config) SessionDeviceLocationHeader() string {
+	return p.p.String(ViperKeySessionDeviceLocationHeader)
+}
+
+// SessionBinding returns how sessions are bound to the IP range and user agent family of the device they were
+// issued to.
+func (p *Config) SessionBinding() *SessionBinding {
+	return &SessionBinding{
+		Mode:             p.p.StringF(ViperKeySessionBindingMode, SessionBindingModeOff),
+		IP:               p.p.BoolF(ViperKeySessionBindingIP, true),
+		UserAgent:        p.p.BoolF(ViperKeySessionBindingUserAgent, true),
+		IPv4PrefixLength: p.p.IntF(ViperKeySessionBindingIPv4PrefixLength, 24),
+		IPv6PrefixLength: p.p.IntF(ViperKeySessionBindingIPv6PrefixLength, 64),
+	}
+}
+
+// SessionWebhookURL returns the URL of the endpoint which is notified whenever a session is created or revoked
+// or nil if no endpoint is configured.
+func (p *Config) SessionWebhookURL() *url.URL {
+	if len(p.p.String(ViperKeySessionWebhookURL)) == 0 {
+		return nil
+	}
+	return p.parseURIOrFail(ViperKeySessionWebhookURL)
+}
+
+// SessionWebhookURLOrErr is like SessionWebhookURL but returns an error if the value is not a valid URL.
+func (p *Config) SessionWebhookURLOrErr() (*url.URL, error) {
+	if len(p.p.String(ViperKeySessionWebhookURL)) == 0 {
+		return nil, nil
+	}
+	return p.parseURI(ViperKeySessionWebhookURL)
+}
+
+// SessionRevocationRedisURL returns the URL of the Redis server session revocations are published to or nil
+// if revocations should not be published.
+func (p *Config) SessionRevocationRedisURL() *url.URL {
+	if len(p.p.String(ViperKeySessionRevocationRedisURL)) == 0 {
+		return nil
+	}
+	return p.parseURIOrFail(ViperKeySessionRevocationRedisURL)
+}
+
+// SessionRevocationRedisURLOrErr is like SessionRevocationRedisURL but returns an error if the value is not a valid URL.
+func (p *Config) SessionRevocationRedisURLOrErr() (*url.URL, error) {
+	if len(p.p.String(ViperKeySessionRevocationRedisURL)) == 0 {
+		return nil, nil
+	}
+	return p.parseURI(ViperKeySessionRevocationRedisURL)
+}
+
+// SessionRevocationRedisChannel returns the Redis channel session revocations are published to.
+func (p *Config) SessionRevocationRedisChannel() string {
+	return p.p.StringF(ViperKeySessionRevocationRedisChannel, "kratos.sessions.revoked")
+}
+
+// SessionWhoami returns which parts of the session and its identity are included in the whoami response.
+func (p *Config) SessionWhoami() *SessionWhoami {
+	return &SessionWhoami{
+		Traits:                   p.p.Strings(ViperKeySessionWhoamiIdentityTraits),
+		VerifiableAddresses:      p.p.BoolF(ViperKeySessionWhoamiIdentityVerifiableAddresses, true),
+		RecoveryAddresses:        p.p.BoolF(ViperKeySessionWhoamiIdentityRecoveryAddresses, true),
+		CommunicationPreferences: p.p.BoolF(ViperKeySessionWhoamiIdentityCommunicationPreferences, true),
+		Device:                   p.p.BoolF(ViperKeySessionWhoamiDevice, true),
+	}
+}
+
+// SessionWhoamiCacheMaxAge returns for how long clients may cache the whoami response. If zero, the
+// response must not be cached.
+func (p *Config) SessionWhoamiCacheMaxAge() time.Duration {
+	return p.p.DurationF(ViperKeySessionWhoamiCacheMaxAge, 0)
+}
+
+// SessionPrivilegedLifespan returns for how long a session stays privileged after it was elevated.
+func (p *Config) SessionPrivilegedLifespan() time.Duration {
+	return p.p.DurationF(ViperKeySessionPrivilegedLifespan, 15*time.Minute)
+}
+
+// SessionPrivilegedReauthenticationMaxAge returns how recently the user must have authenticated to elevate
+// a session.
+func (p *Config) SessionPrivilegedReauthenticationMaxAge() time.Duration {
+	return p.p.DurationF(ViperKeySessionPrivilegedReauthenticationMaxAge, 5*time.Minute)
+}
+
 func (p *Config) SelfServiceBrowserWhitelistedReturnToDomains() (us []url.URL) {
 	src := p.p.Strings(ViperKeyURLsWhitelistedReturnToDomains)
 	for k, u := range src {
@@ -586,6 +1687,17 @@ func (p *Config) SelfServiceBrowserWhitelistedReturnToDomains() (us []url.URL) {
 	return us
 }
 
+// SelfServiceTermsOfServiceVersion returns the version of the terms of service users must accept or an empty
+// string if acceptance is not required.
+func (p *Config) SelfServiceTermsOfServiceVersion() string {
+	return p.p.String(ViperKeySelfServiceTermsOfServiceVersion)
+}
+
+// SelfServiceTermsOfServiceURL returns the URL of the terms of service or an empty string if none is set.
+func (p *Config) SelfServiceTermsOfServiceURL() string {
+	return p.p.String(ViperKeySelfServiceTermsOfServiceURL)
+}
+
 func (p *Config) SelfServiceFlowLoginRequestLifespan() time.Duration {
 	return p.p.DurationF(ViperKeySelfServiceLoginRequestLifespan, time.Hour)
 }
@@ -602,6 +1714,41 @@ func (p *Config) SelfServiceFlowLogoutRedirectURL() *url.URL {
 	return p.p.RequestURIF(ViperKeySelfServiceLogoutBrowserDefaultReturnTo, p.SelfServiceBrowserDefaultReturnTo())
 }
 
+// SelfServiceFlowLogoutSessions returns which sessions are revoked when signing out: "current", "all", or "others".
+func (p *Config) SelfServiceFlowLogoutSessions() string {
+	return p.p.StringF(ViperKeySelfServiceLogoutSessions, "current")
+}
+
+// SelfServiceFlowLogoutBackChannelURLs returns the endpoints of relying applications which are notified
+// whenever a user signs out.
+func (p *Config) SelfServiceFlowLogoutBackChannelURLs() []url.URL {
+	return p.requestURIs(ViperKeySelfServiceLogoutBackChannelURLs)
+}
+
+// SelfServiceFlowLogoutFrontChannelURLs returns the logout pages of relying applications which are loaded
+// in the user's browser whenever the user signs out.
+func (p *Config) SelfServiceFlowLogoutFrontChannelURLs() []url.URL {
+	return p.requestURIs(ViperKeySelfServiceLogoutFrontChannelURLs)
+}
+
+func (p *Config) requestURIs(key string) (us []url.URL) {
+	for k, u := range p.p.Strings(key) {
+		if len(u) == 0 {
+			continue
+		}
+
+		parsed, err := url.ParseRequestURI(u)
+		if err != nil {
+			p.l.WithError(err).Warnf("Ignoring URL \"%s\" from configuration key \"%s.%d\".", u, key, k)
+			continue
+		}
+
+		us = append(us, *parsed)
+	}
+
+	return us
+}
+
 func (p *Config) CourierSMTPFrom() string {
 	return p.p.StringF(ViperKeyCourierSMTPFrom, "noreply@kratos.ory.sh")
 }
@@ -614,6 +1761,80 @@ func (p *Config) CourierTemplatesRoot() string {
 	return p.p.StringF(ViperKeyCourierTemplatesPath, "courier/builtin/templates")
 }
 
+// CourierDeliveryWebhookSecret returns the secret email providers must send with delivery status callbacks or
+// an empty string if the callback endpoints are disabled.
+func (p *Config) CourierDeliveryWebhookSecret() string {
+	return p.p.String(ViperKeyCourierDeliveryWebhookSecret)
+}
+
+// CourierDeliveryWebhookMarkUndeliverable returns true if verifiable addresses should be marked as undeliverable
+// when an email provider reports a permanent bounce.
+func (p *Config) CourierDeliveryWebhookMarkUndeliverable() bool {
+	return p.p.Bool(ViperKeyCourierDeliveryWebhookMarkUndeliverable)
+}
+
+// CourierTemplateLimits returns the sending caps and windows of message templates.
+func (p *Config) CourierTemplateLimits() []CourierTemplateLimit {
+	if !p.p.Exists(ViperKeyCourierTemplateLimits) {
+		return nil
+	}
+
+	out, err := p.marshal()
+	if err != nil {
+		p.l.WithError(err).Errorf("Unable to decode values from %s.", ViperKeyCourierTemplateLimits)
+		return nil
+	}
+
+	var limits []CourierTemplateLimit
+	if err := json.Unmarshal([]byte(gjson.GetBytes(out, ViperKeyCourierTemplateLimits).Raw), &limits); err != nil {
+		p.l.WithError(err).Errorf("Unable to decode values from %s.", ViperKeyCourierTemplateLimits)
+		return nil
+	}
+
+	return limits
+}
+
+// CourierTemplateLimit returns the sending cap and window of the template type or nil if there is none.
+func (p *Config) CourierTemplateLimit(template string) *CourierTemplateLimit {
+	for _, l := range p.CourierTemplateLimits() {
+		if l.Template == template {
+			return &l
+		}
+	}
+	return nil
+}
+
+// CourierTemplateModels returns the model enrichers of message templates.
+func (p *Config) CourierTemplateModels() []CourierTemplateModel {
+	if !p.p.Exists(ViperKeyCourierTemplateModels) {
+		return nil
+	}
+
+	out, err := p.marshal()
+	if err != nil {
+		p.l.WithError(err).Errorf("Unable to decode values from %s.", ViperKeyCourierTemplateModels)
+		return nil
+	}
+
+	var models []CourierTemplateModel
+	if err := json.Unmarshal([]byte(gjson.GetBytes(out, ViperKeyCourierTemplateModels).Raw), &models); err != nil {
+		p.l.WithError(err).Errorf("Unable to decode values from %s.", ViperKeyCourierTemplateModels)
+		return nil
+	}
+
+	return models
+}
+
+// CourierTemplateModel returns the model enricher of the template type or nil if there is none.
+func (p *Config) CourierTemplateModel(template string) *CourierTemplateModel {
+	for _, m := range p.CourierTemplateModels() {
+		if m.Template == template {
+			return &m
+		}
+	}
+	return nil
+}
+
 func splitUrlAndFragment(s string) (string, string) {
 	i := strings.IndexByte(s, '#')
 	if i < 0 {
@@ -623,51 +1844,255 @@ func splitUrlAndFragment(s string) (string, string) {
 }
 
 func (p *Config) parseURIOrFail(key string) *url.URL {
-	u, frag := splitUrlAndFragment(p.p.String(key))
-	url, err := url.ParseRequestURI(u)
+	u, err := p.parseURI(key)
+	if err != nil {
+		// Values fetched from a secrets provider may contain credentials, so only the URI of the secret is logged.
+		p.l.WithError(err).Fatalf("Configuration value from key %s is not a valid URL: %s", key, p.p.String(key))
+	}
+	return u
+}
+
+func (p *Config) parseURI(key string) (*url.URL, error) {
+	u, frag := splitUrlAndFragment(p.remote(key))
+	parsed, err := url.ParseRequestURI(u)
 	if err != nil {
-		p.l.WithError(errors.WithStack(err)).
-			Fatalf("Configuration value from key %s is not a valid URL: %s", key, p.p.String(key))
+		// The error of url.ParseRequestURI contains the value, which may be a credential fetched from a secrets
+		// provider, so only the underlying error is kept.
+		if ue := new(url.Error); errors.As(err, &ue) {
+			err = ue.Err
+		}
+		return nil, invalidValueError(key, "is not a valid URL", err)
 	}
-	if url.Scheme == "" {
-		p.l.WithField("reason", "expected scheme to be set").
-			Fatalf("Configuration value from key %s is not a valid URL: %s", key, p.p.String(key))
+	if parsed.Scheme == "" {
+		return nil, invalidValueError(key, "is not a valid URL", errors.New("expected scheme to be set"))
 	}
 
 	if frag != "" {
-		url.Fragment = frag
+		parsed.Fragment = frag
+	}
+	return parsed, nil
+}
+
+// invalidValueError returns the error of a configuration key with an invalid value. The error is an internal server
+// error, so that a request using the value fails instead of the process exiting. The value itself is not part of
+// the error as it might be sensitive, see Redacted.
+func invalidValueError(key, reason string, err error) error {
+	e := herodot.ErrInternalServerError.
+		WithReasonf("The configuration value of key %s %s.", key, reason).
+		WithDetail("key", key)
+	if err != nil {
+		e = e.WithDebug(err.Error())
 	}
-	return url
+	return errors.WithStack(e)
 }
 
 func (p *Config) Tracing() *tracing.Config {
 	return p.p.TracingConfig("ORY Kratos")
 }
 
+// TracingSamplingOverrides returns the sampling rates of endpoints which should be traced at a different
+// rate than all other requests.
+func (p *Config) TracingSamplingOverrides() []TracingSamplingOverride {
+	if !p.p.Exists(ViperKeyTracingSamplingOverrides) {
+		return nil
+	}
+
+	out, err := p.marshal()
+	if err != nil {
+		p.l.WithError(err).Errorf("Unable to decode values from %s.", ViperKeyTracingSamplingOverrides)
+		return nil
+	}
+
+	var overrides []TracingSamplingOverride
+	if err := json.Unmarshal([]byte(gjson.GetBytes(out, ViperKeyTracingSamplingOverrides).Raw), &overrides); err != nil {
+		p.l.WithError(err).Errorf("Unable to decode values from %s.", ViperKeyTracingSamplingOverrides)
+		return nil
+	}
+
+	return overrides
+}
+
+// Plugins returns the external plugin processes which hooks can call.
+func (p *Config) Plugins() []Plugin {
+	if !p.p.Exists(ViperKeyPlugins) {
+		return nil
+	}
+
+	out, err := p.marshal()
+	if err != nil {
+		p.l.WithError(err).Errorf("Unable to decode values from %s.", ViperKeyPlugins)
+		return nil
+	}
+
+	var plugins []Plugin
+	if err := json.Unmarshal([]byte(gjson.GetBytes(out, ViperKeyPlugins).Raw), &plugins); err != nil {
+		p.l.WithError(err).Errorf("Unable to decode values from %s.", ViperKeyPlugins)
+		return nil
+	}
+
+	return plugins
+}
+
 func (p *Config) IsInsecureDevMode() bool {
 	return p.Source().Bool("dev")
 }
 
+// TelemetryCategories returns all telemetry event categories which can be disabled individually.
+func TelemetryCategories() []string {
+	return []string{
+		TelemetryCategoryHealth,
+		TelemetryCategorySelfService,
+		TelemetryCategoryIdentities,
+		TelemetryCategorySessions,
+		TelemetryCategoryMetrics,
+	}
+}
+
+// IsMaintenanceEnabled returns true if the public self-service endpoints are in maintenance mode.
+func (p *Config) IsMaintenanceEnabled() bool {
+	return p.p.Bool(ViperKeyMaintenanceEnabled)
+}
+
+// MaintenanceRetryAfter returns the duration sent in the `Retry-After` header during maintenance.
+func (p *Config) MaintenanceRetryAfter() time.Duration {
+	return p.p.DurationF(ViperKeyMaintenanceRetryAfter, 5*time.Minute)
+}
+
+// MaintenanceMessage returns the message shown to users during maintenance.
+func (p *Config) MaintenanceMessage() string {
+	return p.p.StringF(ViperKeyMaintenanceMessage, "This service is down for scheduled maintenance. Please try again later.")
+}
+
+// IsTelemetryOptedOut returns true if anonymized telemetry reports were disabled using `--sqa-opt-out`.
+func (p *Config) IsTelemetryOptedOut() bool {
+	return p.p.Bool(ViperKeySQAOptOut)
+}
+
+// IsTelemetryOffline returns true if no telemetry must be sent over the network.
+func (p *Config) IsTelemetryOffline() bool {
+	return p.p.Bool(ViperKeyTelemetryOffline)
+}
+
+// IsTelemetryCategoryEnabled returns true if events of the telemetry category are reported. All categories
+// are enabled by default.
+func (p *Config) IsTelemetryCategoryEnabled(category string) bool {
+	return p.p.BoolF(ViperKeyTelemetryCategories+"."+category, true)
+}
+
+// DevSeedIdentitiesFile returns the path of the file containing identities which are created on startup
+// in dev mode. Returns an empty string if no seed file was set.
+func (p *Config) DevSeedIdentitiesFile() string {
+	return p.Source().String("seed")
+}
+
+// IsTestTokenEndpointEnabled returns true if the admin endpoints returning the most recent recovery and
+// verification tokens are enabled. This requires dev mode.
+func (p *Config) IsTestTokenEndpointEnabled() bool {
+	return p.IsInsecureDevMode() && p.Source().Bool("expose-test-tokens")
+}
+
 func (p *Config) IsBackgroundCourierEnabled() bool {
 	return p.Source().Bool("watch-courier")
 }
 
+func (p *Config) IsBackgroundDormancyEnabled() bool {
+	return p.Source().Bool("watch-dormancy")
+}
+
+func (p *Config) IsBackgroundVerificationRemindersEnabled() bool {
+	return p.Source().Bool("watch-reminders")
+}
+
+// IsMigrateOnStartupEnabled returns true if SQL migrations should be applied before the server starts.
+func (p *Config) IsMigrateOnStartupEnabled() bool {
+	return p.Source().Bool("migrate")
+}
+
+// MetricsGaugesRefreshInterval returns how often the gauges counting identities, sessions, and pending
+// verifications are refreshed. If zero, the gauges are not refreshed.
+func (p *Config) MetricsGaugesRefreshInterval() time.Duration {
+	return p.p.DurationF(ViperKeyMetricsGaugesRefreshInterval, time.Minute)
+}
+
+// KMSRefreshInterval returns how often secrets fetched from a secrets provider are fetched again. Zero means never.
+func (p *Config) KMSRefreshInterval() time.Duration {
+	return p.p.DurationF(ViperKeyKMSRefreshInterval, 0)
+}
+
+// IdempotencyKeyLifespan returns how long the responses of admin requests with an Idempotency-Key header are stored.
+func (p *Config) IdempotencyKeyLifespan() time.Duration {
+	return p.p.DurationF(ViperKeyIdempotencyKeyLifespan, 24*time.Hour)
+}
+
+// IdempotencyInProgressTimeout returns how long a request with an Idempotency-Key header may take before its key
+// is released for retries.
+func (p *Config) IdempotencyInProgressTimeout() time.Duration {
+	return p.p.DurationF(ViperKeyIdempotencyInProgressTimeout, time.Minute)
+}
+
 func (p *Config) CourierExposeMetricsPort() int {
 	return p.Source().Int("expose-metrics-port")
 }
 
 func (p *Config) MetricsListenOn() string {
-	return strings.Replace(p.AdminListenOn(), ":4434", fmt.Sprintf(":%d", p.CourierExposeMetricsPort()), 1)
+	return strings.Replace(p.AdminListenOn().Address, ":4434", fmt.Sprintf(":%d", p.CourierExposeMetricsPort()), 1)
 }
 
 func (p *Config) SelfServiceFlowVerificationUI() *url.URL {
-	return p.parseURIOrFail(ViperKeySelfServiceVerificationUI)
+	return p.selfServiceUI(ViperKeySelfServiceVerificationUI, "verification")
+}
+
+// SelfServiceFlowVerificationUIOrErr is like SelfServiceFlowVerificationUI but returns an error if the value is not a valid URL.
+func (p *Config) SelfServiceFlowVerificationUIOrErr() (*url.URL, error) {
+	return p.selfServiceUIOrErr(ViperKeySelfServiceVerificationUI, "verification")
 }
 
 func (p *Config) SelfServiceFlowVerificationRequestLifespan() time.Duration {
 	return p.p.DurationF(ViperKeySelfServiceVerificationRequestLifespan, time.Hour)
 }
 
+func (p *Config) SelfServiceFlowVerificationToken() TokenFormat {
+	return p.tokenFormat(ViperKeySelfServiceVerificationToken)
+}
+
+// SelfServiceFlowVerificationDeduplicationWindow returns for how long no new verification message is sent to an
+// address which already received one. If zero, every request sends a message.
+func (p *Config) SelfServiceFlowVerificationDeduplicationWindow() time.Duration {
+	return p.p.DurationF(ViperKeySelfServiceVerificationDeduplicationWindow, 0)
+}
+
+// SelfServiceFlowVerificationReminders returns when reminders are sent to addresses which are still unverified.
+// Periods and timestamps which can not be parsed are ignored.
+func (p *Config) SelfServiceFlowVerificationReminders() VerificationReminders {
+	var sendAfter []time.Duration
+	for _, v := range p.p.Strings(ViperKeySelfServiceVerificationRemindersSendAfter) {
+		d, err := time.ParseDuration(v)
+		if err != nil || d <= 0 {
+			p.l.Warnf("Ignoring verification reminder period %q because it is not a positive duration.", v)
+			continue
+		}
+		sendAfter = append(sendAfter, d)
+	}
+	sort.Slice(sendAfter, func(i, j int) bool { return sendAfter[i] < sendAfter[j] })
+
+	var createdAfter time.Time
+	if v := p.p.String(ViperKeySelfServiceVerificationRemindersCreatedAfter); len(v) > 0 {
+		if t, err := time.Parse(time.RFC3339, v); err != nil {
+			p.l.WithError(err).Warnf("Ignoring verification reminder start %q because it is not an RFC 3339 timestamp.", v)
+		} else {
+			createdAfter = t
+		}
+	}
+
+	return VerificationReminders{
+		DryRun:       p.p.Bool(ViperKeySelfServiceVerificationRemindersDryRun),
+		Interval:     p.p.DurationF(ViperKeySelfServiceVerificationRemindersInterval, time.Hour),
+		SendAfter:    sendAfter,
+		MaxPerRun:    p.p.IntF(ViperKeySelfServiceVerificationRemindersMaxPerRun, 0),
+		CreatedAfter: createdAfter,
+	}
+}
+
 func (p *Config) SelfServiceFlowVerificationReturnTo(defaultReturnTo *url.URL) *url.URL {
 	return p.p.RequestURIF(ViperKeySelfServiceVerificationBrowserDefaultReturnTo, defaultReturnTo)
 }
@@ -680,6 +2105,38 @@ func (p *Config) SelfServiceFlowRecoveryRequestLifespan() time.Duration {
 	return p.p.DurationF(ViperKeySelfServiceRecoveryRequestLifespan, time.Hour)
 }
 
+func (p *Config) SelfServiceFlowRecoveryExpireLinksOnPasswordChange() bool {
+	return p.p.Bool(ViperKeySelfServiceRecoveryExpireLinksOnPasswordChange)
+}
+
+func (p *Config) SelfServiceFlowRecoveryToken() TokenFormat {
+	return p.tokenFormat(ViperKeySelfServiceRecoveryToken)
+}
+
+// SelfServiceFlowRecoveryDeduplicationWindow returns for how long no new recovery message is sent to an address
+// which already received one. If zero, every request sends a message.
+func (p *Config) SelfServiceFlowRecoveryDeduplicationWindow() time.Duration {
+	return p.p.DurationF(ViperKeySelfServiceRecoveryDeduplicationWindow, 0)
+}
+
+// tokenFormat returns the format of recovery or verification tokens. Tokens default to 32 alphanumeric
+// characters and codes to 8 digits.
+func (p *Config) tokenFormat(key string) TokenFormat {
+	f := TokenFormat{
+		Format:   p.p.StringF(key+".format", TokenFormatToken),
+		Alphabet: p.p.StringF(key+".alphabet", "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789"),
+	}
+
+	defaultLength := 32
+	if f.Format == TokenFormatCode {
+		defaultLength = 8
+		f.Alphabet = "0123456789"
+	}
+
+	f.Length = p.p.IntF(key+".length", defaultLength)
+	return f
+}
+
 func (p *Config) SelfServiceFlowSettingsPrivilegedSessionMaxAge() time.Duration {
 	return p.p.DurationF(ViperKeySelfServiceSettingsPrivilegedAuthenticationAfter, time.Hour)
 }
@@ -732,3 +2189,20 @@ func (p *Config) PasswordPolicyConfig() *PasswordPolicy {
 		IgnoreNetworkErrors: p.p.BoolF(ViperKeyIgnoreNetworkErrors, true),
 	}
 }
+
+// PasswordMigrationHookURL returns the URL of the endpoint which verifies legacy passwords of identities
+// imported from external systems or nil if no endpoint is configured.
+func (p *Config) PasswordMigrationHookURL() *url.URL {
+	if len(p.p.String(ViperKeyPasswordMigrationHookURL)) == 0 {
+		return nil
+	}
+	return p.parseURIOrFail(ViperKeyPasswordMigrationHookURL)
+}
+
+// PasswordMigrationHookURLOrErr is like PasswordMigrationHookURL but returns an error if the value is not a valid URL.
+func (p *Config) PasswordMigrationHookURLOrErr() (*url.URL, error) {
+	if len(p.p.String(ViperKeyPasswordMigrationHookURL)) == 0 {
+		return nil, nil
+	}
+	return p.parseURI(ViperKeyPasswordMigrationHookURL)
+}
@@ -0,0 +1,119 @@
+package config
+
+import (
+	"context"
+	"net/http"
+	"sync"
+
+	"github.com/knadh/koanf/maps"
+)
+
+type (
+	// ContextResolver resolves the values which override the configuration in a context, for example those of the
+	// tenant a request belongs to or those configured for the requested hostname, see RequestFromContext.
+	ContextResolver interface {
+		// ResolveConfig returns a key identifying the overrides which apply in ctx together with the overrides.
+		// Overrides have the structure of the configuration file; dot-separated keys such as
+		// "selfservice.default_browser_return_url" are expanded. An empty key means that no overrides apply.
+		//
+		// The configuration with the overrides applied is cached per key until the configuration changes, so the
+		// same key must always resolve to the same overrides.
+		ResolveConfig(ctx context.Context) (key string, overrides map[string]interface{}, err error)
+	}
+
+	// ContextResolverFunc is a function implementing ContextResolver.
+	ContextResolverFunc func(ctx context.Context) (key string, overrides map[string]interface{}, err error)
+
+	contextualConfigs struct {
+		sync.RWMutex
+		resolver ContextResolver
+		configs  map[string]*Config
+	}
+
+	requestContextKey struct{}
+)
+
+func (f ContextResolverFunc) ResolveConfig(ctx context.Context) (string, map[string]interface{}, error) {
+	return f(ctx)
+}
+
+// SetContextResolver makes Contextualize, and with it Provider.Config, apply the overrides resolved by the
+// resolver. A nil resolver disables overrides.
+func (p *Config) SetContextResolver(r ContextResolver) {
+	p.contextual.Lock()
+	defer p.contextual.Unlock()
+	p.contextual.resolver = r
+	p.contextual.configs = map[string]*Config{}
+}
+
+// Contextualize returns the configuration with the overrides the context resolver resolves for ctx applied. If no
+// context resolver is set or no overrides apply, the configuration itself is returned. If the overrides can not
+// be resolved or are invalid, the error is logged and the configuration is returned without them.
+func (p *Config) Contextualize(ctx context.Context) *Config {
+	if p.frozen {
+		return p
+	}
+
+	p.contextual.RLock()
+	resolver := p.contextual.resolver
+	p.contextual.RUnlock()
+	if resolver == nil {
+		return p
+	}
+
+	key, overrides, err := resolver.ResolveConfig(ctx)
+	if err != nil {
+		p.l.WithError(err).Error("Unable to resolve the configuration overrides, the configuration is used without them.")
+		return p
+	} else if len(key) == 0 {
+		return p
+	}
+
+	p.contextual.RLock()
+	c, ok := p.contextual.configs[key]
+	p.contextual.RUnlock()
+	if ok {
+		return c
+	}
+
+	p.contextual.Lock()
+	defer p.contextual.Unlock()
+	if c, ok := p.contextual.configs[key]; ok {
+		return c
+	}
+
+	candidate := p.p.Raw()
+	expanded := maps.Unflatten(overrides, ".")
+	mergeValues(candidate, expanded)
+	if err := validateConfig(candidate); err != nil {
+		p.l.WithError(err).WithField("overrides", key).Error("The configuration overrides are invalid, the configuration is used without them.")
+		return p
+	}
+
+	c, err = p.frozenCopy(flattenValues("", candidate, map[string]interface{}{}))
+	if err != nil {
+		p.l.WithError(err).WithField("overrides", key).Error("Unable to apply the configuration overrides, the configuration is used without them.")
+		return p
+	}
+
+	p.contextual.configs[key] = c
+	return c
+}
+
+func (p *Config) resetContextualized() {
+	p.contextual.Lock()
+	p.contextual.configs = map[string]*Config{}
+	p.contextual.Unlock()
+}
+
+// ContextWithRequest returns a copy of ctx which carries the request, so that a ContextResolver can derive the
+// overrides from it, for example from its hostname.
+func ContextWithRequest(ctx context.Context, r *http.Request) context.Context {
+	return context.WithValue(ctx, requestContextKey{}, r)
+}
+
+// RequestFromContext returns the request attached using ContextWithRequest, if any.
+func RequestFromContext(ctx context.Context) (*http.Request, bool) {
+	r, ok := ctx.Value(requestContextKey{}).(*http.Request)
+	return r, ok
+}
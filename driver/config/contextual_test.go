@@ -0,0 +1,88 @@
+package config
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ory/x/configx"
+	"github.com/ory/x/logrusx"
+)
+
+type tenantContextKey struct{}
+
+func TestContextualize(t *testing.T) {
+	p := MustNew(logrusx.New("", ""), configx.SkipValidation())
+	p.MustSet(ViperKeyDSN, "memory")
+	p.MustSet(ViperKeyDefaultIdentitySchemaURL, "file://stub/identity.schema.json")
+	p.MustSet(ViperKeySelfServiceBrowserDefaultReturnTo, "https://www.ory.sh/")
+	p.MustSet(ViperKeySessionLifespan, "1h")
+
+	ctx := context.Background()
+	assert.Same(t, p, p.Contextualize(ctx), "the configuration is returned if no resolver is set")
+
+	p.SetContextResolver(ContextResolverFunc(func(ctx context.Context) (string, map[string]interface{}, error) {
+		switch tenant, _ := ctx.Value(tenantContextKey{}).(string); tenant {
+		case "acme":
+			return "acme", map[string]interface{}{
+				"session":     map[string]interface{}{"lifespan": "2h"},
+				"selfservice": map[string]interface{}{"default_browser_return_url": "https://acme.example.org/"},
+			}, nil
+		case "flat":
+			return "flat", map[string]interface{}{"session.lifespan": "3h"}, nil
+		case "invalid":
+			return "invalid", map[string]interface{}{"session.lifespan": "forever"}, nil
+		case "broken":
+			return "", nil, errors.New("tenant store unavailable")
+		}
+		return "", nil, nil
+	}))
+
+	acme := context.WithValue(ctx, tenantContextKey{}, "acme")
+
+	t.Run("case=overrides are applied", func(t *testing.T) {
+		c := p.Contextualize(acme)
+		assert.Equal(t, 2*time.Hour, c.SessionLifespan())
+		assert.Equal(t, "https://acme.example.org/", c.SelfServiceBrowserDefaultReturnTo().String())
+		assert.Equal(t, time.Hour, p.SessionLifespan(), "the configuration itself is not changed")
+		assert.Error(t, c.Set(ViperKeySessionLifespan, "4h"), "contextualized configurations can not be changed")
+	})
+
+	t.Run("case=dot-separated keys are expanded", func(t *testing.T) {
+		c := p.Contextualize(context.WithValue(ctx, tenantContextKey{}, "flat"))
+		assert.Equal(t, 3*time.Hour, c.SessionLifespan())
+		assert.Equal(t, "https://www.ory.sh/", c.SelfServiceBrowserDefaultReturnTo().String())
+	})
+
+	t.Run("case=the configuration is used without invalid or unresolvable overrides", func(t *testing.T) {
+		assert.Same(t, p, p.Contextualize(ctx))
+		assert.Same(t, p, p.Contextualize(context.WithValue(ctx, tenantContextKey{}, "invalid")))
+		assert.Same(t, p, p.Contextualize(context.WithValue(ctx, tenantContextKey{}, "broken")))
+	})
+
+	t.Run("case=contextualized configurations are cached until the configuration changes", func(t *testing.T) {
+		c := p.Contextualize(acme)
+		assert.Same(t, c, p.Contextualize(acme))
+
+		p.MustSet(ViperKeySessionName, "acme_session")
+		changed := p.Contextualize(acme)
+		assert.NotSame(t, c, changed)
+		assert.Equal(t, "acme_session", changed.SessionName())
+		assert.Equal(t, 2*time.Hour, changed.SessionLifespan())
+	})
+
+	t.Run("case=the request is attached to the context", func(t *testing.T) {
+		r := &http.Request{Host: "acme.example.org"}
+		actual, ok := RequestFromContext(ContextWithRequest(ctx, r))
+		require.True(t, ok)
+		assert.Same(t, r, actual)
+
+		_, ok = RequestFromContext(ctx)
+		assert.False(t, ok)
+	})
+}
@@ -0,0 +1,251 @@
+package config
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"io/ioutil"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ghodss/yaml"
+	"github.com/pkg/errors"
+
+	"github.com/ory/jsonschema/v3"
+	"github.com/ory/x/configx"
+)
+
+// DefaultConfigDirectoryPollInterval is the interval in which a config directory is checked for changes.
+const DefaultConfigDirectoryPollInterval = 10 * time.Second
+
+var immutableKeys = []string{"serve", "profiling", "log"}
+
+// configDirectory is a directory of config fragments, for example a mounted Kubernetes ConfigMap or Secret.
+//
+// Fragments are the .json, .yaml, and .yml files of the directory. They are merged in lexical order of their
+// file names, so 10-overrides.yaml overrides keys set in 00-base.yaml. Objects are merged key by key, all other
+// values, including arrays, are replaced. Entries starting with a dot, such as the ..data symlink Kubernetes
+// swaps atomically when a ConfigMap changes, are ignored, but the files they point to are read through the
+// symlinks of the fragments.
+type configDirectory struct {
+	dir    string
+	digest [sha256.Size]byte
+	values map[string]interface{}
+}
+
+// read returns the digest and the merged values of the fragments.
+func (d *configDirectory) read() (digest [sha256.Size]byte, values map[string]interface{}, err error) {
+	infos, err := ioutil.ReadDir(d.dir)
+	if err != nil {
+		return digest, nil, errors.WithStack(err)
+	}
+
+	names := make([]string, 0, len(infos))
+	for _, info := range infos {
+		name := info.Name()
+		switch strings.ToLower(filepath.Ext(name)) {
+		case ".json", ".yaml", ".yml":
+		default:
+			continue
+		}
+		if strings.HasPrefix(name, ".") {
+			continue
+		}
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	h := sha256.New()
+	values = map[string]interface{}{}
+	for _, name := range names {
+		raw, err := ioutil.ReadFile(filepath.Join(d.dir, name))
+		if err != nil {
+			return digest, nil, errors.WithStack(err)
+		}
+		_, _ = h.Write([]byte(name))
+		_, _ = h.Write(raw)
+
+		raw, err = yaml.YAMLToJSON(raw)
+		if err != nil {
+			return digest, nil, errors.Wrapf(err, "unable to parse config fragment %s", name)
+		}
+
+		var fragment map[string]interface{}
+		if err := json.Unmarshal(raw, &fragment); err != nil {
+			return digest, nil, errors.Wrapf(err, "config fragment %s must be an object", name)
+		}
+		mergeValues(values, fragment)
+	}
+
+	copy(digest[:], h.Sum(nil))
+	return digest, values, nil
+}
+
+func (d *configDirectory) load() (err error) {
+	d.digest, d.values, err = d.read()
+	return err
+}
+
+// WithConfigDirectory loads the config fragments of the directory. The fragments take precedence over config
+// files and environment variables.
+func WithConfigDirectory(dir string) (configx.OptionModifier, error) {
+	d := &configDirectory{dir: dir}
+	if err := d.load(); err != nil {
+		return nil, err
+	}
+	return configx.WithValues(flattenValues("", d.values, map[string]interface{}{})), nil
+}
+
+// WatchConfigDirectory checks the config directory for changes in the given interval until the context is
+// canceled. Changed fragments are validated together with the rest of the configuration and applied. Changes
+// to keys which can not be changed at runtime (serve, profiling, and log) and keys removed from all fragments
+// only take effect after a restart.
+func (p *Config) WatchConfigDirectory(ctx context.Context, dir string, interval time.Duration) {
+	d := &configDirectory{dir: dir}
+	if err := d.load(); err != nil {
+		p.l.WithError(err).WithField("directory", dir).Error("Unable to load the config directory.")
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := p.reloadConfigDirectory(d); err != nil {
+				p.l.WithError(err).WithField("directory", dir).Error("The changed config directory was rejected, the previous configuration is kept.")
+			}
+		}
+	}
+}
+
+func (p *Config) reloadConfigDirectory(d *configDirectory) error {
+	digest, values, err := d.read()
+	if err != nil {
+		return err
+	} else if digest == d.digest {
+		return nil
+	}
+
+	candidate := p.p.Raw()
+	mergeValues(candidate, values)
+	if err := validateConfig(candidate); err != nil {
+		return err
+	}
+
+	previous := flattenValues("", d.values, map[string]interface{}{})
+	current := flattenValues("", values, map[string]interface{}{})
+	d.digest, d.values = digest, values
+
+	keys := make([]string, 0, len(current))
+	for key := range current {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var applied int
+	for _, key := range keys {
+		if old, ok := previous[key]; ok && reflect.DeepEqual(old, current[key]) {
+			continue
+		} else if isImmutableKey(key) {
+			p.l.WithField("key", key).Warn("A config fragment changed a key which can not be changed at runtime, restart ORY Kratos to apply the change.")
+			continue
+		}
+
+		if err := p.p.Set(key, current[key]); err != nil {
+			return err
+		}
+		applied++
+	}
+
+	for key := range previous {
+		if _, ok := current[key]; !ok {
+			p.l.WithField("key", key).Warn("A key was removed from the config fragments, restart ORY Kratos to apply the change.")
+		}
+	}
+
+	if applied > 0 {
+		p.l.WithField("directory", d.dir).Infof("Applied %d changed configuration values from the config directory.", applied)
+		p.notifySubscribers()
+	}
+	return nil
+}
+
+var validationSchema struct {
+	sync.Once
+	s   *jsonschema.Schema
+	err error
+}
+
+// compiledValidationSchema compiles the configuration schema once and returns it on subsequent calls.
+func compiledValidationSchema() (*jsonschema.Schema, error) {
+	validationSchema.Do(func() {
+		c := jsonschema.NewCompiler()
+		if err := c.AddResource("config.schema.json", bytes.NewReader(ValidationSchema)); err != nil {
+			validationSchema.err = errors.WithStack(err)
+			return
+		}
+		validationSchema.s, validationSchema.err = c.Compile("config.schema.json")
+		validationSchema.err = errors.WithStack(validationSchema.err)
+	})
+	return validationSchema.s, validationSchema.err
+}
+
+func validateConfig(values map[string]interface{}) error {
+	s, err := compiledValidationSchema()
+	if err != nil {
+		return err
+	}
+
+	raw, err := json.Marshal(values)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	return s.Validate(bytes.NewReader(raw))
+}
+
+func isImmutableKey(key string) bool {
+	for _, immutable := range immutableKeys {
+		if key == immutable || strings.HasPrefix(key, immutable+".") {
+			return true
+		}
+	}
+	return false
+}
+
+// mergeValues merges src into dst. Objects are merged key by key, all other values of src replace those of dst.
+func mergeValues(dst, src map[string]interface{}) {
+	for key, value := range src {
+		if s, ok := value.(map[string]interface{}); ok {
+			if d, ok := dst[key].(map[string]interface{}); ok {
+				mergeValues(d, s)
+				continue
+			}
+			copied := map[string]interface{}{}
+			mergeValues(copied, s)
+			value = copied
+		}
+		dst[key] = value
+	}
+}
+
+// flattenValues adds the values to result using dot-separated keys, for example serve.public.port.
+func flattenValues(prefix string, values map[string]interface{}, result map[string]interface{}) map[string]interface{} {
+	for key, value := range values {
+		if len(prefix) > 0 {
+			key = prefix + "." + key
+		}
+		if nested, ok := value.(map[string]interface{}); ok && len(nested) > 0 {
+			flattenValues(key, nested, result)
+			continue
+		}
+		result[key] = value
+	}
+	return result
+}
@@ -0,0 +1,94 @@
+package config
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ory/x/configx"
+	"github.com/ory/x/logrusx"
+)
+
+func TestConfigDirectory(t *testing.T) {
+	dir, err := ioutil.TempDir("", "kratos-config-dir-")
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = os.RemoveAll(dir) })
+
+	// publish mimics how Kubernetes updates a mounted ConfigMap: the fragments are written to a new directory
+	// and the ..data symlink is swapped atomically.
+	var version int
+	publish := func(t *testing.T, fragments map[string]string) {
+		version++
+		data := filepath.Join(dir, fmt.Sprintf("..version-%d", version))
+		require.NoError(t, os.Mkdir(data, 0700))
+		for name, content := range fragments {
+			require.NoError(t, ioutil.WriteFile(filepath.Join(data, name), []byte(content), 0600))
+			if _, err := os.Lstat(filepath.Join(dir, name)); os.IsNotExist(err) {
+				require.NoError(t, os.Symlink(filepath.Join("..data", name), filepath.Join(dir, name)))
+			}
+		}
+		require.NoError(t, os.Symlink(filepath.Base(data), filepath.Join(dir, "..data_tmp")))
+		require.NoError(t, os.Rename(filepath.Join(dir, "..data_tmp"), filepath.Join(dir, "..data")))
+	}
+
+	base := `
+dsn: memory
+identity:
+  default_schema_url: file://stub/identity.schema.json
+selfservice:
+  default_browser_return_url: https://www.ory.sh/
+serve:
+  public:
+    port: 4433
+session:
+  lifespan: 1h
+`
+	publish(t, map[string]string{
+		"00-base.yaml":     base,
+		"10-override.json": `{"session":{"lifespan":"2h"}}`,
+	})
+
+	opt, err := WithConfigDirectory(dir)
+	require.NoError(t, err)
+	p := MustNew(logrusx.New("", ""), opt, configx.SkipValidation())
+
+	t.Run("case=fragments are merged in lexical order", func(t *testing.T) {
+		assert.Equal(t, "memory", p.DSN())
+		assert.Equal(t, 2*time.Hour, p.SessionLifespan())
+	})
+
+	d := &configDirectory{dir: dir}
+	require.NoError(t, d.load())
+
+	t.Run("case=unchanged fragments are not applied again", func(t *testing.T) {
+		var calls int
+		t.Cleanup(p.OnChange("", func() { calls++ }))
+		require.NoError(t, p.reloadConfigDirectory(d))
+		assert.Equal(t, 0, calls)
+	})
+
+	t.Run("case=symlink swaps are applied", func(t *testing.T) {
+		publish(t, map[string]string{
+			"00-base.yaml":     base,
+			"10-override.json": `{"session":{"lifespan":"3h"},"serve":{"public":{"port":4444}}}`,
+		})
+		require.NoError(t, p.reloadConfigDirectory(d))
+		assert.Equal(t, 3*time.Hour, p.SessionLifespan())
+		assert.Equal(t, 4433, p.p.Int(ViperKeyPublicPort), "immutable keys are only applied after a restart")
+	})
+
+	t.Run("case=invalid fragments are rejected", func(t *testing.T) {
+		publish(t, map[string]string{
+			"00-base.yaml":     base,
+			"10-override.json": `{"session":{"lifespan":"forever"}}`,
+		})
+		require.Error(t, p.reloadConfigDirectory(d))
+		assert.Equal(t, 3*time.Hour, p.SessionLifespan())
+	})
+}
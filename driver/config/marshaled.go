@@ -0,0 +1,46 @@
+package config
+
+import (
+	"sync"
+
+	kjson "github.com/knadh/koanf/parsers/json"
+)
+
+// marshaledConfig caches the configuration encoded as JSON. Accessors of nested values such as hooks query it
+// using gjson on every request, and encoding the whole configuration each time is expensive. The cache is reset
+// whenever the configuration changes.
+type marshaledConfig struct {
+	sync.RWMutex
+	out        []byte
+	generation uint64
+}
+
+// marshal returns the configuration encoded as JSON. The returned slice must not be modified.
+func (p *Config) marshal() ([]byte, error) {
+	p.marshaled.RLock()
+	out, generation := p.marshaled.out, p.marshaled.generation
+	p.marshaled.RUnlock()
+	if out != nil {
+		return out, nil
+	}
+
+	out, err := p.p.Marshal(kjson.Parser())
+	if err != nil {
+		return nil, err
+	}
+
+	// Do not cache the result if the configuration changed while it was encoded.
+	p.marshaled.Lock()
+	if p.marshaled.generation == generation {
+		p.marshaled.out = out
+	}
+	p.marshaled.Unlock()
+	return out, nil
+}
+
+func (p *Config) resetMarshaled() {
+	p.marshaled.Lock()
+	p.marshaled.out = nil
+	p.marshaled.generation++
+	p.marshaled.Unlock()
+}
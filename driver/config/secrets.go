@@ -0,0 +1,216 @@
+package config
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/tidwall/gjson"
+)
+
+const (
+	// SecretsProviderScheme is the URI scheme of secrets which are resolved by a SecretsProvider.
+	SecretsProviderScheme = "kms"
+
+	// VaultScheme is a shorthand for secrets stored in Vault: vault://<path> is the same as kms://vault/<path>.
+	VaultScheme = "vault"
+)
+
+type (
+	// SecretsProvider resolves secrets which are stored in a key management system instead of the configuration.
+	// Such secrets are configured as URIs of the form kms://<provider>/<path>, for example
+	// kms://vault/secret/data/kratos#cookie, where <provider> is the name the SecretsProvider was registered with.
+	SecretsProvider interface {
+		// ResolveSecret returns the plaintext secret the URI points to.
+		ResolveSecret(ctx context.Context, uri *url.URL) ([]byte, error)
+	}
+
+	// VaultSecretsProvider reads secrets from the KV secrets engine of HashiCorp Vault. The URI's path is the path
+	// of the secret and its fragment the key of the value, for example kms://vault/secret/data/kratos#cookie.
+	VaultSecretsProvider struct {
+		Address string
+		Token   string
+		Client  *http.Client
+	}
+
+	resolvedSecrets struct {
+		sync.Mutex
+		values map[string]resolvedSecret
+	}
+	resolvedSecret struct {
+		value     []byte
+		fetchedAt time.Time
+	}
+)
+
+var (
+	secretsProvidersMu sync.RWMutex
+	secretsProviders   = map[string]SecretsProvider{
+		"vault": &VaultSecretsProvider{
+			Address: os.Getenv("VAULT_ADDR"),
+			Token:   os.Getenv("VAULT_TOKEN"),
+			Client:  &http.Client{Timeout: 10 * time.Second},
+		},
+	}
+)
+
+// RegisterSecretsProvider makes the SecretsProvider available for secrets of the form kms://<name>/<path>. It is
+// meant to be called from an init function of custom builds, for example to add providers for AWS KMS or GCP KMS.
+// Registering a provider with the same name twice replaces the previous provider.
+func RegisterSecretsProvider(name string, provider SecretsProvider) {
+	secretsProvidersMu.Lock()
+	defer secretsProvidersMu.Unlock()
+	secretsProviders[name] = provider
+}
+
+// IsSecretsProviderURI returns true if the secret is a URI which is resolved by a SecretsProvider.
+func IsSecretsProviderURI(secret string) bool {
+	return strings.HasPrefix(secret, SecretsProviderScheme+"://") || strings.HasPrefix(secret, VaultScheme+"://")
+}
+
+// ResolveSecret returns the secret as is unless it is a kms:// or vault:// URI, in which case the secret is fetched
+// from the SecretsProvider registered for the URI's host. Fetched secrets are cached until kms.refresh_interval has
+// passed, or for the lifetime of the process if no interval is set. If re-fetching a secret fails, the cached
+// secret is used.
+func (p *Config) ResolveSecret(ctx context.Context, secret string) ([]byte, error) {
+	if !IsSecretsProviderURI(secret) {
+		return []byte(secret), nil
+	}
+
+	p.resolved.Lock()
+	defer p.resolved.Unlock()
+	cached, ok := p.resolved.values[secret]
+	if ok {
+		if interval := p.KMSRefreshInterval(); interval == 0 || time.Since(cached.fetchedAt) < interval {
+			return cached.value, nil
+		}
+	}
+
+	value, err := p.fetchSecret(ctx, secret)
+	if err != nil {
+		if ok {
+			p.l.WithError(err).Warn("Unable to re-fetch a secret from the secrets provider, the previous secret is used instead.")
+			return cached.value, nil
+		}
+		return nil, err
+	}
+
+	p.resolved.values[secret] = resolvedSecret{value: value, fetchedAt: time.Now()}
+	return value, nil
+}
+
+func (p *Config) fetchSecret(ctx context.Context, secret string) ([]byte, error) {
+	if strings.HasPrefix(secret, VaultScheme+"://") {
+		secret = SecretsProviderScheme + "://" + VaultScheme + "/" + strings.TrimPrefix(secret, VaultScheme+"://")
+	}
+
+	uri, err := url.Parse(secret)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	secretsProvidersMu.RLock()
+	provider, ok := secretsProviders[uri.Host]
+	secretsProvidersMu.RUnlock()
+	if !ok {
+		return nil, errors.Errorf("no secrets provider is registered for %q", uri.Host)
+	}
+
+	value, err := provider.ResolveSecret(ctx, uri)
+	if err != nil {
+		return nil, err
+	}
+	if len(value) == 0 {
+		return nil, errors.Errorf("the secrets provider %q returned an empty secret", uri.Host)
+	}
+	return value, nil
+}
+
+// remote returns the value of the key and fetches it from the secrets provider if it is a kms:// or vault:// URI,
+// for example to keep the DSN out of configuration files and environment variables.
+func (p *Config) remote(key string) string {
+	raw := p.p.String(key)
+	if !IsSecretsProviderURI(raw) {
+		return raw
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	value, err := p.ResolveSecret(ctx, raw)
+	if err != nil {
+		p.l.WithError(err).Fatalf("Unable to fetch the value of configuration key %s from the secrets provider.", key)
+	}
+	return string(value)
+}
+
+// secrets returns the secrets set for the key and resolves kms:// URIs. ORY Kratos can not run without its
+// secrets, so a secret which can not be resolved is fatal.
+func (p *Config) secrets(key string) [][]byte {
+	secrets := p.p.Strings(key)
+	if len(secrets) == 0 {
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	result := make([][]byte, len(secrets))
+	for k, v := range secrets {
+		value, err := p.ResolveSecret(ctx, v)
+		if err != nil {
+			p.l.WithError(err).Fatalf("Unable to resolve secret #%d of configuration key %s.", k, key)
+		}
+		result[k] = value
+	}
+
+	return result
+}
+
+func (v *VaultSecretsProvider) ResolveSecret(ctx context.Context, uri *url.URL) ([]byte, error) {
+	if v.Address == "" {
+		return nil, errors.New("the address of Vault is unknown, set the environment variable VAULT_ADDR")
+	}
+	if uri.Fragment == "" {
+		return nil, errors.Errorf("the secret URI %s does not name the key of the value, for example #cookie", uri.Redacted())
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "GET", strings.TrimSuffix(v.Address, "/")+"/v1/"+strings.TrimPrefix(uri.Path, "/"), nil)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	req.Header.Set("X-Vault-Token", v.Token)
+
+	res, err := v.Client.Do(req)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	defer res.Body.Close()
+
+	body, err := ioutil.ReadAll(res.Body)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	if res.StatusCode != http.StatusOK {
+		return nil, errors.Errorf("vault responded with status code %d", res.StatusCode)
+	}
+
+	// Version 2 of the KV secrets engine nests the values in data.data, version 1 in data.
+	for _, path := range []string{"data.data.", "data."} {
+		if value := gjson.GetBytes(body, path+gjsonEscape(uri.Fragment)); value.Type == gjson.String {
+			return []byte(value.String()), nil
+		}
+	}
+	return nil, errors.Errorf("the Vault secret %s has no string value %q", uri.Path, uri.Fragment)
+}
+
+func gjsonEscape(key string) string {
+	r := strings.NewReplacer(".", `\.`, "*", `\*`, "?", `\?`)
+	return r.Replace(key)
+}
@@ -0,0 +1,83 @@
+package config
+
+import (
+	"context"
+	"sync"
+
+	"github.com/pkg/errors"
+
+	"github.com/ory/x/configx"
+)
+
+type snapshotContextKey struct{}
+
+// ErrSnapshotImmutable is returned when a value of a configuration snapshot is set.
+var ErrSnapshotImmutable = errors.New("the configuration snapshot can not be changed")
+
+// snapshots caches the snapshot of the current configuration. It is reset whenever the configuration changes, so
+// that a snapshot is only taken once per change and not once per request.
+type snapshots struct {
+	sync.Mutex
+	current *Config
+}
+
+// Snapshot returns an immutable view of the configuration. Use it for the duration of a request so that a
+// configuration reload while the request is handled can not produce a mix of old and new values, for example a
+// new UI URL with the old flow lifespan. If the context carries a snapshot, see ContextWithSnapshot, that snapshot
+// is returned.
+func (p *Config) Snapshot(ctx context.Context) *Config {
+	if s, ok := SnapshotFromContext(ctx); ok {
+		return s
+	} else if p.frozen {
+		return p
+	}
+
+	p.snapshots.Lock()
+	defer p.snapshots.Unlock()
+	if p.snapshots.current != nil {
+		return p.snapshots.current
+	}
+
+	snapshot, err := p.frozenCopy(p.p.All())
+	if err != nil {
+		p.l.WithError(err).Error("Unable to take a snapshot of the configuration, the current configuration is used instead.")
+		return p
+	}
+
+	p.snapshots.current = snapshot
+	return p.snapshots.current
+}
+
+// frozenCopy returns an immutable configuration with the given values, which are keyed by dot-separated paths.
+func (p *Config) frozenCopy(values map[string]interface{}) (*Config, error) {
+	provider, err := configx.New(ValidationSchema, configx.SkipValidation(), configx.WithValues(values))
+	if err != nil {
+		return nil, err
+	}
+
+	return &Config{
+		l:         p.l,
+		p:         provider,
+		resolved:  p.resolved,
+		subs:      new(subscriptions),
+		marshaled: new(marshaledConfig),
+		frozen:    true,
+	}, nil
+}
+
+func (p *Config) resetSnapshot() {
+	p.snapshots.Lock()
+	p.snapshots.current = nil
+	p.snapshots.Unlock()
+}
+
+// ContextWithSnapshot returns a copy of ctx which carries the configuration snapshot.
+func ContextWithSnapshot(ctx context.Context, snapshot *Config) context.Context {
+	return context.WithValue(ctx, snapshotContextKey{}, snapshot)
+}
+
+// SnapshotFromContext returns the configuration snapshot attached using ContextWithSnapshot, if any.
+func SnapshotFromContext(ctx context.Context) (*Config, bool) {
+	s, ok := ctx.Value(snapshotContextKey{}).(*Config)
+	return s, ok
+}
@@ -0,0 +1,51 @@
+package config
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ory/x/configx"
+	"github.com/ory/x/logrusx"
+)
+
+func TestSnapshot(t *testing.T) {
+	ctx := context.Background()
+	p := MustNew(logrusx.New("", ""), configx.SkipValidation())
+	p.MustSet(ViperKeySessionLifespan, "1h")
+	p.MustSet(ViperKeySelfServiceBrowserDefaultReturnTo, "https://www.ory.sh/")
+
+	s := p.Snapshot(ctx)
+	assert.Equal(t, time.Hour, s.SessionLifespan())
+	assert.Same(t, s, p.Snapshot(ctx), "the snapshot is reused until the configuration changes")
+
+	t.Run("case=snapshots do not change", func(t *testing.T) {
+		p.MustSet(ViperKeySessionLifespan, "2h")
+		p.MustSet(ViperKeySelfServiceBrowserDefaultReturnTo, "https://www.ory.sh/kratos/")
+
+		assert.Equal(t, time.Hour, s.SessionLifespan())
+		assert.Equal(t, "https://www.ory.sh/", s.SelfServiceBrowserDefaultReturnTo().String())
+
+		current := p.Snapshot(ctx)
+		assert.NotSame(t, s, current)
+		assert.Equal(t, 2*time.Hour, current.SessionLifespan())
+		assert.Equal(t, "https://www.ory.sh/kratos/", current.SelfServiceBrowserDefaultReturnTo().String())
+	})
+
+	t.Run("case=snapshots can not be changed", func(t *testing.T) {
+		assert.ErrorIs(t, s.Set(ViperKeySessionLifespan, "3h"), ErrSnapshotImmutable)
+		assert.Same(t, s, s.Snapshot(ctx))
+	})
+
+	t.Run("case=the snapshot of the context is used", func(t *testing.T) {
+		ctx := ContextWithSnapshot(ctx, s)
+		assert.Same(t, s, p.Snapshot(ctx))
+
+		actual, ok := SnapshotFromContext(ctx)
+		require.True(t, ok)
+		assert.Same(t, s, actual)
+	})
+}
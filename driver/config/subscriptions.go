@@ -0,0 +1,91 @@
+package config
+
+import (
+	"reflect"
+	"sync"
+
+	"github.com/ory/x/watcherx"
+)
+
+type (
+	subscription struct {
+		key  string
+		last interface{}
+		fn   func()
+	}
+
+	subscriptions struct {
+		sync.Mutex
+		subs []*subscription
+	}
+)
+
+// OnChange registers fn to be called whenever the value of key changes, either because the configuration
+// file was changed on disk or because the value was set using Set. Keys are dot-separated paths, for
+// example "courier.smtp" which also fires when a nested key such as "courier.smtp.connection_uri"
+// changes. An empty key subscribes to all changes.
+//
+// Immutable keys (serve, profiling, log) never change at runtime because the configuration provider
+// rejects such changes, so subscribing to them has no effect. The returned function removes the
+// subscription.
+func (p *Config) OnChange(key string, fn func()) (unsubscribe func()) {
+	s := &subscription{key: key, last: p.get(key), fn: fn}
+
+	p.subs.Lock()
+	p.subs.subs = append(p.subs.subs, s)
+	p.subs.Unlock()
+
+	return func() {
+		p.subs.Lock()
+		defer p.subs.Unlock()
+		for k, v := range p.subs.subs {
+			if v == s {
+				p.subs.subs = append(p.subs.subs[:k], p.subs.subs[k+1:]...)
+				return
+			}
+		}
+	}
+}
+
+func (p *Config) get(key string) interface{} {
+	if key == "" {
+		return p.p.All()
+	}
+	return p.p.Get(key)
+}
+
+// notifySubscribers calls all subscribers whose values have changed since they were last notified. It must be
+// called whenever the configuration changed, as it also resets the cached JSON encoding, snapshot, and
+// contextualized copies of the configuration.
+func (p *Config) notifySubscribers() {
+	p.resetMarshaled()
+	p.resetSnapshot()
+	p.resetContextualized()
+
+	p.subs.Lock()
+	var changed []func()
+	for _, s := range p.subs.subs {
+		current := p.get(s.key)
+		if reflect.DeepEqual(current, s.last) {
+			continue
+		}
+		s.last = current
+		changed = append(changed, s.fn)
+	}
+	p.subs.Unlock()
+
+	// Call the subscribers outside of the lock so that they can read the configuration or
+	// (un)subscribe without deadlocking.
+	for _, fn := range changed {
+		fn()
+	}
+}
+
+func (p *Config) watcher(_ watcherx.Event, err error) {
+	if err != nil {
+		// The change was rejected, for example because it was invalid or touched an immutable key,
+		// and the previous configuration is still in use.
+		return
+	}
+	p.notifySubscribers()
+}
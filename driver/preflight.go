@@ -0,0 +1,216 @@
+package driver
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/ory/jsonschema/v3"
+
+	"github.com/ory/kratos/driver/config"
+	"github.com/ory/kratos/schema"
+)
+
+// minSecretEntropy is the minimum estimated entropy of secrets in bits.
+const minSecretEntropy = 48
+
+// PreflightError lists all problems found by Preflight.
+type PreflightError struct {
+	Problems []string
+}
+
+func (e *PreflightError) Error() string {
+	return fmt.Sprintf("the configuration has %d problem(s):\n  - %s", len(e.Problems), strings.Join(e.Problems, "\n  - "))
+}
+
+type preflight struct {
+	ctx      context.Context
+	c        *config.Config
+	client   *http.Client
+	problems []string
+}
+
+// Preflight validates configuration which can only be checked at runtime, such as whether the UI URLs are
+// reachable and the identity schemas can be loaded. Instead of stopping at the first problem, it returns a
+// *PreflightError listing all of them.
+//
+// UI URLs are not requested in dev mode and not checked at all if the hosted UI is enabled.
+func Preflight(ctx context.Context, r Registry) error {
+	p := &preflight{
+		ctx:    ctx,
+		c:      r.Config(ctx),
+		client: &http.Client{Timeout: 5 * time.Second},
+	}
+
+	p.checkUIURLs()
+	p.checkURL(config.ViperKeySelfServiceBrowserDefaultReturnTo, false)
+	p.checkSMTP()
+	p.checkIdentitySchemas()
+	p.checkSecrets()
+
+	if len(p.problems) > 0 {
+		return errors.WithStack(&PreflightError{Problems: p.problems})
+	}
+	return nil
+}
+
+func (p *preflight) report(key, format string, args ...interface{}) {
+	p.problems = append(p.problems, fmt.Sprintf("%s: %s", key, fmt.Sprintf(format, args...)))
+}
+
+func (p *preflight) checkUIURLs() {
+	// The hosted UI is served by ORY Kratos itself and replaces the configured UI URLs.
+	if p.c.SelfServiceHostedUIEnabled() {
+		return
+	}
+
+	for _, ui := range []struct {
+		key     string
+		enabled bool
+	}{
+		{key: config.ViperKeySelfServiceErrorUI, enabled: true},
+		{key: config.ViperKeySelfServiceLoginUI, enabled: p.c.SelfServiceFlowLoginEnabled()},
+		{key: config.ViperKeySelfServiceRegistrationUI, enabled: p.c.SelfServiceFlowRegistrationEnabled()},
+		{key: config.ViperKeySelfServiceSettingsURL, enabled: p.c.SelfServiceFlowSettingsEnabled()},
+		{key: config.ViperKeySelfServiceRecoveryUI, enabled: p.c.SelfServiceFlowRecoveryEnabled()},
+		{key: config.ViperKeySelfServiceVerificationUI, enabled: p.c.SelfServiceFlowVerificationEnabled()},
+	} {
+		if ui.enabled {
+			// In dev mode the UI is often started together with ORY Kratos and not yet reachable.
+			p.checkURL(ui.key, !p.c.IsInsecureDevMode())
+		}
+	}
+}
+
+// checkURL reports the key if its value is not an absolute URL or, if reachable is true, if it does not
+// respond to HTTP requests. Any response, including errors, counts as reachable.
+func (p *preflight) checkURL(key string, reachable bool) {
+	raw := p.c.Source().String(key)
+	u, err := url.Parse(raw)
+	if err != nil || u.Scheme == "" || u.Host == "" {
+		p.report(key, "%q is not an absolute URL such as https://my-app.com/login", raw)
+		return
+	}
+
+	if !reachable {
+		return
+	}
+
+	req, err := http.NewRequestWithContext(p.ctx, "HEAD", u.String(), nil)
+	if err != nil {
+		p.report(key, "unable to request %q: %s", raw, err)
+		return
+	}
+
+	res, err := p.client.Do(req)
+	if err != nil {
+		p.report(key, "%q is not reachable, make sure the UI is running: %s", raw, err)
+		return
+	}
+	_ = res.Body.Close()
+}
+
+func (p *preflight) checkSMTP() {
+	raw := p.c.Source().String(config.ViperKeyCourierSMTPURL)
+	if config.IsSecretsProviderURI(raw) {
+		value, err := p.c.ResolveSecret(p.ctx, raw)
+		if err != nil {
+			p.report(config.ViperKeyCourierSMTPURL, "unable to fetch the connection URI: %s", err)
+			return
+		}
+		raw = string(value)
+	}
+
+	u, err := url.Parse(raw)
+	if err != nil {
+		if config.IsSecretsProviderURI(p.c.Source().String(config.ViperKeyCourierSMTPURL)) {
+			// The error contains the connection URI which must not be logged if it was fetched from a secrets provider.
+			p.report(config.ViperKeyCourierSMTPURL, "unable to parse the connection URI fetched from the secrets provider")
+			return
+		}
+		p.report(config.ViperKeyCourierSMTPURL, "unable to parse the connection URI: %s", err)
+		return
+	}
+
+	if u.Scheme != "smtp" && u.Scheme != "smtps" {
+		p.report(config.ViperKeyCourierSMTPURL, "expected the scheme to be smtp or smtps but got %q", u.Scheme)
+	}
+	if u.Hostname() == "" {
+		p.report(config.ViperKeyCourierSMTPURL, "the connection URI has no host")
+	}
+}
+
+func (p *preflight) checkIdentitySchemas() {
+	// IdentityTraitsSchemas exits the process if the default schema URL is invalid.
+	raw := p.c.Source().String(config.ViperKeyDefaultIdentitySchemaURL)
+	if u, err := url.ParseRequestURI(raw); err != nil || u.Scheme == "" {
+		p.report(config.ViperKeyDefaultIdentitySchemaURL, "%q is not a valid URL such as file:///etc/config/kratos/identity.schema.json", raw)
+		return
+	}
+
+	for _, s := range p.c.IdentityTraitsSchemas() {
+		if p.c.IdentitySchemaLoading().OnFailure == config.SchemaLoadingCache && schema.IsRemote(s.URL) {
+			// With this failure policy ORY Kratos starts even if the schema host is down.
+			continue
+		}
+
+		key := config.ViperKeyIdentitySchemas
+		if s.ID == config.DefaultIdentityTraitsSchemaID {
+			key = config.ViperKeyDefaultIdentitySchemaURL
+		}
+
+		if _, err := jsonschema.NewCompiler().Compile(s.URL); err != nil {
+			p.report(key, "unable to load the identity schema %q from %q: %s", s.ID, s.URL, err)
+		}
+	}
+}
+
+func (p *preflight) checkSecrets() {
+	if len(p.c.Source().Strings(config.ViperKeySecretsDefault)) == 0 &&
+		p.c.Source().String(config.ViperKeySecretsGeneratedPath) == "" &&
+		!p.c.IsInsecureDevMode() {
+		p.report(config.ViperKeySecretsDefault, "no secret is set, so a random secret is generated on every start which invalidates all sessions on restart, set %s to keep the generated secret", config.ViperKeySecretsGeneratedPath)
+	}
+
+	for _, key := range []string{config.ViperKeySecretsDefault, config.ViperKeySecretsCookie, config.ViperKeySecretsPepper, config.ViperKeyHasherArgon2ConfigPepper} {
+		for k, raw := range p.c.Source().Strings(key) {
+			// Secrets stored in a key management system are resolved here so that ORY Kratos fails at startup
+			// instead of when the secret is first used.
+			value, err := p.c.ResolveSecret(p.ctx, raw)
+			if err != nil {
+				p.report(key, "unable to resolve secret #%d: %s", k, err)
+				continue
+			}
+
+			if secret := string(value); len(secret) < 16 {
+				p.report(key, "secret #%d must be at least 16 characters long but has %d", k, len(secret))
+			} else if e := secretEntropy(secret); e < minSecretEntropy {
+				p.report(key, "secret #%d is too predictable (about %.0f bits of entropy), use a random value such as the output of `openssl rand -hex 32`", k, e)
+			}
+		}
+	}
+}
+
+// secretEntropy estimates the entropy of the secret in bits based on how often each character occurs in it.
+// Repetitive secrets such as "passwordpassword" score low while random secrets score high.
+func secretEntropy(secret string) float64 {
+	counts := map[rune]float64{}
+	var n float64
+	for _, c := range secret {
+		counts[c]++
+		n++
+	}
+
+	var perChar float64
+	for _, count := range counts {
+		f := count / n
+		perChar -= f * math.Log2(f)
+	}
+	return perChar * n
+}
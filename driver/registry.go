@@ -11,6 +11,7 @@ import (
 
 	"github.com/ory/x/logrusx"
 
+	"github.com/ory/kratos/cipher"
 	"github.com/ory/kratos/continuity"
 	"github.com/ory/kratos/courier"
 	"github.com/ory/kratos/hash"
@@ -22,16 +23,22 @@ import (
 
 	"github.com/ory/x/healthx"
 
+	"github.com/ory/kratos/courier/delivery"
+	"github.com/ory/kratos/maintenance"
 	"github.com/ory/kratos/persistence"
+	"github.com/ory/kratos/plugin"
+	"github.com/ory/kratos/runtimeconfig"
 	"github.com/ory/kratos/selfservice/flow/login"
 	"github.com/ory/kratos/selfservice/flow/logout"
 	"github.com/ory/kratos/selfservice/flow/registration"
+	"github.com/ory/kratos/selfservice/hostedui"
 
 	"github.com/ory/kratos/x"
 
 	"github.com/ory/x/dbal"
 
 	"github.com/ory/kratos/driver/config"
+	"github.com/ory/kratos/idempotency"
 	"github.com/ory/kratos/identity"
 	"github.com/ory/kratos/selfservice/errorx"
 	password2 "github.com/ory/kratos/selfservice/strategy/password"
@@ -47,6 +54,8 @@ type Registry interface {
 
 	WithCSRFHandler(c x.CSRFHandler)
 	WithCSRFTokenGenerator(cg x.CSRFToken)
+	WithClock(c x.Clock)
+	WithIDGenerator(g x.IDGenerator)
 
 	HealthHandler(ctx context.Context) *healthx.Handler
 	CookieManager(ctx context.Context) sessions.Store
@@ -57,6 +66,7 @@ type Registry interface {
 	RegisterPublicRoutes(ctx context.Context, public *x.RouterPublic)
 	RegisterAdminRoutes(ctx context.Context, admin *x.RouterAdmin)
 	PrometheusManager() *prometheus.MetricsManager
+	MetricsGaugeRefresher() *prometheus.GaugeRefresher
 	Tracer(context.Context) *tracing.Tracer
 
 	config.Provider
@@ -65,6 +75,8 @@ type Registry interface {
 	x.CSRFProvider
 	x.WriterProvider
 	x.LoggingProvider
+	x.ClockProvider
+	x.IDGeneratorProvider
 
 	continuity.ManagementProvider
 	continuity.PersistenceProvider
@@ -79,11 +91,17 @@ type Registry interface {
 
 	hash.HashProvider
 
+	cipher.Provider
+
 	identity.HandlerProvider
+	idempotency.HandlerProvider
+	idempotency.PersistenceProvider
 	identity.ValidationProvider
 	identity.PoolProvider
 	identity.PrivilegedPoolProvider
+	identity.TransactionPersistenceProvider
 	identity.ManagementProvider
+	identity.DormancyEnforcerProvider
 	identity.ActiveCredentialsCounterStrategyProvider
 
 	schema.HandlerProvider
@@ -93,6 +111,8 @@ type Registry interface {
 	session.HandlerProvider
 	session.ManagementProvider
 	session.PersistenceProvider
+	session.WebhookProvider
+	session.RevocationPublisherProvider
 
 	settings.HandlerProvider
 	settings.ErrorHandlerProvider
@@ -105,10 +125,23 @@ type Registry interface {
 	login.HookExecutorProvider
 	login.HandlerProvider
 	login.StrategyProvider
+	logout.StrategyProvider
 
 	logout.HandlerProvider
+	logout.PropagatorProvider
+	logout.HooksProvider
+
+	hostedui.HandlerProvider
+
+	plugin.ManagementProvider
+
+	maintenance.HandlerProvider
+	runtimeconfig.HandlerProvider
+
+	delivery.HandlerProvider
 
 	registration.FlowPersistenceProvider
+	registration.TransactionPersistenceProvider
 	registration.ErrorHandlerProvider
 	registration.HooksProvider
 	registration.HookExecutorProvider
@@ -121,6 +154,7 @@ type Registry interface {
 	verification.StrategyProvider
 
 	link.SenderProvider
+	link.VerificationReminderProvider
 	link.VerificationTokenPersistenceProvider
 	link.RecoveryTokenPersistenceProvider
 
@@ -16,6 +16,7 @@ import (
 
 	"github.com/gobuffalo/pop/v5"
 
+	"github.com/ory/kratos/cipher"
 	"github.com/ory/kratos/continuity"
 	"github.com/ory/kratos/hash"
 	"github.com/ory/kratos/schema"
@@ -40,16 +41,22 @@ import (
 	"github.com/ory/x/logrusx"
 
 	"github.com/ory/kratos/courier"
+	"github.com/ory/kratos/courier/delivery"
+	"github.com/ory/kratos/maintenance"
 	"github.com/ory/kratos/persistence"
 	"github.com/ory/kratos/persistence/sql"
+	"github.com/ory/kratos/plugin"
+	"github.com/ory/kratos/runtimeconfig"
 	"github.com/ory/kratos/selfservice/flow/login"
 	"github.com/ory/kratos/selfservice/flow/logout"
 	"github.com/ory/kratos/selfservice/flow/registration"
+	"github.com/ory/kratos/selfservice/hostedui"
 	"github.com/ory/kratos/selfservice/strategy/oidc"
 
 	"github.com/ory/herodot"
 
 	"github.com/ory/kratos/driver/config"
+	"github.com/ory/kratos/idempotency"
 	"github.com/ory/kratos/identity"
 	"github.com/ory/kratos/selfservice/errorx"
 	password2 "github.com/ory/kratos/selfservice/strategy/password"
@@ -61,35 +68,53 @@ type RegistryDefault struct {
 	l   *logrusx.Logger
 	c   *config.Config
 
-	injectedSelfserviceHooks map[string]func(config.SelfServiceHook) interface{}
+	injectedSelfserviceHooks      map[string]func(config.SelfServiceHook) interface{}
+	injectedSelfserviceStrategies []StrategyFactory
 
 	nosurf         x.CSRFHandler
 	trc            *tracing.Tracer
 	pmm            *prometheus.MetricsManager
 	writer         herodot.Writer
+	clock          x.Clock
+	idGenerator    x.IDGenerator
 	healthxHandler *healthx.Handler
 	metricsHandler *prometheus.Handler
+	gaugeRefresher *prometheus.GaugeRefresher
 
 	persister persistence.Persister
 
-	hookVerifier         *hook.Verifier
-	hookSessionIssuer    *hook.SessionIssuer
-	hookSessionDestroyer *hook.SessionDestroyer
+	hookVerifier               *hook.Verifier
+	hookSessionIssuer          *hook.SessionIssuer
+	hookSessionDestroyer       *hook.SessionDestroyer
+	hookRequireVerifiedAddress *hook.RequireVerifiedAddress
+	hookLinkExpirer            *hook.LinkExpirer
 
-	identityHandler   *identity.Handler
-	identityValidator *identity.Validator
-	identityManager   *identity.Manager
+	identityHandler          *identity.Handler
+	idempotencyHandler       *idempotency.Handler
+	identityValidator        *identity.Validator
+	identityManager          *identity.Manager
+	identityDormancyEnforcer *identity.DormancyEnforcer
 
 	continuityManager continuity.Manager
 
 	schemaHandler *schema.Handler
 
+	maintenanceHandler   *maintenance.Handler
+	runtimeConfigHandler *runtimeconfig.Handler
+
+	courierDeliveryHandler *delivery.Handler
+
 	sessionHandler *session.Handler
 	sessionManager session.Manager
+	sessionWebhook *session.Webhook
+
+	sessionRevocationPublisher *session.RevocationPublisher
 
 	passwordHasher    hash.Hasher
 	passwordValidator password2.Validator
 
+	cipher cipher.Cipher
+
 	errorHandler *errorx.Handler
 	errorManager *errorx.Manager
 
@@ -110,12 +135,19 @@ type RegistryDefault struct {
 	selfserviceVerifyManager      *identity.Manager
 	selfserviceVerifyHandler      *verification.Handler
 
-	selfserviceLinkSender *link.Sender
+	selfserviceLinkSender               *link.Sender
+	selfserviceLinkTestTokens           *link.TestTokens
+	selfserviceLinkVerificationReminder *link.VerificationReminder
 
 	selfserviceRecoveryErrorHandler *recovery.ErrorHandler
 	selfserviceRecoveryHandler      *recovery.Handler
 
-	selfserviceLogoutHandler *logout.Handler
+	selfserviceLogoutHandler    *logout.Handler
+	selfserviceLogoutPropagator *logout.Propagator
+
+	hostedUIHandler *hostedui.Handler
+
+	pluginManager *plugin.Manager
 
 	selfserviceStrategies []interface{}
 
@@ -148,6 +180,10 @@ func (m *RegistryDefault) RegisterPublicRoutes(ctx context.Context, router *x.Ro
 	m.VerificationHandler().RegisterPublicRoutes(router)
 	m.AllVerificationStrategies().RegisterPublicRoutes(router)
 
+	m.CourierDeliveryHandler().RegisterPublicRoutes(router)
+
+	m.HostedUIHandler().RegisterPublicRoutes(router)
+
 	m.HealthHandler(ctx).SetHealthRoutes(router.Router, false)
 }
 
@@ -166,6 +202,10 @@ func (m *RegistryDefault) RegisterAdminRoutes(ctx context.Context, router *x.Rou
 	m.VerificationHandler().RegisterAdminRoutes(router)
 	m.AllVerificationStrategies().RegisterAdminRoutes(router)
 
+	m.MaintenanceHandler().RegisterAdminRoutes(router)
+	m.RuntimeConfigHandler().RegisterAdminRoutes(router)
+	m.CourierDeliveryHandler().RegisterAdminRoutes(router)
+
 	m.HealthHandler(ctx).SetHealthRoutes(router.Router, true)
 	m.HealthHandler(ctx).SetVersionRoutes(router.Router)
 	m.MetricsHandler().SetRoutes(router.Router)
@@ -192,6 +232,29 @@ func (m *RegistryDefault) LogoutHandler() *logout.Handler {
 	return m.selfserviceLogoutHandler
 }
 
+func (m *RegistryDefault) PluginManager() *plugin.Manager {
+	if m.pluginManager == nil {
+		m.pluginManager = plugin.NewManager(m)
+	}
+	return m.pluginManager
+}
+
+func (m *RegistryDefault) PostLogoutHooks(ctx context.Context) (b []logout.PostHookExecutor) {
+	for _, v := range m.getHooks("", m.Config(ctx).SelfServiceFlowLogoutAfterHooks()) {
+		if hook, ok := v.(logout.PostHookExecutor); ok {
+			b = append(b, hook)
+		}
+	}
+	return
+}
+
+func (m *RegistryDefault) LogoutPropagator() *logout.Propagator {
+	if m.selfserviceLogoutPropagator == nil {
+		m.selfserviceLogoutPropagator = logout.NewPropagator(m)
+	}
+	return m.selfserviceLogoutPropagator
+}
+
 func (m *RegistryDefault) HealthHandler(_ context.Context) *healthx.Handler {
 	if m.healthxHandler == nil {
 		m.healthxHandler = healthx.NewHandler(m.Writer(), config.Version,
@@ -200,7 +263,8 @@ func (m *RegistryDefault) HealthHandler(_ context.Context) *healthx.Handler {
 					return m.Ping()
 				},
 				"migrations": func(r *http.Request) error {
-					status, err := m.Persister().MigrationStatus(r.Context())
+					// Contract migrations may be pending while the new version is rolled out.
+					status, err := m.Persister().MigrationStatusPhase(r.Context(), persistence.MigrationPhaseExpand)
 					if err != nil {
 						return err
 					}
@@ -240,7 +304,10 @@ func (m *RegistryDefault) Config(ctx context.Context) *config.Config {
 	if m.c == nil {
 		panic("configuration not set")
 	}
-	return corp.ContextualizeConfig(ctx, m.c)
+	if s, ok := config.SnapshotFromContext(ctx); ok {
+		return s
+	}
+	return corp.ContextualizeConfig(ctx, m.c).Contextualize(ctx)
 }
 
 func (m *RegistryDefault) selfServiceStrategies() []interface{} {
@@ -251,6 +318,10 @@ func (m *RegistryDefault) selfServiceStrategies() []interface{} {
 			profile.NewStrategy(m),
 			link.NewStrategy(m),
 		}
+
+		for _, f := range append(registeredStrategies(), m.injectedSelfserviceStrategies...) {
+			m.selfserviceStrategies = append(m.selfserviceStrategies, f(m))
+		}
 	}
 
 	return m.selfserviceStrategies
@@ -259,7 +330,7 @@ func (m *RegistryDefault) selfServiceStrategies() []interface{} {
 func (m *RegistryDefault) RegistrationStrategies(ctx context.Context) (registrationStrategies registration.Strategies) {
 	for _, strategy := range m.selfServiceStrategies() {
 		if s, ok := strategy.(registration.Strategy); ok {
-			if m.Config(ctx).SelfServiceStrategy(string(s.ID())).Enabled {
+			if m.Config(ctx).SelfServiceStrategy(string(s.ID())).EnabledFor(config.FlowRegistration) {
 				registrationStrategies = append(registrationStrategies, s)
 			}
 		}
@@ -281,7 +352,7 @@ func (m *RegistryDefault) AllRegistrationStrategies() registration.Strategies {
 func (m *RegistryDefault) LoginStrategies(ctx context.Context) (loginStrategies login.Strategies) {
 	for _, strategy := range m.selfServiceStrategies() {
 		if s, ok := strategy.(login.Strategy); ok {
-			if m.Config(ctx).SelfServiceStrategy(string(s.ID())).Enabled {
+			if m.Config(ctx).SelfServiceStrategy(string(s.ID())).EnabledFor(config.FlowLogin) {
 				loginStrategies = append(loginStrategies, s)
 			}
 		}
@@ -289,6 +360,17 @@ func (m *RegistryDefault) LoginStrategies(ctx context.Context) (loginStrategies
 	return
 }
 
+func (m *RegistryDefault) LogoutStrategies(ctx context.Context) (logoutStrategies logout.Strategies) {
+	for _, strategy := range m.selfServiceStrategies() {
+		if s, ok := strategy.(logout.Strategy); ok {
+			if m.Config(ctx).SelfServiceStrategy(string(s.ID())).EnabledFor(config.FlowLogout) {
+				logoutStrategies = append(logoutStrategies, s)
+			}
+		}
+	}
+	return
+}
+
 func (m *RegistryDefault) AllLoginStrategies() login.Strategies {
 	var loginStrategies []login.Strategy
 	for _, strategy := range m.selfServiceStrategies() {
@@ -311,6 +393,8 @@ func (m *RegistryDefault) ActiveCredentialsCounterStrategies(ctx context.Context
 func (m *RegistryDefault) IdentityValidator() *identity.Validator {
 	if m.identityValidator == nil {
 		m.identityValidator = identity.NewValidator(m)
+		// Identity schemas are compiled once, so compile them again if a schema was added or replaced.
+		m.c.OnChange("identity", m.identityValidator.Reset)
 	}
 	return m.identityValidator
 }
@@ -335,6 +419,13 @@ func (m *RegistryDefault) Logger() *logrusx.Logger {
 	return m.l
 }
 
+func (m *RegistryDefault) IdempotencyHandler() *idempotency.Handler {
+	if m.idempotencyHandler == nil {
+		m.idempotencyHandler = idempotency.NewHandler(m)
+	}
+	return m.idempotencyHandler
+}
+
 func (m *RegistryDefault) IdentityHandler() *identity.Handler {
 	if m.identityHandler == nil {
 		m.identityHandler = identity.NewHandler(m)
@@ -349,6 +440,27 @@ func (m *RegistryDefault) SchemaHandler() *schema.Handler {
 	return m.schemaHandler
 }
 
+func (m *RegistryDefault) CourierDeliveryHandler() *delivery.Handler {
+	if m.courierDeliveryHandler == nil {
+		m.courierDeliveryHandler = delivery.NewHandler(m)
+	}
+	return m.courierDeliveryHandler
+}
+
+func (m *RegistryDefault) RuntimeConfigHandler() *runtimeconfig.Handler {
+	if m.runtimeConfigHandler == nil {
+		m.runtimeConfigHandler = runtimeconfig.NewHandler(m)
+	}
+	return m.runtimeConfigHandler
+}
+
+func (m *RegistryDefault) MaintenanceHandler() *maintenance.Handler {
+	if m.maintenanceHandler == nil {
+		m.maintenanceHandler = maintenance.NewHandler(m)
+	}
+	return m.maintenanceHandler
+}
+
 func (m *RegistryDefault) SessionHandler() *session.Handler {
 	if m.sessionHandler == nil {
 		m.sessionHandler = session.NewHandler(m)
@@ -358,11 +470,18 @@ func (m *RegistryDefault) SessionHandler() *session.Handler {
 
 func (m *RegistryDefault) Hasher() hash.Hasher {
 	if m.passwordHasher == nil {
-		m.passwordHasher = hash.NewHasherArgon2(m)
+		m.passwordHasher = hash.NewHasher(m)
 	}
 	return m.passwordHasher
 }
 
+func (m *RegistryDefault) Cipher() cipher.Cipher {
+	if m.cipher == nil {
+		m.cipher = cipher.NewAESGCM(m)
+	}
+	return m.cipher
+}
+
 func (m *RegistryDefault) PasswordValidator() password2.Validator {
 	if m.passwordValidator == nil {
 		m.passwordValidator = password2.NewDefaultPasswordValidatorStrategy(m)
@@ -370,6 +489,13 @@ func (m *RegistryDefault) PasswordValidator() password2.Validator {
 	return m.passwordValidator
 }
 
+func (m *RegistryDefault) HostedUIHandler() *hostedui.Handler {
+	if m.hostedUIHandler == nil {
+		m.hostedUIHandler = hostedui.NewHandler(m)
+	}
+	return m.hostedUIHandler
+}
+
 func (m *RegistryDefault) SelfServiceErrorHandler() *errorx.Handler {
 	if m.errorHandler == nil {
 		m.errorHandler = errorx.NewHandler(m)
@@ -377,8 +503,17 @@ func (m *RegistryDefault) SelfServiceErrorHandler() *errorx.Handler {
 	return m.errorHandler
 }
 
+// cookieStore returns a cookie store which encrypts cookies using the cipher if `secrets.cipher` is set and
+// cookie encryption is enabled.
+func (m *RegistryDefault) cookieStore(ctx context.Context) *sessions.CookieStore {
+	if m.Config(ctx).SessionEncryptCookie() && len(m.Config(ctx).SecretsCipher()) > 0 {
+		return x.NewCipherCookieStore(ctx, m.Cipher(), m.Config(ctx).SecretsSession()...)
+	}
+	return x.NewCookieStore(m.Config(ctx).SessionEncryptCookie(), m.Config(ctx).SecretsSession()...)
+}
+
 func (m *RegistryDefault) CookieManager(ctx context.Context) sessions.Store {
-	cs := sessions.NewCookieStore(m.Config(ctx).SecretsSession()...)
+	cs := m.cookieStore(ctx)
 	cs.Options.Secure = !m.Config(ctx).IsInsecureDevMode()
 	cs.Options.HttpOnly = true
 
@@ -403,7 +538,7 @@ func (m *RegistryDefault) CookieManager(ctx context.Context) sessions.Store {
 
 func (m *RegistryDefault) ContinuityCookieManager(ctx context.Context) sessions.Store {
 	// To support hot reloading, this can not be instantiated only once.
-	cs := sessions.NewCookieStore(m.Config(ctx).SecretsSession()...)
+	cs := m.cookieStore(ctx)
 	cs.Options.Secure = !m.Config(ctx).IsInsecureDevMode()
 	cs.Options.HttpOnly = true
 	cs.Options.SameSite = http.SameSiteLaxMode
@@ -430,6 +565,20 @@ func (m *RegistryDefault) SessionManager() session.Manager {
 	return m.sessionManager
 }
 
+func (m *RegistryDefault) SessionWebhook() *session.Webhook {
+	if m.sessionWebhook == nil {
+		m.sessionWebhook = session.NewWebhook(m)
+	}
+	return m.sessionWebhook
+}
+
+func (m *RegistryDefault) SessionRevocationPublisher() *session.RevocationPublisher {
+	if m.sessionRevocationPublisher == nil {
+		m.sessionRevocationPublisher = session.NewRevocationPublisher(m)
+	}
+	return m.sessionRevocationPublisher
+}
+
 func (m *RegistryDefault) SelfServiceErrorManager() *errorx.Manager {
 	if m.errorManager == nil {
 		m.errorManager = errorx.NewManager(m)
@@ -455,6 +604,9 @@ func (m *RegistryDefault) Init(ctx context.Context) error {
 		panic("RegistryDefault.Init() must not be called more than once.")
 	}
 
+	schema.ConfigureRemoteLoading(m.Config(ctx).IdentitySchemaLoading())
+	schema.ConfigureRemoteClient(&http.Client{Transport: x.NewEgressTransport(m, config.EgressDependencyRemoteSchemas)})
+
 	bc := backoff.NewExponentialBackOff()
 	bc.MaxElapsedTime = time.Minute * 5
 	bc.Reset()
@@ -530,6 +682,10 @@ func (m *RegistryDefault) ContinuityPersister() continuity.Persister {
 	return m.persister
 }
 
+func (m *RegistryDefault) IdempotencyPersister() idempotency.Persister {
+	return m.persister
+}
+
 func (m *RegistryDefault) IdentityPool() identity.Pool {
 	return m.persister
 }
@@ -538,10 +694,18 @@ func (m *RegistryDefault) PrivilegedIdentityPool() identity.PrivilegedPool {
 	return m.persister
 }
 
+func (m *RegistryDefault) IdentityTransactionPersister() identity.TransactionPersister {
+	return m.persister
+}
+
 func (m *RegistryDefault) RegistrationFlowPersister() registration.FlowPersister {
 	return m.persister
 }
 
+func (m *RegistryDefault) RegistrationTransactionPersister() registration.TransactionPersister {
+	return m.persister
+}
+
 func (m *RegistryDefault) RecoveryFlowPersister() recovery.FlowPersister {
 	return m.persister
 }
@@ -586,6 +750,28 @@ func (m *RegistryDefault) WithCSRFTokenGenerator(cg x.CSRFToken) {
 	m.csrfTokenGenerator = cg
 }
 
+func (m *RegistryDefault) WithClock(c x.Clock) {
+	m.clock = c
+}
+
+func (m *RegistryDefault) Clock() x.Clock {
+	if m.clock == nil {
+		m.clock = x.SystemClock
+	}
+	return m.clock
+}
+
+func (m *RegistryDefault) WithIDGenerator(g x.IDGenerator) {
+	m.idGenerator = g
+}
+
+func (m *RegistryDefault) IDGenerator() x.IDGenerator {
+	if m.idGenerator == nil {
+		m.idGenerator = x.RandomIDGenerator
+	}
+	return m.idGenerator
+}
+
 func (m *RegistryDefault) GenerateCSRFToken(r *http.Request) string {
 	if m.csrfTokenGenerator == nil {
 		m.csrfTokenGenerator = x.DefaultCSRFToken
@@ -600,6 +786,20 @@ func (m *RegistryDefault) IdentityManager() *identity.Manager {
 	return m.identityManager
 }
 
+func (m *RegistryDefault) IdentityDormancyEnforcer() *identity.DormancyEnforcer {
+	if m.identityDormancyEnforcer == nil {
+		m.identityDormancyEnforcer = identity.NewDormancyEnforcer(m)
+	}
+	return m.identityDormancyEnforcer
+}
+
+func (m *RegistryDefault) MetricsGaugeRefresher() *prometheus.GaugeRefresher {
+	if m.gaugeRefresher == nil {
+		m.gaugeRefresher = prometheus.NewGaugeRefresher(m)
+	}
+	return m.gaugeRefresher
+}
+
 func (m *RegistryDefault) PrometheusManager() *prometheus.MetricsManager {
 	m.rwl.Lock()
 	defer m.rwl.Unlock()
@@ -26,6 +26,20 @@ func (m *RegistryDefault) HookSessionDestroyer() *hook.SessionDestroyer {
 	return m.hookSessionDestroyer
 }
 
+func (m *RegistryDefault) HookRequireVerifiedAddress() *hook.RequireVerifiedAddress {
+	if m.hookRequireVerifiedAddress == nil {
+		m.hookRequireVerifiedAddress = hook.NewRequireVerifiedAddress(m)
+	}
+	return m.hookRequireVerifiedAddress
+}
+
+func (m *RegistryDefault) HookLinkExpirer() *hook.LinkExpirer {
+	if m.hookLinkExpirer == nil {
+		m.hookLinkExpirer = hook.NewLinkExpirer(m)
+	}
+	return m.hookLinkExpirer
+}
+
 func (m *RegistryDefault) WithHooks(hooks map[string]func(config.SelfServiceHook) interface{}) {
 	m.injectedSelfserviceHooks = hooks
 }
@@ -37,6 +51,16 @@ func (m *RegistryDefault) getHooks(credentialsType string, configs []config.Self
 			i = append(i, m.HookSessionIssuer())
 		case hook.KeySessionDestroyer:
 			i = append(i, m.HookSessionDestroyer())
+		case hook.KeyKetoTuplesWriter:
+			i = append(i, hook.NewKetoTuplesWriter(m, h.Config))
+		case hook.KeyWebHook:
+			i = append(i, hook.NewWebHook(m, h.Config))
+		case hook.KeyPlugin:
+			i = append(i, hook.NewPlugin(m, h.Config))
+		case hook.KeyRequireVerifiedAddress:
+			i = append(i, m.HookRequireVerifiedAddress())
+		case hook.KeyAgeGate:
+			i = append(i, hook.NewAgeGate(m, h.Config))
 		default:
 			var found bool
 			for name, m := range m.injectedSelfserviceHooks {
@@ -0,0 +1,45 @@
+package driver
+
+import (
+	"sync"
+)
+
+// StrategyFactory creates a self-service strategy using the registry. The strategy may implement any of the
+// strategy interfaces of the self-service flows, for example login.Strategy, registration.Strategy, or
+// settings.Strategy, as well as identity.ActiveCredentialsCounter.
+type StrategyFactory func(r Registry) interface{}
+
+var (
+	pluginStrategiesLock sync.Mutex
+	pluginStrategies     []StrategyFactory
+)
+
+// RegisterStrategy adds a self-service strategy to every registry created afterwards. It is intended to be
+// called from the init function of a package which is compiled into a custom ORY Kratos build, for example:
+//
+//	func init() {
+//		driver.RegisterStrategy(func(r driver.Registry) interface{} {
+//			return smartcard.NewStrategy(r)
+//		})
+//	}
+//
+// The strategy is enabled using `selfservice.methods.<id>.enabled` where `<id>` is the strategy's
+// credentials type.
+func RegisterStrategy(f StrategyFactory) {
+	pluginStrategiesLock.Lock()
+	defer pluginStrategiesLock.Unlock()
+	pluginStrategies = append(pluginStrategies, f)
+}
+
+func registeredStrategies() []StrategyFactory {
+	pluginStrategiesLock.Lock()
+	defer pluginStrategiesLock.Unlock()
+	return append([]StrategyFactory{}, pluginStrategies...)
+}
+
+// WithStrategies adds self-service strategies to this registry only. Unlike RegisterStrategy, it does not
+// affect other registries, which is useful in tests.
+func (m *RegistryDefault) WithStrategies(fs ...StrategyFactory) {
+	m.injectedSelfserviceStrategies = append(m.injectedSelfserviceStrategies, fs...)
+	m.selfserviceStrategies = nil
+}
@@ -3,6 +3,7 @@ package driver
 import (
 	"context"
 
+	"github.com/ory/kratos/driver/config"
 	"github.com/ory/kratos/selfservice/flow/recovery"
 )
 
@@ -25,7 +26,7 @@ func (m *RegistryDefault) RecoveryHandler() *recovery.Handler {
 func (m *RegistryDefault) RecoveryStrategies(ctx context.Context) (recoveryStrategies recovery.Strategies) {
 	for _, strategy := range m.selfServiceStrategies() {
 		if s, ok := strategy.(recovery.Strategy); ok {
-			if m.Config(ctx).SelfServiceStrategy(s.RecoveryStrategyID()).Enabled {
+			if m.Config(ctx).SelfServiceStrategy(s.RecoveryStrategyID()).EnabledFor(config.FlowRecovery) {
 				recoveryStrategies = append(recoveryStrategies, s)
 			}
 		}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"net/url"
 
+	"github.com/ory/kratos/driver/config"
 	"github.com/ory/kratos/schema"
 )
 
@@ -26,3 +27,16 @@ func (m *RegistryDefault) IdentityTraitsSchemas(ctx context.Context) schema.Sche
 
 	return ss
 }
+
+// PreloadIdentitySchemas loads all remote identity schemas. It returns an error if a schema can not be loaded,
+// unless the failure policy is "cache" in which case the schema is loaded again on first use.
+func PreloadIdentitySchemas(ctx context.Context, r Registry) error {
+	if err := schema.PreloadRemote(r.IdentityTraitsSchemas(ctx)); err != nil {
+		if r.Config(ctx).IdentitySchemaLoading().OnFailure == config.SchemaLoadingCache {
+			r.Logger().WithError(err).Warn("Unable to preload an identity schema, it will be loaded again on first use.")
+			return nil
+		}
+		return err
+	}
+	return nil
+}
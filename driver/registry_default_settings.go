@@ -3,6 +3,8 @@ package driver
 import (
 	"context"
 
+	"github.com/ory/kratos/driver/config"
+	"github.com/ory/kratos/identity"
 	"github.com/ory/kratos/selfservice/flow/settings"
 )
 
@@ -16,6 +18,11 @@ func (m *RegistryDefault) PostSettingsPrePersistHooks(ctx context.Context, setti
 }
 
 func (m *RegistryDefault) PostSettingsPostPersistHooks(ctx context.Context, settingsType string) (b []settings.PostHookPostPersistExecutor) {
+	// Links are expired before the verifier sends new verification links.
+	if settingsType == identity.CredentialsTypePassword.String() && m.Config(ctx).SelfServiceFlowRecoveryExpireLinksOnPasswordChange() {
+		b = append(b, m.HookLinkExpirer())
+	}
+
 	if m.Config(ctx).SelfServiceFlowVerificationEnabled() {
 		b = append(b, m.HookVerifier())
 	}
@@ -52,7 +59,7 @@ func (m *RegistryDefault) SettingsFlowErrorHandler() *settings.ErrorHandler {
 func (m *RegistryDefault) SettingsStrategies(ctx context.Context) (profileStrategies settings.Strategies) {
 	for _, strategy := range m.selfServiceStrategies() {
 		if s, ok := strategy.(settings.Strategy); ok {
-			if m.Config(ctx).SelfServiceStrategy(s.SettingsStrategyID()).Enabled {
+			if m.Config(ctx).SelfServiceStrategy(s.SettingsStrategyID()).EnabledFor(config.FlowSettings) {
 				profileStrategies = append(profileStrategies, s)
 			}
 		}
@@ -3,6 +3,7 @@ package driver_test
 import (
 	"context"
 	"fmt"
+	"net/http"
 	"testing"
 
 	"github.com/ory/kratos/driver"
@@ -19,6 +20,7 @@ import (
 	"github.com/ory/kratos/selfservice/flow/registration"
 	"github.com/ory/kratos/selfservice/flow/settings"
 	"github.com/ory/kratos/selfservice/hook"
+	"github.com/ory/kratos/x"
 )
 
 func TestDriverDefault_Hooks(t *testing.T) {
@@ -190,6 +192,31 @@ func TestDriverDefault_Strategies(t *testing.T) {
 			})
 		}
 	})
+
+	t.Run("case=enabled flows", func(t *testing.T) {
+		conf, reg := internal.NewFastRegistryWithMocks(t)
+		conf.MustSet(config.ViperKeySelfServiceStrategyConfig+".password.enabled_flows", []string{config.FlowLogin})
+		conf.MustSet(config.ViperKeySelfServiceStrategyConfig+".oidc.enabled", true)
+		conf.MustSet(config.ViperKeySelfServiceStrategyConfig+".oidc.enabled_flows", []string{config.FlowRegistration})
+
+		var ids []string
+		for _, s := range reg.RegistrationStrategies(context.Background()) {
+			ids = append(ids, s.ID().String())
+		}
+		assert.Equal(t, []string{"oidc"}, ids)
+
+		ids = nil
+		for _, s := range reg.LoginStrategies(context.Background()) {
+			ids = append(ids, s.ID().String())
+		}
+		assert.Equal(t, []string{"password"}, ids)
+
+		ids = nil
+		for _, s := range reg.SettingsStrategies(context.Background()) {
+			ids = append(ids, s.SettingsStrategyID())
+		}
+		assert.Equal(t, []string{"profile"}, ids)
+	})
 }
 
 func TestDefaultRegistry_AllStrategies(t *testing.T) {
@@ -231,3 +258,40 @@ func TestDefaultRegistry_AllStrategies(t *testing.T) {
 		}
 	})
 }
+
+type pluginStrategy struct{}
+
+func (s *pluginStrategy) ID() identity.CredentialsType {
+	return "smartcard"
+}
+
+func (s *pluginStrategy) RegisterLoginRoutes(*x.RouterPublic) {}
+
+func (s *pluginStrategy) PopulateLoginMethod(*http.Request, *login.Flow) error {
+	return nil
+}
+
+func TestDefaultRegistry_PluginStrategies(t *testing.T) {
+	conf, reg := internal.NewFastRegistryWithMocks(t)
+	reg.WithStrategies(func(driver.Registry) interface{} {
+		return new(pluginStrategy)
+	})
+
+	t.Run("case=strategy is registered", func(t *testing.T) {
+		expects := []string{"password", "oidc", "smartcard"}
+		s := reg.AllLoginStrategies()
+		require.Len(t, s, len(expects))
+		for k, e := range expects {
+			assert.Equal(t, e, s[k].ID().String())
+		}
+	})
+
+	t.Run("case=strategy is disabled by default", func(t *testing.T) {
+		_, err := reg.LoginStrategies(context.Background()).Strategy("smartcard")
+		require.Error(t, err)
+
+		conf.MustSet(config.ViperKeySelfServiceStrategyConfig+".smartcard.enabled", true)
+		_, err = reg.LoginStrategies(context.Background()).Strategy("smartcard")
+		require.NoError(t, err)
+	})
+}
@@ -3,6 +3,7 @@ package driver
 import (
 	"context"
 
+	"github.com/ory/kratos/driver/config"
 	"github.com/ory/kratos/identity"
 	"github.com/ory/kratos/selfservice/flow/verification"
 	"github.com/ory/kratos/selfservice/strategy/link"
@@ -44,10 +45,26 @@ func (m *RegistryDefault) LinkSender() *link.Sender {
 	return m.selfserviceLinkSender
 }
 
+func (m *RegistryDefault) VerificationReminder() *link.VerificationReminder {
+	if m.selfserviceLinkVerificationReminder == nil {
+		m.selfserviceLinkVerificationReminder = link.NewVerificationReminder(m)
+	}
+
+	return m.selfserviceLinkVerificationReminder
+}
+
+func (m *RegistryDefault) LinkTestTokens() *link.TestTokens {
+	if m.selfserviceLinkTestTokens == nil {
+		m.selfserviceLinkTestTokens = link.NewTestTokens()
+	}
+
+	return m.selfserviceLinkTestTokens
+}
+
 func (m *RegistryDefault) VerificationStrategies(ctx context.Context) (verificationStrategies verification.Strategies) {
 	for _, strategy := range m.selfServiceStrategies() {
 		if s, ok := strategy.(verification.Strategy); ok {
-			if m.Config(ctx).SelfServiceStrategy(s.VerificationStrategyID()).Enabled {
+			if m.Config(ctx).SelfServiceStrategy(s.VerificationStrategyID()).EnabledFor(config.FlowVerification) {
 				verificationStrategies = append(verificationStrategies, s)
 			}
 		}
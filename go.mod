@@ -84,4 +84,6 @@ require (
 	golang.org/x/crypto v0.0.0-20201124201722-c8d3bf9c5392
 	golang.org/x/oauth2 v0.0.0-20201208152858-08078c50e5b5
 	golang.org/x/tools v0.1.0
+	google.golang.org/grpc v1.36.0
+	google.golang.org/protobuf v1.25.0
 )
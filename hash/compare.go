@@ -0,0 +1,35 @@
+package hash
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+
+	"github.com/ory/kratos/driver/config"
+)
+
+var ErrUnknownHashAlgorithm = errors.New("the hash algorithm is not supported")
+
+// Compare compares a password to a hash using the algorithm the hash was generated with. Argon2 hashes are
+// compared using the given hasher, bcrypt, imported scrypt, Firebase scrypt, and PBKDF2 hashes using the respective
+// algorithm. Imported hashes which do not include their parameters are compared using the configured defaults.
+func Compare(ctx context.Context, c config.Provider, h Hasher, password []byte, hash []byte) error {
+	switch {
+	case IsBcryptHash(hash):
+		return CompareBcrypt(password, hash)
+	case IsScryptHash(hash):
+		return CompareScrypt(password, hash, c.Config(ctx).HasherScrypt())
+	case IsFirebaseScryptHash(hash):
+		return CompareFirebaseScrypt(password, hash)
+	case IsPBKDF2Hash(hash):
+		return ComparePBKDF2(password, hash, c.Config(ctx).HasherPBKDF2())
+	default:
+		return h.Compare(ctx, password, hash)
+	}
+}
+
+// NeedsUpgrade returns true if the hash was not generated by the given hasher or with outdated settings
+// and should be replaced once the password is known.
+func NeedsUpgrade(ctx context.Context, h Hasher, hash []byte) bool {
+	return !h.Understands(hash) || h.NeedsUpgrade(ctx, hash)
+}
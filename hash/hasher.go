@@ -1,6 +1,10 @@
 package hash
 
-import "context"
+import (
+	"context"
+
+	"github.com/ory/kratos/driver/config"
+)
 
 // Hasher provides methods for generating and comparing password hashes.
 type Hasher interface {
@@ -9,8 +13,55 @@ type Hasher interface {
 
 	// Generate returns a hash derived from the password or an error if the hash method failed.
 	Generate(ctx context.Context, password []byte) ([]byte, error)
+
+	// NeedsUpgrade returns true if the hash was generated with outdated settings and should be re-generated
+	// once the password is known.
+	NeedsUpgrade(ctx context.Context, hash []byte) bool
+
+	// Understands returns true if the hash was generated by this hasher's algorithm.
+	Understands(hash []byte) bool
 }
 
 type HashProvider interface {
 	Hasher() Hasher
 }
+
+// Configured generates hashes using the algorithm configured in `hashers.algorithm`. It compares hashes generated
+// by any of the Argon2 and bcrypt hashers so that the algorithm can be changed without invalidating passwords.
+type Configured struct {
+	c      config.Provider
+	argon2 *Argon2
+	bcrypt *Bcrypt
+}
+
+func NewHasher(c config.Provider) *Configured {
+	return &Configured{c: c, argon2: NewHasherArgon2(c), bcrypt: NewHasherBcrypt(c)}
+}
+
+func (h *Configured) current(ctx context.Context) Hasher {
+	if h.c.Config(ctx).HasherAlgorithm() == config.HashAlgorithmBcrypt {
+		return h.bcrypt
+	}
+	return h.argon2
+}
+
+func (h *Configured) Generate(ctx context.Context, password []byte) ([]byte, error) {
+	return h.current(ctx).Generate(ctx, password)
+}
+
+func (h *Configured) Compare(ctx context.Context, password []byte, hash []byte) error {
+	if h.bcrypt.Understands(hash) {
+		return h.bcrypt.Compare(ctx, password, hash)
+	}
+	return h.argon2.Compare(ctx, password, hash)
+}
+
+// NeedsUpgrade returns true if the hash was not generated by the configured algorithm or with outdated settings.
+func (h *Configured) NeedsUpgrade(ctx context.Context, hash []byte) bool {
+	current := h.current(ctx)
+	return !current.Understands(hash) || current.NeedsUpgrade(ctx, hash)
+}
+
+func (h *Configured) Understands(hash []byte) bool {
+	return h.argon2.Understands(hash) || h.bcrypt.Understands(hash)
+}
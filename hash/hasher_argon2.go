@@ -3,11 +3,15 @@ package hash
 import (
 	"bytes"
 	"context"
+	"crypto/hmac"
 	"crypto/rand"
+	"crypto/sha256"
 	"crypto/subtle"
 	"encoding/base64"
+	"encoding/hex"
 	"fmt"
 	"strings"
+	"time"
 
 	"github.com/pkg/errors"
 	"golang.org/x/crypto/argon2"
@@ -19,10 +23,12 @@ var (
 	ErrInvalidHash               = errors.New("the encoded hash is not in the correct format")
 	ErrIncompatibleVersion       = errors.New("incompatible version of argon2")
 	ErrMismatchedHashAndPassword = errors.New("passwords do not match")
+	ErrUnknownPepper             = errors.New("the pepper the hash was created with is not configured")
 )
 
 type Argon2 struct {
 	c Argon2Configuration
+	l *limiter
 }
 
 type Argon2Configuration interface {
@@ -30,27 +36,40 @@ type Argon2Configuration interface {
 }
 
 func NewHasherArgon2(c Argon2Configuration) *Argon2 {
-	return &Argon2{c: c}
+	return &Argon2{c: c, l: new(limiter)}
 }
 
 func (h *Argon2) Generate(ctx context.Context, password []byte) ([]byte, error) {
-	p := h.c.Config(ctx).HasherArgon2()
+	p, err := h.c.Config(ctx).HasherArgon2OrErr()
+	if err != nil {
+		return nil, err
+	}
 
 	salt := make([]byte, p.SaltLength)
 	if _, err := rand.Read(salt); err != nil {
 		return nil, err
 	}
 
-	// Pass the plaintext password, salt and parameters to the argon2.IDKey
-	// function. This will generate a hash of the password using the Argon2id
-	// variant.
-	hash := argon2.IDKey([]byte(password), salt, p.Iterations, p.Memory, p.Parallelism, p.KeyLength)
+	// If a pepper is configured, it is mixed into the password and its ID is stored
+	// as part of the parameters so that it can be rotated.
+	var pepperParam string
+	if peppers := h.c.Config(ctx).HasherPepper(config.HashAlgorithmArgon2); len(peppers) > 0 {
+		password = applyPepper(peppers[0], password)
+		pepperParam = ",k=" + pepperID(peppers[0])
+	}
+
+	// Pass the plaintext password, salt and parameters to the argon2 key derivation
+	// function of the configured variant.
+	hash, err := h.deriveKey(ctx, "generate", password, salt, p)
+	if err != nil {
+		return nil, err
+	}
 
 	var b bytes.Buffer
 	if _, err := fmt.Fprintf(
 		&b,
-		"$argon2id$v=%d$m=%d,t=%d,p=%d$%s$%s",
-		argon2.Version, p.Memory, p.Iterations, p.Parallelism,
+		"$%s$v=%d$m=%d,t=%d,p=%d%s$%s$%s",
+		argon2Variant(p.Variant), argon2.Version, p.Memory, p.Iterations, p.Parallelism, pepperParam,
 		base64.RawStdEncoding.EncodeToString(salt),
 		base64.RawStdEncoding.EncodeToString(hash),
 	); err != nil {
@@ -63,13 +82,24 @@ func (h *Argon2) Generate(ctx context.Context, password []byte) ([]byte, error)
 func (h *Argon2) Compare(ctx context.Context, password []byte, hash []byte) error {
 	// Extract the parameters, salt and derived key from the encoded password
 	// hash.
-	p, salt, hash, err := decodeHash(string(hash))
+	p, salt, hash, pid, err := decodeHash(string(hash))
 	if err != nil {
 		return err
 	}
 
+	if len(pid) > 0 {
+		pepper, ok := findPepper(h.c.Config(ctx).HasherPepper(config.HashAlgorithmArgon2), pid)
+		if !ok {
+			return errors.WithStack(ErrUnknownPepper)
+		}
+		password = applyPepper(pepper, password)
+	}
+
 	// Derive the key from the other password using the same parameters.
-	otherHash := argon2.IDKey([]byte(password), salt, p.Iterations, p.Memory, p.Parallelism, p.KeyLength)
+	otherHash, err := h.deriveKey(ctx, "compare", password, salt, p)
+	if err != nil {
+		return err
+	}
 
 	// Check that the contents of the hashed passwords are identical. Note
 	// that we are using the subtle.ConstantTimeCompare() function for this
@@ -80,38 +110,132 @@ func (h *Argon2) Compare(ctx context.Context, password []byte, hash []byte) erro
 	return ErrMismatchedHashAndPassword
 }
 
-func decodeHash(encodedHash string) (p *config.Argon2, salt, hash []byte, err error) {
+// NeedsUpgrade returns true if the hash was created with a different Argon2 variant or pepper than
+// the configured ones.
+func (h *Argon2) NeedsUpgrade(ctx context.Context, hash []byte) bool {
+	p, _, _, pid, err := decodeHash(string(hash))
+	if err != nil {
+		return false
+	}
+
+	var current string
+	if peppers := h.c.Config(ctx).HasherPepper(config.HashAlgorithmArgon2); len(peppers) > 0 {
+		current = pepperID(peppers[0])
+	}
+
+	return p.Variant != argon2Variant(h.c.Config(ctx).HasherArgon2().Variant) || pid != current
+}
+
+func (h *Argon2) Understands(hash []byte) bool {
+	return IsArgon2Hash(hash)
+}
+
+func argon2Variant(variant string) string {
+	if variant == config.Argon2VariantI {
+		return config.Argon2VariantI
+	}
+	return config.Argon2VariantID
+}
+
+// deriveKey derives the key once the limits of hashers.argon2.max_concurrent and hashers.argon2.dedicated_memory
+// allow it and records the duration of the operation. The limits are only enforced if max_concurrent or max_wait
+// is set.
+func (h *Argon2) deriveKey(ctx context.Context, operation string, password, salt []byte, p *config.Argon2) ([]byte, error) {
+	c, err := h.c.Config(ctx).HasherArgon2OrErr()
+	if err != nil {
+		return nil, err
+	}
+
+	memory := uint64(p.Memory) * 1024 // p.Memory is in KiB
+
+	var budget uint64
+	if c.MaxConcurrent > 0 || c.MaxWait > 0 {
+		budget = uint64(c.DedicatedMemory)
+	}
+	if err := h.l.acquire(ctx, memory, c.MaxConcurrent, budget, c.MaxWait); err != nil {
+		return nil, err
+	}
+	defer h.l.release(memory)
+
+	start := time.Now()
+	key := deriveArgon2Key(p.Variant, password, salt, p)
+	argon2Duration.WithLabelValues(operation).Observe(time.Since(start).Seconds())
+	return key, nil
+}
+
+func deriveArgon2Key(variant string, password, salt []byte, p *config.Argon2) []byte {
+	if argon2Variant(variant) == config.Argon2VariantI {
+		return argon2.Key(password, salt, p.Iterations, p.Memory, p.Parallelism, p.KeyLength)
+	}
+	return argon2.IDKey(password, salt, p.Iterations, p.Memory, p.Parallelism, p.KeyLength)
+}
+
+// applyPepper mixes the pepper into the password using HMAC-SHA256.
+func applyPepper(pepper, password []byte) []byte {
+	mac := hmac.New(sha256.New, pepper)
+	_, _ = mac.Write(password)
+	return mac.Sum(nil)
+}
+
+// pepperID identifies a pepper in a hash without revealing it.
+func pepperID(pepper []byte) string {
+	sum := sha256.Sum256(pepper)
+	return hex.EncodeToString(sum[:4])
+}
+
+func findPepper(peppers [][]byte, id string) ([]byte, bool) {
+	for _, pepper := range peppers {
+		if pepperID(pepper) == id {
+			return pepper, true
+		}
+	}
+	return nil, false
+}
+
+func decodeHash(encodedHash string) (p *config.Argon2, salt, hash []byte, pid string, err error) {
 	parts := strings.Split(encodedHash, "$")
 	if len(parts) != 6 {
-		return nil, nil, nil, ErrInvalidHash
+		return nil, nil, nil, "", ErrInvalidHash
+	}
+
+	p = new(config.Argon2)
+	switch parts[1] {
+	case config.Argon2VariantID, config.Argon2VariantI:
+		p.Variant = parts[1]
+	default:
+		return nil, nil, nil, "", ErrInvalidHash
 	}
 
 	var version int
 	_, err = fmt.Sscanf(parts[2], "v=%d", &version)
 	if err != nil {
-		return nil, nil, nil, err
+		return nil, nil, nil, "", err
 	}
 	if version != argon2.Version {
-		return nil, nil, nil, ErrIncompatibleVersion
+		return nil, nil, nil, "", ErrIncompatibleVersion
 	}
 
-	p = new(config.Argon2)
-	_, err = fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &p.Memory, &p.Iterations, &p.Parallelism)
+	params := parts[3]
+	if i := strings.Index(params, ",k="); i >= 0 {
+		params, pid = params[:i], params[i+len(",k="):]
+	}
+
+	_, err = fmt.Sscanf(params, "m=%d,t=%d,p=%d", &p.Memory, &p.Iterations, &p.Parallelism)
 	if err != nil {
-		return nil, nil, nil, err
+		return nil, nil, nil, "", err
 	}
 
 	salt, err = base64.RawStdEncoding.DecodeString(parts[4])
 	if err != nil {
-		return nil, nil, nil, err
+		return nil, nil, nil, "", err
 	}
 	p.SaltLength = uint32(len(salt))
 
 	hash, err = base64.RawStdEncoding.DecodeString(parts[5])
 	if err != nil {
-		return nil, nil, nil, err
+		return nil, nil, nil, "", err
 	}
 	p.KeyLength = uint32(len(hash))
 
-	return p, salt, hash, nil
+	return p, salt, hash, pid, nil
 }
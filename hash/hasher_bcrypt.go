@@ -0,0 +1,71 @@
+package hash
+
+import (
+	"bytes"
+	"context"
+
+	"github.com/pkg/errors"
+	"golang.org/x/crypto/bcrypt"
+
+	"github.com/ory/kratos/driver/config"
+)
+
+var (
+	prefixBcrypt2a = []byte("$2a$")
+	prefixBcrypt2b = []byte("$2b$")
+	prefixBcrypt2y = []byte("$2y$")
+)
+
+type Bcrypt struct {
+	c BcryptConfiguration
+}
+
+type BcryptConfiguration interface {
+	config.Provider
+}
+
+func NewHasherBcrypt(c BcryptConfiguration) *Bcrypt {
+	return &Bcrypt{c: c}
+}
+
+// Generate returns a bcrypt hash of the password. Only the first 72 bytes of the password are used.
+func (h *Bcrypt) Generate(ctx context.Context, password []byte) ([]byte, error) {
+	hash, err := bcrypt.GenerateFromPassword(password, int(h.c.Config(ctx).HasherBcrypt().Cost))
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	return hash, nil
+}
+
+func (h *Bcrypt) Compare(_ context.Context, password []byte, hash []byte) error {
+	return CompareBcrypt(password, hash)
+}
+
+// NeedsUpgrade returns true if the hash was created with a different cost than the configured one.
+func (h *Bcrypt) NeedsUpgrade(ctx context.Context, hash []byte) bool {
+	cost, err := bcrypt.Cost(hash)
+	if err != nil {
+		return false
+	}
+	return uint32(cost) != h.c.Config(ctx).HasherBcrypt().Cost
+}
+
+func (h *Bcrypt) Understands(hash []byte) bool {
+	return IsBcryptHash(hash)
+}
+
+// IsBcryptHash returns true if the hash is a bcrypt hash using the `$2a$`, `$2b$`, or `$2y$` prefix.
+func IsBcryptHash(hash []byte) bool {
+	return bytes.HasPrefix(hash, prefixBcrypt2a) || bytes.HasPrefix(hash, prefixBcrypt2b) || bytes.HasPrefix(hash, prefixBcrypt2y)
+}
+
+// CompareBcrypt compares a password to a bcrypt hash and returns nil if they match.
+func CompareBcrypt(password []byte, hash []byte) error {
+	if err := bcrypt.CompareHashAndPassword(hash, password); err != nil {
+		if errors.Is(err, bcrypt.ErrMismatchedHashAndPassword) {
+			return ErrMismatchedHashAndPassword
+		}
+		return errors.WithStack(ErrInvalidHash)
+	}
+	return nil
+}
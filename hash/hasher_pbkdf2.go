@@ -0,0 +1,141 @@
+package hash
+
+import (
+	"bytes"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"crypto/subtle"
+	gohash "hash"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+	"golang.org/x/crypto/pbkdf2"
+
+	"github.com/ory/kratos/driver/config"
+)
+
+var (
+	prefixPBKDF2       = []byte("$pbkdf2-")
+	prefixDjangoPBKDF2 = []byte("pbkdf2_")
+)
+
+type pbkdf2Params struct {
+	digest     func() gohash.Hash
+	iterations int
+	salt       []byte
+	hash       []byte
+}
+
+// IsPBKDF2Hash returns true if the hash is a PBKDF2 hash in the format `$pbkdf2-<digest>$i=<iterations>$<salt>$<hash>`,
+// in the format `$pbkdf2-<digest>$<iterations>$<salt>$<hash>` used by passlib, or in the format
+// `pbkdf2_<digest>$<iterations>$<salt>$<hash>` used by Django. The digest is one of sha1, sha256, and sha512.
+func IsPBKDF2Hash(hash []byte) bool {
+	return bytes.HasPrefix(hash, prefixPBKDF2) || bytes.HasPrefix(hash, prefixDjangoPBKDF2)
+}
+
+// ComparePBKDF2 compares a password to a PBKDF2 hash and returns nil if they match. Hashes which do not include
+// the iterations, such as `$pbkdf2-sha256$<salt>$<hash>`, are compared using the default iterations.
+func ComparePBKDF2(password []byte, hash []byte, defaults *config.PBKDF2) error {
+	p, err := decodePBKDF2Hash(string(hash), defaults)
+	if err != nil {
+		return err
+	}
+
+	otherHash := pbkdf2.Key(password, p.salt, p.iterations, len(p.hash), p.digest)
+	if subtle.ConstantTimeCompare(p.hash, otherHash) == 1 {
+		return nil
+	}
+	return ErrMismatchedHashAndPassword
+}
+
+func decodePBKDF2Hash(encodedHash string, defaults *config.PBKDF2) (*pbkdf2Params, error) {
+	if strings.HasPrefix(encodedHash, string(prefixDjangoPBKDF2)) {
+		return decodeDjangoPBKDF2Hash(encodedHash)
+	}
+
+	parts := strings.Split(encodedHash, "$")
+	if len(parts) < 4 {
+		return nil, ErrInvalidHash
+	}
+
+	p := new(pbkdf2Params)
+	var err error
+	if p.digest, err = pbkdf2Digest(strings.TrimPrefix(parts[1], "pbkdf2-")); err != nil {
+		return nil, err
+	}
+
+	keyLength := -1
+	switch {
+	case len(parts) == 4 && defaults != nil:
+		p.iterations = defaults.Iterations
+	case len(parts) == 5:
+		for _, param := range strings.Split(parts[2], ",") {
+			switch {
+			case strings.HasPrefix(param, "i="):
+				p.iterations, err = strconv.Atoi(strings.TrimPrefix(param, "i="))
+			case strings.HasPrefix(param, "l="):
+				keyLength, err = strconv.Atoi(strings.TrimPrefix(param, "l="))
+			default:
+				p.iterations, err = strconv.Atoi(param)
+			}
+			if err != nil {
+				return nil, errors.WithStack(ErrInvalidHash)
+			}
+		}
+	default:
+		return nil, ErrInvalidHash
+	}
+
+	// passlib uses "." instead of "+" in its base64 alphabet.
+	if p.salt, err = decodeBase64(strings.ReplaceAll(parts[len(parts)-2], ".", "+")); err != nil {
+		return nil, err
+	}
+	if p.hash, err = decodeBase64(strings.ReplaceAll(parts[len(parts)-1], ".", "+")); err != nil {
+		return nil, err
+	}
+
+	if p.iterations < 1 || len(p.hash) == 0 || (keyLength >= 0 && keyLength != len(p.hash)) {
+		return nil, ErrInvalidHash
+	}
+	return p, nil
+}
+
+// decodeDjangoPBKDF2Hash decodes hashes in the format used by Django, which does not encode the salt.
+func decodeDjangoPBKDF2Hash(encodedHash string) (*pbkdf2Params, error) {
+	parts := strings.Split(encodedHash, "$")
+	if len(parts) != 4 {
+		return nil, ErrInvalidHash
+	}
+
+	p := &pbkdf2Params{salt: []byte(parts[2])}
+	var err error
+	if p.digest, err = pbkdf2Digest(strings.TrimPrefix(parts[0], string(prefixDjangoPBKDF2))); err != nil {
+		return nil, err
+	}
+
+	if p.iterations, err = strconv.Atoi(parts[1]); err != nil || p.iterations < 1 {
+		return nil, errors.WithStack(ErrInvalidHash)
+	}
+
+	if p.hash, err = decodeBase64(parts[3]); err != nil {
+		return nil, err
+	} else if len(p.hash) == 0 {
+		return nil, ErrInvalidHash
+	}
+
+	return p, nil
+}
+
+func pbkdf2Digest(name string) (func() gohash.Hash, error) {
+	switch name {
+	case "sha1":
+		return sha1.New, nil
+	case "sha256":
+		return sha256.New, nil
+	case "sha512":
+		return sha512.New, nil
+	}
+	return nil, errors.WithStack(ErrInvalidHash)
+}
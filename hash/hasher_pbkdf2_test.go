@@ -0,0 +1,72 @@
+package hash_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ory/kratos/driver/config"
+	"github.com/ory/kratos/hash"
+	"github.com/ory/kratos/internal"
+)
+
+func TestComparePBKDF2(t *testing.T) {
+	defaults := &config.PBKDF2{Iterations: config.PBKDF2DefaultIterations}
+	for k, h := range []string{
+		"$pbkdf2-sha1$i=1000,l=32$c2FsdHlzYWx0eXNhbHQhIQ$n042QUSXXu9IRwv1Tr8eKfG+PaYCkucfVHirUhwRwnw",
+		"$pbkdf2-sha256$i=1000,l=32$c2FsdHlzYWx0eXNhbHQhIQ$JA3nZ7rNoOy8sy+l2+CaYg5yKuaB5sWcbqXj4DZqT8E",
+		"$pbkdf2-sha512$i=1000$c2FsdHlzYWx0eXNhbHQhIQ$b32TqIP6X0ohI5bLt56R9uHVdq6SvhSGEiIsY0EawcQ",
+		// passlib
+		"$pbkdf2-sha256$29000$c2FsdHlzYWx0eXNhbHQhIQ$mlwk7lUbyDBoyXY2em/vW6puWaWSolaVAntCnQ83WAE",
+		// Django
+		"pbkdf2_sha256$1000$seasalt$YIWkt6M1JFXrHg5s0jZjBSc7C2Cz6QvchSJ0h8Y+i7c=",
+		// without iterations
+		"$pbkdf2-sha256$c2FsdHlzYWx0eXNhbHQhIQ$xKfI7QhqDPfYBwcaikWOYw/ayzkWduaLI9pKI9QBpSA",
+	} {
+		require.NoError(t, hash.ValidateHash([]byte(h)), "%d", k)
+		require.NoError(t, hash.ComparePBKDF2([]byte("password"), []byte(h), defaults), "%d", k)
+		assert.ErrorIs(t, hash.ComparePBKDF2([]byte("not-password"), []byte(h), defaults), hash.ErrMismatchedHashAndPassword, "%d", k)
+	}
+
+	t.Run("case=requires the iterations without defaults", func(t *testing.T) {
+		assert.Error(t, hash.ComparePBKDF2([]byte("password"), []byte("$pbkdf2-sha256$c2FsdHlzYWx0eXNhbHQhIQ$xKfI7QhqDPfYBwcaikWOYw/ayzkWduaLI9pKI9QBpSA"), nil))
+	})
+
+	t.Run("case=rejects invalid hashes", func(t *testing.T) {
+		for k, h := range []string{
+			"$pbkdf2-md5$i=1000$c2FsdHlzYWx0eXNhbHQhIQ$JA3nZ7rNoOy8sy+l2+CaYg5yKuaB5sWcbqXj4DZqT8E",
+			"$pbkdf2-sha256$i=abc$c2FsdHlzYWx0eXNhbHQhIQ$JA3nZ7rNoOy8sy+l2+CaYg5yKuaB5sWcbqXj4DZqT8E",
+			"$pbkdf2-sha256$i=0$c2FsdHlzYWx0eXNhbHQhIQ$JA3nZ7rNoOy8sy+l2+CaYg5yKuaB5sWcbqXj4DZqT8E",
+			"$pbkdf2-sha256$i=1000,l=64$c2FsdHlzYWx0eXNhbHQhIQ$JA3nZ7rNoOy8sy+l2+CaYg5yKuaB5sWcbqXj4DZqT8E",
+			"$pbkdf2-sha256$i=1000$c2FsdHlzYWx0eXNhbHQhIQ$",
+			"$pbkdf2-sha256$i=1000",
+			"pbkdf2_sha256$abc$seasalt$YIWkt6M1JFXrHg5s0jZjBSc7C2Cz6QvchSJ0h8Y+i7c=",
+			"pbkdf2_sha256$1000$seasalt",
+		} {
+			assert.Error(t, hash.ValidateHash([]byte(h)), "%d", k)
+		}
+	})
+}
+
+func TestCompareImportedHashesWithDefaults(t *testing.T) {
+	conf, reg := internal.NewFastRegistryWithMocks(t)
+	h := hash.NewHasherArgon2(reg)
+	ctx := context.Background()
+
+	pbkdf2Hash := []byte("$pbkdf2-sha256$c2FsdHlzYWx0eXNhbHQhIQ$xKfI7QhqDPfYBwcaikWOYw/ayzkWduaLI9pKI9QBpSA")
+	scryptHash := []byte("$scrypt$c2FsdHlzYWx0eXNhbHQhIQ$rISIxjc73eCMfpdVxbkFYYu2IgV92JKahfsEUJKKsdY")
+
+	for _, hs := range [][]byte{pbkdf2Hash, scryptHash} {
+		require.NoError(t, hash.ValidateHash(hs), "%s", hs)
+		require.NoError(t, hash.Compare(ctx, reg, h, []byte("password"), hs), "%s", hs)
+		assert.True(t, hash.NeedsUpgrade(ctx, h, hs), "%s", hs)
+	}
+
+	conf.MustSet(config.ViperKeyHasherPBKDF2Iterations, 1000)
+	conf.MustSet(config.ViperKeyHasherScryptCost, 10)
+	for _, hs := range [][]byte{pbkdf2Hash, scryptHash} {
+		assert.ErrorIs(t, hash.Compare(ctx, reg, h, []byte("password"), hs), hash.ErrMismatchedHashAndPassword, "%s", hs)
+	}
+}
@@ -0,0 +1,182 @@
+package hash
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/subtle"
+	"encoding/base64"
+	"fmt"
+	"strings"
+
+	"github.com/pkg/errors"
+	"golang.org/x/crypto/bcrypt"
+	"golang.org/x/crypto/scrypt"
+
+	"github.com/ory/kratos/driver/config"
+)
+
+var (
+	prefixArgon2id       = []byte("$argon2id$")
+	prefixArgon2i        = []byte("$argon2i$")
+	prefixScrypt         = []byte("$scrypt$")
+	prefixFirebaseScrypt = []byte("$firescrypt$")
+)
+
+type scryptParams struct {
+	cost        uint
+	blockSize   int
+	parallelism int
+	salt        []byte
+	hash        []byte
+}
+
+// IsArgon2Hash returns true if the hash was generated by the Argon2 hasher using either variant.
+func IsArgon2Hash(hash []byte) bool {
+	return bytes.HasPrefix(hash, prefixArgon2id) || bytes.HasPrefix(hash, prefixArgon2i)
+}
+
+// IsScryptHash returns true if the hash is a scrypt hash in the format
+// `$scrypt$ln=<log2(N)>,r=<block size>,p=<parallelism>$<salt>$<hash>` or, if all hashes use the same
+// parameters, `$scrypt$<salt>$<hash>`.
+func IsScryptHash(hash []byte) bool {
+	return bytes.HasPrefix(hash, prefixScrypt)
+}
+
+// IsFirebaseScryptHash returns true if the hash is a hash exported from Firebase in the format
+// `$firescrypt$ln=<mem_cost>,r=<rounds>,p=1$<salt>$<hash>$<salt separator>$<signer key>`.
+func IsFirebaseScryptHash(hash []byte) bool {
+	return bytes.HasPrefix(hash, prefixFirebaseScrypt)
+}
+
+// IsSupportedHash returns true if the hash is in one of the formats which can be compared. Unlike
+// ValidateHash, it only checks the hash's prefix.
+func IsSupportedHash(hash []byte) bool {
+	return IsArgon2Hash(hash) || IsBcryptHash(hash) || IsScryptHash(hash) || IsFirebaseScryptHash(hash) || IsPBKDF2Hash(hash)
+}
+
+// ValidateHash returns an error if the hash is not in a format which can be compared.
+func ValidateHash(hash []byte) error {
+	var err error
+	switch {
+	case IsArgon2Hash(hash):
+		_, _, _, _, err = decodeHash(string(hash))
+	case IsBcryptHash(hash):
+		_, err = bcrypt.Cost(hash)
+	case IsScryptHash(hash):
+		_, err = decodeScryptHash(string(hash), 6, &config.Scrypt{Cost: config.ScryptDefaultCost, BlockSize: config.ScryptDefaultBlockSize, Parallelism: config.ScryptDefaultParallelism})
+	case IsFirebaseScryptHash(hash):
+		_, _, _, err = decodeFirebaseScryptHash(string(hash))
+	case IsPBKDF2Hash(hash):
+		_, err = decodePBKDF2Hash(string(hash), &config.PBKDF2{Iterations: config.PBKDF2DefaultIterations})
+	default:
+		err = ErrUnknownHashAlgorithm
+	}
+	return err
+}
+
+// CompareScrypt compares a password to a scrypt hash and returns nil if they match. Hashes which do not include
+// the parameters are compared using the default parameters. If defaults is nil, the parameters are required.
+func CompareScrypt(password []byte, hash []byte, defaults *config.Scrypt) error {
+	p, err := decodeScryptHash(string(hash), 6, defaults)
+	if err != nil {
+		return err
+	}
+
+	otherHash, err := scrypt.Key(password, p.salt, 1<<p.cost, p.blockSize, p.parallelism, len(p.hash))
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	if subtle.ConstantTimeCompare(p.hash, otherHash) == 1 {
+		return nil
+	}
+	return ErrMismatchedHashAndPassword
+}
+
+// CompareFirebaseScrypt compares a password to a hash exported from Firebase and returns nil if they match.
+//
+// Firebase derives a key from the password using scrypt and uses it to encrypt the project's signer key
+// with AES-256-CTR. The result is the password hash.
+func CompareFirebaseScrypt(password []byte, hash []byte) error {
+	p, saltSeparator, signerKey, err := decodeFirebaseScryptHash(string(hash))
+	if err != nil {
+		return err
+	}
+
+	salt := make([]byte, 0, len(p.salt)+len(saltSeparator))
+	salt = append(append(salt, p.salt...), saltSeparator...)
+
+	key, err := scrypt.Key(password, salt, 1<<p.cost, p.blockSize, p.parallelism, 32)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	otherHash := make([]byte, len(signerKey))
+	cipher.NewCTR(block, make([]byte, aes.BlockSize)).XORKeyStream(otherHash, signerKey)
+
+	if subtle.ConstantTimeCompare(p.hash, otherHash) == 1 {
+		return nil
+	}
+	return ErrMismatchedHashAndPassword
+}
+
+func decodeScryptHash(encodedHash string, expectedParts int, defaults *config.Scrypt) (*scryptParams, error) {
+	parts := strings.Split(encodedHash, "$")
+	p := new(scryptParams)
+	if defaults != nil && len(parts) == expectedParts-1 {
+		// The parameters are omitted, so the salt and hash move up by one.
+		p.cost, p.blockSize, p.parallelism = defaults.Cost, defaults.BlockSize, defaults.Parallelism
+		parts = append(parts[:2:2], append([]string{""}, parts[2:]...)...)
+	} else if len(parts) != expectedParts {
+		return nil, ErrInvalidHash
+	} else if _, err := fmt.Sscanf(parts[2], "ln=%d,r=%d,p=%d", &p.cost, &p.blockSize, &p.parallelism); err != nil {
+		return nil, errors.WithStack(ErrInvalidHash)
+	}
+
+	var err error
+	if p.salt, err = decodeBase64(parts[3]); err != nil {
+		return nil, err
+	}
+
+	if p.hash, err = decodeBase64(parts[4]); err != nil {
+		return nil, err
+	} else if len(p.hash) == 0 {
+		return nil, ErrInvalidHash
+	}
+
+	return p, nil
+}
+
+func decodeFirebaseScryptHash(encodedHash string) (p *scryptParams, saltSeparator, signerKey []byte, err error) {
+	p, err = decodeScryptHash(encodedHash, 8, nil)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	parts := strings.Split(encodedHash, "$")
+	if saltSeparator, err = decodeBase64(parts[6]); err != nil {
+		return nil, nil, nil, err
+	}
+
+	if signerKey, err = decodeBase64(parts[7]); err != nil {
+		return nil, nil, nil, err
+	} else if len(signerKey) == 0 {
+		return nil, nil, nil, ErrInvalidHash
+	}
+
+	return p, saltSeparator, signerKey, nil
+}
+
+// decodeBase64 decodes standard base64 with or without padding as exported by Firebase and other systems.
+func decodeBase64(s string) ([]byte, error) {
+	if b, err := base64.RawStdEncoding.DecodeString(strings.TrimRight(s, "=")); err == nil {
+		return b, nil
+	}
+	return nil, ErrInvalidHash
+}
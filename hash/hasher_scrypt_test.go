@@ -0,0 +1,67 @@
+package hash_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ory/kratos/hash"
+	"github.com/ory/kratos/internal"
+)
+
+const (
+	// Test vector from RFC 7914 (password "password", salt "NaCl", N=1024, r=8, p=16).
+	scryptHash = "$scrypt$ln=10,r=8,p=16$TmFDbA$/bq+HJ00cgB4VucZDQHp/nxq18vII3gw53N2Y0s3MWIurzDZLiKjiG/xCSedmDDaxyevuUqD7m2DYMvfoswGQA"
+
+	// Test vector from https://github.com/firebase/scrypt (password "user1password").
+	firebaseScryptHash = "$firescrypt$ln=14,r=8,p=1$42xEC+ixf3L2lw==$lSrfV15cpx95/sZS2W9c9Kp6i/LVgQNDNC/qzrCnh1SAyZvqmZqAjTdn3aoItz+VHjoZilo78198JAdRuid5lQ==$Bw==$jxspr8Ki0RYycVU8zykbdLGjFQ3McFUH0uiiTvC8pVMXAn210wjLNmdZJzxUECKbm0QsEmYUSDzZvpjeJ9WmXA=="
+)
+
+func TestCompareScrypt(t *testing.T) {
+	require.NoError(t, hash.CompareScrypt([]byte("password"), []byte(scryptHash), nil))
+	assert.ErrorIs(t, hash.CompareScrypt([]byte("not-password"), []byte(scryptHash), nil), hash.ErrMismatchedHashAndPassword)
+}
+
+func TestCompareFirebaseScrypt(t *testing.T) {
+	require.NoError(t, hash.CompareFirebaseScrypt([]byte("user1password"), []byte(firebaseScryptHash)))
+	assert.ErrorIs(t, hash.CompareFirebaseScrypt([]byte("user2password"), []byte(firebaseScryptHash)), hash.ErrMismatchedHashAndPassword)
+}
+
+func TestCompare(t *testing.T) {
+	_, reg := internal.NewFastRegistryWithMocks(t)
+	h := hash.NewHasherArgon2(reg)
+	ctx := context.Background()
+
+	argon2Hash, err := h.Generate(ctx, []byte("password"))
+	require.NoError(t, err)
+
+	for k, tc := range []struct {
+		password, hash string
+		upgrade        bool
+	}{
+		{password: "password", hash: string(argon2Hash)},
+		{password: "password", hash: scryptHash, upgrade: true},
+		{password: "user1password", hash: firebaseScryptHash, upgrade: true},
+	} {
+		require.NoError(t, hash.ValidateHash([]byte(tc.hash)), "%d", k)
+		require.NoError(t, hash.Compare(ctx, reg, h, []byte(tc.password), []byte(tc.hash)), "%d", k)
+		require.Error(t, hash.Compare(ctx, reg, h, []byte("wrong"+tc.password), []byte(tc.hash)), "%d", k)
+		assert.Equal(t, tc.upgrade, hash.NeedsUpgrade(ctx, h, []byte(tc.hash)), "%d", k)
+	}
+}
+
+func TestValidateHash(t *testing.T) {
+	for k, h := range []string{
+		"",
+		"plaintext",
+		"$2a$10$N9qo8uLOickgx2ZMRZoMyeIjZAgcfl7p92ldGxad68LJZdL17lhWy",
+		"$scrypt$ln=10,r=8$TmFDbA$/bq+HJ00cgB4VucZDQHp",
+		"$scrypt$ln=10,r=8,p=16$TmFDbA$",
+		"$firescrypt$ln=14,r=8,p=1$42xEC+ixf3L2lw==$lSrfV15cpx95$Bw==",
+		"$argon2id$v=19$m=abc$salt$hash",
+	} {
+		assert.Error(t, hash.ValidateHash([]byte(h)), "%d", k)
+	}
+}
@@ -4,11 +4,13 @@ import (
 	"context"
 	"crypto/rand"
 	"fmt"
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
+	"github.com/ory/kratos/driver/config"
 	"github.com/ory/kratos/hash"
 	"github.com/ory/kratos/internal"
 )
@@ -50,3 +52,137 @@ func TestHasher(t *testing.T) {
 		})
 	}
 }
+
+func TestArgon2Variant(t *testing.T) {
+	conf, reg := internal.NewFastRegistryWithMocks(t)
+	h := hash.NewHasherArgon2(reg)
+	ctx := context.Background()
+
+	id, err := h.Generate(ctx, []byte("password"))
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(string(id), "$argon2id$"), "%s", id)
+
+	conf.MustSet(config.ViperKeyHasherArgon2ConfigVariant, config.Argon2VariantI)
+	i, err := h.Generate(ctx, []byte("password"))
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(string(i), "$argon2i$"), "%s", i)
+
+	require.NoError(t, h.Compare(ctx, []byte("password"), i))
+	require.Error(t, h.Compare(ctx, []byte("not-password"), i))
+	require.NoError(t, h.Compare(ctx, []byte("password"), id))
+
+	assert.False(t, h.NeedsUpgrade(ctx, i))
+	assert.True(t, h.NeedsUpgrade(ctx, id))
+}
+
+func TestArgon2Pepper(t *testing.T) {
+	conf, reg := internal.NewFastRegistryWithMocks(t)
+	h := hash.NewHasherArgon2(reg)
+	ctx := context.Background()
+
+	unpeppered, err := h.Generate(ctx, []byte("password"))
+	require.NoError(t, err)
+
+	conf.MustSet(config.ViperKeySecretsPepper, []string{"old-pepper-0123456789"})
+	old, err := h.Generate(ctx, []byte("password"))
+	require.NoError(t, err)
+	assert.Contains(t, string(old), ",k=")
+	assert.NotContains(t, string(old), "old-pepper")
+
+	require.NoError(t, h.Compare(ctx, []byte("password"), old))
+	require.Error(t, h.Compare(ctx, []byte("not-password"), old))
+	require.NoError(t, h.Compare(ctx, []byte("password"), unpeppered), "hashes without a pepper must still be accepted")
+	assert.True(t, h.NeedsUpgrade(ctx, unpeppered))
+	assert.False(t, h.NeedsUpgrade(ctx, old))
+
+	t.Run("case=rotation", func(t *testing.T) {
+		conf.MustSet(config.ViperKeySecretsPepper, []string{"new-pepper-0123456789", "old-pepper-0123456789"})
+
+		current, err := h.Generate(ctx, []byte("password"))
+		require.NoError(t, err)
+
+		require.NoError(t, h.Compare(ctx, []byte("password"), current))
+		require.NoError(t, h.Compare(ctx, []byte("password"), old))
+		assert.False(t, h.NeedsUpgrade(ctx, current))
+		assert.True(t, h.NeedsUpgrade(ctx, old))
+
+		conf.MustSet(config.ViperKeySecretsPepper, []string{"new-pepper-0123456789"})
+		assert.ErrorIs(t, h.Compare(ctx, []byte("password"), old), hash.ErrUnknownPepper)
+		require.NoError(t, h.Compare(ctx, []byte("password"), current))
+	})
+
+	t.Run("case=hasher peppers take precedence", func(t *testing.T) {
+		conf.MustSet(config.ViperKeySecretsPepper, []string{"old-pepper-0123456789"})
+		conf.MustSet(config.ViperKeyHasherArgon2ConfigPepper, []string{"argon2-pepper-0123456789", "old-pepper-0123456789"})
+		t.Cleanup(func() {
+			conf.MustSet(config.ViperKeyHasherArgon2ConfigPepper, []string{})
+		})
+
+		current, err := h.Generate(ctx, []byte("password"))
+		require.NoError(t, err)
+
+		require.NoError(t, h.Compare(ctx, []byte("password"), current))
+		require.NoError(t, h.Compare(ctx, []byte("password"), old))
+		assert.False(t, h.NeedsUpgrade(ctx, current))
+		assert.True(t, h.NeedsUpgrade(ctx, old))
+
+		conf.MustSet(config.ViperKeyHasherArgon2ConfigPepper, []string{"argon2-pepper-0123456789"})
+		assert.ErrorIs(t, h.Compare(ctx, []byte("password"), old), hash.ErrUnknownPepper)
+	})
+}
+
+func TestBcrypt(t *testing.T) {
+	conf, reg := internal.NewFastRegistryWithMocks(t)
+	conf.MustSet(config.ViperKeyHasherBcryptCost, 4)
+	h := hash.NewHasherBcrypt(reg)
+	ctx := context.Background()
+
+	hs, err := h.Generate(ctx, []byte("password"))
+	require.NoError(t, err)
+	assert.True(t, hash.IsBcryptHash(hs), "%s", hs)
+	assert.True(t, h.Understands(hs))
+	require.NoError(t, hash.ValidateHash(hs))
+
+	require.NoError(t, h.Compare(ctx, []byte("password"), hs))
+	assert.ErrorIs(t, h.Compare(ctx, []byte("not-password"), hs), hash.ErrMismatchedHashAndPassword)
+	assert.False(t, h.NeedsUpgrade(ctx, hs))
+
+	conf.MustSet(config.ViperKeyHasherBcryptCost, 5)
+	assert.True(t, h.NeedsUpgrade(ctx, hs))
+
+	t.Run("case=compares hashes created by other systems", func(t *testing.T) {
+		// PHP's password_hash uses the $2y$ prefix for the same algorithm.
+		legacy := append([]byte("$2y$"), hs[len("$2a$"):]...)
+		require.NoError(t, hash.ValidateHash(legacy))
+		require.NoError(t, hash.Compare(ctx, reg, hash.NewHasherArgon2(reg), []byte("password"), legacy))
+		assert.True(t, hash.NeedsUpgrade(ctx, hash.NewHasherArgon2(reg), legacy))
+	})
+}
+
+func TestConfiguredHasher(t *testing.T) {
+	conf, reg := internal.NewFastRegistryWithMocks(t)
+	conf.MustSet(config.ViperKeyHasherBcryptCost, 4)
+	h := hash.NewHasher(reg)
+	ctx := context.Background()
+
+	argon2Hash, err := h.Generate(ctx, []byte("password"))
+	require.NoError(t, err)
+	assert.True(t, hash.IsArgon2Hash(argon2Hash), "%s", argon2Hash)
+	assert.False(t, hash.NeedsUpgrade(ctx, h, argon2Hash))
+
+	conf.MustSet(config.ViperKeyHasherAlgorithm, config.HashAlgorithmBcrypt)
+	bcryptHash, err := h.Generate(ctx, []byte("password"))
+	require.NoError(t, err)
+	assert.True(t, hash.IsBcryptHash(bcryptHash), "%s", bcryptHash)
+
+	for _, hs := range [][]byte{argon2Hash, bcryptHash} {
+		require.NoError(t, hash.Compare(ctx, reg, h, []byte("password"), hs))
+		require.Error(t, hash.Compare(ctx, reg, h, []byte("not-password"), hs))
+	}
+	assert.True(t, hash.NeedsUpgrade(ctx, h, argon2Hash))
+	assert.False(t, hash.NeedsUpgrade(ctx, h, bcryptHash))
+
+	conf.MustSet(config.ViperKeyHasherAlgorithm, config.HashAlgorithmArgon2)
+	assert.False(t, hash.NeedsUpgrade(ctx, h, argon2Hash))
+	assert.True(t, hash.NeedsUpgrade(ctx, h, bcryptHash))
+}
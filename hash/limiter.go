@@ -0,0 +1,125 @@
+package hash
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/ory/herodot"
+)
+
+var (
+	argon2Duration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "kratos_hasher_argon2_duration_seconds",
+		Help:    "Time it took to hash a password using Argon2, excluding the time spent waiting for other hashes.",
+		Buckets: []float64{.05, .1, .25, .5, 1, 2, 4, 8},
+	}, []string{"operation"})
+	argon2Wait = prometheus.NewHistogram(prometheus.HistogramOpts{
+		Name:    "kratos_hasher_argon2_wait_seconds",
+		Help:    "Time a password hash waited because hashers.argon2.max_concurrent or hashers.argon2.dedicated_memory was reached.",
+		Buckets: []float64{.01, .05, .1, .25, .5, 1, 2, 4, 8},
+	})
+	argon2Shed = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "kratos_hasher_argon2_shed_total",
+		Help: "Number of password hashes which were rejected because they waited longer than hashers.argon2.max_wait.",
+	})
+	argon2Running = prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: "kratos_hasher_argon2_running",
+		Help: "Number of passwords which are being hashed using Argon2.",
+	})
+	argon2Memory = prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: "kratos_hasher_argon2_memory_bytes",
+		Help: "Memory used by the Argon2 password hashes which are running.",
+	})
+)
+
+func init() {
+	prometheus.MustRegister(argon2Duration, argon2Wait, argon2Shed, argon2Running, argon2Memory)
+}
+
+// ErrOverloaded is returned if a password could not be hashed within hashers.argon2.max_wait because too many
+// passwords were hashed at the same time. The request can be retried.
+var ErrOverloaded = herodot.DefaultError{
+	StatusField: http.StatusText(http.StatusServiceUnavailable),
+	ErrorField:  "Too many passwords are being hashed at the moment, retry the request later.",
+	CodeField:   http.StatusServiceUnavailable,
+}
+
+// IsOverloaded returns true if the error is ErrOverloaded.
+func IsOverloaded(err error) bool {
+	var e *herodot.DefaultError
+	return errors.As(err, &e) && e.ErrorField == ErrOverloaded.ErrorField
+}
+
+// limiter governs the number of concurrent hashes and the memory they use, which is the same as a semaphore with
+// budget / memory slots if all hashes use the same memory. A hash is always admitted if no other hash is running,
+// even if it uses more memory than the budget.
+type limiter struct {
+	sync.Mutex
+	running int
+	memory  uint64
+	wake    chan struct{}
+}
+
+// acquire waits until the hash which uses memory bytes can run without exceeding max running hashes and budget
+// bytes of memory. A max or budget of zero is not enforced. If the hash waited for maxWait, which is not enforced
+// if zero, ErrOverloaded is returned.
+func (l *limiter) acquire(ctx context.Context, memory uint64, max int, budget uint64, maxWait time.Duration) error {
+	start := time.Now()
+	var timeout <-chan time.Time
+	if maxWait > 0 {
+		timer := time.NewTimer(maxWait)
+		defer timer.Stop()
+		timeout = timer.C
+	}
+
+	for {
+		l.Lock()
+		if l.running == 0 || ((max <= 0 || l.running < max) && (budget == 0 || l.memory+memory <= budget)) {
+			l.running++
+			l.memory += memory
+			l.Unlock()
+
+			argon2Running.Inc()
+			argon2Memory.Add(float64(memory))
+			if max > 0 || budget > 0 {
+				argon2Wait.Observe(time.Since(start).Seconds())
+			}
+			return nil
+		}
+
+		if l.wake == nil {
+			l.wake = make(chan struct{})
+		}
+		wake := l.wake
+		l.Unlock()
+
+		select {
+		case <-ctx.Done():
+			return errors.WithStack(ctx.Err())
+		case <-timeout:
+			argon2Shed.Inc()
+			return errors.WithStack(ErrOverloaded.WithReasonf("The password could not be hashed within %s.", maxWait))
+		case <-wake:
+		}
+	}
+}
+
+// release marks the hash which used memory bytes as completed and wakes up all waiting hashes.
+func (l *limiter) release(memory uint64) {
+	l.Lock()
+	l.running--
+	l.memory -= memory
+	if l.wake != nil {
+		close(l.wake)
+		l.wake = nil
+	}
+	l.Unlock()
+
+	argon2Running.Dec()
+	argon2Memory.Sub(float64(memory))
+}
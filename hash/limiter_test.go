@@ -0,0 +1,63 @@
+package hash
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLimiter(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("case=does not wait without a limit", func(t *testing.T) {
+		var l limiter
+		for i := 0; i < 10; i++ {
+			require.NoError(t, l.acquire(ctx, 1024, 0, 0, 0))
+		}
+		assert.Equal(t, 10, l.running)
+	})
+
+	t.Run("case=waits for running hashes", func(t *testing.T) {
+		var l limiter
+		require.NoError(t, l.acquire(ctx, 1024, 1, 4096, 0))
+
+		ctx, cancel := context.WithTimeout(ctx, 10*time.Millisecond)
+		defer cancel()
+		require.ErrorIs(t, l.acquire(ctx, 1024, 1, 4096, 0), context.DeadlineExceeded)
+
+		acquired := make(chan error)
+		go func() {
+			acquired <- l.acquire(context.Background(), 1024, 1, 4096, 0)
+		}()
+		l.release(1024)
+		require.NoError(t, <-acquired)
+	})
+
+	t.Run("case=waits for the memory budget", func(t *testing.T) {
+		var l limiter
+		require.NoError(t, l.acquire(ctx, 3072, 10, 4096, 0))
+
+		ctx, cancel := context.WithTimeout(ctx, 10*time.Millisecond)
+		defer cancel()
+		require.ErrorIs(t, l.acquire(ctx, 2048, 10, 4096, 0), context.DeadlineExceeded)
+		require.NoError(t, l.acquire(ctx, 1024, 10, 4096, 0))
+	})
+
+	t.Run("case=sheds hashes which wait too long", func(t *testing.T) {
+		var l limiter
+		require.NoError(t, l.acquire(ctx, 1024, 1, 0, 0))
+
+		err := l.acquire(ctx, 1024, 1, 0, 10*time.Millisecond)
+		require.Error(t, err)
+		assert.True(t, IsOverloaded(err))
+		assert.Equal(t, 1, l.running)
+	})
+
+	t.Run("case=admits a hash exceeding the budget if no other hash runs", func(t *testing.T) {
+		var l limiter
+		require.NoError(t, l.acquire(ctx, 8192, 10, 4096, 0))
+	})
+}
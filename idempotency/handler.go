@@ -0,0 +1,193 @@
+package idempotency
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"io/ioutil"
+	"net/http"
+
+	"github.com/julienschmidt/httprouter"
+	"github.com/pkg/errors"
+
+	"github.com/ory/herodot"
+	"github.com/ory/x/sqlcon"
+
+	"github.com/ory/kratos/driver/config"
+	"github.com/ory/kratos/x"
+)
+
+const (
+	// HeaderKey is the request header which carries the idempotency key.
+	HeaderKey = "Idempotency-Key"
+
+	// HeaderReplayed is set to "true" if the response was replayed from a previous request.
+	HeaderReplayed = "Idempotent-Replayed"
+
+	maxKeyLength = 255
+)
+
+var (
+	ErrKeyReused = herodot.DefaultError{
+		StatusField: http.StatusText(http.StatusUnprocessableEntity),
+		ErrorField:  "The Idempotency-Key was already used for a different request.",
+		CodeField:   http.StatusUnprocessableEntity,
+	}
+	ErrRequestInProgress = herodot.DefaultError{
+		StatusField: http.StatusText(http.StatusConflict),
+		ErrorField:  "A request with this Idempotency-Key is still being processed, retry it later.",
+		CodeField:   http.StatusConflict,
+	}
+)
+
+type (
+	handlerDependencies interface {
+		PersistenceProvider
+		x.WriterProvider
+		x.LoggingProvider
+		x.ClockProvider
+		x.IDGeneratorProvider
+		config.Provider
+	}
+	HandlerProvider interface {
+		IdempotencyHandler() *Handler
+	}
+	// Handler makes admin API endpoints idempotent. Requests which are sent with an Idempotency-Key header are
+	// executed once, retries with the same key and body receive the stored response.
+	Handler struct {
+		r handlerDependencies
+	}
+)
+
+func NewHandler(r handlerDependencies) *Handler {
+	return &Handler{r: r}
+}
+
+// Wrap stores the response of requests with an Idempotency-Key header and replays it for retries. Responses with
+// a status code of 500 or above are not stored so that the request can be retried. Until the response is stored,
+// the key is only held for the in-progress timeout so that requests which never complete do not block it.
+func (h *Handler) Wrap(next httprouter.Handle) httprouter.Handle {
+	return func(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+		key := r.Header.Get(HeaderKey)
+		if len(key) == 0 {
+			next(w, r, ps)
+			return
+		} else if len(key) > maxKeyLength {
+			h.r.Writer().WriteError(w, r, errors.WithStack(herodot.ErrBadRequest.WithReasonf("The Idempotency-Key header must not be longer than %d characters.", maxKeyLength)))
+			return
+		}
+
+		body, err := ioutil.ReadAll(r.Body)
+		if err != nil {
+			h.r.Writer().WriteError(w, r, errors.WithStack(err))
+			return
+		}
+		r.Body = ioutil.NopCloser(bytes.NewReader(body))
+		sum := sha256.Sum256(body)
+
+		record := &Record{
+			ID:          h.r.IDGenerator().NewUUID(),
+			Key:         key,
+			Endpoint:    r.Method + " " + r.URL.Path,
+			RequestHash: hex.EncodeToString(sum[:]),
+			ExpiresAt:   h.r.Clock().Now().UTC().Add(h.r.Config(r.Context()).IdempotencyInProgressTimeout()),
+		}
+		if existing, err := h.claim(r, record); err != nil {
+			h.r.Writer().WriteError(w, r, err)
+			return
+		} else if existing != nil {
+			h.replay(w, r, record, existing)
+			return
+		}
+
+		rec := &responseRecorder{ResponseWriter: w, code: http.StatusOK}
+		next(rec, r, ps)
+
+		if rec.code >= http.StatusInternalServerError {
+			if err := h.r.IdempotencyPersister().DeleteIdempotencyRecord(r.Context(), record.Endpoint, record.Key); err != nil {
+				h.r.Logger().WithRequest(r).WithError(err).Error("Unable to release the Idempotency-Key of a failed request.")
+			}
+			return
+		}
+
+		record.ResponseCode = rec.code
+		record.ResponseLocation = rec.Header().Get("Location")
+		record.ResponseBody = rec.body.String()
+		record.ExpiresAt = h.r.Clock().Now().UTC().Add(h.r.Config(r.Context()).IdempotencyKeyLifespan())
+		if err := h.r.IdempotencyPersister().UpdateIdempotencyRecord(r.Context(), record); err != nil {
+			h.r.Logger().WithRequest(r).WithError(err).Error("Unable to store the response of a request with an Idempotency-Key.")
+		}
+	}
+}
+
+// claim stores the record unless a request with the same key was sent before, in which case the stored record
+// is returned. Expired records, including those of requests which exceeded the in-progress timeout, are replaced.
+func (h *Handler) claim(r *http.Request, record *Record) (*Record, error) {
+	p := h.r.IdempotencyPersister()
+	for i := 0; i < 2; i++ {
+		err := p.CreateIdempotencyRecord(r.Context(), record)
+		if err == nil {
+			return nil, nil
+		} else if !errors.Is(err, sqlcon.ErrUniqueViolation) {
+			return nil, err
+		}
+
+		existing, err := p.GetIdempotencyRecord(r.Context(), record.Endpoint, record.Key)
+		if errors.Is(err, sqlcon.ErrNoRows) {
+			// The record was deleted in the meantime because the request failed, try again.
+			continue
+		} else if err != nil {
+			return nil, err
+		}
+
+		if existing.ExpiresAt.After(h.r.Clock().Now()) {
+			return existing, nil
+		}
+
+		if err := p.DeleteIdempotencyRecord(r.Context(), record.Endpoint, record.Key); err != nil {
+			return nil, err
+		}
+	}
+	return nil, errors.WithStack(ErrRequestInProgress)
+}
+
+func (h *Handler) replay(w http.ResponseWriter, r *http.Request, record, existing *Record) {
+	if existing.RequestHash != record.RequestHash {
+		h.r.Writer().WriteError(w, r, errors.WithStack(ErrKeyReused))
+		return
+	} else if existing.InProgress() {
+		h.r.Writer().WriteError(w, r, errors.WithStack(ErrRequestInProgress))
+		return
+	}
+
+	if len(existing.ResponseLocation) > 0 {
+		w.Header().Set("Location", existing.ResponseLocation)
+	}
+	if len(existing.ResponseBody) > 0 {
+		w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	}
+	w.Header().Set(HeaderReplayed, "true")
+	w.WriteHeader(existing.ResponseCode)
+	_, _ = w.Write([]byte(existing.ResponseBody))
+}
+
+type responseRecorder struct {
+	http.ResponseWriter
+	code        int
+	body        bytes.Buffer
+	wroteHeader bool
+}
+
+func (r *responseRecorder) WriteHeader(code int) {
+	if !r.wroteHeader {
+		r.code = code
+		r.wroteHeader = true
+	}
+	r.ResponseWriter.WriteHeader(code)
+}
+
+func (r *responseRecorder) Write(b []byte) (int, error) {
+	r.wroteHeader = true
+	r.body.Write(b)
+	return r.ResponseWriter.Write(b)
+}
@@ -0,0 +1,148 @@
+package idempotency_test
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/julienschmidt/httprouter"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ory/kratos/driver/config"
+	"github.com/ory/kratos/idempotency"
+	"github.com/ory/kratos/internal"
+	"github.com/ory/kratos/x"
+)
+
+func TestHandler(t *testing.T) {
+	conf, reg := internal.NewFastRegistryWithMocks(t)
+
+	var calls int
+	status := http.StatusCreated
+	router := x.NewRouterAdmin()
+	router.POST("/things", reg.IdempotencyHandler().Wrap(func(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+		calls++
+		w.Header().Set("Location", "/things/1")
+		w.WriteHeader(status)
+		_, _ = fmt.Fprintf(w, `{"calls":%d}`, calls)
+	}))
+	ts := httptest.NewServer(router)
+	t.Cleanup(ts.Close)
+
+	send := func(t *testing.T, key, body string) (*http.Response, string) {
+		req, err := http.NewRequest("POST", ts.URL+"/things", strings.NewReader(body))
+		require.NoError(t, err)
+		if len(key) > 0 {
+			req.Header.Set(idempotency.HeaderKey, key)
+		}
+		res, err := ts.Client().Do(req)
+		require.NoError(t, err)
+		defer res.Body.Close()
+		b, err := ioutil.ReadAll(res.Body)
+		require.NoError(t, err)
+		return res, string(b)
+	}
+
+	t.Run("case=requests without a key are always executed", func(t *testing.T) {
+		calls = 0
+		send(t, "", `{}`)
+		send(t, "", `{}`)
+		assert.Equal(t, 2, calls)
+	})
+
+	t.Run("case=retries receive the stored response", func(t *testing.T) {
+		calls = 0
+		key := x.NewUUID().String()
+
+		res, body := send(t, key, `{"name":"foo"}`)
+		assert.Equal(t, http.StatusCreated, res.StatusCode)
+		assert.Equal(t, `{"calls":1}`, body)
+		assert.Empty(t, res.Header.Get(idempotency.HeaderReplayed))
+
+		res, body = send(t, key, `{"name":"foo"}`)
+		assert.Equal(t, http.StatusCreated, res.StatusCode)
+		assert.Equal(t, `{"calls":1}`, body)
+		assert.Equal(t, "/things/1", res.Header.Get("Location"))
+		assert.Equal(t, "true", res.Header.Get(idempotency.HeaderReplayed))
+		assert.Equal(t, 1, calls)
+	})
+
+	t.Run("case=rejects reusing a key for a different body", func(t *testing.T) {
+		calls = 0
+		key := x.NewUUID().String()
+
+		send(t, key, `{"name":"foo"}`)
+		res, _ := send(t, key, `{"name":"bar"}`)
+		assert.Equal(t, http.StatusUnprocessableEntity, res.StatusCode)
+		assert.Equal(t, 1, calls)
+	})
+
+	t.Run("case=failed requests can be retried", func(t *testing.T) {
+		calls = 0
+		key := x.NewUUID().String()
+
+		status = http.StatusInternalServerError
+		res, _ := send(t, key, `{}`)
+		assert.Equal(t, http.StatusInternalServerError, res.StatusCode)
+
+		status = http.StatusCreated
+		res, _ = send(t, key, `{}`)
+		assert.Equal(t, http.StatusCreated, res.StatusCode)
+		assert.Equal(t, 2, calls)
+	})
+
+	t.Run("case=expired keys can be used again", func(t *testing.T) {
+		conf.MustSet(config.ViperKeyIdempotencyKeyLifespan, time.Nanosecond.String())
+		t.Cleanup(func() {
+			conf.MustSet(config.ViperKeyIdempotencyKeyLifespan, nil)
+		})
+
+		calls = 0
+		key := x.NewUUID().String()
+		send(t, key, `{}`)
+		time.Sleep(time.Millisecond)
+		send(t, key, `{}`)
+		assert.Equal(t, 2, calls)
+	})
+
+	t.Run("case=keys of requests which never completed are released after the in-progress timeout", func(t *testing.T) {
+		clock := x.NewFakeClock(time.Now().UTC())
+		reg.WithClock(clock)
+		t.Cleanup(func() {
+			reg.WithClock(x.SystemClock)
+		})
+
+		calls = 0
+		key := x.NewUUID().String()
+		sum := sha256.Sum256([]byte(`{}`))
+		require.NoError(t, reg.IdempotencyPersister().CreateIdempotencyRecord(context.Background(), &idempotency.Record{
+			ID:          x.NewUUID(),
+			Key:         key,
+			Endpoint:    "POST /things",
+			RequestHash: hex.EncodeToString(sum[:]),
+			ExpiresAt:   clock.Now().Add(conf.IdempotencyInProgressTimeout()),
+		}))
+
+		res, _ := send(t, key, `{}`)
+		assert.Equal(t, http.StatusConflict, res.StatusCode)
+		assert.Equal(t, 0, calls)
+
+		clock.Add(conf.IdempotencyInProgressTimeout() + time.Second)
+		res, _ = send(t, key, `{}`)
+		assert.Equal(t, http.StatusCreated, res.StatusCode)
+		assert.Equal(t, 1, calls)
+
+		clock.Add(conf.IdempotencyInProgressTimeout() + time.Second)
+		res, _ = send(t, key, `{}`)
+		assert.Equal(t, "true", res.Header.Get(idempotency.HeaderReplayed))
+		assert.Equal(t, 1, calls)
+	})
+}
@@ -0,0 +1,22 @@
+package idempotency
+
+import "context"
+
+type PersistenceProvider interface {
+	IdempotencyPersister() Persister
+}
+
+type Persister interface {
+	// CreateIdempotencyRecord stores the record and returns sqlcon.ErrUniqueViolation if a record with the same
+	// endpoint and key exists already.
+	CreateIdempotencyRecord(ctx context.Context, r *Record) error
+
+	// GetIdempotencyRecord returns the record of the endpoint and key or sqlcon.ErrNoRows.
+	GetIdempotencyRecord(ctx context.Context, endpoint, key string) (*Record, error)
+
+	// UpdateIdempotencyRecord stores the response of the record.
+	UpdateIdempotencyRecord(ctx context.Context, r *Record) error
+
+	// DeleteIdempotencyRecord deletes the record of the endpoint and key if it exists.
+	DeleteIdempotencyRecord(ctx context.Context, endpoint, key string) error
+}
@@ -0,0 +1,76 @@
+package idempotency
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ory/x/sqlcon"
+
+	"github.com/ory/kratos/x"
+)
+
+func TestPersister(ctx context.Context, p Persister) func(t *testing.T) {
+	newRecord := func() *Record {
+		return &Record{
+			ID:          x.NewUUID(),
+			Key:         x.NewUUID().String(),
+			Endpoint:    "POST /identities",
+			RequestHash: "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855",
+			ExpiresAt:   time.Now().Add(time.Hour).UTC().Truncate(time.Second),
+		}
+	}
+
+	return func(t *testing.T) {
+		t.Run("case=not found", func(t *testing.T) {
+			_, err := p.GetIdempotencyRecord(ctx, "POST /identities", x.NewUUID().String())
+			require.ErrorIs(t, err, sqlcon.ErrNoRows)
+		})
+
+		t.Run("case=create, update, and find", func(t *testing.T) {
+			expected := newRecord()
+			require.NoError(t, p.CreateIdempotencyRecord(ctx, expected))
+
+			actual, err := p.GetIdempotencyRecord(ctx, expected.Endpoint, expected.Key)
+			require.NoError(t, err)
+			assert.Equal(t, expected.ID, actual.ID)
+			assert.True(t, actual.InProgress())
+
+			expected.ResponseCode = 201
+			expected.ResponseLocation = "http://kratos/identities/1"
+			expected.ResponseBody = `{"id":"1"}`
+			require.NoError(t, p.UpdateIdempotencyRecord(ctx, expected))
+
+			actual, err = p.GetIdempotencyRecord(ctx, expected.Endpoint, expected.Key)
+			require.NoError(t, err)
+			assert.Equal(t, 201, actual.ResponseCode)
+			assert.Equal(t, expected.ResponseLocation, actual.ResponseLocation)
+			assert.Equal(t, expected.ResponseBody, actual.ResponseBody)
+			x.AssertEqualTime(t, expected.ExpiresAt, actual.ExpiresAt)
+		})
+
+		t.Run("case=keys are unique per endpoint", func(t *testing.T) {
+			expected := newRecord()
+			require.NoError(t, p.CreateIdempotencyRecord(ctx, expected))
+
+			duplicate := newRecord()
+			duplicate.Key = expected.Key
+			require.ErrorIs(t, p.CreateIdempotencyRecord(ctx, duplicate), sqlcon.ErrUniqueViolation)
+
+			duplicate.Endpoint = "POST /recovery/link"
+			require.NoError(t, p.CreateIdempotencyRecord(ctx, duplicate))
+		})
+
+		t.Run("case=delete", func(t *testing.T) {
+			expected := newRecord()
+			require.NoError(t, p.CreateIdempotencyRecord(ctx, expected))
+			require.NoError(t, p.DeleteIdempotencyRecord(ctx, expected.Endpoint, expected.Key))
+
+			_, err := p.GetIdempotencyRecord(ctx, expected.Endpoint, expected.Key)
+			require.ErrorIs(t, err, sqlcon.ErrNoRows)
+		})
+	}
+}
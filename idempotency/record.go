@@ -0,0 +1,52 @@
+package idempotency
+
+import (
+	"context"
+	"time"
+
+	"github.com/gofrs/uuid"
+
+	"github.com/ory/kratos/corp"
+)
+
+// Record stores the response of an admin API request which was sent with an Idempotency-Key header so that
+// retries of the request receive the same response instead of being executed again.
+type Record struct {
+	ID uuid.UUID `json:"id" db:"id"`
+
+	// Key is the value of the Idempotency-Key header.
+	Key string `json:"key" db:"idempotency_key"`
+
+	// Endpoint is the HTTP method and path of the request, for example "POST /identities".
+	Endpoint string `json:"endpoint" db:"endpoint"`
+
+	// RequestHash is the hex encoded SHA-256 hash of the request body. Retries must send the same body.
+	RequestHash string `json:"request_hash" db:"request_hash"`
+
+	// ResponseCode is the HTTP status code of the response. It is zero while the request is being processed.
+	ResponseCode int `json:"response_code" db:"response_code"`
+
+	// ResponseLocation is the Location header of the response.
+	ResponseLocation string `json:"response_location" db:"response_location"`
+
+	// ResponseBody is the body of the response.
+	ResponseBody string `json:"response_body" db:"response_body"`
+
+	// ExpiresAt is the time after which the key may be used again.
+	ExpiresAt time.Time `json:"expires_at" db:"expires_at"`
+
+	// CreatedAt is a helper struct field for gobuffalo.pop.
+	CreatedAt time.Time `json:"-" db:"created_at"`
+
+	// UpdatedAt is a helper struct field for gobuffalo.pop.
+	UpdatedAt time.Time `json:"-" db:"updated_at"`
+}
+
+func (r Record) TableName(ctx context.Context) string {
+	return corp.ContextualizeTableName(ctx, "idempotency_records")
+}
+
+// InProgress returns true if the request of the record has not completed yet.
+func (r *Record) InProgress() bool {
+	return r.ResponseCode == 0
+}
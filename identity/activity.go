@@ -0,0 +1,46 @@
+package identity
+
+import (
+	"time"
+)
+
+// Activity is an activity of an identity which is recorded with a timestamp.
+type Activity string
+
+const (
+	// ActivityLogin is recorded when the identity completes a login flow.
+	ActivityLogin Activity = "last_login_at"
+	// ActivitySeen is recorded when the identity's session is checked using `/sessions/whoami`.
+	ActivitySeen Activity = "last_seen_at"
+)
+
+// ActivityFilter selects identities which have been inactive since the given times. Identities for which an
+// activity was never recorded are compared using the time activity tracking was enabled or, for identities
+// created afterwards, their creation date. Zero times are ignored.
+type ActivityFilter struct {
+	LastLoginBefore       time.Time
+	LastSeenBefore        time.Time
+	PasswordChangedBefore time.Time
+	StateChangedBefore    time.Time
+
+	// State restricts the filter to identities in this state if set.
+	State State
+
+	// NotNotifiedOfDormancy restricts the filter to identities which were not notified that they are dormant.
+	NotNotifiedOfDormancy bool
+
+	// NotifiedOfDormancyBefore restricts the filter to identities which were notified that they are dormant
+	// before this time.
+	NotifiedOfDormancyBefore time.Time
+
+	// DeactivatedForDormancyBefore restricts the filter to identities which were deactivated by the dormancy
+	// policy before this time. Identities deactivated using the Admin API never match.
+	DeactivatedForDormancyBefore time.Time
+}
+
+// IsZero returns true if the filter matches all identities.
+func (f *ActivityFilter) IsZero() bool {
+	return f.LastLoginBefore.IsZero() && f.LastSeenBefore.IsZero() && f.PasswordChangedBefore.IsZero() &&
+		f.StateChangedBefore.IsZero() && f.State == "" && !f.NotNotifiedOfDormancy &&
+		f.NotifiedOfDormancyBefore.IsZero() && f.DeactivatedForDormancyBefore.IsZero()
+}
@@ -0,0 +1,117 @@
+package identity
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"time"
+
+	"github.com/gofrs/uuid"
+
+	"github.com/ory/kratos/corp"
+	"github.com/ory/kratos/x"
+)
+
+// An anonymized stub of a deleted identity. A stub is retained for each identifier of the deleted identity.
+//
+// swagger:model anonymizedIdentityStub
+type AnonymizedStub struct {
+	ID uuid.UUID `json:"-" faker:"-" db:"id"`
+
+	// IdentifierHash is the keyed hash of an identifier or address of the deleted identity.
+	//
+	// required: true
+	IdentifierHash string `json:"identifier_hash" db:"identifier_hash"`
+
+	// IdentityCreatedAt is the time the deleted identity was created.
+	//
+	// required: true
+	IdentityCreatedAt time.Time `json:"identity_created_at" faker:"-" db:"identity_created_at"`
+
+	// DeletedAt is the time the identity was deleted.
+	//
+	// required: true
+	DeletedAt time.Time `json:"deleted_at" faker:"-" db:"created_at"`
+
+	// ExpiresAt is the time the stub is purged. Stubs without expiry are retained forever.
+	//
+	// Extensions:
+	// ---
+	// x-omitempty: true
+	// ---
+	ExpiresAt *time.Time `json:"expires_at,omitempty" faker:"-" db:"expires_at"`
+
+	// UpdatedAt is a helper struct field for gobuffalo.pop.
+	UpdatedAt time.Time `json:"-" faker:"-" db:"updated_at"`
+}
+
+func (s AnonymizedStub) TableName(ctx context.Context) string {
+	return corp.ContextualizeTableName(ctx, "identity_anonymized_stubs")
+}
+
+// HashIdentifier returns the keyed hash of an identifier which is stored in anonymized stubs.
+func HashIdentifier(secret []byte, identifier string) string {
+	h := hmac.New(sha256.New, secret)
+	_, _ = h.Write([]byte(identifier))
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// HashIdentifiers returns the hashes of the identifier for all secrets so that stubs can be found after the
+// secrets were rotated.
+func HashIdentifiers(secrets [][]byte, identifier string) []string {
+	hashes := make([]string, len(secrets))
+	for k, secret := range secrets {
+		hashes[k] = HashIdentifier(secret, identifier)
+	}
+	return hashes
+}
+
+// NewAnonymizedStubs returns one stub for each identifier and address of the identity. The identity must have
+// been loaded including its credentials.
+func NewAnonymizedStubs(i *Identity, secret []byte, deletedAt time.Time, retention time.Duration) []AnonymizedStub {
+	var expiresAt *time.Time
+	if retention > 0 {
+		e := deletedAt.Add(retention)
+		expiresAt = &e
+	}
+
+	identifiers := identifiersOf(i)
+	stubs := make([]AnonymizedStub, len(identifiers))
+	for k, identifier := range identifiers {
+		stubs[k] = AnonymizedStub{
+			ID:                x.NewUUID(),
+			IdentifierHash:    HashIdentifier(secret, identifier),
+			IdentityCreatedAt: i.CreatedAt,
+			DeletedAt:         deletedAt,
+			ExpiresAt:         expiresAt,
+		}
+	}
+	return stubs
+}
+
+// identifiersOf returns the unique credentials identifiers and addresses of the identity.
+func identifiersOf(i *Identity) []string {
+	seen := map[string]bool{}
+	var identifiers []string
+	add := func(identifier string) {
+		if len(identifier) == 0 || seen[identifier] {
+			return
+		}
+		seen[identifier] = true
+		identifiers = append(identifiers, identifier)
+	}
+
+	for _, c := range i.Credentials {
+		for _, identifier := range c.Identifiers {
+			add(identifier)
+		}
+	}
+	for _, a := range i.VerifiableAddresses {
+		add(a.Value)
+	}
+	for _, a := range i.RecoveryAddresses {
+		add(a.Value)
+	}
+	return identifiers
+}
@@ -0,0 +1,87 @@
+package identity
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"regexp"
+
+	"github.com/pkg/errors"
+
+	"github.com/ory/herodot"
+)
+
+// localePattern matches BCP 47 language tags made of a primary language subtag and up to three subtags
+// (e.g. `en`, `de-CH`, `zh-Hant-TW`). It intentionally rejects anything which could be used for path
+// traversal as the locale is used to look up template files.
+var localePattern = regexp.MustCompile(`^[a-zA-Z]{2,3}(-[a-zA-Z0-9]{2,8}){0,3}$`)
+
+// CommunicationPreferences are the per-identity flags the courier consults before sending out messages.
+//
+// swagger:model identityCommunicationPreferences
+type CommunicationPreferences struct {
+	// NoMarketing is true if the identity opted out of any communication which is not
+	// strictly required to complete a self-service flow.
+	NoMarketing bool `json:"no_marketing"`
+
+	// NoVerificationReminders is true if the identity opted out of reminders to verify its addresses.
+	NoVerificationReminders bool `json:"no_verification_reminders"`
+
+	// PreferredLocale is the BCP 47 language tag (e.g. `en` or `de-CH`) messages should be rendered in.
+	PreferredLocale string `json:"preferred_locale,omitempty"`
+}
+
+// IsValidLocale returns true if locale is a well-formed BCP 47 language tag.
+func IsValidLocale(locale string) bool {
+	return localePattern.MatchString(locale)
+}
+
+// Validate returns a bad request error if the preferences contain values which can not be honored.
+func (p *CommunicationPreferences) Validate() error {
+	if p.PreferredLocale != "" && !IsValidLocale(p.PreferredLocale) {
+		return errors.WithStack(herodot.ErrBadRequest.WithReasonf(`The preferred locale "%s" is not a valid BCP 47 language tag.`, p.PreferredLocale))
+	}
+
+	return nil
+}
+
+// AcceptsMarketing returns true unless the identity opted out of marketing communication. Nil
+// preferences accept marketing communication.
+func (p *CommunicationPreferences) AcceptsMarketing() bool {
+	return p == nil || !p.NoMarketing
+}
+
+// AcceptsVerificationReminders returns true unless the identity opted out of verification reminders or of
+// marketing communication, as reminders are not required to complete a self-service flow. Nil preferences
+// accept verification reminders.
+func (p *CommunicationPreferences) AcceptsVerificationReminders() bool {
+	return p == nil || !(p.NoMarketing || p.NoVerificationReminders)
+}
+
+// Locale returns the preferred locale or an empty string if none is set.
+func (p *CommunicationPreferences) Locale() string {
+	if p == nil {
+		return ""
+	}
+	return p.PreferredLocale
+}
+
+func (p *CommunicationPreferences) Scan(value interface{}) error {
+	switch v := value.(type) {
+	case nil:
+		*p = CommunicationPreferences{}
+		return nil
+	case string:
+		return errors.WithStack(json.Unmarshal([]byte(v), p))
+	case []byte:
+		return errors.WithStack(json.Unmarshal(v, p))
+	}
+	return errors.Errorf("unable to scan communication preferences from type %T", value)
+}
+
+func (p CommunicationPreferences) Value() (driver.Value, error) {
+	out, err := json.Marshal(p)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	return string(out), nil
+}
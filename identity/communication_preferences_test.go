@@ -0,0 +1,64 @@
+package identity
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCommunicationPreferences(t *testing.T) {
+	t.Run("method=Validate", func(t *testing.T) {
+		for _, locale := range []string{"", "en", "de-CH", "zh-Hant-TW", "gsw"} {
+			assert.NoError(t, (&CommunicationPreferences{PreferredLocale: locale}).Validate(), locale)
+		}
+
+		for _, locale := range []string{"e", "english", "de_CH", "de-", "../de", "de/CH", `de\CH`, "..", "de-CH-x-y-z", "de CH"} {
+			assert.Error(t, (&CommunicationPreferences{PreferredLocale: locale}).Validate(), locale)
+		}
+	})
+
+	t.Run("method=AcceptsMarketing", func(t *testing.T) {
+		var p *CommunicationPreferences
+		assert.True(t, p.AcceptsMarketing())
+		assert.Empty(t, p.Locale())
+		assert.True(t, (&CommunicationPreferences{}).AcceptsMarketing())
+		assert.False(t, (&CommunicationPreferences{NoMarketing: true}).AcceptsMarketing())
+	})
+
+	t.Run("method=AcceptsVerificationReminders", func(t *testing.T) {
+		var p *CommunicationPreferences
+		assert.True(t, p.AcceptsVerificationReminders())
+		assert.True(t, (&CommunicationPreferences{}).AcceptsVerificationReminders())
+		assert.False(t, (&CommunicationPreferences{NoVerificationReminders: true}).AcceptsVerificationReminders())
+		assert.False(t, (&CommunicationPreferences{NoMarketing: true}).AcceptsVerificationReminders())
+	})
+
+	t.Run("method=Scan", func(t *testing.T) {
+		expected := CommunicationPreferences{NoMarketing: true, PreferredLocale: "de-CH"}
+		raw := `{"no_marketing":true,"preferred_locale":"de-CH"}`
+
+		for k, in := range []interface{}{raw, []byte(raw)} {
+			var actual CommunicationPreferences
+			require.NoError(t, actual.Scan(in), "%d", k)
+			assert.Equal(t, expected, actual, "%d", k)
+		}
+
+		actual := expected
+		require.NoError(t, actual.Scan(nil))
+		assert.Equal(t, CommunicationPreferences{}, actual)
+
+		assert.Error(t, actual.Scan(1234))
+		assert.Error(t, actual.Scan("not json"))
+	})
+
+	t.Run("method=Value", func(t *testing.T) {
+		v, err := CommunicationPreferences{NoMarketing: true, PreferredLocale: "de-CH"}.Value()
+		require.NoError(t, err)
+		assert.JSONEq(t, `{"no_marketing":true,"no_verification_reminders":false,"preferred_locale":"de-CH"}`, v.(string))
+
+		var actual CommunicationPreferences
+		require.NoError(t, actual.Scan(v))
+		assert.Equal(t, CommunicationPreferences{NoMarketing: true, PreferredLocale: "de-CH"}, actual)
+	})
+}
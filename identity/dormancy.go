@@ -0,0 +1,261 @@
+package identity
+
+import (
+	"context"
+	"time"
+
+	"github.com/gofrs/uuid"
+	"github.com/pkg/errors"
+
+	"github.com/ory/x/sqlcon"
+
+	"github.com/ory/kratos/courier"
+	"github.com/ory/kratos/courier/template"
+	"github.com/ory/kratos/driver/config"
+	"github.com/ory/kratos/x"
+)
+
+const dormancyPageSize = 500
+
+type (
+	dormancyDependencies interface {
+		config.Provider
+		PrivilegedPoolProvider
+		ManagementProvider
+		courier.Provider
+		x.ClockProvider
+		x.LoggingProvider
+	}
+	DormancyEnforcerProvider interface {
+		IdentityDormancyEnforcer() *DormancyEnforcer
+	}
+	// DormancyEnforcer notifies, deactivates, and deletes identities according to the dormancy policy.
+	DormancyEnforcer struct {
+		r dormancyDependencies
+	}
+
+	// DormancyReport lists the identities the dormancy policy was applied to.
+	//
+	// swagger:model dormancyReport
+	DormancyReport struct {
+		// DryRun is true if the identities were only reported but not notified, deactivated, or deleted.
+		//
+		// required: true
+		DryRun bool `json:"dry_run"`
+
+		// Notified are the identities which were notified that they are dormant.
+		//
+		// required: true
+		Notified []uuid.UUID `json:"notified"`
+
+		// Deactivated are the identities which were deactivated.
+		//
+		// required: true
+		Deactivated []uuid.UUID `json:"deactivated"`
+
+		// Deleted are the identities which were deleted.
+		//
+		// required: true
+		Deleted []uuid.UUID `json:"deleted"`
+	}
+)
+
+func NewDormancyEnforcer(r dormancyDependencies) *DormancyEnforcer {
+	return &DormancyEnforcer{r: r}
+}
+
+// Enforce applies the dormancy policy once. The steps build on each other: an identity is only deactivated after
+// it was notified, and only deleted after it was deactivated or, if deactivation is disabled, notified by an
+// earlier run. The time between two steps is at least the difference between their configured periods, so
+// identities always receive the notification before anything else happens to them. Each identity is only
+// reported for one step per run. If dryRun is true, the identities are reported without being notified,
+// deactivated, or deleted.
+func (e *DormancyEnforcer) Enforce(ctx context.Context, dryRun bool) (*DormancyReport, error) {
+	c := e.r.Config(ctx).IdentityDormancy()
+	now := e.r.Clock().Now().UTC()
+	report := &DormancyReport{DryRun: dryRun, Notified: []uuid.UUID{}, Deactivated: []uuid.UUID{}, Deleted: []uuid.UUID{}}
+	handled := map[uuid.UUID]bool{}
+
+	if c.DeleteAfter > 0 && c.DeactivateAfter == 0 && c.NotifyAfter == 0 {
+		e.r.Logger().Warn("Not deleting dormant identities because neither notify_after nor deactivate_after is set.")
+	} else if c.DeleteAfter > 0 {
+		before := now.Add(-c.DeleteAfter)
+		f := ActivityFilter{LastLoginBefore: before, LastSeenBefore: before}
+		if c.DeactivateAfter > 0 {
+			// Identities deactivated using the Admin API are never deleted.
+			f.State = StateInactive
+			f.DeactivatedForDormancyBefore = now.Add(c.DeactivateAfter - c.DeleteAfter)
+		} else {
+			f.NotifiedOfDormancyBefore = now.Add(c.NotifyAfter - c.DeleteAfter)
+		}
+
+		is, err := e.list(ctx, f)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, i := range is {
+			if !dryRun {
+				if err := e.r.IdentityManager().Delete(ctx, i.ID); err != nil && !errors.Is(err, sqlcon.ErrNoRows) {
+					return nil, err
+				}
+			}
+			handled[i.ID] = true
+			report.Deleted = append(report.Deleted, i.ID)
+		}
+	}
+
+	if c.DeactivateAfter > 0 {
+		before := now.Add(-c.DeactivateAfter)
+		f := ActivityFilter{LastLoginBefore: before, LastSeenBefore: before, StateChangedBefore: before, State: StateActive}
+		if c.NotifyAfter > 0 {
+			f.NotifiedOfDormancyBefore = now.Add(c.NotifyAfter - c.DeactivateAfter)
+		}
+
+		is, err := e.list(ctx, f)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, i := range is {
+			if handled[i.ID] {
+				continue
+			}
+			if !dryRun {
+				if err := e.r.PrivilegedIdentityPool().DeactivateDormantIdentity(ctx, i.ID, now); err != nil && !errors.Is(err, sqlcon.ErrNoRows) {
+					return nil, err
+				}
+			}
+			handled[i.ID] = true
+			report.Deactivated = append(report.Deactivated, i.ID)
+		}
+	}
+
+	if c.NotifyAfter > 0 {
+		before := now.Add(-c.NotifyAfter)
+		is, err := e.list(ctx, ActivityFilter{LastLoginBefore: before, LastSeenBefore: before, StateChangedBefore: before, State: StateActive, NotNotifiedOfDormancy: true})
+		if err != nil {
+			return nil, err
+		}
+
+		for k := range is {
+			i := &is[k]
+			if handled[i.ID] {
+				continue
+			}
+
+			address := dormancyNotificationAddress(i)
+			if address == "" {
+				// The identity is marked as notified anyway so that the remaining steps still apply to it.
+				e.r.Logger().WithField("identity_id", i.ID).Debug("Not notifying dormant identity because it has no email address.")
+				if !dryRun {
+					if err := e.r.PrivilegedIdentityPool().MarkIdentityDormancyNotified(ctx, i.ID, now); err != nil && !errors.Is(err, sqlcon.ErrNoRows) {
+						return nil, err
+					}
+				}
+				continue
+			}
+
+			if !dryRun {
+				if err := e.notify(ctx, c, i, address, now); err != nil {
+					return nil, err
+				}
+			}
+			report.Notified = append(report.Notified, i.ID)
+		}
+	}
+
+	return report, nil
+}
+
+// Watch enforces the dormancy policy in the configured interval until the context is canceled.
+func (e *DormancyEnforcer) Watch(ctx context.Context) error {
+	return x.RunPeriodically(ctx, func(ctx context.Context) time.Duration {
+		c := e.r.Config(ctx).IdentityDormancy()
+		report, err := e.Enforce(ctx, c.DryRun)
+		if err != nil {
+			e.r.Logger().WithError(err).Error("Unable to enforce the dormancy policy.")
+		} else {
+			e.r.Logger().
+				WithField("dry_run", report.DryRun).
+				WithField("notified", report.Notified).
+				WithField("deactivated", report.Deactivated).
+				WithField("deleted", report.Deleted).
+				Info("Enforced the dormancy policy.")
+		}
+		return c.Interval
+	})
+}
+
+func (e *DormancyEnforcer) notify(ctx context.Context, c config.IdentityDormancy, i *Identity, address string, now time.Time) error {
+	model, err := template.IdentityModel(i)
+	if err != nil {
+		return err
+	}
+
+	m := &template.DormancyNotificationModel{To: address, LastActiveAt: i.LastActiveAt(), Identity: model}
+	// The later steps wait for the identity to be inactive long enough and for the previous step to be far enough
+	// in the past, whichever takes longer.
+	if c.DeactivateAfter > 0 {
+		m.DeactivateAt = latest(m.LastActiveAt.Add(c.DeactivateAfter), now.Add(c.DeactivateAfter-c.NotifyAfter))
+	}
+	if c.DeleteAfter > 0 && c.DeactivateAfter > 0 {
+		m.DeleteAt = latest(m.LastActiveAt.Add(c.DeleteAfter), m.DeactivateAt.Add(c.DeleteAfter-c.DeactivateAfter))
+	} else if c.DeleteAfter > 0 {
+		m.DeleteAt = latest(m.LastActiveAt.Add(c.DeleteAfter), now.Add(c.DeleteAfter-c.NotifyAfter))
+	}
+
+	if _, err := e.r.Courier(ctx).QueueEmailFor(ctx, i.CommunicationPreferences, template.NewDormancyNotification(e.r.Config(ctx), m)); err != nil {
+		return err
+	}
+
+	return e.r.PrivilegedIdentityPool().MarkIdentityDormancyNotified(ctx, i.ID, now)
+}
+
+// list returns all identities matching the filter. All pages are loaded before the policy is applied
+// because applying it changes which identities match.
+func (e *DormancyEnforcer) list(ctx context.Context, f ActivityFilter) ([]Identity, error) {
+	var is []Identity
+	if err := x.ForEachPage(dormancyPageSize, func(page, perPage int) (int, error) {
+		p, err := e.r.PrivilegedIdentityPool().ListIdentitiesByActivity(ctx, f, page, perPage)
+		is = append(is, p...)
+		return len(p), err
+	}); err != nil {
+		return nil, err
+	}
+	return is, nil
+}
+
+// dormancyNotificationAddress returns the email address dormancy notifications are sent to, preferring verified
+// addresses.
+func dormancyNotificationAddress(i *Identity) string {
+	var address string
+	for _, a := range i.VerifiableAddresses {
+		if a.Via != VerifiableAddressTypeEmail {
+			continue
+		}
+		if a.Verified {
+			return a.Value
+		}
+		if address == "" {
+			address = a.Value
+		}
+	}
+	if address != "" {
+		return address
+	}
+
+	for _, a := range i.RecoveryAddresses {
+		if a.Via == RecoveryAddressTypeEmail {
+			return a.Value
+		}
+	}
+	return ""
+}
+
+func latest(a, b time.Time) time.Time {
+	if a.After(b) {
+		return a
+	}
+	return b
+}
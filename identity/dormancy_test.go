@@ -0,0 +1,168 @@
+package identity_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/gofrs/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ory/x/sqlcon"
+
+	"github.com/ory/kratos/courier"
+	"github.com/ory/kratos/driver/config"
+	"github.com/ory/kratos/identity"
+	"github.com/ory/kratos/internal"
+	"github.com/ory/kratos/internal/testhelpers"
+	"github.com/ory/kratos/x"
+)
+
+func TestDormancyEnforcer(t *testing.T) {
+	conf, reg := internal.NewFastRegistryWithMocks(t)
+	testhelpers.SetDefaultIdentitySchema(t, conf, "file://./stub/identity.schema.json")
+	conf.MustSet(config.ViperKeyCourierSMTPURL, "smtp://foo@bar@dev.null/")
+	conf.MustSet(config.ViperKeyIdentityDormancyNotifyAfter, "24h")
+	conf.MustSet(config.ViperKeyIdentityDormancyDeactivateAfter, "48h")
+	conf.MustSet(config.ViperKeyIdentityDormancyDeleteAfter, "72h")
+
+	now := time.Now().UTC()
+	clock := x.NewFakeClock(now)
+	reg.WithClock(clock)
+
+	ctx := context.Background()
+	p := reg.PrivilegedIdentityPool()
+
+	newIdentity := func(t *testing.T, email string) *identity.Identity {
+		i := identity.NewIdentity("")
+		i.VerifiableAddresses = []identity.VerifiableAddress{*identity.NewVerifiableEmailAddress(email, i.ID)}
+		require.NoError(t, p.CreateIdentity(ctx, i))
+		return i
+	}
+
+	create := func(t *testing.T, email string, inactiveFor time.Duration, state identity.State) *identity.Identity {
+		i := newIdentity(t, email)
+		at := now.Add(-inactiveFor)
+		require.NoError(t, p.RecordIdentityActivity(ctx, i.ID, identity.ActivityLogin, at))
+		require.NoError(t, p.RecordIdentityActivity(ctx, i.ID, identity.ActivitySeen, at))
+		require.NoError(t, p.UpdateIdentityState(ctx, i.ID, state, at))
+		return i
+	}
+
+	active := create(t, "active@ory.sh", time.Hour, identity.StateActive)
+	dormant := create(t, "dormant@ory.sh", 30*time.Hour, identity.StateActive)
+	expired := create(t, "expired@ory.sh", 50*time.Hour, identity.StateActive)
+	gone := create(t, "gone@ory.sh", 80*time.Hour, identity.StateActive)
+	deactivated := create(t, "deactivated@ory.sh", 80*time.Hour, identity.StateInactive)
+
+	// An identity which existed long before activity tracking was enabled and never had any activity recorded.
+	legacy := newIdentity(t, "legacy@ory.sh")
+	require.NoError(t, reg.Persister().GetConnection(ctx).RawQuery(
+		"UPDATE identities SET created_at = ?, activity_tracked_since = ? WHERE id = ?",
+		now.Add(-400*time.Hour), now, legacy.ID).Exec())
+
+	assertReport := func(t *testing.T, r *identity.DormancyReport, notified, deactivated, deleted []uuid.UUID) {
+		assert.ElementsMatch(t, notified, r.Notified)
+		assert.ElementsMatch(t, deactivated, r.Deactivated)
+		assert.ElementsMatch(t, deleted, r.Deleted)
+	}
+
+	assertMessages := func(t *testing.T, recipients ...string) {
+		messages, err := reg.CourierPersister().NextMessages(ctx, 10)
+		if len(recipients) == 0 {
+			require.ErrorIs(t, err, courier.ErrQueueEmpty)
+			return
+		}
+		require.NoError(t, err)
+
+		actual := make([]string, len(messages))
+		for k, m := range messages {
+			actual[k] = m.Recipient
+			assert.Equal(t, "dormancy_notification", m.TemplateType)
+		}
+		assert.ElementsMatch(t, recipients, actual)
+	}
+
+	t.Run("case=dry run does not change identities", func(t *testing.T) {
+		report, err := reg.IdentityDormancyEnforcer().Enforce(ctx, true)
+		require.NoError(t, err)
+		assert.True(t, report.DryRun)
+		assertReport(t, report, []uuid.UUID{dormant.ID, expired.ID, gone.ID}, nil, nil)
+
+		actual, err := p.GetIdentity(ctx, dormant.ID)
+		require.NoError(t, err)
+		assert.Nil(t, actual.DormancyNotifiedAt)
+
+		assertMessages(t)
+	})
+
+	t.Run("case=enabling the policy only notifies existing identities", func(t *testing.T) {
+		report, err := reg.IdentityDormancyEnforcer().Enforce(ctx, false)
+		require.NoError(t, err)
+		assert.False(t, report.DryRun)
+		assertReport(t, report, []uuid.UUID{dormant.ID, expired.ID, gone.ID}, nil, nil)
+
+		for _, id := range []uuid.UUID{dormant.ID, expired.ID, gone.ID} {
+			actual, err := p.GetIdentity(ctx, id)
+			require.NoError(t, err)
+			assert.True(t, actual.IsActive())
+			assert.NotNil(t, actual.DormancyNotifiedAt)
+		}
+
+		for _, id := range []uuid.UUID{active.ID, legacy.ID} {
+			actual, err := p.GetIdentity(ctx, id)
+			require.NoError(t, err)
+			assert.Nil(t, actual.DormancyNotifiedAt)
+		}
+
+		assertMessages(t, "dormant@ory.sh", "expired@ory.sh", "gone@ory.sh")
+	})
+
+	t.Run("case=identities are only notified once", func(t *testing.T) {
+		report, err := reg.IdentityDormancyEnforcer().Enforce(ctx, false)
+		require.NoError(t, err)
+		assertReport(t, report, nil, nil, nil)
+	})
+
+	t.Run("case=notified identities are deactivated", func(t *testing.T) {
+		clock.Add(25 * time.Hour)
+
+		report, err := reg.IdentityDormancyEnforcer().Enforce(ctx, false)
+		require.NoError(t, err)
+		assertReport(t, report, []uuid.UUID{active.ID, legacy.ID}, []uuid.UUID{dormant.ID, expired.ID, gone.ID}, nil)
+
+		for _, id := range []uuid.UUID{dormant.ID, expired.ID, gone.ID} {
+			actual, err := p.GetIdentity(ctx, id)
+			require.NoError(t, err)
+			assert.False(t, actual.IsActive())
+		}
+
+		assertMessages(t, "active@ory.sh", "legacy@ory.sh")
+	})
+
+	t.Run("case=deactivated identities are deleted", func(t *testing.T) {
+		clock.Add(25 * time.Hour)
+
+		report, err := reg.IdentityDormancyEnforcer().Enforce(ctx, false)
+		require.NoError(t, err)
+		assertReport(t, report, nil, []uuid.UUID{active.ID, legacy.ID}, []uuid.UUID{dormant.ID, expired.ID, gone.ID})
+
+		for _, id := range []uuid.UUID{dormant.ID, expired.ID, gone.ID} {
+			_, err := p.GetIdentity(ctx, id)
+			require.ErrorIs(t, err, sqlcon.ErrNoRows)
+		}
+	})
+
+	t.Run("case=identities deactivated using the admin api are not deleted", func(t *testing.T) {
+		clock.Add(100 * time.Hour)
+
+		report, err := reg.IdentityDormancyEnforcer().Enforce(ctx, false)
+		require.NoError(t, err)
+		assertReport(t, report, nil, nil, []uuid.UUID{active.ID, legacy.ID})
+
+		actual, err := p.GetIdentity(ctx, deactivated.ID)
+		require.NoError(t, err)
+		assert.False(t, actual.IsActive())
+	})
+}
@@ -0,0 +1,135 @@
+package identity
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/gofrs/uuid"
+	"github.com/ory/jsonschema/v3"
+
+	"github.com/ory/kratos/driver/config"
+)
+
+const (
+	DuplicateReasonEmail       DuplicateReason = "email"
+	DuplicateReasonPhone       DuplicateReason = "phone"
+	DuplicateReasonOIDCSubject DuplicateReason = "oidc_subject"
+)
+
+// duplicateEmailNormalization is stricter than the configurable identifier normalization because the report
+// lists likely duplicates for review instead of rejecting registrations.
+var (
+	duplicateEmailNormalization = config.IdentifierNormalization{Lowercase: true, Trim: true, StripGmailAliases: true}
+	phoneNumber                 = regexp.MustCompile(`^\+?[0-9 ()./-]{7,}$`)
+)
+
+type (
+	// DuplicateReason explains why identities are likely duplicates.
+	DuplicateReason string
+
+	// DuplicateIdentities are identities which likely belong to the same person.
+	//
+	// swagger:model duplicateIdentities
+	DuplicateIdentities struct {
+		// Reason is one of `email`, `phone`, and `oidc_subject`.
+		//
+		// required: true
+		Reason DuplicateReason `json:"reason"`
+
+		// Value is the normalized email address, phone number, or OpenID Connect subject the identities share.
+		//
+		// required: true
+		Value string `json:"value"`
+
+		// IdentityIDs are the IDs of the identities which share the value.
+		//
+		// required: true
+		IdentityIDs []uuid.UUID `json:"identity_ids"`
+	}
+
+	// IdentityIdentifier is an identifier or an address of an identity. Type is the credentials type for
+	// credentials identifiers and the address type (e.g. `email`) for verifiable and recovery addresses.
+	IdentityIdentifier struct {
+		IdentityID uuid.UUID `db:"identity_id"`
+		Type       string    `db:"type"`
+		Value      string    `db:"value"`
+	}
+)
+
+// FindDuplicateIdentities groups the identifiers by normalized email address, phone number, and OpenID Connect
+// subject and returns all groups which span more than one identity, regardless of the identity schema.
+func FindDuplicateIdentities(identifiers []IdentityIdentifier) []DuplicateIdentities {
+	type key struct {
+		reason DuplicateReason
+		value  string
+	}
+
+	owners := map[key]map[uuid.UUID]struct{}{}
+	for _, i := range identifiers {
+		reason, value := duplicateKey(i)
+		if reason == "" || value == "" {
+			continue
+		}
+
+		k := key{reason: reason, value: value}
+		if owners[k] == nil {
+			owners[k] = map[uuid.UUID]struct{}{}
+		}
+		owners[k][i.IdentityID] = struct{}{}
+	}
+
+	duplicates := []DuplicateIdentities{}
+	for k, ids := range owners {
+		if len(ids) < 2 {
+			continue
+		}
+
+		d := DuplicateIdentities{Reason: k.reason, Value: k.value, IdentityIDs: make([]uuid.UUID, 0, len(ids))}
+		for id := range ids {
+			d.IdentityIDs = append(d.IdentityIDs, id)
+		}
+		sort.Slice(d.IdentityIDs, func(i, j int) bool {
+			return d.IdentityIDs[i].String() < d.IdentityIDs[j].String()
+		})
+		duplicates = append(duplicates, d)
+	}
+
+	sort.Slice(duplicates, func(i, j int) bool {
+		if duplicates[i].Reason != duplicates[j].Reason {
+			return duplicates[i].Reason < duplicates[j].Reason
+		}
+		return duplicates[i].Value < duplicates[j].Value
+	})
+	return duplicates
+}
+
+func duplicateKey(i IdentityIdentifier) (DuplicateReason, string) {
+	switch i.Type {
+	case string(VerifiableAddressTypeEmail):
+		return DuplicateReasonEmail, NormalizeIdentifier(duplicateEmailNormalization, i.Value)
+	case string(CredentialsTypePassword):
+		if jsonschema.Formats["email"](i.Value) {
+			return DuplicateReasonEmail, NormalizeIdentifier(duplicateEmailNormalization, i.Value)
+		} else if phoneNumber.MatchString(i.Value) {
+			return DuplicateReasonPhone, normalizePhoneNumber(i.Value)
+		}
+	case string(CredentialsTypeOIDC):
+		// OpenID Connect identifiers are formatted as `<provider>:<subject>`.
+		if parts := strings.SplitN(i.Value, ":", 2); len(parts) == 2 {
+			return DuplicateReasonOIDCSubject, parts[1]
+		}
+	}
+	return "", ""
+}
+
+// normalizePhoneNumber removes everything but digits and a leading `+`.
+func normalizePhoneNumber(value string) string {
+	var b strings.Builder
+	for k, r := range strings.TrimSpace(value) {
+		if (r >= '0' && r <= '9') || (k == 0 && r == '+') {
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,50 @@
+package identity_test
+
+import (
+	"testing"
+
+	"github.com/gofrs/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ory/kratos/identity"
+	"github.com/ory/kratos/x"
+)
+
+func TestFindDuplicateIdentities(t *testing.T) {
+	a, b, c := x.NewUUID(), x.NewUUID(), x.NewUUID()
+
+	assert.Empty(t, identity.FindDuplicateIdentities(nil))
+
+	actual := identity.FindDuplicateIdentities([]identity.IdentityIdentifier{
+		// same normalized email, found in identifiers and addresses
+		{IdentityID: a, Type: "password", Value: "j.doe+news@gmail.com"},
+		{IdentityID: a, Type: "email", Value: "J.Doe+news@gmail.com"},
+		{IdentityID: b, Type: "email", Value: "jdoe@gmail.com"},
+
+		// same phone number
+		{IdentityID: b, Type: "password", Value: "+49 (151) 1234-5678"},
+		{IdentityID: c, Type: "password", Value: "+4915112345678"},
+
+		// same OpenID Connect subject of different providers
+		{IdentityID: a, Type: "oidc", Value: "google-web:1234"},
+		{IdentityID: c, Type: "oidc", Value: "google-app:1234"},
+
+		// no duplicates
+		{IdentityID: a, Type: "password", Value: "jdoe"},
+		{IdentityID: b, Type: "password", Value: "jdoe2"},
+		{IdentityID: c, Type: "email", Value: "foo@ory.sh"},
+		{IdentityID: c, Type: "oidc", Value: "github:5678"},
+	})
+
+	require.Len(t, actual, 3)
+	for k, expected := range []identity.DuplicateIdentities{
+		{Reason: identity.DuplicateReasonEmail, Value: "jdoe@gmail.com", IdentityIDs: []uuid.UUID{a, b}},
+		{Reason: identity.DuplicateReasonOIDCSubject, Value: "1234", IdentityIDs: []uuid.UUID{a, c}},
+		{Reason: identity.DuplicateReasonPhone, Value: "+4915112345678", IdentityIDs: []uuid.UUID{b, c}},
+	} {
+		assert.Equal(t, expected.Reason, actual[k].Reason, k)
+		assert.Equal(t, expected.Value, actual[k].Value, k)
+		assert.ElementsMatch(t, expected.IdentityIDs, actual[k].IdentityIDs, k)
+	}
+}
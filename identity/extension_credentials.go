@@ -2,24 +2,25 @@ package identity
 
 import (
 	"fmt"
-	"strings"
 	"sync"
 
 	"github.com/ory/go-convenience/stringslice"
 	"github.com/ory/jsonschema/v3"
 	"github.com/ory/x/sqlxx"
 
+	"github.com/ory/kratos/driver/config"
 	"github.com/ory/kratos/schema"
 )
 
 type SchemaExtensionCredentials struct {
 	i *Identity
+	n config.IdentifierNormalization
 	v []string
 	l sync.Mutex
 }
 
-func NewSchemaExtensionCredentials(i *Identity) *SchemaExtensionCredentials {
-	return &SchemaExtensionCredentials{i: i}
+func NewSchemaExtensionCredentials(i *Identity, n config.IdentifierNormalization) *SchemaExtensionCredentials {
+	return &SchemaExtensionCredentials{i: i, n: n}
 }
 
 func (r *SchemaExtensionCredentials) Run(_ jsonschema.ValidationContext, s schema.ExtensionConfig, value interface{}) error {
@@ -35,7 +36,7 @@ func (r *SchemaExtensionCredentials) Run(_ jsonschema.ValidationContext, s schem
 			}
 		}
 
-		r.v = stringslice.Unique(append(r.v, strings.ToLower(fmt.Sprintf("%s", value))))
+		r.v = stringslice.Unique(append(r.v, NormalizeIdentifier(r.n, fmt.Sprintf("%s", value))))
 		cred.Identifiers = r.v
 		r.i.SetCredentials(CredentialsTypePassword, *cred)
 	}
@@ -8,6 +8,7 @@ import (
 	"github.com/ory/jsonschema/v3"
 	_ "github.com/ory/jsonschema/v3/fileloader"
 
+	"github.com/ory/kratos/driver/config"
 	"github.com/ory/kratos/identity"
 	"github.com/ory/kratos/schema"
 
@@ -48,7 +49,7 @@ func TestSchemaExtensionCredentials(t *testing.T) {
 			require.NoError(t, err)
 
 			i := new(identity.Identity)
-			e := identity.NewSchemaExtensionCredentials(i)
+			e := identity.NewSchemaExtensionCredentials(i, config.IdentifierNormalization{Lowercase: true})
 			if tc.existing != nil {
 				i.SetCredentials(identity.CredentialsTypePassword, *tc.existing)
 			}
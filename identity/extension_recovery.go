@@ -6,6 +6,7 @@ import (
 
 	"github.com/ory/jsonschema/v3"
 
+	"github.com/ory/kratos/driver/config"
 	"github.com/ory/kratos/schema"
 )
 
@@ -13,10 +14,11 @@ type SchemaExtensionRecovery struct {
 	l sync.Mutex
 	v []RecoveryAddress
 	i *Identity
+	n config.IdentifierNormalization
 }
 
-func NewSchemaExtensionRecovery(i *Identity) *SchemaExtensionRecovery {
-	return &SchemaExtensionRecovery{i: i}
+func NewSchemaExtensionRecovery(i *Identity, n config.IdentifierNormalization) *SchemaExtensionRecovery {
+	return &SchemaExtensionRecovery{i: i, n: n}
 }
 
 func (r *SchemaExtensionRecovery) Run(ctx jsonschema.ValidationContext, s schema.ExtensionConfig, value interface{}) error {
@@ -29,7 +31,7 @@ func (r *SchemaExtensionRecovery) Run(ctx jsonschema.ValidationContext, s schema
 			return ctx.Error("format", "%q is not valid %q", value, "email")
 		}
 
-		address := NewRecoveryEmailAddress(fmt.Sprintf("%s", value), r.i.ID)
+		address := NewRecoveryEmailAddress(NormalizeIdentifier(r.n, fmt.Sprintf("%s", value)), r.i.ID)
 
 		if has := r.has(r.i.RecoveryAddresses, address); has != nil {
 			if r.has(r.v, address) == nil {
@@ -50,9 +52,12 @@ func (r *SchemaExtensionRecovery) Run(ctx jsonschema.ValidationContext, s schema
 	return ctx.Error("", "recovery.via has unknown value %q", s.Recovery.Via)
 }
 
+// has returns the address from the haystack which matches the needle. Addresses stored before the
+// normalization rules changed match as well and are returned with the normalized value.
 func (r *SchemaExtensionRecovery) has(haystack []RecoveryAddress, needle *RecoveryAddress) *RecoveryAddress {
 	for _, has := range haystack {
-		if has.Value == needle.Value && has.Via == needle.Via {
+		if NormalizeIdentifier(r.n, has.Value) == needle.Value && has.Via == needle.Via {
+			has.Value = needle.Value
 			return &has
 		}
 	}
@@ -10,6 +10,7 @@ import (
 	"github.com/ory/jsonschema/v3"
 	_ "github.com/ory/jsonschema/v3/fileloader"
 
+	"github.com/ory/kratos/driver/config"
 	"github.com/ory/kratos/schema"
 	"github.com/ory/kratos/x"
 
@@ -144,7 +145,7 @@ func TestSchemaExtensionRecovery(t *testing.T) {
 			runner, err := schema.NewExtensionRunner(schema.ExtensionRunnerIdentityMetaSchema)
 			require.NoError(t, err)
 
-			e := NewSchemaExtensionRecovery(id)
+			e := NewSchemaExtensionRecovery(id, config.IdentifierNormalization{Lowercase: true})
 			runner.AddRunner(e).Register(c)
 
 			err = c.MustCompile(tc.schema).Validate(bytes.NewBufferString(tc.doc))
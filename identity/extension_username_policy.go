@@ -0,0 +1,61 @@
+package identity
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"github.com/ory/jsonschema/v3"
+
+	"github.com/ory/kratos/driver/config"
+	"github.com/ory/kratos/schema"
+)
+
+// SchemaExtensionUsernamePolicy enforces the username policy for password identifiers which are not email
+// addresses.
+type SchemaExtensionUsernamePolicy struct {
+	p config.UsernamePolicy
+	n config.IdentifierNormalization
+}
+
+func NewSchemaExtensionUsernamePolicy(p config.UsernamePolicy, n config.IdentifierNormalization) *SchemaExtensionUsernamePolicy {
+	return &SchemaExtensionUsernamePolicy{p: p, n: n}
+}
+
+func (r *SchemaExtensionUsernamePolicy) Run(ctx jsonschema.ValidationContext, s schema.ExtensionConfig, value interface{}) error {
+	if !s.Credentials.Password.Identifier || jsonschema.Formats["email"](value) {
+		return nil
+	}
+
+	username := NormalizeIdentifier(r.n, fmt.Sprintf("%s", value))
+	for _, reserved := range r.p.Reserved {
+		if strings.EqualFold(username, reserved) {
+			return ctx.Error("username", "the username %q is reserved", username)
+		}
+	}
+
+	if r.p.Pattern != "" {
+		pattern, err := regexp.Compile(r.p.Pattern)
+		if err != nil {
+			return errors.WithStack(err)
+		}
+
+		if !pattern.MatchString(username) {
+			return ctx.Error("username", "the username %q does not match the pattern %q", username, r.p.Pattern)
+		}
+	}
+
+	for _, word := range r.p.BlockedWords {
+		if word != "" && strings.Contains(strings.ToLower(username), strings.ToLower(word)) {
+			return ctx.Error("username", "the username %q contains a word which is not allowed", username)
+		}
+	}
+
+	return nil
+}
+
+func (r *SchemaExtensionUsernamePolicy) Finish() error {
+	return nil
+}
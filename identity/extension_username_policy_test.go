@@ -0,0 +1,55 @@
+package identity_test
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+
+	"github.com/ory/jsonschema/v3"
+	_ "github.com/ory/jsonschema/v3/fileloader"
+
+	"github.com/ory/kratos/driver/config"
+	"github.com/ory/kratos/identity"
+	"github.com/ory/kratos/schema"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSchemaExtensionUsernamePolicy(t *testing.T) {
+	policy := config.UsernamePolicy{
+		Reserved:     []string{"admin", "Support"},
+		Pattern:      "^[a-z0-9_.-]{3,16}$",
+		BlockedWords: []string{"darn"},
+	}
+
+	for k, tc := range []struct {
+		doc       string
+		expectErr string
+	}{
+		{doc: `{"emails":["admin@ory.sh"], "username": "foobar"}`},
+		{doc: `{"username": "FooBar"}`},
+		{doc: `{"username": "Admin"}`, expectErr: `the username "admin" is reserved`},
+		{doc: `{"username": "support"}`, expectErr: `the username "support" is reserved`},
+		{doc: `{"username": "fo"}`, expectErr: `the username "fo" does not match the pattern "^[a-z0-9_.-]{3,16}$"`},
+		{doc: `{"username": "foo bar"}`, expectErr: `the username "foo bar" does not match the pattern "^[a-z0-9_.-]{3,16}$"`},
+		{doc: `{"username": "xDARNx"}`, expectErr: `the username "xdarnx" contains a word which is not allowed`},
+	} {
+		t.Run(fmt.Sprintf("case=%d", k), func(t *testing.T) {
+			c := jsonschema.NewCompiler()
+			runner, err := schema.NewExtensionRunner(schema.ExtensionRunnerIdentityMetaSchema)
+			require.NoError(t, err)
+
+			e := identity.NewSchemaExtensionUsernamePolicy(policy, config.IdentifierNormalization{Lowercase: true})
+			runner.AddRunner(e).Register(c)
+
+			err = c.MustCompile("file://./stub/extension/credentials/multi.schema.json").Validate(bytes.NewBufferString(tc.doc))
+			if tc.expectErr != "" {
+				require.EqualError(t, err, "I[#/username] S[#/properties/username/username] "+tc.expectErr)
+				return
+			}
+
+			require.NoError(t, err)
+			require.NoError(t, e.Finish())
+		})
+	}
+}
@@ -7,6 +7,7 @@ import (
 
 	"github.com/ory/jsonschema/v3"
 
+	"github.com/ory/kratos/driver/config"
 	"github.com/ory/kratos/schema"
 )
 
@@ -15,10 +16,11 @@ type SchemaExtensionVerification struct {
 	l        sync.Mutex
 	v        []VerifiableAddress
 	i        *Identity
+	n        config.IdentifierNormalization
 }
 
-func NewSchemaExtensionVerification(i *Identity, lifespan time.Duration) *SchemaExtensionVerification {
-	return &SchemaExtensionVerification{i: i, lifespan: lifespan}
+func NewSchemaExtensionVerification(i *Identity, lifespan time.Duration, n config.IdentifierNormalization) *SchemaExtensionVerification {
+	return &SchemaExtensionVerification{i: i, lifespan: lifespan, n: n}
 }
 
 func (r *SchemaExtensionVerification) Run(ctx jsonschema.ValidationContext, s schema.ExtensionConfig, value interface{}) error {
@@ -31,7 +33,7 @@ func (r *SchemaExtensionVerification) Run(ctx jsonschema.ValidationContext, s sc
 			return ctx.Error("format", "%q is not valid %q", value, "email")
 		}
 
-		address := NewVerifiableEmailAddress(fmt.Sprintf("%s", value), r.i.ID)
+		address := NewVerifiableEmailAddress(NormalizeIdentifier(r.n, fmt.Sprintf("%s", value)), r.i.ID)
 
 		if has := r.has(r.i.VerifiableAddresses, address); has != nil {
 			if r.has(r.v, address) == nil {
@@ -52,9 +54,12 @@ func (r *SchemaExtensionVerification) Run(ctx jsonschema.ValidationContext, s sc
 	return ctx.Error("", "verification.via has unknown value %q", s.Verification.Via)
 }
 
+// has returns the address from the haystack which matches the needle. Addresses stored before the
+// normalization rules changed match as well and are returned with the normalized value.
 func (r *SchemaExtensionVerification) has(haystack []VerifiableAddress, needle *VerifiableAddress) *VerifiableAddress {
 	for _, has := range haystack {
-		if has.Value == needle.Value && has.Via == needle.Via {
+		if NormalizeIdentifier(r.n, has.Value) == needle.Value && has.Via == needle.Via {
+			has.Value = needle.Value
 			return &has
 		}
 	}
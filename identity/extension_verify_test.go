@@ -11,6 +11,7 @@ import (
 	"github.com/ory/jsonschema/v3"
 	_ "github.com/ory/jsonschema/v3/fileloader"
 
+	"github.com/ory/kratos/driver/config"
 	"github.com/ory/kratos/schema"
 	"github.com/ory/kratos/x"
 
@@ -174,7 +175,7 @@ func TestSchemaExtensionVerification(t *testing.T) {
 			require.NoError(t, err)
 
 			const expiresAt = time.Minute
-			e := NewSchemaExtensionVerification(id, time.Minute)
+			e := NewSchemaExtensionVerification(id, time.Minute, config.IdentifierNormalization{Lowercase: true})
 			runner.AddRunner(e).Register(c)
 
 			err = c.MustCompile(tc.schema).Validate(bytes.NewBufferString(tc.doc))
@@ -3,25 +3,39 @@ package identity
 import (
 	"encoding/json"
 	"net/http"
+	"net/url"
+	"time"
 
 	"github.com/ory/kratos/driver/config"
+	"github.com/ory/kratos/hash"
+	"github.com/ory/kratos/idempotency"
 
+	"github.com/gofrs/uuid"
 	"github.com/julienschmidt/httprouter"
 	"github.com/pkg/errors"
 
+	"github.com/ory/herodot"
 	"github.com/ory/x/jsonx"
 	"github.com/ory/x/urlx"
 
 	"github.com/ory/kratos/x"
 )
 
-const RouteBase = "/identities"
+const (
+	RouteBase       = "/identities"
+	RouteDuplicates = "/duplicate-identities"
+	RouteDormant    = "/dormant-identities"
+	RouteDeleted    = "/deleted-identities"
+)
 
 type (
 	handlerDependencies interface {
 		PoolProvider
 		PrivilegedPoolProvider
 		ManagementProvider
+		DormancyEnforcerProvider
+		idempotency.HandlerProvider
+		x.ClockProvider
 		x.WriterProvider
 		config.Provider
 	}
@@ -42,8 +56,13 @@ func (h *Handler) RegisterAdminRoutes(admin *x.RouterAdmin) {
 	admin.GET(RouteBase+"/:id", h.get)
 	admin.DELETE(RouteBase+"/:id", h.delete)
 
-	admin.POST(RouteBase, h.create)
+	admin.POST(RouteBase, h.r.IdempotencyHandler().Wrap(h.create))
 	admin.PUT(RouteBase+"/:id", h.update)
+	admin.POST(RouteBase+"/:id/merge", h.merge)
+
+	admin.GET(RouteDuplicates, h.duplicates)
+	admin.GET(RouteDormant, h.dormant)
+	admin.GET(RouteDeleted, h.deleted)
 }
 
 // A single identity.
@@ -87,13 +106,38 @@ type listIdentityParameters struct {
 	// default: 0
 	// min: 0
 	Page int `json:"page"`
+
+	// Only list identities which did not log in since this time (RFC 3339). Identities which never logged in
+	// are listed if they were created before this time.
+	//
+	// required: false
+	// in: query
+	// format: date-time
+	LastLoginBefore string `json:"last_login_before"`
+
+	// Only list identities which were not seen using `/sessions/whoami` since this time (RFC 3339). Identities
+	// which were never seen are listed if they were created before this time.
+	//
+	// required: false
+	// in: query
+	// format: date-time
+	LastSeenBefore string `json:"last_seen_before"`
+
+	// Only list identities which did not change their password since this time (RFC 3339). Identities which
+	// never set a password are listed if they were created before this time.
+	//
+	// required: false
+	// in: query
+	// format: date-time
+	PasswordChangedBefore string `json:"password_changed_before"`
 }
 
 // swagger:route GET /identities admin listIdentities
 //
 // List Identities
 //
-// Lists all identities. Does not support search at the moment.
+// Lists all identities. Does not support search at the moment. Dormant identities can be listed by filtering
+// by the time they last logged in, were last seen, or last changed their password.
 //
 // Learn how identities work in [ORY Kratos' User And Identity Model Documentation](https://www.ory.sh/docs/next/kratos/concepts/identity-user-model).
 //
@@ -104,25 +148,186 @@ type listIdentityParameters struct {
 //
 //     Responses:
 //       200: identityList
+//       400: genericError
 //       500: genericError
 func (h *Handler) list(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+	f, filterQuery, err := parseActivityFilter(r)
+	if err != nil {
+		h.r.Writer().WriteError(w, r, err)
+		return
+	}
+
 	page, itemsPerPage := x.ParsePagination(r)
-	is, err := h.r.IdentityPool().ListIdentities(r.Context(), page, itemsPerPage)
+	is, err := h.r.IdentityPool().ListIdentitiesByActivity(r.Context(), f, page, itemsPerPage)
 	if err != nil {
 		h.r.Writer().WriteError(w, r, err)
 		return
 	}
 
-	total, err := h.r.IdentityPool().CountIdentities(r.Context())
+	total, err := h.r.IdentityPool().CountIdentitiesByActivity(r.Context(), f)
 	if err != nil {
 		h.r.Writer().WriteError(w, r, err)
 		return
 	}
 
-	x.PaginationHeader(w, urlx.AppendPaths(h.r.Config(r.Context()).SelfAdminURL(), RouteBase), total, page, itemsPerPage)
+	x.PaginationHeader(w, urlx.CopyWithQuery(urlx.AppendPaths(h.r.Config(r.Context()).SelfAdminURL(), RouteBase), filterQuery), total, page, itemsPerPage)
 	h.r.Writer().Write(w, r, is)
 }
 
+// parseActivityFilter parses the activity filter of the list request. The filter is also returned as query
+// parameters so that pagination links keep it.
+func parseActivityFilter(r *http.Request) (ActivityFilter, url.Values, error) {
+	var f ActivityFilter
+	query := url.Values{}
+	for _, p := range []struct {
+		name   string
+		target *time.Time
+	}{
+		{name: "last_login_before", target: &f.LastLoginBefore},
+		{name: "last_seen_before", target: &f.LastSeenBefore},
+		{name: "password_changed_before", target: &f.PasswordChangedBefore},
+	} {
+		value := r.URL.Query().Get(p.name)
+		if len(value) == 0 {
+			continue
+		}
+
+		t, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			return f, nil, errors.WithStack(herodot.ErrBadRequest.WithReasonf("Query parameter %s must be a RFC 3339 timestamp: %s", p.name, err))
+		}
+
+		*p.target = t
+		query.Set(p.name, value)
+	}
+
+	return f, query, nil
+}
+
+// A list of likely duplicate identities.
+// swagger:response duplicateIdentitiesList
+// nolint:deadcode,unused
+type duplicateIdentitiesListResponse struct {
+	// in: body
+	// required: true
+	// type: array
+	Body []DuplicateIdentities
+}
+
+// swagger:route GET /duplicate-identities admin listDuplicateIdentities
+//
+// List Likely Duplicate Identities
+//
+// Lists groups of identities which likely belong to the same person because they share an email address (after
+// normalizing case, whitespace, and Gmail aliases), a phone number, or an OpenID Connect subject, regardless of
+// their identity schema. Use this report to merge or delete duplicate identities.
+//
+//     Produces:
+//     - application/json
+//
+//     Schemes: http, https
+//
+//     Responses:
+//       200: duplicateIdentitiesList
+//       500: genericError
+func (h *Handler) duplicates(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+	identifiers, err := h.r.PrivilegedIdentityPool().ListIdentifiers(r.Context())
+	if err != nil {
+		h.r.Writer().WriteError(w, r, err)
+		return
+	}
+
+	h.r.Writer().Write(w, r, FindDuplicateIdentities(identifiers))
+}
+
+// The identities the dormancy policy applies to.
+// swagger:response dormancyReport
+// nolint:deadcode,unused
+type dormancyReportResponse struct {
+	// in: body
+	// required: true
+	Body DormancyReport
+}
+
+// swagger:route GET /dormant-identities admin getDormancyReport
+//
+// Report Dormant Identities
+//
+// Reports which identities would be notified, deactivated, or deleted if the dormancy policy configured in
+// `identity.dormancy` was enforced now. This endpoint never changes any identity.
+//
+//     Produces:
+//     - application/json
+//
+//     Schemes: http, https
+//
+//     Responses:
+//       200: dormancyReport
+//       500: genericError
+func (h *Handler) dormant(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+	report, err := h.r.IdentityDormancyEnforcer().Enforce(r.Context(), true)
+	if err != nil {
+		h.r.Writer().WriteError(w, r, err)
+		return
+	}
+
+	h.r.Writer().Write(w, r, report)
+}
+
+// swagger:parameters listDeletedIdentities
+// nolint:deadcode,unused
+type listDeletedIdentitiesParameters struct {
+	// Identifier is an identifier or address, for example an email address, of a deleted identity.
+	//
+	// required: true
+	// in: query
+	Identifier string `json:"identifier"`
+}
+
+// A list of anonymized stubs of deleted identities.
+// swagger:response anonymizedIdentityStubList
+// nolint:deadcode,unused
+type anonymizedIdentityStubListResponse struct {
+	// in: body
+	// required: true
+	// type: array
+	Body []AnonymizedStub
+}
+
+// swagger:route GET /deleted-identities admin listDeletedIdentities
+//
+// List Deleted Identities by Identifier
+//
+// Lists the retained anonymized stubs of deleted identities which used the given identifier. Stubs are only
+// retained if `identity.deletion.retain_anonymized` is enabled.
+//
+//     Produces:
+//     - application/json
+//
+//     Schemes: http, https
+//
+//     Responses:
+//       200: anonymizedIdentityStubList
+//       400: genericError
+//       500: genericError
+func (h *Handler) deleted(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+	identifier := r.URL.Query().Get("identifier")
+	if len(identifier) == 0 {
+		h.r.Writer().WriteError(w, r, errors.WithStack(herodot.ErrBadRequest.WithReason("Query parameter identifier must be set.")))
+		return
+	}
+
+	stubs, err := h.r.PrivilegedIdentityPool().FindAnonymizedStubs(r.Context(),
+		HashIdentifiers(h.r.Config(r.Context()).SecretsDefault(), NormalizeIdentifier(h.r.Config(r.Context()).IdentifierNormalization(), identifier)),
+		h.r.Clock().Now().UTC())
+	if err != nil {
+		h.r.Writer().WriteError(w, r, err)
+		return
+	}
+
+	h.r.Writer().Write(w, r, stubs)
+}
+
 // swagger:parameters getIdentity
 // nolint:deadcode,unused
 type getIdentityParameters struct {
@@ -165,6 +370,14 @@ func (h *Handler) get(w http.ResponseWriter, r *http.Request, ps httprouter.Para
 // swagger:parameters createIdentity
 // nolint:deadcode,unused
 type createIdentityParameters struct {
+	// Idempotency Key
+	//
+	// If set, retries of the request with the same key and body receive the response of the first request
+	// instead of being executed again.
+	//
+	// in: header
+	IdempotencyKey string `json:"Idempotency-Key"`
+
 	// in: body
 	Body CreateIdentity
 }
@@ -183,14 +396,48 @@ type CreateIdentity struct {
 	// required: true
 	// in: body
 	Traits json.RawMessage `json:"traits"`
+
+	// CommunicationPreferences are consulted by the courier before messages are sent to this identity.
+	//
+	// in: body
+	CommunicationPreferences *CommunicationPreferences `json:"communication_preferences,omitempty"`
+
+	// Credentials imports the identity's credentials.
+	//
+	// in: body
+	Credentials *CreateIdentityCredentials `json:"credentials,omitempty"`
+}
+
+// CreateIdentityCredentials are the credentials imported together with an identity.
+type CreateIdentityCredentials struct {
+	// Password imports the identity's password credentials.
+	Password *CreateIdentityPasswordCredentials `json:"password,omitempty"`
+}
+
+// CreateIdentityPasswordCredentials are the password credentials imported together with an identity.
+type CreateIdentityPasswordCredentials struct {
+	// HashedPassword imports the identity's password hash. Supported are Argon2id hashes in the format
+	// `$argon2id$v=19$m=<memory>,t=<iterations>,p=<parallelism>$<salt>$<hash>`, scrypt hashes in the format
+	// `$scrypt$ln=<log2(N)>,r=<block size>,p=<parallelism>$<salt>$<hash>`, hashes exported from Firebase
+	// in the format `$firescrypt$ln=<mem_cost>,r=<rounds>,p=1$<salt>$<hash>$<salt separator>$<signer key>`, and
+	// PBKDF2 hashes in the format `$pbkdf2-<sha1|sha256|sha512>$i=<iterations>$<salt>$<hash>` or the format used by
+	// Django. Salts, hashes, and keys are base64 encoded. scrypt and PBKDF2 hashes are replaced by Argon2id hashes
+	// on the first login.
+	HashedPassword string `json:"hashed_password,omitempty"`
+
+	// UseExternalCheck marks identities migrated from external systems. Their password is verified by
+	// the password migration hook on the first login and stored by ORY Kratos afterwards.
+	UseExternalCheck bool `json:"use_external_check"`
 }
 
 // swagger:route POST /identities admin createIdentity
 //
 // Create an Identity
 //
-// This endpoint creates an identity. It is NOT possible to set an identity's credentials (password, ...)
-// using this method! A way to achieve that will be introduced in the future.
+// This endpoint creates an identity. Password credentials can be imported using `credentials.password.hashed_password`
+// (Argon2id, scrypt, Firebase scrypt, and PBKDF2 hashes are supported). Identities migrated from external systems can instead
+// be marked with `credentials.password.use_external_check` to have their password verified by the password migration
+// hook on the first login.
 //
 // Learn how identities work in [ORY Kratos' User And Identity Model Documentation](https://www.ory.sh/docs/next/kratos/concepts/identity-user-model).
 //
@@ -214,7 +461,33 @@ func (h *Handler) create(w http.ResponseWriter, r *http.Request, _ httprouter.Pa
 		return
 	}
 
-	i := &Identity{SchemaID: cr.SchemaID, Traits: []byte(cr.Traits)}
+	if cr.CommunicationPreferences != nil {
+		if err := cr.CommunicationPreferences.Validate(); err != nil {
+			h.r.Writer().WriteError(w, r, err)
+			return
+		}
+	}
+
+	i := &Identity{SchemaID: cr.SchemaID, Traits: []byte(cr.Traits), CommunicationPreferences: cr.CommunicationPreferences}
+	if cr.Credentials != nil && cr.Credentials.Password != nil {
+		if pw := cr.Credentials.Password; len(pw.HashedPassword) > 0 && pw.UseExternalCheck {
+			h.r.Writer().WriteError(w, r, errors.WithStack(herodot.ErrBadRequest.WithReasonf("Either hashed_password or use_external_check may be set but not both.")))
+			return
+		} else if len(pw.HashedPassword) > 0 {
+			if err := hash.ValidateHash([]byte(pw.HashedPassword)); err != nil {
+				h.r.Writer().WriteError(w, r, errors.WithStack(herodot.ErrBadRequest.WithReasonf("The imported password hash is not supported: %s", err)))
+				return
+			}
+		}
+
+		co, err := json.Marshal(cr.Credentials.Password)
+		if err != nil {
+			h.r.Writer().WriteError(w, r, errors.WithStack(err))
+			return
+		}
+
+		i.SetCredentials(CredentialsTypePassword, Credentials{Type: CredentialsTypePassword, Identifiers: []string{}, Config: co})
+	}
 	if err := h.r.IdentityManager().Create(r.Context(), i); err != nil {
 		h.r.Writer().WriteError(w, r, err)
 		return
@@ -253,6 +526,14 @@ type UpdateIdentity struct {
 	//
 	// required: true
 	Traits json.RawMessage `json:"traits"`
+
+	// CommunicationPreferences are consulted by the courier before messages are sent to this identity. If set
+	// will update the Identity's CommunicationPreferences.
+	CommunicationPreferences *CommunicationPreferences `json:"communication_preferences,omitempty"`
+
+	// State is the identity's state. If set will update the Identity's State. Inactive identities can not
+	// sign in.
+	State State `json:"state,omitempty"`
 }
 
 // swagger:route PUT /identities/{id} admin updateIdentity
@@ -297,6 +578,24 @@ func (h *Handler) update(w http.ResponseWriter, r *http.Request, ps httprouter.P
 		identity.SchemaID = ur.SchemaID
 	}
 
+	if ur.CommunicationPreferences != nil {
+		if err := ur.CommunicationPreferences.Validate(); err != nil {
+			h.r.Writer().WriteError(w, r, err)
+			return
+		}
+		identity.CommunicationPreferences = ur.CommunicationPreferences
+	}
+
+	if ur.State != "" && ur.State != identity.State {
+		if err := ur.State.Valid(); err != nil {
+			h.r.Writer().WriteError(w, r, err)
+			return
+		}
+		now := h.r.Clock().Now().UTC()
+		identity.State = ur.State
+		identity.StateChangedAt = &now
+	}
+
 	identity.Traits = []byte(ur.Traits)
 	if err := h.r.IdentityManager().Update(
 		r.Context(),
@@ -310,6 +609,69 @@ func (h *Handler) update(w http.ResponseWriter, r *http.Request, ps httprouter.P
 	h.r.Writer().Write(w, r, identity)
 }
 
+// swagger:parameters mergeIdentities
+// nolint:deadcode,unused
+type mergeIdentitiesParameters struct {
+	// ID must be set to the ID of the primary identity which the other identity is merged into.
+	//
+	// required: true
+	// in: path
+	ID string `json:"id"`
+	// in: body
+	Body MergeIdentities
+}
+
+type MergeIdentities struct {
+	// IdentityID is the ID of the secondary identity which is merged into the primary identity and deleted.
+	//
+	// required: true
+	IdentityID uuid.UUID `json:"identity_id"`
+}
+
+// swagger:route POST /identities/{id}/merge admin mergeIdentities
+//
+// Merge two Identities
+//
+// This endpoint merges the credentials, verifiable and recovery addresses, sessions, and metadata of the secondary
+// identity into the primary identity and deletes the secondary identity. Traits, credentials, and metadata of the
+// primary identity take precedence. This action can not be undone.
+//
+// Learn how identities work in [ORY Kratos' User And Identity Model Documentation](https://www.ory.sh/docs/next/kratos/concepts/identity-user-model).
+//
+//     Consumes:
+//     - application/json
+//
+//     Produces:
+//     - application/json
+//
+//     Schemes: http, https
+//
+//     Responses:
+//       200: identityResponse
+//       400: genericError
+//       404: genericError
+//       500: genericError
+func (h *Handler) merge(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	var mr MergeIdentities
+	if err := errors.WithStack(jsonx.NewStrictDecoder(r.Body).Decode(&mr)); err != nil {
+		h.r.Writer().WriteError(w, r, err)
+		return
+	}
+
+	identity, err := h.r.IdentityManager().Merge(
+		r.Context(),
+		x.ParseUUID(ps.ByName("id")),
+		mr.IdentityID,
+		ManagerAllowWriteProtectedTraits,
+	)
+	if err != nil {
+		h.r.Writer().WriteError(w, r, err)
+		return
+	}
+
+	h.r.Writer().Write(w, r, identity)
+}
+
 // swagger:parameters deleteIdentity
 // nolint:deadcode,unused
 type deleteIdentityParameters struct {
@@ -326,7 +688,8 @@ type deleteIdentityParameters struct {
 //
 // Calling this endpoint irrecoverably and permanently deletes the identity given its ID. This action can not be undone.
 // This endpoint returns 204 when the identity was deleted or when the identity was not found, in which case it is
-// assumed that is has been deleted already.
+// assumed that is has been deleted already. If `identity.deletion.retain_anonymized` is enabled, anonymized stubs
+// of the identity's identifiers are retained.
 //
 // Learn how identities work in [ORY Kratos' User And Identity Model Documentation](https://www.ory.sh/docs/next/kratos/concepts/identity-user-model).
 //
@@ -340,7 +703,7 @@ type deleteIdentityParameters struct {
 //		 404: genericError
 //       500: genericError
 func (h *Handler) delete(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
-	if err := h.r.IdentityPool().(PrivilegedPool).DeleteIdentity(r.Context(), x.ParseUUID(ps.ByName("id"))); err != nil {
+	if err := h.r.IdentityManager().Delete(r.Context(), x.ParseUUID(ps.ByName("id"))); err != nil {
 		h.r.Writer().WriteError(w, r, err)
 		return
 	}
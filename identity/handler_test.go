@@ -2,11 +2,15 @@ package identity_test
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
+	"fmt"
 	"io/ioutil"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
 	"testing"
+	"time"
 
 	"github.com/ory/x/urlx"
 
@@ -232,6 +236,94 @@ func TestHandler(t *testing.T) {
 		assert.EqualValues(t, "ory street", res.Get("traits.address").String(), "%s", res.Raw)
 	})
 
+	t.Run("case=should create and update communication preferences", func(t *testing.T) {
+		var cr identity.CreateIdentity
+		cr.SchemaID = "employee"
+		cr.Traits = []byte(`{"email":"` + x.NewUUID().String() + `@ory.sh"}`)
+		cr.CommunicationPreferences = &identity.CommunicationPreferences{NoMarketing: true, PreferredLocale: "de-CH"}
+		res := send(t, "POST", "/identities", http.StatusCreated, &cr)
+		assert.True(t, res.Get("communication_preferences.no_marketing").Bool(), "%s", res.Raw)
+		assert.EqualValues(t, "de-CH", res.Get("communication_preferences.preferred_locale").String(), "%s", res.Raw)
+
+		id := res.Get("id").String()
+		res = get(t, "/identities/"+id, http.StatusOK)
+		assert.True(t, res.Get("communication_preferences.no_marketing").Bool(), "%s", res.Raw)
+		assert.EqualValues(t, "de-CH", res.Get("communication_preferences.preferred_locale").String(), "%s", res.Raw)
+
+		res = send(t, "PUT", "/identities/"+id, http.StatusOK, &identity.UpdateIdentity{
+			Traits:                   cr.Traits,
+			CommunicationPreferences: &identity.CommunicationPreferences{PreferredLocale: "en"},
+		})
+		assert.False(t, res.Get("communication_preferences.no_marketing").Bool(), "%s", res.Raw)
+		assert.EqualValues(t, "en", res.Get("communication_preferences.preferred_locale").String(), "%s", res.Raw)
+
+		res = send(t, "PUT", "/identities/"+id, http.StatusOK, &identity.UpdateIdentity{Traits: cr.Traits})
+		assert.EqualValues(t, "en", res.Get("communication_preferences.preferred_locale").String(), "unset preferences must not be reset: %s", res.Raw)
+
+		res = get(t, "/identities/"+id, http.StatusOK)
+		assert.EqualValues(t, "en", res.Get("communication_preferences.preferred_locale").String(), "%s", res.Raw)
+	})
+
+	t.Run("case=should reject invalid communication preferences", func(t *testing.T) {
+		var cr identity.CreateIdentity
+		cr.SchemaID = "employee"
+		cr.Traits = []byte(`{"email":"` + x.NewUUID().String() + `@ory.sh"}`)
+		cr.CommunicationPreferences = &identity.CommunicationPreferences{PreferredLocale: "../../etc/passwd"}
+		res := send(t, "POST", "/identities", http.StatusBadRequest, &cr)
+		assert.Contains(t, res.Get("error.reason").String(), "BCP 47", "%s", res.Raw)
+
+		cr.CommunicationPreferences = nil
+		res = send(t, "POST", "/identities", http.StatusCreated, &cr)
+		assert.False(t, res.Get("communication_preferences").Exists(), "%s", res.Raw)
+
+		res = send(t, "PUT", "/identities/"+res.Get("id").String(), http.StatusBadRequest, &identity.UpdateIdentity{
+			Traits:                   cr.Traits,
+			CommunicationPreferences: &identity.CommunicationPreferences{PreferredLocale: `de\CH`},
+		})
+		assert.Contains(t, res.Get("error.reason").String(), "BCP 47", "%s", res.Raw)
+	})
+
+	t.Run("case=should import password credentials", func(t *testing.T) {
+		const firebaseScryptHash = "$firescrypt$ln=14,r=8,p=1$42xEC+ixf3L2lw==$lSrfV15cpx95/sZS2W9c9Kp6i/LVgQNDNC/qzrCnh1SAyZvqmZqAjTdn3aoItz+VHjoZilo78198JAdRuid5lQ==$Bw==$jxspr8Ki0RYycVU8zykbdLGjFQ3McFUH0uiiTvC8pVMXAn210wjLNmdZJzxUECKbm0QsEmYUSDzZvpjeJ9WmXA=="
+
+		for k, tc := range []struct {
+			pw     identity.CreateIdentityPasswordCredentials
+			expect string
+		}{
+			{pw: identity.CreateIdentityPasswordCredentials{HashedPassword: firebaseScryptHash}, expect: "hashed_password"},
+			{pw: identity.CreateIdentityPasswordCredentials{HashedPassword: "pbkdf2_sha256$1000$seasalt$YIWkt6M1JFXrHg5s0jZjBSc7C2Cz6QvchSJ0h8Y+i7c="}, expect: "hashed_password"},
+			{pw: identity.CreateIdentityPasswordCredentials{UseExternalCheck: true}, expect: "use_external_check"},
+		} {
+			t.Run(fmt.Sprintf("case=%d", k), func(t *testing.T) {
+				pw := tc.pw
+				res := send(t, "POST", "/identities", http.StatusCreated, &identity.CreateIdentity{
+					Traits:      []byte(`{"bar":"baz"}`),
+					Credentials: &identity.CreateIdentityCredentials{Password: &pw},
+				})
+				assert.False(t, res.Get("credentials").Exists(), "credentials must never be returned: %s", res.Raw)
+
+				i, err := reg.PrivilegedIdentityPool().GetIdentityConfidential(context.Background(), x.ParseUUID(res.Get("id").String()))
+				require.NoError(t, err)
+				c, ok := i.GetCredentials(identity.CredentialsTypePassword)
+				require.True(t, ok)
+				assert.True(t, gjson.GetBytes(c.Config, tc.expect).Exists(), "%s", c.Config)
+			})
+		}
+
+		t.Run("case=should reject unsupported hashes", func(t *testing.T) {
+			for _, pw := range []identity.CreateIdentityPasswordCredentials{
+				{HashedPassword: "$md5$not-supported"},
+				{HashedPassword: firebaseScryptHash, UseExternalCheck: true},
+			} {
+				pw := pw
+				send(t, "POST", "/identities", http.StatusBadRequest, &identity.CreateIdentity{
+					Traits:      []byte(`{"bar":"baz"}`),
+					Credentials: &identity.CreateIdentityCredentials{Password: &pw},
+				})
+			}
+		})
+	})
+
 	t.Run("case=should be able to update multiple identities", func(t *testing.T) {
 		for i := 0; i <= 5; i++ {
 			var cr identity.CreateIdentity
@@ -258,6 +350,25 @@ func TestHandler(t *testing.T) {
 		assert.EqualValues(t, "baz", res.Get(`#(traits.bar=="baz").traits.bar`).String(), "%s", res.Raw)
 	})
 
+	t.Run("case=should list identities by activity", func(t *testing.T) {
+		active := identity.NewIdentity("")
+		active.Traits = identity.Traits(`{"bar":"active"}`)
+		require.NoError(t, reg.PrivilegedIdentityPool().CreateIdentity(context.Background(), active))
+		require.NoError(t, reg.PrivilegedIdentityPool().RecordIdentityActivity(context.Background(), active.ID, identity.ActivityLogin, time.Now().Add(time.Hour)))
+
+		before := url.QueryEscape(time.Now().Add(time.Minute).UTC().Format(time.RFC3339))
+		res := get(t, "/identities?per_page=1000&last_login_before="+before, http.StatusOK)
+		assert.EqualValues(t, "baz", res.Get(`#(traits.bar=="baz").traits.bar`).String(), "%s", res.Raw)
+		assert.False(t, res.Get(`#(traits.bar=="active")`).Exists(), "%s", res.Raw)
+
+		res = get(t, "/identities?per_page=1000", http.StatusOK)
+		assert.EqualValues(t, "active", res.Get(`#(traits.bar=="active").traits.bar`).String(), "%s", res.Raw)
+		assert.NotEmpty(t, res.Get(`#(traits.bar=="active").last_login_at`).String(), "%s", res.Raw)
+
+		res = get(t, "/identities?last_seen_before=yesterday", http.StatusBadRequest)
+		assert.Contains(t, res.Get("error.reason").String(), "last_seen_before", "%s", res.Raw)
+	})
+
 	t.Run("case=should not be able to update an identity that does not exist yet", func(t *testing.T) {
 		res := send(t, "PUT", "/identities/not-found", http.StatusNotFound, json.RawMessage(`{"traits": {"bar":"baz"}}`))
 		assert.Contains(t, res.Get("error.message").String(), "Unable to locate the resource", "%s", res.Raw)
@@ -266,4 +377,27 @@ func TestHandler(t *testing.T) {
 	t.Run("case=should return 404 for non-existing identities", func(t *testing.T) {
 		remove(t, "/identities/"+x.NewUUID().String(), http.StatusNotFound)
 	})
+
+	t.Run("case=should list duplicate identities", func(t *testing.T) {
+		var ids []string
+		for _, identifier := range []string{"dup.licate+a@gmail.com", "duplicate@gmail.com"} {
+			i := identity.NewIdentity("")
+			i.SetCredentials(identity.CredentialsTypePassword, identity.Credentials{
+				Type: identity.CredentialsTypePassword, Identifiers: []string{identifier}, Config: []byte(`{}`),
+			})
+			require.NoError(t, reg.PrivilegedIdentityPool().CreateIdentity(context.Background(), i))
+			ids = append(ids, i.ID.String())
+		}
+
+		res := get(t, "/duplicate-identities", http.StatusOK)
+		group := res.Get(`#(value=="duplicate@gmail.com")`)
+		require.True(t, group.Exists(), "%s", res.Raw)
+		assert.Equal(t, "email", group.Get("reason").String(), "%s", res.Raw)
+
+		var actual []string
+		for _, id := range group.Get("identity_ids").Array() {
+			actual = append(actual, id.String())
+		}
+		assert.ElementsMatch(t, ids, actual)
+	})
 }
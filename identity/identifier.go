@@ -0,0 +1,45 @@
+package identity
+
+import (
+	"strings"
+
+	"github.com/ory/kratos/driver/config"
+)
+
+// NormalizeIdentifier applies the configured normalization rules to a login identifier or an address so that
+// values which only differ in e.g. case belong to the same identity.
+func NormalizeIdentifier(n config.IdentifierNormalization, value string) string {
+	if n.Trim {
+		value = strings.TrimSpace(value)
+	}
+
+	if n.Lowercase {
+		value = strings.ToLower(value)
+	}
+
+	if n.StripGmailAliases {
+		value = stripGmailAliases(value)
+	}
+
+	return value
+}
+
+// stripGmailAliases removes the dots and the `+` suffix from the local part of Gmail addresses because Gmail
+// delivers all of these variants to the same inbox.
+func stripGmailAliases(value string) string {
+	at := strings.LastIndex(value, "@")
+	if at < 0 {
+		return value
+	}
+
+	local, domain := value[:at], value[at+1:]
+	if !strings.EqualFold(domain, "gmail.com") && !strings.EqualFold(domain, "googlemail.com") {
+		return value
+	}
+
+	if plus := strings.Index(local, "+"); plus >= 0 {
+		local = local[:plus]
+	}
+
+	return strings.ReplaceAll(local, ".", "") + "@" + domain
+}
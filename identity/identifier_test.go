@@ -0,0 +1,37 @@
+package identity
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/ory/kratos/driver/config"
+)
+
+func TestNormalizeIdentifier(t *testing.T) {
+	for k, tc := range []struct {
+		n        config.IdentifierNormalization
+		in       string
+		expected string
+	}{
+		{in: " Foo@Ory.sh ", expected: " Foo@Ory.sh "},
+		{n: config.IdentifierNormalization{Lowercase: true}, in: "Foo@Ory.sh", expected: "foo@ory.sh"},
+		{n: config.IdentifierNormalization{Trim: true}, in: " FooBar\t", expected: "FooBar"},
+		{n: config.IdentifierNormalization{StripGmailAliases: true}, in: "j.doe+news@gmail.com", expected: "jdoe@gmail.com"},
+		{n: config.IdentifierNormalization{StripGmailAliases: true}, in: "j.doe+news@GoogleMail.com", expected: "jdoe@GoogleMail.com"},
+		{n: config.IdentifierNormalization{StripGmailAliases: true}, in: "j.doe+news@ory.sh", expected: "j.doe+news@ory.sh"},
+		{n: config.IdentifierNormalization{StripGmailAliases: true}, in: "j.doe", expected: "j.doe"},
+		{
+			n:        config.IdentifierNormalization{Lowercase: true, Trim: true, StripGmailAliases: true},
+			in:       " J.Doe+News@Gmail.com ",
+			expected: "jdoe@gmail.com",
+		},
+	} {
+		t.Run(fmt.Sprintf("case=%d", k), func(t *testing.T) {
+			actual := NormalizeIdentifier(tc.n, tc.in)
+			assert.Equal(t, tc.expected, actual)
+			assert.Equal(t, actual, NormalizeIdentifier(tc.n, actual), "normalization must be idempotent")
+		})
+	}
+}
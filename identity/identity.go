@@ -59,6 +59,19 @@ type (
 		// required: true
 		Traits Traits `json:"traits" faker:"-" db:"traits"`
 
+		// State is the identity's state. Inactive identities can not sign in and their sessions are rejected.
+		//
+		// required: true
+		State State `json:"state" faker:"-" db:"state"`
+
+		// StateChangedAt is the time the identity's state was last changed.
+		//
+		// Extensions:
+		// ---
+		// x-omitempty: true
+		// ---
+		StateChangedAt *time.Time `json:"state_changed_at,omitempty" faker:"-" db:"state_changed_at"`
+
 		// VerifiableAddresses contains all the addresses that can be verified by the user.
 		//
 		// Extensions:
@@ -75,6 +88,65 @@ type (
 		// ---
 		RecoveryAddresses []RecoveryAddress `json:"recovery_addresses,omitempty" faker:"-" has_many:"identity_recovery_addresses" fk_id:"identity_id"`
 
+		// CommunicationPreferences are consulted by the courier before messages are sent to this identity.
+		//
+		// Extensions:
+		// ---
+		// x-omitempty: true
+		// ---
+		CommunicationPreferences *CommunicationPreferences `json:"communication_preferences,omitempty" faker:"-" db:"communication_preferences"`
+
+		// TermsOfService records the version of the terms of service the identity accepted.
+		//
+		// Extensions:
+		// ---
+		// x-omitempty: true
+		// ---
+		TermsOfService *TermsOfServiceAcceptance `json:"terms_of_service,omitempty" faker:"-" db:"terms_of_service"`
+
+		// LastLoginAt is the time the identity last completed a login flow.
+		//
+		// Extensions:
+		// ---
+		// x-omitempty: true
+		// ---
+		LastLoginAt *time.Time `json:"last_login_at,omitempty" faker:"-" db:"last_login_at"`
+
+		// LastSeenAt is the time the identity's session was last checked using `/sessions/whoami`. It is
+		// updated at most once per `identity.activity.last_seen_interval`.
+		//
+		// Extensions:
+		// ---
+		// x-omitempty: true
+		// ---
+		LastSeenAt *time.Time `json:"last_seen_at,omitempty" faker:"-" db:"last_seen_at"`
+
+		// PasswordChangedAt is the time the identity's password was last set.
+		//
+		// Extensions:
+		// ---
+		// x-omitempty: true
+		// ---
+		PasswordChangedAt *time.Time `json:"password_changed_at,omitempty" faker:"-" db:"password_changed_at"`
+
+		// DormancyNotifiedAt is the time the identity was notified that it is dormant. It is reset once
+		// the identity is active again.
+		//
+		// Extensions:
+		// ---
+		// x-omitempty: true
+		// ---
+		DormancyNotifiedAt *time.Time `json:"dormancy_notified_at,omitempty" faker:"-" db:"dormancy_notified_at"`
+
+		// UpdatedBy is the principal which last created or changed the identity using the admin API, if the admin API
+		// is protected by an authentication middleware which records the principal.
+		//
+		// Extensions:
+		// ---
+		// x-omitempty: true
+		// ---
+		UpdatedBy sqlxx.NullString `json:"updated_by,omitempty" faker:"-" db:"updated_by"`
+
 		// CredentialsCollection is a helper struct field for gobuffalo.pop.
 		CredentialsCollection CredentialsCollection `json:"-" faker:"-" has_many:"identity_credentials" fk_id:"identity_id"`
 
@@ -85,8 +157,27 @@ type (
 		UpdatedAt time.Time `json:"-" db:"updated_at"`
 	}
 	Traits json.RawMessage
+
+	// State is the state of an identity.
+	//
+	// swagger:model identityState
+	State string
+)
+
+const (
+	StateActive   State = "active"
+	StateInactive State = "inactive"
 )
 
+// Valid returns an error if the state is unknown.
+func (s State) Valid() error {
+	switch s {
+	case StateActive, StateInactive:
+		return nil
+	}
+	return errors.WithStack(herodot.ErrBadRequest.WithReasonf("Identity state must be one of %s or %s but got: %s", StateActive, StateInactive, s))
+}
+
 func (t *Traits) Scan(value interface{}) error {
 	return sqlxx.JSONScan(t, value)
 }
@@ -169,6 +260,22 @@ func (i *Identity) ParseCredentials(t CredentialsType, config interface{}) (*Cre
 	return nil, herodot.ErrNotFound.WithReasonf("identity does not have credential type %s", t)
 }
 
+// IsActive returns false if the identity was deactivated.
+func (i *Identity) IsActive() bool {
+	return i.State != StateInactive
+}
+
+// LastActiveAt returns the most recent time the identity was created, signed in, seen, or had its state changed.
+func (i *Identity) LastActiveAt() time.Time {
+	last := i.CreatedAt
+	for _, t := range []*time.Time{i.LastLoginAt, i.LastSeenAt, i.StateChangedAt} {
+		if t != nil && t.After(last) {
+			last = *t
+		}
+	}
+	return last
+}
+
 func (i *Identity) CopyWithoutCredentials() *Identity {
 	var ii = *i
 	ii.Credentials = nil
@@ -185,6 +292,7 @@ func NewIdentity(traitsSchemaID string) *Identity {
 		Credentials:         map[CredentialsType]Credentials{},
 		Traits:              Traits("{}"),
 		SchemaID:            traitsSchemaID,
+		State:               StateActive,
 		VerifiableAddresses: []VerifiableAddress{},
 		l:                   new(sync.RWMutex),
 	}
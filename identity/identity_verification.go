@@ -16,6 +16,8 @@ const (
 
 	VerifiableAddressStatusPending   VerifiableAddressStatus = "pending"
 	VerifiableAddressStatusCompleted VerifiableAddressStatus = "completed"
+	// VerifiableAddressStatusUndeliverable is set when messages to the address bounced permanently.
+	VerifiableAddressStatusUndeliverable VerifiableAddressStatus = "undeliverable"
 )
 
 type (
@@ -44,6 +46,11 @@ type (
 
 		VerifiedAt sqlxx.NullTime `json:"verified_at" faker:"-" db:"verified_at"`
 
+		// RemindersSent counts the verification reminders sent to the address.
+		RemindersSent int `json:"-" faker:"-" db:"reminders_sent"`
+		// RemindedAt is the time the last verification reminder was sent to the address.
+		RemindedAt sqlxx.NullTime `json:"-" faker:"-" db:"reminded_at"`
+
 		// IdentityID is a helper struct field for gobuffalo.pop.
 		IdentityID uuid.UUID `json:"-" faker:"-" db:"identity_id"`
 		// CreatedAt is a helper struct field for gobuffalo.pop.
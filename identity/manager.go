@@ -3,17 +3,24 @@ package identity
 import (
 	"context"
 	"reflect"
+	"time"
 
+	"github.com/gobuffalo/pop/v5"
 	"github.com/gofrs/uuid"
 
 	"github.com/mohae/deepcopy"
 	"github.com/pkg/errors"
+	"github.com/tidwall/gjson"
 
 	"github.com/ory/herodot"
 	"github.com/ory/jsonschema/v3"
 	"github.com/ory/x/errorsx"
+	"github.com/ory/x/sqlcon"
+	"github.com/ory/x/sqlxx"
 
 	"github.com/ory/kratos/courier"
+	"github.com/ory/kratos/driver/config"
+	"github.com/ory/kratos/x"
 )
 
 var ErrProtectedFieldModified = herodot.ErrForbidden.
@@ -22,8 +29,12 @@ var ErrProtectedFieldModified = herodot.ErrForbidden.
 type (
 	managerDependencies interface {
 		PoolProvider
+		TransactionPersistenceProvider
 		courier.Provider
 		ValidationProvider
+		x.ClockProvider
+		x.LoggingProvider
+		config.Provider
 	}
 	ManagementProvider interface {
 		IdentityManager() *Manager
@@ -35,6 +46,7 @@ type (
 	managerOptions struct {
 		ExposeValidationErrors    bool
 		AllowWriteProtectedTraits bool
+		EnforceUsernamePolicy     bool
 	}
 
 	ManagerOption func(*managerOptions)
@@ -52,6 +64,10 @@ func ManagerAllowWriteProtectedTraits(options *managerOptions) {
 	options.AllowWriteProtectedTraits = true
 }
 
+func ManagerEnforceUsernamePolicy(options *managerOptions) {
+	options.EnforceUsernamePolicy = true
+}
+
 func newManagerOptions(opts []ManagerOption) *managerOptions {
 	var o managerOptions
 	for _, f := range opts {
@@ -66,9 +82,76 @@ func (m *Manager) Create(ctx context.Context, i *Identity, opts ...ManagerOption
 		return err
 	}
 
+	if err := m.checkIdentifierReuse(ctx, nil, i); err != nil {
+		return err
+	}
+
+	touchPasswordChangedAt(nil, i, m.r.Clock().Now().UTC())
+	touchUpdatedBy(ctx, i)
 	return m.r.IdentityPool().(PrivilegedPool).CreateIdentity(ctx, i)
 }
 
+// Delete deletes the identity. If configured, anonymized stubs of the identity's identifiers are retained. The
+// identity is only deleted if the stubs were stored.
+func (m *Manager) Delete(ctx context.Context, id uuid.UUID) error {
+	c := m.r.Config(ctx).IdentityDeletion()
+	if !c.RetainAnonymized {
+		return m.r.IdentityPool().(PrivilegedPool).DeleteIdentity(ctx, id)
+	}
+
+	return m.r.IdentityTransactionPersister().Transaction(ctx, func(ctx context.Context, _ *pop.Connection) error {
+		i, err := m.r.IdentityPool().(PrivilegedPool).GetIdentityConfidential(ctx, id)
+		if err != nil {
+			return err
+		}
+
+		if err := m.r.IdentityPool().(PrivilegedPool).DeleteIdentity(ctx, id); err != nil {
+			return err
+		}
+
+		now := m.r.Clock().Now().UTC()
+		if err := m.r.IdentityPool().(PrivilegedPool).DeleteExpiredAnonymizedStubs(ctx, now); err != nil {
+			return err
+		}
+
+		return m.r.IdentityPool().(PrivilegedPool).CreateAnonymizedStubs(ctx,
+			NewAnonymizedStubs(i, m.r.Config(ctx).SecretsDefault()[0], now, c.Retention))
+	})
+}
+
+// checkIdentifierReuse returns sqlcon.ErrUniqueViolation if updated uses an identifier which original, which may
+// be nil for new identities, did not use and which belonged to a deleted identity whose stub is retained.
+func (m *Manager) checkIdentifierReuse(ctx context.Context, original, updated *Identity) error {
+	c := m.r.Config(ctx).IdentityDeletion()
+	if !c.RetainAnonymized || !c.PreventIdentifierReuse {
+		return nil
+	}
+
+	known := map[string]bool{}
+	if original != nil {
+		for _, identifier := range identifiersOf(original) {
+			known[identifier] = true
+		}
+	}
+
+	var hashes []string
+	for _, identifier := range identifiersOf(updated) {
+		if !known[identifier] {
+			hashes = append(hashes, HashIdentifiers(m.r.Config(ctx).SecretsDefault(), identifier)...)
+		}
+	}
+
+	stubs, err := m.r.IdentityPool().(PrivilegedPool).FindAnonymizedStubs(ctx, hashes, m.r.Clock().Now().UTC())
+	if err != nil {
+		return err
+	} else if len(stubs) > 0 {
+		m.r.Logger().WithField("identity_id", updated.ID).Debug("Rejecting an identifier which belonged to a deleted identity.")
+		return errors.WithStack(sqlcon.ErrUniqueViolation)
+	}
+
+	return nil
+}
+
 func (m *Manager) requiresPrivilegedAccess(_ context.Context, original, updated *Identity, o *managerOptions) error {
 	if !o.AllowWriteProtectedTraits {
 		if !CredentialsEqual(updated.Credentials, original.Credentials) {
@@ -103,9 +186,41 @@ func (m *Manager) Update(ctx context.Context, updated *Identity, opts ...Manager
 		return err
 	}
 
+	if err := m.checkIdentifierReuse(ctx, original, updated); err != nil {
+		return err
+	}
+
+	touchPasswordChangedAt(original, updated, m.r.Clock().Now().UTC())
+	touchUpdatedBy(ctx, updated)
 	return m.r.IdentityPool().(PrivilegedPool).UpdateIdentity(ctx, updated)
 }
 
+// touchPasswordChangedAt sets the time the password was changed to now if the password hash of updated differs
+// from the password hash of original, which may be nil for new identities.
+func touchPasswordChangedAt(original, updated *Identity, now time.Time) {
+	hashedPassword := func(i *Identity) string {
+		c, ok := i.GetCredentials(CredentialsTypePassword)
+		if !ok {
+			return ""
+		}
+		return gjson.GetBytes(c.Config, "hashed_password").String()
+	}
+
+	hash := hashedPassword(updated)
+	if len(hash) == 0 || (original != nil && hashedPassword(original) == hash) {
+		return
+	}
+
+	updated.PasswordChangedAt = &now
+}
+
+// touchUpdatedBy records the admin principal which changes the identity, if the change is made using the admin API.
+func touchUpdatedBy(ctx context.Context, i *Identity) {
+	if p, ok := x.AdminPrincipalFromContext(ctx); ok {
+		i.UpdatedBy = sqlxx.NullString(p.String())
+	}
+}
+
 func (m *Manager) UpdateSchemaID(ctx context.Context, id uuid.UUID, schemaID string, opts ...ManagerOption) error {
 	o := newManagerOptions(opts)
 	original, err := m.r.IdentityPool().(PrivilegedPool).GetIdentityConfidential(ctx, id)
@@ -143,11 +258,72 @@ func (m *Manager) UpdateTraits(ctx context.Context, id uuid.UUID, traits Traits,
 		return err
 	}
 
+	if err := m.checkIdentifierReuse(ctx, original, updated); err != nil {
+		return err
+	}
+
 	return m.r.IdentityPool().(PrivilegedPool).UpdateIdentity(ctx, updated)
 }
 
+// Merge merges the credentials, addresses, sessions, and metadata of the secondary identity into the primary
+// identity and deletes the secondary identity using Delete. See MergeIdentities for how conflicts are resolved.
+// Identifiers and addresses which are derived from traits are updated to match the merged traits.
+func (m *Manager) Merge(ctx context.Context, primaryID, secondaryID uuid.UUID, opts ...ManagerOption) (*Identity, error) {
+	if primaryID == secondaryID {
+		return nil, errors.WithStack(herodot.ErrBadRequest.WithReason("An identity can not be merged into itself."))
+	}
+
+	o := newManagerOptions(opts)
+	primary, err := m.r.IdentityPool().(PrivilegedPool).GetIdentityConfidential(ctx, primaryID)
+	if err != nil {
+		return nil, err
+	}
+
+	secondary, err := m.r.IdentityPool().(PrivilegedPool).GetIdentityConfidential(ctx, secondaryID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := MergeIdentities(primary, secondary); err != nil {
+		return nil, err
+	}
+
+	if err := m.validate(ctx, primary, o); err != nil {
+		return nil, err
+	}
+
+	touchUpdatedBy(ctx, primary)
+	if err := m.r.IdentityTransactionPersister().Transaction(ctx, func(ctx context.Context, _ *pop.Connection) error {
+		if err := m.r.IdentityPool().(PrivilegedPool).MoveIdentitySessions(ctx, secondaryID, primaryID); err != nil {
+			return err
+		}
+
+		// The secondary identity is deleted first because the primary identity takes over its credentials
+		// identifiers and addresses which must be unique.
+		if err := m.Delete(ctx, secondaryID); err != nil {
+			return err
+		}
+
+		return m.r.IdentityPool().(PrivilegedPool).UpdateIdentity(ctx, primary)
+	}); err != nil {
+		return nil, err
+	}
+
+	m.r.Audit().
+		WithField("identity_id", primaryID).
+		WithField("merged_identity_id", secondaryID).
+		WithField("updated_by", primary.UpdatedBy).
+		Info("Merged identities.")
+	return primary, nil
+}
+
 func (m *Manager) validate(ctx context.Context, i *Identity, o *managerOptions) error {
-	if err := m.r.IdentityValidator().Validate(ctx, i); err != nil {
+	var vo []ValidatorOption
+	if o.EnforceUsernamePolicy {
+		vo = append(vo, ValidatorEnforceUsernamePolicy)
+	}
+
+	if err := m.r.IdentityValidator().Validate(ctx, i, vo...); err != nil {
 		if _, ok := errorsx.Cause(err).(*jsonschema.ValidationError); ok && !o.ExposeValidationErrors {
 			return herodot.ErrBadRequest.WithReasonf("%s", err).WithWrap(err)
 		}
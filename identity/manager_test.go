@@ -4,14 +4,19 @@ import (
 	"context"
 	"fmt"
 	"testing"
+	"time"
 
 	"github.com/pkg/errors"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
+	"github.com/ory/x/sqlcon"
+	"github.com/ory/x/sqlxx"
+
 	"github.com/ory/kratos/driver/config"
 	"github.com/ory/kratos/identity"
 	"github.com/ory/kratos/internal"
+	"github.com/ory/kratos/session"
 	"github.com/ory/kratos/x"
 )
 
@@ -91,6 +96,50 @@ func TestManager(t *testing.T) {
 			checkExtensionFieldsForIdentities(t, "bar@ory.sh", original)
 		})
 
+		t.Run("case=should record when the password changed using the injected clock", func(t *testing.T) {
+			clock := x.NewFakeClock(time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC))
+			reg.WithClock(clock)
+			t.Cleanup(func() {
+				reg.WithClock(x.SystemClock)
+			})
+
+			original := identity.NewIdentity(config.DefaultIdentityTraitsSchemaID)
+			original.Traits = newTraits("password-changed@ory.sh", "")
+			original.SetCredentials(identity.CredentialsTypePassword, identity.Credentials{
+				Type: identity.CredentialsTypePassword, Identifiers: []string{"password-changed@ory.sh"},
+				Config: sqlxx.JSONRawMessage(`{"hashed_password":"first"}`),
+			})
+			require.NoError(t, reg.IdentityManager().Create(context.Background(), original))
+			require.NotNil(t, original.PasswordChangedAt)
+			assert.Equal(t, clock.Now(), *original.PasswordChangedAt)
+
+			clock.Add(time.Hour)
+			original.Traits = newTraits("password-changed@ory.sh", "unchanged password")
+			require.NoError(t, reg.IdentityManager().Update(context.Background(), original, identity.ManagerAllowWriteProtectedTraits))
+			assert.Equal(t, clock.Now().Add(-time.Hour), *original.PasswordChangedAt)
+
+			original.SetCredentials(identity.CredentialsTypePassword, identity.Credentials{
+				Type: identity.CredentialsTypePassword, Identifiers: []string{"password-changed@ory.sh"},
+				Config: sqlxx.JSONRawMessage(`{"hashed_password":"second"}`),
+			})
+			require.NoError(t, reg.IdentityManager().Update(context.Background(), original, identity.ManagerAllowWriteProtectedTraits))
+			assert.Equal(t, clock.Now(), *original.PasswordChangedAt)
+		})
+
+		t.Run("case=should record the admin principal", func(t *testing.T) {
+			original := identity.NewIdentity(config.DefaultIdentityTraitsSchemaID)
+			original.Traits = newTraits("updated-by@ory.sh", "")
+			require.NoError(t, reg.IdentityManager().Create(context.Background(), original))
+			assert.Empty(t, original.UpdatedBy)
+
+			ctx := x.WithAdminPrincipal(context.Background(), x.AdminPrincipal{APIKeyID: "1234", Subject: "alice"})
+			require.NoError(t, reg.IdentityManager().Update(ctx, original, identity.ManagerAllowWriteProtectedTraits))
+
+			actual, err := reg.PrivilegedIdentityPool().GetIdentityConfidential(context.Background(), original.ID)
+			require.NoError(t, err)
+			assert.EqualValues(t, "alice (key 1234)", actual.UpdatedBy)
+		})
+
 		t.Run("case=should not update protected traits without option", func(t *testing.T) {
 			original := identity.NewIdentity(config.DefaultIdentityTraitsSchemaID)
 			original.Traits = newTraits("email-update-1@ory.sh", "")
@@ -203,4 +252,130 @@ func TestManager(t *testing.T) {
 			checkExtensionFields(fromStore, "email-updatetraits-1@ory.sh")(t)
 		})
 	})
+
+	t.Run("method=Merge", func(t *testing.T) {
+		t.Run("case=should not merge an identity into itself", func(t *testing.T) {
+			original := identity.NewIdentity(config.DefaultIdentityTraitsSchemaID)
+			original.Traits = newTraits("merge-self@ory.sh", "")
+			require.NoError(t, reg.IdentityManager().Create(context.Background(), original))
+
+			_, err := reg.IdentityManager().Merge(context.Background(), original.ID, original.ID, identity.ManagerAllowWriteProtectedTraits)
+			require.Error(t, err)
+		})
+
+		t.Run("case=should merge secondary into primary identity", func(t *testing.T) {
+			primary := identity.NewIdentity(config.DefaultIdentityTraitsSchemaID)
+			primary.Traits = identity.Traits(`{"email":"merge-primary@ory.sh"}`)
+			require.NoError(t, reg.IdentityManager().Create(context.Background(), primary))
+
+			secondary := identity.NewIdentity(config.DefaultIdentityTraitsSchemaID)
+			secondary.Traits = newTraits("merge-secondary@ory.sh", "secondary")
+			secondary.SetCredentials(identity.CredentialsTypeOIDC, identity.Credentials{
+				Type:        identity.CredentialsTypeOIDC,
+				Identifiers: []string{"google:merge-secondary"},
+				Config:      sqlxx.JSONRawMessage(`{"providers":[{"provider":"google","subject":"merge-secondary"}]}`),
+			})
+			require.NoError(t, reg.IdentityManager().Create(context.Background(), secondary))
+
+			s := session.NewActiveSession(x.SystemClock, x.RandomIDGenerator, secondary, conf, time.Now().UTC())
+			require.NoError(t, reg.SessionPersister().CreateSession(context.Background(), s))
+
+			merged, err := reg.IdentityManager().Merge(context.Background(), primary.ID, secondary.ID, identity.ManagerAllowWriteProtectedTraits)
+			require.NoError(t, err)
+			assert.JSONEq(t, `{"email":"merge-primary@ory.sh","email_verify":"merge-secondary@ory.sh","email_recovery":"merge-secondary@ory.sh","email_creds":"merge-secondary@ory.sh","unprotected":"secondary"}`, string(merged.Traits))
+
+			fromStore, err := reg.PrivilegedIdentityPool().GetIdentityConfidential(context.Background(), primary.ID)
+			require.NoError(t, err)
+			assert.ElementsMatch(t, []string{"merge-primary@ory.sh", "merge-secondary@ory.sh"}, fromStore.Credentials[identity.CredentialsTypePassword].Identifiers)
+			assert.Equal(t, []string{"google:merge-secondary"}, fromStore.Credentials[identity.CredentialsTypeOIDC].Identifiers)
+			require.Len(t, fromStore.VerifiableAddresses, 1)
+			assert.Equal(t, "merge-secondary@ory.sh", fromStore.VerifiableAddresses[0].Value)
+
+			_, err = reg.PrivilegedIdentityPool().GetIdentityConfidential(context.Background(), secondary.ID)
+			require.Error(t, err)
+
+			actual, err := reg.SessionPersister().GetSession(context.Background(), s.ID)
+			require.NoError(t, err)
+			assert.Equal(t, primary.ID, actual.IdentityID)
+		})
+
+		t.Run("case=should retain anonymized stubs of the secondary identity", func(t *testing.T) {
+			conf.MustSet(config.ViperKeyIdentityDeletionRetainAnonymized, true)
+			t.Cleanup(func() {
+				conf.MustSet(config.ViperKeyIdentityDeletionRetainAnonymized, false)
+			})
+
+			primary := identity.NewIdentity(config.DefaultIdentityTraitsSchemaID)
+			primary.Traits = identity.Traits(`{"email":"merge-stub-primary@ory.sh"}`)
+			require.NoError(t, reg.IdentityManager().Create(context.Background(), primary))
+
+			secondary := identity.NewIdentity(config.DefaultIdentityTraitsSchemaID)
+			secondary.Traits = newTraits("merge-stub-secondary@ory.sh", "")
+			require.NoError(t, reg.IdentityManager().Create(context.Background(), secondary))
+
+			_, err := reg.IdentityManager().Merge(context.Background(), primary.ID, secondary.ID, identity.ManagerAllowWriteProtectedTraits)
+			require.NoError(t, err)
+
+			stubs, err := reg.PrivilegedIdentityPool().FindAnonymizedStubs(context.Background(),
+				identity.HashIdentifiers(conf.SecretsDefault(), "merge-stub-secondary@ory.sh"), time.Now())
+			require.NoError(t, err)
+			require.Len(t, stubs, 1)
+			assert.Equal(t, secondary.CreatedAt.Unix(), stubs[0].IdentityCreatedAt.Unix())
+		})
+	})
+
+	t.Run("method=Delete", func(t *testing.T) {
+		t.Run("case=should delete identity without retaining stubs by default", func(t *testing.T) {
+			original := identity.NewIdentity(config.DefaultIdentityTraitsSchemaID)
+			original.Traits = newTraits("delete-plain@ory.sh", "")
+			require.NoError(t, reg.IdentityManager().Create(context.Background(), original))
+			require.NoError(t, reg.IdentityManager().Delete(context.Background(), original.ID))
+
+			stubs, err := reg.PrivilegedIdentityPool().FindAnonymizedStubs(context.Background(),
+				identity.HashIdentifiers(conf.SecretsDefault(), "delete-plain@ory.sh"), time.Now())
+			require.NoError(t, err)
+			assert.Empty(t, stubs)
+
+			again := identity.NewIdentity(config.DefaultIdentityTraitsSchemaID)
+			again.Traits = newTraits("delete-plain@ory.sh", "")
+			require.NoError(t, reg.IdentityManager().Create(context.Background(), again))
+		})
+
+		t.Run("case=should retain anonymized stubs and prevent identifier reuse", func(t *testing.T) {
+			conf.MustSet(config.ViperKeyIdentityDeletionRetainAnonymized, true)
+			t.Cleanup(func() {
+				conf.MustSet(config.ViperKeyIdentityDeletionRetainAnonymized, false)
+				conf.MustSet(config.ViperKeyIdentityDeletionPreventIdentifierReuse, true)
+			})
+
+			original := identity.NewIdentity(config.DefaultIdentityTraitsSchemaID)
+			original.Traits = newTraits("delete-stub@ory.sh", "")
+			require.NoError(t, reg.IdentityManager().Create(context.Background(), original))
+			require.NoError(t, reg.IdentityManager().Delete(context.Background(), original.ID))
+			require.ErrorIs(t, reg.IdentityManager().Delete(context.Background(), original.ID), sqlcon.ErrNoRows)
+
+			_, err := reg.PrivilegedIdentityPool().GetIdentity(context.Background(), original.ID)
+			require.ErrorIs(t, err, sqlcon.ErrNoRows)
+
+			stubs, err := reg.PrivilegedIdentityPool().FindAnonymizedStubs(context.Background(),
+				identity.HashIdentifiers(conf.SecretsDefault(), "delete-stub@ory.sh"), time.Now())
+			require.NoError(t, err)
+			require.Len(t, stubs, 1)
+			assert.NotContains(t, stubs[0].IdentifierHash, "delete-stub")
+			assert.Equal(t, original.CreatedAt.Unix(), stubs[0].IdentityCreatedAt.Unix())
+			require.NotNil(t, stubs[0].ExpiresAt)
+
+			reused := identity.NewIdentity(config.DefaultIdentityTraitsSchemaID)
+			reused.Traits = newTraits("delete-stub@ory.sh", "")
+			require.ErrorIs(t, reg.IdentityManager().Create(context.Background(), reused), sqlcon.ErrUniqueViolation)
+
+			other := identity.NewIdentity(config.DefaultIdentityTraitsSchemaID)
+			other.Traits = newTraits("delete-other@ory.sh", "")
+			require.NoError(t, reg.IdentityManager().Create(context.Background(), other))
+			require.ErrorIs(t, reg.IdentityManager().UpdateTraits(context.Background(), other.ID, newTraits("delete-stub@ory.sh", ""), identity.ManagerAllowWriteProtectedTraits), sqlcon.ErrUniqueViolation)
+
+			conf.MustSet(config.ViperKeyIdentityDeletionPreventIdentifierReuse, false)
+			require.NoError(t, reg.IdentityManager().Create(context.Background(), reused))
+		})
+	})
 }
@@ -0,0 +1,134 @@
+package identity
+
+import (
+	"encoding/json"
+
+	"github.com/gofrs/uuid"
+	"github.com/pkg/errors"
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+
+	"github.com/ory/go-convenience/stringslice"
+	"github.com/ory/x/sqlxx"
+)
+
+// MergeIdentities merges the secondary identity into the primary identity. Values of the primary identity take
+// precedence: top-level traits, credentials, communication preferences, and the terms of service acceptance of the
+// secondary identity are only used if the primary identity has none. Credentials identifiers, OpenID Connect
+// providers, and addresses of both identities are combined.
+func MergeIdentities(primary, secondary *Identity) error {
+	traits, err := mergeTraits(primary.Traits, secondary.Traits)
+	if err != nil {
+		return err
+	}
+	primary.Traits = traits
+
+	for t, sc := range secondary.Credentials {
+		pc, ok := primary.GetCredentials(t)
+		if !ok {
+			sc.ID = uuid.Nil
+			sc.IdentityID = primary.ID
+			primary.SetCredentials(t, sc)
+			continue
+		}
+
+		pc.Identifiers = stringslice.Unique(append(pc.Identifiers, sc.Identifiers...))
+		if t == CredentialsTypeOIDC {
+			config, err := mergeOIDCProviders(pc.Config, sc.Config)
+			if err != nil {
+				return err
+			}
+			pc.Config = config
+		}
+		primary.SetCredentials(t, *pc)
+	}
+
+	for _, a := range secondary.VerifiableAddresses {
+		if !hasVerifiableAddress(primary.VerifiableAddresses, a) {
+			a.ID = uuid.Nil
+			a.IdentityID = primary.ID
+			primary.VerifiableAddresses = append(primary.VerifiableAddresses, a)
+		}
+	}
+
+	for _, a := range secondary.RecoveryAddresses {
+		if !hasRecoveryAddress(primary.RecoveryAddresses, a) {
+			a.ID = uuid.Nil
+			a.IdentityID = primary.ID
+			primary.RecoveryAddresses = append(primary.RecoveryAddresses, a)
+		}
+	}
+
+	if primary.CommunicationPreferences == nil {
+		primary.CommunicationPreferences = secondary.CommunicationPreferences
+	}
+
+	if primary.TermsOfService == nil {
+		primary.TermsOfService = secondary.TermsOfService
+	}
+
+	return nil
+}
+
+func mergeTraits(primary, secondary Traits) (Traits, error) {
+	merged := map[string]json.RawMessage{}
+	for _, traits := range []Traits{secondary, primary} {
+		if len(traits) == 0 {
+			continue
+		}
+
+		var t map[string]json.RawMessage
+		if err := json.Unmarshal(traits, &t); err != nil {
+			return nil, errors.WithStack(err)
+		}
+
+		for k, v := range t {
+			merged[k] = v
+		}
+	}
+
+	out, err := json.Marshal(merged)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	return out, nil
+}
+
+// mergeOIDCProviders appends the OpenID Connect providers linked to the secondary credentials to the primary ones.
+func mergeOIDCProviders(primary, secondary sqlxx.JSONRawMessage) (sqlxx.JSONRawMessage, error) {
+	providers := gjson.GetBytes(primary, "providers").Array()
+	providers = append(providers, gjson.GetBytes(secondary, "providers").Array()...)
+
+	raw := make([]json.RawMessage, len(providers))
+	for k := range providers {
+		raw[k] = json.RawMessage(providers[k].Raw)
+	}
+
+	if len(primary) == 0 {
+		primary = sqlxx.JSONRawMessage("{}")
+	}
+
+	out, err := sjson.SetBytes(primary, "providers", raw)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	return out, nil
+}
+
+func hasVerifiableAddress(haystack []VerifiableAddress, needle VerifiableAddress) bool {
+	for _, a := range haystack {
+		if a.Via == needle.Via && a.Value == needle.Value {
+			return true
+		}
+	}
+	return false
+}
+
+func hasRecoveryAddress(haystack []RecoveryAddress, needle RecoveryAddress) bool {
+	for _, a := range haystack {
+		if a.Via == needle.Via && a.Value == needle.Value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,67 @@
+package identity_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ory/x/sqlxx"
+
+	"github.com/ory/kratos/driver/config"
+	"github.com/ory/kratos/identity"
+)
+
+func TestMergeIdentities(t *testing.T) {
+	primary := identity.NewIdentity(config.DefaultIdentityTraitsSchemaID)
+	primary.Traits = identity.Traits(`{"email":"primary@ory.sh","name":"Primary"}`)
+	primary.SetCredentials(identity.CredentialsTypeOIDC, identity.Credentials{
+		Type:        identity.CredentialsTypeOIDC,
+		Identifiers: []string{"google:primary"},
+		Config:      sqlxx.JSONRawMessage(`{"providers":[{"provider":"google","subject":"primary"}]}`),
+	})
+	primary.VerifiableAddresses = []identity.VerifiableAddress{*identity.NewVerifiableEmailAddress("shared@ory.sh", primary.ID)}
+
+	secondary := identity.NewIdentity(config.DefaultIdentityTraitsSchemaID)
+	secondary.Traits = identity.Traits(`{"email":"secondary@ory.sh","phone":"+49123456789"}`)
+	secondary.SetCredentials(identity.CredentialsTypePassword, identity.Credentials{
+		Type:        identity.CredentialsTypePassword,
+		Identifiers: []string{"secondary@ory.sh"},
+		Config:      sqlxx.JSONRawMessage(`{"hashed_password":"foo"}`),
+	})
+	secondary.SetCredentials(identity.CredentialsTypeOIDC, identity.Credentials{
+		Type:        identity.CredentialsTypeOIDC,
+		Identifiers: []string{"github:secondary"},
+		Config:      sqlxx.JSONRawMessage(`{"providers":[{"provider":"github","subject":"secondary"}]}`),
+	})
+	secondary.VerifiableAddresses = []identity.VerifiableAddress{
+		*identity.NewVerifiableEmailAddress("shared@ory.sh", secondary.ID),
+		*identity.NewVerifiableEmailAddress("secondary@ory.sh", secondary.ID),
+	}
+	secondary.RecoveryAddresses = []identity.RecoveryAddress{*identity.NewRecoveryEmailAddress("secondary@ory.sh", secondary.ID)}
+	secondary.CommunicationPreferences = &identity.CommunicationPreferences{}
+
+	require.NoError(t, identity.MergeIdentities(primary, secondary))
+
+	assert.JSONEq(t, `{"email":"primary@ory.sh","name":"Primary","phone":"+49123456789"}`, string(primary.Traits))
+
+	password, ok := primary.GetCredentials(identity.CredentialsTypePassword)
+	require.True(t, ok)
+	assert.Equal(t, []string{"secondary@ory.sh"}, password.Identifiers)
+	assert.Equal(t, primary.ID, password.IdentityID)
+
+	oidc, ok := primary.GetCredentials(identity.CredentialsTypeOIDC)
+	require.True(t, ok)
+	assert.Equal(t, []string{"google:primary", "github:secondary"}, oidc.Identifiers)
+	assert.JSONEq(t, `{"providers":[{"provider":"google","subject":"primary"},{"provider":"github","subject":"secondary"}]}`, string(oidc.Config))
+
+	require.Len(t, primary.VerifiableAddresses, 2)
+	assert.Equal(t, "shared@ory.sh", primary.VerifiableAddresses[0].Value)
+	assert.Equal(t, "secondary@ory.sh", primary.VerifiableAddresses[1].Value)
+	assert.Equal(t, primary.ID, primary.VerifiableAddresses[1].IdentityID)
+
+	require.Len(t, primary.RecoveryAddresses, 1)
+	assert.Equal(t, primary.ID, primary.RecoveryAddresses[0].IdentityID)
+
+	assert.Equal(t, secondary.CommunicationPreferences, primary.CommunicationPreferences)
+}
@@ -10,6 +10,7 @@ import (
 	"time"
 
 	"github.com/bxcodec/faker/v3"
+	"github.com/gobuffalo/pop/v5"
 
 	"github.com/ory/x/sqlxx"
 
@@ -35,6 +36,18 @@ type (
 		// CountIdentities counts the number of identities in the store.
 		CountIdentities(ctx context.Context) (int64, error)
 
+		// ListIdentitiesByActivity lists the identities matching the activity filter given the page and itemsPerPage.
+		ListIdentitiesByActivity(ctx context.Context, f ActivityFilter, page, itemsPerPage int) ([]Identity, error)
+
+		// CountIdentitiesByActivity counts the identities matching the activity filter.
+		CountIdentitiesByActivity(ctx context.Context, f ActivityFilter) (int64, error)
+
+		// CountIdentitiesBySchema counts the number of identities in the store per identity schema ID.
+		CountIdentitiesBySchema(ctx context.Context) (map[string]int64, error)
+
+		// CountUnverifiedAddresses counts the verifiable addresses which have not been verified yet.
+		CountUnverifiedAddresses(ctx context.Context) (int64, error)
+
 		// GetIdentity returns an identity by its id. Will return an error if the identity does not exist or backend
 		// connectivity is broken.
 		GetIdentity(context.Context, uuid.UUID) (*Identity, error)
@@ -54,6 +67,16 @@ type (
 		PrivilegedIdentityPool() PrivilegedPool
 	}
 
+	// TransactionPersister runs the callback in a database transaction. Pools called with the context passed to
+	// the callback join the transaction.
+	TransactionPersister interface {
+		Transaction(ctx context.Context, callback func(ctx context.Context, connection *pop.Connection) error) error
+	}
+
+	TransactionPersistenceProvider interface {
+		IdentityTransactionPersister() TransactionPersister
+	}
+
 	PrivilegedPool interface {
 		Pool
 
@@ -67,6 +90,40 @@ type (
 		// UpdateVerifiableAddress
 		UpdateVerifiableAddress(ctx context.Context, address *VerifiableAddress) error
 
+		// RecordIdentityActivity sets the timestamp of the activity. UpdateIdentity does not change these
+		// timestamps so that concurrent updates do not overwrite them.
+		RecordIdentityActivity(ctx context.Context, id uuid.UUID, a Activity, at time.Time) error
+
+		// UpdateIdentityState sets the identity's state and the time the state was changed.
+		UpdateIdentityState(ctx context.Context, id uuid.UUID, state State, at time.Time) error
+
+		// DeactivateDormantIdentity deactivates the identity because it is dormant. Unlike UpdateIdentityState, it
+		// records the deactivation so that the dormancy policy only deletes identities it deactivated itself. The
+		// record is cleared when activity is recorded or the state is changed.
+		DeactivateDormantIdentity(ctx context.Context, id uuid.UUID, at time.Time) error
+
+		// MarkIdentityDormancyNotified records that the identity was notified that it is dormant. The mark is
+		// cleared when activity is recorded for the identity.
+		MarkIdentityDormancyNotified(ctx context.Context, id uuid.UUID, at time.Time) error
+
+		// ListVerificationReminderCandidates lists the pending email addresses which were added after createdAfter,
+		// unless it is zero, and before createdBefore and received fewer than maxReminders verification reminders,
+		// oldest first.
+		ListVerificationReminderCandidates(ctx context.Context, createdAfter, createdBefore time.Time, maxReminders int, page, perPage int) ([]VerifiableAddress, error)
+
+		// MarkVerificationRemindersSent records how many verification reminders were sent to the address and when
+		// the last one was sent.
+		MarkVerificationRemindersSent(ctx context.Context, id uuid.UUID, reminders int, at time.Time) error
+
+		// CreateAnonymizedStubs stores anonymized stubs of a deleted identity.
+		CreateAnonymizedStubs(ctx context.Context, stubs []AnonymizedStub) error
+
+		// FindAnonymizedStubs returns the stubs with one of the identifier hashes which have not expired at the given time.
+		FindAnonymizedStubs(ctx context.Context, hashes []string, at time.Time) ([]AnonymizedStub, error)
+
+		// DeleteExpiredAnonymizedStubs purges the stubs which expired before the given time.
+		DeleteExpiredAnonymizedStubs(ctx context.Context, at time.Time) error
+
 		// Create creates an identity. It is capable of setting credentials without encoding. Will return an error
 		// if identity exists, backend connectivity is broken, or trait validation fails.
 		CreateIdentity(context.Context, *Identity) error
@@ -83,6 +140,12 @@ type (
 
 		// ListRecoveryAddresses lists all tracked recovery addresses.
 		ListRecoveryAddresses(ctx context.Context, page, itemsPerPage int) ([]RecoveryAddress, error)
+
+		// ListIdentifiers lists the credentials identifiers and the verifiable and recovery addresses of all identities.
+		ListIdentifiers(ctx context.Context) ([]IdentityIdentifier, error)
+
+		// MoveIdentitySessions moves all sessions of one identity to another identity.
+		MoveIdentitySessions(ctx context.Context, from, to uuid.UUID) error
 	}
 )
 
@@ -360,6 +423,67 @@ func TestPool(ctx context.Context, conf *config.Config, p interface {
 			createdIDs = append(createdIDs, expected.ID)
 		})
 
+		t.Run("case=create with custom credentials type", func(t *testing.T) {
+			const ct CredentialsType = "smartcard"
+			expected := passwordIdentity("", x.NewUUID().String())
+			expected.SetCredentials(ct, Credentials{
+				Type:        ct,
+				Identifiers: []string{"smartcard-" + x.NewUUID().String()},
+				Config:      sqlxx.JSONRawMessage(`{}`),
+			})
+			require.NoError(t, p.CreateIdentity(ctx, expected))
+			createdIDs = append(createdIDs, expected.ID)
+
+			_, creds, err := p.FindByCredentialsIdentifier(ctx, ct, expected.Credentials[ct].Identifiers[0])
+			require.NoError(t, err)
+			assert.Equal(t, ct, creds.Type)
+		})
+
+		t.Run("case=create and update communication preferences", func(t *testing.T) {
+			expected := passwordIdentity("", x.NewUUID().String())
+			expected.CommunicationPreferences = &CommunicationPreferences{NoMarketing: true, PreferredLocale: "de-CH"}
+			require.NoError(t, p.CreateIdentity(ctx, expected))
+			createdIDs = append(createdIDs, expected.ID)
+
+			actual, err := p.GetIdentity(ctx, expected.ID)
+			require.NoError(t, err)
+			assert.Equal(t, expected.CommunicationPreferences, actual.CommunicationPreferences)
+
+			actual.CommunicationPreferences = &CommunicationPreferences{PreferredLocale: "en"}
+			require.NoError(t, p.UpdateIdentity(ctx, actual))
+
+			actual, err = p.GetIdentityConfidential(ctx, expected.ID)
+			require.NoError(t, err)
+			assert.Equal(t, &CommunicationPreferences{PreferredLocale: "en"}, actual.CommunicationPreferences)
+
+			actual.CommunicationPreferences = nil
+			require.NoError(t, p.UpdateIdentity(ctx, actual))
+
+			actual, err = p.GetIdentity(ctx, expected.ID)
+			require.NoError(t, err)
+			assert.Nil(t, actual.CommunicationPreferences)
+		})
+
+		t.Run("case=create and update terms of service acceptance", func(t *testing.T) {
+			expected := passwordIdentity("", x.NewUUID().String())
+			expected.TermsOfService = &TermsOfServiceAcceptance{Version: "v1", AcceptedAt: time.Now().UTC().Round(time.Second)}
+			require.NoError(t, p.CreateIdentity(ctx, expected))
+			createdIDs = append(createdIDs, expected.ID)
+
+			actual, err := p.GetIdentity(ctx, expected.ID)
+			require.NoError(t, err)
+			assert.True(t, actual.TermsOfService.HasAccepted("v1"))
+			assert.EqualValues(t, expected.TermsOfService.AcceptedAt.Unix(), actual.TermsOfService.AcceptedAt.Unix())
+
+			actual.TermsOfService = &TermsOfServiceAcceptance{Version: "v2", AcceptedAt: time.Now().UTC()}
+			require.NoError(t, p.UpdateIdentity(ctx, actual))
+
+			actual, err = p.GetIdentityConfidential(ctx, expected.ID)
+			require.NoError(t, err)
+			assert.False(t, actual.TermsOfService.HasAccepted("v1"))
+			assert.True(t, actual.TermsOfService.HasAccepted("v2"))
+		})
+
 		t.Run("case=list", func(t *testing.T) {
 			is, err := p.ListIdentities(ctx, 0, 25)
 			require.NoError(t, err)
@@ -375,6 +499,249 @@ func TestPool(ctx context.Context, conf *config.Config, p interface {
 			}
 		})
 
+		t.Run("case=record activity and list by activity", func(t *testing.T) {
+			create := func(t *testing.T) *Identity {
+				i := NewIdentity(config.DefaultIdentityTraitsSchemaID)
+				i.Traits = Traits(`{}`)
+				require.NoError(t, p.CreateIdentity(ctx, i))
+				createdIDs = append(createdIDs, i.ID)
+				return i
+			}
+
+			dormant, active := create(t), create(t)
+			cutoff := time.Now().UTC().Add(time.Minute)
+			require.NoError(t, p.RecordIdentityActivity(ctx, active.ID, ActivityLogin, cutoff.Add(time.Hour)))
+			require.NoError(t, p.RecordIdentityActivity(ctx, active.ID, ActivitySeen, cutoff.Add(2*time.Hour)))
+			require.ErrorIs(t, p.RecordIdentityActivity(ctx, x.NewUUID(), ActivityLogin, cutoff), sqlcon.ErrNoRows)
+
+			actual, err := p.GetIdentity(ctx, active.ID)
+			require.NoError(t, err)
+			require.NotNil(t, actual.LastLoginAt)
+			require.NotNil(t, actual.LastSeenAt)
+			assert.EqualValues(t, cutoff.Add(time.Hour).Unix(), actual.LastLoginAt.Unix())
+			assert.EqualValues(t, cutoff.Add(2*time.Hour).Unix(), actual.LastSeenAt.Unix())
+
+			// Updating an identity which was loaded before the activity was recorded keeps the activity.
+			require.NoError(t, p.UpdateIdentity(ctx, active))
+			actual, err = p.GetIdentity(ctx, active.ID)
+			require.NoError(t, err)
+			require.NotNil(t, actual.LastLoginAt)
+			assert.EqualValues(t, cutoff.Add(time.Hour).Unix(), actual.LastLoginAt.Unix())
+
+			for _, tc := range []struct {
+				f      ActivityFilter
+				listed bool
+			}{
+				{f: ActivityFilter{LastLoginBefore: cutoff}, listed: false},
+				{f: ActivityFilter{LastLoginBefore: cutoff.Add(90 * time.Minute)}, listed: true},
+				{f: ActivityFilter{LastSeenBefore: cutoff.Add(90 * time.Minute)}, listed: false},
+				{f: ActivityFilter{PasswordChangedBefore: cutoff}, listed: true},
+			} {
+				is, err := p.ListIdentitiesByActivity(ctx, tc.f, 0, 500)
+				require.NoError(t, err)
+
+				var listedDormant, listedActive bool
+				for _, i := range is {
+					listedDormant = listedDormant || i.ID == dormant.ID
+					listedActive = listedActive || i.ID == active.ID
+				}
+				assert.True(t, listedDormant, "%+v", tc.f)
+				assert.Equal(t, tc.listed, listedActive, "%+v", tc.f)
+
+				count, err := p.CountIdentitiesByActivity(ctx, tc.f)
+				require.NoError(t, err)
+				assert.EqualValues(t, len(is), count)
+			}
+		})
+
+		t.Run("case=update state and mark dormancy notified", func(t *testing.T) {
+			i := NewIdentity(config.DefaultIdentityTraitsSchemaID)
+			i.Traits = Traits(`{}`)
+			i.State = ""
+			require.NoError(t, p.CreateIdentity(ctx, i))
+			createdIDs = append(createdIDs, i.ID)
+
+			actual, err := p.GetIdentity(ctx, i.ID)
+			require.NoError(t, err)
+			assert.Equal(t, StateActive, actual.State)
+			assert.Nil(t, actual.StateChangedAt)
+
+			now := time.Now().UTC()
+			require.NoError(t, p.UpdateIdentityState(ctx, i.ID, StateInactive, now))
+			require.Error(t, p.UpdateIdentityState(ctx, i.ID, "unknown", now))
+			require.ErrorIs(t, p.UpdateIdentityState(ctx, x.NewUUID(), StateActive, now), sqlcon.ErrNoRows)
+
+			actual, err = p.GetIdentity(ctx, i.ID)
+			require.NoError(t, err)
+			assert.Equal(t, StateInactive, actual.State)
+			assert.False(t, actual.IsActive())
+			require.NotNil(t, actual.StateChangedAt)
+			assert.EqualValues(t, now.Unix(), actual.StateChangedAt.Unix())
+
+			isNotified := func(t *testing.T) bool {
+				is, err := p.ListIdentitiesByActivity(ctx, ActivityFilter{State: StateInactive, NotNotifiedOfDormancy: true}, 0, 500)
+				require.NoError(t, err)
+				for _, l := range is {
+					if l.ID == i.ID {
+						return false
+					}
+				}
+				return true
+			}
+
+			assert.False(t, isNotified(t))
+			require.NoError(t, p.MarkIdentityDormancyNotified(ctx, i.ID, now))
+			require.ErrorIs(t, p.MarkIdentityDormancyNotified(ctx, x.NewUUID(), now), sqlcon.ErrNoRows)
+			assert.True(t, isNotified(t))
+
+			require.NoError(t, p.RecordIdentityActivity(ctx, i.ID, ActivitySeen, now))
+			assert.False(t, isNotified(t))
+		})
+
+		t.Run("case=deactivate dormant identity", func(t *testing.T) {
+			i := NewIdentity(config.DefaultIdentityTraitsSchemaID)
+			i.Traits = Traits(`{}`)
+			require.NoError(t, p.CreateIdentity(ctx, i))
+			createdIDs = append(createdIDs, i.ID)
+
+			isListed := func(t *testing.T, f ActivityFilter) bool {
+				is, err := p.ListIdentitiesByActivity(ctx, f, 0, 500)
+				require.NoError(t, err)
+				for _, l := range is {
+					if l.ID == i.ID {
+						return true
+					}
+				}
+				return false
+			}
+
+			now := time.Now().UTC()
+			require.NoError(t, p.MarkIdentityDormancyNotified(ctx, i.ID, now))
+			assert.True(t, isListed(t, ActivityFilter{NotifiedOfDormancyBefore: now.Add(time.Minute)}))
+			assert.False(t, isListed(t, ActivityFilter{NotifiedOfDormancyBefore: now.Add(-time.Minute)}))
+
+			// Identities deactivated using the Admin API are not considered deactivated by the dormancy policy.
+			require.NoError(t, p.UpdateIdentityState(ctx, i.ID, StateInactive, now))
+			assert.False(t, isListed(t, ActivityFilter{DeactivatedForDormancyBefore: now.Add(time.Minute)}))
+
+			require.NoError(t, p.DeactivateDormantIdentity(ctx, i.ID, now))
+			require.ErrorIs(t, p.DeactivateDormantIdentity(ctx, x.NewUUID(), now), sqlcon.ErrNoRows)
+			assert.True(t, isListed(t, ActivityFilter{State: StateInactive, DeactivatedForDormancyBefore: now.Add(time.Minute)}))
+			assert.False(t, isListed(t, ActivityFilter{DeactivatedForDormancyBefore: now.Add(-time.Minute)}))
+
+			actual, err := p.GetIdentity(ctx, i.ID)
+			require.NoError(t, err)
+			assert.Equal(t, StateInactive, actual.State)
+			require.NotNil(t, actual.StateChangedAt)
+			assert.EqualValues(t, now.Unix(), actual.StateChangedAt.Unix())
+
+			require.NoError(t, p.RecordIdentityActivity(ctx, i.ID, ActivityLogin, now))
+			assert.False(t, isListed(t, ActivityFilter{DeactivatedForDormancyBefore: now.Add(time.Minute)}))
+			assert.False(t, isListed(t, ActivityFilter{NotifiedOfDormancyBefore: now.Add(time.Minute)}))
+
+			require.NoError(t, p.DeactivateDormantIdentity(ctx, i.ID, now))
+			require.NoError(t, p.UpdateIdentityState(ctx, i.ID, StateActive, now))
+			assert.False(t, isListed(t, ActivityFilter{DeactivatedForDormancyBefore: now.Add(time.Minute)}))
+		})
+
+		t.Run("case=list and mark verification reminder candidates", func(t *testing.T) {
+			i := NewIdentity(config.DefaultIdentityTraitsSchemaID)
+			i.Traits = Traits(`{}`)
+			i.VerifiableAddresses = []VerifiableAddress{*NewVerifiableEmailAddress("reminder.TestPool@ory.sh", i.ID)}
+			require.NoError(t, p.CreateIdentity(ctx, i))
+			createdIDs = append(createdIDs, i.ID)
+			address := i.VerifiableAddresses[0]
+
+			isCandidate := func(t *testing.T, createdBefore time.Time, maxReminders int) bool {
+				as, err := p.ListVerificationReminderCandidates(ctx, time.Time{}, createdBefore, maxReminders, 0, 500)
+				require.NoError(t, err)
+				for _, a := range as {
+					if a.ID == address.ID {
+						return true
+					}
+				}
+				return false
+			}
+
+			now := time.Now().UTC()
+			assert.True(t, isCandidate(t, now.Add(time.Minute), 2))
+			assert.False(t, isCandidate(t, now.Add(-time.Hour), 2), "the address was added after createdBefore")
+
+			as, err := p.ListVerificationReminderCandidates(ctx, now.Add(time.Minute), now.Add(time.Hour), 2, 0, 500)
+			require.NoError(t, err)
+			for _, a := range as {
+				assert.NotEqual(t, address.ID, a.ID, "the address was added before createdAfter")
+			}
+
+			require.NoError(t, p.MarkVerificationRemindersSent(ctx, address.ID, 1, now))
+			require.ErrorIs(t, p.MarkVerificationRemindersSent(ctx, x.NewUUID(), 1, now), sqlcon.ErrNoRows)
+			assert.True(t, isCandidate(t, now.Add(time.Minute), 2))
+			assert.False(t, isCandidate(t, now.Add(time.Minute), 1))
+
+			actual, err := p.GetIdentity(ctx, i.ID)
+			require.NoError(t, err)
+			require.Len(t, actual.VerifiableAddresses, 1)
+			assert.Equal(t, 1, actual.VerifiableAddresses[0].RemindersSent)
+			assert.True(t, actual.VerifiableAddresses[0].RemindedAt.Valid)
+
+			require.NoError(t, p.UpdateIdentity(ctx, actual))
+			assert.False(t, isCandidate(t, now.Add(time.Minute), 1), "updating the identity keeps the reminders")
+
+			actual.VerifiableAddresses[0].Verified = true
+			actual.VerifiableAddresses[0].Status = VerifiableAddressStatusCompleted
+			require.NoError(t, p.UpdateVerifiableAddress(ctx, &actual.VerifiableAddresses[0]))
+			assert.False(t, isCandidate(t, now.Add(time.Minute), 2), "verified addresses are not reminded")
+		})
+
+		t.Run("case=anonymized stubs", func(t *testing.T) {
+			i := NewIdentity(config.DefaultIdentityTraitsSchemaID)
+			i.SetCredentials(CredentialsTypePassword, Credentials{Identifiers: []string{"stub@ory.sh"}, Config: []byte(`{}`)})
+			i.CreatedAt = time.Now().UTC().Add(-time.Hour)
+
+			now := time.Now().UTC()
+			stubs := NewAnonymizedStubs(i, []byte("secret"), now, time.Hour)
+			require.Len(t, stubs, 1)
+			expired := NewAnonymizedStubs(i, []byte("secret"), now.Add(-2*time.Hour), time.Hour)
+			forever := NewAnonymizedStubs(i, []byte("other-secret"), now, 0)
+			require.NoError(t, p.CreateAnonymizedStubs(ctx, append(append(stubs, expired...), forever...)))
+
+			hashes := HashIdentifiers([][]byte{[]byte("secret"), []byte("other-secret")}, "stub@ory.sh")
+			actual, err := p.FindAnonymizedStubs(ctx, hashes, now)
+			require.NoError(t, err)
+			require.Len(t, actual, 2)
+
+			actual, err = p.FindAnonymizedStubs(ctx, hashes[:1], now)
+			require.NoError(t, err)
+			require.Len(t, actual, 1)
+			assert.Equal(t, stubs[0].ID, actual[0].ID)
+			assert.Equal(t, i.CreatedAt.Unix(), actual[0].IdentityCreatedAt.Unix())
+
+			actual, err = p.FindAnonymizedStubs(ctx, nil, now)
+			require.NoError(t, err)
+			assert.Empty(t, actual)
+
+			require.NoError(t, p.DeleteExpiredAnonymizedStubs(ctx, now.Add(2*time.Hour)))
+			actual, err = p.FindAnonymizedStubs(ctx, hashes, now)
+			require.NoError(t, err)
+			require.Len(t, actual, 1)
+			assert.Equal(t, forever[0].ID, actual[0].ID)
+			assert.Nil(t, actual[0].ExpiresAt)
+		})
+
+		t.Run("case=count by schema", func(t *testing.T) {
+			before, err := p.CountIdentitiesBySchema(ctx)
+			require.NoError(t, err)
+
+			i := NewIdentity(config.DefaultIdentityTraitsSchemaID)
+			i.Traits = Traits(`{}`)
+			require.NoError(t, p.CreateIdentity(ctx, i))
+			createdIDs = append(createdIDs, i.ID)
+
+			after, err := p.CountIdentitiesBySchema(ctx)
+			require.NoError(t, err)
+			assert.EqualValues(t, before[config.DefaultIdentityTraitsSchemaID]+1, after[config.DefaultIdentityTraitsSchemaID])
+		})
+
 		t.Run("case=find identity by its credentials identifier", func(t *testing.T) {
 			expected := passwordIdentity("", "find-credentials-identifier@ory.sh")
 			expected.Traits = Traits(`{}`)
@@ -414,6 +781,32 @@ func TestPool(ctx context.Context, conf *config.Config, p interface {
 			assertEqual(t, expected, actual)
 		})
 
+		t.Run("case=list identifiers", func(t *testing.T) {
+			pi := passwordIdentity("", "list-identifiers-"+x.NewUUID().String()+"@ory.sh")
+			pi.Traits = Traits(`{}`)
+			pi.VerifiableAddresses = []VerifiableAddress{*NewVerifiableEmailAddress("list-identifiers-verify@ory.sh", pi.ID)}
+			pi.RecoveryAddresses = []RecoveryAddress{*NewRecoveryEmailAddress("list-identifiers-recovery@ory.sh", pi.ID)}
+			require.NoError(t, p.CreateIdentity(ctx, pi))
+			createdIDs = append(createdIDs, pi.ID)
+
+			oi := oidcIdentity("", "google:list-identifiers-"+x.NewUUID().String())
+			oi.Traits = Traits(`{}`)
+			require.NoError(t, p.CreateIdentity(ctx, oi))
+			createdIDs = append(createdIDs, oi.ID)
+
+			identifiers, err := p.ListIdentifiers(ctx)
+			require.NoError(t, err)
+
+			for _, expected := range []IdentityIdentifier{
+				{IdentityID: pi.ID, Type: string(CredentialsTypePassword), Value: pi.Credentials[CredentialsTypePassword].Identifiers[0]},
+				{IdentityID: pi.ID, Type: string(VerifiableAddressTypeEmail), Value: "list-identifiers-verify@ory.sh"},
+				{IdentityID: pi.ID, Type: string(RecoveryAddressTypeEmail), Value: "list-identifiers-recovery@ory.sh"},
+				{IdentityID: oi.ID, Type: string(CredentialsTypeOIDC), Value: oi.Credentials[CredentialsTypeOIDC].Identifiers[0]},
+			} {
+				assert.Contains(t, identifiers, expected)
+			}
+		})
+
 		t.Run("suite=verifiable-address", func(t *testing.T) {
 			createIdentityWithAddresses := func(t *testing.T, email string) VerifiableAddress {
 				var i Identity
@@ -492,6 +885,20 @@ func TestPool(ctx context.Context, conf *config.Config, p interface {
 				assert.Equal(t, VerifiableAddressTypeEmail, actual.Via)
 				assert.Equal(t, "verification.TestPersister.Update-Identity-next@ory.sh", actual.Value)
 			})
+
+			t.Run("case=count unverified", func(t *testing.T) {
+				before, err := p.CountUnverifiedAddresses(ctx)
+				require.NoError(t, err)
+
+				var i Identity
+				require.NoError(t, faker.FakeData(&i))
+				i.VerifiableAddresses = []VerifiableAddress{*NewVerifiableEmailAddress("verification.TestPersister.Count@ory.sh", i.ID)}
+				require.NoError(t, p.CreateIdentity(ctx, &i))
+
+				after, err := p.CountUnverifiedAddresses(ctx)
+				require.NoError(t, err)
+				assert.EqualValues(t, before+1, after)
+			})
 		})
 
 		t.Run("suite=recovery-address", func(t *testing.T) {
@@ -0,0 +1,46 @@
+package identity
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// TermsOfServiceAcceptance records which version of the terms of service the identity accepted and when.
+//
+// swagger:model identityTermsOfServiceAcceptance
+type TermsOfServiceAcceptance struct {
+	// Version is the version of the terms of service which was accepted.
+	Version string `json:"version"`
+
+	// AcceptedAt is the time the terms of service were accepted.
+	AcceptedAt time.Time `json:"accepted_at"`
+}
+
+// HasAccepted returns true if the identity accepted the given version of the terms of service.
+func (a *TermsOfServiceAcceptance) HasAccepted(version string) bool {
+	return a != nil && a.Version == version
+}
+
+func (a *TermsOfServiceAcceptance) Scan(value interface{}) error {
+	switch v := value.(type) {
+	case nil:
+		*a = TermsOfServiceAcceptance{}
+		return nil
+	case string:
+		return errors.WithStack(json.Unmarshal([]byte(v), a))
+	case []byte:
+		return errors.WithStack(json.Unmarshal(v, a))
+	}
+	return errors.Errorf("unable to scan terms of service acceptance from type %T", value)
+}
+
+func (a TermsOfServiceAcceptance) Value() (driver.Value, error) {
+	out, err := json.Marshal(a)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	return string(out), nil
+}
@@ -3,6 +3,7 @@ package identity
 import (
 	"context"
 
+	"github.com/pkg/errors"
 	"github.com/tidwall/sjson"
 
 	"github.com/ory/kratos/driver/config"
@@ -21,12 +22,24 @@ type (
 	ValidationProvider interface {
 		IdentityValidator() *Validator
 	}
+
+	validatorOptions struct {
+		EnforceUsernamePolicy bool
+	}
+
+	ValidatorOption func(*validatorOptions)
 )
 
 func NewValidator(d validatorDependencies) *Validator {
 	return &Validator{v: schema.NewValidator(), d: d}
 }
 
+// ValidatorEnforceUsernamePolicy enforces the username policy. It is used by the self-service flows, whereas
+// identities managed using the admin API are not subject to the username policy.
+func ValidatorEnforceUsernamePolicy(options *validatorOptions) {
+	options.EnforceUsernamePolicy = true
+}
+
 func (v *Validator) ValidateWithRunner(ctx context.Context, i *Identity, runners ...schema.Extension) error {
 	runner, err := schema.NewExtensionRunner(schema.ExtensionRunnerIdentityMetaSchema, runners...)
 	if err != nil {
@@ -46,10 +59,41 @@ func (v *Validator) ValidateWithRunner(ctx context.Context, i *Identity, runners
 	return v.v.Validate(s.URL.String(), traits, schema.WithExtensionRunner(runner))
 }
 
-func (v *Validator) Validate(ctx context.Context, i *Identity) error {
-	return v.ValidateWithRunner(ctx, i,
-		NewSchemaExtensionCredentials(i),
-		NewSchemaExtensionVerification(i, v.d.Config(ctx).SelfServiceFlowVerificationRequestLifespan()),
-		NewSchemaExtensionRecovery(i),
-	)
+func (v *Validator) Validate(ctx context.Context, i *Identity, opts ...ValidatorOption) error {
+	var o validatorOptions
+	for _, f := range opts {
+		f(&o)
+	}
+
+	c := v.d.Config(ctx)
+	runners := []schema.Extension{
+		NewSchemaExtensionCredentials(i, c.IdentifierNormalization()),
+		NewSchemaExtensionVerification(i, c.SelfServiceFlowVerificationRequestLifespan(), c.IdentifierNormalization()),
+		NewSchemaExtensionRecovery(i, c.IdentifierNormalization()),
+	}
+	if o.EnforceUsernamePolicy {
+		runners = append(runners, NewSchemaExtensionUsernamePolicy(c.UsernamePolicy(), c.IdentifierNormalization()))
+	}
+
+	return v.ValidateWithRunner(ctx, i, runners...)
+}
+
+// WarmUp compiles all identity schemas so that the first validation does not have to.
+func (v *Validator) WarmUp(ctx context.Context) error {
+	runner, err := schema.NewExtensionRunner(schema.ExtensionRunnerIdentityMetaSchema)
+	if err != nil {
+		return err
+	}
+
+	for _, s := range v.d.IdentityTraitsSchemas(ctx) {
+		if err := v.v.WarmUp(s.URL.String(), runner); err != nil {
+			return errors.Wrapf(err, "unable to compile identity schema %s", s.ID)
+		}
+	}
+	return nil
+}
+
+// Reset drops the compiled identity schemas. They are compiled again on their next use.
+func (v *Validator) Reset() {
+	v.v.Reset()
 }
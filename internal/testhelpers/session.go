@@ -92,7 +92,7 @@ func NewHTTPClientWithSessionToken(t *testing.T, reg *driver.RegistryDefault, se
 }
 
 func NewHTTPClientWithArbitrarySessionToken(t *testing.T, reg *driver.RegistryDefault) *http.Client {
-	return NewHTTPClientWithSessionToken(t, reg, session.NewActiveSession(
+	return NewHTTPClientWithSessionToken(t, reg, session.NewActiveSession(x.SystemClock, x.RandomIDGenerator,
 		&identity.Identity{ID: x.NewUUID()},
 		NewSessionLifespanProvider(time.Hour),
 		time.Now(),
@@ -100,7 +100,7 @@ func NewHTTPClientWithArbitrarySessionToken(t *testing.T, reg *driver.RegistryDe
 }
 
 func NewHTTPClientWithArbitrarySessionCookie(t *testing.T, reg *driver.RegistryDefault) *http.Client {
-	return NewHTTPClientWithSessionCookie(t, reg, session.NewActiveSession(
+	return NewHTTPClientWithSessionCookie(t, reg, session.NewActiveSession(x.SystemClock, x.RandomIDGenerator,
 		&identity.Identity{ID: x.NewUUID()},
 		NewSessionLifespanProvider(time.Hour),
 		time.Now(),
@@ -109,10 +109,10 @@ func NewHTTPClientWithArbitrarySessionCookie(t *testing.T, reg *driver.RegistryD
 
 func NewHTTPClientWithIdentitySessionCookie(t *testing.T, reg *driver.RegistryDefault, id *identity.Identity) *http.Client {
 	return NewHTTPClientWithSessionCookie(t, reg,
-		session.NewActiveSession(id, NewSessionLifespanProvider(time.Hour), time.Now()))
+		session.NewActiveSession(x.SystemClock, x.RandomIDGenerator, id, NewSessionLifespanProvider(time.Hour), time.Now()))
 }
 
 func NewHTTPClientWithIdentitySessionToken(t *testing.T, reg *driver.RegistryDefault, id *identity.Identity) *http.Client {
 	return NewHTTPClientWithSessionToken(t, reg,
-		session.NewActiveSession(id, NewSessionLifespanProvider(time.Hour), time.Now()))
+		session.NewActiveSession(x.SystemClock, x.RandomIDGenerator, id, NewSessionLifespanProvider(time.Hour), time.Now()))
 }
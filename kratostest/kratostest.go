@@ -0,0 +1,187 @@
+// Package kratostest provides an in-process ORY Kratos instance for integration tests of services which depend
+// on ORY Kratos. The instance uses an in-memory SQLite database, relaxed CSRF protection and a courier which
+// captures messages instead of sending them.
+//
+//	ts := kratostest.New(t)
+//	i := ts.CreateIdentity(t, `{"email":"foo@example.org"}`, "some-password")
+//	res, err := http.Get(ts.Public.URL + "/sessions/whoami")
+package kratostest
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ory/kratos/courier"
+	"github.com/ory/kratos/driver"
+	"github.com/ory/kratos/driver/config"
+	"github.com/ory/kratos/identity"
+	"github.com/ory/kratos/internal"
+	"github.com/ory/kratos/internal/testhelpers"
+	"github.com/ory/kratos/selfservice/flow/recovery"
+	"github.com/ory/kratos/selfservice/flow/settings"
+	"github.com/ory/kratos/selfservice/flow/verification"
+	"github.com/ory/kratos/selfservice/strategy/password"
+	"github.com/ory/kratos/x"
+)
+
+// DefaultIdentitySchema is used unless WithIdentitySchema is set. Its `email` trait is the password
+// identifier as well as the verification and recovery address.
+const DefaultIdentitySchema = `{
+  "$id": "https://schemas.ory.sh/kratostest/default.schema.json",
+  "$schema": "http://json-schema.org/draft-07/schema#",
+  "type": "object",
+  "properties": {
+    "traits": {
+      "type": "object",
+      "properties": {
+        "email": {
+          "type": "string",
+          "format": "email",
+          "ory.sh/kratos": {
+            "credentials": {
+              "password": {
+                "identifier": true
+              }
+            },
+            "verification": {
+              "via": "email"
+            },
+            "recovery": {
+              "via": "email"
+            }
+          }
+        }
+      },
+      "required": ["email"],
+      "additionalProperties": false
+    }
+  }
+}`
+
+type (
+	// Server is an in-process ORY Kratos instance.
+	Server struct {
+		// Public is the server exposing the public API.
+		Public *httptest.Server
+
+		// Admin is the server exposing the admin API.
+		Admin *httptest.Server
+
+		// Config is the instance's configuration. Changes are applied immediately.
+		Config *config.Config
+
+		// Registry gives access to the instance's internals.
+		Registry *driver.RegistryDefault
+
+		sync.Mutex
+		messages []courier.Message
+	}
+
+	// Option modifies the configuration or registry before the servers are started.
+	Option func(t *testing.T, s *Server)
+)
+
+// WithIdentitySchema sets the default identity schema. The location must be a URL, e.g. `file://path/to/schema.json`.
+func WithIdentitySchema(location string) Option {
+	return func(t *testing.T, s *Server) {
+		s.Config.MustSet(config.ViperKeyDefaultIdentitySchemaURL, location)
+	}
+}
+
+// WithConfig sets a configuration value, for example `selfservice.flows.settings.privileged_session_max_age`.
+func WithConfig(key string, value interface{}) Option {
+	return func(t *testing.T, s *Server) {
+		s.Config.MustSet(key, value)
+	}
+}
+
+// WithClock replaces the wall clock, for example with a x.FakeClock to expire flows and sessions without sleeping.
+func WithClock(c x.Clock) Option {
+	return func(t *testing.T, s *Server) {
+		s.Registry.WithClock(c)
+	}
+}
+
+// WithIDGenerator replaces the random ID generator, for example with a x.SequentialIDGenerator.
+func WithIDGenerator(g x.IDGenerator) Option {
+	return func(t *testing.T, s *Server) {
+		s.Registry.WithIDGenerator(g)
+	}
+}
+
+// New starts an ORY Kratos instance with the password, profile and link strategies as well as the recovery and
+// verification flows enabled. The servers are stopped when the test ends.
+func New(t *testing.T, opts ...Option) *Server {
+	conf, reg := internal.NewFastRegistryWithMocks(t)
+
+	schemaPath := filepath.Join(t.TempDir(), "identity.schema.json")
+	require.NoError(t, ioutil.WriteFile(schemaPath, []byte(DefaultIdentitySchema), 0600))
+	testhelpers.SetDefaultIdentitySchema(t, conf, "file://"+filepath.ToSlash(schemaPath))
+
+	testhelpers.StrategyEnable(t, conf, identity.CredentialsTypePassword.String(), true)
+	testhelpers.StrategyEnable(t, conf, settings.StrategyProfile, true)
+	testhelpers.StrategyEnable(t, conf, recovery.StrategyRecoveryLinkName, true)
+	testhelpers.StrategyEnable(t, conf, verification.StrategyVerificationLinkName, true)
+	conf.MustSet(config.ViperKeySelfServiceRecoveryEnabled, true)
+	conf.MustSet(config.ViperKeySelfServiceVerificationEnabled, true)
+
+	s := &Server{Config: conf, Registry: reg}
+	for _, opt := range opts {
+		opt(t, s)
+	}
+
+	s.Public, s.Admin = testhelpers.NewKratosServer(t, reg)
+	return s
+}
+
+// CreateIdentity creates an identity with the given traits and password using the default identity schema.
+func (s *Server) CreateIdentity(t *testing.T, traits string, pw string) *identity.Identity {
+	i, err := password.NewIdentityWithPassword(context.Background(), s.Registry.Hasher(), "", json.RawMessage(traits), pw)
+	require.NoError(t, err)
+	require.NoError(t, s.Registry.IdentityManager().Create(context.Background(), i))
+	return i
+}
+
+// Messages returns all messages the courier captured so far, oldest first. Messages are never sent.
+func (s *Server) Messages(t *testing.T) []courier.Message {
+	s.Lock()
+	defer s.Unlock()
+
+	for {
+		next, err := s.Registry.CourierPersister().NextMessages(context.Background(), 255)
+		if errors.Is(err, courier.ErrQueueEmpty) {
+			break
+		}
+		require.NoError(t, err)
+		s.messages = append(s.messages, next...)
+	}
+
+	return append([]courier.Message{}, s.messages...)
+}
+
+// LatestMessageTo returns the most recent message sent to recipient and fails the test if there is none.
+func (s *Server) LatestMessageTo(t *testing.T, recipient string) *courier.Message {
+	messages := s.Messages(t)
+	for k := len(messages) - 1; k >= 0; k-- {
+		if strings.EqualFold(messages[k].Recipient, recipient) {
+			return &messages[k]
+		}
+	}
+
+	require.FailNowf(t, "no message found", "No message was sent to %s.", recipient)
+	return nil
+}
+
+// LatestLinkTo returns the recovery or verification link of the most recent message sent to recipient.
+func (s *Server) LatestLinkTo(t *testing.T, recipient string) string {
+	return testhelpers.CourierExpectLinkInMessage(t, s.LatestMessageTo(t, recipient), 1)
+}
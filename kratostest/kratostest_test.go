@@ -0,0 +1,70 @@
+package kratostest_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tidwall/gjson"
+
+	"github.com/ory/kratos/kratostest"
+	"github.com/ory/kratos/selfservice/flow/login"
+	"github.com/ory/kratos/selfservice/flow/recovery"
+	"github.com/ory/kratos/selfservice/strategy/link"
+)
+
+func submitAPIFlow(t *testing.T, ts *kratostest.Server, initRoute, method string, payload interface{}) string {
+	res, err := http.Get(ts.Public.URL + initRoute)
+	require.NoError(t, err)
+	defer res.Body.Close()
+	flow, err := ioutil.ReadAll(res.Body)
+	require.NoError(t, err)
+	require.EqualValues(t, http.StatusOK, res.StatusCode, "%s", flow)
+
+	action := gjson.GetBytes(flow, "methods."+method+".config.action").String()
+	require.NotEmpty(t, action, "%s", flow)
+
+	var b bytes.Buffer
+	require.NoError(t, json.NewEncoder(&b).Encode(payload))
+	res, err = http.Post(action, "application/json", &b)
+	require.NoError(t, err)
+	defer res.Body.Close()
+	body, err := ioutil.ReadAll(res.Body)
+	require.NoError(t, err)
+	require.EqualValues(t, http.StatusOK, res.StatusCode, "%s", body)
+	return string(body)
+}
+
+func TestServer(t *testing.T) {
+	ts := kratostest.New(t)
+	i := ts.CreateIdentity(t, `{"email":"kratostest@ory.sh"}`, "5g2Yp9fqgm5Qfg2T")
+
+	t.Run("case=login with the created identity", func(t *testing.T) {
+		body := submitAPIFlow(t, ts, login.RouteInitAPIFlow, "password", map[string]string{
+			"identifier": "kratostest@ory.sh",
+			"password":   "5g2Yp9fqgm5Qfg2T",
+		})
+		assert.NotEmpty(t, gjson.Get(body, "session_token").String(), "%s", body)
+		assert.Equal(t, i.ID.String(), gjson.Get(body, "session.identity.id").String(), "%s", body)
+	})
+
+	t.Run("case=captures the recovery message", func(t *testing.T) {
+		submitAPIFlow(t, ts, recovery.RouteInitAPIFlow, "link", map[string]string{"email": "kratostest@ory.sh"})
+
+		message := ts.LatestMessageTo(t, "kratostest@ory.sh")
+		assert.Contains(t, message.Subject, "Recover access to your account")
+		assert.True(t, strings.HasPrefix(ts.LatestLinkTo(t, "kratostest@ory.sh"), ts.Public.URL+link.RouteRecovery), "%s", message.Body)
+		assert.Len(t, ts.Messages(t), 1)
+	})
+
+	t.Run("case=options are applied", func(t *testing.T) {
+		ts := kratostest.New(t, kratostest.WithConfig("selfservice.flows.recovery.enabled", false))
+		assert.False(t, ts.Config.SelfServiceFlowRecoveryEnabled())
+		assert.Empty(t, ts.Messages(t))
+	})
+}
@@ -0,0 +1,160 @@
+package maintenance
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/julienschmidt/httprouter"
+	"github.com/pkg/errors"
+
+	"github.com/ory/herodot"
+	"github.com/ory/x/jsonx"
+
+	"github.com/ory/kratos/driver/config"
+	"github.com/ory/kratos/x"
+)
+
+const (
+	RouteMaintenance = "/maintenance"
+
+	// selfServicePrefix is the path prefix of all public self-service endpoints.
+	selfServicePrefix = "/self-service/"
+)
+
+var ErrMaintenance = herodot.DefaultError{
+	StatusField: http.StatusText(http.StatusServiceUnavailable),
+	ErrorField:  "Service Unavailable",
+	CodeField:   http.StatusServiceUnavailable,
+}
+
+type (
+	handlerDependencies interface {
+		config.Provider
+		x.WriterProvider
+	}
+	HandlerProvider interface {
+		MaintenanceHandler() *Handler
+	}
+	// Handler puts the public self-service endpoints into maintenance mode.
+	//
+	// Maintenance mode is enabled using the configuration or the admin API. A value set using the admin API
+	// takes precedence over the configuration until it is set again or ORY Kratos restarts.
+	Handler struct {
+		d  handlerDependencies
+		mu sync.RWMutex
+
+		enabled *bool
+	}
+
+	// Maintenance Mode
+	//
+	// swagger:model maintenanceMode
+	Mode struct {
+		// Enabled is true if the public self-service endpoints are in maintenance mode.
+		//
+		// required: true
+		Enabled bool `json:"enabled"`
+	}
+)
+
+func NewHandler(d handlerDependencies) *Handler {
+	return &Handler{d: d}
+}
+
+func (h *Handler) RegisterAdminRoutes(admin *x.RouterAdmin) {
+	admin.GET(RouteMaintenance, h.get)
+	admin.PUT(RouteMaintenance, h.set)
+}
+
+// IsEnabled returns true if the public self-service endpoints are in maintenance mode.
+func (h *Handler) IsEnabled(ctx context.Context) bool {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	if h.enabled != nil {
+		return *h.enabled
+	}
+	return h.d.Config(ctx).IsMaintenanceEnabled()
+}
+
+// SetEnabled enables or disables maintenance mode regardless of the configuration.
+func (h *Handler) SetEnabled(enabled bool) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.enabled = &enabled
+}
+
+// ServeHTTP is a negroni middleware which responds with 503 Service Unavailable to requests to the
+// self-service endpoints while maintenance mode is enabled. All other endpoints, such as whoami, keep working.
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request, next http.HandlerFunc) {
+	if !strings.HasPrefix(r.URL.Path, selfServicePrefix) || !h.IsEnabled(r.Context()) {
+		next(w, r)
+		return
+	}
+
+	c := h.d.Config(r.Context())
+	w.Header().Set("Retry-After", strconv.Itoa(int(c.MaintenanceRetryAfter().Seconds())))
+	h.d.Writer().WriteError(w, r, errors.WithStack(ErrMaintenance.WithReason(c.MaintenanceMessage())))
+}
+
+// swagger:route GET /maintenance admin getMaintenanceMode
+//
+// Get the Maintenance Mode
+//
+// Returns whether the public self-service endpoints are in maintenance mode.
+//
+//     Produces:
+//     - application/json
+//
+//     Schemes: http, https
+//
+//     Responses:
+//       200: maintenanceMode
+//       500: genericError
+func (h *Handler) get(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+	h.d.Writer().Write(w, r, &Mode{Enabled: h.IsEnabled(r.Context())})
+}
+
+// swagger:parameters setMaintenanceMode
+// nolint:deadcode,unused
+type setMaintenanceModeParameters struct {
+	// in: body
+	Body Mode
+}
+
+// swagger:route PUT /maintenance admin setMaintenanceMode
+//
+// Enable or Disable the Maintenance Mode
+//
+// While maintenance mode is enabled, the public self-service endpoints respond with 503 Service Unavailable
+// and a `Retry-After` header. The whoami endpoint keeps working.
+//
+// The value overrides `maintenance.enabled` of the configuration until ORY Kratos restarts. It only applies
+// to the instance receiving the request.
+//
+//     Consumes:
+//     - application/json
+//
+//     Produces:
+//     - application/json
+//
+//     Schemes: http, https
+//
+//     Responses:
+//       200: maintenanceMode
+//       400: genericError
+//       500: genericError
+func (h *Handler) set(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+	var m Mode
+	if err := jsonx.NewStrictDecoder(r.Body).Decode(&m); err != nil {
+		h.d.Writer().WriteErrorCode(w, r, http.StatusBadRequest, errors.WithStack(err))
+		return
+	}
+
+	h.SetEnabled(m.Enabled)
+	h.d.Writer().Write(w, r, &Mode{Enabled: h.IsEnabled(r.Context())})
+}
@@ -0,0 +1,87 @@
+package maintenance_test
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tidwall/gjson"
+
+	"github.com/ory/kratos/driver/config"
+	"github.com/ory/kratos/internal"
+	"github.com/ory/kratos/maintenance"
+	"github.com/ory/kratos/x"
+)
+
+func TestHandler(t *testing.T) {
+	conf, reg := internal.NewFastRegistryWithMocks(t)
+	conf.MustSet(config.ViperKeyMaintenanceRetryAfter, "10m")
+	conf.MustSet(config.ViperKeyMaintenanceMessage, "Back soon!")
+
+	h := maintenance.NewHandler(reg)
+	serve := func(t *testing.T, path string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, httptest.NewRequest("GET", path, nil), func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusNoContent)
+		})
+		return w
+	}
+
+	t.Run("case=passes requests if disabled", func(t *testing.T) {
+		assert.Equal(t, http.StatusNoContent, serve(t, "/self-service/login/browser").Code)
+	})
+
+	t.Run("case=blocks self-service requests if enabled in the config", func(t *testing.T) {
+		conf.MustSet(config.ViperKeyMaintenanceEnabled, true)
+		t.Cleanup(func() {
+			conf.MustSet(config.ViperKeyMaintenanceEnabled, false)
+		})
+
+		res := serve(t, "/self-service/login/browser")
+		assert.Equal(t, http.StatusServiceUnavailable, res.Code)
+		assert.Equal(t, "600", res.Header().Get("Retry-After"))
+		assert.Equal(t, "Back soon!", gjson.GetBytes(res.Body.Bytes(), "error.reason").String(), "%s", res.Body.Bytes())
+
+		assert.Equal(t, http.StatusNoContent, serve(t, "/sessions/whoami").Code)
+		assert.Equal(t, http.StatusNoContent, serve(t, "/health/alive").Code)
+	})
+
+	t.Run("case=admin api overrides the config", func(t *testing.T) {
+		router := x.NewRouterAdmin()
+		h.RegisterAdminRoutes(router)
+		ts := httptest.NewServer(router)
+		t.Cleanup(ts.Close)
+
+		set := func(t *testing.T, body string) string {
+			req, err := http.NewRequest("PUT", ts.URL+maintenance.RouteMaintenance, bytes.NewBufferString(body))
+			require.NoError(t, err)
+			res, err := ts.Client().Do(req)
+			require.NoError(t, err)
+			defer res.Body.Close()
+
+			var buf bytes.Buffer
+			_, err = buf.ReadFrom(res.Body)
+			require.NoError(t, err)
+			assert.Equal(t, http.StatusOK, res.StatusCode, "%s", buf.String())
+			return buf.String()
+		}
+
+		assert.True(t, gjson.Get(set(t, `{"enabled":true}`), "enabled").Bool())
+		assert.Equal(t, http.StatusServiceUnavailable, serve(t, "/self-service/registration/api").Code)
+
+		res, err := ts.Client().Get(ts.URL + maintenance.RouteMaintenance)
+		require.NoError(t, err)
+		defer res.Body.Close()
+		var buf bytes.Buffer
+		_, err = buf.ReadFrom(res.Body)
+		require.NoError(t, err)
+		assert.True(t, gjson.Get(buf.String(), "enabled").Bool(), "%s", buf.String())
+
+		conf.MustSet(config.ViperKeyMaintenanceEnabled, true)
+		assert.False(t, gjson.Get(set(t, `{"enabled":false}`), "enabled").Bool())
+		assert.Equal(t, http.StatusNoContent, serve(t, "/self-service/registration/api").Code)
+	})
+}
@@ -0,0 +1,108 @@
+package prometheus
+
+import (
+	"context"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/ory/kratos/driver/config"
+	"github.com/ory/kratos/identity"
+	"github.com/ory/kratos/session"
+	"github.com/ory/kratos/x"
+)
+
+var (
+	identitiesTotal = prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: "kratos_identities_total",
+		Help: "Number of identities.",
+	})
+	identitiesBySchema = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "kratos_identities_by_schema",
+		Help: "Number of identities per identity schema.",
+	}, []string{"schema_id"})
+	sessionsActive = prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: "kratos_sessions_active",
+		Help: "Number of sessions which are active and not expired.",
+	})
+	verificationsPending = prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: "kratos_verifications_pending",
+		Help: "Number of verifiable addresses which have not been verified yet.",
+	})
+)
+
+func init() {
+	prometheus.MustRegister(identitiesTotal, identitiesBySchema, sessionsActive, verificationsPending)
+}
+
+type (
+	gaugeRefresherDependencies interface {
+		config.Provider
+		identity.PoolProvider
+		session.PersistenceProvider
+		x.LoggingProvider
+	}
+	// GaugeRefresher periodically refreshes the gauges counting identities, sessions, and pending
+	// verifications so that capacity dashboards do not need to query the database.
+	GaugeRefresher struct {
+		d gaugeRefresherDependencies
+	}
+)
+
+func NewGaugeRefresher(d gaugeRefresherDependencies) *GaugeRefresher {
+	return &GaugeRefresher{d: d}
+}
+
+// Refresh counts identities, sessions, and pending verifications and updates the gauges.
+func (g *GaugeRefresher) Refresh(ctx context.Context) error {
+	bySchema, err := g.d.IdentityPool().CountIdentitiesBySchema(ctx)
+	if err != nil {
+		return err
+	}
+
+	active, err := g.d.SessionPersister().CountActiveSessions(ctx)
+	if err != nil {
+		return err
+	}
+
+	pending, err := g.d.IdentityPool().CountUnverifiedAddresses(ctx)
+	if err != nil {
+		return err
+	}
+
+	var total int64
+	identitiesBySchema.Reset()
+	for schemaID, count := range bySchema {
+		identitiesBySchema.WithLabelValues(schemaID).Set(float64(count))
+		total += count
+	}
+
+	identitiesTotal.Set(float64(total))
+	sessionsActive.Set(float64(active))
+	verificationsPending.Set(float64(pending))
+	return nil
+}
+
+// Watch refreshes the gauges in the configured interval until the context is canceled. It does nothing
+// if the interval is zero.
+func (g *GaugeRefresher) Watch(ctx context.Context) {
+	interval := g.d.Config(ctx).MetricsGaugesRefreshInterval()
+	if interval <= 0 {
+		return
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if err := g.Refresh(ctx); err != nil {
+			g.d.Logger().WithError(err).Error("Unable to refresh the metrics gauges.")
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
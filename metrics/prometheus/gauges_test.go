@@ -0,0 +1,57 @@
+package prometheus_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/prometheus/common/expfmt"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ory/kratos/driver/config"
+	"github.com/ory/kratos/identity"
+	"github.com/ory/kratos/metrics/prometheus"
+
+	"github.com/ory/kratos/internal"
+	"github.com/ory/kratos/x"
+)
+
+func TestGaugeRefresher(t *testing.T) {
+	conf, reg := internal.NewFastRegistryWithMocks(t)
+	conf.MustSet(config.ViperKeyDefaultIdentitySchemaURL, "file://../../test/stub/identity/empty.schema.json")
+	router := x.NewRouterAdmin()
+	reg.MetricsHandler().SetRoutes(router.Router)
+	ts := httptest.NewServer(router)
+	defer ts.Close()
+
+	ctx := context.Background()
+	for k := 0; k < 2; k++ {
+		i := identity.NewIdentity(config.DefaultIdentityTraitsSchemaID)
+		i.Traits = identity.Traits(`{}`)
+		require.NoError(t, reg.IdentityManager().Create(ctx, i))
+	}
+
+	require.NoError(t, reg.MetricsGaugeRefresher().Refresh(ctx))
+
+	response, err := http.DefaultClient.Get(ts.URL + prometheus.MetricsPrometheusPath)
+	require.NoError(t, err)
+	defer response.Body.Close()
+
+	textParser := expfmt.TextParser{}
+	families, err := textParser.TextToMetricFamilies(response.Body)
+	require.NoError(t, err)
+
+	require.Contains(t, families, "kratos_identities_total")
+	assert.EqualValues(t, 2, families["kratos_identities_total"].Metric[0].GetGauge().GetValue())
+
+	require.Contains(t, families, "kratos_identities_by_schema")
+	bySchema := families["kratos_identities_by_schema"].Metric
+	require.Len(t, bySchema, 1)
+	assert.Equal(t, config.DefaultIdentityTraitsSchemaID, bySchema[0].Label[0].GetValue())
+	assert.EqualValues(t, 2, bySchema[0].GetGauge().GetValue())
+
+	require.Contains(t, families, "kratos_sessions_active")
+	assert.EqualValues(t, 0, families["kratos_sessions_active"].Metric[0].GetGauge().GetValue())
+}
@@ -9,6 +9,7 @@ import (
 
 	"github.com/ory/kratos/continuity"
 	"github.com/ory/kratos/courier"
+	"github.com/ory/kratos/idempotency"
 	"github.com/ory/kratos/identity"
 	"github.com/ory/kratos/selfservice/errorx"
 	"github.com/ory/kratos/selfservice/flow/login"
@@ -24,8 +25,33 @@ type Provider interface {
 	Persister() Persister
 }
 
+// MigrationPhase selects a subset of the SQL migrations for zero-downtime (expand/contract) upgrades.
+type MigrationPhase string
+
+const (
+	// MigrationPhaseAll selects all migrations.
+	MigrationPhaseAll MigrationPhase = ""
+
+	// MigrationPhaseExpand selects the additive migrations which are applied before deploying a new version.
+	// Both the old and the new version work with the expanded schema.
+	MigrationPhaseExpand MigrationPhase = "expand"
+
+	// MigrationPhaseContract selects the destructive migrations, whose names end in `_contract`. They are
+	// applied once no instance of the old version is running anymore.
+	MigrationPhaseContract MigrationPhase = "contract"
+)
+
+// PlannedMigration is a migration which would be executed by MigrateUp or MigrateDown.
+type PlannedMigration struct {
+	Version   string `json:"version"`
+	Name      string `json:"name"`
+	Direction string `json:"direction"`
+	SQL       string `json:"sql"`
+}
+
 type Persister interface {
 	continuity.Persister
+	idempotency.Persister
 	identity.PrivilegedPool
 	registration.FlowPersister
 	login.FlowPersister
@@ -40,9 +66,14 @@ type Persister interface {
 
 	Close(context.Context) error
 	Ping() error
+	BackupSQLite(c context.Context, path string) error
 	MigrationStatus(c context.Context) (popx.MigrationStatuses, error)
 	MigrateDown(c context.Context, steps int) error
 	MigrateUp(c context.Context) error
+	MigrateUpPhase(c context.Context, phase MigrationPhase) error
+	MigrationStatusPhase(c context.Context, phase MigrationPhase) (popx.MigrationStatuses, error)
+	MigrationPlanUp(c context.Context, phase MigrationPhase) ([]PlannedMigration, error)
+	MigrationPlanDown(c context.Context, steps int) ([]PlannedMigration, error)
 	Migrator() *popx.Migrator
 	GetConnection(ctx context.Context) *pop.Connection
 	Transaction(ctx context.Context, callback func(ctx context.Context, connection *pop.Connection) error) error
@@ -0,0 +1 @@
+INSERT INTO identities (id, schema_id, traits, communication_preferences, created_at, updated_at) VALUES ('308929c3-6b2d-4b4a-a4a4-0ea31ba4f3a5', 'default', '{"email":"3089@ory.sh"}', '{"no_marketing":true,"preferred_locale":"de-CH"}', '2013-10-07 08:23:19', '2013-10-07 08:23:19');
@@ -0,0 +1 @@
+INSERT INTO identities (id, schema_id, traits, communication_preferences, terms_of_service, created_at, updated_at) VALUES ('8e7a1b6c-3f0d-4f4e-9a39-5d2c6b1f0e21', 'default', '{"email":"8e7a@ory.sh"}', NULL, '{"version":"2021-04","accepted_at":"2021-04-22T09:00:00Z"}', '2013-10-07 08:23:19', '2013-10-07 08:23:19');
@@ -0,0 +1 @@
+INSERT INTO sessions (id, issued_at, expires_at, authenticated_at, created_at, updated_at, token, identity_id, active, privileged_until) VALUES ('b9a3d7c2-6e41-4f0a-8d5b-2c7e9f1a4b63', '2013-10-07 08:23:19', '2013-10-07 08:23:19', '2013-10-07 08:23:19', '2013-10-07 08:23:19', '2013-10-07 08:23:19', '5b2e8a9f1c4d4e7b9a3f6c2d8e1b7a40', '5ff66179-c240-4703-b0d8-494592cefff5', true, '2013-10-07 08:38:19');
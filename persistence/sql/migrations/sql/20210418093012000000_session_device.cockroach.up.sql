@@ -0,0 +1 @@
+ALTER TABLE "sessions" ADD COLUMN "device" json;
\ No newline at end of file
@@ -0,0 +1 @@
+ALTER TABLE `sessions` DROP COLUMN `device`;
\ No newline at end of file
@@ -0,0 +1 @@
+ALTER TABLE `sessions` ADD COLUMN `device` JSON;
\ No newline at end of file
@@ -0,0 +1 @@
+ALTER TABLE "sessions" ADD COLUMN "device" jsonb;
\ No newline at end of file
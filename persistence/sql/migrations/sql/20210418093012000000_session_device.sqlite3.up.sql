@@ -0,0 +1 @@
+ALTER TABLE "sessions" ADD COLUMN "device" TEXT;
\ No newline at end of file
@@ -0,0 +1 @@
+ALTER TABLE "identities" DROP COLUMN "terms_of_service";
\ No newline at end of file
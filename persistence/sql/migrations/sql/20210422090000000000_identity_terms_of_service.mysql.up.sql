@@ -0,0 +1 @@
+ALTER TABLE `identities` ADD COLUMN `terms_of_service` JSON;
\ No newline at end of file
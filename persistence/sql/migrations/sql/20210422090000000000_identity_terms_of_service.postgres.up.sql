@@ -0,0 +1 @@
+ALTER TABLE "identities" ADD COLUMN "terms_of_service" jsonb;
\ No newline at end of file
@@ -0,0 +1,11 @@
+CREATE TABLE "_identities_tmp" (
+"id" TEXT PRIMARY KEY,
+"schema_id" TEXT NOT NULL,
+"traits" TEXT NOT NULL,
+"created_at" DATETIME NOT NULL,
+"updated_at" DATETIME NOT NULL,
+"communication_preferences" TEXT
+);
+INSERT INTO "_identities_tmp" (id, schema_id, traits, created_at, updated_at, communication_preferences) SELECT id, schema_id, traits, created_at, updated_at, communication_preferences FROM "identities";
+DROP TABLE "identities";
+ALTER TABLE "_identities_tmp" RENAME TO "identities";
\ No newline at end of file
@@ -0,0 +1 @@
+ALTER TABLE "identities" ADD COLUMN "terms_of_service" TEXT;
\ No newline at end of file
@@ -0,0 +1 @@
+ALTER TABLE "sessions" DROP COLUMN "privileged_until";
\ No newline at end of file
@@ -0,0 +1 @@
+ALTER TABLE "sessions" ADD COLUMN "privileged_until" timestamp;
\ No newline at end of file
@@ -0,0 +1 @@
+ALTER TABLE "sessions" ADD COLUMN "privileged_until" DATETIME;
\ No newline at end of file
@@ -0,0 +1,8 @@
+CREATE TABLE "courier_suppressions" (
+"id" UUID NOT NULL,
+PRIMARY KEY("id"),
+"address" VARCHAR (255) NOT NULL,
+"reason" VARCHAR (16) NOT NULL,
+"created_at" timestamp NOT NULL,
+"updated_at" timestamp NOT NULL
+);CREATE UNIQUE INDEX "courier_suppressions_address_uq_idx" ON "courier_suppressions" (address);
@@ -0,0 +1 @@
+DROP TABLE "courier_suppressions";
\ No newline at end of file
@@ -0,0 +1,7 @@
+CREATE TABLE "courier_suppressions" (
+"id" TEXT PRIMARY KEY,
+"address" TEXT NOT NULL,
+"reason" TEXT NOT NULL,
+"created_at" DATETIME NOT NULL,
+"updated_at" DATETIME NOT NULL
+);CREATE UNIQUE INDEX "courier_suppressions_address_uq_idx" ON "courier_suppressions" (address);
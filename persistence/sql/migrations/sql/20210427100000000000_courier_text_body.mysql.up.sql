@@ -0,0 +1 @@
+ALTER TABLE `courier_messages` ADD COLUMN `text_body` text;
\ No newline at end of file
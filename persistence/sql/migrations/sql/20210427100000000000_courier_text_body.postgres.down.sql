@@ -0,0 +1 @@
+ALTER TABLE "courier_messages" DROP COLUMN "text_body";
\ No newline at end of file
@@ -0,0 +1 @@
+ALTER TABLE "courier_messages" ADD COLUMN "text_body" TEXT;
\ No newline at end of file
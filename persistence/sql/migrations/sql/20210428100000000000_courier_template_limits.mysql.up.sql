@@ -0,0 +1 @@
+ALTER TABLE `courier_messages` ADD COLUMN `template_type` VARCHAR (64) NOT NULL DEFAULT '';ALTER TABLE `courier_messages` ADD COLUMN `send_after` DATETIME;
\ No newline at end of file
@@ -0,0 +1,15 @@
+CREATE TABLE "_courier_messages_tmp" (
+"id" TEXT PRIMARY KEY,
+"type" INTEGER NOT NULL,
+"status" INTEGER NOT NULL,
+"body" TEXT NOT NULL,
+"subject" TEXT NOT NULL,
+"recipient" TEXT NOT NULL,
+"created_at" DATETIME NOT NULL,
+"updated_at" DATETIME NOT NULL,
+"text_body" TEXT
+);
+INSERT INTO "_courier_messages_tmp" (id, type, status, body, subject, recipient, created_at, updated_at, text_body) SELECT id, type, status, body, subject, recipient, created_at, updated_at, text_body FROM "courier_messages";
+DROP TABLE "courier_messages";
+ALTER TABLE "_courier_messages_tmp" RENAME TO "courier_messages";
+CREATE INDEX "courier_messages_status_idx" ON "courier_messages" (status);
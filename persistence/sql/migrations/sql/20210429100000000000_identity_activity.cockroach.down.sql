@@ -0,0 +1 @@
+ALTER TABLE "identities" DROP COLUMN "password_changed_at";ALTER TABLE "identities" DROP COLUMN "last_seen_at";ALTER TABLE "identities" DROP COLUMN "last_login_at";
\ No newline at end of file
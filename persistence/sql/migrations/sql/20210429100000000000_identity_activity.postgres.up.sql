@@ -0,0 +1 @@
+ALTER TABLE "identities" ADD COLUMN "last_login_at" timestamp;ALTER TABLE "identities" ADD COLUMN "last_seen_at" timestamp;ALTER TABLE "identities" ADD COLUMN "password_changed_at" timestamp;
\ No newline at end of file
@@ -0,0 +1,12 @@
+CREATE TABLE "_identities_tmp" (
+"id" TEXT PRIMARY KEY,
+"schema_id" TEXT NOT NULL,
+"traits" TEXT NOT NULL,
+"created_at" DATETIME NOT NULL,
+"updated_at" DATETIME NOT NULL,
+"communication_preferences" TEXT,
+"terms_of_service" TEXT
+);
+INSERT INTO "_identities_tmp" (id, schema_id, traits, created_at, updated_at, communication_preferences, terms_of_service) SELECT id, schema_id, traits, created_at, updated_at, communication_preferences, terms_of_service FROM "identities";
+DROP TABLE "identities";
+ALTER TABLE "_identities_tmp" RENAME TO "identities";
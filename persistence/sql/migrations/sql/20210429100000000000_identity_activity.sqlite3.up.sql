@@ -0,0 +1 @@
+ALTER TABLE "identities" ADD COLUMN "last_login_at" DATETIME;ALTER TABLE "identities" ADD COLUMN "last_seen_at" DATETIME;ALTER TABLE "identities" ADD COLUMN "password_changed_at" DATETIME;
\ No newline at end of file
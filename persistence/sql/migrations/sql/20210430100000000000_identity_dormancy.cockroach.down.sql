@@ -0,0 +1 @@
+ALTER TABLE "identities" DROP COLUMN "dormancy_notified_at";ALTER TABLE "identities" DROP COLUMN "state_changed_at";ALTER TABLE "identities" DROP COLUMN "state";
\ No newline at end of file
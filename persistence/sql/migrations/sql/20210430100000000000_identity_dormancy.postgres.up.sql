@@ -0,0 +1 @@
+ALTER TABLE "identities" ADD COLUMN "state" VARCHAR (32) NOT NULL DEFAULT 'active';ALTER TABLE "identities" ADD COLUMN "state_changed_at" timestamp;ALTER TABLE "identities" ADD COLUMN "dormancy_notified_at" timestamp;
\ No newline at end of file
@@ -0,0 +1 @@
+DROP TABLE `identity_anonymized_stubs`;
\ No newline at end of file
@@ -0,0 +1,9 @@
+CREATE TABLE `identity_anonymized_stubs` (
+`id` char(36) NOT NULL,
+PRIMARY KEY(`id`),
+`identifier_hash` VARCHAR (64) NOT NULL,
+`identity_created_at` DATETIME NOT NULL,
+`expires_at` DATETIME,
+`created_at` DATETIME NOT NULL,
+`updated_at` DATETIME NOT NULL
+) ENGINE=InnoDB;CREATE INDEX `identity_anonymized_stubs_identifier_hash_idx` ON `identity_anonymized_stubs` (`identifier_hash`);
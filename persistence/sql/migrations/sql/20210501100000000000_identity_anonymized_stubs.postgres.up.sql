@@ -0,0 +1,9 @@
+CREATE TABLE "identity_anonymized_stubs" (
+"id" UUID NOT NULL,
+PRIMARY KEY("id"),
+"identifier_hash" VARCHAR (64) NOT NULL,
+"identity_created_at" timestamp NOT NULL,
+"expires_at" timestamp,
+"created_at" timestamp NOT NULL,
+"updated_at" timestamp NOT NULL
+);CREATE INDEX "identity_anonymized_stubs_identifier_hash_idx" ON "identity_anonymized_stubs" (identifier_hash);
@@ -0,0 +1,8 @@
+CREATE TABLE "identity_anonymized_stubs" (
+"id" TEXT PRIMARY KEY,
+"identifier_hash" TEXT NOT NULL,
+"identity_created_at" DATETIME NOT NULL,
+"expires_at" DATETIME,
+"created_at" DATETIME NOT NULL,
+"updated_at" DATETIME NOT NULL
+);CREATE INDEX "identity_anonymized_stubs_identifier_hash_idx" ON "identity_anonymized_stubs" (identifier_hash);
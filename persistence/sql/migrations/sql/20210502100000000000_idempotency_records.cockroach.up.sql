@@ -0,0 +1,13 @@
+CREATE TABLE "idempotency_records" (
+"id" UUID NOT NULL,
+PRIMARY KEY("id"),
+"idempotency_key" VARCHAR (255) NOT NULL,
+"endpoint" VARCHAR (255) NOT NULL,
+"request_hash" VARCHAR (64) NOT NULL,
+"response_code" integer NOT NULL,
+"response_location" VARCHAR (2048) NOT NULL,
+"response_body" text NOT NULL,
+"expires_at" timestamp NOT NULL,
+"created_at" timestamp NOT NULL,
+"updated_at" timestamp NOT NULL
+);CREATE UNIQUE INDEX "idempotency_records_endpoint_key_idx" ON "idempotency_records" (endpoint, idempotency_key);
\ No newline at end of file
@@ -0,0 +1 @@
+DROP TABLE `idempotency_records`;
\ No newline at end of file
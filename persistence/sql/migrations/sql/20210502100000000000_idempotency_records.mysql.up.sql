@@ -0,0 +1,13 @@
+CREATE TABLE `idempotency_records` (
+`id` char(36) NOT NULL,
+PRIMARY KEY(`id`),
+`idempotency_key` VARCHAR (255) NOT NULL,
+`endpoint` VARCHAR (255) NOT NULL,
+`request_hash` VARCHAR (64) NOT NULL,
+`response_code` INTEGER NOT NULL,
+`response_location` VARCHAR (2048) NOT NULL,
+`response_body` text NOT NULL,
+`expires_at` DATETIME NOT NULL,
+`created_at` DATETIME NOT NULL,
+`updated_at` DATETIME NOT NULL
+) ENGINE=InnoDB;CREATE UNIQUE INDEX `idempotency_records_endpoint_key_idx` ON `idempotency_records` (`endpoint`, `idempotency_key`);
\ No newline at end of file
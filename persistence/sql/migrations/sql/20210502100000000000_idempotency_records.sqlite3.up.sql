@@ -0,0 +1,12 @@
+CREATE TABLE "idempotency_records" (
+"id" TEXT PRIMARY KEY,
+"idempotency_key" TEXT NOT NULL,
+"endpoint" TEXT NOT NULL,
+"request_hash" TEXT NOT NULL,
+"response_code" INTEGER NOT NULL,
+"response_location" TEXT NOT NULL,
+"response_body" TEXT NOT NULL,
+"expires_at" DATETIME NOT NULL,
+"created_at" DATETIME NOT NULL,
+"updated_at" DATETIME NOT NULL
+);CREATE UNIQUE INDEX "idempotency_records_endpoint_key_idx" ON "idempotency_records" (endpoint, idempotency_key);
\ No newline at end of file
@@ -0,0 +1 @@
+ALTER TABLE `identities` DROP COLUMN `updated_by`;
\ No newline at end of file
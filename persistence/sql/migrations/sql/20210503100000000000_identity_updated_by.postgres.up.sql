@@ -0,0 +1 @@
+ALTER TABLE "identities" ADD COLUMN "updated_by" VARCHAR (255);
\ No newline at end of file
@@ -0,0 +1,18 @@
+CREATE TABLE "_identities_tmp" (
+"id" TEXT PRIMARY KEY,
+"schema_id" TEXT NOT NULL,
+"traits" TEXT NOT NULL,
+"created_at" DATETIME NOT NULL,
+"updated_at" DATETIME NOT NULL,
+"communication_preferences" TEXT,
+"terms_of_service" TEXT,
+"last_login_at" DATETIME,
+"last_seen_at" DATETIME,
+"password_changed_at" DATETIME,
+"state" TEXT NOT NULL DEFAULT 'active',
+"state_changed_at" DATETIME,
+"dormancy_notified_at" DATETIME
+);
+INSERT INTO "_identities_tmp" (id, schema_id, traits, created_at, updated_at, communication_preferences, terms_of_service, last_login_at, last_seen_at, password_changed_at, state, state_changed_at, dormancy_notified_at) SELECT id, schema_id, traits, created_at, updated_at, communication_preferences, terms_of_service, last_login_at, last_seen_at, password_changed_at, state, state_changed_at, dormancy_notified_at FROM "identities";
+DROP TABLE "identities";
+ALTER TABLE "_identities_tmp" RENAME TO "identities";
\ No newline at end of file
@@ -0,0 +1 @@
+ALTER TABLE "identities" ADD COLUMN "updated_by" TEXT;
\ No newline at end of file
@@ -0,0 +1 @@
+ALTER TABLE `identity_verifiable_addresses` ADD COLUMN `reminders_sent` INTEGER NOT NULL DEFAULT 0;ALTER TABLE `identity_verifiable_addresses` ADD COLUMN `reminded_at` DATETIME;
\ No newline at end of file
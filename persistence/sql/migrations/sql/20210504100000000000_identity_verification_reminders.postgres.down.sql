@@ -0,0 +1 @@
+ALTER TABLE "identity_verifiable_addresses" DROP COLUMN "reminded_at";ALTER TABLE "identity_verifiable_addresses" DROP COLUMN "reminders_sent";
\ No newline at end of file
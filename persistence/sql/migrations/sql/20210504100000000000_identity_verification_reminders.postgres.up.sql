@@ -0,0 +1 @@
+ALTER TABLE "identity_verifiable_addresses" ADD COLUMN "reminders_sent" integer NOT NULL DEFAULT 0;ALTER TABLE "identity_verifiable_addresses" ADD COLUMN "reminded_at" timestamp;
\ No newline at end of file
@@ -0,0 +1 @@
+ALTER TABLE "identities" ADD COLUMN "dormancy_deactivated_at" timestamp;ALTER TABLE "identities" ADD COLUMN "activity_tracked_since" timestamp;UPDATE identities SET activity_tracked_since = CURRENT_TIMESTAMP
\ No newline at end of file
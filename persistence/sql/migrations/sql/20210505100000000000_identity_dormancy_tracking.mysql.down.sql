@@ -0,0 +1 @@
+ALTER TABLE `identities` DROP COLUMN `activity_tracked_since`;ALTER TABLE `identities` DROP COLUMN `dormancy_deactivated_at`;
\ No newline at end of file
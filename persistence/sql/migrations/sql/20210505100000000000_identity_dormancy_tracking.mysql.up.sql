@@ -0,0 +1 @@
+ALTER TABLE `identities` ADD COLUMN `dormancy_deactivated_at` DATETIME;ALTER TABLE `identities` ADD COLUMN `activity_tracked_since` DATETIME;UPDATE identities SET activity_tracked_since = CURRENT_TIMESTAMP
\ No newline at end of file
@@ -9,6 +9,7 @@ import (
 
 	"github.com/ory/x/popx"
 
+	"github.com/ory/kratos/cipher"
 	"github.com/ory/kratos/driver/config"
 	"github.com/ory/kratos/identity"
 	"github.com/ory/kratos/persistence"
@@ -28,6 +29,8 @@ type (
 		x.LoggingProvider
 		config.Provider
 		x.TracingProvider
+		x.ClockProvider
+		cipher.Provider
 	}
 	Persister struct {
 		c        *pop.Connection
@@ -60,11 +63,15 @@ func (p *Persister) MigrationStatus(ctx context.Context) (popx.MigrationStatuses
 }
 
 func (p *Persister) MigrateDown(ctx context.Context, steps int) error {
-	return p.mb.Down(ctx, steps)
+	return p.withMigrationLock(ctx, func() error {
+		return p.mb.Down(ctx, steps)
+	})
 }
 
 func (p *Persister) MigrateUp(ctx context.Context) error {
-	return p.mb.Up(ctx)
+	return p.withMigrationLock(ctx, func() error {
+		return p.mb.Up(ctx)
+	})
 }
 
 func (p *Persister) Migrator() *popx.Migrator {
@@ -0,0 +1,20 @@
+package sql
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+
+	"github.com/ory/herodot"
+	"github.com/ory/x/sqlcon"
+)
+
+// BackupSQLite writes a consistent snapshot of the SQLite database to path. It is safe to call while ORY Kratos
+// is serving requests. The file at path must not exist yet.
+func (p *Persister) BackupSQLite(ctx context.Context, path string) error {
+	if !p.isSQLite {
+		return errors.WithStack(herodot.ErrBadRequest.WithReasonf("Snapshots are only supported for SQLite but the database is %s.", p.c.Dialect.Name()))
+	}
+
+	return sqlcon.HandleError(p.GetConnection(ctx).RawQuery("VACUUM INTO ?", path).Exec())
+}
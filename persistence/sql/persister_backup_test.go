@@ -0,0 +1,32 @@
+package sql_test
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPersister_BackupSQLite(t *testing.T) {
+	for name, p := range createCleanDatabases(t) {
+		t.Run(fmt.Sprintf("db=%s", name), func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "backup.db")
+			err := p.Persister().BackupSQLite(context.Background(), path)
+			if name != "sqlite" {
+				require.Error(t, err)
+				return
+			}
+
+			require.NoError(t, err)
+			raw, err := ioutil.ReadFile(path)
+			require.NoError(t, err)
+			assert.Equal(t, "SQLite format 3\x00", string(raw[:16]))
+
+			require.Error(t, p.Persister().BackupSQLite(context.Background(), path), "must not overwrite an existing file")
+		})
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"database/sql"
 	"fmt"
+	"time"
 
 	"github.com/gobuffalo/pop/v5"
 	"github.com/gofrs/uuid"
@@ -28,7 +29,7 @@ func (p *Persister) NextMessages(ctx context.Context, limit uint8) ([]courier.Me
 	if err := p.Transaction(ctx, func(ctx context.Context, tx *pop.Connection) error {
 		if err := tx.
 			Eager().
-			Where("status = ?", courier.MessageStatusQueued).
+			Where("status = ? AND (send_after IS NULL OR send_after <= ?)", courier.MessageStatusQueued, p.r.Clock().Now().UTC()).
 			Order("created_at ASC").Limit(int(limit)).All(&m); err != nil {
 			return err
 		}
@@ -65,6 +66,55 @@ func (p *Persister) LatestQueuedMessage(ctx context.Context) (*courier.Message,
 	return &m, nil
 }
 
+func (p *Persister) FindIdenticalMessage(ctx context.Context, m *courier.Message, since time.Time) (*courier.Message, error) {
+	var existing courier.Message
+	if err := p.GetConnection(ctx).
+		Where("type = ? AND recipient = ? AND subject = ? AND body = ? AND created_at > ?", m.Type, m.Recipient, m.Subject, m.Body, since.UTC()).
+		Order("created_at DESC").First(&existing); err != nil {
+		return nil, sqlcon.HandleError(err)
+	}
+
+	return &existing, nil
+}
+
+func (p *Persister) LatestMessageByRecipient(ctx context.Context, recipient string) (*courier.Message, error) {
+	var m courier.Message
+	if err := p.GetConnection(ctx).Where("recipient = ?", recipient).Order("created_at DESC").First(&m); err != nil {
+		return nil, sqlcon.HandleError(err)
+	}
+
+	return &m, nil
+}
+
+func (p *Persister) CountSentMessages(ctx context.Context, recipient, templateType string, since time.Time) (int, error) {
+	count, err := p.GetConnection(ctx).
+		Where("recipient = ? AND template_type = ? AND created_at > ?", recipient, templateType, since.UTC()).
+		Where("status IN (?)", courier.MessageStatusSent, courier.MessageStatusDelivered, courier.MessageStatusBounced, courier.MessageStatusComplained).
+		Count(new(courier.Message))
+	if err != nil {
+		return 0, sqlcon.HandleError(err)
+	}
+	return count, nil
+}
+
+func (p *Persister) DeferMessage(ctx context.Context, id uuid.UUID, until time.Time) error {
+	count, err := p.GetConnection(ctx).RawQuery(
+		// #nosec G201
+		fmt.Sprintf(
+			"UPDATE %s SET status = ?, send_after = ? WHERE id = ?",
+			corp.ContextualizeTableName(ctx, "courier_messages"),
+		), courier.MessageStatusQueued, until.UTC(), id).ExecWithCount()
+	if err != nil {
+		return sqlcon.HandleError(err)
+	}
+
+	if count == 0 {
+		return errors.WithStack(sqlcon.ErrNoRows)
+	}
+
+	return nil
+}
+
 func (p *Persister) SetMessageStatus(ctx context.Context, id uuid.UUID, ms courier.MessageStatus) error {
 	count, err := p.GetConnection(ctx).RawQuery(
 		// #nosec G201
@@ -82,3 +132,52 @@ func (p *Persister) SetMessageStatus(ctx context.Context, id uuid.UUID, ms couri
 
 	return nil
 }
+
+func (p *Persister) AddSuppression(ctx context.Context, s *courier.Suppression) error {
+	s.Address = courier.NormalizeSuppressedAddress(s.Address)
+	return sqlcon.HandleError(p.GetConnection(ctx).Create(s))
+}
+
+func (p *Persister) GetSuppression(ctx context.Context, address string) (*courier.Suppression, error) {
+	var s courier.Suppression
+	if err := p.GetConnection(ctx).Where("address = ?", courier.NormalizeSuppressedAddress(address)).First(&s); err != nil {
+		return nil, sqlcon.HandleError(err)
+	}
+
+	return &s, nil
+}
+
+func (p *Persister) ListSuppressions(ctx context.Context, page, itemsPerPage int) ([]courier.Suppression, error) {
+	s := make([]courier.Suppression, 0)
+	if err := p.GetConnection(ctx).Paginate(page, itemsPerPage).Order("created_at DESC").All(&s); err != nil {
+		return nil, sqlcon.HandleError(err)
+	}
+
+	return s, nil
+}
+
+func (p *Persister) CountSuppressions(ctx context.Context) (int64, error) {
+	count, err := p.GetConnection(ctx).Count(new(courier.Suppression))
+	if err != nil {
+		return 0, sqlcon.HandleError(err)
+	}
+	return int64(count), nil
+}
+
+func (p *Persister) DeleteSuppression(ctx context.Context, address string) error {
+	count, err := p.GetConnection(ctx).RawQuery(
+		// #nosec G201
+		fmt.Sprintf(
+			"DELETE FROM %s WHERE address = ?",
+			corp.ContextualizeTableName(ctx, "courier_suppressions"),
+		), courier.NormalizeSuppressedAddress(address)).ExecWithCount()
+	if err != nil {
+		return sqlcon.HandleError(err)
+	}
+
+	if count == 0 {
+		return errors.WithStack(sqlcon.ErrNoRows)
+	}
+
+	return nil
+}
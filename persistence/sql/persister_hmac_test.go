@@ -8,8 +8,10 @@ import (
 
 	"github.com/ory/x/configx"
 
+	"github.com/ory/kratos/cipher"
 	"github.com/ory/kratos/identity"
 	"github.com/ory/kratos/schema"
+	"github.com/ory/kratos/x"
 
 	"github.com/gobuffalo/pop/v5"
 	"github.com/stretchr/testify/assert"
@@ -44,6 +46,14 @@ func (l *logRegistryOnly) Config(_ context.Context) *config.Config {
 	return l.c
 }
 
+func (l *logRegistryOnly) Clock() x.Clock {
+	return x.SystemClock
+}
+
+func (l *logRegistryOnly) Cipher() cipher.Cipher {
+	return cipher.NewAESGCM(l)
+}
+
 func (l *logRegistryOnly) Audit() *logrusx.Logger {
 	panic("implement me")
 }
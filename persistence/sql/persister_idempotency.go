@@ -0,0 +1,37 @@
+package sql
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/ory/x/sqlcon"
+
+	"github.com/ory/kratos/corp"
+	"github.com/ory/kratos/idempotency"
+)
+
+var _ idempotency.Persister = new(Persister)
+
+func (p *Persister) CreateIdempotencyRecord(ctx context.Context, r *idempotency.Record) error {
+	return sqlcon.HandleError(p.GetConnection(ctx).Create(r))
+}
+
+func (p *Persister) GetIdempotencyRecord(ctx context.Context, endpoint, key string) (*idempotency.Record, error) {
+	var r idempotency.Record
+	if err := p.GetConnection(ctx).Where("endpoint = ? AND idempotency_key = ?", endpoint, key).First(&r); err != nil {
+		return nil, sqlcon.HandleError(err)
+	}
+	return &r, nil
+}
+
+func (p *Persister) UpdateIdempotencyRecord(ctx context.Context, r *idempotency.Record) error {
+	return sqlcon.HandleError(p.GetConnection(ctx).Update(r))
+}
+
+func (p *Persister) DeleteIdempotencyRecord(ctx context.Context, endpoint, key string) error {
+	return sqlcon.HandleError(p.GetConnection(ctx).RawQuery(
+		// #nosec G201
+		fmt.Sprintf("DELETE FROM %s WHERE endpoint = ? AND idempotency_key = ?", corp.ContextualizeTableName(ctx, "idempotency_records")),
+		endpoint, key,
+	).Exec())
+}
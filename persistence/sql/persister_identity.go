@@ -3,12 +3,13 @@ package sql
 import (
 	"context"
 	"database/sql"
+	"encoding/json"
 	"fmt"
-	"strings"
 	"time"
 
 	"github.com/ory/kratos/corp"
 
+	"github.com/ory/go-convenience/stringslice"
 	"github.com/ory/jsonschema/v3"
 	"github.com/ory/x/sqlxx"
 
@@ -46,35 +47,74 @@ func (p *Persister) ListRecoveryAddresses(ctx context.Context, page, itemsPerPag
 	return a, err
 }
 
+func (p *Persister) ListIdentifiers(ctx context.Context) ([]identity.IdentityIdentifier, error) {
+	var identifiers []identity.IdentityIdentifier
+	// #nosec G201
+	if err := p.GetConnection(ctx).RawQuery(fmt.Sprintf(`SELECT
+    ic.identity_id AS identity_id,
+    ict.name AS type,
+    ici.identifier AS value
+FROM %s ic
+         INNER JOIN %s ict on ic.identity_credential_type_id = ict.id
+         INNER JOIN %s ici on ic.id = ici.identity_credential_id
+UNION
+SELECT identity_id, via, value FROM %s
+UNION
+SELECT identity_id, via, value FROM %s`,
+		corp.ContextualizeTableName(ctx, "identity_credentials"),
+		corp.ContextualizeTableName(ctx, "identity_credential_types"),
+		corp.ContextualizeTableName(ctx, "identity_credential_identifiers"),
+		corp.ContextualizeTableName(ctx, "identity_verifiable_addresses"),
+		corp.ContextualizeTableName(ctx, "identity_recovery_addresses"),
+	)).All(&identifiers); err != nil {
+		return nil, sqlcon.HandleError(err)
+	}
+
+	return identifiers, nil
+}
+
 func (p *Persister) FindByCredentialsIdentifier(ctx context.Context, ct identity.CredentialsType, match string) (*identity.Identity, *identity.Credentials, error) {
 	var cts []identity.CredentialsTypeTable
 	if err := p.GetConnection(ctx).All(&cts); err != nil {
 		return nil, nil, sqlcon.HandleError(err)
 	}
 
-	var find struct {
-		IdentityID uuid.UUID `db:"identity_id"`
+	// Apply the same normalization rules as when the identifier was stored. Identifiers stored before the rules
+	// were configured were only lowercased and are looked up as well.
+	matches := []string{match}
+	if ct == identity.CredentialsTypePassword {
+		matches = stringslice.Unique([]string{
+			identity.NormalizeIdentifier(p.r.Config(ctx).IdentifierNormalization(), match),
+			identity.NormalizeIdentifier(config.IdentifierNormalization{Lowercase: true}, match),
+		})
 	}
 
-	// Force case-insensitivity for identifiers
-	if ct == identity.CredentialsTypePassword {
-		match = strings.ToLower(match)
+	var find struct {
+		IdentityID uuid.UUID `db:"identity_id"`
 	}
 
-	// #nosec G201
-	if err := p.GetConnection(ctx).RawQuery(fmt.Sprintf(`SELECT
+	var err error
+	for _, m := range matches {
+		// #nosec G201
+		err = p.GetConnection(ctx).RawQuery(fmt.Sprintf(`SELECT
     ic.identity_id
 FROM %s ic
          INNER JOIN %s ict on ic.identity_credential_type_id = ict.id
          INNER JOIN %s ici on ic.id = ici.identity_credential_id
 WHERE ici.identifier = ?
   AND ict.name = ?`,
-		corp.ContextualizeTableName(ctx, "identity_credentials"),
-		corp.ContextualizeTableName(ctx, "identity_credential_types"),
-		corp.ContextualizeTableName(ctx, "identity_credential_identifiers"),
-	), match, ct).First(&find); err != nil {
+			corp.ContextualizeTableName(ctx, "identity_credentials"),
+			corp.ContextualizeTableName(ctx, "identity_credential_types"),
+			corp.ContextualizeTableName(ctx, "identity_credential_identifiers"),
+		), m, ct).First(&find)
+		if errors.Cause(err) != sql.ErrNoRows {
+			break
+		}
+	}
+
+	if err != nil {
 		if errors.Cause(err) == sql.ErrNoRows {
-			return nil, nil, herodot.ErrNotFound.WithTrace(err).WithReasonf(`No identity matching credentials identifier "%s" could be found.`, match)
+			return nil, nil, herodot.ErrNotFound.WithTrace(err).WithReasonf(`No identity matching credentials identifier "%s" could be found.`, matches[0])
 		}
 
 		return nil, nil, sqlcon.HandleError(err)
@@ -95,7 +135,15 @@ WHERE ici.identifier = ?
 
 func (p *Persister) findIdentityCredentialsType(ctx context.Context, ct identity.CredentialsType) (*identity.CredentialsTypeTable, error) {
 	var m identity.CredentialsTypeTable
-	if err := p.GetConnection(ctx).Where("name = ?", ct).First(&m); err != nil {
+	if err := p.GetConnection(ctx).Where("name = ?", ct).First(&m); err == nil {
+		return &m, nil
+	} else if err := sqlcon.HandleError(err); !errors.Is(err, sqlcon.ErrNoRows) {
+		return nil, err
+	}
+
+	// Credentials types of strategies added using driver.RegisterStrategy are not created by the migrations.
+	m = identity.CredentialsTypeTable{ID: x.NewUUID(), Name: ct}
+	if err := p.GetConnection(ctx).Create(&m); err != nil {
 		return nil, sqlcon.HandleError(err)
 	}
 	return &m, nil
@@ -117,14 +165,19 @@ func (p *Persister) createIdentityCredentials(ctx context.Context, i *identity.I
 		}
 
 		cred.CredentialTypeID = ct.ID
+		plaintext := cred.Config
+		if cred.Config, err = p.encryptCredentialsConfig(ctx, plaintext); err != nil {
+			return err
+		}
 		if err := c.Create(&cred); err != nil {
 			return sqlcon.HandleError(err)
 		}
+		cred.Config = plaintext
 
 		for _, ids := range cred.Identifiers {
-			// Force case-insensitivity for identifiers
+			// Normalize identifiers, e.g. to force case-insensitivity
 			if cred.Type == identity.CredentialsTypePassword {
-				ids = strings.ToLower(ids)
+				ids = identity.NormalizeIdentifier(p.r.Config(ctx).IdentifierNormalization(), ids)
 			}
 
 			if len(ids) == 0 {
@@ -146,6 +199,60 @@ func (p *Persister) createIdentityCredentials(ctx context.Context, i *identity.I
 	return nil
 }
 
+// encryptCredentialsConfig encrypts the configuration of credentials if `identity.credentials.encrypt` is enabled.
+// The ciphertext is stored as a JSON string because the column has the JSON type.
+func (p *Persister) encryptCredentialsConfig(ctx context.Context, plaintext sqlxx.JSONRawMessage) (sqlxx.JSONRawMessage, error) {
+	if !p.r.Config(ctx).IdentityCredentialsEncrypt() {
+		return plaintext, nil
+	}
+
+	encrypted, err := p.r.Cipher().Encrypt(ctx, plaintext)
+	if err != nil {
+		return nil, err
+	}
+
+	out, err := json.Marshal(encrypted)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	return out, nil
+}
+
+// decryptCredentialsConfig decrypts the configuration of credentials if it is encrypted. The second return value is
+// true if encryption is enabled and the configuration is stored in plain text or was encrypted with an older key.
+func (p *Persister) decryptCredentialsConfig(ctx context.Context, stored sqlxx.JSONRawMessage) (sqlxx.JSONRawMessage, bool, error) {
+	encrypt := p.r.Config(ctx).IdentityCredentialsEncrypt()
+
+	var encrypted string
+	if err := json.Unmarshal(stored, &encrypted); err != nil || !p.r.Cipher().Understands(encrypted) {
+		return stored, encrypt, nil
+	}
+
+	plaintext, err := p.r.Cipher().Decrypt(ctx, encrypted)
+	if err != nil {
+		return nil, false, errors.WithStack(herodot.ErrInternalServerError.
+			WithReasonf("Unable to decrypt the identity credentials. Make sure that the key they were encrypted with is still part of %s.", config.ViperKeySecretsCipher).
+			WithDebug(err.Error()))
+	}
+
+	return plaintext, encrypt && p.r.Cipher().NeedsRotation(ctx, encrypted), nil
+}
+
+// rotateCredentialsConfig lazily encrypts the configuration of existing credentials using the current key. Errors
+// are only logged because the credentials can still be read.
+func (p *Persister) rotateCredentialsConfig(ctx context.Context, id uuid.UUID, plaintext sqlxx.JSONRawMessage) {
+	encrypted, err := p.encryptCredentialsConfig(ctx, plaintext)
+	if err == nil {
+		/* #nosec G201 TableName is static */
+		err = p.GetConnection(ctx).RawQuery(fmt.Sprintf("UPDATE %s SET config = ? WHERE id = ?",
+			corp.ContextualizeTableName(ctx, "identity_credentials")), string(encrypted), id).Exec()
+	}
+
+	if err != nil {
+		p.r.Logger().WithError(err).WithField("identity_credentials_id", id).Warn("Unable to encrypt the identity credentials with the current key.")
+	}
+}
+
 func (p *Persister) createVerifiableAddresses(ctx context.Context, i *identity.Identity) error {
 	for k := range i.VerifiableAddresses {
 		i.VerifiableAddresses[k].IdentityID = i.ID
@@ -174,6 +281,192 @@ func (p *Persister) CountIdentities(ctx context.Context) (int64, error) {
 	return int64(count), nil
 }
 
+func (p *Persister) CountIdentitiesByActivity(ctx context.Context, f identity.ActivityFilter) (int64, error) {
+	count, err := whereActivity(p.GetConnection(ctx).Q(), f).Count(new(identity.Identity))
+	if err != nil {
+		return 0, sqlcon.HandleError(err)
+	}
+	return int64(count), nil
+}
+
+func (p *Persister) RecordIdentityActivity(ctx context.Context, id uuid.UUID, a identity.Activity, at time.Time) error {
+	var column string
+	switch a {
+	case identity.ActivityLogin:
+		column = "last_login_at"
+	case identity.ActivitySeen:
+		column = "last_seen_at"
+	default:
+		return errors.Errorf("unknown identity activity: %s", a)
+	}
+
+	// Any activity means the identity is no longer dormant.
+	count, err := p.GetConnection(ctx).RawQuery(
+		/* #nosec G201 TableName and column are static */
+		fmt.Sprintf("UPDATE %s SET %s = ?, dormancy_notified_at = NULL, dormancy_deactivated_at = NULL WHERE id = ?", new(identity.Identity).TableName(ctx), column),
+		at.UTC(), id).ExecWithCount()
+	if err != nil {
+		return sqlcon.HandleError(err)
+	}
+
+	if count == 0 {
+		return errors.WithStack(sqlcon.ErrNoRows)
+	}
+
+	return nil
+}
+
+func (p *Persister) UpdateIdentityState(ctx context.Context, id uuid.UUID, state identity.State, at time.Time) error {
+	if err := state.Valid(); err != nil {
+		return err
+	}
+
+	count, err := p.GetConnection(ctx).RawQuery(
+		/* #nosec G201 TableName is static */
+		fmt.Sprintf("UPDATE %s SET state = ?, state_changed_at = ?, dormancy_deactivated_at = NULL WHERE id = ?", new(identity.Identity).TableName(ctx)),
+		state, at.UTC(), id).ExecWithCount()
+	if err != nil {
+		return sqlcon.HandleError(err)
+	}
+
+	if count == 0 {
+		return errors.WithStack(sqlcon.ErrNoRows)
+	}
+
+	return nil
+}
+
+func (p *Persister) DeactivateDormantIdentity(ctx context.Context, id uuid.UUID, at time.Time) error {
+	count, err := p.GetConnection(ctx).RawQuery(
+		/* #nosec G201 TableName is static */
+		fmt.Sprintf("UPDATE %s SET state = ?, state_changed_at = ?, dormancy_deactivated_at = ? WHERE id = ?", new(identity.Identity).TableName(ctx)),
+		identity.StateInactive, at.UTC(), at.UTC(), id).ExecWithCount()
+	if err != nil {
+		return sqlcon.HandleError(err)
+	}
+
+	if count == 0 {
+		return errors.WithStack(sqlcon.ErrNoRows)
+	}
+
+	return nil
+}
+
+func (p *Persister) MarkIdentityDormancyNotified(ctx context.Context, id uuid.UUID, at time.Time) error {
+	count, err := p.GetConnection(ctx).RawQuery(
+		/* #nosec G201 TableName is static */
+		fmt.Sprintf("UPDATE %s SET dormancy_notified_at = ? WHERE id = ?", new(identity.Identity).TableName(ctx)),
+		at.UTC(), id).ExecWithCount()
+	if err != nil {
+		return sqlcon.HandleError(err)
+	}
+
+	if count == 0 {
+		return errors.WithStack(sqlcon.ErrNoRows)
+	}
+
+	return nil
+}
+
+func (p *Persister) ListVerificationReminderCandidates(ctx context.Context, createdAfter, createdBefore time.Time, maxReminders int, page, perPage int) ([]identity.VerifiableAddress, error) {
+	a := make([]identity.VerifiableAddress, 0)
+	q := p.GetConnection(ctx).
+		Where("verified = ? AND status = ? AND via = ? AND reminders_sent < ? AND created_at < ?",
+			false, identity.VerifiableAddressStatusPending, identity.VerifiableAddressTypeEmail, maxReminders, createdBefore.UTC())
+	if !createdAfter.IsZero() {
+		q = q.Where("created_at > ?", createdAfter.UTC())
+	}
+	if err := q.Order("created_at ASC, id ASC").Paginate(page, perPage).All(&a); err != nil {
+		return nil, sqlcon.HandleError(err)
+	}
+	return a, nil
+}
+
+func (p *Persister) MarkVerificationRemindersSent(ctx context.Context, id uuid.UUID, reminders int, at time.Time) error {
+	count, err := p.GetConnection(ctx).RawQuery(
+		/* #nosec G201 TableName is static */
+		fmt.Sprintf("UPDATE %s SET reminders_sent = ?, reminded_at = ? WHERE id = ?", new(identity.VerifiableAddress).TableName(ctx)),
+		reminders, at.UTC(), id).ExecWithCount()
+	if err != nil {
+		return sqlcon.HandleError(err)
+	}
+
+	if count == 0 {
+		return errors.WithStack(sqlcon.ErrNoRows)
+	}
+
+	return nil
+}
+
+func (p *Persister) CreateAnonymizedStubs(ctx context.Context, stubs []identity.AnonymizedStub) error {
+	return sqlcon.HandleError(p.Transaction(ctx, func(ctx context.Context, tx *pop.Connection) error {
+		for k := range stubs {
+			if err := tx.Create(&stubs[k]); err != nil {
+				return err
+			}
+		}
+		return nil
+	}))
+}
+
+func (p *Persister) FindAnonymizedStubs(ctx context.Context, hashes []string, at time.Time) ([]identity.AnonymizedStub, error) {
+	stubs := make([]identity.AnonymizedStub, 0)
+	if len(hashes) == 0 {
+		return stubs, nil
+	}
+
+	args := make([]interface{}, len(hashes))
+	for k := range hashes {
+		args[k] = hashes[k]
+	}
+
+	if err := p.GetConnection(ctx).
+		Where("identifier_hash IN (?)", args...).
+		Where("(expires_at IS NULL OR expires_at > ?)", at.UTC()).
+		Order("created_at DESC").
+		All(&stubs); err != nil {
+		return nil, sqlcon.HandleError(err)
+	}
+
+	return stubs, nil
+}
+
+func (p *Persister) DeleteExpiredAnonymizedStubs(ctx context.Context, at time.Time) error {
+	/* #nosec G201 TableName is static */
+	return sqlcon.HandleError(p.GetConnection(ctx).RawQuery(
+		fmt.Sprintf("DELETE FROM %s WHERE expires_at IS NOT NULL AND expires_at <= ?", new(identity.AnonymizedStub).TableName(ctx)),
+		at.UTC()).Exec())
+}
+
+func (p *Persister) CountIdentitiesBySchema(ctx context.Context) (map[string]int64, error) {
+	var rows []struct {
+		SchemaID string `db:"schema_id"`
+		Count    int64  `db:"count"`
+	}
+
+	/* #nosec G201 TableName is static */
+	if err := p.GetConnection(ctx).RawQuery(fmt.Sprintf(
+		"SELECT schema_id, COUNT(*) AS count FROM %s GROUP BY schema_id",
+		new(identity.Identity).TableName(ctx),
+	)).All(&rows); err != nil {
+		return nil, sqlcon.HandleError(err)
+	}
+
+	counts := make(map[string]int64, len(rows))
+	for _, r := range rows {
+		counts[r.SchemaID] = r.Count
+	}
+	return counts, nil
+}
+
+func (p *Persister) CountUnverifiedAddresses(ctx context.Context) (int64, error) {
+	count, err := p.GetConnection(ctx).Where("verified = ?", false).Count(new(identity.VerifiableAddress))
+	if err != nil {
+		return 0, sqlcon.HandleError(err)
+	}
+	return int64(count), nil
+}
+
 func (p *Persister) CreateIdentity(ctx context.Context, i *identity.Identity) error {
 	if i.SchemaID == "" {
 		i.SchemaID = config.DefaultIdentityTraitsSchemaID
@@ -183,6 +476,10 @@ func (p *Persister) CreateIdentity(ctx context.Context, i *identity.Identity) er
 		i.Traits = identity.Traits("{}")
 	}
 
+	if i.State == "" {
+		i.State = identity.StateActive
+	}
+
 	if err := p.injectTraitsSchemaURL(ctx, i); err != nil {
 		return err
 	}
@@ -209,10 +506,48 @@ func (p *Persister) CreateIdentity(ctx context.Context, i *identity.Identity) er
 }
 
 func (p *Persister) ListIdentities(ctx context.Context, page, perPage int) ([]identity.Identity, error) {
+	return p.ListIdentitiesByActivity(ctx, identity.ActivityFilter{}, page, perPage)
+}
+
+// whereActivity restricts the query to identities matching the activity filter.
+func whereActivity(q *pop.Query, f identity.ActivityFilter) *pop.Query {
+	for _, c := range []struct {
+		column   string
+		fallback string
+		before   time.Time
+	}{
+		// Logins and sessions of identities which existed before activity tracking was enabled were never
+		// recorded, so they are compared using the time tracking was enabled instead of their creation date.
+		{column: "last_login_at", fallback: "activity_tracked_since, created_at", before: f.LastLoginBefore},
+		{column: "last_seen_at", fallback: "activity_tracked_since, created_at", before: f.LastSeenBefore},
+		{column: "password_changed_at", fallback: "created_at", before: f.PasswordChangedBefore},
+		{column: "state_changed_at", fallback: "created_at", before: f.StateChangedBefore},
+	} {
+		if !c.before.IsZero() {
+			/* #nosec G201 column and fallback are static */
+			q = q.Where(fmt.Sprintf("COALESCE(%s, %s) < ?", c.column, c.fallback), c.before.UTC())
+		}
+	}
+	if f.State != "" {
+		q = q.Where("state = ?", f.State)
+	}
+	if f.NotNotifiedOfDormancy {
+		q = q.Where("dormancy_notified_at IS NULL")
+	}
+	if !f.NotifiedOfDormancyBefore.IsZero() {
+		q = q.Where("dormancy_notified_at < ?", f.NotifiedOfDormancyBefore.UTC())
+	}
+	if !f.DeactivatedForDormancyBefore.IsZero() {
+		q = q.Where("dormancy_deactivated_at < ?", f.DeactivatedForDormancyBefore.UTC())
+	}
+	return q
+}
+
+func (p *Persister) ListIdentitiesByActivity(ctx context.Context, f identity.ActivityFilter, page, perPage int) ([]identity.Identity, error) {
 	is := make([]identity.Identity, 0)
 
 	/* #nosec G201 TableName is static */
-	if err := sqlcon.HandleError(p.GetConnection(ctx).Paginate(page, perPage).Order("id DESC").
+	if err := sqlcon.HandleError(whereActivity(p.GetConnection(ctx).Q(), f).Paginate(page, perPage).Order("id DESC").
 		Eager("VerifiableAddresses", "RecoveryAddresses").All(&is)); err != nil {
 		return nil, err
 	}
@@ -251,7 +586,8 @@ func (p *Persister) UpdateIdentity(ctx context.Context, i *identity.Identity) er
 			}
 		}
 
-		if err := tx.Update(i); err != nil {
+		// Activity timestamps are recorded using RecordIdentityActivity and MarkIdentityDormancyNotified.
+		if err := tx.Update(i, "last_login_at", "last_seen_at", "dormancy_notified_at"); err != nil {
 			return err
 		}
 
@@ -267,6 +603,13 @@ func (p *Persister) UpdateIdentity(ctx context.Context, i *identity.Identity) er
 	}))
 }
 
+func (p *Persister) MoveIdentitySessions(ctx context.Context, from, to uuid.UUID) error {
+	/* #nosec G201 TableName is static */
+	return sqlcon.HandleError(p.GetConnection(ctx).RawQuery(fmt.Sprintf(
+		`UPDATE %s SET identity_id = ? WHERE identity_id = ?`, corp.ContextualizeTableName(ctx, "sessions")),
+		to, from).Exec())
+}
+
 func (p *Persister) DeleteIdentity(ctx context.Context, id uuid.UUID) error {
 	/* #nosec G201 TableName is static */
 	count, err := p.GetConnection(ctx).RawQuery(fmt.Sprintf("DELETE FROM %s WHERE id = ?", new(identity.Identity).TableName(ctx)), id).ExecWithCount()
@@ -311,6 +654,14 @@ func (p *Persister) GetIdentityConfidential(ctx context.Context, id uuid.UUID) (
 			return nil, sqlcon.HandleError(err)
 		}
 
+		plaintext, rotate, err := p.decryptCredentialsConfig(ctx, creds.Config)
+		if err != nil {
+			return nil, err
+		} else if rotate {
+			p.rotateCredentialsConfig(ctx, creds.ID, plaintext)
+		}
+		creds.Config = plaintext
+
 		creds.CredentialIdentifierCollection = nil
 		creds.Identifiers = make([]string, len(cs))
 		for k := range cs {
@@ -2,12 +2,17 @@ package sql_test
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
+	"strings"
 	"sync"
 	"testing"
 
+	"github.com/gofrs/uuid"
+
 	"github.com/ory/kratos/persistence/sql"
 
+	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
 	"github.com/ory/kratos/driver/config"
@@ -55,3 +60,100 @@ func TestPersister_CreateIdentityRacy(t *testing.T) {
 
 	}
 }
+
+func TestPersister_EncryptCredentials(t *testing.T) {
+	ctx := context.Background()
+	for name, reg := range createCleanDatabases(t) {
+		t.Run(fmt.Sprintf("db=%s", name), func(t *testing.T) {
+			conf := reg.Config(ctx)
+			conf.MustSet(config.ViperKeyDefaultIdentitySchemaURL, "file://./stub/identity.schema.json")
+			t.Cleanup(func() {
+				conf.MustSet(config.ViperKeyIdentityCredentialsEncrypt, false)
+				conf.MustSet(config.ViperKeySecretsCipher, []string{})
+			})
+
+			p := reg.Persister().(*sql.Persister)
+			const plaintext = `{"providers":[{"subject":"foo","provider":"github","refresh_token":"the-refresh-token"}]}`
+
+			create := func(t *testing.T) *identity.Identity {
+				i := identity.NewIdentity(config.DefaultIdentityTraitsSchemaID)
+				i.SetCredentials(identity.CredentialsTypeOIDC, identity.Credentials{
+					Type:        identity.CredentialsTypeOIDC,
+					Identifiers: []string{"github:" + i.ID.String()},
+					Config:      sqlxx.JSONRawMessage(plaintext),
+				})
+				require.NoError(t, p.CreateIdentity(ctx, i))
+				return i
+			}
+
+			stored := func(t *testing.T, id uuid.UUID) string {
+				var row struct {
+					Config string `db:"config"`
+				}
+				require.NoError(t, p.Connection(ctx).RawQuery("SELECT config FROM identity_credentials WHERE identity_id = ?", id).First(&row))
+				return row.Config
+			}
+
+			encrypted := func(t *testing.T, value string) string {
+				var ciphertext string
+				require.NoError(t, json.Unmarshal([]byte(value), &ciphertext), value)
+				assert.True(t, strings.HasPrefix(ciphertext, "$aes256gcm$"), ciphertext)
+				assert.NotContains(t, ciphertext, "the-refresh-token")
+				return ciphertext
+			}
+
+			load := func(t *testing.T, id uuid.UUID) {
+				i, err := p.GetIdentityConfidential(ctx, id)
+				require.NoError(t, err)
+				assert.JSONEq(t, plaintext, string(i.Credentials[identity.CredentialsTypeOIDC].Config))
+			}
+
+			t.Run("case=stores credentials in plain text by default", func(t *testing.T) {
+				i := create(t)
+				assert.JSONEq(t, plaintext, stored(t, i.ID))
+				load(t, i.ID)
+			})
+
+			t.Run("case=encrypts credentials", func(t *testing.T) {
+				conf.MustSet(config.ViperKeyIdentityCredentialsEncrypt, true)
+				i := create(t)
+				assert.JSONEq(t, plaintext, string(i.Credentials[identity.CredentialsTypeOIDC].Config), "the identity keeps the plain text credentials")
+				encrypted(t, stored(t, i.ID))
+				load(t, i.ID)
+
+				conf.MustSet(config.ViperKeyIdentityCredentialsEncrypt, false)
+				load(t, i.ID)
+			})
+
+			t.Run("case=lazily encrypts existing credentials", func(t *testing.T) {
+				conf.MustSet(config.ViperKeyIdentityCredentialsEncrypt, false)
+				i := create(t)
+
+				conf.MustSet(config.ViperKeyIdentityCredentialsEncrypt, true)
+				load(t, i.ID)
+				ciphertext := encrypted(t, stored(t, i.ID))
+
+				load(t, i.ID)
+				assert.Equal(t, ciphertext, encrypted(t, stored(t, i.ID)), "credentials encrypted with the current key are not re-encrypted")
+			})
+
+			t.Run("case=lazily re-encrypts credentials with the current key", func(t *testing.T) {
+				conf.MustSet(config.ViperKeyIdentityCredentialsEncrypt, true)
+				conf.MustSet(config.ViperKeySecretsCipher, []string{"old-key-which-is-32-bytes-long.."})
+				i := create(t)
+				assert.True(t, strings.HasPrefix(encrypted(t, stored(t, i.ID)), "$aes256gcm$k=0$"))
+
+				conf.MustSet(config.ViperKeySecretsCipher, []string{"new-key-which-is-32-bytes-long..", "old-key-which-is-32-bytes-long.."})
+				load(t, i.ID)
+				assert.True(t, strings.HasPrefix(encrypted(t, stored(t, i.ID)), "$aes256gcm$k=1$"))
+
+				conf.MustSet(config.ViperKeySecretsCipher, []string{"new-key-which-is-32-bytes-long.."})
+				load(t, i.ID)
+
+				conf.MustSet(config.ViperKeySecretsCipher, []string{"yet-another-key-32-bytes-long..."})
+				_, err := p.GetIdentityConfidential(ctx, i.ID)
+				require.Error(t, err, "credentials can not be read once their key was removed")
+			})
+		})
+	}
+}
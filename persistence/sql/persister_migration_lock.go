@@ -0,0 +1,58 @@
+package sql
+
+import (
+	"context"
+	"fmt"
+	"hash/crc32"
+
+	"github.com/pkg/errors"
+
+	"github.com/ory/x/sqlcon"
+)
+
+const migrationLockName = "ory_kratos_migrations"
+
+var migrationLockID = int64(crc32.ChecksumIEEE([]byte(migrationLockName)))
+
+// withMigrationLock runs f while holding a database advisory lock so that only one instance migrates the
+// database at a time. Instances which start concurrently wait for the lock and find the migrations applied.
+//
+// SQLite serializes writes itself and CockroachDB does not support advisory locks, which is why f is run
+// without a lock for those databases.
+func (p *Persister) withMigrationLock(ctx context.Context, f func() error) error {
+	var lock, unlock string
+	switch p.c.Dialect.Name() {
+	case "postgres":
+		// The lock is released when the transaction ends.
+		lock = fmt.Sprintf("SELECT pg_advisory_xact_lock(%d)", migrationLockID)
+	case "mysql":
+		lock = fmt.Sprintf("SELECT GET_LOCK('%s', -1)", migrationLockName)
+		unlock = fmt.Sprintf("SELECT RELEASE_LOCK('%s')", migrationLockName)
+	default:
+		return f()
+	}
+
+	// The transaction pins a single connection which holds the lock.
+	tx, err := p.c.WithContext(ctx).NewTransaction()
+	if err != nil {
+		return sqlcon.HandleError(err)
+	}
+	defer func() {
+		_ = tx.TX.Rollback()
+	}()
+
+	p.r.Logger().Info("Waiting for the migration lock.")
+	if err := tx.RawQuery(lock).Exec(); err != nil {
+		return errors.Wrap(sqlcon.HandleError(err), "unable to acquire the migration lock")
+	}
+	if unlock != "" {
+		defer func() {
+			if err := tx.RawQuery(unlock).Exec(); err != nil {
+				p.r.Logger().WithError(err).Warn("Unable to release the migration lock.")
+			}
+		}()
+	}
+	p.r.Logger().Info("Acquired the migration lock.")
+
+	return f()
+}
@@ -0,0 +1,46 @@
+package sql_test
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ory/kratos/internal/testhelpers"
+	"github.com/ory/kratos/persistence/sql"
+)
+
+func TestPersister_MigrateUpConcurrently(t *testing.T) {
+	for name, reg := range createCleanDatabases(t) {
+		t.Run(fmt.Sprintf("db=%s", name), func(t *testing.T) {
+			if name == "sqlite" || name == "cockroach" {
+				t.Skipf("%s does not support advisory locks", name)
+			}
+
+			p := reg.Persister().(*sql.Persister)
+			testhelpers.CleanSQL(t, p.Connection(context.Background()))
+
+			var wg sync.WaitGroup
+			errs := make([]error, 5)
+			for k := range errs {
+				wg.Add(1)
+				go func(k int) {
+					defer wg.Done()
+					errs[k] = p.MigrateUp(context.Background())
+				}(k)
+			}
+			wg.Wait()
+
+			for _, err := range errs {
+				assert.NoError(t, err)
+			}
+
+			status, err := p.MigrationStatus(context.Background())
+			require.NoError(t, err)
+			assert.False(t, status.HasPending())
+		})
+	}
+}
@@ -0,0 +1,77 @@
+package sql
+
+import (
+	"context"
+	"io/fs"
+	"strings"
+
+	"github.com/ory/x/popx"
+
+	"github.com/ory/kratos/persistence"
+)
+
+const contractMigrationSuffix = "_contract"
+
+func inMigrationPhase(name string, phase persistence.MigrationPhase) bool {
+	switch phase {
+	case persistence.MigrationPhaseExpand:
+		return !strings.HasSuffix(name, contractMigrationSuffix)
+	case persistence.MigrationPhaseContract:
+		return strings.HasSuffix(name, contractMigrationSuffix)
+	}
+	return true
+}
+
+// migrationPhaseFS hides all migration files which are not part of the phase.
+type migrationPhaseFS struct {
+	fs.FS
+	phase persistence.MigrationPhase
+}
+
+func (f *migrationPhaseFS) ReadDir(name string) ([]fs.DirEntry, error) {
+	entries, err := fs.ReadDir(f.FS, name)
+	if err != nil {
+		return nil, err
+	}
+
+	filtered := make([]fs.DirEntry, 0, len(entries))
+	for _, e := range entries {
+		if m := migrationFileName.FindStringSubmatch(e.Name()); !e.IsDir() && len(m) > 0 && !inMigrationPhase(m[2], f.phase) {
+			continue
+		}
+		filtered = append(filtered, e)
+	}
+	return filtered, nil
+}
+
+// MigrationStatusPhase returns the status of the migrations in the phase.
+func (p *Persister) MigrationStatusPhase(ctx context.Context, phase persistence.MigrationPhase) (popx.MigrationStatuses, error) {
+	statuses, err := p.MigrationStatus(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	filtered := make(popx.MigrationStatuses, 0, len(statuses))
+	for _, s := range statuses {
+		if inMigrationPhase(s.Name, phase) {
+			filtered = append(filtered, s)
+		}
+	}
+	return filtered, nil
+}
+
+// MigrateUpPhase applies the pending migrations of the phase.
+func (p *Persister) MigrateUpPhase(ctx context.Context, phase persistence.MigrationPhase) error {
+	if phase == persistence.MigrationPhaseAll {
+		return p.MigrateUp(ctx)
+	}
+
+	mb, err := popx.NewMigrationBox(&migrationPhaseFS{FS: migrations, phase: phase}, popx.NewMigrator(p.c, p.r.Logger(), p.r.Tracer(ctx), 0))
+	if err != nil {
+		return err
+	}
+
+	return p.withMigrationLock(ctx, func() error {
+		return mb.Up(ctx)
+	})
+}
@@ -0,0 +1,95 @@
+package sql
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"path"
+	"regexp"
+
+	"github.com/pkg/errors"
+
+	"github.com/ory/x/popx"
+
+	"github.com/ory/kratos/persistence"
+)
+
+var migrationFileName = regexp.MustCompile(`^(\d+)_([^.]+)\.(\w+)\.(up|down)\.sql$`)
+
+// MigrationPlanUp returns the migrations MigrateUpPhase would apply, oldest first.
+func (p *Persister) MigrationPlanUp(ctx context.Context, phase persistence.MigrationPhase) ([]persistence.PlannedMigration, error) {
+	statuses, err := p.MigrationStatusPhase(ctx, phase)
+	if err != nil {
+		return nil, err
+	}
+
+	var pending []popx.MigrationStatus
+	for _, s := range statuses {
+		if s.State == popx.Pending {
+			pending = append(pending, s)
+		}
+	}
+
+	return p.migrationPlan(pending, "up")
+}
+
+// MigrationPlanDown returns the migrations MigrateDown would roll back, newest first. If steps is zero or less,
+// all applied migrations are rolled back.
+func (p *Persister) MigrationPlanDown(ctx context.Context, steps int) ([]persistence.PlannedMigration, error) {
+	statuses, err := p.MigrationStatus(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var applied []popx.MigrationStatus
+	for k := len(statuses) - 1; k >= 0; k-- {
+		if statuses[k].State == popx.Applied {
+			applied = append(applied, statuses[k])
+		}
+	}
+
+	if steps > 0 && len(applied) > steps {
+		applied = applied[:steps]
+	}
+
+	return p.migrationPlan(applied, "down")
+}
+
+func (p *Persister) migrationPlan(statuses []popx.MigrationStatus, direction string) ([]persistence.PlannedMigration, error) {
+	files, err := fs.ReadDir(migrations, "migrations/sql")
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	dialect := p.c.Dialect.Name()
+	byVersion := map[string]string{}
+	for _, f := range files {
+		m := migrationFileName.FindStringSubmatch(f.Name())
+		if len(m) == 0 || m[3] != dialect || m[4] != direction {
+			continue
+		}
+		byVersion[m[1]] = f.Name()
+	}
+
+	plan := make([]persistence.PlannedMigration, 0, len(statuses))
+	for _, s := range statuses {
+		name, ok := byVersion[s.Version]
+		if !ok {
+			return nil, errors.WithStack(fmt.Errorf("unable to find the %s migration %s_%s for dialect %s", direction, s.Version, s.Name, dialect))
+		}
+
+		content, err := fs.ReadFile(migrations, path.Join("migrations/sql", name))
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+
+		plan = append(plan, persistence.PlannedMigration{
+			Version:   s.Version,
+			Name:      s.Name,
+			Direction: direction,
+			SQL:       string(content),
+		})
+	}
+
+	return plan, nil
+}
@@ -0,0 +1,52 @@
+package sql_test
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ory/kratos/persistence"
+)
+
+func TestPersister_MigrationPlan(t *testing.T) {
+	for name, p := range createCleanDatabases(t) {
+		t.Run(fmt.Sprintf("db=%s", name), func(t *testing.T) {
+			ctx := context.Background()
+
+			up, err := p.Persister().MigrationPlanUp(ctx, persistence.MigrationPhaseAll)
+			require.NoError(t, err)
+			assert.Empty(t, up)
+
+			statuses, err := p.Persister().MigrationStatus(ctx)
+			require.NoError(t, err)
+			require.True(t, len(statuses) > 2)
+
+			down, err := p.Persister().MigrationPlanDown(ctx, 2)
+			require.NoError(t, err)
+			require.Len(t, down, 2)
+			for k, m := range down {
+				expected := statuses[len(statuses)-1-k]
+				assert.Equal(t, expected.Version, m.Version)
+				assert.Equal(t, expected.Name, m.Name)
+				assert.Equal(t, "down", m.Direction)
+			}
+
+			all, err := p.Persister().MigrationPlanDown(ctx, 0)
+			require.NoError(t, err)
+			assert.Len(t, all, len(statuses))
+
+			expand, err := p.Persister().MigrationStatusPhase(ctx, persistence.MigrationPhaseExpand)
+			require.NoError(t, err)
+			contract, err := p.Persister().MigrationStatusPhase(ctx, persistence.MigrationPhaseContract)
+			require.NoError(t, err)
+			assert.Len(t, statuses, len(expand)+len(contract))
+			for _, s := range contract {
+				assert.True(t, strings.HasSuffix(s.Name, "_contract"), "%s", s.Name)
+			}
+		})
+	}
+}
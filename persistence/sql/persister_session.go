@@ -3,6 +3,7 @@ package sql
 import (
 	"context"
 	"fmt"
+	"time"
 
 	"github.com/ory/kratos/corp"
 
@@ -87,3 +88,45 @@ func (p *Persister) RevokeSessionByToken(ctx context.Context, token string) erro
 	}
 	return nil
 }
+
+func (p *Persister) ElevateSession(ctx context.Context, sid uuid.UUID, until time.Time) error {
+	// #nosec G201
+	count, err := p.GetConnection(ctx).RawQuery(fmt.Sprintf(
+		"UPDATE %s SET privileged_until = ? WHERE id = ?",
+		corp.ContextualizeTableName(ctx, "sessions"),
+	), until.UTC(), sid).ExecWithCount()
+	if err != nil {
+		return sqlcon.HandleError(err)
+	}
+	if count == 0 {
+		return sqlcon.ErrNoRows
+	}
+	return nil
+}
+
+func (p *Persister) CountActiveSessions(ctx context.Context) (int64, error) {
+	count, err := p.GetConnection(ctx).Where("active = ? AND expires_at > ?", true, p.r.Clock().Now().UTC()).Count(new(session.Session))
+	if err != nil {
+		return 0, sqlcon.HandleError(err)
+	}
+	return int64(count), nil
+}
+
+func (p *Persister) ListSessionsByIdentity(ctx context.Context, identityID uuid.UUID, page, perPage int) ([]session.Session, int64, error) {
+	i, err := p.GetIdentity(ctx, identityID)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	ss := make([]session.Session, 0)
+	q := p.GetConnection(ctx).Where("identity_id = ?", identityID).Paginate(page, perPage).Order("created_at DESC")
+	if err := q.All(&ss); err != nil {
+		return nil, 0, sqlcon.HandleError(err)
+	}
+
+	for k := range ss {
+		ss[k].Identity = i
+	}
+
+	return ss, int64(q.Paginator.TotalEntriesSize), nil
+}
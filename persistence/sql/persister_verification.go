@@ -11,6 +11,7 @@ import (
 
 	"github.com/ory/x/sqlcon"
 
+	"github.com/ory/kratos/identity"
 	"github.com/ory/kratos/selfservice/flow/verification"
 	"github.com/ory/kratos/selfservice/strategy/link"
 )
@@ -92,7 +93,7 @@ func (p *Persister) UseVerificationToken(ctx context.Context, token string) (*li
 			return err
 		}
 		/* #nosec G201 TableName is static */
-		return tx.RawQuery(fmt.Sprintf("UPDATE %s SET used=true, used_at=? WHERE id=?", rt.TableName(ctx)), time.Now().UTC(), rt.ID).Exec()
+		return tx.RawQuery(fmt.Sprintf("UPDATE %s SET used=true, used_at=? WHERE id=?", rt.TableName(ctx)), p.r.Clock().Now().UTC(), rt.ID).Exec()
 	})); err != nil {
 		return nil, err
 	}
@@ -104,3 +105,21 @@ func (p *Persister) DeleteVerificationToken(ctx context.Context, token string) e
 	/* #nosec G201 TableName is static */
 	return p.GetConnection(ctx).RawQuery(fmt.Sprintf("DELETE FROM %s WHERE token=?", new(link.VerificationToken).TableName(ctx)), token).Exec()
 }
+
+func (p *Persister) ExpireVerificationTokens(ctx context.Context, identityID uuid.UUID) error {
+	now := p.r.Clock().Now().UTC()
+	/* #nosec G201 TableName is static */
+	return sqlcon.HandleError(p.GetConnection(ctx).RawQuery(fmt.Sprintf(
+		"UPDATE %s SET expires_at=? WHERE NOT used AND expires_at > ? AND identity_verifiable_address_id IN (SELECT id FROM %s WHERE identity_id=?)",
+		new(link.VerificationToken).TableName(ctx), new(identity.VerifiableAddress).TableName(ctx)), now, now, identityID).Exec())
+}
+
+func (p *Persister) HasRecentVerificationToken(ctx context.Context, addressID uuid.UUID, since time.Time) (bool, error) {
+	count, err := p.GetConnection(ctx).
+		Where("identity_verifiable_address_id = ? AND NOT used AND issued_at > ? AND expires_at > ?", addressID, since.UTC(), p.r.Clock().Now().UTC()).
+		Count(new(link.VerificationToken))
+	if err != nil {
+		return false, sqlcon.HandleError(err)
+	}
+	return count > 0, nil
+}
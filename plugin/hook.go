@@ -0,0 +1,36 @@
+package plugin
+
+import (
+	"github.com/ory/kratos/identity"
+	"github.com/ory/kratos/session"
+)
+
+// MethodExecuteHook is the gRPC method called by the plugin hook. Plugins written in Go implement it using
+// HookServer and Serve.
+const MethodExecuteHook = "/" + ServiceName + "/ExecuteHook"
+
+type (
+	// HookRequest is sent to the plugin when the hook is executed.
+	HookRequest struct {
+		// Event is the event which triggered the hook: "registration", "login", or "logout".
+		Event string `json:"event"`
+
+		// Identity is the identity about to be registered. It is only set for registrations.
+		Identity *identity.Identity `json:"identity,omitempty"`
+
+		// Session is the session which was issued (login) or ended (logout).
+		Session *session.Session `json:"session,omitempty"`
+	}
+	// HookResponse is returned by the plugin. Registrations are rejected if it contains errors.
+	HookResponse struct {
+		Errors []FieldError `json:"errors"`
+	}
+	// FieldError is shown at the form field identified by the JSON pointer.
+	FieldError struct {
+		// InstancePtr is the JSON pointer of the rejected value, for example "#/traits/email".
+		InstancePtr string `json:"instance_ptr"`
+
+		// Message is the human readable error message.
+		Message string `json:"message"`
+	}
+)
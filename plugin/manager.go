@@ -0,0 +1,285 @@
+package plugin
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"os/exec"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/structpb"
+
+	"github.com/ory/herodot"
+
+	"github.com/ory/kratos/driver/config"
+	"github.com/ory/kratos/x"
+)
+
+const handshakeTimeout = 10 * time.Second
+
+type (
+	managerDependencies interface {
+		config.Provider
+		x.LoggingProvider
+	}
+	ManagementProvider interface {
+		PluginManager() *Manager
+	}
+	// Manager starts the plugin processes declared in the configuration and calls the gRPC service defined
+	// in plugin.proto.
+	//
+	// Processes are started on first use and restarted if they exit. Each process receives ProtocolVersion
+	// in the ProtocolVersionEnv environment variable and prints a handshake announcing where it listens as
+	// the first line of its standard output, see Serve. A plugin should exit once its standard input is
+	// closed, which happens when ORY Kratos exits.
+	//
+	// Plugins are started and perform the handshake without holding the lock of the manager, so a plugin
+	// which is slow to start does not block calls to other plugins.
+	Manager struct {
+		d         managerDependencies
+		mu        sync.Mutex
+		processes map[string]*process
+		starting  map[string]*start
+	}
+	// start is shared by all calls which wait for the same plugin to start.
+	start struct {
+		done chan struct{}
+		p    *process
+		err  error
+	}
+	process struct {
+		cmd    *exec.Cmd
+		stdin  io.Closer
+		conn   *grpc.ClientConn
+		exited chan struct{}
+	}
+	// handshakeWriter passes the first line the plugin writes to its standard output to the manager and
+	// forwards everything else to the log.
+	handshakeWriter struct {
+		w    io.Writer
+		buf  []byte
+		line chan string
+	}
+)
+
+func NewManager(d managerDependencies) *Manager {
+	return &Manager{d: d, processes: map[string]*process{}, starting: map[string]*start{}}
+}
+
+func (h *handshakeWriter) Write(b []byte) (int, error) {
+	if h.line == nil {
+		return h.w.Write(b)
+	}
+
+	h.buf = append(h.buf, b...)
+	i := bytes.IndexByte(h.buf, '\n')
+	if i < 0 {
+		return len(b), nil
+	}
+
+	h.line <- string(h.buf[:i])
+	h.line = nil
+	if rest := h.buf[i+1:]; len(rest) > 0 {
+		if _, err := h.w.Write(rest); err != nil {
+			return len(b), err
+		}
+	}
+	h.buf = nil
+	return len(b), nil
+}
+
+func (p *process) close() {
+	if p.conn != nil {
+		_ = p.conn.Close()
+	}
+	_ = p.stdin.Close()
+	_ = p.cmd.Process.Kill()
+}
+
+// Call calls the method, for example MethodExecuteHook, of the named plugin. Arguments and replies are
+// sent as google.protobuf.Struct messages using their JSON representation.
+func (m *Manager) Call(ctx context.Context, name, method string, args, reply interface{}) error {
+	p, err := m.process(ctx, name)
+	if err != nil {
+		return err
+	}
+
+	in, err := toStruct(args)
+	if err != nil {
+		return err
+	}
+
+	var out structpb.Struct
+	if err := p.conn.Invoke(ctx, method, in, &out); err != nil {
+		if ctx.Err() != nil {
+			return errors.WithStack(ctx.Err())
+		}
+
+		if status.Code(err) != codes.Unavailable {
+			return errors.WithStack(herodot.ErrInternalServerError.WithReasonf(`Plugin "%s" returned an error.`, name).WithDebug(err.Error()))
+		}
+
+		// The connection to the plugin broke, which is why the process is restarted on the next call.
+		m.stop(name, p)
+		return errors.WithStack(herodot.ErrInternalServerError.WithReasonf(`Unable to call plugin "%s".`, name).WithDebug(err.Error()))
+	}
+
+	return fromStruct(&out, reply)
+}
+
+// Close stops all plugin processes.
+func (m *Manager) Close() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for name, p := range m.processes {
+		p.close()
+		delete(m.processes, name)
+	}
+}
+
+func (m *Manager) process(ctx context.Context, name string) (*process, error) {
+	m.mu.Lock()
+	if p, ok := m.processes[name]; ok {
+		m.mu.Unlock()
+		return p, nil
+	}
+
+	if s, ok := m.starting[name]; ok {
+		m.mu.Unlock()
+		select {
+		case <-s.done:
+			return s.p, s.err
+		case <-ctx.Done():
+			return nil, errors.WithStack(ctx.Err())
+		}
+	}
+
+	s := &start{done: make(chan struct{})}
+	m.starting[name] = s
+	m.mu.Unlock()
+
+	p, conn, err := m.start(ctx, name)
+
+	m.mu.Lock()
+	delete(m.starting, name)
+	if err == nil {
+		select {
+		case <-p.exited:
+			_ = conn.Close()
+			err = errors.WithStack(herodot.ErrInternalServerError.WithReasonf(`Unable to start plugin "%s".`, name).WithDebug("the plugin exited after completing the handshake"))
+		default:
+			// The connection is only assigned while holding the lock because stop closes it.
+			p.conn = conn
+			m.processes[name] = p
+			s.p = p
+		}
+	}
+	s.err = err
+	m.mu.Unlock()
+	close(s.done)
+
+	if err != nil {
+		return nil, err
+	}
+	m.d.Logger().WithField("plugin", name).Info("Started plugin process.")
+	return p, nil
+}
+
+// start starts the plugin process and waits for its handshake. It must be called without holding the lock.
+func (m *Manager) start(ctx context.Context, name string) (*process, *grpc.ClientConn, error) {
+	var c *config.Plugin
+	for _, p := range m.d.Config(ctx).Plugins() {
+		if p.Name == name {
+			p := p
+			c = &p
+			break
+		}
+	}
+	if c == nil {
+		return nil, nil, errors.WithStack(herodot.ErrInternalServerError.WithReasonf(`Plugin "%s" is not configured in "%s".`, name, config.ViperKeyPlugins))
+	}
+
+	logs := m.d.Logger().WithField("plugin", name).Writer()
+	stdout := &handshakeWriter{w: logs, line: make(chan string, 1)}
+	lines := stdout.line
+
+	cmd := exec.Command(c.Command, c.Args...)
+	cmd.Env = append(os.Environ(), fmt.Sprintf("%s=%d", ProtocolVersionEnv, ProtocolVersion))
+	cmd.Stdout = stdout
+	cmd.Stderr = logs
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		_ = logs.Close()
+		return nil, nil, errors.WithStack(err)
+	}
+
+	if err := cmd.Start(); err != nil {
+		_ = logs.Close()
+		return nil, nil, errors.WithStack(herodot.ErrInternalServerError.WithReasonf(`Unable to start plugin "%s".`, name).WithDebug(err.Error()))
+	}
+
+	p := &process{cmd: cmd, stdin: stdin, exited: make(chan struct{})}
+	go func() {
+		err := cmd.Wait()
+		_ = logs.Close()
+		close(p.exited)
+		m.d.Logger().WithError(err).WithField("plugin", name).Warn("Plugin process exited.")
+		m.stop(name, p)
+	}()
+
+	// The handshake is not bound to the context of the request which started the plugin because other
+	// requests may be waiting for the plugin as well.
+	conn, err := m.connect(context.Background(), p, lines)
+	if err != nil {
+		p.close()
+		return nil, nil, errors.WithStack(herodot.ErrInternalServerError.WithReasonf(`Unable to start plugin "%s".`, name).WithDebug(err.Error()))
+	}
+
+	return p, conn, nil
+}
+
+func (m *Manager) connect(ctx context.Context, p *process, lines <-chan string) (*grpc.ClientConn, error) {
+	ctx, cancel := context.WithTimeout(ctx, handshakeTimeout)
+	defer cancel()
+
+	var line string
+	select {
+	case line = <-lines:
+	case <-p.exited:
+		return nil, errors.New("the plugin exited before completing the handshake")
+	case <-ctx.Done():
+		return nil, errors.Wrap(ctx.Err(), "the plugin did not complete the handshake")
+	}
+
+	h, err := parseHandshake(line)
+	if err != nil {
+		return nil, err
+	}
+
+	conn, err := grpc.DialContext(ctx, "passthrough:///"+h.address,
+		grpc.WithInsecure(),
+		grpc.WithBlock(),
+		grpc.WithAuthority("localhost"),
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return new(net.Dialer).DialContext(ctx, h.network, h.address)
+		}),
+	)
+	return conn, errors.WithStack(err)
+}
+
+func (m *Manager) stop(name string, p *process) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.processes[name] == p {
+		delete(m.processes, name)
+	}
+	p.close()
+}
@@ -0,0 +1,158 @@
+package plugin_test
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ory/herodot"
+
+	"github.com/ory/kratos/driver/config"
+	"github.com/ory/kratos/internal"
+	"github.com/ory/kratos/plugin"
+)
+
+type helperPlugin struct{}
+
+func (p *helperPlugin) ExecuteHook(_ context.Context, req *plugin.HookRequest) (*plugin.HookResponse, error) {
+	var res plugin.HookResponse
+	switch req.Event {
+	case "registration":
+		res.Errors = []plugin.FieldError{{InstancePtr: "#/traits/email", Message: "rejected"}}
+	case "fail":
+		return nil, errors.New("plugin failure")
+	case "exit":
+		os.Exit(0)
+	}
+	return &res, nil
+}
+
+// TestHelperPlugin is not a real test. It is started by TestManager as the plugin process.
+func TestHelperPlugin(t *testing.T) {
+	if os.Getenv("KRATOS_TEST_HELPER_PLUGIN") != "1" {
+		t.Skip("only runs as a plugin process")
+	}
+
+	require.NoError(t, plugin.Serve(new(helperPlugin)))
+	os.Exit(0)
+}
+
+// TestHelperOutdatedPlugin is not a real test. It is started by TestManager as a plugin process which
+// speaks an unsupported protocol version.
+func TestHelperOutdatedPlugin(t *testing.T) {
+	if os.Getenv("KRATOS_TEST_HELPER_PLUGIN") != "1" {
+		t.Skip("only runs as a plugin process")
+	}
+
+	fmt.Println("0|unix|/dev/null|grpc")
+	_, _ = io.Copy(ioutil.Discard, os.Stdin)
+	os.Exit(0)
+}
+
+// TestHelperHungPlugin is not a real test. It is started by TestManager as a plugin process which never
+// completes the handshake.
+func TestHelperHungPlugin(t *testing.T) {
+	if os.Getenv("KRATOS_TEST_HELPER_PLUGIN") != "1" {
+		t.Skip("only runs as a plugin process")
+	}
+
+	_, _ = io.Copy(ioutil.Discard, os.Stdin)
+	os.Exit(0)
+}
+
+func TestManager(t *testing.T) {
+	require.NoError(t, os.Setenv("KRATOS_TEST_HELPER_PLUGIN", "1"))
+	t.Cleanup(func() {
+		_ = os.Unsetenv("KRATOS_TEST_HELPER_PLUGIN")
+	})
+
+	conf, reg := internal.NewFastRegistryWithMocks(t)
+	conf.MustSet(config.ViperKeyPlugins, []map[string]interface{}{
+		{"name": "helper", "command": os.Args[0], "args": []string{"-test.run=TestHelperPlugin"}},
+	})
+	m := plugin.NewManager(reg)
+	t.Cleanup(m.Close)
+
+	call := func(event string) (*plugin.HookResponse, error) {
+		var res plugin.HookResponse
+		err := m.Call(context.Background(), "helper", plugin.MethodExecuteHook, &plugin.HookRequest{Event: event}, &res)
+		return &res, err
+	}
+
+	t.Run("case=calls the plugin", func(t *testing.T) {
+		res, err := call("login")
+		require.NoError(t, err)
+		assert.Empty(t, res.Errors)
+
+		res, err = call("registration")
+		require.NoError(t, err)
+		assert.Equal(t, []plugin.FieldError{{InstancePtr: "#/traits/email", Message: "rejected"}}, res.Errors)
+	})
+
+	t.Run("case=returns errors of the plugin", func(t *testing.T) {
+		_, err := call("fail")
+		require.Error(t, err)
+
+		_, err = call("login")
+		require.NoError(t, err)
+	})
+
+	t.Run("case=restarts the plugin after it exited", func(t *testing.T) {
+		_, err := call("exit")
+		require.Error(t, err)
+
+		_, err = call("login")
+		require.NoError(t, err)
+	})
+
+	t.Run("case=rejects plugins speaking another protocol version", func(t *testing.T) {
+		conf.MustSet(config.ViperKeyPlugins, []map[string]interface{}{
+			{"name": "helper", "command": os.Args[0], "args": []string{"-test.run=TestHelperPlugin"}},
+			{"name": "outdated", "command": os.Args[0], "args": []string{"-test.run=TestHelperOutdatedPlugin"}},
+		})
+
+		var res plugin.HookResponse
+		err := m.Call(context.Background(), "outdated", plugin.MethodExecuteHook, &plugin.HookRequest{}, &res)
+		require.Error(t, err)
+		assert.Contains(t, errors.Cause(err).(*herodot.DefaultError).DebugField, "protocol version 0")
+	})
+
+	t.Run("case=a plugin which is slow to start does not block other plugins", func(t *testing.T) {
+		// Stops the helper plugin so that it is started again while the hung plugin is starting.
+		m.Close()
+		conf.MustSet(config.ViperKeyPlugins, []map[string]interface{}{
+			{"name": "helper", "command": os.Args[0], "args": []string{"-test.run=TestHelperPlugin"}},
+			{"name": "hung", "command": os.Args[0], "args": []string{"-test.run=TestHelperHungPlugin"}},
+		})
+
+		hung := make(chan error, 1)
+		go func() {
+			var res plugin.HookResponse
+			hung <- m.Call(context.Background(), "hung", plugin.MethodExecuteHook, &plugin.HookRequest{}, &res)
+		}()
+
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		var res plugin.HookResponse
+		require.NoError(t, m.Call(ctx, "helper", plugin.MethodExecuteHook, &plugin.HookRequest{Event: "login"}, &res))
+
+		select {
+		case err := <-hung:
+			t.Fatalf("expected the hung plugin to still be starting but got: %+v", err)
+		default:
+		}
+	})
+
+	t.Run("case=fails for unknown plugins", func(t *testing.T) {
+		var res plugin.HookResponse
+		require.Error(t, m.Call(context.Background(), "unknown", plugin.MethodExecuteHook, &plugin.HookRequest{}, &res))
+	})
+}
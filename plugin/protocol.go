@@ -0,0 +1,81 @@
+package plugin
+
+import (
+	"encoding/json"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+const (
+	// ProtocolVersion is the version of the plugin protocol. It is increased whenever the handshake or the
+	// gRPC service defined in plugin.proto change in an incompatible way.
+	ProtocolVersion = 1
+
+	// ProtocolVersionEnv is the environment variable in which ORY Kratos passes ProtocolVersion to the plugin.
+	ProtocolVersionEnv = "KRATOS_PLUGIN_PROTOCOL_VERSION"
+
+	// ServiceName is the fully qualified name of the gRPC service plugins implement.
+	ServiceName = "ory.kratos.plugin.v1.Plugin"
+
+	protocolGRPC = "grpc"
+)
+
+// handshake is the first line a plugin prints to its standard output once it is ready to accept
+// connections, for example "1|unix|/tmp/plugin/plugin.sock|grpc".
+type handshake struct {
+	version  int
+	network  string
+	address  string
+	protocol string
+}
+
+func (h *handshake) String() string {
+	return strings.Join([]string{strconv.Itoa(h.version), h.network, h.address, h.protocol}, "|")
+}
+
+func parseHandshake(line string) (*handshake, error) {
+	parts := strings.Split(strings.TrimSpace(line), "|")
+	if len(parts) != 4 {
+		return nil, errors.Errorf("expected the handshake to have the format `version|network|address|protocol` but got: %s", line)
+	}
+
+	version, err := strconv.Atoi(parts[0])
+	if err != nil {
+		return nil, errors.Errorf("expected the handshake to start with the protocol version but got: %s", line)
+	}
+	if version != ProtocolVersion {
+		return nil, errors.Errorf("the plugin speaks protocol version %d but ORY Kratos requires version %d", version, ProtocolVersion)
+	}
+	if parts[3] != protocolGRPC {
+		return nil, errors.Errorf(`the plugin speaks protocol "%s" but ORY Kratos requires "%s"`, parts[3], protocolGRPC)
+	}
+
+	return &handshake{version: version, network: parts[1], address: parts[2], protocol: parts[3]}, nil
+}
+
+// toStruct and fromStruct convert requests and responses to the google.protobuf.Struct messages used by the
+// gRPC service. Values are converted using their JSON representation.
+func toStruct(v interface{}) (*structpb.Struct, error) {
+	raw, err := json.Marshal(v)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	var s structpb.Struct
+	if err := protojson.Unmarshal(raw, &s); err != nil {
+		return nil, errors.WithStack(err)
+	}
+	return &s, nil
+}
+
+func fromStruct(s *structpb.Struct, v interface{}) error {
+	raw, err := protojson.Marshal(s)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	return errors.WithStack(json.Unmarshal(raw, v))
+}
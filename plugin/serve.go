@@ -0,0 +1,114 @@
+package plugin
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+
+	"github.com/pkg/errors"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+// HookServer is implemented by plugins written in Go. Plugins written in other languages implement the
+// gRPC service defined in plugin.proto instead.
+type HookServer interface {
+	ExecuteHook(ctx context.Context, req *HookRequest) (*HookResponse, error)
+}
+
+var serviceDesc = grpc.ServiceDesc{
+	ServiceName: ServiceName,
+	HandlerType: (*HookServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{MethodName: "ExecuteHook", Handler: executeHookHandler},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "plugin/plugin.proto",
+}
+
+func executeHookHandler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(structpb.Struct)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+
+	handler := func(ctx context.Context, in interface{}) (interface{}, error) {
+		var req HookRequest
+		if err := fromStruct(in.(*structpb.Struct), &req); err != nil {
+			return nil, status.Error(codes.InvalidArgument, err.Error())
+		}
+
+		res, err := srv.(HookServer).ExecuteHook(ctx, &req)
+		if err != nil {
+			return nil, err
+		}
+		return toStruct(res)
+	}
+
+	if interceptor == nil {
+		return handler(ctx, in)
+	}
+	return interceptor(ctx, in, &grpc.UnaryServerInfo{Server: srv, FullMethod: MethodExecuteHook}, handler)
+}
+
+// Serve serves the hook until ORY Kratos closes the plugin's standard input. It is called from the main
+// function of plugins written in Go:
+//
+//	func main() {
+//		if err := plugin.Serve(new(myPlugin)); err != nil {
+//			log.Fatal(err)
+//		}
+//	}
+func Serve(impl HookServer) error {
+	if v := os.Getenv(ProtocolVersionEnv); v != strconv.Itoa(ProtocolVersion) {
+		return errors.Errorf(`ORY Kratos requested plugin protocol version "%s" but this plugin speaks version %d`, v, ProtocolVersion)
+	}
+
+	l, cleanup, err := listen()
+	if err != nil {
+		return err
+	}
+	defer cleanup()
+
+	s := grpc.NewServer()
+	s.RegisterService(&serviceDesc, impl)
+
+	go func() {
+		_, _ = io.Copy(ioutil.Discard, os.Stdin)
+		s.Stop()
+	}()
+
+	h := handshake{version: ProtocolVersion, network: l.Addr().Network(), address: l.Addr().String(), protocol: protocolGRPC}
+	if _, err := fmt.Fprintln(os.Stdout, h.String()); err != nil {
+		return errors.WithStack(err)
+	}
+
+	return errors.WithStack(s.Serve(l))
+}
+
+func listen() (net.Listener, func(), error) {
+	if runtime.GOOS == "windows" {
+		l, err := net.Listen("tcp", "127.0.0.1:0")
+		return l, func() {}, errors.WithStack(err)
+	}
+
+	dir, err := ioutil.TempDir("", "kratos-plugin")
+	if err != nil {
+		return nil, nil, errors.WithStack(err)
+	}
+
+	l, err := net.Listen("unix", filepath.Join(dir, "plugin.sock"))
+	if err != nil {
+		_ = os.RemoveAll(dir)
+		return nil, nil, errors.WithStack(err)
+	}
+	return l, func() { _ = os.RemoveAll(dir) }, nil
+}
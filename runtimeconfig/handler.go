@@ -0,0 +1,64 @@
+package runtimeconfig
+
+import (
+	"net/http"
+
+	"github.com/julienschmidt/httprouter"
+
+	"github.com/ory/kratos/driver/config"
+	"github.com/ory/kratos/x"
+)
+
+const RouteConfig = "/config"
+
+type (
+	handlerDependencies interface {
+		config.Provider
+		x.WriterProvider
+	}
+	HandlerProvider interface {
+		RuntimeConfigHandler() *Handler
+	}
+	// Handler serves the effective configuration over the admin API, so that operators can see which of the config
+	// files, config directory fragments, environment variables, and flags won.
+	Handler struct {
+		d handlerDependencies
+	}
+)
+
+func NewHandler(d handlerDependencies) *Handler {
+	return &Handler{d: d}
+}
+
+func (h *Handler) RegisterAdminRoutes(admin *x.RouterAdmin) {
+	admin.GET(RouteConfig, h.get)
+}
+
+// The Effective Configuration
+//
+// swagger:response effectiveConfig
+// nolint:deadcode,unused
+type effectiveConfigResponse struct {
+	// in: body
+	Body map[string]interface{}
+}
+
+// swagger:route GET /config admin getEffectiveConfig
+//
+// Get the Effective Configuration
+//
+// Returns the configuration ORY Kratos uses for the request, with all config files, config directory fragments,
+// environment variables, flags, and per-context overrides applied. The values of sensitive keys, such as `dsn`,
+// `secrets`, and `courier.smtp.connection_uri`, are replaced by `[redacted]`.
+//
+//     Produces:
+//     - application/json
+//
+//     Schemes: http, https
+//
+//     Responses:
+//       200: effectiveConfig
+//       500: genericError
+func (h *Handler) get(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+	h.d.Writer().Write(w, r, h.d.Config(r.Context()).Redacted())
+}
@@ -102,3 +102,56 @@ func NewDuplicateCredentialsError() error {
 		Messages: new(text.Messages).Add(text.NewErrorValidationDuplicateCredentials()),
 	})
 }
+
+type ValidationErrorContextAddressNotVerifiedError struct{}
+
+func (r *ValidationErrorContextAddressNotVerifiedError) AddContext(_, _ string) {}
+
+func (r *ValidationErrorContextAddressNotVerifiedError) FinishInstanceContext() {}
+
+func NewAddressNotVerifiedError() error {
+	return errors.WithStack(&ValidationError{
+		ValidationError: &jsonschema.ValidationError{
+			Message:     `none of the addresses (email, phone, ...) of this account are verified yet`,
+			InstancePtr: "#/",
+			Context:     &ValidationErrorContextAddressNotVerifiedError{},
+		},
+		Messages: new(text.Messages).Add(text.NewErrorValidationAddressNotVerified()),
+	})
+}
+
+type ValidationErrorContextTermsOfServiceNotAcceptedError struct{}
+
+func (r *ValidationErrorContextTermsOfServiceNotAcceptedError) AddContext(_, _ string) {}
+
+func (r *ValidationErrorContextTermsOfServiceNotAcceptedError) FinishInstanceContext() {}
+
+func NewTermsOfServiceNotAcceptedError(version, url string) error {
+	return errors.WithStack(&ValidationError{
+		ValidationError: &jsonschema.ValidationError{
+			Message:     fmt.Sprintf("the terms of service (version %s) must be accepted", version),
+			InstancePtr: "#/terms_of_service",
+			Context:     &ValidationErrorContextTermsOfServiceNotAcceptedError{},
+		},
+		Messages: new(text.Messages).Add(text.NewErrorValidationTermsOfServiceNotAccepted(version, url)),
+	})
+}
+
+type ValidationErrorContextMinimumAgeError struct {
+	MinimumAge int
+}
+
+func (r *ValidationErrorContextMinimumAgeError) AddContext(_, _ string) {}
+
+func (r *ValidationErrorContextMinimumAgeError) FinishInstanceContext() {}
+
+func NewMinimumAgeError(instancePtr string, minimumAge int) error {
+	return errors.WithStack(&ValidationError{
+		ValidationError: &jsonschema.ValidationError{
+			Message:     fmt.Sprintf("must be at least %d years old", minimumAge),
+			InstancePtr: instancePtr,
+			Context:     &ValidationErrorContextMinimumAgeError{MinimumAge: minimumAge},
+		},
+		Messages: new(text.Messages).Add(text.NewErrorValidationRegistrationMinimumAge(minimumAge)),
+	})
+}
@@ -47,6 +47,7 @@ type (
 	}
 
 	ExtensionRunner struct {
+		name     ExtensionRunnerMetaSchema
 		meta     *jsonschema.Schema
 		compile  func(ctx jsonschema.CompilerContext, m map[string]interface{}) (interface{}, error)
 		validate func(ctx jsonschema.ValidationContext, s interface{}, v interface{}) error
@@ -62,7 +63,7 @@ func NewExtensionRunner(meta ExtensionRunnerMetaSchema, runners ...Extension) (*
 		return nil, errors.WithStack(err)
 	}
 
-	r := new(ExtensionRunner)
+	r := &ExtensionRunner{name: meta}
 	r.meta, err = jsonschema.CompileString(string(meta), string(schema))
 	if err != nil {
 		return nil, errors.WithStack(err)
@@ -10,6 +10,7 @@ import (
 	"github.com/pkg/errors"
 
 	"github.com/ory/herodot"
+	"github.com/ory/jsonschema/v3"
 
 	"github.com/ory/kratos/x"
 )
@@ -90,13 +91,12 @@ func (h *Handler) get(w http.ResponseWriter, r *http.Request, ps httprouter.Para
 		}
 		defer src.Close()
 	} else {
-		resp, err := http.Get(s.URL.String())
+		src, err = jsonschema.LoadURL(s.URL.String())
 		if err != nil {
 			h.r.Writer().WriteError(w, r, errors.WithStack(herodot.ErrInternalServerError.WithReasonf("The file for this JSON Schema ID could not be found or opened. This is a configuration issue.").WithDebugf("%+v", err)))
 			return
 		}
-		defer resp.Body.Close()
-		src = resp.Body
+		defer src.Close()
 	}
 
 	w.Header().Add("Content-Type", "application/json")
@@ -0,0 +1,163 @@
+package schema
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"sync"
+
+	"github.com/cenkalti/backoff"
+	"github.com/pkg/errors"
+
+	"github.com/ory/jsonschema/v3"
+
+	"github.com/ory/kratos/driver/config"
+)
+
+// remoteLoader replaces the jsonschema loaders for HTTP and HTTPS URLs. Depending on the configured failure
+// policy, it retries failed requests or falls back to the last copy of the schema it loaded successfully.
+type remoteLoader struct {
+	sync.RWMutex
+	conf    config.SchemaLoading
+	client  *http.Client
+	loaders map[string]func(url string) (io.ReadCloser, error)
+	cache   map[string][]byte
+}
+
+var remote = &remoteLoader{
+	conf:    config.SchemaLoading{OnFailure: config.SchemaLoadingFail},
+	loaders: map[string]func(url string) (io.ReadCloser, error){},
+	cache:   map[string][]byte{},
+}
+
+func init() {
+	for _, scheme := range []string{"http", "https"} {
+		remote.loaders[scheme] = jsonschema.Loaders[scheme]
+		jsonschema.Loaders[scheme] = remote.load
+	}
+}
+
+// ConfigureRemoteLoading sets what happens if a schema can not be loaded over HTTP or HTTPS. It applies to
+// all schemas loaded by this process.
+func ConfigureRemoteLoading(c config.SchemaLoading) {
+	remote.Lock()
+	defer remote.Unlock()
+	remote.conf = c
+}
+
+// ConfigureRemoteClient sets the HTTP client which loads schemas over HTTP or HTTPS, for example to send requests
+// through the egress proxy. It applies to all schemas loaded by this process.
+func ConfigureRemoteClient(hc *http.Client) {
+	remote.Lock()
+	defer remote.Unlock()
+	remote.client = hc
+}
+
+// IsRemote returns true if the schema is loaded over HTTP or HTTPS.
+func IsRemote(rawURL string) bool {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return false
+	}
+	return u.Scheme == "http" || u.Scheme == "https"
+}
+
+// PreloadRemote loads all remote schemas so that failures show up on startup and, if the failure policy is
+// "cache", a copy of each schema is available if its host goes down later on.
+func PreloadRemote(ss Schemas) error {
+	for _, s := range ss {
+		if !IsRemote(s.RawURL) {
+			continue
+		}
+
+		rc, err := jsonschema.LoadURL(s.RawURL)
+		if err != nil {
+			return errors.Wrapf(err, "unable to load identity schema %s from %s", s.ID, s.RawURL)
+		}
+		_ = rc.Close()
+	}
+	return nil
+}
+
+func (l *remoteLoader) load(u string) (io.ReadCloser, error) {
+	l.RLock()
+	conf := l.conf
+	l.RUnlock()
+
+	var body []byte
+	fetch := func() (err error) {
+		body, err = l.fetch(u)
+		return err
+	}
+
+	var err error
+	if conf.OnFailure == config.SchemaLoadingRetry && conf.MaxAttempts > 1 {
+		bc := backoff.NewExponentialBackOff()
+		bc.InitialInterval = conf.InitialInterval
+		bc.MaxInterval = conf.MaxInterval
+		bc.MaxElapsedTime = 0
+		bc.Reset()
+		err = backoff.Retry(fetch, backoff.WithMaxRetries(bc, conf.MaxAttempts-1))
+	} else {
+		err = fetch()
+	}
+
+	if err != nil {
+		if conf.OnFailure == config.SchemaLoadingCache {
+			l.RLock()
+			cached, ok := l.cache[u]
+			l.RUnlock()
+			if ok {
+				return ioutil.NopCloser(bytes.NewReader(cached)), nil
+			}
+		}
+		return nil, err
+	}
+
+	l.Lock()
+	l.cache[u] = body
+	l.Unlock()
+	return ioutil.NopCloser(bytes.NewReader(body)), nil
+}
+
+func (l *remoteLoader) fetch(u string) ([]byte, error) {
+	parsed, err := url.Parse(u)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	l.RLock()
+	client := l.client
+	l.RUnlock()
+	if client != nil {
+		return l.get(client, u)
+	}
+
+	load, ok := l.loaders[parsed.Scheme]
+	if !ok || load == nil {
+		return nil, errors.Errorf("no loader is registered for scheme %s", parsed.Scheme)
+	}
+
+	rc, err := load(u)
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+
+	return ioutil.ReadAll(rc)
+}
+
+func (l *remoteLoader) get(client *http.Client, u string) ([]byte, error) {
+	res, err := client.Get(u)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return nil, errors.Errorf("%s returned status code %d", u, res.StatusCode)
+	}
+	return ioutil.ReadAll(res.Body)
+}
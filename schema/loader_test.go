@@ -0,0 +1,88 @@
+package schema
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ory/jsonschema/v3"
+
+	"github.com/ory/kratos/driver/config"
+)
+
+func TestRemoteLoader(t *testing.T) {
+	var failures, requests int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		if atomic.AddInt32(&failures, -1) >= 0 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		_, _ = w.Write([]byte(`{"type":"object"}`))
+	}))
+	t.Cleanup(ts.Close)
+	t.Cleanup(func() {
+		ConfigureRemoteLoading(config.SchemaLoading{OnFailure: config.SchemaLoadingFail})
+	})
+
+	load := func(t *testing.T, fail int32, c config.SchemaLoading) (string, error) {
+		atomic.StoreInt32(&failures, fail)
+		atomic.StoreInt32(&requests, 0)
+		ConfigureRemoteLoading(c)
+
+		rc, err := jsonschema.LoadURL(ts.URL + "/" + t.Name())
+		if err != nil {
+			return "", err
+		}
+		defer rc.Close()
+
+		body, err := ioutil.ReadAll(rc)
+		require.NoError(t, err)
+		return string(body), nil
+	}
+
+	t.Run("policy=fail", func(t *testing.T) {
+		_, err := load(t, 1, config.SchemaLoading{OnFailure: config.SchemaLoadingFail})
+		require.Error(t, err)
+		assert.EqualValues(t, 1, atomic.LoadInt32(&requests))
+	})
+
+	t.Run("policy=retry", func(t *testing.T) {
+		c := config.SchemaLoading{
+			OnFailure:       config.SchemaLoadingRetry,
+			MaxAttempts:     3,
+			InitialInterval: time.Millisecond,
+			MaxInterval:     time.Millisecond,
+		}
+
+		body, err := load(t, 2, c)
+		require.NoError(t, err)
+		assert.JSONEq(t, `{"type":"object"}`, body)
+		assert.EqualValues(t, 3, atomic.LoadInt32(&requests))
+
+		_, err = load(t, 3, c)
+		require.Error(t, err)
+		assert.EqualValues(t, 3, atomic.LoadInt32(&requests))
+	})
+
+	t.Run("policy=cache", func(t *testing.T) {
+		c := config.SchemaLoading{OnFailure: config.SchemaLoadingCache}
+
+		_, err := load(t, 1, c)
+		require.Error(t, err, "nothing was cached yet")
+
+		body, err := load(t, 0, c)
+		require.NoError(t, err)
+		assert.JSONEq(t, `{"type":"object"}`, body)
+
+		body, err = load(t, 1, c)
+		require.NoError(t, err)
+		assert.JSONEq(t, `{"type":"object"}`, body)
+	})
+}
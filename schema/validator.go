@@ -12,16 +12,36 @@ import (
 	"github.com/ory/jsonschema/v3"
 )
 
+// Validator validates documents against JSON schemas. Compiled schemas are cached, see Reset.
 type Validator struct {
 	sync.RWMutex
+	compiled   map[compiledSchemaKey][]*compiledSchema
+	generation int
 }
 
 type ValidationProvider interface {
 	SchemaValidator() *Validator
 }
 
+type (
+	compiledSchemaKey struct {
+		href string
+		meta ExtensionRunnerMetaSchema
+	}
+
+	// compiledSchema is a schema compiled with an extension which forwards to the runner of the validation using
+	// the schema. Each compiled schema is used by one validation at a time, so that concurrent validations can use
+	// different runners.
+	compiledSchema struct {
+		key        compiledSchemaKey
+		schema     *jsonschema.Schema
+		runner     *ExtensionRunner
+		generation int
+	}
+)
+
 func NewValidator() *Validator {
-	return &Validator{}
+	return &Validator{compiled: map[compiledSchemaKey][]*compiledSchema{}}
 }
 
 type validatorOptions struct {
@@ -44,32 +64,103 @@ func (v *Validator) Validate(
 		opt(&o)
 	}
 
-	compiler := jsonschema.NewCompiler()
-	resource, err := jsonschema.LoadURL(href)
+	c, err := v.acquire(href, o.e)
+	if err != nil {
+		return err
+	}
+
+	c.runner = o.e
+	err = c.schema.Validate(bytes.NewBuffer(document))
+	c.runner = nil
+	v.release(c)
 	if err != nil {
-		return errors.WithStack(herodot.ErrInternalServerError.WithReasonf("Unable to parse validate JSON object against JSON schema.").WithDebugf("%s", err))
+		return errors.WithStack(err)
 	}
 
 	if o.e != nil {
-		o.e.Register(compiler)
+		return o.e.Finish()
+	}
+
+	return nil
+}
+
+// WarmUp compiles the schema so that the first validation using it does not have to. The extension runner may be
+// nil if the schema is validated without one.
+func (v *Validator) WarmUp(href string, e *ExtensionRunner) error {
+	c, err := v.acquire(href, e)
+	if err != nil {
+		return err
+	}
+	v.release(c)
+	return nil
+}
+
+// Reset drops all compiled schemas, for example because the schemas changed. Schemas are compiled again on
+// their next use.
+func (v *Validator) Reset() {
+	v.Lock()
+	defer v.Unlock()
+	v.compiled = map[compiledSchemaKey][]*compiledSchema{}
+	v.generation++
+}
+
+// acquire returns a compiled schema which is not in use by another validation, compiling one if there is none.
+func (v *Validator) acquire(href string, e *ExtensionRunner) (*compiledSchema, error) {
+	key := compiledSchemaKey{href: href}
+	if e != nil {
+		key.meta = e.name
 	}
 
-	if err := compiler.AddResource(href, resource); err != nil {
-		return errors.WithStack(herodot.ErrInternalServerError.WithReasonf("Unable to parse validate JSON object against JSON schema.").WithDebugf("%s", err))
+	v.Lock()
+	if free := v.compiled[key]; len(free) > 0 {
+		c := free[len(free)-1]
+		v.compiled[key] = free[:len(free)-1]
+		v.Unlock()
+		return c, nil
+	}
+	generation := v.generation
+	v.Unlock()
+
+	return v.compile(key, e, generation)
+}
+
+// release returns the compiled schema to the cache unless the cache was reset while the schema was in use.
+func (v *Validator) release(c *compiledSchema) {
+	v.Lock()
+	defer v.Unlock()
+	if c.generation == v.generation {
+		v.compiled[c.key] = append(v.compiled[c.key], c)
 	}
+}
+
+func (v *Validator) compile(key compiledSchemaKey, e *ExtensionRunner, generation int) (*compiledSchema, error) {
+	c := &compiledSchema{key: key, generation: generation}
 
-	schema, err := compiler.Compile(href)
+	compiler := jsonschema.NewCompiler()
+	resource, err := jsonschema.LoadURL(key.href)
 	if err != nil {
-		return errors.WithStack(herodot.ErrInternalServerError.WithReasonf("Unable to parse validate JSON object against JSON schema.").WithDebugf("%s", err))
+		return nil, errors.WithStack(herodot.ErrInternalServerError.WithReasonf("Unable to parse validate JSON object against JSON schema.").WithDebugf("%s", err))
+	}
+	defer resource.Close()
+
+	if e != nil {
+		compiler.Extensions[extensionName] = jsonschema.Extension{
+			Meta:    e.meta,
+			Compile: e.compile,
+			Validate: func(ctx jsonschema.ValidationContext, s interface{}, value interface{}) error {
+				return c.runner.validate(ctx, s, value)
+			},
+		}
 	}
 
-	if err := schema.Validate(bytes.NewBuffer(document)); err != nil {
-		return errors.WithStack(err)
+	if err := compiler.AddResource(key.href, resource); err != nil {
+		return nil, errors.WithStack(herodot.ErrInternalServerError.WithReasonf("Unable to parse validate JSON object against JSON schema.").WithDebugf("%s", err))
 	}
 
-	if o.e != nil {
-		return o.e.Finish()
+	c.schema, err = compiler.Compile(key.href)
+	if err != nil {
+		return nil, errors.WithStack(herodot.ErrInternalServerError.WithReasonf("Unable to parse validate JSON object against JSON schema.").WithDebugf("%s", err))
 	}
 
-	return nil
+	return c, nil
 }
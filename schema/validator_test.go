@@ -5,9 +5,11 @@ import (
 	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"sync"
 	"testing"
 
 	"github.com/julienschmidt/httprouter"
+	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
 	"github.com/ory/x/stringsx"
@@ -68,3 +70,45 @@ func TestSchemaValidator(t *testing.T) {
 		})
 	}
 }
+
+func TestSchemaValidatorCache(t *testing.T) {
+	v := NewValidator()
+	href := "file://./stub/extension/schema.json"
+	key := compiledSchemaKey{href: href, meta: ExtensionRunnerIdentityMetaSchema}
+
+	warm, err := NewExtensionRunner(ExtensionRunnerIdentityMetaSchema)
+	require.NoError(t, err)
+	require.NoError(t, v.WarmUp(href, warm))
+	require.Len(t, v.compiled[key], 1)
+
+	t.Run("case=concurrent validations use their own runner", func(t *testing.T) {
+		var wg sync.WaitGroup
+		for i := 0; i < 10; i++ {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				r := new(extensionStub)
+				runner, err := NewExtensionRunner(ExtensionRunnerIdentityMetaSchema, r)
+				require.NoError(t, err)
+
+				email := fmt.Sprintf("foo-%d@ory.sh", i)
+				require.NoError(t, v.Validate(href, json.RawMessage(fmt.Sprintf(`{"email":"%s"}`, email)), WithExtensionRunner(runner)))
+				assert.Equal(t, []string{email}, r.identifiers)
+			}(i)
+		}
+		wg.Wait()
+
+		assert.NotEmpty(t, v.compiled[key])
+	})
+
+	t.Run("case=reset drops compiled schemas", func(t *testing.T) {
+		c, err := v.acquire(href, warm)
+		require.NoError(t, err)
+
+		v.Reset()
+		assert.Empty(t, v.compiled)
+
+		v.release(c)
+		assert.Empty(t, v.compiled, "schemas compiled before the reset must not be cached again")
+	})
+}
@@ -49,7 +49,12 @@ func (m *Manager) Create(ctx context.Context, w http.ResponseWriter, r *http.Req
 	q := url.Values{}
 	q.Set("error", id.String())
 
-	return urlx.CopyWithQuery(m.d.Config(ctx).SelfServiceFlowErrorURL(), q).String(), nil
+	errorURL, err := m.d.Config(ctx).SelfServiceFlowErrorURLOrErr()
+	if err != nil {
+		return "", err
+	}
+
+	return urlx.CopyWithQuery(errorURL, q).String(), nil
 }
 
 // Forward is a simple helper that saves all errors in the store and forwards the HTTP Request
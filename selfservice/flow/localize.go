@@ -0,0 +1,38 @@
+package flow
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/pkg/errors"
+
+	"github.com/ory/kratos/driver/config"
+	"github.com/ory/kratos/text"
+)
+
+// Locale returns the locale negotiated from the request's Accept-Language header and the configured locales.
+func Locale(r *http.Request, c *config.Config) string {
+	return text.NegotiateLocale(r.Header.Get("Accept-Language"), c.SelfServiceLocalesSupported(), c.SelfServiceLocalesDefault())
+}
+
+// Localize returns the flow with the texts of all its messages translated into the negotiated locale and sets the
+// Content-Language header accordingly. Message IDs and contexts are kept as is so that clients may rely on them.
+func Localize(w http.ResponseWriter, r *http.Request, c *config.Config, f interface{}) (interface{}, error) {
+	locale := Locale(r, c)
+	w.Header().Add("Vary", "Accept-Language")
+	w.Header().Set("Content-Language", locale)
+	if locale == text.DefaultLocale {
+		return f, nil
+	}
+
+	raw, err := json.Marshal(f)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	localized, err := text.LocalizeJSON(raw, locale)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	return json.RawMessage(localized), nil
+}
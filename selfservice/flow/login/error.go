@@ -23,6 +23,10 @@ import (
 var (
 	ErrHookAbortFlow   = errors.New("aborted login hook execution")
 	ErrAlreadyLoggedIn = herodot.ErrBadRequest.WithReason("A valid session was detected and thus login is not possible. Did you forget to set `?refresh=true`?")
+	ErrDisabled        = herodot.ErrNotFound.WithReason("Login is not allowed because it was disabled.")
+
+	// ErrIdentityDisabled is returned when an inactive identity attempts to sign in.
+	ErrIdentityDisabled = herodot.ErrUnauthorized.WithError("identity is disabled").WithReason("This account was disabled.")
 )
 
 type (
@@ -94,7 +98,12 @@ func (s *ErrorHandler) WriteFlowError(w http.ResponseWriter, r *http.Request, ct
 			http.Redirect(w, r, urlx.CopyWithQuery(urlx.AppendPaths(s.d.Config(r.Context()).SelfPublicURL(r),
 				RouteGetFlow), url.Values{"id": {a.ID.String()}}).String(), http.StatusFound)
 		} else {
-			http.Redirect(w, r, a.AppendTo(s.d.Config(r.Context()).SelfServiceFlowLoginUI()).String(), http.StatusFound)
+			loginUI, err := s.d.Config(r.Context()).SelfServiceFlowLoginUIOrErr()
+			if err != nil {
+				s.forward(w, r, a, err)
+				return
+			}
+			http.Redirect(w, r, a.AppendTo(loginUI).String(), http.StatusFound)
 		}
 		return
 	}
@@ -117,7 +126,12 @@ func (s *ErrorHandler) WriteFlowError(w http.ResponseWriter, r *http.Request, ct
 	}
 
 	if f.Type == flow.TypeBrowser {
-		http.Redirect(w, r, f.AppendTo(s.d.Config(r.Context()).SelfServiceFlowLoginUI()).String(), http.StatusFound)
+		loginUI, err := s.d.Config(r.Context()).SelfServiceFlowLoginUIOrErr()
+		if err != nil {
+			s.forward(w, r, f, err)
+			return
+		}
+		http.Redirect(w, r, f.AppendTo(loginUI).String(), http.StatusFound)
 		return
 	}
 
@@ -59,7 +59,7 @@ func TestHandleError(t *testing.T) {
 
 	newFlow := func(t *testing.T, ttl time.Duration, ft flow.Type) *login.Flow {
 		req := &http.Request{URL: urlx.ParseOrPanic("/")}
-		f := login.NewFlow(ttl, "csrf_token", req, ft)
+		f := login.NewFlow(x.SystemClock, x.RandomIDGenerator, ttl, "csrf_token", req, ft)
 		for _, s := range reg.LoginStrategies(context.Background()) {
 			require.NoError(t, s.PopulateLoginMethod(req, f))
 		}
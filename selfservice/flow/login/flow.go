@@ -90,10 +90,10 @@ type Flow struct {
 	Forced bool `json:"forced" db:"forced"`
 }
 
-func NewFlow(exp time.Duration, csrf string, r *http.Request, flowType flow.Type) *Flow {
-	now := time.Now().UTC()
+func NewFlow(c x.Clock, ids x.IDGenerator, exp time.Duration, csrf string, r *http.Request, flowType flow.Type) *Flow {
+	now := c.Now().UTC()
 	return &Flow{
-		ID:         x.NewUUID(),
+		ID:         ids.NewUUID(),
 		ExpiresAt:  now.Add(exp),
 		IssuedAt:   now,
 		RequestURL: x.RequestURL(r).String(),
@@ -135,8 +135,8 @@ func (f Flow) TableName(ctx context.Context) string {
 	return corp.ContextualizeTableName(ctx, "selfservice_login_flows")
 }
 
-func (f *Flow) Valid() error {
-	if f.ExpiresAt.Before(time.Now()) {
+func (f *Flow) Valid(c x.Clock) error {
+	if f.ExpiresAt.Before(c.Now()) {
 		return errors.WithStack(NewFlowExpiredError(f.ExpiresAt))
 	}
 	return nil
@@ -35,7 +35,7 @@ func TestFakeFlow(t *testing.T) {
 
 func TestNewFlow(t *testing.T) {
 	t.Run("case=0", func(t *testing.T) {
-		r := login.NewFlow(0, "csrf", &http.Request{
+		r := login.NewFlow(x.SystemClock, x.RandomIDGenerator, 0, "csrf", &http.Request{
 			URL:  urlx.ParseOrPanic("/"),
 			Host: "ory.sh", TLS: &tls.ConnectionState{},
 		}, flow.TypeBrowser)
@@ -46,7 +46,7 @@ func TestNewFlow(t *testing.T) {
 	})
 
 	t.Run("case=1", func(t *testing.T) {
-		r := login.NewFlow(0, "csrf", &http.Request{
+		r := login.NewFlow(x.SystemClock, x.RandomIDGenerator, 0, "csrf", &http.Request{
 			URL:  urlx.ParseOrPanic("/?refresh=true"),
 			Host: "ory.sh"}, flow.TypeAPI)
 		assert.Equal(t, r.IssuedAt, r.ExpiresAt)
@@ -56,7 +56,7 @@ func TestNewFlow(t *testing.T) {
 	})
 
 	t.Run("case=2", func(t *testing.T) {
-		r := login.NewFlow(0, "csrf", &http.Request{
+		r := login.NewFlow(x.SystemClock, x.RandomIDGenerator, 0, "csrf", &http.Request{
 			URL:  urlx.ParseOrPanic("https://ory.sh/"),
 			Host: "ory.sh"}, flow.TypeBrowser)
 		assert.Equal(t, "https://ory.sh/", r.RequestURL)
@@ -79,10 +79,20 @@ func TestFlow(t *testing.T) {
 			{r: &login.Flow{ExpiresAt: time.Now().Add(-time.Hour), IssuedAt: time.Now().Add(-time.Minute)}},
 		} {
 			if tc.valid {
-				require.NoError(t, tc.r.Valid())
+				require.NoError(t, tc.r.Valid(x.SystemClock))
 			} else {
-				require.Error(t, tc.r.Valid())
+				require.Error(t, tc.r.Valid(x.SystemClock))
 			}
 		}
 	})
+	t.Run("case=expires according to the clock", func(t *testing.T) {
+		clock := x.NewFakeClock(time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC))
+		f := login.NewFlow(clock, x.NewSequentialIDGenerator(), time.Hour, "csrf", &http.Request{URL: urlx.ParseOrPanic("/")}, flow.TypeBrowser)
+		assert.Equal(t, "00000000-0000-0000-0000-000000000001", f.ID.String())
+		assert.Equal(t, clock.Now(), f.IssuedAt)
+		require.NoError(t, f.Valid(clock))
+
+		clock.Add(time.Hour + time.Second)
+		require.Error(t, f.Valid(clock))
+	})
 }
@@ -2,7 +2,6 @@ package login
 
 import (
 	"net/http"
-	"time"
 
 	"github.com/julienschmidt/httprouter"
 	"github.com/pkg/errors"
@@ -32,6 +31,8 @@ type (
 		session.HandlerProvider
 		session.ManagementProvider
 		x.WriterProvider
+		x.ClockProvider
+		x.IDGeneratorProvider
 		x.CSRFTokenGeneratorProvider
 		x.CSRFProvider
 		config.Provider
@@ -61,7 +62,11 @@ func (h *Handler) RegisterAdminRoutes(admin *x.RouterAdmin) {
 }
 
 func (h *Handler) NewLoginFlow(w http.ResponseWriter, r *http.Request, flow flow.Type) (*Flow, error) {
-	a := NewFlow(h.d.Config(r.Context()).SelfServiceFlowLoginRequestLifespan(), h.d.GenerateCSRFToken(r), r, flow)
+	if !h.d.Config(r.Context()).SelfServiceFlowLoginEnabled() {
+		return nil, errors.WithStack(ErrDisabled)
+	}
+
+	a := NewFlow(h.d.Clock(), h.d.IDGenerator(), h.d.Config(r.Context()).SelfServiceFlowLoginRequestLifespan(), h.d.GenerateCSRFToken(r), r, flow)
 	for _, s := range h.d.LoginStrategies(r.Context()) {
 		if err := s.PopulateLoginMethod(r, a); err != nil {
 			return nil, err
@@ -171,9 +176,15 @@ func (h *Handler) initBrowserFlow(w http.ResponseWriter, r *http.Request, ps htt
 		return
 	}
 
+	loginUI, err := h.d.Config(r.Context()).SelfServiceFlowLoginUIOrErr()
+	if err != nil {
+		h.d.SelfServiceErrorManager().Forward(r.Context(), w, r, err)
+		return
+	}
+
 	// we assume an error means the user has no session
 	if _, err := h.d.SessionManager().FetchFromRequest(r.Context(), r); err != nil {
-		http.Redirect(w, r, a.AppendTo(h.d.Config(r.Context()).SelfServiceFlowLoginUI()).String(), http.StatusFound)
+		http.Redirect(w, r, a.AppendTo(loginUI).String(), http.StatusFound)
 		return
 	}
 
@@ -182,13 +193,20 @@ func (h *Handler) initBrowserFlow(w http.ResponseWriter, r *http.Request, ps htt
 			h.d.SelfServiceErrorManager().Forward(r.Context(), w, r, err)
 			return
 		}
-		http.Redirect(w, r, a.AppendTo(h.d.Config(r.Context()).SelfServiceFlowLoginUI()).String(), http.StatusFound)
+		http.Redirect(w, r, a.AppendTo(loginUI).String(), http.StatusFound)
 		return
 	}
 
-	returnTo, err := x.SecureRedirectTo(r, h.d.Config(r.Context()).SelfServiceBrowserDefaultReturnTo(),
+	defaultReturnTo, err := h.d.Config(r.Context()).SelfServiceBrowserDefaultReturnToOrErr()
+	if err != nil {
+		h.d.SelfServiceErrorManager().Forward(r.Context(), w, r, err)
+		return
+	}
+
+	returnTo, err := x.SecureRedirectTo(r, defaultReturnTo,
 		x.SecureRedirectAllowSelfServiceURLs(h.d.Config(r.Context()).SelfPublicURL(r)),
 		x.SecureRedirectAllowURLs(h.d.Config(r.Context()).SelfServiceBrowserWhitelistedReturnToDomains()),
+		x.SecureRedirectRejectPrivateHosts(h.d.Config(r.Context()).Egress()),
 	)
 	if err != nil {
 		h.d.SelfServiceErrorManager().Forward(r.Context(), w, r, err)
@@ -231,13 +249,18 @@ type getSelfServiceLoginFlow struct {
 //       410: genericError
 //       500: genericError
 func (h *Handler) fetchFlow(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+	if !h.d.Config(r.Context()).SelfServiceFlowLoginEnabled() {
+		h.d.Writer().WriteError(w, r, errors.WithStack(ErrDisabled))
+		return
+	}
+
 	ar, err := h.d.LoginFlowPersister().GetLoginFlow(r.Context(), x.ParseUUID(r.URL.Query().Get("id")))
 	if err != nil {
 		h.d.Writer().WriteError(w, r, err)
 		return
 	}
 
-	if ar.ExpiresAt.Before(time.Now()) {
+	if ar.ExpiresAt.Before(h.d.Clock().Now()) {
 		if ar.Type == flow.TypeBrowser {
 			h.d.Writer().WriteError(w, r, errors.WithStack(x.ErrGone.
 				WithReason("The login flow has expired. Redirect the user to the login flow init endpoint to initialize a new login flow.").
@@ -250,5 +273,10 @@ func (h *Handler) fetchFlow(w http.ResponseWriter, r *http.Request, _ httprouter
 		return
 	}
 
-	h.d.Writer().Write(w, r, ar)
+	localized, err := flow.Localize(w, r, h.d.Config(r.Context()), ar)
+	if err != nil {
+		h.d.Writer().WriteError(w, r, err)
+		return
+	}
+	h.d.Writer().Write(w, r, localized)
 }
@@ -17,6 +17,7 @@ import (
 	"github.com/tidwall/gjson"
 
 	"github.com/ory/x/assertx"
+	"github.com/ory/x/urlx"
 
 	"github.com/ory/kratos/driver/config"
 	"github.com/ory/kratos/identity"
@@ -24,6 +25,7 @@ import (
 	"github.com/ory/kratos/internal/testhelpers"
 	"github.com/ory/kratos/selfservice/flow"
 	"github.com/ory/kratos/selfservice/flow/login"
+	"github.com/ory/kratos/text"
 	"github.com/ory/kratos/x"
 )
 
@@ -109,6 +111,17 @@ func TestInitFlow(t *testing.T) {
 			assert.Contains(t, res.Request.URL.String(), login.RouteInitAPIFlow)
 			assertion(body, true, true)
 		})
+
+		t.Run("case=fails if login is disabled", func(t *testing.T) {
+			conf.MustSet(config.ViperKeySelfServiceLoginEnabled, false)
+			t.Cleanup(func() {
+				conf.MustSet(config.ViperKeySelfServiceLoginEnabled, true)
+			})
+
+			res, body := initFlow(t, url.Values{}, true)
+			assert.Equal(t, http.StatusNotFound, res.StatusCode)
+			assertx.EqualAsJSON(t, login.ErrDisabled, json.RawMessage(gjson.GetBytes(body, "error").Raw), "%s", body)
+		})
 	})
 
 	t.Run("flow=browser", func(t *testing.T) {
@@ -200,6 +213,56 @@ func TestGetFlow(t *testing.T) {
 			res, body := x.EasyGet(t, admin.Client(), endpoint.URL+login.RouteGetFlow+"?id="+lr.ID.String())
 			assertExpiredPayload(t, res, body)
 		})
+
+		t.Run("case=expires based on the injected clock", func(t *testing.T) {
+			clock := x.NewFakeClock(time.Now())
+			reg.WithClock(clock)
+			t.Cleanup(func() {
+				reg.WithClock(x.SystemClock)
+			})
+
+			lr := login.NewFlow(clock, x.RandomIDGenerator, time.Hour, x.FakeCSRFToken, &http.Request{URL: urlx.ParseOrPanic(public.URL + login.RouteInitBrowserFlow)}, flow.TypeBrowser)
+			require.NoError(t, reg.LoginFlowPersister().CreateLoginFlow(context.Background(), lr))
+
+			res, _ := x.EasyGet(t, admin.Client(), endpoint.URL+login.RouteGetFlow+"?id="+lr.ID.String())
+			assert.EqualValues(t, http.StatusOK, res.StatusCode)
+
+			clock.Add(2 * time.Hour)
+			res, body := x.EasyGet(t, admin.Client(), endpoint.URL+login.RouteGetFlow+"?id="+lr.ID.String())
+			assertExpiredPayload(t, res, body)
+		})
+
+		t.Run("case=localizes messages", func(t *testing.T) {
+			conf.MustSet(config.ViperKeySelfServiceLocalesSupported, []string{"en", "de"})
+			t.Cleanup(func() {
+				conf.MustSet(config.ViperKeySelfServiceLocalesSupported, []string{"en"})
+			})
+
+			lr := login.NewFlow(x.SystemClock, x.RandomIDGenerator, time.Hour, x.FakeCSRFToken, &http.Request{URL: urlx.ParseOrPanic(public.URL)}, flow.TypeAPI)
+			lr.Messages.Add(text.NewErrorValidationInvalidCredentials())
+			require.NoError(t, reg.LoginFlowPersister().CreateLoginFlow(context.Background(), lr))
+
+			for locale, expected := range map[string]string{
+				"":                "The provided credentials are invalid",
+				"de-CH, en;q=0.5": "Die Anmeldedaten sind ungültig",
+				"fr":              "The provided credentials are invalid",
+			} {
+				req, err := http.NewRequest("GET", endpoint.URL+login.RouteGetFlow+"?id="+lr.ID.String(), nil)
+				require.NoError(t, err)
+				req.Header.Set("Accept-Language", locale)
+
+				res, err := endpoint.Client().Do(req)
+				require.NoError(t, err)
+				body, err := ioutil.ReadAll(res.Body)
+				require.NoError(t, res.Body.Close())
+				require.NoError(t, err)
+
+				assert.EqualValues(t, http.StatusOK, res.StatusCode, "%s", body)
+				assert.EqualValues(t, text.ErrorValidationInvalidCredentials, gjson.GetBytes(body, "messages.0.id").Int(), "%s", body)
+				assert.Contains(t, gjson.GetBytes(body, "messages.0.text").String(), expected, "%s", body)
+				assert.Equal(t, lr.ID.String(), gjson.GetBytes(body, "id").String(), "%s", body)
+			}
+		})
 	}
 
 	t.Run("daemon=admin", func(t *testing.T) {
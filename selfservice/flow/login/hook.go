@@ -4,7 +4,6 @@ import (
 	"context"
 	"fmt"
 	"net/http"
-	"time"
 
 	"github.com/pkg/errors"
 
@@ -33,10 +32,14 @@ type (
 type (
 	executorDependencies interface {
 		config.Provider
+		identity.PrivilegedPoolProvider
 		session.ManagementProvider
 		session.PersistenceProvider
+		session.WebhookProvider
 		x.WriterProvider
 		x.LoggingProvider
+		x.ClockProvider
+		x.IDGeneratorProvider
 
 		HooksProvider
 	}
@@ -61,7 +64,12 @@ func NewHookExecutor(d executorDependencies) *HookExecutor {
 }
 
 func (e *HookExecutor) PostLoginHook(w http.ResponseWriter, r *http.Request, ct identity.CredentialsType, a *Flow, i *identity.Identity) error {
-	s := session.NewActiveSession(i, e.d.Config(r.Context()), time.Now().UTC()).Declassify()
+	if !i.IsActive() {
+		return errors.WithStack(ErrIdentityDisabled)
+	}
+
+	s := session.NewActiveSession(e.d.Clock(), e.d.IDGenerator(), i, e.d.Config(r.Context()), e.d.Clock().Now().UTC()).Declassify()
+	s.Device = session.NewDevice(r, e.d.Config(r.Context()))
 
 	e.d.Logger().
 		WithRequest(r).
@@ -98,6 +106,8 @@ func (e *HookExecutor) PostLoginHook(w http.ResponseWriter, r *http.Request, ct
 		if err := e.d.SessionPersister().CreateSession(r.Context(), s); err != nil {
 			return errors.WithStack(err)
 		}
+		e.recordLogin(r, s)
+		e.d.SessionWebhook().SessionCreated(r.Context(), s, ct.String())
 		e.d.Audit().
 			WithRequest(r).
 			WithField("session_id", s.ID).
@@ -111,6 +121,8 @@ func (e *HookExecutor) PostLoginHook(w http.ResponseWriter, r *http.Request, ct
 	if err := e.d.SessionManager().CreateAndIssueCookie(r.Context(), w, r, s); err != nil {
 		return errors.WithStack(err)
 	}
+	e.recordLogin(r, s)
+	e.d.SessionWebhook().SessionCreated(r.Context(), s, ct.String())
 
 	e.d.Audit().
 		WithRequest(r).
@@ -121,6 +133,17 @@ func (e *HookExecutor) PostLoginHook(w http.ResponseWriter, r *http.Request, ct
 		e.d.Writer(), e.d.Config(r.Context()), x.SecureRedirectOverrideDefaultReturnTo(e.d.Config(r.Context()).SelfServiceFlowLoginReturnTo(ct.String())))
 }
 
+// recordLogin records the time of the login on the identity. Failures are logged but do not fail the login.
+func (e *HookExecutor) recordLogin(r *http.Request, s *session.Session) {
+	if err := e.d.PrivilegedIdentityPool().RecordIdentityActivity(r.Context(), s.IdentityID, identity.ActivityLogin, s.AuthenticatedAt); err != nil {
+		e.d.Logger().
+			WithRequest(r).
+			WithError(err).
+			WithField("identity_id", s.IdentityID).
+			Warn("Unable to record the time of the login.")
+	}
+}
+
 func (e *HookExecutor) PreLoginHook(w http.ResponseWriter, r *http.Request, a *Flow) error {
 	for _, executor := range e.d.PreLoginHooks(r.Context()) {
 		if err := executor.ExecuteLoginPreHook(w, r, a); err != nil {
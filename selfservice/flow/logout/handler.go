@@ -5,6 +5,9 @@ import (
 	"net/http"
 
 	"github.com/julienschmidt/httprouter"
+	"github.com/pkg/errors"
+
+	"github.com/ory/herodot"
 
 	"github.com/ory/kratos/driver/config"
 	"github.com/ory/kratos/selfservice/errorx"
@@ -14,6 +17,15 @@ import (
 
 const (
 	RouteBrowser = "/self-service/browser/flows/logout"
+
+	// SessionsCurrent revokes only the session of the request.
+	SessionsCurrent = "current"
+
+	// SessionsAll revokes all sessions of the identity.
+	SessionsAll = "all"
+
+	// SessionsOthers revokes all sessions of the identity except the session of the request.
+	SessionsOthers = "others"
 )
 
 type (
@@ -22,6 +34,9 @@ type (
 		session.ManagementProvider
 		errorx.ManagementProvider
 		config.Provider
+		StrategyProvider
+		PropagatorProvider
+		HooksProvider
 	}
 	HandlerProvider interface {
 		LogoutHandler() *Handler
@@ -49,27 +64,71 @@ func (h *Handler) RegisterPublicRoutes(router *x.RouterPublic) {
 // with browsers (Chrome, Firefox, ...).
 //
 // On successful logout, the browser will be redirected (HTTP 302 Found) to the `return_to` parameter of the initial request
-// or fall back to `urls.default_return_to`.
+// or fall back to `urls.default_return_to`. If the session was issued by an upstream identity provider with RP-initiated
+// logout enabled, the browser is first redirected to the identity provider's end session endpoint. If front-channel
+// logout URLs are configured, a page loading these URLs is rendered before redirecting.
+//
+// The `sessions` query parameter overrides which sessions are revoked: only the `current` session, `all` sessions of
+// the identity, or all `others` except the current session. It defaults to `selfservice.flows.logout.sessions`.
 //
 // More information can be found at [ORY Kratos User Logout Documentation](https://www.ory.sh/docs/next/kratos/self-service/flows/user-logout).
 //
 //     Schemes: http, https
 //
 //     Responses:
+//       200: emptyResponse
 //       302: emptyResponse
 //       500: genericError
 func (h *Handler) logout(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
 	_ = h.d.CSRFHandler().RegenerateToken(w, r)
 
-	if err := h.d.SessionManager().PurgeFromRequest(r.Context(), w, r); err != nil {
+	// The session is needed to notify relying applications once it was purged.
+	sess, err := h.d.SessionManager().FetchFromRequest(r.Context(), r)
+	if err != nil && !errors.Is(err, session.ErrNoActiveSessionFound) {
 		h.d.SelfServiceErrorManager().Forward(r.Context(), w, r, err)
 		return
 	}
 
+	sessions := h.d.Config(r.Context()).SelfServiceFlowLogoutSessions()
+	if requested := r.URL.Query().Get("sessions"); len(requested) > 0 {
+		sessions = requested
+	}
+
+	switch sessions {
+	case SessionsCurrent, SessionsAll, SessionsOthers:
+	default:
+		h.d.SelfServiceErrorManager().Forward(r.Context(), w, r, errors.WithStack(herodot.ErrBadRequest.WithReasonf(`The sessions parameter must be one of "%s", "%s", or "%s" but got "%s".`, SessionsCurrent, SessionsAll, SessionsOthers, sessions)))
+		return
+	}
+
+	if sess != nil && sessions != SessionsCurrent {
+		if err := h.d.SessionManager().RevokeOtherSessions(r.Context(), sess.IdentityID, sess.ID); err != nil {
+			h.d.SelfServiceErrorManager().Forward(r.Context(), w, r, err)
+			return
+		}
+	}
+
+	if sessions != SessionsOthers {
+		if err := h.d.SessionManager().PurgeFromRequest(r.Context(), w, r); err != nil {
+			h.d.SelfServiceErrorManager().Forward(r.Context(), w, r, err)
+			return
+		}
+
+		if sess != nil {
+			for _, hook := range h.d.PostLogoutHooks(r.Context()) {
+				if err := hook.ExecuteLogoutPostHook(w, r, sess); err != nil {
+					h.d.SelfServiceErrorManager().Forward(r.Context(), w, r, err)
+					return
+				}
+			}
+		}
+	}
+
 	ret, err := x.SecureRedirectTo(r, h.d.Config(r.Context()).SelfServiceFlowLogoutRedirectURL(),
 		x.SecureRedirectUseSourceURL(r.RequestURI),
 		x.SecureRedirectAllowURLs(h.d.Config(r.Context()).SelfServiceBrowserWhitelistedReturnToDomains()),
 		x.SecureRedirectAllowSelfServiceURLs(h.d.Config(r.Context()).SelfPublicURL(r)),
+		x.SecureRedirectRejectPrivateHosts(h.d.Config(r.Context()).Egress()),
 	)
 	if err != nil {
 		fmt.Printf("\n%s\n\n", err.Error())
@@ -77,5 +136,34 @@ func (h *Handler) logout(w http.ResponseWriter, r *http.Request, ps httprouter.P
 		return
 	}
 
-	http.Redirect(w, r, ret.String(), http.StatusFound)
+	if sessions == SessionsOthers {
+		// The user is still signed in, which is why neither the upstream identity provider nor relying
+		// applications are signed out.
+		http.Redirect(w, r, ret.String(), http.StatusFound)
+		return
+	}
+
+	next := ret
+	for _, s := range h.d.LogoutStrategies(r.Context()) {
+		upstream, err := s.UpstreamLogoutURL(w, r, ret)
+		if err != nil {
+			h.d.SelfServiceErrorManager().Forward(r.Context(), w, r, err)
+			return
+		} else if upstream != nil {
+			next = upstream
+			break
+		}
+	}
+
+	if sess != nil {
+		h.d.LogoutPropagator().BackChannel(r.Context(), sess)
+		if len(h.d.Config(r.Context()).SelfServiceFlowLogoutFrontChannelURLs()) > 0 {
+			if err := h.d.LogoutPropagator().FrontChannel(w, r, sess, next); err != nil {
+				h.d.SelfServiceErrorManager().Forward(r.Context(), w, r, err)
+			}
+			return
+		}
+	}
+
+	http.Redirect(w, r, next.String(), http.StatusFound)
 }
@@ -6,6 +6,7 @@ import (
 	"net/http"
 	"net/url"
 	"testing"
+	"time"
 
 	"github.com/gobuffalo/httptest"
 	"github.com/julienschmidt/httprouter"
@@ -108,4 +109,48 @@ func TestLogoutHandler(t *testing.T) {
 		require.NoError(t, err)
 		assert.Equal(t, returnToURL, res.Request.URL.String())
 	})
+	t.Run("case=revokes the requested sessions", func(t *testing.T) {
+		conf.MustSet(config.ViperKeyURLsWhitelistedReturnToDomains, []string{})
+
+		var current, other *session.Session
+		router.GET("/set-with-other", func(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+			i := identity.NewIdentity(config.DefaultIdentityTraitsSchemaID)
+			require.NoError(t, reg.PrivilegedIdentityPool().CreateIdentity(r.Context(), i))
+
+			other = session.NewActiveSession(x.SystemClock, x.RandomIDGenerator, i, conf, time.Now().UTC())
+			require.NoError(t, reg.SessionPersister().CreateSession(r.Context(), other))
+
+			current = session.NewActiveSession(x.SystemClock, x.RandomIDGenerator, i, conf, time.Now().UTC())
+			require.NoError(t, reg.SessionManager().CreateAndIssueCookie(r.Context(), w, r, current))
+			w.WriteHeader(http.StatusOK)
+		})
+
+		isActive := func(t *testing.T, s *session.Session) bool {
+			actual, err := reg.SessionPersister().GetSession(context.Background(), s.ID)
+			require.NoError(t, err)
+			return actual.Active
+		}
+
+		for _, tc := range []struct {
+			sessions      string
+			expectCurrent bool
+			expectOther   bool
+		}{
+			{sessions: logout.SessionsCurrent, expectCurrent: false, expectOther: true},
+			{sessions: logout.SessionsAll, expectCurrent: false, expectOther: false},
+			{sessions: logout.SessionsOthers, expectCurrent: true, expectOther: false},
+		} {
+			t.Run("sessions="+tc.sessions, func(t *testing.T) {
+				client := testhelpers.NewClientWithCookies(t)
+				testhelpers.MockHydrateCookieClient(t, client, ts.URL+"/set-with-other")
+
+				res, err := client.Get(ts.URL + logout.RouteBrowser + "?sessions=" + tc.sessions)
+				require.NoError(t, err)
+				assert.Equal(t, redirTS.URL, res.Request.URL.String())
+
+				assert.Equal(t, tc.expectCurrent, isActive(t, current))
+				assert.Equal(t, tc.expectOther, isActive(t, other))
+			})
+		}
+	})
 }
@@ -0,0 +1,18 @@
+package logout
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/ory/kratos/session"
+)
+
+type (
+	PostHookExecutor interface {
+		ExecuteLogoutPostHook(w http.ResponseWriter, r *http.Request, s *session.Session) error
+	}
+
+	HooksProvider interface {
+		PostLogoutHooks(ctx context.Context) []PostHookExecutor
+	}
+)
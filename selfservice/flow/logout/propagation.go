@@ -0,0 +1,157 @@
+package logout
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"html/template"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/gofrs/uuid"
+	"github.com/hashicorp/go-retryablehttp"
+	"github.com/pkg/errors"
+
+	"github.com/ory/x/urlx"
+
+	"github.com/ory/kratos/driver/config"
+	"github.com/ory/kratos/session"
+	"github.com/ory/kratos/x"
+)
+
+// frontChannelTemplate loads every front-channel logout URL in a hidden iframe and continues to the next
+// URL once all iframes have loaded or after a few seconds, whichever happens first.
+var frontChannelTemplate = template.Must(template.New("front_channel").Parse(`<!DOCTYPE html>
+<html>
+<head>
+  <meta charset="utf-8">
+  <meta http-equiv="refresh" content="5;url={{.Next}}">
+  <title>Signing out...</title>
+</head>
+<body>
+{{range .URLs}}  <iframe src="{{.}}" style="display:none" onload="loaded()"></iframe>
+{{end}}  <script>
+    var pending = {{len .URLs}};
+    function loaded() {
+      if (--pending <= 0) {
+        window.location.replace({{.Next}});
+      }
+    }
+  </script>
+</body>
+</html>
+`))
+
+type (
+	// BackChannelPayload is sent to every back-channel logout URL whenever a user signs out.
+	BackChannelPayload struct {
+		// SessionID is the ID of the session which ended.
+		SessionID uuid.UUID `json:"session_id"`
+
+		// IdentityID is the ID of the identity the session belonged to.
+		IdentityID uuid.UUID `json:"identity_id"`
+
+		// Timestamp is the time the session ended at.
+		Timestamp time.Time `json:"timestamp"`
+	}
+
+	propagatorDependencies interface {
+		config.Provider
+		x.LoggingProvider
+		x.ClockProvider
+	}
+	PropagatorProvider interface {
+		LogoutPropagator() *Propagator
+	}
+	// Propagator notifies relying applications that a user signed out so that they can end their own
+	// sessions as well.
+	Propagator struct {
+		d  propagatorDependencies
+		hc *retryablehttp.Client
+	}
+)
+
+func NewPropagator(d propagatorDependencies) *Propagator {
+	return &Propagator{d: d, hc: x.NewEgressHTTPClient(d, config.EgressDependencyWebHooks)}
+}
+
+// BackChannel notifies all back-channel logout URLs that the session ended. Requests are sent in the
+// background and failures are logged but never returned to the caller.
+func (p *Propagator) BackChannel(ctx context.Context, s *session.Session) {
+	endpoints := p.d.Config(ctx).SelfServiceFlowLogoutBackChannelURLs()
+	if len(endpoints) == 0 {
+		return
+	}
+
+	l := p.d.Logger().
+		WithField("session_id", s.ID).
+		WithField("identity_id", s.IdentityID)
+
+	body, err := json.Marshal(&BackChannelPayload{
+		SessionID:  s.ID,
+		IdentityID: s.IdentityID,
+		Timestamp:  p.d.Clock().Now().UTC(),
+	})
+	if err != nil {
+		l.WithError(err).Error("Unable to encode the back-channel logout payload.")
+		return
+	}
+
+	for k := range endpoints {
+		endpoint := endpoints[k].String()
+		req, err := retryablehttp.NewRequest("POST", endpoint, bytes.NewReader(body))
+		if err != nil {
+			l.WithError(err).WithField("endpoint", endpoint).Error("Unable to create the back-channel logout request.")
+			continue
+		}
+		req.Header.Set("Content-Type", "application/json")
+
+		go func() {
+			res, err := p.hc.Do(req)
+			if err != nil {
+				l.WithError(err).WithField("endpoint", endpoint).Error("Unable to deliver the back-channel logout notification.")
+				return
+			}
+			defer res.Body.Close()
+
+			if res.StatusCode < 200 || res.StatusCode > 299 {
+				l.WithField("endpoint", endpoint).WithField("status_code", res.StatusCode).Error("The back-channel logout endpoint responded with an unexpected status code.")
+			}
+		}()
+	}
+}
+
+// FrontChannelURLs returns the front-channel logout URLs including the issuer and the ID of the session
+// which ended.
+func (p *Propagator) FrontChannelURLs(r *http.Request, s *session.Session) (us []string) {
+	iss := p.d.Config(r.Context()).SelfPublicURL(r).String()
+	for _, u := range p.d.Config(r.Context()).SelfServiceFlowLogoutFrontChannelURLs() {
+		u := u
+		us = append(us, urlx.CopyWithQuery(&u, url.Values{
+			"iss": {iss},
+			"sid": {s.ID.String()},
+		}).String())
+	}
+	return us
+}
+
+// FrontChannel renders a page which loads all front-channel logout URLs and then redirects the browser to next.
+func (p *Propagator) FrontChannel(w http.ResponseWriter, r *http.Request, s *session.Session, next *url.URL) error {
+	var b bytes.Buffer
+	if err := frontChannelTemplate.Execute(&b, &struct {
+		URLs []string
+		Next string
+	}{
+		URLs: p.FrontChannelURLs(r, s),
+		Next: next.String(),
+	}); err != nil {
+		return errors.WithStack(err)
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write(b.Bytes())
+	return nil
+}
@@ -0,0 +1,79 @@
+package logout_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ory/x/urlx"
+
+	"github.com/ory/kratos/driver/config"
+	"github.com/ory/kratos/internal"
+	"github.com/ory/kratos/selfservice/flow/logout"
+	"github.com/ory/kratos/session"
+	"github.com/ory/kratos/x"
+)
+
+func TestPropagator(t *testing.T) {
+	conf, reg := internal.NewFastRegistryWithMocks(t)
+	conf.MustSet(config.ViperKeyPublicBaseURL, "https://www.ory.sh/")
+
+	sess := &session.Session{ID: x.NewUUID(), IdentityID: x.NewUUID()}
+
+	t.Run("method=BackChannel", func(t *testing.T) {
+		received := make(chan logout.BackChannelPayload, 2)
+		ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			var p logout.BackChannelPayload
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&p))
+			received <- p
+			w.WriteHeader(http.StatusNoContent)
+		}))
+		t.Cleanup(ts.Close)
+
+		conf.MustSet(config.ViperKeySelfServiceLogoutBackChannelURLs, []string{ts.URL + "/a", ts.URL + "/b"})
+		t.Cleanup(func() {
+			conf.MustSet(config.ViperKeySelfServiceLogoutBackChannelURLs, []string{})
+		})
+
+		reg.LogoutPropagator().BackChannel(context.Background(), sess)
+		for i := 0; i < 2; i++ {
+			select {
+			case p := <-received:
+				assert.Equal(t, sess.ID, p.SessionID)
+				assert.Equal(t, sess.IdentityID, p.IdentityID)
+			case <-time.After(5 * time.Second):
+				t.Fatal("back-channel logout notification was not delivered")
+			}
+		}
+	})
+
+	t.Run("method=FrontChannel", func(t *testing.T) {
+		conf.MustSet(config.ViperKeySelfServiceLogoutFrontChannelURLs, []string{"https://app.example.org/logout?foo=bar"})
+		t.Cleanup(func() {
+			conf.MustSet(config.ViperKeySelfServiceLogoutFrontChannelURLs, []string{})
+		})
+
+		r := httptest.NewRequest("GET", "/", nil)
+		us := reg.LogoutPropagator().FrontChannelURLs(r, sess)
+		require.Len(t, us, 1)
+
+		u, err := url.Parse(us[0])
+		require.NoError(t, err)
+		assert.Equal(t, "bar", u.Query().Get("foo"))
+		assert.Equal(t, sess.ID.String(), u.Query().Get("sid"))
+		assert.Equal(t, "https://www.ory.sh/", u.Query().Get("iss"))
+
+		w := httptest.NewRecorder()
+		require.NoError(t, reg.LogoutPropagator().FrontChannel(w, r, sess, urlx.ParseOrPanic("https://www.ory.sh/return")))
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Contains(t, w.Body.String(), "https://app.example.org/logout")
+		assert.Contains(t, w.Body.String(), "https://www.ory.sh/return")
+	})
+}
@@ -0,0 +1,26 @@
+package logout
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+
+	"github.com/ory/kratos/identity"
+)
+
+// Strategy is implemented by self-service strategies which sign the user out of an upstream identity
+// provider when signing out of ORY Kratos.
+type Strategy interface {
+	ID() identity.CredentialsType
+
+	// UpstreamLogoutURL returns the URL the browser is redirected to for signing out of the upstream
+	// identity provider, or nil if the user does not need to be signed out upstream. The upstream
+	// identity provider is expected to redirect the browser to returnTo afterwards.
+	UpstreamLogoutURL(w http.ResponseWriter, r *http.Request, returnTo *url.URL) (*url.URL, error)
+}
+
+type Strategies []Strategy
+
+type StrategyProvider interface {
+	LogoutStrategies(ctx context.Context) Strategies
+}
@@ -43,6 +43,8 @@ type (
 	errorHandlerDependencies interface {
 		errorx.ManagementProvider
 		x.WriterProvider
+		x.ClockProvider
+		x.IDGeneratorProvider
 		x.LoggingProvider
 		x.CSRFTokenGeneratorProvider
 		config.Provider
@@ -84,7 +86,7 @@ func (s *ErrorHandler) WriteFlowError(
 
 	if e := new(FlowExpiredError); errors.As(err, &e) {
 		// create new flow because the old one is not valid
-		a, err := NewFlow(s.d.Config(r.Context()).SelfServiceFlowRecoveryRequestLifespan(), s.d.GenerateCSRFToken(r), r, s.d.RecoveryStrategies(r.Context()), f.Type)
+		a, err := NewFlow(s.d.Clock(), s.d.IDGenerator(), s.d.Config(r.Context()).SelfServiceFlowRecoveryRequestLifespan(), s.d.GenerateCSRFToken(r), r, s.d.RecoveryStrategies(r.Context()), f.Type)
 		if err != nil {
 			// failed to create a new session and redirect to it, handle that error as a new one
 			s.WriteFlowError(w, r, methodName, f, err)
@@ -101,7 +103,12 @@ func (s *ErrorHandler) WriteFlowError(
 			http.Redirect(w, r, urlx.CopyWithQuery(urlx.AppendPaths(s.d.Config(r.Context()).SelfPublicURL(r),
 				RouteGetFlow), url.Values{"id": {a.ID.String()}}).String(), http.StatusFound)
 		} else {
-			http.Redirect(w, r, a.AppendTo(s.d.Config(r.Context()).SelfServiceFlowRecoveryUI()).String(), http.StatusFound)
+			recoveryUI, err := s.d.Config(r.Context()).SelfServiceFlowRecoveryUIOrErr()
+			if err != nil {
+				s.forward(w, r, a, err)
+				return
+			}
+			http.Redirect(w, r, a.AppendTo(recoveryUI).String(), http.StatusFound)
 		}
 		return
 	}
@@ -125,7 +132,12 @@ func (s *ErrorHandler) WriteFlowError(
 	}
 
 	if f.Type == flow.TypeBrowser {
-		http.Redirect(w, r, f.AppendTo(s.d.Config(r.Context()).SelfServiceFlowRecoveryUI()).String(), http.StatusFound)
+		recoveryUI, err := s.d.Config(r.Context()).SelfServiceFlowRecoveryUIOrErr()
+		if err != nil {
+			s.forward(w, r, f, err)
+			return
+		}
+		http.Redirect(w, r, f.AppendTo(recoveryUI).String(), http.StatusFound)
 		return
 	}
 
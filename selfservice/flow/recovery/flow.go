@@ -99,9 +99,9 @@ type Flow struct {
 	RecoveredIdentityID uuid.NullUUID `json:"-" faker:"-" db:"recovered_identity_id"`
 }
 
-func NewFlow(exp time.Duration, csrf string, r *http.Request, strategies Strategies, ft flow.Type) (*Flow, error) {
-	now := time.Now().UTC()
-	req := &Flow{ID: x.NewUUID(),
+func NewFlow(c x.Clock, ids x.IDGenerator, exp time.Duration, csrf string, r *http.Request, strategies Strategies, ft flow.Type) (*Flow, error) {
+	now := c.Now().UTC()
+	req := &Flow{ID: ids.NewUUID(),
 		ExpiresAt: now.Add(exp), IssuedAt: now,
 		RequestURL: x.RequestURL(r).String(),
 		Methods:    map[string]*FlowMethod{},
@@ -127,8 +127,8 @@ func (f *Flow) GetID() uuid.UUID {
 	return f.ID
 }
 
-func (f *Flow) Valid() error {
-	if f.ExpiresAt.Before(time.Now().UTC()) {
+func (f *Flow) Valid(c x.Clock) error {
+	if f.ExpiresAt.Before(c.Now().UTC()) {
 		return errors.WithStack(NewFlowExpiredError(f.ExpiresAt))
 	}
 	return nil
@@ -13,6 +13,7 @@ import (
 
 	"github.com/ory/kratos/selfservice/flow"
 	"github.com/ory/kratos/selfservice/flow/recovery"
+	"github.com/ory/kratos/x"
 )
 
 func TestFlow(t *testing.T) {
@@ -26,11 +27,11 @@ func TestFlow(t *testing.T) {
 		r         *recovery.Flow
 		expectErr bool
 	}{
-		{r: must(recovery.NewFlow(time.Hour, "", u, nil, flow.TypeBrowser))},
-		{r: must(recovery.NewFlow(-time.Hour, "", u, nil, flow.TypeBrowser)), expectErr: true},
+		{r: must(recovery.NewFlow(x.SystemClock, x.RandomIDGenerator, time.Hour, "", u, nil, flow.TypeBrowser))},
+		{r: must(recovery.NewFlow(x.SystemClock, x.RandomIDGenerator, -time.Hour, "", u, nil, flow.TypeBrowser)), expectErr: true},
 	} {
 		t.Run(fmt.Sprintf("case=%d", k), func(t *testing.T) {
-			err := tc.r.Valid()
+			err := tc.r.Valid(x.SystemClock)
 			if tc.expectErr {
 				require.Error(t, err)
 				return
@@ -41,5 +42,5 @@ func TestFlow(t *testing.T) {
 	}
 
 	assert.EqualValues(t, recovery.StateChooseMethod,
-		must(recovery.NewFlow(time.Hour, "", u, nil, flow.TypeBrowser)).State)
+		must(recovery.NewFlow(x.SystemClock, x.RandomIDGenerator, time.Hour, "", u, nil, flow.TypeBrowser)).State)
 }
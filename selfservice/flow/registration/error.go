@@ -22,6 +22,7 @@ import (
 var (
 	ErrHookAbortFlow   = errors.New("aborted registration hook execution")
 	ErrAlreadyLoggedIn = herodot.ErrBadRequest.WithReason("A valid session was detected and thus registration is not possible.")
+	ErrDisabled        = herodot.ErrNotFound.WithReason("Registration is not allowed because it was disabled.")
 )
 
 type (
@@ -99,7 +100,12 @@ func (s *ErrorHandler) WriteFlowError(
 			http.Redirect(w, r, urlx.CopyWithQuery(urlx.AppendPaths(s.d.Config(r.Context()).SelfPublicURL(r),
 				RouteGetFlow), url.Values{"id": {a.ID.String()}}).String(), http.StatusFound)
 		} else {
-			http.Redirect(w, r, a.AppendTo(s.d.Config(r.Context()).SelfServiceFlowRegistrationUI()).String(), http.StatusFound)
+			registrationUI, err := s.d.Config(r.Context()).SelfServiceFlowRegistrationUIOrErr()
+			if err != nil {
+				s.forward(w, r, a, err)
+				return
+			}
+			http.Redirect(w, r, a.AppendTo(registrationUI).String(), http.StatusFound)
 		}
 		return
 	}
@@ -122,7 +128,12 @@ func (s *ErrorHandler) WriteFlowError(
 	}
 
 	if f.Type == flow.TypeBrowser {
-		http.Redirect(w, r, f.AppendTo(s.d.Config(r.Context()).SelfServiceFlowRegistrationUI()).String(), http.StatusFound)
+		registrationUI, err := s.d.Config(r.Context()).SelfServiceFlowRegistrationUIOrErr()
+		if err != nil {
+			s.forward(w, r, f, err)
+			return
+		}
+		http.Redirect(w, r, f.AppendTo(registrationUI).String(), http.StatusFound)
 		return
 	}
 
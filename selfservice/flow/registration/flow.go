@@ -77,10 +77,10 @@ type Flow struct {
 	CSRFToken string `json:"-" db:"csrf_token"`
 }
 
-func NewFlow(exp time.Duration, csrf string, r *http.Request, ft flow.Type) *Flow {
-	now := time.Now().UTC()
+func NewFlow(c x.Clock, ids x.IDGenerator, exp time.Duration, csrf string, r *http.Request, ft flow.Type) *Flow {
+	now := c.Now().UTC()
 	return &Flow{
-		ID:         x.NewUUID(),
+		ID:         ids.NewUUID(),
 		ExpiresAt:  now.Add(exp),
 		IssuedAt:   now,
 		RequestURL: x.RequestURL(r).String(),
@@ -125,8 +125,8 @@ func (f *Flow) GetID() uuid.UUID {
 	return f.ID
 }
 
-func (f *Flow) Valid() error {
-	if f.ExpiresAt.Before(time.Now()) {
+func (f *Flow) Valid(c x.Clock) error {
+	if f.ExpiresAt.Before(c.Now()) {
 		return errors.WithStack(NewFlowExpiredError(f.ExpiresAt))
 	}
 	return nil
@@ -2,7 +2,6 @@ package registration
 
 import (
 	"net/http"
-	"time"
 
 	"github.com/julienschmidt/httprouter"
 	"github.com/pkg/errors"
@@ -30,6 +29,8 @@ type (
 		session.HandlerProvider
 		session.ManagementProvider
 		x.WriterProvider
+		x.ClockProvider
+		x.IDGeneratorProvider
 		x.CSRFTokenGeneratorProvider
 		x.CSRFProvider
 		StrategyProvider
@@ -63,7 +64,11 @@ func (h *Handler) RegisterAdminRoutes(admin *x.RouterAdmin) {
 }
 
 func (h *Handler) NewRegistrationFlow(w http.ResponseWriter, r *http.Request, ft flow.Type) (*Flow, error) {
-	a := NewFlow(h.d.Config(r.Context()).SelfServiceFlowRegistrationRequestLifespan(), h.d.GenerateCSRFToken(r), r, ft)
+	if !h.d.Config(r.Context()).SelfServiceFlowRegistrationEnabled() {
+		return nil, errors.WithStack(ErrDisabled)
+	}
+
+	a := NewFlow(h.d.Clock(), h.d.IDGenerator(), h.d.Config(r.Context()).SelfServiceFlowRegistrationRequestLifespan(), h.d.GenerateCSRFToken(r), r, ft)
 	for _, s := range h.d.RegistrationStrategies(r.Context()) {
 		if err := s.PopulateRegistrationMethod(r, a); err != nil {
 			return nil, err
@@ -149,11 +154,21 @@ func (h *Handler) initBrowserFlow(w http.ResponseWriter, r *http.Request, ps htt
 		return
 	}
 
-	redirTo := a.AppendTo(h.d.Config(r.Context()).SelfServiceFlowRegistrationUI()).String()
+	redirTo, err := h.d.Config(r.Context()).SelfServiceFlowRegistrationUIOrErr()
+	if err != nil {
+		h.d.SelfServiceErrorManager().Forward(r.Context(), w, r, err)
+		return
+	}
+	redirTo = a.AppendTo(redirTo)
+
 	if _, err := h.d.SessionManager().FetchFromRequest(r.Context(), r); err == nil {
-		redirTo = h.d.Config(r.Context()).SelfServiceBrowserDefaultReturnTo().String()
+		redirTo, err = h.d.Config(r.Context()).SelfServiceBrowserDefaultReturnToOrErr()
+		if err != nil {
+			h.d.SelfServiceErrorManager().Forward(r.Context(), w, r, err)
+			return
+		}
 	}
-	http.Redirect(w, r, redirTo, http.StatusFound)
+	http.Redirect(w, r, redirTo.String(), http.StatusFound)
 }
 
 // nolint:deadcode,unused
@@ -189,13 +204,18 @@ type getSelfServiceRegistrationFlowParameters struct {
 //       410: genericError
 //       500: genericError
 func (h *Handler) fetchFlow(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	if !h.d.Config(r.Context()).SelfServiceFlowRegistrationEnabled() {
+		h.d.Writer().WriteError(w, r, errors.WithStack(ErrDisabled))
+		return
+	}
+
 	ar, err := h.d.RegistrationFlowPersister().GetRegistrationFlow(r.Context(), x.ParseUUID(r.URL.Query().Get("id")))
 	if err != nil {
 		h.d.Writer().WriteError(w, r, err)
 		return
 	}
 
-	if ar.ExpiresAt.Before(time.Now()) {
+	if ar.ExpiresAt.Before(h.d.Clock().Now()) {
 		if ar.Type == flow.TypeBrowser {
 			h.d.Writer().WriteError(w, r, errors.WithStack(x.ErrGone.
 				WithReason("The registration flow has expired. Redirect the user to the registration flow init endpoint to initialize a new registration flow.").
@@ -208,5 +228,10 @@ func (h *Handler) fetchFlow(w http.ResponseWriter, r *http.Request, ps httproute
 		return
 	}
 
-	h.d.Writer().Write(w, r, ar)
+	localized, err := flow.Localize(w, r, h.d.Config(r.Context()), ar)
+	if err != nil {
+		h.d.Writer().WriteError(w, r, err)
+		return
+	}
+	h.d.Writer().Write(w, r, localized)
 }
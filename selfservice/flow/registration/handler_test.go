@@ -114,6 +114,17 @@ func TestInitFlow(t *testing.T) {
 			assert.Equal(t, http.StatusBadRequest, res.StatusCode)
 			assertx.EqualAsJSON(t, registration.ErrAlreadyLoggedIn, json.RawMessage(gjson.GetBytes(body, "error").Raw), "%s", body)
 		})
+
+		t.Run("case=fails if registration is disabled", func(t *testing.T) {
+			conf.MustSet(config.ViperKeySelfServiceRegistrationEnabled, false)
+			t.Cleanup(func() {
+				conf.MustSet(config.ViperKeySelfServiceRegistrationEnabled, true)
+			})
+
+			res, body := initFlow(t, true)
+			assert.Equal(t, http.StatusNotFound, res.StatusCode)
+			assertx.EqualAsJSON(t, registration.ErrDisabled, json.RawMessage(gjson.GetBytes(body, "error").Raw), "%s", body)
+		})
 	})
 
 	t.Run("flow=browser", func(t *testing.T) {
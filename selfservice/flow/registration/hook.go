@@ -4,8 +4,8 @@ import (
 	"context"
 	"fmt"
 	"net/http"
-	"time"
 
+	"github.com/gobuffalo/pop/v5"
 	"github.com/pkg/errors"
 
 	"github.com/ory/x/sqlcon"
@@ -63,11 +63,15 @@ type (
 	executorDependencies interface {
 		config.Provider
 		identity.ManagementProvider
+		identity.PrivilegedPoolProvider
 		identity.ValidationProvider
 		session.PersistenceProvider
 		HooksProvider
+		TransactionPersistenceProvider
 		x.LoggingProvider
 		x.WriterProvider
+		x.ClockProvider
+		x.IDGeneratorProvider
 	}
 	HookExecutor struct {
 		d executorDependencies
@@ -112,8 +116,73 @@ func (e *HookExecutor) PostRegistrationHook(w http.ResponseWriter, r *http.Reque
 			Debug("ExecutePostRegistrationPrePersistHook completed successfully.")
 	}
 
+	s, aborted, err := e.persist(w, r, ct, a, i)
+	if err != nil {
+		return err
+	} else if aborted {
+		return nil
+	}
+
+	e.d.Logger().
+		WithRequest(r).
+		WithField("flow_method", ct).
+		WithField("identity_id", i.ID).
+		Debug("Post registration execution hooks completed successfully.")
+
+	if a.Type == flow.TypeAPI {
+		e.d.Writer().Write(w, r, &APIFlowResponse{Identity: i})
+		return nil
+	}
+
+	return x.SecureContentNegotiationRedirection(w, r, s.Declassify(), a.RequestURL,
+		e.d.Writer(), e.d.Config(r.Context()), x.SecureRedirectOverrideDefaultReturnTo(e.d.Config(r.Context()).SelfServiceFlowRegistrationReturnTo(ct.String())))
+}
+
+// persist creates the identity and runs the post persist hooks. If a hook fails, the identity is handled according
+// to selfservice.flows.registration.after.on_hook_failure: it is kept, the transaction the identity was created in
+// is rolled back, or the identity is deleted again.
+func (e *HookExecutor) persist(w http.ResponseWriter, r *http.Request, ct identity.CredentialsType, a *Flow, i *identity.Identity) (s *session.Session, aborted bool, err error) {
+	switch e.d.Config(r.Context()).SelfServiceFlowRegistrationOnHookFailure() {
+	case config.RegistrationOnHookFailureRollback:
+		if err := e.d.RegistrationTransactionPersister().Transaction(r.Context(), func(ctx context.Context, _ *pop.Connection) error {
+			r := r.WithContext(ctx)
+			if err := e.createIdentity(r, ct, i); err != nil {
+				return err
+			}
+			s, aborted, err = e.runPostPersistHooks(w, r, ct, a, i)
+			return err
+		}); err != nil {
+			return nil, false, err
+		}
+		return s, aborted, nil
+	case config.RegistrationOnHookFailureDeleteIdentity:
+		if err := e.createIdentity(r, ct, i); err != nil {
+			return nil, false, err
+		}
+
+		s, aborted, err = e.runPostPersistHooks(w, r, ct, a, i)
+		if err != nil {
+			if err := e.d.PrivilegedIdentityPool().DeleteIdentity(r.Context(), i.ID); err != nil {
+				e.d.Logger().
+					WithRequest(r).
+					WithError(err).
+					WithField("identity_id", i.ID).
+					Error("Unable to delete the identity after a post registration hook failed.")
+			}
+			return nil, false, err
+		}
+		return s, aborted, nil
+	}
+
+	if err := e.createIdentity(r, ct, i); err != nil {
+		return nil, false, err
+	}
+	return e.runPostPersistHooks(w, r, ct, a, i)
+}
+
+func (e *HookExecutor) createIdentity(r *http.Request, ct identity.CredentialsType, i *identity.Identity) error {
 	// We need to make sure that the identity has a valid schema before passing it down to the identity pool.
-	if err := e.d.IdentityValidator().Validate(r.Context(), i); err != nil {
+	if err := e.d.IdentityValidator().Validate(r.Context(), i, identity.ValidatorEnforceUsernamePolicy); err != nil {
 		return err
 		// We're now creating the identity because any of the hooks could trigger a "redirect" or a "session" which
 		// would imply that the identity has to exist already.
@@ -126,9 +195,15 @@ func (e *HookExecutor) PostRegistrationHook(w http.ResponseWriter, r *http.Reque
 	e.d.Audit().
 		WithRequest(r).
 		WithField("identity_id", i.ID).
+		WithField("flow_method", ct).
 		Info("A new identity has registered using self-service registration.")
+	return nil
+}
 
-	s := session.NewActiveSession(i, e.d.Config(r.Context()), time.Now().UTC())
+// runPostPersistHooks returns true if a hook aborted the flow because it has already written the response.
+func (e *HookExecutor) runPostPersistHooks(w http.ResponseWriter, r *http.Request, ct identity.CredentialsType, a *Flow, i *identity.Identity) (*session.Session, bool, error) {
+	s := session.NewActiveSession(e.d.Clock(), e.d.IDGenerator(), i, e.d.Config(r.Context()), e.d.Clock().Now().UTC())
+	s.Device = session.NewDevice(r, e.d.Config(r.Context()))
 	e.d.Logger().
 		WithRequest(r).
 		WithField("identity_id", i.ID).
@@ -145,9 +220,9 @@ func (e *HookExecutor) PostRegistrationHook(w http.ResponseWriter, r *http.Reque
 					WithField("identity_id", i.ID).
 					WithField("flow_method", ct).
 					Debug("A ExecutePostRegistrationPostPersistHook hook aborted early.")
-				return nil
+				return s, true, nil
 			}
-			return err
+			return nil, false, err
 		}
 
 		e.d.Logger().WithRequest(r).
@@ -158,20 +233,7 @@ func (e *HookExecutor) PostRegistrationHook(w http.ResponseWriter, r *http.Reque
 			WithField("flow_method", ct).
 			Debug("ExecutePostRegistrationPostPersistHook completed successfully.")
 	}
-
-	e.d.Logger().
-		WithRequest(r).
-		WithField("flow_method", ct).
-		WithField("identity_id", i.ID).
-		Debug("Post registration execution hooks completed successfully.")
-
-	if a.Type == flow.TypeAPI {
-		e.d.Writer().Write(w, r, &APIFlowResponse{Identity: i})
-		return nil
-	}
-
-	return x.SecureContentNegotiationRedirection(w, r, s.Declassify(), a.RequestURL,
-		e.d.Writer(), e.d.Config(r.Context()), x.SecureRedirectOverrideDefaultReturnTo(e.d.Config(r.Context()).SelfServiceFlowRegistrationReturnTo(ct.String())))
+	return s, false, nil
 }
 
 func (e *HookExecutor) PreRegistrationHook(w http.ResponseWriter, r *http.Request, a *Flow) error {
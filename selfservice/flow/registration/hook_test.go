@@ -36,7 +36,7 @@ func TestRegistrationExecutor(t *testing.T) {
 				router := httprouter.New()
 				handleErr := testhelpers.SelfServiceHookRegistrationErrorHandler
 				router.GET("/registration/pre", func(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
-					if handleErr(t, w, r, reg.RegistrationHookExecutor().PreRegistrationHook(w, r, registration.NewFlow(time.Minute, x.FakeCSRFToken, r, ft))) {
+					if handleErr(t, w, r, reg.RegistrationHookExecutor().PreRegistrationHook(w, r, registration.NewFlow(x.SystemClock, x.RandomIDGenerator, time.Minute, x.FakeCSRFToken, r, ft))) {
 						_, _ = w.Write([]byte("ok"))
 					}
 				})
@@ -45,7 +45,7 @@ func TestRegistrationExecutor(t *testing.T) {
 					if i == nil {
 						i = testhelpers.SelfServiceHookFakeIdentity(t)
 					}
-					a := registration.NewFlow(time.Minute, x.FakeCSRFToken, r, ft)
+					a := registration.NewFlow(x.SystemClock, x.RandomIDGenerator, time.Minute, x.FakeCSRFToken, r, ft)
 					a.RequestURL = x.RequestURL(r).String()
 					_ = handleErr(t, w, r, reg.RegistrationHookExecutor().PostRegistrationHook(w, r, identity.CredentialsType(strategy), a, i))
 				})
@@ -95,6 +95,32 @@ func TestRegistrationExecutor(t *testing.T) {
 					require.Error(t, err)
 				})
 
+				for _, tc := range []struct {
+					onHookFailure string
+					kept          bool
+				}{
+					{onHookFailure: config.RegistrationOnHookFailureRollback},
+					{onHookFailure: config.RegistrationOnHookFailureDeleteIdentity},
+					{onHookFailure: config.RegistrationOnHookFailureKeepIdentity, kept: true},
+				} {
+					t.Run("case=handle failing post persist hooks with "+tc.onHookFailure, func(t *testing.T) {
+						t.Cleanup(testhelpers.SelfServiceHookConfigReset(t, conf))
+						conf.MustSet(config.ViperKeySelfServiceRegistrationAfterOnHookFailure, tc.onHookFailure)
+						viperSetPost(t, conf, strategy, []config.SelfServiceHook{{Name: "err", Config: []byte(`{"ExecutePostRegistrationPostPersistHook": "err"}`)}})
+						i := testhelpers.SelfServiceHookFakeIdentity(t)
+
+						res, _ := makeRequestPost(t, newServer(t, i, flow.TypeBrowser), false, url.Values{})
+						assert.EqualValues(t, http.StatusInternalServerError, res.StatusCode)
+
+						_, err := reg.IdentityPool().GetIdentity(context.Background(), i.ID)
+						if tc.kept {
+							require.NoError(t, err)
+						} else {
+							require.Error(t, err)
+						}
+					})
+				}
+
 				t.Run("case=prevent return_to value because domain not whitelisted", func(t *testing.T) {
 					t.Cleanup(testhelpers.SelfServiceHookConfigReset(t, conf))
 					i := testhelpers.SelfServiceHookFakeIdentity(t)
@@ -6,6 +6,7 @@ import (
 	"testing"
 
 	"github.com/bxcodec/faker/v3"
+	"github.com/gobuffalo/pop/v5"
 	"github.com/gobuffalo/uuid"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -26,6 +27,16 @@ type FlowPersistenceProvider interface {
 	RegistrationFlowPersister() FlowPersister
 }
 
+// TransactionPersister runs the callback in a database transaction. Persisters called with the context passed to
+// the callback join the transaction.
+type TransactionPersister interface {
+	Transaction(ctx context.Context, callback func(ctx context.Context, connection *pop.Connection) error) error
+}
+
+type TransactionPersistenceProvider interface {
+	RegistrationTransactionPersister() TransactionPersister
+}
+
 func TestFlowPersister(ctx context.Context, p FlowPersister) func(t *testing.T) {
 	var clearids = func(r *Flow) {
 		r.ID = uuid.UUID{}
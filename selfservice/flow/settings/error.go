@@ -21,6 +21,7 @@ import (
 
 var (
 	ErrHookAbortRequest = errors.New("aborted settings hook execution")
+	ErrDisabled         = herodot.ErrNotFound.WithReason("Settings are not allowed because they were disabled.")
 )
 
 type (
@@ -125,7 +126,12 @@ func (s *ErrorHandler) WriteFlowError(
 			http.Redirect(w, r, urlx.CopyWithQuery(urlx.AppendPaths(s.d.Config(r.Context()).SelfPublicURL(r),
 				RouteGetFlow), url.Values{"id": {a.ID.String()}}).String(), http.StatusFound)
 		} else {
-			http.Redirect(w, r, a.AppendTo(s.d.Config(r.Context()).SelfServiceFlowSettingsUI()).String(), http.StatusFound)
+			settingsUI, err := s.d.Config(r.Context()).SelfServiceFlowSettingsUIOrErr()
+			if err != nil {
+				s.forward(w, r, a, err)
+				return
+			}
+			http.Redirect(w, r, a.AppendTo(settingsUI).String(), http.StatusFound)
 		}
 		return
 	}
@@ -152,7 +158,12 @@ func (s *ErrorHandler) WriteFlowError(
 	}
 
 	if f.Type == flow.TypeBrowser {
-		http.Redirect(w, r, f.AppendTo(s.d.Config(r.Context()).SelfServiceFlowSettingsUI()).String(), http.StatusFound)
+		settingsUI, err := s.d.Config(r.Context()).SelfServiceFlowSettingsUIOrErr()
+		if err != nil {
+			s.forward(w, r, f, err)
+			return
+		}
+		http.Redirect(w, r, f.AppendTo(settingsUI).String(), http.StatusFound)
 		return
 	}
 
@@ -69,7 +69,7 @@ func TestHandleError(t *testing.T) {
 
 	newFlow := func(t *testing.T, ttl time.Duration, ft flow.Type) *settings.Flow {
 		req := &http.Request{URL: urlx.ParseOrPanic("/")}
-		f := settings.NewFlow(ttl, req, &id, ft)
+		f := settings.NewFlow(x.SystemClock, x.RandomIDGenerator, ttl, req, &id, ft)
 		for _, s := range reg.SettingsStrategies(context.Background()) {
 			require.NoError(t, s.PopulateSettingsMethod(req, &id, f))
 		}
@@ -125,7 +125,7 @@ func TestHandleError(t *testing.T) {
 			t.Cleanup(reset)
 
 			// This needs an authenticated client in order to call the RouteGetFlow endpoint
-			c := testhelpers.NewHTTPClientWithSessionToken(t, reg, session.NewActiveSession(&id,
+			c := testhelpers.NewHTTPClientWithSessionToken(t, reg, session.NewActiveSession(x.SystemClock, x.RandomIDGenerator, &id,
 				testhelpers.NewSessionLifespanProvider(time.Hour), time.Now()))
 
 			settingsFlow = newFlow(t, time.Minute, flow.TypeAPI)
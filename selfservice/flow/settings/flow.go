@@ -121,10 +121,10 @@ type APIFlowResponse struct {
 	Identity *identity.Identity `json:"identity"`
 }
 
-func NewFlow(exp time.Duration, r *http.Request, i *identity.Identity, ft flow.Type) *Flow {
-	now := time.Now().UTC()
+func NewFlow(c x.Clock, ids x.IDGenerator, exp time.Duration, r *http.Request, i *identity.Identity, ft flow.Type) *Flow {
+	now := c.Now().UTC()
 	return &Flow{
-		ID:         x.NewUUID(),
+		ID:         ids.NewUUID(),
 		ExpiresAt:  now.Add(exp),
 		IssuedAt:   now,
 		RequestURL: x.RequestURL(r).String(),
@@ -148,8 +148,8 @@ func (r *Flow) AppendTo(settingsURL *url.URL) *url.URL {
 	return urlx.CopyWithQuery(settingsURL, url.Values{"flow": {r.ID.String()}})
 }
 
-func (r *Flow) Valid(s *session.Session) error {
-	if r.ExpiresAt.Before(time.Now().UTC()) {
+func (r *Flow) Valid(c x.Clock, s *session.Session) error {
+	if r.ExpiresAt.Before(c.Now().UTC()) {
 		return errors.WithStack(NewFlowExpiredError(r.ExpiresAt))
 	}
 
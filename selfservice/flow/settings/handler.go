@@ -2,7 +2,6 @@ package settings
 
 import (
 	"net/http"
-	"time"
 
 	"github.com/julienschmidt/httprouter"
 	"github.com/pkg/errors"
@@ -36,6 +35,8 @@ type (
 	handlerDependencies interface {
 		x.CSRFProvider
 		x.WriterProvider
+		x.ClockProvider
+		x.IDGeneratorProvider
 		x.LoggingProvider
 
 		config.Provider
@@ -74,7 +75,12 @@ func (h *Handler) RegisterPublicRoutes(public *x.RouterPublic) {
 	h.d.CSRFHandler().IgnorePath(RouteInitAPIFlow)
 
 	public.GET(RouteInitBrowserFlow, h.d.SessionHandler().IsAuthenticated(h.initBrowserFlow, func(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
-		http.Redirect(w, r, h.d.Config(r.Context()).SelfServiceFlowLoginUI().String(), http.StatusFound)
+		loginUI, err := h.d.Config(r.Context()).SelfServiceFlowLoginUIOrErr()
+		if err != nil {
+			h.d.SelfServiceErrorManager().Forward(r.Context(), w, r, err)
+			return
+		}
+		http.Redirect(w, r, loginUI.String(), http.StatusFound)
 	}))
 
 	public.GET(RouteInitAPIFlow, h.d.SessionHandler().IsAuthenticated(h.initApiFlow, nil))
@@ -87,7 +93,11 @@ func (h *Handler) RegisterAdminRoutes(admin *x.RouterAdmin) {
 }
 
 func (h *Handler) NewFlow(w http.ResponseWriter, r *http.Request, i *identity.Identity, ft flow.Type) (*Flow, error) {
-	f := NewFlow(h.d.Config(r.Context()).SelfServiceFlowSettingsFlowLifespan(), r, i, ft)
+	if !h.d.Config(r.Context()).SelfServiceFlowSettingsEnabled() {
+		return nil, errors.WithStack(ErrDisabled)
+	}
+
+	f := NewFlow(h.d.Clock(), h.d.IDGenerator(), h.d.Config(r.Context()).SelfServiceFlowSettingsFlowLifespan(), r, i, ft)
 	for _, strategy := range h.d.SettingsStrategies(r.Context()) {
 		if err := h.d.ContinuityManager().Abort(r.Context(), w, r, ContinuityKey(strategy.SettingsStrategyID())); err != nil {
 			return nil, err
@@ -188,7 +198,13 @@ func (h *Handler) initBrowserFlow(w http.ResponseWriter, r *http.Request, ps htt
 		return
 	}
 
-	http.Redirect(w, r, f.AppendTo(h.d.Config(r.Context()).SelfServiceFlowSettingsUI()).String(), http.StatusFound)
+	settingsUI, err := h.d.Config(r.Context()).SelfServiceFlowSettingsUIOrErr()
+	if err != nil {
+		h.d.SelfServiceErrorManager().Forward(r.Context(), w, r, err)
+		return
+	}
+
+	http.Redirect(w, r, f.AppendTo(settingsUI).String(), http.StatusFound)
 }
 
 // nolint:deadcode,unused
@@ -255,6 +271,10 @@ func (h *Handler) wrapErrorForbidden(err error, shouldWrap bool) error {
 }
 
 func (h *Handler) fetchFlow(w http.ResponseWriter, r *http.Request, checkSession bool) error {
+	if !h.d.Config(r.Context()).SelfServiceFlowSettingsEnabled() {
+		return errors.WithStack(ErrDisabled)
+	}
+
 	rid := x.ParseUUID(r.URL.Query().Get("id"))
 	pr, err := h.d.SettingsFlowPersister().GetSettingsFlow(r.Context(), rid)
 	if err != nil {
@@ -272,7 +292,7 @@ func (h *Handler) fetchFlow(w http.ResponseWriter, r *http.Request, checkSession
 		}
 	}
 
-	if pr.ExpiresAt.Before(time.Now().UTC()) {
+	if pr.ExpiresAt.Before(h.d.Clock().Now().UTC()) {
 		if pr.Type == flow.TypeBrowser {
 			h.d.Writer().WriteError(w, r, errors.WithStack(x.ErrGone.
 				WithReason("The settings flow has expired. Redirect the user to the settings flow init endpoint to initialize a new settings flow.").
@@ -285,6 +305,10 @@ func (h *Handler) fetchFlow(w http.ResponseWriter, r *http.Request, checkSession
 		return nil
 	}
 
-	h.d.Writer().Write(w, r, pr)
+	localized, err := flow.Localize(w, r, h.d.Config(r.Context()), pr)
+	if err != nil {
+		return err
+	}
+	h.d.Writer().Write(w, r, localized)
 	return nil
 }
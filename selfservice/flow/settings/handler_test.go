@@ -52,7 +52,7 @@ func TestHandler(t *testing.T) {
 	primaryUser, otherUser := clients["primary"], clients["secondary"]
 	publicClient, adminClient := testhelpers.NewSDKClient(publicTS), testhelpers.NewSDKClient(adminTS)
 	newExpiredFlow := func() *settings.Flow {
-		return settings.NewFlow(-time.Minute,
+		return settings.NewFlow(x.SystemClock, x.RandomIDGenerator, -time.Minute,
 			&http.Request{URL: urlx.ParseOrPanic(publicTS.URL + login.RouteInitBrowserFlow)},
 			primaryIdentity, flow.TypeBrowser)
 	}
@@ -4,7 +4,6 @@ import (
 	"context"
 	"fmt"
 	"net/http"
-	"time"
 
 	"github.com/ory/kratos/schema"
 	"github.com/ory/x/sqlcon"
@@ -39,6 +38,7 @@ type (
 		HooksProvider
 		FlowPersistenceProvider
 
+		x.ClockProvider
 		x.LoggingProvider
 		x.WriterProvider
 	}
@@ -123,9 +123,8 @@ func (e *HookExecutor) PostSettingsHook(w http.ResponseWriter, r *http.Request,
 		e.d.Logger().WithRequest(r).WithFields(logFields).Debug("ExecuteSettingsPrePersistHook completed successfully.")
 	}
 
-	options := []identity.ManagerOption{identity.ManagerExposeValidationErrorsForInternalTypeAssertion}
-	ttl := e.d.Config(r.Context()).SelfServiceFlowSettingsPrivilegedSessionMaxAge()
-	if ctxUpdate.Session.AuthenticatedAt.Add(ttl).After(time.Now()) {
+	options := []identity.ManagerOption{identity.ManagerExposeValidationErrorsForInternalTypeAssertion, identity.ManagerEnforceUsernamePolicy}
+	if ctxUpdate.Session.IsPrivileged(e.d.Clock().Now(), e.d.Config(r.Context()).SelfServiceFlowSettingsPrivilegedSessionMaxAge()) {
 		options = append(options, identity.ManagerAllowWriteProtectedTraits)
 	}
 
@@ -201,8 +200,13 @@ func (e *HookExecutor) PostSettingsHook(w http.ResponseWriter, r *http.Request,
 		return nil
 	}
 
+	settingsUI, err := e.d.Config(r.Context()).SelfServiceFlowSettingsUIOrErr()
+	if err != nil {
+		return err
+	}
+
 	return x.SecureContentNegotiationRedirection(w, r, ctxUpdate.Session.Declassify(), ctxUpdate.Flow.RequestURL, e.d.Writer(), e.d.Config(r.Context()),
 		x.SecureRedirectOverrideDefaultReturnTo(
 			e.d.Config(r.Context()).SelfServiceFlowSettingsReturnTo(settingsType,
-				ctxUpdate.Flow.AppendTo(e.d.Config(r.Context()).SelfServiceFlowSettingsUI()))))
+				ctxUpdate.Flow.AppendTo(settingsUI))))
 }
@@ -46,9 +46,9 @@ func TestSettingsExecutor(t *testing.T) {
 				handleErr := testhelpers.SelfServiceHookSettingsErrorHandler
 				router.GET("/settings/post", func(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
 					i := testhelpers.SelfServiceHookCreateFakeIdentity(t, reg)
-					sess := session.NewActiveSession(i, conf, time.Now().UTC())
+					sess := session.NewActiveSession(x.SystemClock, x.RandomIDGenerator, i, conf, time.Now().UTC())
 
-					a := settings.NewFlow(time.Minute, r, sess.Identity, ft)
+					a := settings.NewFlow(x.SystemClock, x.RandomIDGenerator, time.Minute, r, sess.Identity, ft)
 					a.RequestURL = x.RequestURL(r).String()
 					require.NoError(t, reg.SettingsFlowPersister().CreateSettingsFlow(r.Context(), a))
 					_ = handleErr(t, w, r, reg.SettingsHookExecutor().
@@ -45,6 +45,7 @@ func (c UpdateContext) GetSessionIdentity() *identity.Identity {
 
 func PrepareUpdate(d interface {
 	x.LoggingProvider
+	x.ClockProvider
 	continuity.ManagementProvider
 	session.ManagementProvider
 	FlowPersistenceProvider
@@ -67,7 +68,7 @@ func PrepareUpdate(d interface {
 		return new(UpdateContext), err
 	}
 
-	if err := req.Valid(ss); err != nil {
+	if err := req.Valid(d.Clock(), ss); err != nil {
 		return new(UpdateContext), err
 	}
 
@@ -115,7 +116,13 @@ func OnUnauthenticated(reg interface {
 	x.WriterProvider
 }) func(http.ResponseWriter, *http.Request, httprouter.Params) {
 	return func(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
-		handler := session.RedirectOnUnauthenticated(reg.Config(r.Context()).SelfServiceFlowLoginUI().String())
+		loginUI, err := reg.Config(r.Context()).SelfServiceFlowLoginUIOrErr()
+		if err != nil {
+			reg.Writer().WriteError(w, r, err)
+			return
+		}
+
+		handler := session.RedirectOnUnauthenticated(loginUI.String())
 		if x.IsJSONRequest(r) {
 			handler = session.RespondWithJSONErrorOnAuthenticated(reg.Writer(), herodot.ErrUnauthorized.WithReasonf("A valid ORY Session Cookie or ORY Session Token is missing."))
 		}
@@ -23,6 +23,8 @@ type (
 	errorHandlerDependencies interface {
 		errorx.ManagementProvider
 		x.WriterProvider
+		x.ClockProvider
+		x.IDGeneratorProvider
 		x.LoggingProvider
 		x.CSRFTokenGeneratorProvider
 		config.Provider
@@ -79,7 +81,7 @@ func (s *ErrorHandler) WriteFlowError(
 
 	if e := new(FlowExpiredError); errors.As(err, &e) {
 		// create new flow because the old one is not valid
-		a, err := NewFlow(s.d.Config(r.Context()).SelfServiceFlowVerificationRequestLifespan(),
+		a, err := NewFlow(s.d.Clock(), s.d.IDGenerator(), s.d.Config(r.Context()).SelfServiceFlowVerificationRequestLifespan(),
 			s.d.GenerateCSRFToken(r), r, s.d.VerificationStrategies(r.Context()), f.Type)
 		if err != nil {
 			// failed to create a new session and redirect to it, handle that error as a new one
@@ -97,7 +99,12 @@ func (s *ErrorHandler) WriteFlowError(
 			http.Redirect(w, r, urlx.CopyWithQuery(urlx.AppendPaths(s.d.Config(r.Context()).SelfPublicURL(r),
 				RouteGetFlow), url.Values{"id": {a.ID.String()}}).String(), http.StatusFound)
 		} else {
-			http.Redirect(w, r, a.AppendTo(s.d.Config(r.Context()).SelfServiceFlowVerificationUI()).String(), http.StatusFound)
+			verificationUI, err := s.d.Config(r.Context()).SelfServiceFlowVerificationUIOrErr()
+			if err != nil {
+				s.forward(w, r, a, err)
+				return
+			}
+			http.Redirect(w, r, a.AppendTo(verificationUI).String(), http.StatusFound)
 		}
 		return
 	}
@@ -121,7 +128,12 @@ func (s *ErrorHandler) WriteFlowError(
 	}
 
 	if f.Type == flow.TypeBrowser {
-		http.Redirect(w, r, f.AppendTo(s.d.Config(r.Context()).SelfServiceFlowVerificationUI()).String(), http.StatusFound)
+		verificationUI, err := s.d.Config(r.Context()).SelfServiceFlowVerificationUIOrErr()
+		if err != nil {
+			s.forward(w, r, f, err)
+			return
+		}
+		http.Redirect(w, r, f.AppendTo(verificationUI).String(), http.StatusFound)
 		return
 	}
 
@@ -92,10 +92,10 @@ func (f Flow) TableName(ctx context.Context) string {
 	return corp.ContextualizeTableName(ctx, "selfservice_verification_flows")
 }
 
-func NewFlow(exp time.Duration, csrf string, r *http.Request, strategies Strategies, ft flow.Type) (*Flow, error) {
-	now := time.Now().UTC()
+func NewFlow(c x.Clock, ids x.IDGenerator, exp time.Duration, csrf string, r *http.Request, strategies Strategies, ft flow.Type) (*Flow, error) {
+	now := c.Now().UTC()
 	f := &Flow{
-		ID:         x.NewUUID(),
+		ID:         ids.NewUUID(),
 		ExpiresAt:  now.Add(exp),
 		IssuedAt:   now,
 		RequestURL: x.RequestURL(r).String(),
@@ -114,8 +114,8 @@ func NewFlow(exp time.Duration, csrf string, r *http.Request, strategies Strateg
 	return f, nil
 }
 
-func (f *Flow) Valid() error {
-	if f.ExpiresAt.Before(time.Now()) {
+func (f *Flow) Valid(c x.Clock) error {
+	if f.ExpiresAt.Before(c.Now()) {
 		return errors.WithStack(NewFlowExpiredError(f.ExpiresAt))
 	}
 	return nil
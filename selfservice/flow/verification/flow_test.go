@@ -12,6 +12,7 @@ import (
 	"github.com/ory/x/urlx"
 
 	"github.com/ory/kratos/selfservice/flow"
+	"github.com/ory/kratos/x"
 )
 
 func TestFlow(t *testing.T) {
@@ -25,11 +26,11 @@ func TestFlow(t *testing.T) {
 		r         *Flow
 		expectErr bool
 	}{
-		{r: must(NewFlow(time.Hour, "", u, nil, flow.TypeBrowser))},
-		{r: must(NewFlow(-time.Hour, "", u, nil, flow.TypeBrowser)), expectErr: true},
+		{r: must(NewFlow(x.SystemClock, x.RandomIDGenerator, time.Hour, "", u, nil, flow.TypeBrowser))},
+		{r: must(NewFlow(x.SystemClock, x.RandomIDGenerator, -time.Hour, "", u, nil, flow.TypeBrowser)), expectErr: true},
 	} {
 		t.Run(fmt.Sprintf("case=%d", k), func(t *testing.T) {
-			err := tc.r.Valid()
+			err := tc.r.Valid(x.SystemClock)
 			if tc.expectErr {
 				require.Error(t, err)
 				return
@@ -40,5 +41,5 @@ func TestFlow(t *testing.T) {
 	}
 
 	assert.EqualValues(t, StateChooseMethod,
-		must(NewFlow(time.Hour, "", u, nil, flow.TypeBrowser)).State)
+		must(NewFlow(x.SystemClock, x.RandomIDGenerator, time.Hour, "", u, nil, flow.TypeBrowser)).State)
 }
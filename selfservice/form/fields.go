@@ -11,7 +11,23 @@ import (
 	"github.com/ory/kratos/text"
 )
 
-const DisableFormField = "disableFormField"
+const (
+	DisableFormField = "disableFormField"
+
+	// PlaceholderFormField is the identity schema annotation setting the field's placeholder.
+	PlaceholderFormField = "placeholder"
+
+	// AutocompleteFormField is the identity schema annotation setting the field's autocomplete attribute,
+	// for example "email" or "given-name".
+	AutocompleteFormField = "autocomplete"
+
+	// InputMaskFormField is the identity schema annotation setting the field's input mask, for example
+	// "+1 (999) 999-9999".
+	InputMaskFormField = "inputMask"
+
+	// PatternHintFormField is the identity schema annotation explaining the field's pattern to the user.
+	PatternHintFormField = "patternHint"
+)
 
 // Fields contains multiple fields
 //
@@ -51,6 +67,18 @@ type Field struct {
 	// Required is the equivalent of `<input required="{{.Required}}">`
 	Required bool `json:"required,omitempty"`
 
+	// Placeholder is the equivalent of `<input placeholder="{{.Placeholder}}">`
+	Placeholder string `json:"placeholder,omitempty"`
+
+	// Autocomplete is the equivalent of `<input autocomplete="{{.Autocomplete}}">`
+	Autocomplete string `json:"autocomplete,omitempty"`
+
+	// InputMask is a hint for UIs which format the value while the user is typing it, for example "+1 (999) 999-9999".
+	InputMask string `json:"input_mask,omitempty"`
+
+	// PatternHint explains the pattern to the user, for example "Only lowercase letters and digits".
+	PatternHint string `json:"pattern_hint,omitempty"`
+
 	// Value is the equivalent of `<input value="{{.Value}}">`
 	Value interface{} `json:"value,omitempty" faker:"string"`
 
@@ -148,9 +176,22 @@ func fieldFromPath(name string, p jsonschemax.Path) Field {
 		}
 	}
 
+	// Set UI hints if the custom properties are set
+	f.Placeholder = customStringProperty(p, PlaceholderFormField)
+	f.Autocomplete = customStringProperty(p, AutocompleteFormField)
+	f.InputMask = customStringProperty(p, InputMaskFormField)
+	f.PatternHint = customStringProperty(p, PatternHintFormField)
+
 	return f
 }
 
+func customStringProperty(p jsonschemax.Path, key string) string {
+	if v, ok := p.CustomProperties[key].(string); ok {
+		return v
+	}
+	return ""
+}
+
 func addPrefix(name, prefix, separator string) string {
 	if prefix == "" {
 		return name
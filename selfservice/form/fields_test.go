@@ -32,6 +32,30 @@ func TestFieldFromPath(t *testing.T) {
 			assert.True(t, !gjson.GetBytes(schema, fmt.Sprintf("properties.%s.test_expected_pattern", path.Name)).Exists() || (gjson.GetBytes(schema, fmt.Sprintf("properties.%s.test_expected_pattern", path.Name)).Bool() && htmlField.Pattern != ""))
 		}
 	})
+
+	t.Run("ui hints are transferred from custom properties", func(t *testing.T) {
+		f := fieldFromPath("traits.phone", jsonschemax.Path{
+			Name: "traits.phone",
+			CustomProperties: map[string]interface{}{
+				PlaceholderFormField:  "+1 555 0100",
+				AutocompleteFormField: "tel",
+				InputMaskFormField:    "+1 (999) 999-9999",
+				PatternHintFormField:  "Only digits",
+			},
+		})
+		assert.Equal(t, "+1 555 0100", f.Placeholder)
+		assert.Equal(t, "tel", f.Autocomplete)
+		assert.Equal(t, "+1 (999) 999-9999", f.InputMask)
+		assert.Equal(t, "Only digits", f.PatternHint)
+	})
+
+	t.Run("ui hints which are not strings are ignored", func(t *testing.T) {
+		f := fieldFromPath("traits.phone", jsonschemax.Path{
+			Name:             "traits.phone",
+			CustomProperties: map[string]interface{}{PlaceholderFormField: 1234},
+		})
+		assert.Empty(t, f.Placeholder)
+	})
 }
 
 //
@@ -0,0 +1,146 @@
+package hook
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/tidwall/gjson"
+
+	"github.com/ory/kratos/identity"
+	"github.com/ory/kratos/schema"
+	"github.com/ory/kratos/selfservice/flow/registration"
+	"github.com/ory/kratos/session"
+	"github.com/ory/kratos/x"
+)
+
+var (
+	_ registration.PostHookPrePersistExecutor  = new(AgeGate)
+	_ registration.PostHookPostPersistExecutor = new(AgeGate)
+)
+
+const (
+	AgeGateActionBlock = "block"
+	AgeGateActionFlag  = "flag"
+)
+
+type (
+	ageGateDependencies interface {
+		x.LoggingProvider
+		x.ClockProvider
+	}
+	// AgeGateConfig is the configuration of the age_gate hook.
+	AgeGateConfig struct {
+		// BirthdateTrait is the path of the trait holding the birthdate formatted as `YYYY-MM-DD`.
+		BirthdateTrait string `json:"birthdate_trait"`
+
+		// MinimumAge is the minimum age in years required to register.
+		MinimumAge int `json:"minimum_age"`
+
+		// JurisdictionTrait is the path of the trait holding the jurisdiction (e.g. the country code).
+		JurisdictionTrait string `json:"jurisdiction_trait"`
+
+		// Jurisdictions overrides the minimum age per jurisdiction. Keys are compared case-insensitively.
+		Jurisdictions map[string]int `json:"jurisdictions"`
+
+		// Action is either `block` or `flag`.
+		Action string `json:"action"`
+	}
+	// AgeGate enforces a minimum age during registration based on the birthdate trait. Under-age registrations
+	// are either blocked or completed and flagged in the audit log.
+	AgeGate struct {
+		r ageGateDependencies
+		c AgeGateConfig
+	}
+)
+
+func NewAgeGate(r ageGateDependencies, config json.RawMessage) *AgeGate {
+	e := &AgeGate{r: r, c: AgeGateConfig{BirthdateTrait: "birthdate", Action: AgeGateActionBlock}}
+	if err := json.Unmarshal(config, &e.c); err != nil {
+		r.Logger().WithError(err).Error("Unable to decode the configuration of the age_gate hook.")
+	}
+
+	jurisdictions := make(map[string]int, len(e.c.Jurisdictions))
+	for k, v := range e.c.Jurisdictions {
+		jurisdictions[strings.ToUpper(k)] = v
+	}
+	e.c.Jurisdictions = jurisdictions
+	return e
+}
+
+// ExecutePostRegistrationPrePersistHook rejects under-age registrations if the action is `block`.
+func (e *AgeGate) ExecutePostRegistrationPrePersistHook(_ http.ResponseWriter, _ *http.Request, _ *registration.Flow, i *identity.Identity) error {
+	if e.c.Action == AgeGateActionFlag {
+		return nil
+	}
+
+	_, err := e.check(i)
+	return err
+}
+
+// ExecutePostRegistrationPostPersistHook writes an audit log entry for under-age registrations if the action
+// is `flag`.
+func (e *AgeGate) ExecutePostRegistrationPostPersistHook(_ http.ResponseWriter, r *http.Request, _ *registration.Flow, s *session.Session) error {
+	if e.c.Action != AgeGateActionFlag {
+		return nil
+	}
+
+	minimumAge, err := e.check(s.Identity)
+	if err == nil {
+		return nil
+	}
+
+	e.r.Audit().
+		WithRequest(r).
+		WithError(err).
+		WithField("identity_id", s.Identity.ID).
+		WithField("minimum_age", minimumAge).
+		Warn("Flagged an identity which does not meet the minimum age.")
+	return nil
+}
+
+// check returns the minimum age which applies to the identity and a validation error if the identity is
+// younger or has no valid birthdate.
+func (e *AgeGate) check(i *identity.Identity) (int, error) {
+	minimumAge := e.minimumAge(i)
+	if minimumAge <= 0 {
+		return 0, nil
+	}
+
+	ptr := "#/traits/" + strings.ReplaceAll(e.c.BirthdateTrait, ".", "/")
+	value := gjson.GetBytes(i.Traits, e.c.BirthdateTrait).String()
+	if value == "" {
+		return minimumAge, schema.NewRequiredError(ptr, e.c.BirthdateTrait)
+	}
+
+	birthdate, err := time.Parse("2006-01-02", value)
+	if err != nil {
+		return minimumAge, schema.NewInvalidFormatError(ptr, "date", value)
+	}
+
+	if age(birthdate, e.r.Clock().Now()) < minimumAge {
+		return minimumAge, schema.NewMinimumAgeError(ptr, minimumAge)
+	}
+	return minimumAge, nil
+}
+
+func (e *AgeGate) minimumAge(i *identity.Identity) int {
+	if e.c.JurisdictionTrait != "" {
+		jurisdiction := gjson.GetBytes(i.Traits, e.c.JurisdictionTrait).String()
+		if m, ok := e.c.Jurisdictions[strings.ToUpper(jurisdiction)]; ok {
+			return m
+		}
+	}
+	return e.c.MinimumAge
+}
+
+// age returns the age in full years of someone born on birthdate at the given time.
+func age(birthdate, now time.Time) int {
+	now = now.UTC()
+	years := now.Year() - birthdate.Year()
+	if now.Month() < birthdate.Month() || (now.Month() == birthdate.Month() && now.Day() < birthdate.Day()) {
+		years--
+	}
+	return years
+}
@@ -0,0 +1,79 @@
+package hook_test
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ory/kratos/driver/config"
+	"github.com/ory/kratos/identity"
+	"github.com/ory/kratos/internal"
+	"github.com/ory/kratos/schema"
+	"github.com/ory/kratos/selfservice/flow/registration"
+	"github.com/ory/kratos/selfservice/hook"
+	"github.com/ory/kratos/session"
+	"github.com/ory/kratos/text"
+	"github.com/ory/kratos/x"
+)
+
+func TestAgeGate(t *testing.T) {
+	_, reg := internal.NewFastRegistryWithMocks(t)
+	reg.WithClock(x.NewFakeClock(time.Date(2021, 4, 22, 12, 0, 0, 0, time.UTC)))
+
+	newIdentity := func(traits string) *identity.Identity {
+		i := identity.NewIdentity(config.DefaultIdentityTraitsSchemaID)
+		i.Traits = identity.Traits(traits)
+		return i
+	}
+
+	execute := func(h *hook.AgeGate, i *identity.Identity) error {
+		return h.ExecutePostRegistrationPrePersistHook(httptest.NewRecorder(), httptest.NewRequest("POST", "/", nil), new(registration.Flow), i)
+	}
+
+	t.Run("action=block", func(t *testing.T) {
+		h := hook.NewAgeGate(reg, json.RawMessage(`{"minimum_age":16,"jurisdiction_trait":"country","jurisdictions":{"us":13}}`))
+
+		for k, tc := range []struct {
+			traits     string
+			minimumAge int
+		}{
+			{traits: `{"birthdate":"2005-04-23"}`, minimumAge: 16},
+			{traits: `{"birthdate":"2008-04-23","country":"US"}`, minimumAge: 13},
+			{traits: `{"birthdate":"2008-04-22","country":"DE"}`, minimumAge: 16},
+		} {
+			err := execute(h, newIdentity(tc.traits))
+			var ve *schema.ValidationError
+			require.True(t, errors.As(err, &ve), "%d: %+v", k, err)
+			assert.Equal(t, "#/traits/birthdate", ve.InstancePtr, k)
+			assert.Equal(t, text.ErrorValidationRegistrationMinimumAge, ve.Messages[0].ID, k)
+			assert.Equal(t, tc.minimumAge, ve.Context.(*schema.ValidationErrorContextMinimumAgeError).MinimumAge, k)
+		}
+
+		for k, traits := range []string{
+			`{"birthdate":"2005-04-22"}`,
+			`{"birthdate":"2008-04-22","country":"us"}`,
+		} {
+			require.NoError(t, execute(h, newIdentity(traits)), k)
+		}
+
+		for k, traits := range []string{`{}`, `{"birthdate":"22.04.2005"}`} {
+			var ve *schema.ValidationError
+			require.True(t, errors.As(execute(h, newIdentity(traits)), &ve), k)
+			assert.Equal(t, "#/traits/birthdate", ve.InstancePtr, k)
+		}
+	})
+
+	t.Run("action=flag", func(t *testing.T) {
+		h := hook.NewAgeGate(reg, json.RawMessage(`{"minimum_age":16,"action":"flag"}`))
+		i := newIdentity(`{"birthdate":"2010-01-01"}`)
+
+		require.NoError(t, execute(h, i))
+		require.NoError(t, h.ExecutePostRegistrationPostPersistHook(httptest.NewRecorder(), httptest.NewRequest("POST", "/", nil),
+			new(registration.Flow), &session.Session{ID: x.NewUUID(), Identity: i}))
+	})
+}
@@ -3,4 +3,10 @@ package hook
 const (
 	KeySessionIssuer    = "session"
 	KeySessionDestroyer = "revoke_active_sessions"
+	KeyKetoTuplesWriter = "keto"
+	KeyWebHook          = "web_hook"
+	KeyPlugin           = "plugin"
+
+	KeyRequireVerifiedAddress = "require_verified_address"
+	KeyAgeGate                = "age_gate"
 )
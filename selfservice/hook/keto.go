@@ -0,0 +1,137 @@
+package hook
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/url"
+
+	"github.com/google/go-jsonnet"
+	"github.com/hashicorp/go-retryablehttp"
+	"github.com/pkg/errors"
+
+	"github.com/ory/herodot"
+	"github.com/ory/x/fetcher"
+	"github.com/ory/x/urlx"
+
+	"github.com/ory/kratos/driver/config"
+	"github.com/ory/kratos/identity"
+	"github.com/ory/kratos/selfservice/flow/registration"
+	"github.com/ory/kratos/session"
+	"github.com/ory/kratos/x"
+)
+
+var _ registration.PostHookPostPersistExecutor = new(KetoTuplesWriter)
+
+type (
+	ketoTuplesWriterDependencies interface {
+		config.Provider
+		x.LoggingProvider
+	}
+	// KetoTuplesWriterConfig is the configuration of the keto hook.
+	KetoTuplesWriterConfig struct {
+		// URL is the base URL of ORY Keto's write API.
+		URL string `json:"url"`
+
+		// Tuples is the URL of the Jsonnet code which returns the relation tuples for the identity.
+		Tuples string `json:"tuples"`
+	}
+	// KetoRelationTuple is a relation tuple as expected by ORY Keto's write API.
+	KetoRelationTuple struct {
+		Namespace string `json:"namespace"`
+		Object    string `json:"object"`
+		Relation  string `json:"relation"`
+		Subject   string `json:"subject"`
+	}
+	// KetoTuplesWriter writes relation tuples for newly registered identities to ORY Keto.
+	KetoTuplesWriter struct {
+		r  ketoTuplesWriterDependencies
+		c  KetoTuplesWriterConfig
+		hc *retryablehttp.Client
+		f  *fetcher.Fetcher
+	}
+)
+
+func NewKetoTuplesWriter(r ketoTuplesWriterDependencies, config json.RawMessage) *KetoTuplesWriter {
+	e := &KetoTuplesWriter{r: r, hc: x.NewEgressHTTPClient(r, config.EgressDependencyWebHooks)}
+	e.f = fetcher.NewFetcher(fetcher.WithClient(e.hc))
+	if err := json.Unmarshal(config, &e.c); err != nil {
+		r.Logger().WithError(err).Error("Unable to decode the configuration of the keto hook.")
+	}
+	return e
+}
+
+func (e *KetoTuplesWriter) ExecutePostRegistrationPostPersistHook(_ http.ResponseWriter, r *http.Request, _ *registration.Flow, s *session.Session) error {
+	tuples, err := e.tuples(s.Identity)
+	if err != nil {
+		return err
+	}
+
+	for _, t := range tuples {
+		if err := e.write(r, t); err != nil {
+			return err
+		}
+	}
+
+	e.r.Logger().
+		WithRequest(r).
+		WithField("identity_id", s.Identity.ID).
+		WithField("tuples", len(tuples)).
+		Debug("Wrote relation tuples for the registered identity to ORY Keto.")
+	return nil
+}
+
+// tuples evaluates the Jsonnet code with the identity available as `std.extVar('identity')`.
+func (e *KetoTuplesWriter) tuples(i *identity.Identity) ([]KetoRelationTuple, error) {
+	jn, err := e.f.Fetch(e.c.Tuples)
+	if err != nil {
+		return nil, err
+	}
+
+	ij, err := json.Marshal(i)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	vm := jsonnet.MakeVM()
+	vm.ExtCode("identity", string(ij))
+	evaluated, err := vm.EvaluateSnippet(e.c.Tuples, jn.String())
+	if err != nil {
+		return nil, errors.WithStack(herodot.ErrInternalServerError.WithReasonf("Unable to evaluate the Jsonnet code of the keto hook.").WithDebug(err.Error()))
+	}
+
+	var tuples []KetoRelationTuple
+	if err := json.Unmarshal([]byte(evaluated), &tuples); err != nil {
+		return nil, errors.WithStack(herodot.ErrInternalServerError.WithReasonf("The Jsonnet code of the keto hook must return an array of relation tuples.").WithDebug(err.Error()))
+	}
+	return tuples, nil
+}
+
+func (e *KetoTuplesWriter) write(r *http.Request, t KetoRelationTuple) error {
+	body, err := json.Marshal(t)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	u, err := url.Parse(e.c.URL)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	req, err := retryablehttp.NewRequest("PUT", urlx.AppendPaths(u, "relation-tuples").String(), bytes.NewReader(body))
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	res, err := e.hc.Do(req.WithContext(r.Context()))
+	if err != nil {
+		return errors.WithStack(herodot.ErrInternalServerError.WithReasonf("Unable to reach ORY Keto.").WithDebug(err.Error()))
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		return errors.WithStack(herodot.ErrInternalServerError.WithReasonf("ORY Keto responded with unexpected status code %d when writing a relation tuple.", res.StatusCode))
+	}
+	return nil
+}
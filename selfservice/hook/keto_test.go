@@ -0,0 +1,72 @@
+package hook_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ory/kratos/driver/config"
+	"github.com/ory/kratos/identity"
+	"github.com/ory/kratos/internal"
+	"github.com/ory/kratos/selfservice/flow"
+	"github.com/ory/kratos/selfservice/flow/registration"
+	"github.com/ory/kratos/selfservice/hook"
+	"github.com/ory/kratos/session"
+	"github.com/ory/kratos/x"
+)
+
+func TestKetoTuplesWriter(t *testing.T) {
+	_, reg := internal.NewFastRegistryWithMocks(t)
+
+	var written []hook.KetoRelationTuple
+	status := http.StatusCreated
+	keto := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "PUT", r.Method)
+		assert.Equal(t, "/relation-tuples", r.URL.Path)
+
+		var tuple hook.KetoRelationTuple
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&tuple))
+		written = append(written, tuple)
+		w.WriteHeader(status)
+	}))
+	t.Cleanup(keto.Close)
+
+	newHook := func(t *testing.T, tuples string) *hook.KetoTuplesWriter {
+		c, err := json.Marshal(&hook.KetoTuplesWriterConfig{URL: keto.URL, Tuples: tuples})
+		require.NoError(t, err)
+		return hook.NewKetoTuplesWriter(reg, c)
+	}
+
+	execute := func(h *hook.KetoTuplesWriter, i *identity.Identity) error {
+		r := httptest.NewRequest("POST", "/", nil).WithContext(context.Background())
+		return h.ExecutePostRegistrationPostPersistHook(httptest.NewRecorder(), r,
+			&registration.Flow{Type: flow.TypeBrowser}, &session.Session{ID: x.NewUUID(), Identity: i})
+	}
+
+	i := identity.NewIdentity(config.DefaultIdentityTraitsSchemaID)
+	i.Traits = identity.Traits(`{"email":"admin@ory.sh"}`)
+
+	t.Run("case=writes tuples", func(t *testing.T) {
+		written = nil
+		require.NoError(t, execute(newHook(t, "file://./stub/keto.jsonnet"), i))
+		assert.Equal(t, []hook.KetoRelationTuple{
+			{Namespace: "files", Object: "home:" + i.ID.String(), Relation: "owner", Subject: i.ID.String()},
+			{Namespace: "groups", Object: "admins", Relation: "member", Subject: i.ID.String()},
+		}, written)
+	})
+
+	t.Run("case=fails if keto rejects a tuple", func(t *testing.T) {
+		status = http.StatusBadRequest
+		t.Cleanup(func() { status = http.StatusCreated })
+		require.Error(t, execute(newHook(t, "file://./stub/keto.jsonnet"), i))
+	})
+
+	t.Run("case=fails if jsonnet is invalid", func(t *testing.T) {
+		require.Error(t, execute(newHook(t, "file://./stub/does-not-exist.jsonnet"), i))
+	})
+}
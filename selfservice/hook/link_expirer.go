@@ -0,0 +1,34 @@
+package hook
+
+import (
+	"net/http"
+
+	"github.com/ory/kratos/identity"
+	"github.com/ory/kratos/selfservice/flow/settings"
+	"github.com/ory/kratos/selfservice/strategy/link"
+)
+
+var _ settings.PostHookPostPersistExecutor = new(LinkExpirer)
+
+type (
+	linkExpirerDependencies interface {
+		link.RecoveryTokenPersistenceProvider
+		link.VerificationTokenPersistenceProvider
+	}
+	// LinkExpirer expires all outstanding recovery and verification links of an identity after its password
+	// was changed.
+	LinkExpirer struct {
+		r linkExpirerDependencies
+	}
+)
+
+func NewLinkExpirer(r linkExpirerDependencies) *LinkExpirer {
+	return &LinkExpirer{r: r}
+}
+
+func (e *LinkExpirer) ExecuteSettingsPostPersistHook(_ http.ResponseWriter, r *http.Request, _ *settings.Flow, i *identity.Identity) error {
+	if err := e.r.RecoveryTokenPersister().ExpireRecoveryTokens(r.Context(), i.ID); err != nil {
+		return err
+	}
+	return e.r.VerificationTokenPersister().ExpireVerificationTokens(r.Context(), i.ID)
+}
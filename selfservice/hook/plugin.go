@@ -0,0 +1,74 @@
+package hook
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/ory/kratos/identity"
+	"github.com/ory/kratos/plugin"
+	"github.com/ory/kratos/selfservice/flow/login"
+	"github.com/ory/kratos/selfservice/flow/logout"
+	"github.com/ory/kratos/selfservice/flow/registration"
+	"github.com/ory/kratos/session"
+	"github.com/ory/kratos/x"
+)
+
+var _ registration.PostHookPrePersistExecutor = new(Plugin)
+var _ login.PostHookExecutor = new(Plugin)
+var _ logout.PostHookExecutor = new(Plugin)
+
+// PluginEventLogin is sent when a user signed in.
+const PluginEventLogin = "login"
+
+type (
+	pluginDependencies interface {
+		plugin.ManagementProvider
+		x.LoggingProvider
+	}
+	// PluginConfig is the configuration of the plugin hook.
+	PluginConfig struct {
+		// Name is the name of the plugin as configured in `plugins`.
+		Name string `json:"name"`
+	}
+	// Plugin executes a hook implemented by an external plugin process.
+	Plugin struct {
+		r pluginDependencies
+		c PluginConfig
+	}
+)
+
+func NewPlugin(r pluginDependencies, config json.RawMessage) *Plugin {
+	e := &Plugin{r: r}
+	if err := json.Unmarshal(config, &e.c); err != nil {
+		r.Logger().WithError(err).Error("Unable to decode the configuration of the plugin hook.")
+	}
+	return e
+}
+
+func (e *Plugin) ExecutePostRegistrationPrePersistHook(_ http.ResponseWriter, r *http.Request, _ *registration.Flow, i *identity.Identity) error {
+	return e.execute(r, &plugin.HookRequest{Event: WebHookEventRegistration, Identity: i})
+}
+
+func (e *Plugin) ExecuteLoginPostHook(_ http.ResponseWriter, r *http.Request, _ *login.Flow, s *session.Session) error {
+	return e.execute(r, &plugin.HookRequest{Event: PluginEventLogin, Session: s})
+}
+
+func (e *Plugin) ExecuteLogoutPostHook(_ http.ResponseWriter, r *http.Request, s *session.Session) error {
+	return e.execute(r, &plugin.HookRequest{Event: WebHookEventLogout, Session: s})
+}
+
+func (e *Plugin) execute(r *http.Request, req *plugin.HookRequest) error {
+	var res plugin.HookResponse
+	if err := e.r.PluginManager().Call(r.Context(), e.c.Name, plugin.MethodExecuteHook, req, &res); err != nil {
+		return err
+	}
+
+	if len(res.Errors) > 0 {
+		errs := WebHookErrorResponse{Errors: make([]WebHookFieldError, len(res.Errors))}
+		for k, fe := range res.Errors {
+			errs.Errors[k] = WebHookFieldError(fe)
+		}
+		return errs.validationError()
+	}
+	return nil
+}
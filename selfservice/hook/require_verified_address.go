@@ -0,0 +1,76 @@
+package hook
+
+import (
+	"net/http"
+
+	"github.com/pkg/errors"
+
+	"github.com/ory/kratos/driver/config"
+	"github.com/ory/kratos/identity"
+	"github.com/ory/kratos/schema"
+	"github.com/ory/kratos/selfservice/flow"
+	"github.com/ory/kratos/selfservice/flow/login"
+	"github.com/ory/kratos/selfservice/flow/registration"
+	"github.com/ory/kratos/session"
+	"github.com/ory/kratos/x"
+)
+
+var (
+	_ login.PostHookExecutor                   = new(RequireVerifiedAddress)
+	_ registration.PostHookPostPersistExecutor = new(RequireVerifiedAddress)
+)
+
+type (
+	requireVerifiedAddressDependencies interface {
+		config.Provider
+		x.WriterProvider
+	}
+	// RequireVerifiedAddress prevents issuing a session to identities which have not verified any of their
+	// addresses yet.
+	RequireVerifiedAddress struct {
+		r requireVerifiedAddressDependencies
+	}
+)
+
+func NewRequireVerifiedAddress(r requireVerifiedAddressDependencies) *RequireVerifiedAddress {
+	return &RequireVerifiedAddress{r: r}
+}
+
+// ExecuteLoginPostHook rejects the login with a validation error if no address is verified.
+func (e *RequireVerifiedAddress) ExecuteLoginPostHook(_ http.ResponseWriter, _ *http.Request, _ *login.Flow, s *session.Session) error {
+	if hasVerifiedAddress(s.Identity) {
+		return nil
+	}
+	return schema.NewAddressNotVerifiedError()
+}
+
+// ExecutePostRegistrationPostPersistHook completes the registration without issuing a session if no address is
+// verified. Browsers are redirected to the verification UI and API clients receive the identity only. Hooks
+// after this one, such as the session hook, are not executed in that case.
+func (e *RequireVerifiedAddress) ExecutePostRegistrationPostPersistHook(w http.ResponseWriter, r *http.Request, a *registration.Flow, s *session.Session) error {
+	if hasVerifiedAddress(s.Identity) {
+		return nil
+	}
+
+	if a.Type == flow.TypeAPI {
+		e.r.Writer().Write(w, r, &registration.APIFlowResponse{Identity: s.Identity})
+		return errors.WithStack(registration.ErrHookAbortFlow)
+	}
+
+	verificationUI, err := e.r.Config(r.Context()).SelfServiceFlowVerificationUIOrErr()
+	if err != nil {
+		return err
+	}
+
+	http.Redirect(w, r, verificationUI.String(), http.StatusSeeOther)
+	return errors.WithStack(registration.ErrHookAbortFlow)
+}
+
+func hasVerifiedAddress(i *identity.Identity) bool {
+	for _, a := range i.VerifiableAddresses {
+		if a.Verified {
+			return true
+		}
+	}
+	return false
+}
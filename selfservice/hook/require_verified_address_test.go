@@ -0,0 +1,80 @@
+package hook_test
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tidwall/gjson"
+
+	"github.com/ory/kratos/driver/config"
+	"github.com/ory/kratos/identity"
+	"github.com/ory/kratos/internal"
+	"github.com/ory/kratos/schema"
+	"github.com/ory/kratos/selfservice/flow"
+	"github.com/ory/kratos/selfservice/flow/registration"
+	"github.com/ory/kratos/selfservice/hook"
+	"github.com/ory/kratos/session"
+	"github.com/ory/kratos/text"
+	"github.com/ory/kratos/x"
+)
+
+func TestRequireVerifiedAddress(t *testing.T) {
+	conf, reg := internal.NewFastRegistryWithMocks(t)
+	conf.MustSet(config.ViperKeySelfServiceVerificationUI, "http://localhost/verify")
+
+	h := hook.NewRequireVerifiedAddress(reg)
+
+	newSession := func(verified bool) *session.Session {
+		i := identity.NewIdentity(config.DefaultIdentityTraitsSchemaID)
+		i.VerifiableAddresses = []identity.VerifiableAddress{
+			{ID: x.NewUUID(), Value: "foo@ory.sh", Via: identity.VerifiableAddressTypeEmail, Verified: verified},
+		}
+		return &session.Session{ID: x.NewUUID(), Identity: i}
+	}
+
+	t.Run("method=ExecuteLoginPostHook", func(t *testing.T) {
+		r := httptest.NewRequest("POST", "/", nil)
+
+		require.NoError(t, h.ExecuteLoginPostHook(httptest.NewRecorder(), r, nil, newSession(true)))
+
+		err := h.ExecuteLoginPostHook(httptest.NewRecorder(), r, nil, newSession(false))
+		var ve *schema.ValidationError
+		require.True(t, errors.As(err, &ve), "%+v", err)
+		assert.Equal(t, text.ErrorValidationAddressNotVerified, ve.Messages[0].ID)
+	})
+
+	t.Run("method=ExecutePostRegistrationPostPersistHook", func(t *testing.T) {
+		t.Run("case=verified", func(t *testing.T) {
+			w := httptest.NewRecorder()
+			require.NoError(t, h.ExecutePostRegistrationPostPersistHook(w, httptest.NewRequest("POST", "/", nil),
+				&registration.Flow{Type: flow.TypeBrowser}, newSession(true)))
+			assert.Empty(t, w.Header().Get("Location"))
+		})
+
+		t.Run("flow=browser", func(t *testing.T) {
+			w := httptest.NewRecorder()
+			err := h.ExecutePostRegistrationPostPersistHook(w, httptest.NewRequest("POST", "/", nil),
+				&registration.Flow{Type: flow.TypeBrowser}, newSession(false))
+			require.True(t, errors.Is(err, registration.ErrHookAbortFlow), "%+v", err)
+			assert.Equal(t, http.StatusSeeOther, w.Code)
+			assert.Equal(t, "http://localhost/verify", w.Header().Get("Location"))
+		})
+
+		t.Run("flow=api", func(t *testing.T) {
+			w := httptest.NewRecorder()
+			s := newSession(false)
+			err := h.ExecutePostRegistrationPostPersistHook(w, httptest.NewRequest("POST", "/", nil),
+				&registration.Flow{Type: flow.TypeAPI}, s)
+			require.True(t, errors.Is(err, registration.ErrHookAbortFlow), "%+v", err)
+
+			body := w.Body.String()
+			assert.Equal(t, s.Identity.ID.String(), gjson.Get(body, "identity.id").String(), "%s", body)
+			assert.False(t, gjson.Get(body, "session").Exists(), "%s", body)
+			assert.False(t, gjson.Get(body, "session_token").Exists(), "%s", body)
+		})
+	})
+}
@@ -13,6 +13,8 @@ type (
 	sessionDestroyerDependencies interface {
 		session.ManagementProvider
 		session.PersistenceProvider
+		session.WebhookProvider
+		session.RevocationPublisherProvider
 	}
 	SessionDestroyer struct {
 		r sessionDestroyerDependencies
@@ -27,5 +29,7 @@ func (e *SessionDestroyer) ExecuteLoginPostHook(_ http.ResponseWriter, r *http.R
 	if err := e.r.SessionPersister().DeleteSessionsByIdentity(r.Context(), s.Identity.ID); err != nil {
 		return err
 	}
+	e.r.SessionWebhook().IdentitySessionsRevoked(r.Context(), s.Identity.ID)
+	e.r.SessionRevocationPublisher().IdentitySessionsRevoked(r.Context(), s.Identity.ID)
 	return nil
 }
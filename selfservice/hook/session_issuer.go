@@ -2,7 +2,6 @@ package hook
 
 import (
 	"net/http"
-	"time"
 
 	"github.com/pkg/errors"
 
@@ -20,7 +19,9 @@ type (
 	sessionIssuerDependencies interface {
 		session.ManagementProvider
 		session.PersistenceProvider
+		session.WebhookProvider
 		x.WriterProvider
+		x.ClockProvider
 	}
 	SessionIssuerProvider interface {
 		HookSessionIssuer() *SessionIssuer
@@ -35,10 +36,11 @@ func NewSessionIssuer(r sessionIssuerDependencies) *SessionIssuer {
 }
 
 func (e *SessionIssuer) ExecutePostRegistrationPostPersistHook(w http.ResponseWriter, r *http.Request, a *registration.Flow, s *session.Session) error {
-	s.AuthenticatedAt = time.Now().UTC()
+	s.AuthenticatedAt = e.r.Clock().Now().UTC()
 	if err := e.r.SessionPersister().CreateSession(r.Context(), s); err != nil {
 		return err
 	}
+	e.r.SessionWebhook().SessionCreated(r.Context(), s, a.Active.String())
 
 	if a.Type == flow.TypeAPI {
 		e.r.Writer().Write(w, r, &registration.APIFlowResponse{
@@ -9,6 +9,7 @@ import (
 	"github.com/ory/kratos/selfservice/flow/settings"
 	"github.com/ory/kratos/selfservice/strategy/link"
 	"github.com/ory/kratos/session"
+	"github.com/ory/kratos/x"
 )
 
 var _ registration.PostHookPostPersistExecutor = new(Verifier)
@@ -19,6 +20,8 @@ type (
 		link.SenderProvider
 		link.VerificationTokenPersistenceProvider
 		config.Provider
+		x.ClockProvider
+		x.IDGeneratorProvider
 	}
 	Verifier struct {
 		r verifierDependencies
@@ -47,12 +50,13 @@ func (e *Verifier) do(r *http.Request, i *identity.Identity) error {
 			continue
 		}
 
-		token := link.NewVerificationToken(address, e.r.Config(r.Context()).SelfServiceFlowVerificationRequestLifespan())
+		token := link.NewVerificationToken(e.r.Clock(), e.r.IDGenerator(), address, e.r.Config(r.Context()).SelfServiceFlowVerificationRequestLifespan(),
+			e.r.Config(r.Context()).SelfServiceFlowVerificationToken())
 		if err := e.r.VerificationTokenPersister().CreateVerificationToken(r.Context(), token); err != nil {
 			return err
 		}
 
-		if err := e.r.LinkSender().SendVerificationTokenTo(r.Context(), address, token); err != nil {
+		if err := e.r.LinkSender().SendVerificationTokenTo(r.Context(), i, address, token); err != nil {
 			return err
 		}
 	}
@@ -0,0 +1,276 @@
+package hook
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/gofrs/uuid"
+	"github.com/hashicorp/go-retryablehttp"
+	"github.com/pkg/errors"
+
+	"github.com/ory/herodot"
+	"github.com/ory/jsonschema/v3"
+
+	"github.com/ory/kratos/driver/config"
+	"github.com/ory/kratos/identity"
+	"github.com/ory/kratos/selfservice/flow"
+	"github.com/ory/kratos/selfservice/flow/logout"
+	"github.com/ory/kratos/selfservice/flow/registration"
+	"github.com/ory/kratos/session"
+	"github.com/ory/kratos/x"
+)
+
+var _ logout.PostHookExecutor = new(WebHook)
+var _ registration.PostHookPrePersistExecutor = new(WebHook)
+var _ registration.PostHookPostPersistExecutor = new(WebHook)
+
+const (
+	// WebHookEventLogout is sent when a user signed out.
+	WebHookEventLogout = "logout"
+
+	// WebHookEventRegistration is sent before a new identity is stored, or right after it was stored if the hook
+	// sets after_persist. The web hook can reject the registration by responding with field errors.
+	WebHookEventRegistration = "registration"
+
+	// WebHookPayloadVersion1 is the payload containing the event and either the session or the identity.
+	WebHookPayloadVersion1 = "v1"
+
+	// WebHookPayloadVersion2 adds the payload version, the flow, and the time of the event.
+	WebHookPayloadVersion2 = "v2"
+
+	// WebHookPayloadVersionLatest is sent if the hook does not set a payload version.
+	WebHookPayloadVersionLatest = WebHookPayloadVersion2
+)
+
+// webHookPayloadDowngrades convert a payload of the version following the key to the version of the key. Every change
+// to the payload or to the serialization of identities and sessions requires a new payload version together with a
+// downgrade which restores the previous payload, so that web hooks which set an older version keep working.
+var webHookPayloadDowngrades = map[string]func(payload map[string]interface{}){
+	WebHookPayloadVersion1: func(payload map[string]interface{}) {
+		delete(payload, "payload_version")
+		delete(payload, "flow")
+		delete(payload, "timestamp")
+	},
+}
+
+// webHookPayloadVersions lists all payload versions from the oldest to the latest.
+var webHookPayloadVersions = []string{WebHookPayloadVersion1, WebHookPayloadVersion2}
+
+type (
+	webHookDependencies interface {
+		config.Provider
+		x.LoggingProvider
+		x.ClockProvider
+	}
+	// WebHookConfig is the configuration of the web_hook hook.
+	WebHookConfig struct {
+		// URL is the endpoint which is called.
+		URL string `json:"url"`
+
+		// Method is the HTTP method, defaults to POST.
+		Method string `json:"method"`
+
+		// Headers are added to every request, for example to authenticate ORY Kratos.
+		Headers map[string]string `json:"headers"`
+
+		// PayloadVersion is the version of the payload sent to the web hook, defaults to
+		// WebHookPayloadVersionLatest.
+		PayloadVersion string `json:"payload_version"`
+
+		// AfterPersist calls the web hook of a registration after the identity was stored instead of before.
+		// What happens to the identity if the web hook fails is configured by
+		// selfservice.flows.registration.after.on_hook_failure, which must not be rollback.
+		AfterPersist bool `json:"after_persist"`
+	}
+	// WebHookPayload is the JSON body sent to the web hook. It is the latest payload version and converted to the
+	// configured payload version before it is sent.
+	WebHookPayload struct {
+		// PayloadVersion is the version of the payload. It is not set in version v1.
+		PayloadVersion string `json:"payload_version,omitempty"`
+
+		// Event is the event which triggered the hook, for example "logout".
+		Event string `json:"event"`
+
+		// Flow is the self-service flow the event occurred in. It is not set for logouts.
+		Flow *WebHookFlow `json:"flow,omitempty"`
+
+		// Timestamp is the time the event occurred at.
+		Timestamp time.Time `json:"timestamp"`
+
+		// Session is the session the event relates to. It is not set for registrations.
+		Session *session.Session `json:"session,omitempty"`
+
+		// Identity is the identity about to be registered. It is only set for registrations.
+		Identity *identity.Identity `json:"identity,omitempty"`
+	}
+	// WebHookFlow identifies the self-service flow of a web hook payload.
+	WebHookFlow struct {
+		ID   uuid.UUID `json:"id"`
+		Type flow.Type `json:"type"`
+	}
+	// WebHookErrorResponse is the JSON body a web hook responds with (using status code 400 or 422) to
+	// reject a registration.
+	WebHookErrorResponse struct {
+		Errors []WebHookFieldError `json:"errors"`
+	}
+	// WebHookFieldError is shown at the form field identified by the JSON pointer.
+	WebHookFieldError struct {
+		// InstancePtr is the JSON pointer of the rejected value, for example "#/traits/email". Use "#/" for
+		// errors which do not relate to a specific field.
+		InstancePtr string `json:"instance_ptr"`
+
+		// Message is the human readable error message.
+		Message string `json:"message"`
+	}
+	// WebHook calls an HTTP endpoint, for example to write audit logs or to purge caches.
+	WebHook struct {
+		r webHookDependencies
+		c WebHookConfig
+	}
+)
+
+func NewWebHook(r webHookDependencies, config json.RawMessage) *WebHook {
+	e := &WebHook{r: r}
+	if err := json.Unmarshal(config, &e.c); err != nil {
+		r.Logger().WithError(err).Error("Unable to decode the configuration of the web_hook hook.")
+	}
+	if len(e.c.Method) == 0 {
+		e.c.Method = "POST"
+	}
+	if len(e.c.PayloadVersion) == 0 {
+		e.c.PayloadVersion = WebHookPayloadVersionLatest
+	}
+	return e
+}
+
+func (e *WebHook) ExecuteLogoutPostHook(_ http.ResponseWriter, r *http.Request, s *session.Session) error {
+	return e.call(r, &WebHookPayload{Event: WebHookEventLogout, Session: s})
+}
+
+// ExecutePostRegistrationPrePersistHook lets the web hook validate the identity, for example to require a corporate
+// email address or to run KYC checks. Field errors returned by the web hook are shown in the registration form.
+func (e *WebHook) ExecutePostRegistrationPrePersistHook(_ http.ResponseWriter, r *http.Request, f *registration.Flow, i *identity.Identity) error {
+	if e.c.AfterPersist {
+		return nil
+	}
+	return e.call(r, &WebHookPayload{Event: WebHookEventRegistration, Flow: &WebHookFlow{ID: f.ID, Type: f.Type}, Identity: i})
+}
+
+// ExecutePostRegistrationPostPersistHook calls the web hook once the identity was stored if the hook sets
+// after_persist, for example to provision the identity in other systems.
+func (e *WebHook) ExecutePostRegistrationPostPersistHook(_ http.ResponseWriter, r *http.Request, f *registration.Flow, s *session.Session) error {
+	if !e.c.AfterPersist {
+		return nil
+	}
+	if e.r.Config(r.Context()).SelfServiceFlowRegistrationOnHookFailure() == config.RegistrationOnHookFailureRollback {
+		// The database transaction of the registration would stay open, and hold its locks, until the web hook
+		// responds.
+		return errors.WithStack(herodot.ErrInternalServerError.WithReasonf(
+			"Web hooks with after_persist can not be used if selfservice.flows.registration.after.on_hook_failure is set to %s.",
+			config.RegistrationOnHookFailureRollback))
+	}
+	return e.call(r, &WebHookPayload{Event: WebHookEventRegistration, Flow: &WebHookFlow{ID: f.ID, Type: f.Type}, Identity: s.Identity})
+}
+
+func (e *WebHook) call(r *http.Request, p *WebHookPayload) error {
+	p.PayloadVersion = WebHookPayloadVersionLatest
+	p.Timestamp = e.r.Clock().Now().UTC()
+	body, err := encodeWebHookPayload(p, e.c.PayloadVersion)
+	if err != nil {
+		return err
+	}
+
+	req, err := retryablehttp.NewRequest(e.c.Method, e.c.URL, bytes.NewReader(body))
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range e.c.Headers {
+		req.Header.Set(k, v)
+	}
+
+	c := e.r.Config(r.Context())
+	hc, err := x.NewHookHTTPClient(c.HTTPClient(), c.Egress(), c.EgressDependency(config.EgressDependencyWebHooks))
+	if err != nil {
+		return errors.WithStack(herodot.ErrInternalServerError.WithReasonf("Unable to configure the HTTP client of the web hook.").WithDebug(err.Error()))
+	}
+
+	res, err := hc.Do(req.WithContext(r.Context()))
+	if err != nil {
+		return errors.WithStack(herodot.ErrInternalServerError.WithReasonf("Unable to reach the web hook.").WithDebug(err.Error()))
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode == http.StatusBadRequest || res.StatusCode == http.StatusUnprocessableEntity {
+		var body WebHookErrorResponse
+		if err := json.NewDecoder(res.Body).Decode(&body); err == nil && len(body.Errors) > 0 {
+			return body.validationError()
+		}
+	}
+
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		return errors.WithStack(herodot.ErrInternalServerError.WithReasonf("The web hook responded with unexpected status code %d.", res.StatusCode))
+	}
+
+	e.r.Logger().
+		WithRequest(r).
+		WithField("event", p.Event).
+		Debug("Called the web hook.")
+	return nil
+}
+
+// encodeWebHookPayload encodes the latest payload and downgrades it to the given version.
+func encodeWebHookPayload(p *WebHookPayload, version string) ([]byte, error) {
+	body, err := json.Marshal(p)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	if version == WebHookPayloadVersionLatest {
+		return body, nil
+	}
+
+	target := -1
+	for k, v := range webHookPayloadVersions {
+		if v == version {
+			target = k
+		}
+	}
+	if target < 0 {
+		return nil, errors.WithStack(herodot.ErrInternalServerError.WithReasonf("The web hook payload version %q is not supported.", version))
+	}
+
+	d := json.NewDecoder(bytes.NewReader(body))
+	d.UseNumber()
+	var payload map[string]interface{}
+	if err := d.Decode(&payload); err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	for k := len(webHookPayloadVersions) - 2; k >= target; k-- {
+		webHookPayloadDowngrades[webHookPayloadVersions[k]](payload)
+	}
+
+	body, err = json.Marshal(payload)
+	return body, errors.WithStack(err)
+}
+
+// validationError converts the field errors to a validation error which is mapped to the form fields.
+func (res *WebHookErrorResponse) validationError() error {
+	err := &jsonschema.ValidationError{
+		Message:     "the web hook rejected the request",
+		InstancePtr: "#/",
+	}
+	for _, fe := range res.Errors {
+		ptr := fe.InstancePtr
+		if len(ptr) == 0 {
+			ptr = "#/"
+		}
+		err.Causes = append(err.Causes, &jsonschema.ValidationError{
+			Message:     fe.Message,
+			InstancePtr: ptr,
+		})
+	}
+	return errors.WithStack(err)
+}
@@ -0,0 +1,170 @@
+package hook_test
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tidwall/gjson"
+
+	"github.com/ory/kratos/driver/config"
+	"github.com/ory/kratos/identity"
+	"github.com/ory/kratos/internal"
+	"github.com/ory/kratos/selfservice/flow"
+	"github.com/ory/kratos/selfservice/flow/registration"
+	"github.com/ory/kratos/selfservice/form"
+	"github.com/ory/kratos/selfservice/hook"
+	"github.com/ory/kratos/session"
+	"github.com/ory/kratos/x"
+)
+
+func TestWebHook(t *testing.T) {
+	conf, reg := internal.NewFastRegistryWithMocks(t)
+
+	var received []hook.WebHookPayload
+	var bodies [][]byte
+	status := http.StatusNoContent
+	var response []byte
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "PUT", r.Method)
+		assert.Equal(t, "secret", r.Header.Get("X-Api-Key"))
+
+		body, err := ioutil.ReadAll(r.Body)
+		require.NoError(t, err)
+		var p hook.WebHookPayload
+		require.NoError(t, json.Unmarshal(body, &p))
+		received = append(received, p)
+		bodies = append(bodies, body)
+		w.WriteHeader(status)
+		_, _ = w.Write(response)
+	}))
+	t.Cleanup(ts.Close)
+
+	c, err := json.Marshal(&hook.WebHookConfig{URL: ts.URL, Method: "PUT", Headers: map[string]string{"X-Api-Key": "secret"}})
+	require.NoError(t, err)
+	h := hook.NewWebHook(reg, c)
+
+	i := identity.NewIdentity(config.DefaultIdentityTraitsSchemaID)
+	s := &session.Session{ID: x.NewUUID(), IdentityID: i.ID, Identity: i}
+	f := &registration.Flow{ID: x.NewUUID(), Type: flow.TypeBrowser}
+	execute := func() error {
+		r := httptest.NewRequest("GET", "/", nil).WithContext(context.Background())
+		return h.ExecuteLogoutPostHook(httptest.NewRecorder(), r, s)
+	}
+
+	t.Run("case=calls the web hook on logout", func(t *testing.T) {
+		received = nil
+		require.NoError(t, execute())
+		require.Len(t, received, 1)
+		assert.Equal(t, hook.WebHookEventLogout, received[0].Event)
+		assert.Equal(t, s.ID, received[0].Session.ID)
+		assert.Equal(t, i.ID, received[0].Session.Identity.ID)
+		assert.Equal(t, hook.WebHookPayloadVersionLatest, received[0].PayloadVersion)
+		assert.False(t, received[0].Timestamp.IsZero())
+	})
+
+	t.Run("case=fails on unexpected status code", func(t *testing.T) {
+		status = http.StatusBadRequest
+		t.Cleanup(func() {
+			status = http.StatusNoContent
+		})
+		require.Error(t, execute())
+	})
+
+	t.Run("case=calls the web hook before registration", func(t *testing.T) {
+		received = nil
+		r := httptest.NewRequest("POST", "/", nil)
+		require.NoError(t, h.ExecutePostRegistrationPrePersistHook(httptest.NewRecorder(), r, f, i))
+		require.Len(t, received, 1)
+		assert.Equal(t, hook.WebHookEventRegistration, received[0].Event)
+		assert.Equal(t, i.ID, received[0].Identity.ID)
+		assert.Nil(t, received[0].Session)
+		require.NotNil(t, received[0].Flow)
+		assert.Equal(t, f.ID, received[0].Flow.ID)
+		assert.Equal(t, flow.TypeBrowser, received[0].Flow.Type)
+	})
+
+	t.Run("case=calls the web hook after registration", func(t *testing.T) {
+		c, err := json.Marshal(&hook.WebHookConfig{URL: ts.URL, Method: "PUT", Headers: map[string]string{"X-Api-Key": "secret"}, AfterPersist: true})
+		require.NoError(t, err)
+		h := hook.NewWebHook(reg, c)
+
+		received = nil
+		r := httptest.NewRequest("POST", "/", nil)
+		require.NoError(t, h.ExecutePostRegistrationPrePersistHook(httptest.NewRecorder(), r, f, i))
+		require.Len(t, received, 0)
+
+		require.NoError(t, h.ExecutePostRegistrationPostPersistHook(httptest.NewRecorder(), r, f, s))
+		require.Len(t, received, 1)
+		assert.Equal(t, hook.WebHookEventRegistration, received[0].Event)
+		assert.Equal(t, i.ID, received[0].Identity.ID)
+		assert.Nil(t, received[0].Session)
+		assert.Equal(t, f.ID, received[0].Flow.ID)
+
+		received = nil
+		require.NoError(t, hook.NewWebHook(reg, json.RawMessage(`{"url":"`+ts.URL+`"}`)).ExecutePostRegistrationPostPersistHook(httptest.NewRecorder(), r, f, s))
+		require.Len(t, received, 0)
+	})
+
+	t.Run("case=rejects web hooks after registration if failures are rolled back", func(t *testing.T) {
+		conf.MustSet(config.ViperKeySelfServiceRegistrationAfterOnHookFailure, config.RegistrationOnHookFailureRollback)
+		t.Cleanup(func() {
+			conf.MustSet(config.ViperKeySelfServiceRegistrationAfterOnHookFailure, nil)
+		})
+
+		c, err := json.Marshal(&hook.WebHookConfig{URL: ts.URL, AfterPersist: true})
+		require.NoError(t, err)
+
+		received = nil
+		r := httptest.NewRequest("POST", "/", nil)
+		require.Error(t, hook.NewWebHook(reg, c).ExecutePostRegistrationPostPersistHook(httptest.NewRecorder(), r, f, s))
+		require.Len(t, received, 0)
+	})
+
+	t.Run("case=sends older payload versions", func(t *testing.T) {
+		c, err := json.Marshal(&hook.WebHookConfig{URL: ts.URL, Method: "PUT", Headers: map[string]string{"X-Api-Key": "secret"}, PayloadVersion: hook.WebHookPayloadVersion1})
+		require.NoError(t, err)
+		h := hook.NewWebHook(reg, c)
+
+		bodies = nil
+		r := httptest.NewRequest("POST", "/", nil)
+		require.NoError(t, h.ExecutePostRegistrationPrePersistHook(httptest.NewRecorder(), r, f, i))
+		require.NoError(t, h.ExecuteLogoutPostHook(httptest.NewRecorder(), r, s))
+		require.Len(t, bodies, 2)
+
+		for _, body := range bodies {
+			for _, key := range []string{"payload_version", "flow", "timestamp"} {
+				assert.False(t, gjson.GetBytes(body, key).Exists(), "%s", body)
+			}
+		}
+		assert.Equal(t, hook.WebHookEventRegistration, gjson.GetBytes(bodies[0], "event").String(), "%s", bodies[0])
+		assert.Equal(t, i.ID.String(), gjson.GetBytes(bodies[0], "identity.id").String(), "%s", bodies[0])
+		assert.Equal(t, s.ID.String(), gjson.GetBytes(bodies[1], "session.id").String(), "%s", bodies[1])
+	})
+
+	t.Run("case=maps field errors of the web hook to the form", func(t *testing.T) {
+		status = http.StatusUnprocessableEntity
+		response = []byte(`{"errors":[{"instance_ptr":"#/traits/email","message":"A corporate email address is required."},{"message":"KYC check failed."}]}`)
+		t.Cleanup(func() {
+			status = http.StatusNoContent
+			response = nil
+		})
+
+		r := httptest.NewRequest("POST", "/", nil)
+		err := h.ExecutePostRegistrationPrePersistHook(httptest.NewRecorder(), r, &registration.Flow{Type: flow.TypeBrowser}, i)
+		require.Error(t, err)
+
+		f := form.NewHTMLForm("/")
+		require.NoError(t, f.ParseError(err))
+		require.Len(t, f.Fields, 1)
+		assert.Equal(t, "traits.email", f.Fields[0].Name)
+		assert.Equal(t, "A corporate email address is required.", f.Fields[0].Messages[0].Text)
+		require.Len(t, f.Messages, 1)
+		assert.Equal(t, "KYC check failed.", f.Messages[0].Text)
+	})
+}
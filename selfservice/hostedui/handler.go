@@ -0,0 +1,282 @@
+package hostedui
+
+import (
+	"bytes"
+	"embed"
+	"encoding/json"
+	"html/template"
+	"io/fs"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/julienschmidt/httprouter"
+	"github.com/pkg/errors"
+
+	"github.com/ory/herodot"
+	"github.com/ory/x/sqlcon"
+	"github.com/ory/x/urlx"
+
+	"github.com/ory/kratos/driver/config"
+	"github.com/ory/kratos/selfservice/errorx"
+	"github.com/ory/kratos/selfservice/flow/login"
+	"github.com/ory/kratos/selfservice/flow/logout"
+	"github.com/ory/kratos/selfservice/flow/recovery"
+	"github.com/ory/kratos/selfservice/flow/registration"
+	"github.com/ory/kratos/selfservice/flow/settings"
+	"github.com/ory/kratos/selfservice/flow/verification"
+	"github.com/ory/kratos/selfservice/form"
+	"github.com/ory/kratos/session"
+	"github.com/ory/kratos/text"
+	"github.com/ory/kratos/x"
+)
+
+const (
+	RouteBase         = "/ui"
+	RouteLogin        = RouteBase + "/login"
+	RouteRegistration = RouteBase + "/registration"
+	RouteRecovery     = RouteBase + "/recovery"
+	RouteVerification = RouteBase + "/verification"
+	RouteSettings     = RouteBase + "/settings"
+	RouteError        = RouteBase + "/error"
+	RouteStatic       = RouteBase + "/static/*filepath"
+)
+
+//go:embed .templates/*.html
+var templates embed.FS
+
+//go:embed .static/*
+var static embed.FS
+
+var pages = template.Must(template.New("").Funcs(template.FuncMap{"label": label}).
+	ParseFS(templates, ".templates/*.html"))
+
+type (
+	handlerDependencies interface {
+		config.Provider
+		x.ClockProvider
+		x.WriterProvider
+		session.ManagementProvider
+		errorx.PersistenceProvider
+		login.FlowPersistenceProvider
+		registration.FlowPersistenceProvider
+		recovery.FlowPersistenceProvider
+		verification.FlowPersistenceProvider
+		settings.FlowPersistenceProvider
+	}
+	HandlerProvider interface {
+		HostedUIHandler() *Handler
+	}
+	// Handler serves a minimal self-service UI which renders the flows of the public API.
+	Handler struct {
+		d handlerDependencies
+	}
+
+	page struct {
+		Theme    config.HostedUITheme
+		Title    string
+		Messages text.Messages
+		Forms    []methodForm
+		Errors   []pageError
+		Links    []pageLink
+	}
+	methodForm struct {
+		Method string `json:"method"`
+		Config struct {
+			Action    string        `json:"action"`
+			Method    string        `json:"method"`
+			Fields    form.Fields   `json:"fields"`
+			Messages  text.Messages `json:"messages"`
+			Providers []form.Field  `json:"providers"`
+		} `json:"config"`
+	}
+	pageError struct {
+		Message string `json:"message"`
+		Reason  string `json:"reason"`
+	}
+	pageLink struct {
+		Title string
+		Href  string
+	}
+)
+
+func NewHandler(d handlerDependencies) *Handler {
+	return &Handler{d: d}
+}
+
+func (h *Handler) RegisterPublicRoutes(public *x.RouterPublic) {
+	public.GET(RouteLogin, h.enabled(h.login))
+	public.GET(RouteRegistration, h.enabled(h.registration))
+	public.GET(RouteRecovery, h.enabled(h.recovery))
+	public.GET(RouteVerification, h.enabled(h.verification))
+	public.GET(RouteSettings, h.enabled(h.settings))
+	public.GET(RouteError, h.enabled(h.fetchError))
+
+	assets, err := fs.Sub(static, ".static")
+	if err != nil {
+		panic(err)
+	}
+	fileServer := http.StripPrefix(RouteBase+"/static", http.FileServer(http.FS(assets)))
+	public.GET(RouteStatic, h.enabled(func(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+		fileServer.ServeHTTP(w, r)
+	}))
+}
+
+func (h *Handler) enabled(next httprouter.Handle) httprouter.Handle {
+	return func(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+		if !h.d.Config(r.Context()).SelfServiceHostedUIEnabled() {
+			h.d.Writer().WriteError(w, r, errors.WithStack(herodot.ErrNotFound.WithReason("The hosted self-service UI is disabled.")))
+			return
+		}
+		next(w, r, ps)
+	}
+}
+
+func (h *Handler) login(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+	f, err := h.d.LoginFlowPersister().GetLoginFlow(r.Context(), x.ParseUUID(r.URL.Query().Get("flow")))
+	if err != nil {
+		h.restart(w, r, login.RouteInitBrowserFlow, err)
+		return
+	}
+
+	var links []pageLink
+	if h.d.Config(r.Context()).SelfServiceFlowRegistrationEnabled() {
+		links = append(links, h.link(r, "Create an account", registration.RouteInitBrowserFlow))
+	}
+	if h.d.Config(r.Context()).SelfServiceFlowRecoveryEnabled() {
+		links = append(links, h.link(r, "Recover your account", recovery.RouteInitBrowserFlow))
+	}
+	h.render(w, r, f.ExpiresAt, login.RouteInitBrowserFlow, &page{Title: "Sign in", Messages: f.Messages, Links: links}, f.Methods)
+}
+
+func (h *Handler) registration(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+	f, err := h.d.RegistrationFlowPersister().GetRegistrationFlow(r.Context(), x.ParseUUID(r.URL.Query().Get("flow")))
+	if err != nil {
+		h.restart(w, r, registration.RouteInitBrowserFlow, err)
+		return
+	}
+
+	links := []pageLink{h.link(r, "Sign in", login.RouteInitBrowserFlow)}
+	h.render(w, r, f.ExpiresAt, registration.RouteInitBrowserFlow, &page{Title: "Create an account", Messages: f.Messages, Links: links}, f.Methods)
+}
+
+func (h *Handler) recovery(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+	f, err := h.d.RecoveryFlowPersister().GetRecoveryFlow(r.Context(), x.ParseUUID(r.URL.Query().Get("flow")))
+	if err != nil {
+		h.restart(w, r, recovery.RouteInitBrowserFlow, err)
+		return
+	}
+
+	links := []pageLink{h.link(r, "Sign in", login.RouteInitBrowserFlow)}
+	h.render(w, r, f.ExpiresAt, recovery.RouteInitBrowserFlow, &page{Title: "Recover your account", Messages: f.Messages, Links: links}, f.Methods)
+}
+
+func (h *Handler) verification(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+	f, err := h.d.VerificationFlowPersister().GetVerificationFlow(r.Context(), x.ParseUUID(r.URL.Query().Get("flow")))
+	if err != nil {
+		h.restart(w, r, verification.RouteInitBrowserFlow, err)
+		return
+	}
+
+	links := []pageLink{h.link(r, "Sign in", login.RouteInitBrowserFlow)}
+	h.render(w, r, f.ExpiresAt, verification.RouteInitBrowserFlow, &page{Title: "Verify your account", Messages: f.Messages, Links: links}, f.Methods)
+}
+
+func (h *Handler) settings(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+	f, err := h.d.SettingsFlowPersister().GetSettingsFlow(r.Context(), x.ParseUUID(r.URL.Query().Get("flow")))
+	if err != nil {
+		h.restart(w, r, settings.RouteInitBrowserFlow, err)
+		return
+	}
+
+	// The settings flow contains the identity's traits and must only be shown to the identity itself. Restarting
+	// the flow asks the user to sign in.
+	sess, err := h.d.SessionManager().FetchFromRequest(r.Context(), r)
+	if err != nil || sess.Identity == nil || sess.Identity.ID != f.IdentityID {
+		http.Redirect(w, r, urlx.AppendPaths(h.d.Config(r.Context()).SelfPublicURL(r), settings.RouteInitBrowserFlow).String(), http.StatusSeeOther)
+		return
+	}
+
+	links := []pageLink{h.link(r, "Sign out", logout.RouteBrowser)}
+	h.render(w, r, f.ExpiresAt, settings.RouteInitBrowserFlow, &page{Title: "Account settings", Messages: f.Messages, Links: links}, f.Methods)
+}
+
+func (h *Handler) fetchError(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+	es, err := h.d.SelfServiceErrorPersister().Read(r.Context(), x.ParseUUID(r.URL.Query().Get("error")))
+	if err != nil {
+		h.d.Writer().WriteError(w, r, err)
+		return
+	}
+
+	p := &page{Title: "An error occurred", Links: []pageLink{h.link(r, "Sign in", login.RouteInitBrowserFlow)}}
+	if err := json.Unmarshal(es.Errors, &p.Errors); err != nil {
+		h.d.Writer().WriteError(w, r, errors.WithStack(err))
+		return
+	}
+	h.write(w, r, p)
+}
+
+// restart sends the browser to the flow's init endpoint if the flow is missing or does not exist anymore.
+func (h *Handler) restart(w http.ResponseWriter, r *http.Request, init string, err error) {
+	if !errors.Is(err, sqlcon.ErrNoRows) {
+		h.d.Writer().WriteError(w, r, err)
+		return
+	}
+	http.Redirect(w, r, urlx.AppendPaths(h.d.Config(r.Context()).SelfPublicURL(r), init).String(), http.StatusSeeOther)
+}
+
+// render writes the page with one form per flow method. Expired flows are restarted instead.
+func (h *Handler) render(w http.ResponseWriter, r *http.Request, expiresAt time.Time, init string, p *page, methods interface{}) {
+	if expiresAt.Before(h.d.Clock().Now()) {
+		http.Redirect(w, r, urlx.AppendPaths(h.d.Config(r.Context()).SelfPublicURL(r), init).String(), http.StatusSeeOther)
+		return
+	}
+
+	// The flow method types differ between flows but share their JSON representation.
+	raw, err := json.Marshal(methods)
+	if err != nil {
+		h.d.Writer().WriteError(w, r, errors.WithStack(err))
+		return
+	}
+	var forms map[string]methodForm
+	if err := json.Unmarshal(raw, &forms); err != nil {
+		h.d.Writer().WriteError(w, r, errors.WithStack(err))
+		return
+	}
+
+	for _, f := range forms {
+		p.Forms = append(p.Forms, f)
+	}
+	sort.Slice(p.Forms, func(i, j int) bool {
+		return p.Forms[i].Method < p.Forms[j].Method
+	})
+	h.write(w, r, p)
+}
+
+func (h *Handler) write(w http.ResponseWriter, r *http.Request, p *page) {
+	p.Theme = h.d.Config(r.Context()).SelfServiceHostedUITheme()
+
+	var b bytes.Buffer
+	if err := pages.ExecuteTemplate(&b, "page.html", p); err != nil {
+		h.d.Writer().WriteError(w, r, errors.WithStack(err))
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Header().Set("Cache-Control", "private, no-cache, no-store, must-revalidate")
+	_, _ = w.Write(b.Bytes())
+}
+
+func (h *Handler) link(r *http.Request, title, route string) pageLink {
+	return pageLink{Title: title, Href: urlx.AppendPaths(h.d.Config(r.Context()).SelfPublicURL(r), route).String()}
+}
+
+// label turns a field name such as "traits.name.first" into a label such as "First".
+func label(name string) string {
+	name = strings.ReplaceAll(name[strings.LastIndex(name, ".")+1:], "_", " ")
+	if len(name) == 0 {
+		return name
+	}
+	return strings.ToUpper(name[:1]) + name[1:]
+}
@@ -0,0 +1,135 @@
+package hostedui_test
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ory/herodot"
+	"github.com/ory/x/urlx"
+
+	"github.com/ory/kratos/driver/config"
+	"github.com/ory/kratos/identity"
+	"github.com/ory/kratos/internal"
+	"github.com/ory/kratos/internal/testhelpers"
+	"github.com/ory/kratos/selfservice/flow"
+	"github.com/ory/kratos/selfservice/flow/login"
+	"github.com/ory/kratos/selfservice/flow/settings"
+	"github.com/ory/kratos/selfservice/hostedui"
+	"github.com/ory/kratos/x"
+)
+
+func TestHandler(t *testing.T) {
+	conf, reg := internal.NewFastRegistryWithMocks(t)
+	conf.MustSet(config.ViperKeySelfServiceStrategyConfig+"."+string(identity.CredentialsTypePassword), map[string]interface{}{"enabled": true})
+	public, _ := testhelpers.NewKratosServer(t, reg)
+
+	hc := &http.Client{CheckRedirect: func(*http.Request, []*http.Request) error {
+		return http.ErrUseLastResponse
+	}}
+
+	newLoginFlow := func(t *testing.T, ttl time.Duration) *login.Flow {
+		req := &http.Request{URL: urlx.ParseOrPanic("/")}
+		f := login.NewFlow(x.SystemClock, x.RandomIDGenerator, ttl, "csrf_token_value", req, flow.TypeBrowser)
+		for _, s := range reg.LoginStrategies(context.Background()) {
+			require.NoError(t, s.PopulateLoginMethod(req, f))
+		}
+		require.NoError(t, reg.LoginFlowPersister().CreateLoginFlow(context.Background(), f))
+		return f
+	}
+
+	t.Run("case=is disabled by default", func(t *testing.T) {
+		conf.MustSet(config.ViperKeySelfServiceLoginUI, "https://www.ory.sh/login")
+		assert.Equal(t, "https://www.ory.sh/login", conf.SelfServiceFlowLoginUI().String())
+
+		res, _ := x.EasyGet(t, hc, public.URL+hostedui.RouteLogin)
+		assert.Equal(t, http.StatusNotFound, res.StatusCode)
+	})
+
+	conf.MustSet(config.ViperKeySelfServiceHostedUIEnabled, true)
+
+	t.Run("case=replaces the configured UI URLs", func(t *testing.T) {
+		for route, actual := range map[string]func() string{
+			hostedui.RouteLogin:        func() string { return conf.SelfServiceFlowLoginUI().String() },
+			hostedui.RouteRegistration: func() string { return conf.SelfServiceFlowRegistrationUI().String() },
+			hostedui.RouteRecovery:     func() string { return conf.SelfServiceFlowRecoveryUI().String() },
+			hostedui.RouteVerification: func() string { return conf.SelfServiceFlowVerificationUI().String() },
+			hostedui.RouteSettings:     func() string { return conf.SelfServiceFlowSettingsUI().String() },
+			hostedui.RouteError:        func() string { return conf.SelfServiceFlowErrorURL().String() },
+		} {
+			assert.Equal(t, public.URL+route, actual())
+		}
+	})
+
+	t.Run("case=renders the login flow", func(t *testing.T) {
+		f := newLoginFlow(t, time.Hour)
+
+		res, body := x.EasyGet(t, hc, public.URL+hostedui.RouteLogin+"?flow="+f.ID.String())
+		require.Equal(t, http.StatusOK, res.StatusCode, "%s", body)
+		assert.Contains(t, res.Header.Get("Content-Type"), "text/html")
+		assert.Contains(t, string(body), `<form class="method" id="password" action="`+public.URL)
+		assert.Contains(t, string(body), "flow="+f.ID.String())
+		assert.Contains(t, string(body), `name="csrf_token" value="csrf_token_value"`)
+		assert.Contains(t, string(body), `name="password"`)
+	})
+
+	t.Run("case=renders the theme", func(t *testing.T) {
+		conf.MustSet(config.ViperKeySelfServiceHostedUITheme, map[string]interface{}{
+			"logo_url": "https://www.ory.sh/logo.svg",
+			"css_url":  "https://www.ory.sh/kratos.css",
+			"colors":   map[string]interface{}{"primary": "#ff0000"},
+			"footer_links": []map[string]interface{}{
+				{"title": "Privacy Policy", "url": "https://www.ory.sh/privacy"},
+			},
+		})
+		t.Cleanup(func() {
+			conf.MustSet(config.ViperKeySelfServiceHostedUITheme, map[string]interface{}{})
+		})
+
+		f := newLoginFlow(t, time.Hour)
+		res, body := x.EasyGet(t, hc, public.URL+hostedui.RouteLogin+"?flow="+f.ID.String())
+		require.Equal(t, http.StatusOK, res.StatusCode, "%s", body)
+		assert.Contains(t, string(body), `<img class="logo" src="https://www.ory.sh/logo.svg"`)
+		assert.Contains(t, string(body), `<link rel="stylesheet" href="https://www.ory.sh/kratos.css">`)
+		assert.Contains(t, string(body), "--primary: #ff0000;")
+		assert.Contains(t, string(body), `<a href="https://www.ory.sh/privacy">Privacy Policy</a>`)
+	})
+
+	t.Run("case=restarts missing and expired flows", func(t *testing.T) {
+		for _, id := range []string{"", x.NewUUID().String(), newLoginFlow(t, -time.Minute).ID.String()} {
+			res, _ := x.EasyGet(t, hc, public.URL+hostedui.RouteLogin+"?flow="+id)
+			assert.Equal(t, http.StatusSeeOther, res.StatusCode)
+			assert.Equal(t, public.URL+login.RouteInitBrowserFlow, res.Header.Get("Location"))
+		}
+	})
+
+	t.Run("case=requires the session of the settings flow", func(t *testing.T) {
+		req := &http.Request{URL: urlx.ParseOrPanic("/")}
+		f := settings.NewFlow(x.SystemClock, x.RandomIDGenerator, time.Hour, req, &identity.Identity{ID: x.NewUUID()}, flow.TypeBrowser)
+		require.NoError(t, reg.SettingsFlowPersister().CreateSettingsFlow(context.Background(), f))
+
+		res, _ := x.EasyGet(t, hc, public.URL+hostedui.RouteSettings+"?flow="+f.ID.String())
+		assert.Equal(t, http.StatusSeeOther, res.StatusCode)
+		assert.Equal(t, public.URL+settings.RouteInitBrowserFlow, res.Header.Get("Location"))
+	})
+
+	t.Run("case=renders errors", func(t *testing.T) {
+		id, err := reg.SelfServiceErrorPersister().Add(context.Background(), "", herodot.ErrBadRequest.WithReason("the reason is <b>bold</b>"))
+		require.NoError(t, err)
+
+		res, body := x.EasyGet(t, hc, public.URL+hostedui.RouteError+"?error="+id.String())
+		require.Equal(t, http.StatusOK, res.StatusCode, "%s", body)
+		assert.Contains(t, string(body), "the reason is &lt;b&gt;bold&lt;/b&gt;")
+	})
+
+	t.Run("case=serves static assets", func(t *testing.T) {
+		res, body := x.EasyGet(t, hc, public.URL+hostedui.RouteBase+"/static/style.css")
+		require.Equal(t, http.StatusOK, res.StatusCode)
+		assert.Contains(t, res.Header.Get("Content-Type"), "text/css")
+		assert.NotEmpty(t, body)
+	})
+}
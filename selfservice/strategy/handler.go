@@ -10,7 +10,10 @@ import (
 	"github.com/ory/kratos/x"
 )
 
-const EndpointDisabledMessage = "This endpoint was disabled by system administrator. Please check your url or contact the system administrator to enable it."
+const (
+	EndpointDisabledMessage = "This endpoint was disabled by system administrator. Please check your url or contact the system administrator to enable it."
+	FlowDisabledMessage     = "The %s flow was disabled by system administrator. Please check your url or contact the system administrator to enable it."
+)
 
 type disabledChecker interface {
 	config.Provider
@@ -25,24 +28,48 @@ func disabledWriter(c disabledChecker, enabled bool, wrap httprouter.Handle, w h
 	wrap(w, r, ps)
 }
 
+// flowDisabledWriter responds with an error naming the flow if the flow itself is disabled and otherwise behaves
+// like disabledWriter for the strategy, taking into account the flows the strategy is enabled for.
+func flowDisabledWriter(c disabledChecker, flow string, enabled bool, strategy string, wrap httprouter.Handle, w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	if !enabled {
+		c.Writer().WriteError(w, r, herodot.ErrNotFound.WithReasonf(FlowDisabledMessage, flow))
+		return
+	}
+	disabledWriter(c, c.Config(r.Context()).SelfServiceStrategy(strategy).EnabledFor(flow), wrap, w, r, ps)
+}
+
 func IsDisabled(c disabledChecker, strategy string, wrap httprouter.Handle) httprouter.Handle {
 	return func(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
 		disabledWriter(c, c.Config(r.Context()).SelfServiceStrategy(strategy).Enabled, wrap, w, r, ps)
 	}
 }
 
+func IsLoginDisabled(c disabledChecker, strategy string, wrap httprouter.Handle) httprouter.Handle {
+	return func(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+		flowDisabledWriter(c, config.FlowLogin, c.Config(r.Context()).SelfServiceFlowLoginEnabled(), strategy, wrap, w, r, ps)
+	}
+}
+
+func IsRegistrationDisabled(c disabledChecker, strategy string, wrap httprouter.Handle) httprouter.Handle {
+	return func(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+		flowDisabledWriter(c, config.FlowRegistration, c.Config(r.Context()).SelfServiceFlowRegistrationEnabled(), strategy, wrap, w, r, ps)
+	}
+}
+
+func IsSettingsDisabled(c disabledChecker, strategy string, wrap httprouter.Handle) httprouter.Handle {
+	return func(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+		flowDisabledWriter(c, config.FlowSettings, c.Config(r.Context()).SelfServiceFlowSettingsEnabled(), strategy, wrap, w, r, ps)
+	}
+}
+
 func IsRecoveryDisabled(c disabledChecker, strategy string, wrap httprouter.Handle) httprouter.Handle {
 	return func(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
-		disabledWriter(c,
-			c.Config(r.Context()).SelfServiceStrategy(strategy).Enabled && c.Config(r.Context()).SelfServiceFlowRecoveryEnabled(),
-			wrap, w, r, ps)
+		flowDisabledWriter(c, config.FlowRecovery, c.Config(r.Context()).SelfServiceFlowRecoveryEnabled(), strategy, wrap, w, r, ps)
 	}
 }
 
 func IsVerificationDisabled(c disabledChecker, strategy string, wrap httprouter.Handle) httprouter.Handle {
 	return func(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
-		disabledWriter(c,
-			c.Config(r.Context()).SelfServiceStrategy(strategy).Enabled && c.Config(r.Context()).SelfServiceFlowVerificationEnabled(),
-			wrap, w, r, ps)
+		flowDisabledWriter(c, config.FlowVerification, c.Config(r.Context()).SelfServiceFlowVerificationEnabled(), strategy, wrap, w, r, ps)
 	}
 }
@@ -2,6 +2,9 @@ package link
 
 import (
 	"context"
+	"time"
+
+	"github.com/gofrs/uuid"
 )
 
 type (
@@ -9,6 +12,13 @@ type (
 		CreateRecoveryToken(ctx context.Context, token *RecoveryToken) error
 		UseRecoveryToken(ctx context.Context, token string) (*RecoveryToken, error)
 		DeleteRecoveryToken(ctx context.Context, token string) error
+
+		// ExpireRecoveryTokens expires all unused recovery tokens of the identity's recovery addresses.
+		ExpireRecoveryTokens(ctx context.Context, identityID uuid.UUID) error
+
+		// HasRecentRecoveryToken returns true if an unused and unexpired recovery token was issued for the
+		// recovery address after the given time.
+		HasRecentRecoveryToken(ctx context.Context, addressID uuid.UUID, since time.Time) (bool, error)
 	}
 
 	RecoveryTokenPersistenceProvider interface {
@@ -19,6 +29,13 @@ type (
 		CreateVerificationToken(ctx context.Context, token *VerificationToken) error
 		UseVerificationToken(ctx context.Context, token string) (*VerificationToken, error)
 		DeleteVerificationToken(ctx context.Context, token string) error
+
+		// ExpireVerificationTokens expires all unused verification tokens of the identity's verifiable addresses.
+		ExpireVerificationTokens(ctx context.Context, identityID uuid.UUID) error
+
+		// HasRecentVerificationToken returns true if an unused and unexpired verification token was issued for the
+		// verifiable address after the given time.
+		HasRecentVerificationToken(ctx context.Context, addressID uuid.UUID, since time.Time) (bool, error)
 	}
 
 	VerificationTokenPersistenceProvider interface {
@@ -82,6 +82,48 @@ func TestPersister(ctx context.Context, conf *config.Config, p interface {
 				require.Error(t, err)
 			})
 
+			t.Run("case=should expire all recovery tokens of an identity", func(t *testing.T) {
+				expected := newRecoveryToken(t, "expire-user@ory.sh")
+				expected.ExpiresAt = time.Now().Add(time.Hour)
+				require.NoError(t, p.CreateRecoveryToken(ctx, expected))
+
+				other := newRecoveryToken(t, "not-expired-user@ory.sh")
+				other.ExpiresAt = time.Now().Add(time.Hour)
+				require.NoError(t, p.CreateRecoveryToken(ctx, other))
+
+				require.NoError(t, p.ExpireRecoveryTokens(ctx, expected.RecoveryAddress.IdentityID))
+
+				actual, err := p.UseRecoveryToken(ctx, expected.Token)
+				require.NoError(t, err)
+				assert.Error(t, actual.Valid(x.SystemClock))
+
+				actual, err = p.UseRecoveryToken(ctx, other.Token)
+				require.NoError(t, err)
+				assert.NoError(t, actual.Valid(x.SystemClock))
+			})
+
+			t.Run("case=should find recent recovery tokens", func(t *testing.T) {
+				token := newRecoveryToken(t, "recent-user@ory.sh")
+				token.ExpiresAt = time.Now().Add(time.Hour)
+				require.NoError(t, p.CreateRecoveryToken(ctx, token))
+				addressID := token.RecoveryAddress.ID
+
+				found, err := p.HasRecentRecoveryToken(ctx, addressID, time.Now().Add(-time.Minute))
+				require.NoError(t, err)
+				assert.True(t, found)
+
+				found, err = p.HasRecentRecoveryToken(ctx, addressID, time.Now().Add(time.Minute))
+				require.NoError(t, err)
+				assert.False(t, found)
+
+				_, err = p.UseRecoveryToken(ctx, token.Token)
+				require.NoError(t, err)
+
+				found, err = p.HasRecentRecoveryToken(ctx, addressID, time.Now().Add(-time.Minute))
+				require.NoError(t, err)
+				assert.False(t, found, "used tokens are not considered")
+			})
+
 		})
 		t.Run("token=verification", func(t *testing.T) {
 
@@ -136,6 +178,48 @@ func TestPersister(ctx context.Context, conf *config.Config, p interface {
 				_, err = p.UseVerificationToken(ctx, expected.Token)
 				require.Error(t, err)
 			})
+
+			t.Run("case=should expire all verification tokens of an identity", func(t *testing.T) {
+				expected := newVerificationToken(t, "expire-user@ory.sh")
+				expected.ExpiresAt = time.Now().Add(time.Hour)
+				require.NoError(t, p.CreateVerificationToken(ctx, expected))
+
+				other := newVerificationToken(t, "not-expired-user@ory.sh")
+				other.ExpiresAt = time.Now().Add(time.Hour)
+				require.NoError(t, p.CreateVerificationToken(ctx, other))
+
+				require.NoError(t, p.ExpireVerificationTokens(ctx, expected.VerifiableAddress.IdentityID))
+
+				actual, err := p.UseVerificationToken(ctx, expected.Token)
+				require.NoError(t, err)
+				assert.Error(t, actual.Valid(x.SystemClock))
+
+				actual, err = p.UseVerificationToken(ctx, other.Token)
+				require.NoError(t, err)
+				assert.NoError(t, actual.Valid(x.SystemClock))
+			})
+
+			t.Run("case=should find recent verification tokens", func(t *testing.T) {
+				token := newVerificationToken(t, "recent-user@ory.sh")
+				token.ExpiresAt = time.Now().Add(time.Hour)
+				require.NoError(t, p.CreateVerificationToken(ctx, token))
+				addressID := token.VerifiableAddress.ID
+
+				found, err := p.HasRecentVerificationToken(ctx, addressID, time.Now().Add(-time.Minute))
+				require.NoError(t, err)
+				assert.True(t, found)
+
+				found, err = p.HasRecentVerificationToken(ctx, addressID, time.Now().Add(time.Minute))
+				require.NoError(t, err)
+				assert.False(t, found)
+
+				_, err = p.UseVerificationToken(ctx, token.Token)
+				require.NoError(t, err)
+
+				found, err = p.HasRecentVerificationToken(ctx, addressID, time.Now().Add(-time.Minute))
+				require.NoError(t, err)
+				assert.False(t, found, "used tokens are not considered")
+			})
 		})
 	}
 }
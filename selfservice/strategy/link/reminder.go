@@ -0,0 +1,160 @@
+package link
+
+import (
+	"context"
+	"time"
+
+	"github.com/gofrs/uuid"
+	"github.com/pkg/errors"
+
+	"github.com/ory/x/sqlcon"
+
+	"github.com/ory/kratos/driver/config"
+	"github.com/ory/kratos/identity"
+	"github.com/ory/kratos/x"
+)
+
+const reminderPageSize = 500
+
+type (
+	reminderDependencies interface {
+		config.Provider
+		identity.PrivilegedPoolProvider
+		SenderProvider
+		VerificationTokenPersistenceProvider
+		x.ClockProvider
+		x.IDGeneratorProvider
+		x.LoggingProvider
+	}
+	VerificationReminderProvider interface {
+		VerificationReminder() *VerificationReminder
+	}
+	// VerificationReminder re-sends verification links to email addresses which remain unverified.
+	VerificationReminder struct {
+		r reminderDependencies
+	}
+
+	// VerificationReminderReport lists the addresses a reminder was sent to.
+	VerificationReminderReport struct {
+		// DryRun is true if the addresses were only reported but not reminded.
+		DryRun bool `json:"dry_run"`
+
+		// Reminded are the IDs of the verifiable addresses which were reminded.
+		Reminded []uuid.UUID `json:"reminded"`
+	}
+)
+
+func NewVerificationReminder(r reminderDependencies) *VerificationReminder {
+	return &VerificationReminder{r: r}
+}
+
+// Remind sends the reminders which are due once. If several reminders are due for an address, for example
+// because no reminders were sent for a while, only one reminder is sent. If dryRun is true, the addresses are
+// reported without being reminded.
+func (v *VerificationReminder) Remind(ctx context.Context, dryRun bool) (*VerificationReminderReport, error) {
+	c := v.r.Config(ctx)
+	conf := c.SelfServiceFlowVerificationReminders()
+	report := &VerificationReminderReport{DryRun: dryRun, Reminded: []uuid.UUID{}}
+	if len(conf.SendAfter) == 0 || !c.SelfServiceFlowVerificationEnabled() {
+		return report, nil
+	}
+
+	now := v.r.Clock().Now().UTC()
+	addresses, err := v.list(ctx, conf.CreatedAfter, now.Add(-conf.SendAfter[0]), len(conf.SendAfter))
+	if err != nil {
+		return nil, err
+	}
+
+	for k := range addresses {
+		if conf.MaxPerRun > 0 && len(report.Reminded) >= conf.MaxPerRun {
+			v.r.Logger().WithField("max_per_run", conf.MaxPerRun).Info("Reached the maximum number of verification reminders per run, further reminders are sent in the next run.")
+			break
+		}
+
+		address := &addresses[k]
+		due := dueReminders(conf.SendAfter, now.Sub(address.CreatedAt))
+		if due <= address.RemindersSent {
+			continue
+		}
+
+		i, err := v.r.PrivilegedIdentityPool().GetIdentity(ctx, address.IdentityID)
+		if errors.Is(err, sqlcon.ErrNoRows) {
+			continue
+		} else if err != nil {
+			return nil, err
+		}
+
+		if !i.IsActive() {
+			v.r.Logger().WithField("identity_id", i.ID).Debug("Not sending verification reminder because the identity is inactive.")
+			continue
+		} else if !i.CommunicationPreferences.AcceptsVerificationReminders() {
+			v.r.Logger().WithField("identity_id", i.ID).Debug("Not sending verification reminder because the identity opted out of verification reminders.")
+			continue
+		}
+
+		if !dryRun {
+			if err := v.remind(ctx, i, address, due, due == len(conf.SendAfter), now); err != nil {
+				return nil, err
+			}
+		}
+		report.Reminded = append(report.Reminded, address.ID)
+	}
+
+	return report, nil
+}
+
+// Watch sends due reminders in the configured interval until the context is canceled.
+func (v *VerificationReminder) Watch(ctx context.Context) error {
+	return x.RunPeriodically(ctx, func(ctx context.Context) time.Duration {
+		c := v.r.Config(ctx).SelfServiceFlowVerificationReminders()
+		report, err := v.Remind(ctx, c.DryRun)
+		if err != nil {
+			v.r.Logger().WithError(err).Error("Unable to send verification reminders.")
+		} else {
+			v.r.Logger().
+				WithField("dry_run", report.DryRun).
+				WithField("reminded", report.Reminded).
+				Info("Sent verification reminders.")
+		}
+		return c.Interval
+	})
+}
+
+func (v *VerificationReminder) remind(ctx context.Context, i *identity.Identity, address *identity.VerifiableAddress, reminder int, last bool, now time.Time) error {
+	c := v.r.Config(ctx)
+	token := NewVerificationToken(v.r.Clock(), v.r.IDGenerator(), address, c.SelfServiceFlowVerificationRequestLifespan(), c.SelfServiceFlowVerificationToken())
+	if err := v.r.VerificationTokenPersister().CreateVerificationToken(ctx, token); err != nil {
+		return err
+	}
+
+	if err := v.r.LinkSender().SendVerificationReminderTo(ctx, i, address, token, reminder, last); err != nil {
+		return err
+	}
+
+	return v.r.PrivilegedIdentityPool().MarkVerificationRemindersSent(ctx, address.ID, reminder, now)
+}
+
+// list returns all addresses which may be due for a reminder. All pages are loaded before reminders are sent
+// because sending reminders changes which addresses match.
+func (v *VerificationReminder) list(ctx context.Context, createdAfter, createdBefore time.Time, maxReminders int) ([]identity.VerifiableAddress, error) {
+	var as []identity.VerifiableAddress
+	if err := x.ForEachPage(reminderPageSize, func(page, perPage int) (int, error) {
+		p, err := v.r.PrivilegedIdentityPool().ListVerificationReminderCandidates(ctx, createdAfter, createdBefore, maxReminders, page, perPage)
+		as = append(as, p...)
+		return len(p), err
+	}); err != nil {
+		return nil, err
+	}
+	return as, nil
+}
+
+// dueReminders returns how many reminders are due for an address which has been unverified for the given period.
+func dueReminders(sendAfter []time.Duration, unverifiedFor time.Duration) int {
+	var due int
+	for _, after := range sendAfter {
+		if unverifiedFor >= after {
+			due++
+		}
+	}
+	return due
+}
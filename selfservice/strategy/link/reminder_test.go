@@ -0,0 +1,140 @@
+package link_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/gofrs/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ory/x/urlx"
+
+	"github.com/ory/kratos/courier"
+	"github.com/ory/kratos/driver/config"
+	"github.com/ory/kratos/identity"
+	"github.com/ory/kratos/internal"
+	"github.com/ory/kratos/selfservice/strategy/link"
+	"github.com/ory/kratos/x"
+)
+
+func TestVerificationReminder(t *testing.T) {
+	conf, reg := internal.NewFastRegistryWithMocks(t)
+	conf.MustSet(config.ViperKeyDefaultIdentitySchemaURL, "file://./stub/default.schema.json")
+	conf.MustSet(config.ViperKeyPublicBaseURL, "https://www.ory.sh/")
+	conf.MustSet(config.ViperKeyCourierSMTPURL, "smtp://foo@bar@dev.null/")
+	conf.MustSet(config.ViperKeySelfServiceVerificationEnabled, true)
+	conf.MustSet(config.ViperKeySelfServiceVerificationRemindersSendAfter, []string{"72h", "24h"})
+
+	clock := x.NewFakeClock(time.Now().UTC())
+	reg.WithClock(clock)
+	ctx := context.Background()
+
+	create := func(t *testing.T, email string, prefs *identity.CommunicationPreferences) *identity.VerifiableAddress {
+		i := identity.NewIdentity(config.DefaultIdentityTraitsSchemaID)
+		i.Traits = identity.Traits(`{"email":"` + email + `"}`)
+		i.CommunicationPreferences = prefs
+		require.NoError(t, reg.IdentityManager().Create(ctx, i))
+		require.Len(t, i.VerifiableAddresses, 1)
+		return &i.VerifiableAddresses[0]
+	}
+
+	remind := func(t *testing.T, dryRun bool, expected ...uuid.UUID) []courier.Message {
+		report, err := reg.VerificationReminder().Remind(ctx, dryRun)
+		require.NoError(t, err)
+		assert.Equal(t, dryRun, report.DryRun)
+		assert.ElementsMatch(t, expected, report.Reminded)
+
+		messages, err := reg.CourierPersister().NextMessages(ctx, 10)
+		if dryRun || len(expected) == 0 {
+			require.ErrorIs(t, err, courier.ErrQueueEmpty)
+			return nil
+		}
+		require.NoError(t, err)
+		require.Len(t, messages, len(expected))
+		for _, m := range messages {
+			assert.Equal(t, "verification_reminder", m.TemplateType)
+			assert.Contains(t, m.Body, urlx.AppendPaths(conf.SelfPublicURL(nil), link.RouteVerification).String()+"?token=")
+		}
+		return messages
+	}
+
+	unverified := create(t, "reminder-unverified@ory.sh", nil)
+	create(t, "reminder-opted-out@ory.sh", &identity.CommunicationPreferences{NoVerificationReminders: true})
+	verified := create(t, "reminder-verified@ory.sh", nil)
+	verified.Verified = true
+	verified.Status = identity.VerifiableAddressStatusCompleted
+	require.NoError(t, reg.PrivilegedIdentityPool().UpdateVerifiableAddress(ctx, verified))
+
+	t.Run("case=no reminder is due", func(t *testing.T) {
+		remind(t, false)
+	})
+
+	clock.Add(25 * time.Hour)
+
+	t.Run("case=dry run does not send reminders", func(t *testing.T) {
+		remind(t, true, unverified.ID)
+	})
+
+	t.Run("case=sends the first reminder", func(t *testing.T) {
+		messages := remind(t, false, unverified.ID)
+		assert.Equal(t, "reminder-unverified@ory.sh", messages[0].Recipient)
+		assert.NotContains(t, messages[0].Body, "last reminder")
+	})
+
+	t.Run("case=reminders are only sent once", func(t *testing.T) {
+		remind(t, false)
+	})
+
+	clock.Add(48 * time.Hour)
+
+	t.Run("case=sends the last reminder", func(t *testing.T) {
+		messages := remind(t, false, unverified.ID)
+		assert.Contains(t, messages[0].Body, "last reminder")
+		remind(t, false)
+	})
+
+	t.Run("case=sends one reminder if several are due and caps reminders per run", func(t *testing.T) {
+		conf.MustSet(config.ViperKeySelfServiceVerificationRemindersMaxPerRun, 1)
+		t.Cleanup(func() {
+			conf.MustSet(config.ViperKeySelfServiceVerificationRemindersMaxPerRun, 0)
+		})
+
+		first := create(t, "reminder-capped-1@ory.sh", nil)
+		second := create(t, "reminder-capped-2@ory.sh", nil)
+		clock.Add(73 * time.Hour)
+
+		report, err := reg.VerificationReminder().Remind(ctx, true)
+		require.NoError(t, err)
+		require.Len(t, report.Reminded, 1)
+		reminded, other := first, second
+		if report.Reminded[0] == second.ID {
+			reminded, other = second, first
+		}
+
+		messages := remind(t, false, reminded.ID)
+		assert.Contains(t, messages[0].Body, "last reminder")
+		remind(t, false, other.ID)
+		remind(t, false)
+	})
+
+	t.Run("case=skips addresses which were added before created_after", func(t *testing.T) {
+		legacy := create(t, "reminder-legacy@ory.sh", nil)
+		conf.MustSet(config.ViperKeySelfServiceVerificationRemindersCreatedAfter, legacy.CreatedAt.Add(time.Second).Format(time.RFC3339))
+		t.Cleanup(func() {
+			conf.MustSet(config.ViperKeySelfServiceVerificationRemindersCreatedAfter, nil)
+		})
+		clock.Add(73 * time.Hour)
+
+		remind(t, false)
+	})
+
+	t.Run("case=reminders are disabled", func(t *testing.T) {
+		create(t, "reminder-disabled@ory.sh", nil)
+		clock.Add(73 * time.Hour)
+
+		conf.MustSet(config.ViperKeySelfServiceVerificationRemindersSendAfter, []string{})
+		remind(t, false)
+	})
+}
@@ -4,7 +4,9 @@ import (
 	"context"
 	"net/http"
 	"net/url"
+	"time"
 
+	"github.com/gofrs/uuid"
 	"github.com/pkg/errors"
 
 	"github.com/ory/x/errorsx"
@@ -25,11 +27,14 @@ type (
 		courier.Provider
 		identity.PoolProvider
 		identity.ManagementProvider
+		x.ClockProvider
+		x.IDGeneratorProvider
 		x.LoggingProvider
 		config.Provider
 
 		VerificationTokenPersistenceProvider
 		RecoveryTokenPersistenceProvider
+		TestTokensProvider
 	}
 
 	SenderProvider interface {
@@ -56,26 +61,50 @@ func (s *Sender) SendRecoveryLink(ctx context.Context, r *http.Request, f *recov
 		WithSensitiveField("address", to).
 		Debug("Preparing verification code.")
 
-	address, err := s.r.IdentityPool().FindRecoveryAddressByValue(ctx, identity.RecoveryAddressTypeEmail, to)
+	window := s.r.Config(ctx).SelfServiceFlowRecoveryDeduplicationWindow()
+	address, err := s.findRecoveryAddress(ctx, to)
 	if err != nil {
-		if err := s.send(ctx, string(via), templates.NewRecoveryInvalid(s.r.Config(ctx), &templates.RecoveryInvalidModel{To: to})); err != nil {
+		if err := s.sendOnce(ctx, string(via), window, templates.NewRecoveryInvalid(s.r.Config(ctx), &templates.RecoveryInvalidModel{To: to})); err != nil {
 			return err
 		}
 		return errors.Cause(ErrUnknownAddress)
 	}
 
-	token := NewSelfServiceRecoveryToken(address, f)
+	if window > 0 {
+		if recent, err := s.r.RecoveryTokenPersister().HasRecentRecoveryToken(ctx, address.ID, s.r.Clock().Now().Add(-window)); err != nil {
+			return err
+		} else if recent {
+			s.r.Audit().
+				WithField("via", via).
+				WithField("identity_id", address.IdentityID).
+				WithSensitiveField("email_address", address.Value).
+				Info("Not sending recovery email because a recovery link was sent to this address recently.")
+			return nil
+		}
+	}
+
+	token := NewSelfServiceRecoveryToken(s.r.Clock(), s.r.IDGenerator(), address, f, s.r.Config(ctx).SelfServiceFlowRecoveryToken())
 	if err := s.r.RecoveryTokenPersister().CreateRecoveryToken(ctx, token); err != nil {
 		return err
 	}
 
-	if err := s.SendRecoveryTokenTo(ctx, address, token); err != nil {
+	if err := s.SendRecoveryTokenTo(ctx, s.recipient(ctx, address.IdentityID), address, token); err != nil {
 		return err
 	}
 
 	return nil
 }
 
+// findRecoveryAddress looks up the address using the identifier normalization rules and falls back to the
+// address as entered for addresses which were stored before the rules changed.
+func (s *Sender) findRecoveryAddress(ctx context.Context, to string) (*identity.RecoveryAddress, error) {
+	address, err := s.r.IdentityPool().FindRecoveryAddressByValue(ctx, identity.RecoveryAddressTypeEmail, identity.NormalizeIdentifier(s.r.Config(ctx).IdentifierNormalization(), to))
+	if errorsx.Cause(err) == sqlcon.ErrNoRows {
+		return s.r.IdentityPool().FindRecoveryAddressByValue(ctx, identity.RecoveryAddressTypeEmail, to)
+	}
+	return address, err
+}
+
 // SendVerificationLink sends a verification link to the specified address. If the address does not exist in the store, an email is
 // still being sent to prevent account enumeration attacks. In that case, this function returns the ErrUnknownAddress
 // error.
@@ -85,14 +114,15 @@ func (s *Sender) SendVerificationLink(ctx context.Context, f *verification.Flow,
 		WithSensitiveField("address", to).
 		Debug("Preparing verification code.")
 
-	address, err := s.r.IdentityPool().FindVerifiableAddressByValue(ctx, via, to)
+	window := s.r.Config(ctx).SelfServiceFlowVerificationDeduplicationWindow()
+	address, err := s.findVerifiableAddress(ctx, via, to)
 	if err != nil {
 		if errorsx.Cause(err) == sqlcon.ErrNoRows {
 			s.r.Audit().
 				WithField("via", via).
 				WithSensitiveField("email_address", address).
 				Info("Sending out invalid verification email because address is unknown.")
-			if err := s.send(ctx, string(via), templates.NewVerificationInvalid(s.r.Config(ctx), &templates.VerificationInvalidModel{To: to})); err != nil {
+			if err := s.sendOnce(ctx, string(via), window, templates.NewVerificationInvalid(s.r.Config(ctx), &templates.VerificationInvalidModel{To: to})); err != nil {
 				return err
 			}
 			return errors.Cause(ErrUnknownAddress)
@@ -100,18 +130,42 @@ func (s *Sender) SendVerificationLink(ctx context.Context, f *verification.Flow,
 		return err
 	}
 
-	token := NewSelfServiceVerificationToken(address, f)
+	if window > 0 {
+		if recent, err := s.r.VerificationTokenPersister().HasRecentVerificationToken(ctx, address.ID, s.r.Clock().Now().Add(-window)); err != nil {
+			return err
+		} else if recent {
+			s.r.Audit().
+				WithField("via", via).
+				WithField("identity_id", address.IdentityID).
+				WithSensitiveField("email_address", address.Value).
+				Info("Not sending verification email because a verification link was sent to this address recently.")
+			return nil
+		}
+	}
+
+	token := NewSelfServiceVerificationToken(s.r.Clock(), s.r.IDGenerator(), address, f, s.r.Config(ctx).SelfServiceFlowVerificationToken())
 	if err := s.r.VerificationTokenPersister().CreateVerificationToken(ctx, token); err != nil {
 		return err
 	}
 
-	if err := s.SendVerificationTokenTo(ctx, address, token); err != nil {
+	if err := s.SendVerificationTokenTo(ctx, s.recipient(ctx, address.IdentityID), address, token); err != nil {
 		return err
 	}
 	return nil
 }
 
-func (s *Sender) SendRecoveryTokenTo(ctx context.Context, address *identity.RecoveryAddress, token *RecoveryToken) error {
+// findVerifiableAddress behaves like findRecoveryAddress for verifiable addresses.
+func (s *Sender) findVerifiableAddress(ctx context.Context, via identity.VerifiableAddressType, to string) (*identity.VerifiableAddress, error) {
+	address, err := s.r.IdentityPool().FindVerifiableAddressByValue(ctx, via, identity.NormalizeIdentifier(s.r.Config(ctx).IdentifierNormalization(), to))
+	if errorsx.Cause(err) == sqlcon.ErrNoRows {
+		return s.r.IdentityPool().FindVerifiableAddressByValue(ctx, via, to)
+	}
+	return address, err
+}
+
+// SendRecoveryTokenTo sends the recovery link to the address of the identity, respecting the identity's communication
+// preferences. The identity is available to the template as `.Identity` and may be nil.
+func (s *Sender) SendRecoveryTokenTo(ctx context.Context, i *identity.Identity, address *identity.RecoveryAddress, token *RecoveryToken) error {
 	s.r.Audit().
 		WithField("via", address.Via).
 		WithField("identity_id", address.IdentityID).
@@ -119,13 +173,29 @@ func (s *Sender) SendRecoveryTokenTo(ctx context.Context, address *identity.Reco
 		WithSensitiveField("email_address", address.Value).
 		WithSensitiveField("recovery_link_token", token.Token).
 		Info("Sending out recovery email with recovery link.")
-	return s.send(ctx, string(address.Via), templates.NewRecoveryValid(s.r.Config(ctx),
-		&templates.RecoveryValidModel{To: address.Value, RecoveryURL: urlx.CopyWithQuery(
-			urlx.AppendPaths(s.r.Config(ctx).SelfPublicURL(nil), RouteRecovery),
-			url.Values{"token": {token.Token}}).String()}))
+
+	link := urlx.CopyWithQuery(
+		urlx.AppendPaths(s.r.Config(ctx).SelfPublicURL(nil), RouteRecovery),
+		url.Values{"token": {token.Token}}).String()
+	model, err := templates.IdentityModel(i)
+	if err != nil {
+		return err
+	}
+
+	if err := s.send(ctx, string(address.Via), communicationPreferences(i), templates.NewRecoveryValid(s.r.Config(ctx),
+		&templates.RecoveryValidModel{To: address.Value, RecoveryURL: link, RecoveryToken: token.Token, Identity: model})); err != nil {
+		return err
+	}
+
+	if s.r.Config(ctx).IsTestTokenEndpointEnabled() {
+		s.r.LinkTestTokens().RecordRecoveryToken(address.Value, token.Token, link)
+	}
+	return nil
 }
 
-func (s *Sender) SendVerificationTokenTo(ctx context.Context, address *identity.VerifiableAddress, token *VerificationToken) error {
+// SendVerificationTokenTo sends the verification link to the address of the identity, respecting the identity's
+// communication preferences. The identity is available to the template as `.Identity` and may be nil.
+func (s *Sender) SendVerificationTokenTo(ctx context.Context, i *identity.Identity, address *identity.VerifiableAddress, token *VerificationToken) error {
 	s.r.Audit().
 		WithField("via", address.Via).
 		WithField("identity_id", address.IdentityID).
@@ -134,16 +204,96 @@ func (s *Sender) SendVerificationTokenTo(ctx context.Context, address *identity.
 		WithSensitiveField("verification_link_token", token.Token).
 		Info("Sending out verification email with verification link.")
 
-	return s.send(ctx, string(address.Via), templates.NewVerificationValid(s.r.Config(ctx),
-		&templates.VerificationValidModel{To: address.Value, VerificationURL: urlx.CopyWithQuery(
-			urlx.AppendPaths(s.r.Config(ctx).SelfPublicURL(nil), RouteVerification),
-			url.Values{"token": {token.Token}}).String()}))
+	link := s.verificationURL(ctx, token)
+	model, err := templates.IdentityModel(i)
+	if err != nil {
+		return err
+	}
+
+	if err := s.send(ctx, string(address.Via), communicationPreferences(i), templates.NewVerificationValid(s.r.Config(ctx),
+		&templates.VerificationValidModel{To: address.Value, VerificationURL: link, VerificationToken: token.Token, Identity: model})); err != nil {
+		return err
+	}
+
+	if s.r.Config(ctx).IsTestTokenEndpointEnabled() {
+		s.r.LinkTestTokens().RecordVerificationToken(address.Value, token.Token, link)
+	}
+	return nil
+}
+
+// SendVerificationReminderTo sends the verification link to the address of the identity using the verification
+// reminder template. reminder counts the reminders sent to the address starting at 1, and last is true if no
+// further reminders will be sent.
+func (s *Sender) SendVerificationReminderTo(ctx context.Context, i *identity.Identity, address *identity.VerifiableAddress, token *VerificationToken, reminder int, last bool) error {
+	s.r.Audit().
+		WithField("via", address.Via).
+		WithField("identity_id", address.IdentityID).
+		WithField("verification_link_id", token.ID).
+		WithField("reminder", reminder).
+		WithSensitiveField("email_address", address.Value).
+		WithSensitiveField("verification_link_token", token.Token).
+		Info("Sending out verification reminder email with verification link.")
+
+	link := s.verificationURL(ctx, token)
+	model, err := templates.IdentityModel(i)
+	if err != nil {
+		return err
+	}
+
+	if err := s.send(ctx, string(address.Via), communicationPreferences(i), templates.NewVerificationReminder(s.r.Config(ctx),
+		&templates.VerificationReminderModel{To: address.Value, VerificationURL: link, VerificationToken: token.Token, Reminder: reminder, Last: last, Identity: model})); err != nil {
+		return err
+	}
+
+	if s.r.Config(ctx).IsTestTokenEndpointEnabled() {
+		s.r.LinkTestTokens().RecordVerificationToken(address.Value, token.Token, link)
+	}
+	return nil
+}
+
+func (s *Sender) verificationURL(ctx context.Context, token *VerificationToken) string {
+	return urlx.CopyWithQuery(
+		urlx.AppendPaths(s.r.Config(ctx).SelfPublicURL(nil), RouteVerification),
+		url.Values{"token": {token.Token}}).String()
+}
+
+// recipient loads the identity owning an address which was looked up by its value. If it can not be loaded, the
+// message is sent using the default communication preferences and without the identity.
+func (s *Sender) recipient(ctx context.Context, id uuid.UUID) *identity.Identity {
+	i, err := s.r.IdentityPool().GetIdentity(ctx, id)
+	if err != nil {
+		s.r.Logger().WithError(err).WithField("identity_id", id).Warn("Unable to load the identity, sending message using the default communication preferences.")
+		return nil
+	}
+	return i
+}
+
+func communicationPreferences(i *identity.Identity) *identity.CommunicationPreferences {
+	if i == nil {
+		return nil
+	}
+	return i.CommunicationPreferences
+}
+
+// sendOnce behaves like send but does not send the message if an identical message was sent within the window.
+func (s *Sender) sendOnce(ctx context.Context, via string, window time.Duration, t courier.EmailTemplate) error {
+	if window <= 0 {
+		return s.send(ctx, via, nil, t)
+	}
+
+	switch via {
+	case identity.AddressTypeEmail:
+		_, err := s.r.Courier(ctx).QueueEmailOnce(ctx, nil, t, s.r.Clock().Now().Add(-window))
+		return err
+	default:
+		return errors.Errorf("received unexpected via type: %s", via)
+	}
 }
 
-func (s *Sender) send(ctx context.Context, via string, t courier.EmailTemplate) error {
+func (s *Sender) send(ctx context.Context, via string, prefs *identity.CommunicationPreferences, t courier.EmailTemplate) error {
 	switch via {
 	case identity.AddressTypeEmail:
-		_, err := s.r.Courier(ctx).QueueEmail(ctx, t)
+		_, err := s.r.Courier(ctx).QueueEmailFor(ctx, prefs, t)
 		return err
 	default:
 		return errors.Errorf("received unexpected via type: %s", via)
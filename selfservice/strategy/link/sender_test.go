@@ -16,6 +16,7 @@ import (
 	"github.com/ory/kratos/selfservice/flow/recovery"
 	"github.com/ory/kratos/selfservice/flow/verification"
 	"github.com/ory/kratos/selfservice/strategy/link"
+	"github.com/ory/kratos/x"
 	"github.com/ory/x/urlx"
 )
 
@@ -32,7 +33,7 @@ func TestManager(t *testing.T) {
 	require.NoError(t, reg.IdentityManager().Create(context.Background(), i))
 
 	t.Run("method=SendRecoveryLink", func(t *testing.T) {
-		f, err := recovery.NewFlow(time.Hour, "", u, reg.RecoveryStrategies(context.Background()), flow.TypeBrowser)
+		f, err := recovery.NewFlow(x.SystemClock, x.RandomIDGenerator, time.Hour, "", u, reg.RecoveryStrategies(context.Background()), flow.TypeBrowser)
 		require.NoError(t, err)
 
 		require.NoError(t, reg.RecoveryFlowPersister().CreateRecoveryFlow(context.Background(), f))
@@ -54,7 +55,7 @@ func TestManager(t *testing.T) {
 	})
 
 	t.Run("method=SendVerificationLink", func(t *testing.T) {
-		f, err := verification.NewFlow(time.Hour, "", u, reg.VerificationStrategies(context.Background()), flow.TypeBrowser)
+		f, err := verification.NewFlow(x.SystemClock, x.RandomIDGenerator, time.Hour, "", u, reg.VerificationStrategies(context.Background()), flow.TypeBrowser)
 		require.NoError(t, err)
 
 		require.NoError(t, reg.VerificationFlowPersister().CreateVerificationFlow(context.Background(), f))
@@ -74,4 +75,41 @@ func TestManager(t *testing.T) {
 		assert.Contains(t, messages[1].Subject, "tried to verify")
 		assert.NotContains(t, messages[1].Body, urlx.AppendPaths(conf.SelfPublicURL(nil), link.RouteVerification).String()+"?token=")
 	})
+
+	t.Run("case=deduplicates messages", func(t *testing.T) {
+		conf.MustSet(config.ViperKeySelfServiceRecoveryDeduplicationWindow, "1m")
+		conf.MustSet(config.ViperKeySelfServiceVerificationDeduplicationWindow, "1m")
+		t.Cleanup(func() {
+			conf.MustSet(config.ViperKeySelfServiceRecoveryDeduplicationWindow, "0s")
+			conf.MustSet(config.ViperKeySelfServiceVerificationDeduplicationWindow, "0s")
+		})
+
+		i := identity.NewIdentity(config.DefaultIdentityTraitsSchemaID)
+		i.Traits = identity.Traits(`{"email": "deduplicated@ory.sh"}`)
+		require.NoError(t, reg.IdentityManager().Create(context.Background(), i))
+
+		rf, err := recovery.NewFlow(x.SystemClock, x.RandomIDGenerator, time.Hour, "", u, reg.RecoveryStrategies(context.Background()), flow.TypeBrowser)
+		require.NoError(t, err)
+		require.NoError(t, reg.RecoveryFlowPersister().CreateRecoveryFlow(context.Background(), rf))
+
+		vf, err := verification.NewFlow(x.SystemClock, x.RandomIDGenerator, time.Hour, "", u, reg.VerificationStrategies(context.Background()), flow.TypeBrowser)
+		require.NoError(t, err)
+		require.NoError(t, reg.VerificationFlowPersister().CreateVerificationFlow(context.Background(), vf))
+
+		for k := 0; k < 2; k++ {
+			require.NoError(t, reg.LinkSender().SendRecoveryLink(context.Background(), nil, rf, "email", "deduplicated@ory.sh"))
+			require.EqualError(t, reg.LinkSender().SendRecoveryLink(context.Background(), nil, rf, "email", "deduplicated-not-tracked@ory.sh"), link.ErrUnknownAddress.Error())
+			require.NoError(t, reg.LinkSender().SendVerificationLink(context.Background(), vf, "email", "deduplicated@ory.sh"))
+			require.EqualError(t, reg.LinkSender().SendVerificationLink(context.Background(), vf, "email", "deduplicated-not-tracked@ory.sh"), link.ErrUnknownAddress.Error())
+		}
+
+		messages, err := reg.CourierPersister().NextMessages(context.Background(), 12)
+		require.NoError(t, err)
+		require.Len(t, messages, 4)
+
+		assert.Contains(t, messages[0].Subject, "Recover access to your account")
+		assert.Contains(t, messages[1].Subject, "Account access attempted")
+		assert.Contains(t, messages[2].Subject, "Please verify")
+		assert.Contains(t, messages[3].Subject, "tried to verify")
+	})
 }
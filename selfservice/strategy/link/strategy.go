@@ -3,6 +3,7 @@ package link
 import (
 	"github.com/ory/kratos/courier"
 	"github.com/ory/kratos/driver/config"
+	"github.com/ory/kratos/idempotency"
 	"github.com/ory/kratos/identity"
 	"github.com/ory/kratos/schema"
 	"github.com/ory/kratos/selfservice/errorx"
@@ -33,12 +34,15 @@ type (
 		x.CSRFProvider
 		x.CSRFTokenGeneratorProvider
 		x.WriterProvider
+		x.ClockProvider
+		x.IDGeneratorProvider
 		x.LoggingProvider
 
 		config.Provider
 
 		session.HandlerProvider
 		session.ManagementProvider
+		session.WebhookProvider
 		settings.HandlerProvider
 		settings.FlowPersistenceProvider
 
@@ -51,6 +55,8 @@ type (
 
 		errorx.ManagementProvider
 
+		idempotency.HandlerProvider
+
 		recovery.ErrorHandlerProvider
 		recovery.FlowPersistenceProvider
 		recovery.StrategyProvider
@@ -62,6 +68,7 @@ type (
 		RecoveryTokenPersistenceProvider
 		VerificationTokenPersistenceProvider
 		SenderProvider
+		TestTokensProvider
 
 		schema.IdentityTraitsProvider
 	}
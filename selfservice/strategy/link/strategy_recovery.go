@@ -31,6 +31,7 @@ import (
 const (
 	RouteRecovery                = "/self-service/recovery/methods/link" // #nosec G101
 	RouteAdminCreateRecoveryLink = "/recovery/link"
+	RouteAdminExpireLinks        = "/identities/:id/links"
 )
 
 func (s *Strategy) RecoveryStrategyID() string {
@@ -46,8 +47,10 @@ func (s *Strategy) RegisterPublicRecoveryRoutes(public *x.RouterPublic) {
 }
 
 func (s *Strategy) RegisterAdminRecoveryRoutes(admin *x.RouterAdmin) {
-	wrappedCreateRecoveryLink := strategy.IsDisabled(s.d, s.RecoveryStrategyID(), s.createRecoveryLink)
+	wrappedCreateRecoveryLink := strategy.IsDisabled(s.d, s.RecoveryStrategyID(), s.d.IdempotencyHandler().Wrap(s.createRecoveryLink))
 	admin.POST(RouteAdminCreateRecoveryLink, wrappedCreateRecoveryLink)
+	admin.DELETE(RouteAdminExpireLinks, s.expireLinks)
+	s.registerAdminTestTokenRoute(admin, RouteAdminTestRecoveryToken, s.d.LinkTestTokens().RecoveryToken)
 }
 
 func (s *Strategy) PopulateRecoveryMethod(r *http.Request, req *recovery.Flow) error {
@@ -67,6 +70,14 @@ func (s *Strategy) PopulateRecoveryMethod(r *http.Request, req *recovery.Flow) e
 //
 // nolint
 type createRecoveryLinkParameters struct {
+	// Idempotency Key
+	//
+	// If set, retries of the request with the same key and body receive the response of the first request
+	// instead of being executed again.
+	//
+	// in: header
+	IdempotencyKey string `json:"Idempotency-Key"`
+
 	// in: body
 	Body CreateRecoveryLink
 }
@@ -148,12 +159,12 @@ func (s *Strategy) createRecoveryLink(w http.ResponseWriter, r *http.Request, _
 		}
 	}
 
-	if time.Now().Add(expiresIn).Before(time.Now()) {
+	if now := s.d.Clock().Now(); now.Add(expiresIn).Before(now) {
 		s.d.Writer().WriteError(w, r, errors.WithStack(herodot.ErrBadRequest.WithReasonf(`Value from "expires_in" must be result to a future time: %s`, p.ExpiresIn)))
 		return
 	}
 
-	req, err := recovery.NewFlow(expiresIn, s.d.GenerateCSRFToken(r), r, s.d.RecoveryStrategies(r.Context()), flow.TypeBrowser)
+	req, err := recovery.NewFlow(s.d.Clock(), s.d.IDGenerator(), expiresIn, s.d.GenerateCSRFToken(r), r, s.d.RecoveryStrategies(r.Context()), flow.TypeBrowser)
 	if err != nil {
 		s.d.Writer().WriteError(w, r, err)
 		return
@@ -171,7 +182,7 @@ func (s *Strategy) createRecoveryLink(w http.ResponseWriter, r *http.Request, _
 	}
 
 	address := id.RecoveryAddresses[0]
-	token := NewRecoveryToken(&address, expiresIn)
+	token := NewRecoveryToken(s.d.Clock(), s.d.IDGenerator(), &address, expiresIn, s.d.Config(r.Context()).SelfServiceFlowRecoveryToken())
 	if err := s.d.RecoveryTokenPersister().CreateRecoveryToken(r.Context(), token); err != nil {
 		s.d.Writer().WriteError(w, r, err)
 		return
@@ -194,6 +205,56 @@ func (s *Strategy) createRecoveryLink(w http.ResponseWriter, r *http.Request, _
 			}).String()})
 }
 
+// swagger:parameters expireLinks
+// nolint:deadcode,unused
+type expireLinksParameters struct {
+	// ID is the identity's ID.
+	//
+	// required: true
+	// in: path
+	ID string `json:"id"`
+}
+
+// swagger:route DELETE /identities/{id}/links admin expireLinks
+//
+// Expire all Recovery and Verification Links of an Identity
+//
+// This endpoint expires all outstanding recovery and verification links of an identity, for example after
+// the password was reset by support staff. Links which were already used or expired are not changed.
+//
+//     Produces:
+//     - application/json
+//
+//     Schemes: http, https
+//
+//     Responses:
+//       204: emptyResponse
+//       404: genericError
+//       500: genericError
+func (s *Strategy) expireLinks(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	i, err := s.d.IdentityPool().GetIdentity(r.Context(), x.ParseUUID(ps.ByName("id")))
+	if err != nil {
+		s.d.Writer().WriteError(w, r, err)
+		return
+	}
+
+	if err := s.d.RecoveryTokenPersister().ExpireRecoveryTokens(r.Context(), i.ID); err != nil {
+		s.d.Writer().WriteError(w, r, err)
+		return
+	}
+
+	if err := s.d.VerificationTokenPersister().ExpireVerificationTokens(r.Context(), i.ID); err != nil {
+		s.d.Writer().WriteError(w, r, err)
+		return
+	}
+
+	s.d.Audit().
+		WithField("identity_id", i.ID).
+		Info("All recovery and verification links of the identity have been expired.")
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
 // swagger:parameters completeSelfServiceRecoveryFlowWithLinkMethod
 type completeSelfServiceRecoveryFlowWithLinkMethodParameters struct {
 	// in: body
@@ -285,7 +346,7 @@ func (s *Strategy) handleRecovery(w http.ResponseWriter, r *http.Request, ps htt
 		return
 	}
 
-	if err := req.Valid(); err != nil {
+	if err := req.Valid(s.d.Clock()); err != nil {
 		s.handleRecoveryError(w, r, req, body, err)
 		return
 	}
@@ -324,11 +385,13 @@ func (s *Strategy) recoveryIssueSession(w http.ResponseWriter, r *http.Request,
 		return
 	}
 
-	sess := session.NewActiveSession(recovered, s.d.Config(r.Context()), time.Now().UTC())
+	sess := session.NewActiveSession(s.d.Clock(), s.d.IDGenerator(), recovered, s.d.Config(r.Context()), s.d.Clock().Now().UTC())
+	sess.Device = session.NewDevice(r, s.d.Config(r.Context()))
 	if err := s.d.SessionManager().CreateAndIssueCookie(r.Context(), w, r, sess); err != nil {
 		s.handleRecoveryError(w, r, f, nil, err)
 		return
 	}
+	s.d.SessionWebhook().SessionCreated(r.Context(), sess, s.RecoveryStrategyID())
 
 	sf, err := s.d.SettingsHandler().NewFlow(w, r, sess.Identity, flow.TypeBrowser)
 	if err != nil {
@@ -336,13 +399,19 @@ func (s *Strategy) recoveryIssueSession(w http.ResponseWriter, r *http.Request,
 		return
 	}
 
-	sf.Messages.Set(text.NewRecoverySuccessful(time.Now().Add(s.d.Config(r.Context()).SelfServiceFlowSettingsPrivilegedSessionMaxAge())))
+	sf.Messages.Set(text.NewRecoverySuccessful(s.d.Clock().Now().Add(s.d.Config(r.Context()).SelfServiceFlowSettingsPrivilegedSessionMaxAge())))
 	if err := s.d.SettingsFlowPersister().UpdateSettingsFlow(r.Context(), sf); err != nil {
 		s.d.SelfServiceErrorManager().Forward(r.Context(), w, r, err)
 		return
 	}
 
-	http.Redirect(w, r, sf.AppendTo(s.d.Config(r.Context()).SelfServiceFlowSettingsUI()).String(), http.StatusFound)
+	settingsUI, err := s.d.Config(r.Context()).SelfServiceFlowSettingsUIOrErr()
+	if err != nil {
+		s.d.SelfServiceErrorManager().Forward(r.Context(), w, r, err)
+		return
+	}
+
+	http.Redirect(w, r, sf.AppendTo(settingsUI).String(), http.StatusFound)
 }
 
 func (s *Strategy) recoveryUseToken(w http.ResponseWriter, r *http.Request, body *completeSelfServiceRecoveryFlowWithLinkMethodParameters) {
@@ -359,7 +428,7 @@ func (s *Strategy) recoveryUseToken(w http.ResponseWriter, r *http.Request, body
 
 	var f *recovery.Flow
 	if !token.FlowID.Valid {
-		f, err = recovery.NewFlow(time.Until(token.ExpiresAt), s.d.GenerateCSRFToken(r), r, s.d.RecoveryStrategies(r.Context()), flow.TypeBrowser)
+		f, err = recovery.NewFlow(s.d.Clock(), s.d.IDGenerator(), token.ExpiresAt.Sub(s.d.Clock().Now()), s.d.GenerateCSRFToken(r), r, s.d.RecoveryStrategies(r.Context()), flow.TypeBrowser)
 		if err != nil {
 			s.handleRecoveryError(w, r, nil, body, err)
 			return
@@ -377,7 +446,7 @@ func (s *Strategy) recoveryUseToken(w http.ResponseWriter, r *http.Request, body
 		}
 	}
 
-	if err := token.Valid(); err != nil {
+	if err := token.Valid(s.d.Clock()); err != nil {
 		s.handleRecoveryError(w, r, f, body, err)
 		return
 	}
@@ -388,7 +457,7 @@ func (s *Strategy) recoveryUseToken(w http.ResponseWriter, r *http.Request, body
 func (s *Strategy) retryRecoveryFlowWithMessage(w http.ResponseWriter, r *http.Request, ft flow.Type, message *text.Message) {
 	s.d.Logger().WithRequest(r).WithField("message", message).Debug("A recovery flow is being retried because a validation error occurred.")
 
-	req, err := recovery.NewFlow(s.d.Config(r.Context()).SelfServiceFlowRecoveryRequestLifespan(), s.d.GenerateCSRFToken(r), r, s.d.RecoveryStrategies(r.Context()), ft)
+	req, err := recovery.NewFlow(s.d.Clock(), s.d.IDGenerator(), s.d.Config(r.Context()).SelfServiceFlowRecoveryRequestLifespan(), s.d.GenerateCSRFToken(r), r, s.d.RecoveryStrategies(r.Context()), ft)
 	if err != nil {
 		s.d.SelfServiceErrorManager().Forward(r.Context(), w, r, err)
 		return
@@ -401,7 +470,12 @@ func (s *Strategy) retryRecoveryFlowWithMessage(w http.ResponseWriter, r *http.R
 	}
 
 	if ft == flow.TypeBrowser {
-		http.Redirect(w, r, req.AppendTo(s.d.Config(r.Context()).SelfServiceFlowRecoveryUI()).String(), http.StatusFound)
+		recoveryUI, err := s.d.Config(r.Context()).SelfServiceFlowRecoveryUIOrErr()
+		if err != nil {
+			s.d.SelfServiceErrorManager().Forward(r.Context(), w, r, err)
+			return
+		}
+		http.Redirect(w, r, req.AppendTo(recoveryUI).String(), http.StatusFound)
 		return
 	}
 
@@ -454,7 +528,12 @@ func (s *Strategy) recoveryHandleFormSubmission(w http.ResponseWriter, r *http.R
 	}
 
 	if req.Type == flow.TypeBrowser {
-		http.Redirect(w, r, req.AppendTo(s.d.Config(r.Context()).SelfServiceFlowRecoveryUI()).String(), http.StatusFound)
+		recoveryUI, err := s.d.Config(r.Context()).SelfServiceFlowRecoveryUIOrErr()
+		if err != nil {
+			s.handleRecoveryError(w, r, req, body, err)
+			return
+		}
+		http.Redirect(w, r, req.AppendTo(recoveryUI).String(), http.StatusFound)
 		return
 	}
 
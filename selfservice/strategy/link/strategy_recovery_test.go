@@ -131,6 +131,39 @@ func TestAdminStrategy(t *testing.T) {
 		assert.Equal(t, "You successfully recovered your account. Please change your password or set up an alternative login method (e.g. social sign in) within the next 60.00 minutes.", sr.Payload.Messages[0].Text)
 	})
 
+	expireLinks := func(t *testing.T, id string) *http.Response {
+		req, err := http.NewRequest("DELETE", adminTS.URL+"/identities/"+id+"/links", nil)
+		require.NoError(t, err)
+		res, err := adminTS.Client().Do(req)
+		require.NoError(t, err)
+		defer res.Body.Close()
+		return res
+	}
+
+	t.Run("description=should not be able to expire the links of an account that does not exist", func(t *testing.T) {
+		assert.Equal(t, http.StatusNotFound, expireLinks(t, x.NewUUID().String()).StatusCode)
+	})
+
+	t.Run("description=should expire all recovery links of an account", func(t *testing.T) {
+		id := identity.Identity{Traits: identity.Traits(`{"email":"recover.revoked@ory.sh"}`)}
+
+		require.NoError(t, reg.IdentityManager().Create(context.Background(),
+			&id, identity.ManagerAllowWriteProtectedTraits))
+
+		uuid := models.UUID(id.ID.String())
+		rl, err := adminSDK.Admin.CreateRecoveryLink(admin.NewCreateRecoveryLinkParams().
+			WithBody(&models.CreateRecoveryLink{IdentityID: &uuid}))
+		require.NoError(t, err)
+
+		assert.Equal(t, http.StatusNoContent, expireLinks(t, id.ID.String()).StatusCode)
+
+		res, err := publicTS.Client().Get(*rl.Payload.RecoveryLink)
+		require.NoError(t, err)
+		require.Equal(t, http.StatusOK, res.StatusCode)
+
+		// We end up here because the link is expired.
+		assert.Contains(t, res.Request.URL.Path, "/recover")
+	})
 }
 
 func TestRecovery(t *testing.T) {
@@ -3,7 +3,6 @@ package link
 import (
 	"net/http"
 	"net/url"
-	"time"
 
 	"github.com/ory/kratos/selfservice/strategy"
 
@@ -40,6 +39,7 @@ func (s *Strategy) RegisterPublicVerificationRoutes(public *x.RouterPublic) {
 }
 
 func (s *Strategy) RegisterAdminVerificationRoutes(admin *x.RouterAdmin) {
+	s.registerAdminTestTokenRoute(admin, RouteAdminTestVerificationToken, s.d.LinkTestTokens().VerificationToken)
 }
 
 func (s *Strategy) PopulateVerificationMethod(r *http.Request, req *verification.Flow) error {
@@ -183,7 +183,7 @@ func (s *Strategy) handleVerification(w http.ResponseWriter, r *http.Request, ps
 		return
 	}
 
-	if err := f.Valid(); err != nil {
+	if err := f.Valid(s.d.Clock()); err != nil {
 		s.handleVerificationError(w, r, f, body, err)
 		return
 	}
@@ -249,7 +249,12 @@ func (s *Strategy) verificationHandleFormSubmission(w http.ResponseWriter, r *ht
 	}
 
 	if f.Type == flow.TypeBrowser {
-		http.Redirect(w, r, f.AppendTo(s.d.Config(r.Context()).SelfServiceFlowVerificationUI()).String(), http.StatusFound)
+		verificationUI, err := s.d.Config(r.Context()).SelfServiceFlowVerificationUIOrErr()
+		if err != nil {
+			s.handleVerificationError(w, r, f, body, err)
+			return
+		}
+		http.Redirect(w, r, f.AppendTo(verificationUI).String(), http.StatusFound)
 		return
 	}
 
@@ -284,7 +289,7 @@ func (s *Strategy) verificationUseToken(w http.ResponseWriter, r *http.Request,
 
 	var f *verification.Flow
 	if !token.FlowID.Valid {
-		f, err = verification.NewFlow(time.Until(token.ExpiresAt), s.d.GenerateCSRFToken(r), r, s.d.VerificationStrategies(r.Context()), flow.TypeBrowser)
+		f, err = verification.NewFlow(s.d.Clock(), s.d.IDGenerator(), token.ExpiresAt.Sub(s.d.Clock().Now()), s.d.GenerateCSRFToken(r), r, s.d.VerificationStrategies(r.Context()), flow.TypeBrowser)
 		if err != nil {
 			s.handleVerificationError(w, r, nil, body, err)
 			return
@@ -302,7 +307,7 @@ func (s *Strategy) verificationUseToken(w http.ResponseWriter, r *http.Request,
 		}
 	}
 
-	if err := token.Valid(); err != nil {
+	if err := token.Valid(s.d.Clock()); err != nil {
 		s.handleVerificationError(w, r, f, body, err)
 		return
 	}
@@ -316,21 +321,27 @@ func (s *Strategy) verificationUseToken(w http.ResponseWriter, r *http.Request,
 
 	address := token.VerifiableAddress
 	address.Verified = true
-	address.VerifiedAt = sqlxx.NullTime(time.Now().UTC())
+	address.VerifiedAt = sqlxx.NullTime(s.d.Clock().Now().UTC())
 	address.Status = identity.VerifiableAddressStatusCompleted
 	if err := s.d.PrivilegedIdentityPool().UpdateVerifiableAddress(r.Context(), address); err != nil {
 		s.handleVerificationError(w, r, f, body, err)
 		return
 	}
 
+	verificationUI, err := s.d.Config(r.Context()).SelfServiceFlowVerificationUIOrErr()
+	if err != nil {
+		s.handleVerificationError(w, r, f, body, err)
+		return
+	}
+
 	http.Redirect(w, r, s.d.Config(r.Context()).SelfServiceFlowVerificationReturnTo(f.
-		AppendTo(s.d.Config(r.Context()).SelfServiceFlowVerificationUI())).String(), http.StatusFound)
+		AppendTo(verificationUI)).String(), http.StatusFound)
 }
 
 func (s *Strategy) retryVerificationFlowWithMessage(w http.ResponseWriter, r *http.Request, ft flow.Type, message *text.Message) {
 	s.d.Logger().WithRequest(r).WithField("message", message).Debug("A verification flow is being retried because a validation error occurred.")
 
-	req, err := verification.NewFlow(s.d.Config(r.Context()).SelfServiceFlowVerificationRequestLifespan(), s.d.GenerateCSRFToken(r), r, s.d.VerificationStrategies(r.Context()), ft)
+	req, err := verification.NewFlow(s.d.Clock(), s.d.IDGenerator(), s.d.Config(r.Context()).SelfServiceFlowVerificationRequestLifespan(), s.d.GenerateCSRFToken(r), r, s.d.VerificationStrategies(r.Context()), ft)
 	if err != nil {
 		s.d.SelfServiceErrorManager().Forward(r.Context(), w, r, err)
 		return
@@ -343,7 +354,12 @@ func (s *Strategy) retryVerificationFlowWithMessage(w http.ResponseWriter, r *ht
 	}
 
 	if ft == flow.TypeBrowser {
-		http.Redirect(w, r, req.AppendTo(s.d.Config(r.Context()).SelfServiceFlowVerificationUI()).String(), http.StatusFound)
+		verificationUI, err := s.d.Config(r.Context()).SelfServiceFlowVerificationUIOrErr()
+		if err != nil {
+			s.d.SelfServiceErrorManager().Forward(r.Context(), w, r, err)
+			return
+		}
+		http.Redirect(w, r, req.AppendTo(verificationUI).String(), http.StatusFound)
 		return
 	}
 
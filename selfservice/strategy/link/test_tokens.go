@@ -0,0 +1,113 @@
+package link
+
+import (
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/julienschmidt/httprouter"
+	"github.com/pkg/errors"
+
+	"github.com/ory/herodot"
+
+	"github.com/ory/kratos/x"
+)
+
+const (
+	RouteAdminTestRecoveryToken     = "/test/self-service/recovery/methods/link/token"
+	RouteAdminTestVerificationToken = "/test/self-service/verification/methods/link/token"
+)
+
+type (
+	// TestToken is the most recent token sent to an address. It is only recorded if the test
+	// token endpoints are enabled.
+	//
+	// swagger:model linkTestToken
+	TestToken struct {
+		// Address is the address the token was sent to.
+		Address string `json:"address"`
+
+		// Token is the token in clear text.
+		Token string `json:"token"`
+
+		// URL is the link which was sent to the address.
+		URL string `json:"url"`
+
+		// IssuedAt is the time (UTC) when the token was sent.
+		IssuedAt time.Time `json:"issued_at"`
+	}
+
+	// TestTokens records the most recent recovery and verification tokens per address in memory. It must
+	// never be used outside of dev mode as it keeps tokens in clear text.
+	TestTokens struct {
+		sync.RWMutex
+		recovery     map[string]*TestToken
+		verification map[string]*TestToken
+	}
+
+	TestTokensProvider interface {
+		LinkTestTokens() *TestTokens
+	}
+)
+
+func NewTestTokens() *TestTokens {
+	return &TestTokens{
+		recovery:     map[string]*TestToken{},
+		verification: map[string]*TestToken{},
+	}
+}
+
+func (t *TestTokens) RecordRecoveryToken(address, token, url string) {
+	t.record(t.recovery, address, token, url)
+}
+
+func (t *TestTokens) RecordVerificationToken(address, token, url string) {
+	t.record(t.verification, address, token, url)
+}
+
+func (t *TestTokens) RecoveryToken(address string) (*TestToken, bool) {
+	return t.get(t.recovery, address)
+}
+
+func (t *TestTokens) VerificationToken(address string) (*TestToken, bool) {
+	return t.get(t.verification, address)
+}
+
+func (t *TestTokens) record(tokens map[string]*TestToken, address, token, url string) {
+	t.Lock()
+	defer t.Unlock()
+	tokens[strings.ToLower(address)] = &TestToken{Address: address, Token: token, URL: url, IssuedAt: time.Now().UTC()}
+}
+
+func (t *TestTokens) get(tokens map[string]*TestToken, address string) (*TestToken, bool) {
+	t.RLock()
+	defer t.RUnlock()
+	token, ok := tokens[strings.ToLower(address)]
+	return token, ok
+}
+
+// registerAdminTestTokenRoute registers a route returning the most recent token sent to an address. The route
+// responds with 404 Not Found unless dev mode and the test token endpoints are enabled.
+func (s *Strategy) registerAdminTestTokenRoute(admin *x.RouterAdmin, route string, find func(address string) (*TestToken, bool)) {
+	admin.GET(route, func(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+		if !s.d.Config(r.Context()).IsTestTokenEndpointEnabled() {
+			s.d.Writer().WriteError(w, r, errors.WithStack(herodot.ErrNotFound.WithReason("The test token endpoint is disabled.")))
+			return
+		}
+
+		address := r.URL.Query().Get("address")
+		if len(address) == 0 {
+			s.d.Writer().WriteError(w, r, errors.WithStack(herodot.ErrBadRequest.WithReason("The address query parameter is required.")))
+			return
+		}
+
+		token, ok := find(address)
+		if !ok {
+			s.d.Writer().WriteError(w, r, errors.WithStack(herodot.ErrNotFound.WithReasonf("No token was sent to address %s.", address)))
+			return
+		}
+
+		s.d.Writer().Write(w, r, token)
+	})
+}
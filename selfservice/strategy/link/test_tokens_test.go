@@ -0,0 +1,108 @@
+package link_test
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tidwall/gjson"
+
+	"github.com/ory/x/urlx"
+
+	"github.com/ory/kratos/driver/config"
+	"github.com/ory/kratos/identity"
+	"github.com/ory/kratos/internal"
+	"github.com/ory/kratos/internal/testhelpers"
+	"github.com/ory/kratos/selfservice/flow"
+	"github.com/ory/kratos/selfservice/flow/recovery"
+	"github.com/ory/kratos/selfservice/flow/verification"
+	"github.com/ory/kratos/selfservice/strategy/link"
+	"github.com/ory/kratos/x"
+)
+
+func TestAdminTestTokens(t *testing.T) {
+	conf, reg := internal.NewFastRegistryWithMocks(t)
+	conf.MustSet(config.ViperKeyDefaultIdentitySchemaURL, "file://./stub/default.schema.json")
+	conf.MustSet(config.ViperKeyPublicBaseURL, "https://www.ory.sh/")
+	conf.MustSet(config.ViperKeyCourierSMTPURL, "smtp://foo@bar@dev.null/")
+	_, adminTS := testhelpers.NewKratosServer(t, reg)
+	conf.MustSet("dev", false)
+
+	u := &http.Request{URL: urlx.ParseOrPanic("https://www.ory.sh/")}
+
+	i := identity.NewIdentity(config.DefaultIdentityTraitsSchemaID)
+	i.Traits = identity.Traits(`{"email": "test-tokens@ory.sh"}`)
+	require.NoError(t, reg.IdentityManager().Create(context.Background(), i))
+
+	var get = func(t *testing.T, route, address string, expectCode int) gjson.Result {
+		res, err := adminTS.Client().Get(adminTS.URL + route + "?" + url.Values{"address": {address}}.Encode())
+		require.NoError(t, err)
+		defer res.Body.Close()
+		body, err := ioutil.ReadAll(res.Body)
+		require.NoError(t, err)
+		require.EqualValues(t, expectCode, res.StatusCode, "%s", body)
+		return gjson.ParseBytes(body)
+	}
+
+	var send = func(t *testing.T) {
+		rf, err := recovery.NewFlow(x.SystemClock, x.RandomIDGenerator, time.Hour, "", u, reg.RecoveryStrategies(context.Background()), flow.TypeBrowser)
+		require.NoError(t, err)
+		require.NoError(t, reg.RecoveryFlowPersister().CreateRecoveryFlow(context.Background(), rf))
+		require.NoError(t, reg.LinkSender().SendRecoveryLink(context.Background(), nil, rf, "email", "test-tokens@ory.sh"))
+
+		vf, err := verification.NewFlow(x.SystemClock, x.RandomIDGenerator, time.Hour, "", u, reg.VerificationStrategies(context.Background()), flow.TypeBrowser)
+		require.NoError(t, err)
+		require.NoError(t, reg.VerificationFlowPersister().CreateVerificationFlow(context.Background(), vf))
+		require.NoError(t, reg.LinkSender().SendVerificationLink(context.Background(), vf, "email", "test-tokens@ory.sh"))
+	}
+
+	t.Run("case=endpoints are disabled by default", func(t *testing.T) {
+		send(t)
+		get(t, link.RouteAdminTestRecoveryToken, "test-tokens@ory.sh", http.StatusNotFound)
+		get(t, link.RouteAdminTestVerificationToken, "test-tokens@ory.sh", http.StatusNotFound)
+	})
+
+	t.Run("case=endpoints require dev mode", func(t *testing.T) {
+		conf.MustSet("expose-test-tokens", true)
+		t.Cleanup(func() { conf.MustSet("expose-test-tokens", false) })
+
+		send(t)
+		get(t, link.RouteAdminTestRecoveryToken, "test-tokens@ory.sh", http.StatusNotFound)
+		get(t, link.RouteAdminTestVerificationToken, "test-tokens@ory.sh", http.StatusNotFound)
+	})
+
+	t.Run("case=endpoints return the most recent tokens", func(t *testing.T) {
+		conf.MustSet("dev", true)
+		conf.MustSet("expose-test-tokens", true)
+		t.Cleanup(func() {
+			conf.MustSet("dev", false)
+			conf.MustSet("expose-test-tokens", false)
+		})
+
+		get(t, link.RouteAdminTestRecoveryToken, "test-tokens@ory.sh", http.StatusNotFound)
+		send(t)
+
+		for route, path := range map[string]string{
+			link.RouteAdminTestRecoveryToken:     link.RouteRecovery,
+			link.RouteAdminTestVerificationToken: link.RouteVerification,
+		} {
+			actual := get(t, route, "TEST-TOKENS@ory.sh", http.StatusOK)
+			assert.EqualValues(t, "test-tokens@ory.sh", actual.Get("address").String(), "%s", actual.Raw)
+			assert.NotEmpty(t, actual.Get("token").String(), "%s", actual.Raw)
+			assert.EqualValues(t, urlx.AppendPaths(conf.SelfPublicURL(nil), path).String()+"?token="+actual.Get("token").String(), actual.Get("url").String(), "%s", actual.Raw)
+
+			get(t, route, "unknown@ory.sh", http.StatusNotFound)
+			get(t, route, "", http.StatusBadRequest)
+		}
+
+		_, err := reg.RecoveryTokenPersister().UseRecoveryToken(context.Background(), get(t, link.RouteAdminTestRecoveryToken, "test-tokens@ory.sh", http.StatusOK).Get("token").String())
+		require.NoError(t, err)
+		_, err = reg.VerificationTokenPersister().UseVerificationToken(context.Background(), get(t, link.RouteAdminTestVerificationToken, "test-tokens@ory.sh", http.StatusOK).Get("token").String())
+		require.NoError(t, err)
+	})
+}
@@ -0,0 +1,12 @@
+package link
+
+import (
+	"github.com/ory/x/randx"
+
+	"github.com/ory/kratos/driver/config"
+)
+
+// newTokenString generates a recovery or verification token using the configured length and alphabet.
+func newTokenString(f config.TokenFormat) string {
+	return randx.MustString(f.Length, []rune(f.Alphabet))
+}
@@ -9,8 +9,7 @@ import (
 	"github.com/gofrs/uuid"
 	errors "github.com/pkg/errors"
 
-	"github.com/ory/x/randx"
-
+	"github.com/ory/kratos/driver/config"
 	"github.com/ory/kratos/identity"
 	"github.com/ory/kratos/selfservice/flow/recovery"
 	"github.com/ory/kratos/x"
@@ -53,29 +52,29 @@ func (RecoveryToken) TableName(ctx context.Context) string {
 	return corp.ContextualizeTableName(ctx, "identity_recovery_tokens")
 }
 
-func NewSelfServiceRecoveryToken(address *identity.RecoveryAddress, f *recovery.Flow) *RecoveryToken {
+func NewSelfServiceRecoveryToken(c x.Clock, g x.IDGenerator, address *identity.RecoveryAddress, f *recovery.Flow, tf config.TokenFormat) *RecoveryToken {
 	return &RecoveryToken{
-		ID:              x.NewUUID(),
-		Token:           randx.MustString(32, randx.AlphaNum),
+		ID:              g.NewUUID(),
+		Token:           newTokenString(tf),
 		RecoveryAddress: address,
 		ExpiresAt:       f.ExpiresAt,
-		IssuedAt:        time.Now().UTC(),
+		IssuedAt:        c.Now().UTC(),
 		FlowID:          uuid.NullUUID{UUID: f.ID, Valid: true}}
 }
 
-func NewRecoveryToken(address *identity.RecoveryAddress, expiresIn time.Duration) *RecoveryToken {
-	now := time.Now().UTC()
+func NewRecoveryToken(c x.Clock, g x.IDGenerator, address *identity.RecoveryAddress, expiresIn time.Duration, tf config.TokenFormat) *RecoveryToken {
+	now := c.Now().UTC()
 	return &RecoveryToken{
-		ID:              x.NewUUID(),
-		Token:           randx.MustString(32, randx.AlphaNum),
+		ID:              g.NewUUID(),
+		Token:           newTokenString(tf),
 		RecoveryAddress: address,
 		ExpiresAt:       now.Add(expiresIn),
 		IssuedAt:        now,
 	}
 }
 
-func (f *RecoveryToken) Valid() error {
-	if f.ExpiresAt.Before(time.Now()) {
+func (f *RecoveryToken) Valid(c x.Clock) error {
+	if f.ExpiresAt.Before(c.Now()) {
 		return errors.WithStack(recovery.NewFlowExpiredError(f.ExpiresAt))
 	}
 	return nil
@@ -11,33 +11,62 @@ import (
 	"github.com/ory/x/stringslice"
 	"github.com/ory/x/urlx"
 
+	"github.com/ory/kratos/driver/config"
 	"github.com/ory/kratos/selfservice/flow"
 	"github.com/ory/kratos/selfservice/flow/recovery"
+	"github.com/ory/kratos/x"
 )
 
 func TestRecoveryToken(t *testing.T) {
 	req := &http.Request{URL: urlx.ParseOrPanic("https://www.ory.sh/")}
+	tf := config.TokenFormat{Format: config.TokenFormatToken, Length: 32, Alphabet: "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789"}
 	t.Run("func=NewSelfServiceRecoveryToken", func(t *testing.T) {
 		t.Run("case=creates unique tokens", func(t *testing.T) {
-			f, err := recovery.NewFlow(time.Hour, "", req, nil, flow.TypeBrowser)
+			f, err := recovery.NewFlow(x.SystemClock, x.RandomIDGenerator, time.Hour, "", req, nil, flow.TypeBrowser)
 			require.NoError(t, err)
 
 			tokens := make([]string, 10)
 			for k := range tokens {
-				tokens[k] = NewSelfServiceRecoveryToken(nil, f).Token
+				tokens[k] = NewSelfServiceRecoveryToken(x.SystemClock, x.RandomIDGenerator, nil, f, tf).Token
 			}
 
 			assert.Len(t, stringslice.Unique(tokens), len(tokens))
 		})
+
+		t.Run("case=creates numeric codes", func(t *testing.T) {
+			f, err := recovery.NewFlow(x.SystemClock, x.RandomIDGenerator, time.Hour, "", req, nil, flow.TypeBrowser)
+			require.NoError(t, err)
+
+			token := NewSelfServiceRecoveryToken(x.SystemClock, x.RandomIDGenerator, nil, f, config.TokenFormat{Format: config.TokenFormatCode, Length: 8, Alphabet: "0123456789"})
+			assert.Regexp(t, "^[0-9]{8}$", token.Token)
+		})
 	})
 	t.Run("method=Valid", func(t *testing.T) {
 		t.Run("case=is invalid when the flow is expired", func(t *testing.T) {
-			f, err := recovery.NewFlow(-time.Hour, "", req, nil, flow.TypeBrowser)
+			f, err := recovery.NewFlow(x.SystemClock, x.RandomIDGenerator, -time.Hour, "", req, nil, flow.TypeBrowser)
+			require.NoError(t, err)
+
+			token := NewSelfServiceRecoveryToken(x.SystemClock, x.RandomIDGenerator, nil, f, tf)
+			require.Error(t, token.Valid(x.SystemClock))
+			assert.EqualError(t, token.Valid(x.SystemClock), f.Valid(x.SystemClock).Error())
+		})
+
+		t.Run("case=expires based on the injected clock", func(t *testing.T) {
+			clock := x.NewFakeClock(time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC))
+			f, err := recovery.NewFlow(clock, x.RandomIDGenerator, time.Hour, "", req, nil, flow.TypeBrowser)
 			require.NoError(t, err)
 
-			token := NewSelfServiceRecoveryToken(nil, f)
-			require.Error(t, token.Valid())
-			assert.EqualError(t, token.Valid(), f.Valid().Error())
+			token := NewSelfServiceRecoveryToken(clock, x.RandomIDGenerator, nil, f, tf)
+			assert.Equal(t, clock.Now(), token.IssuedAt)
+			require.NoError(t, token.Valid(clock))
+
+			clock.Add(2 * time.Hour)
+			require.Error(t, token.Valid(clock))
+
+			token = NewRecoveryToken(clock, x.RandomIDGenerator, nil, time.Hour, tf)
+			assert.Equal(t, clock.Now(), token.IssuedAt)
+			assert.Equal(t, clock.Now().Add(time.Hour), token.ExpiresAt)
+			require.NoError(t, token.Valid(clock))
 		})
 	})
 }
@@ -9,8 +9,7 @@ import (
 	"github.com/gofrs/uuid"
 	"github.com/pkg/errors"
 
-	"github.com/ory/x/randx"
-
+	"github.com/ory/kratos/driver/config"
 	"github.com/ory/kratos/identity"
 	"github.com/ory/kratos/selfservice/flow/verification"
 	"github.com/ory/kratos/x"
@@ -53,29 +52,29 @@ func (VerificationToken) TableName(ctx context.Context) string {
 	return corp.ContextualizeTableName(ctx, "identity_verification_tokens")
 }
 
-func NewSelfServiceVerificationToken(address *identity.VerifiableAddress, f *verification.Flow) *VerificationToken {
+func NewSelfServiceVerificationToken(c x.Clock, g x.IDGenerator, address *identity.VerifiableAddress, f *verification.Flow, tf config.TokenFormat) *VerificationToken {
 	return &VerificationToken{
-		ID:                x.NewUUID(),
-		Token:             randx.MustString(32, randx.AlphaNum),
+		ID:                g.NewUUID(),
+		Token:             newTokenString(tf),
 		VerifiableAddress: address,
 		ExpiresAt:         f.ExpiresAt,
-		IssuedAt:          time.Now().UTC(),
+		IssuedAt:          c.Now().UTC(),
 		FlowID:            uuid.NullUUID{UUID: f.ID, Valid: true}}
 }
 
-func NewVerificationToken(address *identity.VerifiableAddress, expiresIn time.Duration) *VerificationToken {
-	now := time.Now().UTC()
+func NewVerificationToken(c x.Clock, g x.IDGenerator, address *identity.VerifiableAddress, expiresIn time.Duration, tf config.TokenFormat) *VerificationToken {
+	now := c.Now().UTC()
 	return &VerificationToken{
-		ID:                x.NewUUID(),
-		Token:             randx.MustString(32, randx.AlphaNum),
+		ID:                g.NewUUID(),
+		Token:             newTokenString(tf),
 		VerifiableAddress: address,
 		ExpiresAt:         now.Add(expiresIn),
 		IssuedAt:          now,
 	}
 }
 
-func (f *VerificationToken) Valid() error {
-	if f.ExpiresAt.Before(time.Now()) {
+func (f *VerificationToken) Valid(c x.Clock) error {
+	if f.ExpiresAt.Before(c.Now()) {
 		return errors.WithStack(verification.NewFlowExpiredError(f.ExpiresAt))
 	}
 	return nil
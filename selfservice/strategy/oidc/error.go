@@ -13,4 +13,7 @@ var (
 
 	ErrAPIFlowNotSupported = herodot.ErrBadRequest.WithError("API-based flows are not supported for this method").
 				WithReasonf("Social Sign In and OpenID Connect are only supported for flows initiated using the Browser endpoint.")
+
+	ErrFlowNotEnabled = herodot.ErrBadRequest.WithError("this method is not enabled for this flow").
+				WithReasonf("Social Sign In and OpenID Connect are not enabled for this flow.")
 )
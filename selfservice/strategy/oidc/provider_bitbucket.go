@@ -0,0 +1,126 @@
+package oidc
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/url"
+
+	"github.com/pkg/errors"
+	"golang.org/x/oauth2"
+	"golang.org/x/oauth2/bitbucket"
+
+	"github.com/ory/herodot"
+	"github.com/ory/x/stringslice"
+)
+
+var bitbucketAPIURL = "https://api.bitbucket.org/2.0"
+
+type ProviderBitbucket struct {
+	config *Configuration
+	public *url.URL
+}
+
+func NewProviderBitbucket(
+	config *Configuration,
+	public *url.URL,
+) *ProviderBitbucket {
+	return &ProviderBitbucket{
+		config: config,
+		public: public,
+	}
+}
+
+func (b *ProviderBitbucket) Config() *Configuration {
+	return b.config
+}
+
+func (b *ProviderBitbucket) oauth2() *oauth2.Config {
+	return &oauth2.Config{
+		ClientID:     b.config.ClientID,
+		ClientSecret: b.config.ClientSecret,
+		Endpoint:     bitbucket.Endpoint,
+		Scopes:       b.config.Scope,
+		RedirectURL:  b.config.Redir(b.public),
+	}
+}
+
+func (b *ProviderBitbucket) OAuth2(ctx context.Context) (*oauth2.Config, error) {
+	return b.oauth2(), nil
+}
+
+func (b *ProviderBitbucket) AuthCodeURLOptions(r ider) []oauth2.AuthCodeOption {
+	return []oauth2.AuthCodeOption{}
+}
+
+func (b *ProviderBitbucket) Claims(ctx context.Context, exchange *oauth2.Token) (*Claims, error) {
+	client := b.oauth2().Client(ctx, exchange)
+
+	var user struct {
+		AccountID   string `json:"account_id"`
+		Nickname    string `json:"nickname"`
+		DisplayName string `json:"display_name"`
+		Links       struct {
+			Avatar struct {
+				Href string `json:"href"`
+			} `json:"avatar"`
+			HTML struct {
+				Href string `json:"href"`
+			} `json:"html"`
+		} `json:"links"`
+	}
+	if err := b.get(client, "/user", &user); err != nil {
+		return nil, err
+	}
+
+	claims := &Claims{
+		Subject:  user.AccountID,
+		Issuer:   bitbucket.Endpoint.TokenURL,
+		Name:     user.DisplayName,
+		Nickname: user.Nickname,
+		Picture:  user.Links.Avatar.Href,
+		Profile:  user.Links.HTML.Href,
+	}
+
+	// Bitbucket does not include the user's emails in the call to `/user`. Therefore, if scope "email" is set,
+	// we want to make another request to `/user/emails` and merge that with our claims.
+	if stringslice.Has(b.config.Scope, "email") {
+		var emails struct {
+			Values []struct {
+				Email       string `json:"email"`
+				IsPrimary   bool   `json:"is_primary"`
+				IsConfirmed bool   `json:"is_confirmed"`
+			} `json:"values"`
+		}
+		if err := b.get(client, "/user/emails", &emails); err != nil {
+			return nil, err
+		}
+
+		for _, e := range emails.Values {
+			if e.IsPrimary {
+				claims.Email = e.Email
+				claims.EmailVerified = e.IsConfirmed
+				break
+			}
+		}
+	}
+
+	return claims, nil
+}
+
+func (b *ProviderBitbucket) get(client *http.Client, path string, v interface{}) error {
+	res, err := client.Get(bitbucketAPIURL + path)
+	if err != nil {
+		return errors.WithStack(herodot.ErrInternalServerError.WithReasonf("%s", err))
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return errors.WithStack(herodot.ErrInternalServerError.WithReasonf("Bitbucket responded with unexpected status code %d when fetching %s.", res.StatusCode, path))
+	}
+
+	if err := json.NewDecoder(res.Body).Decode(v); err != nil {
+		return errors.WithStack(herodot.ErrInternalServerError.WithReasonf("%s", err))
+	}
+	return nil
+}
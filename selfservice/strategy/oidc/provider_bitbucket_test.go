@@ -0,0 +1,60 @@
+package oidc
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/oauth2"
+
+	"github.com/ory/x/urlx"
+)
+
+func TestProviderBitbucket(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "Bearer access-token", r.Header.Get("Authorization"))
+		switch r.URL.Path {
+		case "/user":
+			_, _ = w.Write([]byte(`{"account_id":"557058:1234","nickname":"foo","display_name":"Foo Bar","links":{"avatar":{"href":"https://avatar"},"html":{"href":"https://bitbucket.org/foo"}}}`))
+		case "/user/emails":
+			_ = json.NewEncoder(w).Encode(map[string]interface{}{"values": []map[string]interface{}{
+				{"email": "other@ory.sh", "is_primary": false, "is_confirmed": true},
+				{"email": "foo@ory.sh", "is_primary": true, "is_confirmed": true},
+			}})
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	t.Cleanup(ts.Close)
+
+	apiURL := bitbucketAPIURL
+	bitbucketAPIURL = ts.URL
+	t.Cleanup(func() {
+		bitbucketAPIURL = apiURL
+	})
+
+	for _, tc := range []struct {
+		scope []string
+		email string
+	}{
+		{scope: []string{"account"}},
+		{scope: []string{"account", "email"}, email: "foo@ory.sh"},
+	} {
+		t.Run("email="+tc.email, func(t *testing.T) {
+			p := NewProviderBitbucket(&Configuration{ID: "bitbucket", Provider: "bitbucket", Scope: tc.scope}, urlx.ParseOrPanic("https://www.ory.sh/"))
+			claims, err := p.Claims(context.Background(), &oauth2.Token{AccessToken: "access-token"})
+			require.NoError(t, err)
+
+			assert.Equal(t, "557058:1234", claims.Subject)
+			assert.Equal(t, "Foo Bar", claims.Name)
+			assert.Equal(t, "foo", claims.Nickname)
+			assert.Equal(t, "https://avatar", claims.Picture)
+			assert.Equal(t, tc.email, claims.Email)
+			assert.Equal(t, tc.email != "", claims.EmailVerified)
+		})
+	}
+}
@@ -12,6 +12,14 @@ import (
 	"github.com/ory/x/urlx"
 )
 
+const (
+	// ClaimsSourceIDToken reads the claims from the ID token.
+	ClaimsSourceIDToken = "id_token"
+
+	// ClaimsSourceUserinfo reads the claims from the OpenID Connect Provider's userinfo endpoint.
+	ClaimsSourceUserinfo = "userinfo"
+)
+
 type Configuration struct {
 	// ID is the provider's ID
 	ID string `json:"id"`
@@ -21,10 +29,12 @@ type Configuration struct {
 	// - google
 	// - github
 	// - gitlab
+	// - bitbucket
 	// - microsoft
 	// - discord
 	// - slack
 	// - facebook
+	// - dev (a mock provider served by ORY Kratos itself, only available in dev mode)
 	Provider string `json:"provider"`
 
 	// ClientID is the application's Client ID.
@@ -55,6 +65,10 @@ type Configuration struct {
 	// Scope specifies optional requested permissions.
 	Scope []string `json:"scope"`
 
+	// AdditionalScope specifies permissions which signed in users can additionally consent to using the
+	// settings flow, for example `https://www.googleapis.com/auth/calendar.readonly`.
+	AdditionalScope []string `json:"additional_scope"`
+
 	// Mapper specifies the JSONNet code snippet which uses the OpenID Connect Provider's data (e.g. GitHub or Google
 	// profile information) to hydrate the identity's data.
 	//
@@ -66,6 +80,36 @@ type Configuration struct {
 	//
 	// More information: https://openid.net/specs/openid-connect-core-1_0.html#ClaimsParameter
 	RequestedClaims json.RawMessage `json:"requested_claims"`
+
+	// ClaimsSource is where the claims are read from. Either `id_token` (default) or `userinfo` for providers
+	// which only return complete claims at the userinfo endpoint. The ID token is verified in both cases.
+	ClaimsSource string `json:"claims_source"`
+
+	// SigningAlgorithms lists the algorithms the ID token may be signed with. Defaults to `RS256`.
+	SigningAlgorithms []string `json:"signing_algorithms"`
+
+	// ClockSkew is the tolerated clock skew when checking the ID token's expiry, for example `30s`.
+	ClockSkew string `json:"clock_skew"`
+
+	// RequiredACR lists the Authentication Context Class References of which the ID token's `acr` claim
+	// must be one. If set, the values are also requested using the `acr_values` parameter.
+	RequiredACR []string `json:"required_acr"`
+
+	// Logout configures logout propagation between ORY Kratos and the provider.
+	Logout LogoutConfiguration `json:"logout"`
+}
+
+type LogoutConfiguration struct {
+	// RPInitiated redirects the browser to the provider's end session endpoint when signing out of ORY Kratos
+	// if the session was issued by this provider.
+	RPInitiated bool `json:"rp_initiated"`
+
+	// EndSessionURL is the provider's end session endpoint. If empty, it is discovered using the issuer URL.
+	EndSessionURL string `json:"end_session_url"`
+
+	// BackChannel accepts back-channel logout tokens from the provider and revokes all sessions of the
+	// identity linked to the token's subject.
+	BackChannel bool `json:"back_channel"`
 }
 
 func (p Configuration) Redir(public *url.URL) string {
@@ -97,6 +141,8 @@ func (c ConfigurationCollection) Provider(id string, public *url.URL) (Provider,
 				return NewProviderGitHub(&p, public), nil
 			case addProviderName("gitlab"):
 				return NewProviderGitLab(&p, public), nil
+			case addProviderName("bitbucket"):
+				return NewProviderBitbucket(&p, public), nil
 			case addProviderName("microsoft"):
 				return NewProviderMicrosoft(&p, public), nil
 			case addProviderName("discord"):
@@ -105,6 +151,8 @@ func (c ConfigurationCollection) Provider(id string, public *url.URL) (Provider,
 				return NewProviderSlack(&p, public), nil
 			case addProviderName("facebook"):
 				return NewProviderFacebook(&p, public), nil
+			case addProviderName("dev"):
+				return NewProviderDev(&p, public), nil
 			}
 			return nil, errors.Errorf("provider type %s is not supported, supported are: %v", p.Provider, providerNames)
 		}
@@ -0,0 +1,247 @@
+package oidc
+
+import (
+	"context"
+	"encoding/json"
+	"html/template"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/julienschmidt/httprouter"
+	"github.com/pkg/errors"
+	"golang.org/x/oauth2"
+
+	"github.com/ory/herodot"
+	"github.com/ory/x/randx"
+	"github.com/ory/x/urlx"
+
+	"github.com/ory/kratos/x"
+)
+
+const (
+	RouteDevAuth  = RouteBase + "/dev/auth"
+	RouteDevToken = RouteBase + "/dev/token"
+
+	devCodeLifespan = time.Minute * 5
+)
+
+var _ Provider = new(ProviderDev)
+
+// ProviderDev is a mock OpenID Connect Provider served by ORY Kratos itself. Instead of authenticating the
+// user, it asks for the subject and claims to be returned. It can only be used in dev mode.
+type ProviderDev struct {
+	config *Configuration
+	public *url.URL
+}
+
+func NewProviderDev(
+	config *Configuration,
+	public *url.URL,
+) *ProviderDev {
+	return &ProviderDev{
+		config: config,
+		public: public,
+	}
+}
+
+func (d *ProviderDev) Config() *Configuration {
+	return d.config
+}
+
+func (d *ProviderDev) OAuth2(ctx context.Context) (*oauth2.Config, error) {
+	return &oauth2.Config{
+		ClientID:     d.config.ClientID,
+		ClientSecret: d.config.ClientSecret,
+		Endpoint: oauth2.Endpoint{
+			AuthURL:   urlx.AppendPaths(d.public, RouteDevAuth).String(),
+			TokenURL:  urlx.AppendPaths(d.public, RouteDevToken).String(),
+			AuthStyle: oauth2.AuthStyleInParams,
+		},
+		Scopes:      d.config.Scope,
+		RedirectURL: d.config.Redir(d.public),
+	}, nil
+}
+
+func (d *ProviderDev) AuthCodeURLOptions(r ider) []oauth2.AuthCodeOption {
+	return []oauth2.AuthCodeOption{}
+}
+
+func (d *ProviderDev) Claims(ctx context.Context, exchange *oauth2.Token) (*Claims, error) {
+	raw, err := json.Marshal(exchange.Extra("claims"))
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	var claims Claims
+	if err := json.Unmarshal(raw, &claims); err != nil {
+		return nil, errors.WithStack(herodot.ErrInternalServerError.WithReasonf("Unable to decode the dev provider's claims: %s", err))
+	}
+
+	if len(claims.Subject) == 0 {
+		return nil, errors.WithStack(herodot.ErrInternalServerError.WithReason("The dev provider did not return a subject."))
+	}
+
+	return &claims, nil
+}
+
+type (
+	devCode struct {
+		claims    *Claims
+		expiresAt time.Time
+	}
+
+	// devCodes keeps the authorization codes issued by the dev provider in memory.
+	devCodes struct {
+		sync.Mutex
+		codes map[string]devCode
+	}
+)
+
+func newDevCodes() *devCodes {
+	return &devCodes{codes: map[string]devCode{}}
+}
+
+func (c *devCodes) issue(claims *Claims) string {
+	c.Lock()
+	defer c.Unlock()
+
+	code := randx.MustString(32, randx.AlphaNum)
+	c.codes[code] = devCode{claims: claims, expiresAt: time.Now().Add(devCodeLifespan)}
+	return code
+}
+
+func (c *devCodes) use(code string) (*Claims, bool) {
+	c.Lock()
+	defer c.Unlock()
+
+	dc, ok := c.codes[code]
+	delete(c.codes, code)
+	if !ok || dc.expiresAt.Before(time.Now()) {
+		return nil, false
+	}
+	return dc.claims, true
+}
+
+var devAuthTemplate = template.Must(template.New("dev").Parse(`<!DOCTYPE html>
+<html>
+<head><title>ORY Kratos Dev OpenID Connect Provider</title></head>
+<body>
+<h1>ORY Kratos Dev OpenID Connect Provider</h1>
+<p>This provider is only available in dev mode. Choose the claims to sign in with.</p>
+<form method="POST" action="{{.Action}}">
+<input type="hidden" name="redirect_uri" value="{{.RedirectURI}}">
+<input type="hidden" name="state" value="{{.State}}">
+<label>Subject <input type="text" name="sub" value="{{.Subject}}" required></label><br>
+<label>Email <input type="email" name="email" value=""></label><br>
+<label>Name <input type="text" name="name" value=""></label><br>
+<button type="submit">Sign in</button>
+</form>
+</body>
+</html>`))
+
+func (s *Strategy) setDevRoutes(r *x.RouterPublic) {
+	if handle, _, _ := r.Lookup("GET", RouteDevAuth); handle == nil {
+		s.d.CSRFHandler().IgnorePath(RouteDevAuth)
+		s.d.CSRFHandler().IgnorePath(RouteDevToken)
+		r.GET(RouteDevAuth, s.isDevMode(s.handleDevAuth))
+		r.POST(RouteDevAuth, s.isDevMode(s.handleDevAuthSubmit))
+		r.POST(RouteDevToken, s.isDevMode(s.handleDevToken))
+	}
+}
+
+func (s *Strategy) isDevMode(h httprouter.Handle) httprouter.Handle {
+	return func(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+		if !s.d.Config(r.Context()).IsInsecureDevMode() {
+			s.d.Writer().WriteError(w, r, errors.WithStack(herodot.ErrNotFound.WithReason("The dev OpenID Connect Provider is only available in dev mode.")))
+			return
+		}
+		h(w, r, ps)
+	}
+}
+
+// validateDevRedirectURI ensures that the dev provider only redirects to the OpenID Connect callback.
+func (s *Strategy) validateDevRedirectURI(r *http.Request, redirectURI string) error {
+	callback := urlx.AppendPaths(s.d.Config(r.Context()).SelfPublicURL(r), strings.Replace(RouteCallback, ":provider", "", 1)).String()
+	if !strings.HasPrefix(redirectURI, callback) {
+		return errors.WithStack(herodot.ErrBadRequest.WithReasonf("The redirect_uri must point to the OpenID Connect callback %s.", callback))
+	}
+	return nil
+}
+
+func (s *Strategy) handleDevAuth(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+	q := r.URL.Query()
+	if err := s.validateDevRedirectURI(r, q.Get("redirect_uri")); err != nil {
+		s.d.Writer().WriteError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	if err := devAuthTemplate.Execute(w, map[string]string{
+		"Action":      urlx.AppendPaths(s.d.Config(r.Context()).SelfPublicURL(r), RouteDevAuth).String(),
+		"RedirectURI": q.Get("redirect_uri"),
+		"State":       q.Get("state"),
+		"Subject":     x.NewUUID().String(),
+	}); err != nil {
+		s.d.Logger().WithError(err).Error("Unable to render the dev OpenID Connect Provider form.")
+	}
+}
+
+func (s *Strategy) handleDevAuthSubmit(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+	if err := r.ParseForm(); err != nil {
+		s.d.Writer().WriteError(w, r, errors.WithStack(herodot.ErrBadRequest.WithReasonf("Unable to parse form: %s", err)))
+		return
+	}
+
+	redirectURI := r.PostForm.Get("redirect_uri")
+	if err := s.validateDevRedirectURI(r, redirectURI); err != nil {
+		s.d.Writer().WriteError(w, r, err)
+		return
+	}
+
+	if len(r.PostForm.Get("sub")) == 0 {
+		s.d.Writer().WriteError(w, r, errors.WithStack(herodot.ErrBadRequest.WithReason("The subject must not be empty.")))
+		return
+	}
+
+	to, err := url.Parse(redirectURI)
+	if err != nil {
+		s.d.Writer().WriteError(w, r, errors.WithStack(herodot.ErrBadRequest.WithReasonf("Unable to parse redirect_uri: %s", err)))
+		return
+	}
+
+	code := s.devCodes.issue(&Claims{
+		Issuer:        urlx.AppendPaths(s.d.Config(r.Context()).SelfPublicURL(r), RouteBase, "dev").String(),
+		Subject:       r.PostForm.Get("sub"),
+		Email:         r.PostForm.Get("email"),
+		EmailVerified: len(r.PostForm.Get("email")) > 0,
+		Name:          r.PostForm.Get("name"),
+	})
+
+	http.Redirect(w, r, urlx.CopyWithQuery(to, url.Values{
+		"code":  {code},
+		"state": {r.PostForm.Get("state")},
+	}).String(), http.StatusSeeOther)
+}
+
+func (s *Strategy) handleDevToken(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+	if err := r.ParseForm(); err != nil {
+		s.d.Writer().WriteError(w, r, errors.WithStack(herodot.ErrBadRequest.WithReasonf("Unable to parse form: %s", err)))
+		return
+	}
+
+	claims, ok := s.devCodes.use(r.PostForm.Get("code"))
+	if !ok {
+		s.d.Writer().WriteError(w, r, errors.WithStack(herodot.ErrBadRequest.WithReason("The authorization code is invalid or expired.")))
+		return
+	}
+
+	s.d.Writer().Write(w, r, map[string]interface{}{
+		"access_token": randx.MustString(32, randx.AlphaNum),
+		"token_type":   "bearer",
+		"expires_in":   int(devCodeLifespan.Seconds()),
+		"claims":       claims,
+	})
+}
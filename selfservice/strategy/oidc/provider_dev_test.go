@@ -0,0 +1,120 @@
+package oidc_test
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ory/x/urlx"
+
+	"github.com/ory/kratos/internal"
+	"github.com/ory/kratos/internal/testhelpers"
+	"github.com/ory/kratos/selfservice/strategy/oidc"
+)
+
+func TestProviderDev(t *testing.T) {
+	conf, reg := internal.NewFastRegistryWithMocks(t)
+	publicTS, _ := testhelpers.NewKratosServer(t, reg)
+	conf.MustSet("dev", false)
+
+	public := urlx.ParseOrPanic(publicTS.URL)
+	provider := oidc.NewProviderDev(&oidc.Configuration{
+		ID:           "dev",
+		Provider:     "dev",
+		ClientID:     "client",
+		ClientSecret: "secret",
+	}, public)
+	redirectURI := provider.Config().Redir(public)
+
+	client := &http.Client{CheckRedirect: func(req *http.Request, via []*http.Request) error {
+		return http.ErrUseLastResponse
+	}}
+
+	var authorize = func(t *testing.T, values url.Values, expectCode int) *http.Response {
+		res, err := client.PostForm(publicTS.URL+oidc.RouteDevAuth, values)
+		require.NoError(t, err)
+		defer res.Body.Close()
+		body, err := ioutil.ReadAll(res.Body)
+		require.NoError(t, err)
+		require.EqualValues(t, expectCode, res.StatusCode, "%s", body)
+		return res
+	}
+
+	t.Run("case=is not available outside of dev mode", func(t *testing.T) {
+		res, err := client.Get(publicTS.URL + oidc.RouteDevAuth + "?" + url.Values{"redirect_uri": {redirectURI}}.Encode())
+		require.NoError(t, err)
+		defer res.Body.Close()
+		assert.EqualValues(t, http.StatusNotFound, res.StatusCode)
+
+		authorize(t, url.Values{"redirect_uri": {redirectURI}, "sub": {"foo"}}, http.StatusNotFound)
+	})
+
+	conf.MustSet("dev", true)
+
+	t.Run("case=renders the authorization form", func(t *testing.T) {
+		oa, err := provider.OAuth2(context.Background())
+		require.NoError(t, err)
+
+		res, err := client.Get(oa.AuthCodeURL("some-state"))
+		require.NoError(t, err)
+		defer res.Body.Close()
+		body, err := ioutil.ReadAll(res.Body)
+		require.NoError(t, err)
+
+		assert.EqualValues(t, http.StatusOK, res.StatusCode, "%s", body)
+		assert.Contains(t, string(body), `name="state" value="some-state"`)
+		assert.Contains(t, string(body), `name="sub"`)
+	})
+
+	t.Run("case=rejects redirects to anything but the callback", func(t *testing.T) {
+		res, err := client.Get(publicTS.URL + oidc.RouteDevAuth + "?" + url.Values{"redirect_uri": {"https://evil.example.org/"}}.Encode())
+		require.NoError(t, err)
+		defer res.Body.Close()
+		assert.EqualValues(t, http.StatusBadRequest, res.StatusCode)
+
+		authorize(t, url.Values{"redirect_uri": {"https://evil.example.org/"}, "sub": {"foo"}}, http.StatusBadRequest)
+	})
+
+	t.Run("case=requires a subject", func(t *testing.T) {
+		authorize(t, url.Values{"redirect_uri": {redirectURI}}, http.StatusBadRequest)
+	})
+
+	t.Run("case=issues a code which can be exchanged for the claims once", func(t *testing.T) {
+		res := authorize(t, url.Values{
+			"redirect_uri": {redirectURI},
+			"state":        {"some-state"},
+			"sub":          {"dev-subject"},
+			"email":        {"dev@ory.sh"},
+			"name":         {"Dev User"},
+		}, http.StatusSeeOther)
+
+		location, err := res.Location()
+		require.NoError(t, err)
+		assert.True(t, strings.HasPrefix(location.String(), redirectURI), "%s", location)
+		assert.Equal(t, "some-state", location.Query().Get("state"))
+		code := location.Query().Get("code")
+		require.NotEmpty(t, code)
+
+		oa, err := provider.OAuth2(context.Background())
+		require.NoError(t, err)
+
+		token, err := oa.Exchange(context.Background(), code)
+		require.NoError(t, err)
+
+		claims, err := provider.Claims(context.Background(), token)
+		require.NoError(t, err)
+		assert.Equal(t, "dev-subject", claims.Subject)
+		assert.Equal(t, "dev@ory.sh", claims.Email)
+		assert.True(t, claims.EmailVerified)
+		assert.Equal(t, "Dev User", claims.Name)
+
+		_, err = oa.Exchange(context.Background(), code)
+		require.Error(t, err)
+	})
+}
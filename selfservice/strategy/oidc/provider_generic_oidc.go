@@ -3,6 +3,8 @@ package oidc
 import (
 	"context"
 	"net/url"
+	"strings"
+	"time"
 
 	"github.com/pkg/errors"
 	"golang.org/x/oauth2"
@@ -81,20 +83,60 @@ func (g *ProviderGenericOIDC) AuthCodeURLOptions(r ider) []oauth2.AuthCodeOption
 	if len(g.config.RequestedClaims) != 0 {
 		options = append(options, oauth2.SetAuthURLParam("claims", string(g.config.RequestedClaims)))
 	}
+	if len(g.config.RequiredACR) != 0 {
+		options = append(options, oauth2.SetAuthURLParam("acr_values", strings.Join(g.config.RequiredACR, " ")))
+	}
 
 	return options
 }
 
+func (g *ProviderGenericOIDC) verifierConfig() (*gooidc.Config, error) {
+	c := &gooidc.Config{
+		ClientID:             g.config.ClientID,
+		SupportedSigningAlgs: g.config.SigningAlgorithms,
+	}
+
+	if len(g.config.ClockSkew) > 0 {
+		skew, err := time.ParseDuration(g.config.ClockSkew)
+		if err != nil {
+			return nil, errors.WithStack(herodot.ErrInternalServerError.WithReasonf("Unable to parse the clock skew of OpenID Connect Provider %s: %s", g.config.ID, err))
+		}
+		c.Now = func() time.Time {
+			return time.Now().Add(-skew)
+		}
+	}
+
+	return c, nil
+}
+
+func (g *ProviderGenericOIDC) validateACR(acr string) error {
+	if len(g.config.RequiredACR) > 0 && !stringslice.Has(g.config.RequiredACR, acr) {
+		return errors.WithStack(herodot.ErrBadRequest.WithReasonf(`The authentication context class reference "%s" is not one of the required values.`, acr))
+	}
+	return nil
+}
+
 func (g *ProviderGenericOIDC) verifyAndDecodeClaimsWithProvider(ctx context.Context, provider *gooidc.Provider, raw string) (*Claims, error) {
-	token, err := provider.
-		Verifier(&gooidc.Config{
-			ClientID: g.config.ClientID,
-		}).
-		Verify(ctx, raw)
+	c, err := g.verifierConfig()
+	if err != nil {
+		return nil, err
+	}
+
+	token, err := provider.Verifier(c).Verify(ctx, raw)
 	if err != nil {
 		return nil, errors.WithStack(herodot.ErrBadRequest.WithReasonf("%s", err))
 	}
 
+	var acr struct {
+		ACR string `json:"acr"`
+	}
+	if err := token.Claims(&acr); err != nil {
+		return nil, errors.WithStack(herodot.ErrBadRequest.WithReasonf("%s", err))
+	}
+	if err := g.validateACR(acr.ACR); err != nil {
+		return nil, err
+	}
+
 	var claims Claims
 	if err := token.Claims(&claims); err != nil {
 		return nil, errors.WithStack(herodot.ErrBadRequest.WithReasonf("%s", err))
@@ -103,6 +145,76 @@ func (g *ProviderGenericOIDC) verifyAndDecodeClaimsWithProvider(ctx context.Cont
 	return &claims, nil
 }
 
+// endSessionURL discovers the provider's end session endpoint.
+func (g *ProviderGenericOIDC) endSessionURL(ctx context.Context) (string, error) {
+	p, err := g.provider(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	var discovery struct {
+		EndSessionEndpoint string `json:"end_session_endpoint"`
+	}
+	if err := p.Claims(&discovery); err != nil {
+		return "", errors.WithStack(herodot.ErrInternalServerError.WithReasonf("Unable to decode the discovery document: %s", err))
+	}
+
+	if len(discovery.EndSessionEndpoint) == 0 {
+		return "", errors.WithStack(herodot.ErrInternalServerError.WithReasonf(`OpenID Connect Provider %s does not advertise an end session endpoint, please set "logout.end_session_url".`, g.config.ID))
+	}
+	return discovery.EndSessionEndpoint, nil
+}
+
+// verifyLogoutToken verifies the signature, issuer, and audience of a back-channel logout token. Logout tokens
+// are not required to expire, which is why their age is checked using the "iat" claim instead.
+func (g *ProviderGenericOIDC) verifyLogoutToken(ctx context.Context, raw string) (*gooidc.IDToken, error) {
+	p, err := g.provider(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	c, err := g.verifierConfig()
+	if err != nil {
+		return nil, err
+	}
+	c.SkipExpiryCheck = true
+
+	token, err := p.Verifier(c).Verify(ctx, raw)
+	if err != nil {
+		return nil, errors.WithStack(herodot.ErrBadRequest.WithReasonf("%s", err))
+	}
+	return token, nil
+}
+
+// claimsFromSource returns the claims from the configured source. If the source is the userinfo endpoint,
+// the userinfo's subject must match the subject of the verified ID token.
+func (g *ProviderGenericOIDC) claimsFromSource(ctx context.Context, provider *gooidc.Provider, exchange *oauth2.Token, idTokenClaims *Claims) (*Claims, error) {
+	switch g.config.ClaimsSource {
+	case "", ClaimsSourceIDToken:
+		return idTokenClaims, nil
+	case ClaimsSourceUserinfo:
+	default:
+		return nil, errors.WithStack(herodot.ErrInternalServerError.WithReasonf(`The claims source "%s" of OpenID Connect Provider %s is not supported.`, g.config.ClaimsSource, g.config.ID))
+	}
+
+	userInfo, err := provider.UserInfo(ctx, oauth2.StaticTokenSource(exchange))
+	if err != nil {
+		return nil, errors.WithStack(herodot.ErrInternalServerError.WithReasonf("Unable to fetch the userinfo: %s", err))
+	}
+
+	var claims Claims
+	if err := userInfo.Claims(&claims); err != nil {
+		return nil, errors.WithStack(herodot.ErrBadRequest.WithReasonf("%s", err))
+	}
+
+	if claims.Subject != idTokenClaims.Subject {
+		return nil, errors.WithStack(herodot.ErrBadRequest.WithReasonf("The subject of the userinfo does not match the subject of the ID token."))
+	}
+
+	claims.Issuer = idTokenClaims.Issuer
+	return &claims, nil
+}
+
 func (g *ProviderGenericOIDC) Claims(ctx context.Context, exchange *oauth2.Token) (*Claims, error) {
 	raw, ok := exchange.Extra("id_token").(string)
 	if !ok || len(raw) == 0 {
@@ -114,5 +226,10 @@ func (g *ProviderGenericOIDC) Claims(ctx context.Context, exchange *oauth2.Token
 		return nil, err
 	}
 
-	return g.verifyAndDecodeClaimsWithProvider(ctx, p, raw)
+	claims, err := g.verifyAndDecodeClaimsWithProvider(ctx, p, raw)
+	if err != nil {
+		return nil, err
+	}
+
+	return g.claimsFromSource(ctx, p, exchange, claims)
 }
@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"net/url"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -67,3 +68,41 @@ func TestProviderGenericOIDC_AddAuthCodeURLOptions(t *testing.T) {
 		assert.Contains(t, makeAuthCodeURL(t, r), "claims="+url.QueryEscape(string(makeOIDCClaims())))
 	})
 }
+
+func TestProviderGenericOIDC_ValidationOptions(t *testing.T) {
+	public, err := url.Parse("https://ory.sh")
+	require.NoError(t, err)
+	p := NewProviderGenericOIDC(&Configuration{
+		Provider:          "generic",
+		ID:                "valid",
+		ClientID:          "client",
+		IssuerURL:         "https://accounts.google.com",
+		SigningAlgorithms: []string{"ES256"},
+		ClockSkew:         "1m",
+		RequiredACR:       []string{"silver", "gold"},
+	}, public)
+
+	t.Run("case=expect acr values to be requested", func(t *testing.T) {
+		c, err := p.OAuth2(context.Background())
+		require.NoError(t, err)
+		assert.Contains(t, c.AuthCodeURL("state", p.AuthCodeURLOptions(&login.Flow{ID: x.NewUUID()})...), "acr_values="+url.QueryEscape("silver gold"))
+	})
+
+	t.Run("case=expect acr to be validated", func(t *testing.T) {
+		assert.NoError(t, p.validateACR("gold"))
+		assert.Error(t, p.validateACR("bronze"))
+		assert.Error(t, p.validateACR(""))
+	})
+
+	t.Run("case=expect verifier to use signing algorithms and clock skew", func(t *testing.T) {
+		c, err := p.verifierConfig()
+		require.NoError(t, err)
+		assert.Equal(t, []string{"ES256"}, c.SupportedSigningAlgs)
+		assert.WithinDuration(t, time.Now().Add(-time.Minute), c.Now(), time.Second)
+	})
+
+	t.Run("case=expect invalid clock skew to fail", func(t *testing.T) {
+		_, err := NewProviderGenericOIDC(&Configuration{ID: "invalid", ClockSkew: "soon"}, public).verifierConfig()
+		require.Error(t, err)
+	})
+}
@@ -1,7 +1,10 @@
 package oidc
 
 import (
+	"bytes"
 	"context"
+	"encoding/json"
+	"net/http"
 	"net/url"
 	"strings"
 
@@ -15,6 +18,25 @@ import (
 	"github.com/ory/herodot"
 )
 
+const (
+	// microsoftTenantCommon allows sign ins with work, school, and personal Microsoft accounts.
+	microsoftTenantCommon = "common"
+
+	// microsoftTenantOrganizations allows sign ins with work and school accounts of any tenant.
+	microsoftTenantOrganizations = "organizations"
+
+	// microsoftTenantConsumers allows sign ins with personal Microsoft accounts only.
+	microsoftTenantConsumers = "consumers"
+
+	// microsoftConsumersTenantID is the ID of the tenant all personal Microsoft accounts belong to.
+	microsoftConsumersTenantID = "9188040d-6c67-4c5b-b112-36a304b66dad"
+)
+
+var (
+	microsoftLoginURL = "https://login.microsoftonline.com"
+	microsoftGraphURL = "https://graph.microsoft.com"
+)
+
 type ProviderMicrosoft struct {
 	*ProviderGenericOIDC
 }
@@ -36,7 +58,7 @@ func (m *ProviderMicrosoft) OAuth2(ctx context.Context) (*oauth2.Config, error)
 		return nil, errors.WithStack(herodot.ErrInternalServerError.WithReasonf("No Tenant specified for the `microsoft` oidc provider %s", m.config.ID))
 	}
 
-	endpointPrefix := "https://login.microsoftonline.com/" + m.config.Tenant
+	endpointPrefix := microsoftLoginURL + "/" + m.config.Tenant
 	endpoint := oauth2.Endpoint{
 		AuthURL:  endpointPrefix + "/oauth2/v2.0/authorize",
 		TokenURL: endpointPrefix + "/oauth2/v2.0/token",
@@ -61,17 +83,147 @@ func (m *ProviderMicrosoft) Claims(ctx context.Context, exchange *oauth2.Token)
 		return nil, errors.WithStack(herodot.ErrBadRequest.WithReasonf("TenantID claim is not a valid UUID: %s", err))
 	}
 
-	issuer := "https://login.microsoftonline.com/" + unverifiedClaims.TenantID + "/v2.0"
+	if err := m.validateTenant(ctx, unverifiedClaims.TenantID); err != nil {
+		return nil, err
+	}
+
+	// Multi-tenant applications receive tokens issued by the user's tenant, which is why the issuer
+	// is derived from the tenant ID instead of the configured tenant.
+	issuer := microsoftLoginURL + "/" + unverifiedClaims.TenantID + "/v2.0"
 	p, err := gooidc.NewProvider(ctx, issuer)
 	if err != nil {
 		return nil, errors.WithStack(herodot.ErrInternalServerError.WithReasonf("Unable to initialize OpenID Connect Provider: %s", err))
 	}
 
-	return m.verifyAndDecodeClaimsWithProvider(ctx, p, raw)
+	claims, err := m.verifyAndDecodeClaimsWithProvider(ctx, p, raw)
+	if err != nil {
+		return nil, err
+	}
+
+	claims, err = m.claimsFromSource(ctx, p, exchange, claims)
+	if err != nil {
+		return nil, err
+	}
+
+	// If the user is a member of too many groups, the groups are not included in the ID token and must
+	// be fetched from Microsoft Graph instead.
+	if _, overage := unverifiedClaims.ClaimNames["groups"]; overage && len(claims.Groups) == 0 {
+		claims.Groups, err = m.memberGroups(ctx, exchange)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return claims, nil
+}
+
+// validateTenant returns an error if the user's tenant is not allowed by the configured tenant.
+func (m *ProviderMicrosoft) validateTenant(ctx context.Context, tenantID string) error {
+	switch m.config.Tenant {
+	case microsoftTenantCommon:
+		return nil
+	case microsoftTenantOrganizations:
+		if tenantID == microsoftConsumersTenantID {
+			return errors.WithStack(herodot.ErrBadRequest.WithReasonf("Personal Microsoft accounts are not allowed to sign in."))
+		}
+		return nil
+	case microsoftTenantConsumers:
+		if tenantID != microsoftConsumersTenantID {
+			return errors.WithStack(herodot.ErrBadRequest.WithReasonf("Only personal Microsoft accounts are allowed to sign in."))
+		}
+		return nil
+	}
+
+	expected := m.config.Tenant
+	if _, err := uuid.FromString(expected); err != nil {
+		// The tenant is configured using its domain name, for example `contoso.onmicrosoft.com`, which
+		// is resolved to the tenant ID using the tenant's discovery document.
+		expected, err = m.tenantID(ctx)
+		if err != nil {
+			return err
+		}
+	}
+
+	if !strings.EqualFold(expected, tenantID) {
+		return errors.WithStack(herodot.ErrBadRequest.WithReasonf("The Microsoft account belongs to tenant %s which is not allowed to sign in.", tenantID))
+	}
+	return nil
+}
+
+// tenantID resolves the configured tenant to its ID using the tenant's OpenID Connect discovery document.
+func (m *ProviderMicrosoft) tenantID(ctx context.Context) (string, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", microsoftLoginURL+"/"+m.config.Tenant+"/v2.0/.well-known/openid-configuration", nil)
+	if err != nil {
+		return "", errors.WithStack(err)
+	}
+
+	res, err := m.httpClient(ctx).Do(req)
+	if err != nil {
+		return "", errors.WithStack(herodot.ErrInternalServerError.WithReasonf("Unable to fetch the discovery document of tenant %s: %s", m.config.Tenant, err))
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return "", errors.WithStack(herodot.ErrInternalServerError.WithReasonf("Unable to fetch the discovery document of tenant %s: unexpected status code %d", m.config.Tenant, res.StatusCode))
+	}
+
+	var discovery struct {
+		Issuer string `json:"issuer"`
+	}
+	if err := json.NewDecoder(res.Body).Decode(&discovery); err != nil {
+		return "", errors.WithStack(herodot.ErrInternalServerError.WithReasonf("Unable to decode the discovery document of tenant %s: %s", m.config.Tenant, err))
+	}
+
+	// The issuer has the format https://login.microsoftonline.com/{tenant-id}/v2.0
+	return strings.TrimSuffix(strings.TrimPrefix(discovery.Issuer, microsoftLoginURL+"/"), "/v2.0"), nil
+}
+
+// memberGroups returns the IDs of all groups the user is a member of using Microsoft Graph. The access
+// token must be valid for Microsoft Graph, which requires the `GroupMember.Read.All` scope.
+func (m *ProviderMicrosoft) memberGroups(ctx context.Context, exchange *oauth2.Token) ([]string, error) {
+	c, err := m.OAuth2(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", microsoftGraphURL+"/v1.0/me/getMemberGroups", bytes.NewBufferString(`{"securityEnabledOnly":false}`))
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	res, err := c.Client(ctx, exchange).Do(req)
+	if err != nil {
+		return nil, errors.WithStack(herodot.ErrInternalServerError.WithReasonf("Unable to fetch the groups from Microsoft Graph: %s", err))
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return nil, errors.WithStack(herodot.ErrInternalServerError.WithReasonf("Unable to fetch the groups from Microsoft Graph: unexpected status code %d", res.StatusCode))
+	}
+
+	var groups struct {
+		Value []string `json:"value"`
+	}
+	if err := json.NewDecoder(res.Body).Decode(&groups); err != nil {
+		return nil, errors.WithStack(herodot.ErrInternalServerError.WithReasonf("Unable to decode the groups from Microsoft Graph: %s", err))
+	}
+
+	return groups.Value, nil
+}
+
+func (m *ProviderMicrosoft) httpClient(ctx context.Context) *http.Client {
+	if c, ok := ctx.Value(oauth2.HTTPClient).(*http.Client); ok {
+		return c
+	}
+	return http.DefaultClient
 }
 
 type microsoftUnverifiedClaims struct {
 	TenantID string `json:"tid,omitempty"`
+
+	// ClaimNames is set instead of the groups claim if the user is a member of too many groups.
+	ClaimNames map[string]string `json:"_claim_names,omitempty"`
 }
 
 func (c *microsoftUnverifiedClaims) Valid() error {
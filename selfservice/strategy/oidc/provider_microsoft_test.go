@@ -0,0 +1,76 @@
+package oidc
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/oauth2"
+
+	"github.com/ory/x/urlx"
+)
+
+func TestProviderMicrosoft(t *testing.T) {
+	const tenantID = "8eaef023-2b34-4da1-9baa-8bc8c9d6a490"
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/contoso.onmicrosoft.com/v2.0/.well-known/openid-configuration":
+			_ = json.NewEncoder(w).Encode(map[string]string{"issuer": microsoftLoginURL + "/" + tenantID + "/v2.0"})
+		case "/v1.0/me/getMemberGroups":
+			assert.Equal(t, "Bearer access-token", r.Header.Get("Authorization"))
+			_ = json.NewEncoder(w).Encode(map[string][]string{"value": {"group-a", "group-b"}})
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	t.Cleanup(ts.Close)
+
+	loginURL, graphURL := microsoftLoginURL, microsoftGraphURL
+	microsoftLoginURL, microsoftGraphURL = ts.URL, ts.URL
+	t.Cleanup(func() {
+		microsoftLoginURL, microsoftGraphURL = loginURL, graphURL
+	})
+
+	provider := func(tenant string) *ProviderMicrosoft {
+		return NewProviderMicrosoft(&Configuration{ID: "microsoft", Provider: "microsoft", Tenant: tenant}, urlx.ParseOrPanic("https://www.ory.sh/"))
+	}
+
+	t.Run("method=validateTenant", func(t *testing.T) {
+		for _, tc := range []struct {
+			tenant  string
+			tid     string
+			allowed bool
+		}{
+			{tenant: "common", tid: tenantID, allowed: true},
+			{tenant: "common", tid: microsoftConsumersTenantID, allowed: true},
+			{tenant: "organizations", tid: tenantID, allowed: true},
+			{tenant: "organizations", tid: microsoftConsumersTenantID, allowed: false},
+			{tenant: "consumers", tid: microsoftConsumersTenantID, allowed: true},
+			{tenant: "consumers", tid: tenantID, allowed: false},
+			{tenant: tenantID, tid: tenantID, allowed: true},
+			{tenant: tenantID, tid: microsoftConsumersTenantID, allowed: false},
+			{tenant: "contoso.onmicrosoft.com", tid: tenantID, allowed: true},
+			{tenant: "contoso.onmicrosoft.com", tid: microsoftConsumersTenantID, allowed: false},
+		} {
+			t.Run("tenant="+tc.tenant+"/tid="+tc.tid, func(t *testing.T) {
+				err := provider(tc.tenant).validateTenant(context.Background(), tc.tid)
+				if tc.allowed {
+					assert.NoError(t, err)
+				} else {
+					assert.Error(t, err)
+				}
+			})
+		}
+	})
+
+	t.Run("method=memberGroups", func(t *testing.T) {
+		groups, err := provider("common").memberGroups(context.Background(), &oauth2.Token{AccessToken: "access-token"})
+		require.NoError(t, err)
+		assert.Equal(t, []string{"group-a", "group-b"}, groups)
+	})
+}
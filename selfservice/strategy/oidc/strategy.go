@@ -14,8 +14,10 @@ import (
 	"github.com/julienschmidt/httprouter"
 	"github.com/pkg/errors"
 	"github.com/tidwall/gjson"
+	"golang.org/x/oauth2"
 
 	"github.com/ory/x/jsonx"
+	"github.com/ory/x/stringslice"
 
 	"github.com/ory/x/fetcher"
 
@@ -53,8 +55,11 @@ type dependencies interface {
 
 	x.LoggingProvider
 	x.CookieProvider
+	x.CSRFProvider
 	x.CSRFTokenGeneratorProvider
 	x.WriterProvider
+	x.ClockProvider
+	x.IDGeneratorProvider
 
 	identity.ValidationProvider
 	identity.PrivilegedPoolProvider
@@ -62,6 +67,9 @@ type dependencies interface {
 
 	session.ManagementProvider
 	session.HandlerProvider
+	session.PersistenceProvider
+	session.WebhookProvider
+	session.RevocationPublisherProvider
 
 	login.HookExecutorProvider
 	login.FlowPersistenceProvider
@@ -95,14 +103,17 @@ func isForced(req interface{}) bool {
 // It supports login, registration and settings via OpenID Providers.
 type Strategy struct {
 	d         dependencies
-	f         *fetcher.Fetcher
 	validator *schema.Validator
+	devCodes  *devCodes
 }
 
 type authCodeContainer struct {
 	FlowID string     `json:"flow_id"`
 	State  string     `json:"state"`
 	Form   url.Values `json:"form"`
+
+	// Scope is the additional scope requested using the settings flow.
+	Scope []string `json:"scope,omitempty"`
 }
 
 func (s *Strategy) CountActiveCredentials(cc map[identity.CredentialsType]identity.Credentials) (count int, err error) {
@@ -144,14 +155,33 @@ func (s *Strategy) setRoutes(r *x.RouterPublic) {
 	if handle, _, _ := r.Lookup("GET", RouteAuth); handle == nil {
 		r.GET(RouteAuth, wrappedHandleAuth)
 	}
+
+	s.setLogoutRoutes(r)
+	s.setDevRoutes(r)
 }
 
 func NewStrategy(d dependencies) *Strategy {
 	return &Strategy{
 		d:         d,
-		f:         fetcher.NewFetcher(),
 		validator: schema.NewValidator(),
+		devCodes:  newDevCodes(),
+	}
+}
+
+// fetchMapper loads the claims mapper. Mappers loaded over HTTP(S) are fetched using the client configured for
+// web hooks and claims mappers.
+func (s *Strategy) fetchMapper(ctx context.Context, source string) (*bytes.Buffer, error) {
+	c := s.d.Config(ctx)
+	hc, err := x.NewHookHTTPClient(c.HTTPClient(), c.Egress(), c.EgressDependency(config.EgressDependencyWebHooks))
+	if err != nil {
+		return nil, errors.WithStack(herodot.ErrInternalServerError.WithReasonf("Unable to configure the HTTP client of the claims mapper.").WithDebug(err.Error()))
 	}
+	return fetcher.NewFetcher(fetcher.WithClient(hc)).Fetch(source)
+}
+
+// egressContext makes the OAuth2 and OpenID Connect libraries send requests through the configured egress proxy.
+func (s *Strategy) egressContext(ctx context.Context) context.Context {
+	return context.WithValue(ctx, oauth2.HTTPClient, &http.Client{Transport: x.NewEgressTransport(s.d, config.EgressDependencyOIDC)})
 }
 
 func (s *Strategy) ID() identity.CredentialsType {
@@ -159,6 +189,7 @@ func (s *Strategy) ID() identity.CredentialsType {
 }
 
 func (s *Strategy) handleAuth(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	r = r.WithContext(s.egressContext(r.Context()))
 	rid := x.ParseUUID(ps.ByName("flow"))
 	if err := r.ParseForm(); err != nil {
 		s.handleError(w, r, rid, "", nil, errors.WithStack(herodot.ErrBadRequest.WithDebug(err.Error()).WithReasonf("Unable to parse HTTP form request: %s", err.Error())))
@@ -193,12 +224,22 @@ func (s *Strategy) handleAuth(w http.ResponseWriter, r *http.Request, ps httprou
 		return
 	}
 
+	scope := r.Form["scope"]
+	if len(scope) > 0 {
+		if err := validateAdditionalScope(req, provider, scope); err != nil {
+			s.handleError(w, r, rid, pid, nil, err)
+			return
+		}
+		config.Scopes = append(config.Scopes, scope...)
+	}
+
 	state := x.NewUUID().String()
 	if err := s.d.ContinuityManager().Pause(r.Context(), w, r, sessionName,
 		continuity.WithPayload(&authCodeContainer{
 			State:  state,
 			FlowID: rid.String(),
 			Form:   r.PostForm,
+			Scope:  scope,
 		}),
 		continuity.WithLifespan(time.Minute*30)); err != nil {
 		s.handleError(w, r, rid, pid, nil, err)
@@ -218,7 +259,11 @@ func (s *Strategy) validateFlow(ctx context.Context, r *http.Request, rid uuid.U
 			return ar, ErrAPIFlowNotSupported
 		}
 
-		if err := ar.Valid(); err != nil {
+		if !s.enabledFor(ctx, config.FlowRegistration) {
+			return ar, errors.WithStack(ErrFlowNotEnabled)
+		}
+
+		if err := ar.Valid(s.d.Clock()); err != nil {
 			return ar, err
 		}
 		return ar, nil
@@ -229,7 +274,11 @@ func (s *Strategy) validateFlow(ctx context.Context, r *http.Request, rid uuid.U
 			return ar, ErrAPIFlowNotSupported
 		}
 
-		if err := ar.Valid(); err != nil {
+		if !s.enabledFor(ctx, config.FlowLogin) {
+			return ar, errors.WithStack(ErrFlowNotEnabled)
+		}
+
+		if err := ar.Valid(s.d.Clock()); err != nil {
 			return ar, err
 		}
 		return ar, nil
@@ -241,12 +290,16 @@ func (s *Strategy) validateFlow(ctx context.Context, r *http.Request, rid uuid.U
 			return ar, ErrAPIFlowNotSupported
 		}
 
+		if !s.enabledFor(ctx, config.FlowSettings) {
+			return ar, errors.WithStack(ErrFlowNotEnabled)
+		}
+
 		sess, err := s.d.SessionManager().FetchFromRequest(ctx, r)
 		if err != nil {
 			return ar, err
 		}
 
-		if err := ar.Valid(sess); err != nil {
+		if err := ar.Valid(s.d.Clock(), sess); err != nil {
 			return ar, err
 		}
 		return ar, nil
@@ -255,6 +308,11 @@ func (s *Strategy) validateFlow(ctx context.Context, r *http.Request, rid uuid.U
 	return ar, err // this must return the error
 }
 
+// enabledFor returns true if this strategy may be used in the given flow.
+func (s *Strategy) enabledFor(ctx context.Context, f string) bool {
+	return s.d.Config(ctx).SelfServiceStrategy(string(s.ID())).EnabledFor(f)
+}
+
 func (s *Strategy) validateCallback(w http.ResponseWriter, r *http.Request) (ider, *authCodeContainer, error) {
 	var (
 		code  = r.URL.Query().Get("code")
@@ -296,7 +354,12 @@ func (s *Strategy) alreadyAuthenticated(w http.ResponseWriter, r *http.Request,
 		if _, ok := req.(*settings.Flow); ok {
 			// ignore this if it's a settings flow
 		} else if !isForced(req) {
-			http.Redirect(w, r, s.d.Config(r.Context()).SelfServiceBrowserDefaultReturnTo().String(), http.StatusFound)
+			returnTo, err := s.d.Config(r.Context()).SelfServiceBrowserDefaultReturnToOrErr()
+			if err != nil {
+				s.d.SelfServiceErrorManager().Forward(r.Context(), w, r, err)
+				return true
+			}
+			http.Redirect(w, r, returnTo.String(), http.StatusFound)
 			return true
 		}
 	}
@@ -305,6 +368,7 @@ func (s *Strategy) alreadyAuthenticated(w http.ResponseWriter, r *http.Request,
 }
 
 func (s *Strategy) handleCallback(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	r = r.WithContext(s.egressContext(r.Context()))
 	var (
 		code = r.URL.Query().Get("code")
 		pid  = ps.ByName("provider")
@@ -330,7 +394,7 @@ func (s *Strategy) handleCallback(w http.ResponseWriter, r *http.Request, ps htt
 		return
 	}
 
-	config, err := provider.OAuth2(context.Background())
+	config, err := provider.OAuth2(s.egressContext(context.Background()))
 	if err != nil {
 		s.handleError(w, r, req.GetID(), pid, nil, err)
 		return
@@ -361,7 +425,11 @@ func (s *Strategy) handleCallback(w http.ResponseWriter, r *http.Request, ps htt
 			s.handleError(w, r, req.GetID(), pid, nil, err)
 			return
 		}
-		s.linkProvider(w, r, &settings.UpdateContext{Session: sess, Flow: a}, claims, provider)
+		if len(container.Scope) > 0 {
+			s.consentProvider(w, r, &settings.UpdateContext{Session: sess, Flow: a}, claims, provider, token, append(config.Scopes, container.Scope...))
+			return
+		}
+		s.linkProvider(w, r, &settings.UpdateContext{Session: sess, Flow: a}, claims, provider, token, config.Scopes)
 		return
 	default:
 		s.handleError(w, r, req.GetID(), pid, nil, errors.WithStack(x.PseudoPanic.
@@ -370,6 +438,21 @@ func (s *Strategy) handleCallback(w http.ResponseWriter, r *http.Request, ps htt
 	}
 }
 
+// validateAdditionalScope returns an error unless the flow is a settings flow and the provider allows
+// consenting to all scopes.
+func validateAdditionalScope(req ider, provider Provider, scope []string) error {
+	if _, ok := req.(*settings.Flow); !ok {
+		return errors.WithStack(herodot.ErrBadRequest.WithReason("Additional scopes can only be requested using the settings flow."))
+	}
+
+	for _, sc := range scope {
+		if !stringslice.Has(provider.Config().AdditionalScope, sc) {
+			return errors.WithStack(herodot.ErrBadRequest.WithReasonf(`The scope "%s" can not be requested from provider "%s".`, sc, provider.Config().ID))
+		}
+	}
+	return nil
+}
+
 func uid(provider, subject string) string {
 	return fmt.Sprintf("%s:%s", provider, subject)
 }
@@ -415,6 +498,8 @@ func (s *Strategy) provider(ctx context.Context, r *http.Request, id string) (Pr
 		return nil, err
 	} else if provider, err := c.Provider(id, s.d.Config(ctx).SelfPublicURL(r)); err != nil {
 		return nil, err
+	} else if _, ok := provider.(*ProviderDev); ok && !s.d.Config(ctx).IsInsecureDevMode() {
+		return nil, errors.WithStack(herodot.ErrInternalServerError.WithReasonf(`OpenID Connect Provider "%s" uses the dev provider which is only available in dev mode.`, id))
 	} else {
 		return provider, nil
 	}
@@ -427,6 +512,10 @@ func (s *Strategy) handleError(w http.ResponseWriter, r *http.Request, rid uuid.
 	}
 
 	if lr, rerr := s.d.LoginFlowPersister().GetLoginFlow(r.Context(), rid); rerr == nil {
+		if method, ok := lr.Methods[s.ID()]; ok && strategy.IsTermsOfServiceNotAcceptedError(err) {
+			// The user must accept the new terms of service and then sign in again.
+			strategy.SetTermsOfServiceField(s.d.Config(r.Context()), method.Config)
+		}
 		s.d.LoginFlowErrorHandler().WriteFlowError(w, r, s.ID(), lr, err)
 		return
 	} else if sr, rerr := s.d.SettingsFlowPersister().GetSettingsFlow(r.Context(), rid); rerr == nil {
@@ -442,6 +531,7 @@ func (s *Strategy) handleError(w http.ResponseWriter, r *http.Request, rid uuid.
 		if method, ok := rr.Methods[s.ID()]; ok {
 			method.Config.UnsetField("provider")
 			method.Config.Reset()
+			strategy.SetTermsOfServiceField(s.d.Config(r.Context()), method.Config)
 
 			if traits != nil {
 				for _, field := range form.NewHTMLFormFromJSON("", traits, "traits").Fields {
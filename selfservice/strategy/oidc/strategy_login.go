@@ -9,9 +9,11 @@ import (
 
 	"github.com/ory/herodot"
 
+	"github.com/ory/kratos/driver/config"
 	"github.com/ory/kratos/identity"
 	"github.com/ory/kratos/selfservice/flow"
 	"github.com/ory/kratos/selfservice/flow/login"
+	"github.com/ory/kratos/selfservice/strategy"
 	"github.com/ory/kratos/x"
 )
 
@@ -52,6 +54,11 @@ func (s *Strategy) processLogin(w http.ResponseWriter, r *http.Request, a *login
 
 			s.d.Logger().WithField("provider", provider.Config().ID).WithField("subject", claims.Subject).Debug("Received successful OpenID Connect callback but user is not registered. Re-initializing registration flow now.")
 
+			if !s.enabledFor(r.Context(), config.FlowRegistration) {
+				s.handleError(w, r, a.GetID(), provider.Config().ID, nil, errors.WithStack(ErrFlowNotEnabled))
+				return
+			}
+
 			// This flow only works for browsers anyways.
 			aa, err := s.d.RegistrationHandler().NewRegistrationFlow(w, r, flow.TypeBrowser)
 			if err != nil {
@@ -75,6 +82,16 @@ func (s *Strategy) processLogin(w http.ResponseWriter, r *http.Request, a *login
 
 	for _, c := range o.Providers {
 		if c.Subject == claims.Subject && c.Provider == provider.Config().ID {
+			if err := strategy.AcceptTermsOfServiceOnLogin(r.Context(), s.d, i, container.Form.Get(strategy.TermsOfServiceFieldName)); err != nil {
+				s.handleError(w, r, a.GetID(), provider.Config().ID, nil, err)
+				return
+			}
+
+			if err := s.rememberProvider(w, r, provider); err != nil {
+				s.handleError(w, r, a.GetID(), provider.Config().ID, nil, err)
+				return
+			}
+
 			if err = s.d.LoginHookExecutor().PostLoginHook(w, r, identity.CredentialsTypeOIDC, a, i); err != nil {
 				s.handleError(w, r, a.GetID(), provider.Config().ID, nil, err)
 				return
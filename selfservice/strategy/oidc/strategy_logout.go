@@ -0,0 +1,219 @@
+package oidc
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"time"
+
+	gooidc "github.com/coreos/go-oidc"
+	"github.com/julienschmidt/httprouter"
+	"github.com/pkg/errors"
+
+	"github.com/ory/herodot"
+	"github.com/ory/x/urlx"
+
+	"github.com/ory/kratos/continuity"
+	"github.com/ory/kratos/identity"
+	"github.com/ory/kratos/selfservice/flow/logout"
+	"github.com/ory/kratos/selfservice/strategy"
+	"github.com/ory/kratos/x"
+)
+
+const (
+	RouteBackChannelLogout = RouteBase + "/backchannel-logout/:provider"
+
+	logoutSessionName = "ory_kratos_oidc_logout"
+
+	// backChannelLogoutEvent must be a member of the logout token's events claim.
+	backChannelLogoutEvent = "http://schemas.openid.net/event/backchannel-logout"
+
+	// backChannelLogoutMaxAge is the maximum age of accepted logout tokens.
+	backChannelLogoutMaxAge = 5 * time.Minute
+)
+
+var _ logout.Strategy = new(Strategy)
+
+type (
+	logoutContainer struct {
+		Provider string `json:"provider"`
+	}
+	logoutTokenClaims struct {
+		Subject  string                 `json:"sub"`
+		IssuedAt int64                  `json:"iat"`
+		Events   map[string]interface{} `json:"events"`
+		Nonce    *string                `json:"nonce"`
+	}
+)
+
+func (s *Strategy) setLogoutRoutes(r *x.RouterPublic) {
+	if handle, _, _ := r.Lookup("POST", RouteBackChannelLogout); handle == nil {
+		s.d.CSRFHandler().IgnorePath(RouteBackChannelLogout)
+		r.POST(RouteBackChannelLogout, strategy.IsDisabled(s.d, s.ID().String(), s.handleBackChannelLogout))
+	}
+}
+
+// rememberProvider remembers the provider the session was issued by so that signing out of ORY Kratos also
+// ends the session at the provider. It does nothing unless RP-initiated logout is enabled for the provider.
+func (s *Strategy) rememberProvider(w http.ResponseWriter, r *http.Request, provider Provider) error {
+	if !provider.Config().Logout.RPInitiated {
+		return nil
+	}
+
+	return s.d.ContinuityManager().Pause(r.Context(), w, r, logoutSessionName,
+		continuity.WithPayload(&logoutContainer{Provider: provider.Config().ID}),
+		continuity.WithLifespan(s.d.Config(r.Context()).SessionLifespan()))
+}
+
+// UpstreamLogoutURL returns the provider's end session URL if the session was issued by a provider with
+// RP-initiated logout enabled.
+func (s *Strategy) UpstreamLogoutURL(w http.ResponseWriter, r *http.Request, returnTo *url.URL) (*url.URL, error) {
+	r = r.WithContext(s.egressContext(r.Context()))
+	var container logoutContainer
+	if _, err := s.d.ContinuityManager().Continue(r.Context(), w, r, logoutSessionName, continuity.WithPayload(&container)); err != nil {
+		// The session was not issued by a provider with RP-initiated logout enabled.
+		return nil, nil
+	}
+
+	provider, err := s.provider(r.Context(), r, container.Provider)
+	if err != nil {
+		return nil, err
+	}
+
+	if !provider.Config().Logout.RPInitiated {
+		return nil, nil
+	}
+
+	endSession := provider.Config().Logout.EndSessionURL
+	if len(endSession) == 0 {
+		discoverer, ok := provider.(interface {
+			endSessionURL(ctx context.Context) (string, error)
+		})
+		if !ok {
+			return nil, errors.WithStack(herodot.ErrInternalServerError.WithReasonf(`OpenID Connect Provider "%s" does not support discovering the end session endpoint, please set "logout.end_session_url".`, container.Provider))
+		}
+
+		if endSession, err = discoverer.endSessionURL(r.Context()); err != nil {
+			return nil, err
+		}
+	}
+
+	u, err := url.Parse(endSession)
+	if err != nil {
+		return nil, errors.WithStack(herodot.ErrInternalServerError.WithReasonf("Unable to parse the end session URL of OpenID Connect Provider %s: %s", container.Provider, err))
+	}
+
+	return urlx.CopyWithQuery(u, url.Values{
+		"client_id":                {provider.Config().ClientID},
+		"post_logout_redirect_uri": {returnTo.String()},
+	}), nil
+}
+
+// swagger:route POST /self-service/methods/oidc/backchannel-logout/{provider} public submitOIDCBackChannelLogout
+//
+// Accept an OpenID Connect Back-Channel Logout Token
+//
+// This endpoint revokes all sessions of the identity linked to the logout token's subject. It implements
+// [OpenID Connect Back-Channel Logout 1.0](https://openid.net/specs/openid-connect-backchannel-1_0.html)
+// and must be enabled per provider using `logout.back_channel`.
+//
+//     Consumes:
+//     - application/x-www-form-urlencoded
+//
+//     Schemes: http, https
+//
+//     Responses:
+//       200: emptyResponse
+//       400: genericError
+//       500: genericError
+func (s *Strategy) handleBackChannelLogout(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	w.Header().Set("Cache-Control", "no-store")
+
+	if err := s.backChannelLogout(r, ps.ByName("provider")); err != nil {
+		s.d.Writer().WriteError(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+func (s *Strategy) backChannelLogout(r *http.Request, pid string) error {
+	r = r.WithContext(s.egressContext(r.Context()))
+	provider, err := s.provider(r.Context(), r, pid)
+	if err != nil {
+		return err
+	}
+
+	if !provider.Config().Logout.BackChannel {
+		return errors.WithStack(herodot.ErrNotFound.WithReasonf(`Back-channel logout is not enabled for OpenID Connect Provider "%s".`, pid))
+	}
+
+	verifier, ok := provider.(interface {
+		verifyLogoutToken(ctx context.Context, raw string) (*gooidc.IDToken, error)
+	})
+	if !ok {
+		return errors.WithStack(herodot.ErrBadRequest.WithReasonf(`OpenID Connect Provider "%s" does not support back-channel logout.`, pid))
+	}
+
+	if err := r.ParseForm(); err != nil {
+		return errors.WithStack(herodot.ErrBadRequest.WithReasonf("Unable to parse HTTP form request: %s", err))
+	}
+
+	token, err := verifier.verifyLogoutToken(r.Context(), r.PostForm.Get("logout_token"))
+	if err != nil {
+		return err
+	}
+
+	var claims logoutTokenClaims
+	if err := token.Claims(&claims); err != nil {
+		return errors.WithStack(herodot.ErrBadRequest.WithReasonf("Unable to decode the logout token: %s", err))
+	}
+
+	if err := claims.validate(s.d.Clock().Now()); err != nil {
+		return err
+	}
+
+	i, _, err := s.d.PrivilegedIdentityPool().FindByCredentialsIdentifier(r.Context(), identity.CredentialsTypeOIDC, uid(pid, claims.Subject))
+	if errors.Is(err, herodot.ErrNotFound) {
+		// There is nothing to revoke if the subject never signed in.
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	if err := s.d.SessionPersister().DeleteSessionsByIdentity(r.Context(), i.ID); err != nil {
+		return err
+	}
+
+	s.d.SessionWebhook().IdentitySessionsRevoked(r.Context(), i.ID)
+	s.d.SessionRevocationPublisher().IdentitySessionsRevoked(r.Context(), i.ID)
+	s.d.Logger().
+		WithRequest(r).
+		WithField("provider", pid).
+		WithField("identity_id", i.ID).
+		Info("Revoked all sessions of the identity because the OpenID Connect Provider sent a back-channel logout token.")
+	return nil
+}
+
+// validate checks the claims of a verified logout token as required by OpenID Connect Back-Channel Logout 1.0.
+// Logout tokens which only identify the provider's session using the "sid" claim are not supported because
+// ORY Kratos does not know the provider's session IDs.
+func (c *logoutTokenClaims) validate(now time.Time) error {
+	if _, ok := c.Events[backChannelLogoutEvent]; !ok {
+		return errors.WithStack(herodot.ErrBadRequest.WithReasonf(`The logout token does not contain the "%s" event.`, backChannelLogoutEvent))
+	}
+
+	if c.Nonce != nil {
+		return errors.WithStack(herodot.ErrBadRequest.WithReason("The logout token must not contain a nonce."))
+	}
+
+	if len(c.Subject) == 0 {
+		return errors.WithStack(herodot.ErrBadRequest.WithReason(`The logout token does not contain the "sub" claim.`))
+	}
+
+	if time.Unix(c.IssuedAt, 0).Before(now.Add(-backChannelLogoutMaxAge)) {
+		return errors.WithStack(herodot.ErrBadRequest.WithReason("The logout token was issued too long ago."))
+	}
+
+	return nil
+}
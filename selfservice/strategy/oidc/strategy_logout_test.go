@@ -0,0 +1,68 @@
+package oidc
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLogoutTokenClaims(t *testing.T) {
+	now := time.Now()
+	nonce := "nonce"
+	events := map[string]interface{}{backChannelLogoutEvent: map[string]interface{}{}}
+
+	for k, tc := range []struct {
+		claims logoutTokenClaims
+		err    bool
+	}{
+		{claims: logoutTokenClaims{Subject: "sub", IssuedAt: now.Unix(), Events: events}},
+		{claims: logoutTokenClaims{Subject: "sub", IssuedAt: now.Unix()}, err: true},
+		{claims: logoutTokenClaims{Subject: "sub", IssuedAt: now.Unix(), Events: events, Nonce: &nonce}, err: true},
+		{claims: logoutTokenClaims{IssuedAt: now.Unix(), Events: events}, err: true},
+		{claims: logoutTokenClaims{Subject: "sub", IssuedAt: now.Add(-time.Hour).Unix(), Events: events}, err: true},
+	} {
+		t.Run("case="+string(rune('a'+k)), func(t *testing.T) {
+			err := tc.claims.validate(now)
+			if tc.err {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestProviderGenericOIDC_EndSessionURL(t *testing.T) {
+	var endSession string
+	ts := httptest.NewServer(nil)
+	t.Cleanup(ts.Close)
+	ts.Config.Handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.NoError(t, json.NewEncoder(w).Encode(map[string]interface{}{
+			"issuer":               ts.URL,
+			"end_session_endpoint": endSession,
+		}))
+	})
+
+	public, err := url.Parse("https://ory.sh")
+	require.NoError(t, err)
+
+	t.Run("case=expect end session endpoint to be discovered", func(t *testing.T) {
+		endSession = ts.URL + "/logout"
+		actual, err := NewProviderGenericOIDC(&Configuration{ID: "valid", IssuerURL: ts.URL}, public).endSessionURL(context.Background())
+		require.NoError(t, err)
+		assert.Equal(t, ts.URL+"/logout", actual)
+	})
+
+	t.Run("case=expect missing end session endpoint to fail", func(t *testing.T) {
+		endSession = ""
+		_, err := NewProviderGenericOIDC(&Configuration{ID: "valid", IssuerURL: ts.URL}, public).endSessionURL(context.Background())
+		require.Error(t, err)
+	})
+}
@@ -12,6 +12,7 @@ import (
 	"github.com/ory/kratos/identity"
 	"github.com/ory/kratos/selfservice/flow"
 	"github.com/ory/kratos/selfservice/flow/registration"
+	"github.com/ory/kratos/selfservice/strategy"
 	"github.com/ory/kratos/x"
 )
 
@@ -41,6 +42,7 @@ func (s *Strategy) PopulateRegistrationMethod(r *http.Request, sr *registration.
 	if err != nil {
 		return err
 	}
+	strategy.SetTermsOfServiceField(s.d.Config(r.Context()), config)
 	sr.Methods[s.ID()] = &registration.FlowMethod{
 		Method: s.ID(),
 		Config: &registration.FlowMethodConfig{FlowMethodConfigurator: config},
@@ -63,6 +65,11 @@ func (s *Strategy) processRegistration(w http.ResponseWriter, r *http.Request, a
 			WithField("subject", claims.Subject).
 			Debug("Received successful OpenID Connect callback but user is already registered. Re-initializing login flow now.")
 
+		if !s.enabledFor(r.Context(), config.FlowLogin) {
+			s.handleError(w, r, a.GetID(), provider.Config().ID, nil, errors.WithStack(ErrFlowNotEnabled))
+			return
+		}
+
 		// This endpoint only handles browser flow at the moment.
 		ar, err := s.d.LoginHandler().NewLoginFlow(w, r, flow.TypeBrowser)
 		if err != nil {
@@ -74,7 +81,7 @@ func (s *Strategy) processRegistration(w http.ResponseWriter, r *http.Request, a
 		return
 	}
 
-	jn, err := s.f.Fetch(provider.Config().Mapper)
+	jn, err := s.fetchMapper(r.Context(), provider.Config().Mapper)
 	if err != nil {
 		s.handleError(w, r, a.GetID(), provider.Config().ID, nil, err)
 		return
@@ -128,7 +135,7 @@ func (s *Strategy) processRegistration(w http.ResponseWriter, r *http.Request, a
 	}
 
 	// Validate the identity itself
-	if err := s.d.IdentityValidator().Validate(r.Context(), i); err != nil {
+	if err := s.d.IdentityValidator().Validate(r.Context(), i, identity.ValidatorEnforceUsernamePolicy); err != nil {
 		s.handleError(w, r, a.GetID(), provider.Config().ID, i.Traits, err)
 		return
 	}
@@ -140,6 +147,17 @@ func (s *Strategy) processRegistration(w http.ResponseWriter, r *http.Request, a
 	}
 
 	i.SetCredentials(s.ID(), *creds)
+
+	if _, err := strategy.AcceptTermsOfService(s.d.Config(r.Context()), s.d.Clock().Now(), i, container.Form.Get(strategy.TermsOfServiceFieldName)); err != nil {
+		s.handleError(w, r, a.GetID(), provider.Config().ID, i.Traits, err)
+		return
+	}
+
+	if err := s.rememberProvider(w, r, provider); err != nil {
+		s.handleError(w, r, a.GetID(), provider.Config().ID, i.Traits, err)
+		return
+	}
+
 	if err := s.d.RegistrationExecutor().PostRegistrationHook(w, r, identity.CredentialsTypeOIDC, a, i); err != nil {
 		s.handleError(w, r, a.GetID(), provider.Config().ID, i.Traits, err)
 		return
@@ -6,11 +6,11 @@ import (
 	"net/http"
 	"net/url"
 	"strings"
-	"time"
 
 	"github.com/gobuffalo/uuid"
 	"github.com/julienschmidt/httprouter"
 	"github.com/pkg/errors"
+	"golang.org/x/oauth2"
 
 	"github.com/ory/herodot"
 	"github.com/ory/jsonschema/v3"
@@ -33,9 +33,13 @@ var UnknownConnectionValidationError = &jsonschema.ValidationError{
 	Message: "can not unlink non-existing OpenID Connect connection", InstancePtr: "#/"}
 var ConnectionExistValidationError = &jsonschema.ValidationError{
 	Message: "can not link unknown or already existing OpenID Connect connection", InstancePtr: "#/"}
+var UnknownConsentConnectionValidationError = &jsonschema.ValidationError{
+	Message: "can not request additional scopes for non-existing OpenID Connect connection", InstancePtr: "#/"}
+var ConsentSubjectMismatchValidationError = &jsonschema.ValidationError{
+	Message: "the additional scopes were granted by a different account than the linked OpenID Connect connection", InstancePtr: "#/"}
 
 func (s *Strategy) RegisterSettingsRoutes(router *x.RouterPublic) {
-	wrappedCompleteSettingsFlow := strategy.IsDisabled(s.d, s.SettingsStrategyID(), s.completeSettingsFlow)
+	wrappedCompleteSettingsFlow := strategy.IsSettingsDisabled(s.d, s.SettingsStrategyID(), s.completeSettingsFlow)
 	router.POST(SettingsPath, wrappedCompleteSettingsFlow)
 	router.GET(SettingsPath, wrappedCompleteSettingsFlow)
 }
@@ -162,6 +166,18 @@ func (s *Strategy) PopulateSettingsMethod(r *http.Request, id *identity.Identity
 		})
 	}
 
+	for _, l := range linked {
+		if len(l.Config().AdditionalScope) == 0 {
+			continue
+		}
+
+		f.Fields = append(f.Fields, form.Field{
+			Name:  "consent",
+			Type:  "submit",
+			Value: l.Config().ID,
+		})
+	}
+
 	sr.Methods[s.SettingsStrategyID()] = &settings.FlowMethod{
 		Method: s.SettingsStrategyID(),
 		Config: &settings.FlowMethodConfig{FlowMethodConfigurator: NewFlowMethod(f)},
@@ -188,6 +204,21 @@ type completeSelfServiceBrowserSettingsOIDCFlowPayload struct {
 	// in: body
 	Unlink string `json:"unlink"`
 
+	// Consent to additional scopes of this linked provider
+	//
+	// If set, neither `link` nor `unlink` must be set.
+	//
+	// type: string
+	// in: body
+	Consent string `json:"consent"`
+
+	// Scope lists the additional scopes to consent to
+	//
+	// Must be a subset of the provider's `additional_scope`. Defaults to all additional scopes.
+	//
+	// in: body
+	Scope []string `json:"scope"`
+
 	// Flow ID is the flow's ID.
 	//
 	// in: query
@@ -232,9 +263,12 @@ func (s *Strategy) completeSettingsFlow(w http.ResponseWriter, r *http.Request,
 		} else if u := len(p.Unlink); u > 0 {
 			s.unlinkProvider(w, r, ctxUpdate, &p)
 			return
+		} else if c := len(p.Consent); c > 0 {
+			s.initConsentProvider(w, r, ctxUpdate, &p)
+			return
 		}
 
-		s.handleSettingsError(w, r, ctxUpdate, &p, errors.WithStack(herodot.ErrInternalServerError.WithReason("Expected either link or unlink to be set when continuing flow but both are unset.")))
+		s.handleSettingsError(w, r, ctxUpdate, &p, errors.WithStack(herodot.ErrInternalServerError.WithReason("Expected either link, unlink, or consent to be set when continuing flow but all are unset.")))
 		return
 	} else if err != nil {
 		s.handleSettingsError(w, r, ctxUpdate, &p, err)
@@ -247,18 +281,29 @@ func (s *Strategy) completeSettingsFlow(w http.ResponseWriter, r *http.Request,
 
 	p.Link = r.Form.Get("link")
 	p.Unlink = r.Form.Get("unlink")
+	p.Consent = r.Form.Get("consent")
+	p.Scope = r.Form["scope"]
 	if l, u := len(p.Link), len(p.Unlink); l > 0 && u > 0 {
 		s.handleSettingsError(w, r, ctxUpdate, &p, errors.WithStack(&jsonschema.ValidationError{
 			Message:     "it is not possible to link and unlink providers in the same request",
 			InstancePtr: "#/",
 		}))
 		return
+	} else if c := len(p.Consent); c > 0 && (l > 0 || u > 0) {
+		s.handleSettingsError(w, r, ctxUpdate, &p, errors.WithStack(&jsonschema.ValidationError{
+			Message:     "it is not possible to consent to additional scopes and link or unlink providers in the same request",
+			InstancePtr: "#/",
+		}))
+		return
 	} else if l > 0 {
 		s.initLinkProvider(w, r, ctxUpdate, &p)
 		return
 	} else if u > 0 {
 		s.unlinkProvider(w, r, ctxUpdate, &p)
 		return
+	} else if c > 0 {
+		s.initConsentProvider(w, r, ctxUpdate, &p)
+		return
 	}
 
 	s.handleSettingsError(w, r, ctxUpdate, &p, errors.WithStack(errors.WithStack(&jsonschema.ValidationError{
@@ -303,7 +348,7 @@ func (s *Strategy) initLinkProvider(w http.ResponseWriter, r *http.Request, ctxU
 		return
 	}
 
-	if ctxUpdate.Session.AuthenticatedAt.Add(s.d.Config(r.Context()).SelfServiceFlowSettingsPrivilegedSessionMaxAge()).Before(time.Now()) {
+	if !ctxUpdate.Session.IsPrivileged(s.d.Clock().Now(), s.d.Config(r.Context()).SelfServiceFlowSettingsPrivilegedSessionMaxAge()) {
 		s.handleSettingsError(w, r, ctxUpdate, p, errors.WithStack(settings.NewFlowNeedsReAuth()))
 		return
 	}
@@ -314,10 +359,10 @@ func (s *Strategy) initLinkProvider(w http.ResponseWriter, r *http.Request, ctxU
 }
 
 func (s *Strategy) linkProvider(w http.ResponseWriter, r *http.Request,
-	ctxUpdate *settings.UpdateContext, claims *Claims, provider Provider) {
+	ctxUpdate *settings.UpdateContext, claims *Claims, provider Provider, token *oauth2.Token, scope []string) {
 	p := &completeSelfServiceBrowserSettingsOIDCFlowPayload{
 		Link: provider.Config().ID, FlowID: ctxUpdate.Flow.ID.String()}
-	if ctxUpdate.Session.AuthenticatedAt.Add(s.d.Config(r.Context()).SelfServiceFlowSettingsPrivilegedSessionMaxAge()).Before(time.Now()) {
+	if !ctxUpdate.Session.IsPrivileged(s.d.Clock().Now(), s.d.Config(r.Context()).SelfServiceFlowSettingsPrivilegedSessionMaxAge()) {
 		s.handleSettingsError(w, r, ctxUpdate, p, errors.WithStack(settings.NewFlowNeedsReAuth()))
 		return
 	}
@@ -328,26 +373,140 @@ func (s *Strategy) linkProvider(w http.ResponseWriter, r *http.Request,
 		return
 	}
 
+	link := ProviderCredentialsConfig{Subject: claims.Subject, Provider: provider.Config().ID}
+	if err := link.setTokens(s.d.Config(r.Context()).SecretsDefault(), token, scope); err != nil {
+		s.handleSettingsError(w, r, ctxUpdate, p, err)
+		return
+	}
+
 	var conf CredentialsConfig
 	creds, err := i.ParseCredentials(s.ID(), &conf)
 	if errors.Is(err, herodot.ErrNotFound) {
-		var err error
-		if creds, err = NewCredentials(provider.Config().ID, claims.Subject); err != nil {
-			s.handleSettingsError(w, r, ctxUpdate, p, err)
-			return
-		}
+		creds = &identity.Credentials{Type: s.ID()}
 	} else if err != nil {
 		s.handleSettingsError(w, r, ctxUpdate, p, err)
 		return
-	} else {
-		creds.Identifiers = append(creds.Identifiers, uid(provider.Config().ID, claims.Subject))
-		conf.Providers = append(conf.Providers, ProviderCredentialsConfig{
-			Subject: claims.Subject, Provider: provider.Config().ID})
-		creds.Config, err = json.Marshal(conf)
-		if err != nil {
+	}
+
+	creds.Identifiers = append(creds.Identifiers, uid(provider.Config().ID, claims.Subject))
+	conf.Providers = append(conf.Providers, link)
+	creds.Config, err = json.Marshal(conf)
+	if err != nil {
+		s.handleSettingsError(w, r, ctxUpdate, p, err)
+		return
+	}
+
+	i.Credentials[s.ID()] = *creds
+	if err := s.d.SettingsHookExecutor().PostSettingsHook(w, r, s.SettingsStrategyID(), ctxUpdate, i, settings.WithCallback(func(ctxUpdate *settings.UpdateContext) error {
+		return s.PopulateSettingsMethod(r, ctxUpdate.Session.Identity, ctxUpdate.Flow)
+	})); err != nil {
+		s.handleSettingsError(w, r, ctxUpdate, p, err)
+		return
+	}
+}
+
+func (s *Strategy) initConsentProvider(w http.ResponseWriter, r *http.Request, ctxUpdate *settings.UpdateContext,
+	p *completeSelfServiceBrowserSettingsOIDCFlowPayload) {
+	if !ctxUpdate.Session.IsPrivileged(s.d.Clock().Now(), s.d.Config(r.Context()).SelfServiceFlowSettingsPrivilegedSessionMaxAge()) {
+		s.handleSettingsError(w, r, ctxUpdate, p, errors.WithStack(settings.NewFlowNeedsReAuth()))
+		return
+	}
+
+	providers, err := s.Config(r.Context())
+	if err != nil {
+		s.handleSettingsError(w, r, ctxUpdate, p, err)
+		return
+	}
+
+	i, err := s.d.PrivilegedIdentityPool().GetIdentityConfidential(r.Context(), ctxUpdate.Session.Identity.ID)
+	if err != nil {
+		s.handleSettingsError(w, r, ctxUpdate, p, err)
+		return
+	}
+
+	linked, err := s.linkedProviders(r.Context(), r, providers, i)
+	if err != nil {
+		s.handleSettingsError(w, r, ctxUpdate, p, err)
+		return
+	}
+
+	var provider Provider
+	for _, l := range linked {
+		if l.Config().ID == p.Consent {
+			provider = l
+		}
+	}
+
+	if provider == nil || len(provider.Config().AdditionalScope) == 0 {
+		s.handleSettingsError(w, r, ctxUpdate, p, errors.WithStack(UnknownConsentConnectionValidationError))
+		return
+	}
+
+	scope := p.Scope
+	if len(scope) == 0 {
+		scope = provider.Config().AdditionalScope
+	}
+
+	if err := validateAdditionalScope(ctxUpdate.Flow, provider, scope); err != nil {
+		s.handleSettingsError(w, r, ctxUpdate, p, err)
+		return
+	}
+
+	http.Redirect(w, r, urlx.CopyWithQuery(urlx.AppendPaths(s.d.Config(r.Context()).SelfPublicURL(r),
+		strings.Replace(RouteAuth, ":flow", p.FlowID, 1)),
+		url.Values{"provider": {p.Consent}, "scope": scope}).String(), http.StatusFound)
+}
+
+// consentProvider stores the provider's tokens after the identity consented to additional scopes.
+func (s *Strategy) consentProvider(w http.ResponseWriter, r *http.Request,
+	ctxUpdate *settings.UpdateContext, claims *Claims, provider Provider, token *oauth2.Token, scope []string) {
+	p := &completeSelfServiceBrowserSettingsOIDCFlowPayload{
+		Consent: provider.Config().ID, Scope: scope, FlowID: ctxUpdate.Flow.ID.String()}
+	if !ctxUpdate.Session.IsPrivileged(s.d.Clock().Now(), s.d.Config(r.Context()).SelfServiceFlowSettingsPrivilegedSessionMaxAge()) {
+		s.handleSettingsError(w, r, ctxUpdate, p, errors.WithStack(settings.NewFlowNeedsReAuth()))
+		return
+	}
+
+	i, err := s.d.PrivilegedIdentityPool().GetIdentityConfidential(r.Context(), ctxUpdate.Session.Identity.ID)
+	if err != nil {
+		s.handleSettingsError(w, r, ctxUpdate, p, err)
+		return
+	}
+
+	var conf CredentialsConfig
+	creds, err := i.ParseCredentials(s.ID(), &conf)
+	if err != nil {
+		s.handleSettingsError(w, r, ctxUpdate, p, errors.WithStack(UnknownConsentConnectionValidationError))
+		return
+	}
+
+	var found bool
+	for k, link := range conf.Providers {
+		if link.Provider != provider.Config().ID {
+			continue
+		}
+
+		if link.Subject != claims.Subject {
+			s.handleSettingsError(w, r, ctxUpdate, p, errors.WithStack(ConsentSubjectMismatchValidationError))
+			return
+		}
+
+		if err := conf.Providers[k].setTokens(s.d.Config(r.Context()).SecretsDefault(), token, scope); err != nil {
 			s.handleSettingsError(w, r, ctxUpdate, p, err)
 			return
 		}
+		found = true
+	}
+
+	if !found {
+		s.handleSettingsError(w, r, ctxUpdate, p, errors.WithStack(UnknownConsentConnectionValidationError))
+		return
+	}
+
+	creds.Config, err = json.Marshal(conf)
+	if err != nil {
+		s.handleSettingsError(w, r, ctxUpdate, p, errors.WithStack(err))
+		return
 	}
 
 	i.Credentials[s.ID()] = *creds
@@ -361,7 +520,7 @@ func (s *Strategy) linkProvider(w http.ResponseWriter, r *http.Request,
 
 func (s *Strategy) unlinkProvider(w http.ResponseWriter, r *http.Request,
 	ctxUpdate *settings.UpdateContext, p *completeSelfServiceBrowserSettingsOIDCFlowPayload) {
-	if ctxUpdate.Session.AuthenticatedAt.Add(s.d.Config(r.Context()).SelfServiceFlowSettingsPrivilegedSessionMaxAge()).Before(time.Now()) {
+	if !ctxUpdate.Session.IsPrivileged(s.d.Clock().Now(), s.d.Config(r.Context()).SelfServiceFlowSettingsPrivilegedSessionMaxAge()) {
 		s.handleSettingsError(w, r, ctxUpdate, p, errors.WithStack(settings.NewFlowNeedsReAuth()))
 		return
 	}
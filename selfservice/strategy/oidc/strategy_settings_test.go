@@ -309,6 +309,22 @@ func TestSettingsStrategy(t *testing.T) {
 			checkCredentials(t, false, users[agent].ID, provider, "hackerman+github+"+testID)
 		})
 
+		t.Run("case=should not be able to unlink a connection once the privileged session expired on the injected clock", func(t *testing.T) {
+			agent, provider := "githuber", "github"
+			t.Cleanup(reset(t))
+
+			conf.MustSet(config.ViperKeySelfServiceSettingsPrivilegedAuthenticationAfter, time.Minute*5)
+			reg.WithClock(x.NewFakeClock(time.Now().Add(10 * time.Minute)))
+			t.Cleanup(func() {
+				reg.WithClock(x.SystemClock)
+			})
+
+			_, res, _ := unlink(t, agent, provider)
+			assert.Contains(t, res.Request.URL.String(), uiTS.URL+"/login")
+
+			checkCredentials(t, true, users[agent].ID, provider, "hackerman+github+"+testID)
+		})
+
 		t.Run("case=should not be able to unlink a connection without a privileged session", func(t *testing.T) {
 			agent, provider := "githuber", "github"
 
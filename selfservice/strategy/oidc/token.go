@@ -0,0 +1,100 @@
+package oidc
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"io"
+
+	"github.com/pkg/errors"
+	"golang.org/x/oauth2"
+
+	"github.com/ory/herodot"
+)
+
+// encryptToken encrypts the token using AES-GCM with a key derived from the first secret. Empty tokens are
+// returned as is.
+func encryptToken(secrets [][]byte, token string) (string, error) {
+	if len(token) == 0 {
+		return "", nil
+	}
+	if len(secrets) == 0 {
+		return "", errors.WithStack(herodot.ErrInternalServerError.WithReason("Unable to encrypt the provider token because no secret is configured."))
+	}
+
+	aead, err := tokenCipher(secrets[0])
+	if err != nil {
+		return "", err
+	}
+
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return "", errors.WithStack(err)
+	}
+
+	return base64.URLEncoding.EncodeToString(aead.Seal(nonce, nonce, []byte(token), nil)), nil
+}
+
+// decryptToken decrypts a token encrypted by encryptToken. All secrets are tried so that tokens remain
+// readable after secrets were rotated.
+func decryptToken(secrets [][]byte, encrypted string) (string, error) {
+	if len(encrypted) == 0 {
+		return "", nil
+	}
+
+	ciphertext, err := base64.URLEncoding.DecodeString(encrypted)
+	if err != nil {
+		return "", errors.WithStack(err)
+	}
+
+	for _, secret := range secrets {
+		aead, err := tokenCipher(secret)
+		if err != nil {
+			return "", err
+		}
+
+		if len(ciphertext) < aead.NonceSize() {
+			break
+		}
+
+		if plaintext, err := aead.Open(nil, ciphertext[:aead.NonceSize()], ciphertext[aead.NonceSize():], nil); err == nil {
+			return string(plaintext), nil
+		}
+	}
+
+	return "", errors.WithStack(herodot.ErrInternalServerError.WithReason("Unable to decrypt the provider token with any of the configured secrets."))
+}
+
+func tokenCipher(secret []byte) (cipher.AEAD, error) {
+	key := sha256.Sum256(secret)
+	block, err := aes.NewCipher(key[:])
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	return aead, nil
+}
+
+// setTokens stores the encrypted tokens and the granted scope of the provider.
+func (c *ProviderCredentialsConfig) setTokens(secrets [][]byte, token *oauth2.Token, scope []string) (err error) {
+	if c.AccessToken, err = encryptToken(secrets, token.AccessToken); err != nil {
+		return err
+	}
+	if c.RefreshToken, err = encryptToken(secrets, token.RefreshToken); err != nil {
+		return err
+	}
+
+	idToken, _ := token.Extra("id_token").(string)
+	if c.IDToken, err = encryptToken(secrets, idToken); err != nil {
+		return err
+	}
+
+	c.Scope = scope
+	return nil
+}
@@ -0,0 +1,68 @@
+package oidc
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/oauth2"
+
+	"github.com/ory/kratos/selfservice/flow/login"
+	"github.com/ory/kratos/selfservice/flow/settings"
+	"github.com/ory/x/urlx"
+)
+
+func TestTokenEncryption(t *testing.T) {
+	current, previous := []byte("current-secret-0123456789"), []byte("previous-secret-0123456789")
+
+	t.Run("case=round trip", func(t *testing.T) {
+		encrypted, err := encryptToken([][]byte{current}, "access-token")
+		require.NoError(t, err)
+		assert.NotContains(t, encrypted, "access-token")
+
+		decrypted, err := decryptToken([][]byte{current}, encrypted)
+		require.NoError(t, err)
+		assert.Equal(t, "access-token", decrypted)
+	})
+
+	t.Run("case=decrypts after secret rotation", func(t *testing.T) {
+		encrypted, err := encryptToken([][]byte{previous}, "access-token")
+		require.NoError(t, err)
+
+		decrypted, err := decryptToken([][]byte{current, previous}, encrypted)
+		require.NoError(t, err)
+		assert.Equal(t, "access-token", decrypted)
+
+		_, err = decryptToken([][]byte{current}, encrypted)
+		require.Error(t, err)
+	})
+
+	t.Run("case=empty tokens are not encrypted", func(t *testing.T) {
+		encrypted, err := encryptToken([][]byte{current}, "")
+		require.NoError(t, err)
+		assert.Empty(t, encrypted)
+	})
+
+	t.Run("case=sets tokens", func(t *testing.T) {
+		var c ProviderCredentialsConfig
+		token := (&oauth2.Token{AccessToken: "access-token", RefreshToken: "refresh-token"}).
+			WithExtra(map[string]interface{}{"id_token": "id-token"})
+		require.NoError(t, c.setTokens([][]byte{current}, token, []string{"openid", "calendar"}))
+
+		for expected, encrypted := range map[string]string{"access-token": c.AccessToken, "refresh-token": c.RefreshToken, "id-token": c.IDToken} {
+			decrypted, err := decryptToken([][]byte{current}, encrypted)
+			require.NoError(t, err)
+			assert.Equal(t, expected, decrypted)
+		}
+		assert.Equal(t, []string{"openid", "calendar"}, c.Scope)
+	})
+}
+
+func TestValidateAdditionalScope(t *testing.T) {
+	provider := NewProviderGenericOIDC(&Configuration{ID: "google", AdditionalScope: []string{"calendar", "drive"}}, urlx.ParseOrPanic("https://www.ory.sh/"))
+
+	assert.NoError(t, validateAdditionalScope(new(settings.Flow), provider, []string{"calendar"}))
+	assert.NoError(t, validateAdditionalScope(new(settings.Flow), provider, []string{"calendar", "drive"}))
+	assert.Error(t, validateAdditionalScope(new(settings.Flow), provider, []string{"calendar", "mail"}))
+	assert.Error(t, validateAdditionalScope(new(login.Flow), provider, []string{"calendar"}))
+}
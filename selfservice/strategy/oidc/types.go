@@ -35,6 +35,14 @@ func NewCredentials(provider, subject string) (*identity.Credentials, error) {
 type ProviderCredentialsConfig struct {
 	Subject  string `json:"subject"`
 	Provider string `json:"provider"`
+
+	// AccessToken, RefreshToken, and IDToken are the provider's tokens, encrypted using the default secret.
+	AccessToken  string `json:"access_token,omitempty"`
+	RefreshToken string `json:"refresh_token,omitempty"`
+	IDToken      string `json:"id_token,omitempty"`
+
+	// Scope is the scope the identity consented to.
+	Scope []string `json:"scope,omitempty"`
 }
 
 type FlowMethod struct {
@@ -13,6 +13,7 @@ import (
 	"github.com/ory/herodot"
 	"github.com/ory/x/urlx"
 
+	"github.com/ory/kratos/hash"
 	"github.com/ory/kratos/identity"
 	"github.com/ory/kratos/schema"
 	"github.com/ory/kratos/selfservice/flow"
@@ -29,7 +30,7 @@ const (
 func (s *Strategy) RegisterLoginRoutes(r *x.RouterPublic) {
 	s.d.CSRFHandler().IgnorePath(RouteLogin)
 
-	wrappedHandleLogin := strategy.IsDisabled(s.d, s.ID().String(), s.handleLogin)
+	wrappedHandleLogin := strategy.IsLoginDisabled(s.d, s.ID().String(), s.handleLogin)
 	r.POST(RouteLogin, wrappedHandleLogin)
 }
 
@@ -38,6 +39,9 @@ func (s *Strategy) handleLoginError(w http.ResponseWriter, r *http.Request, rr *
 		if method, ok := rr.Methods[identity.CredentialsTypePassword]; ok {
 			method.Config.Reset()
 			method.Config.SetValue("identifier", payload.Identifier)
+			if strategy.IsTermsOfServiceNotAcceptedError(err) {
+				strategy.SetTermsOfServiceField(s.d.Config(r.Context()), method.Config)
+			}
 			if rr.Type == flow.TypeBrowser {
 				method.Config.SetCSRF(s.d.GenerateCSRFToken(r))
 			}
@@ -120,7 +124,12 @@ func (s *Strategy) handleLogin(w http.ResponseWriter, r *http.Request, _ httprou
 
 	if _, err := s.d.SessionManager().FetchFromRequest(r.Context(), r); err == nil && !ar.Forced {
 		if ar.Type == flow.TypeBrowser {
-			http.Redirect(w, r, s.d.Config(r.Context()).SelfServiceBrowserDefaultReturnTo().String(), http.StatusFound)
+			returnTo, err := s.d.Config(r.Context()).SelfServiceBrowserDefaultReturnToOrErr()
+			if err != nil {
+				s.handleLoginError(w, r, ar, &p, err)
+				return
+			}
+			http.Redirect(w, r, returnTo.String(), http.StatusFound)
 			return
 		}
 
@@ -128,7 +137,7 @@ func (s *Strategy) handleLogin(w http.ResponseWriter, r *http.Request, _ httprou
 		return
 	}
 
-	if err := ar.Valid(); err != nil {
+	if err := ar.Valid(s.d.Clock()); err != nil {
 		s.handleLoginError(w, r, ar, &p, err)
 		return
 	}
@@ -146,9 +155,28 @@ func (s *Strategy) handleLogin(w http.ResponseWriter, r *http.Request, _ httprou
 		return
 	}
 
-	if err := s.d.Hasher().Compare(r.Context(), []byte(p.Password), []byte(o.HashedPassword)); err != nil {
+	if o.UseExternalCheck {
+		if err := s.migratePassword(r.Context(), i, p.Identifier, p.Password); err != nil {
+			s.handleLoginError(w, r, ar, &p, err)
+			return
+		}
+	} else if err := hash.Compare(r.Context(), s.d, s.d.Hasher(), []byte(p.Password), []byte(o.HashedPassword)); hash.IsOverloaded(err) {
+		s.handleLoginError(w, r, ar, &p, err)
+		return
+	} else if err != nil {
 		s.handleLoginError(w, r, ar, &p, errors.WithStack(schema.NewInvalidCredentialsError()))
 		return
+	} else if hash.NeedsUpgrade(r.Context(), s.d.Hasher(), []byte(o.HashedPassword)) {
+		// Imported hashes and hashes with outdated settings are replaced as soon as the password is known.
+		if err := s.updatePassword(r.Context(), i.ID, p.Password); err != nil {
+			s.d.SelfServiceErrorManager().Forward(r.Context(), w, r, err)
+			return
+		}
+	}
+
+	if err := strategy.AcceptTermsOfServiceOnLogin(r.Context(), s.d, i, p.TermsOfService); err != nil {
+		s.handleLoginError(w, r, ar, &p, err)
+		return
 	}
 
 	if err := s.d.LoginHookExecutor().PostLoginHook(w, r, identity.CredentialsTypePassword, ar, i); err != nil {
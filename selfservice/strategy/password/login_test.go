@@ -7,8 +7,10 @@ import (
 	"fmt"
 	"io/ioutil"
 	"net/http"
+	"net/http/httptest"
 	"net/url"
 	"strings"
+	"sync/atomic"
 	"testing"
 	"time"
 
@@ -541,4 +543,95 @@ func TestCompleteLogin(t *testing.T) {
 
 		assert.Equal(t, identifier, gjson.Get(body2, "identity.traits.subject").String(), "%s", body2)
 	})
+
+	t.Run("case=should migrate the password using the password migration hook", func(t *testing.T) {
+		identifier, pwd := x.NewUUID().String(), "legacy-password"
+		require.NoError(t, reg.PrivilegedIdentityPool().CreateIdentity(context.Background(), &identity.Identity{
+			ID:     x.NewUUID(),
+			Traits: identity.Traits(fmt.Sprintf(`{"subject":"%s"}`, identifier)),
+			Credentials: map[identity.CredentialsType]identity.Credentials{
+				identity.CredentialsTypePassword: {
+					Type:        identity.CredentialsTypePassword,
+					Identifiers: []string{identifier},
+					Config:      sqlxx.JSONRawMessage(`{"use_external_check":true}`),
+				},
+			},
+		}))
+
+		var calls int32
+		hookTS := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			atomic.AddInt32(&calls, 1)
+
+			var p password.MigrationHookPayload
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&p))
+			assert.Equal(t, identifier, p.Identifier)
+			if p.Password != pwd {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			w.WriteHeader(http.StatusNoContent)
+		}))
+		t.Cleanup(hookTS.Close)
+
+		conf.MustSet(config.ViperKeyPasswordMigrationHookURL, hookTS.URL)
+		t.Cleanup(func() {
+			conf.MustSet(config.ViperKeyPasswordMigrationHookURL, "")
+		})
+
+		var values = func(pwd string) func(v url.Values) {
+			return func(v url.Values) {
+				v.Set("identifier", identifier)
+				v.Set("password", pwd)
+			}
+		}
+
+		body := expectValidationError(t, true, false, values("not-legacy-password"))
+		assert.Equal(t,
+			errorsx.Cause(schema.NewInvalidCredentialsError()).(*schema.ValidationError).Messages[0].Text,
+			gjson.Get(body, "methods.password.config.messages.0.text").String(),
+			"%s", body,
+		)
+
+		body = testhelpers.SubmitLoginForm(t, true, nil, publicTS, values(pwd),
+			identity.CredentialsTypePassword, false, http.StatusOK, publicTS.URL+password.RouteLogin)
+		assert.Equal(t, identifier, gjson.Get(body, "session.identity.traits.subject").String(), "%s", body)
+		assert.EqualValues(t, 2, atomic.LoadInt32(&calls))
+
+		_, c, err := reg.PrivilegedIdentityPool().FindByCredentialsIdentifier(context.Background(), identity.CredentialsTypePassword, identifier)
+		require.NoError(t, err)
+		assert.True(t, strings.HasPrefix(gjson.GetBytes(c.Config, "hashed_password").String(), "$argon2id$"), "%s", c.Config)
+		assert.False(t, gjson.GetBytes(c.Config, "use_external_check").Exists(), "%s", c.Config)
+
+		t.Run("case=subsequent logins do not call the hook", func(t *testing.T) {
+			testhelpers.SubmitLoginForm(t, true, nil, publicTS, values(pwd),
+				identity.CredentialsTypePassword, false, http.StatusOK, publicTS.URL+password.RouteLogin)
+			assert.EqualValues(t, 2, atomic.LoadInt32(&calls))
+		})
+	})
+
+	t.Run("case=should accept an imported scrypt hash and upgrade it to argon2", func(t *testing.T) {
+		identifier := x.NewUUID().String()
+		require.NoError(t, reg.PrivilegedIdentityPool().CreateIdentity(context.Background(), &identity.Identity{
+			ID:     x.NewUUID(),
+			Traits: identity.Traits(fmt.Sprintf(`{"subject":"%s"}`, identifier)),
+			Credentials: map[identity.CredentialsType]identity.Credentials{
+				identity.CredentialsTypePassword: {
+					Type:        identity.CredentialsTypePassword,
+					Identifiers: []string{identifier},
+					// Test vector from https://github.com/firebase/scrypt (password "user1password").
+					Config: sqlxx.JSONRawMessage(`{"hashed_password":"$firescrypt$ln=14,r=8,p=1$42xEC+ixf3L2lw==$lSrfV15cpx95/sZS2W9c9Kp6i/LVgQNDNC/qzrCnh1SAyZvqmZqAjTdn3aoItz+VHjoZilo78198JAdRuid5lQ==$Bw==$jxspr8Ki0RYycVU8zykbdLGjFQ3McFUH0uiiTvC8pVMXAn210wjLNmdZJzxUECKbm0QsEmYUSDzZvpjeJ9WmXA=="}`),
+				},
+			},
+		}))
+
+		body := testhelpers.SubmitLoginForm(t, true, nil, publicTS, func(v url.Values) {
+			v.Set("identifier", identifier)
+			v.Set("password", "user1password")
+		}, identity.CredentialsTypePassword, false, http.StatusOK, publicTS.URL+password.RouteLogin)
+		assert.Equal(t, identifier, gjson.Get(body, "session.identity.traits.subject").String(), "%s", body)
+
+		_, c, err := reg.PrivilegedIdentityPool().FindByCredentialsIdentifier(context.Background(), identity.CredentialsTypePassword, identifier)
+		require.NoError(t, err)
+		assert.True(t, strings.HasPrefix(gjson.GetBytes(c.Config, "hashed_password").String(), "$argon2id$"), "%s", c.Config)
+	})
 }
@@ -0,0 +1,98 @@
+package password
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+
+	"github.com/gofrs/uuid"
+	"github.com/hashicorp/go-retryablehttp"
+	"github.com/pkg/errors"
+
+	"github.com/ory/herodot"
+
+	"github.com/ory/kratos/identity"
+	"github.com/ory/kratos/schema"
+)
+
+// MigrationHookPayload is sent to the password migration hook to verify a legacy password.
+type MigrationHookPayload struct {
+	// IdentityID is the ID of the identity trying to log in.
+	IdentityID uuid.UUID `json:"identity_id"`
+
+	// Identifier is the identifier the identity used to log in.
+	Identifier string `json:"identifier"`
+
+	// Password is the cleartext password which should be verified.
+	Password string `json:"password"`
+}
+
+// migratePassword verifies the password using the password migration hook. If the external system accepts
+// the password, it is hashed and stored so that subsequent logins no longer depend on the external system.
+func (s *Strategy) migratePassword(ctx context.Context, i *identity.Identity, identifier, password string) error {
+	endpoint := s.d.Config(ctx).PasswordMigrationHookURL()
+	if endpoint == nil {
+		return errors.WithStack(herodot.ErrInternalServerError.WithReasonf("The identity's password must be verified by the password migration hook but no hook URL is configured."))
+	}
+
+	body, err := json.Marshal(&MigrationHookPayload{IdentityID: i.ID, Identifier: identifier, Password: password})
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	req, err := retryablehttp.NewRequest("POST", endpoint.String(), bytes.NewReader(body))
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	res, err := s.hc.Do(req.WithContext(ctx))
+	if err != nil {
+		return errors.WithStack(herodot.ErrInternalServerError.WithReasonf("Unable to reach the password migration hook.").WithDebug(err.Error()))
+	}
+	defer res.Body.Close()
+
+	switch {
+	case res.StatusCode == http.StatusUnauthorized || res.StatusCode == http.StatusForbidden:
+		return errors.WithStack(schema.NewInvalidCredentialsError())
+	case res.StatusCode < 200 || res.StatusCode > 299:
+		return errors.WithStack(herodot.ErrInternalServerError.WithReasonf("The password migration hook responded with unexpected status code %d.", res.StatusCode))
+	}
+
+	if err := s.updatePassword(ctx, i.ID, password); err != nil {
+		return err
+	}
+
+	s.d.Logger().WithField("identity_id", i.ID).Info("Migrated the identity's password from the external system.")
+	return nil
+}
+
+// updatePassword hashes the password using the configured hasher and replaces the identity's stored
+// password hash. It is used once a password verified by an external system or an imported hash
+// is known to be correct.
+func (s *Strategy) updatePassword(ctx context.Context, id uuid.UUID, password string) error {
+	hpw, err := s.d.Hasher().Generate(ctx, []byte(password))
+	if err != nil {
+		return err
+	}
+
+	co, err := json.Marshal(&CredentialsConfig{HashedPassword: string(hpw)})
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	ic, err := s.d.PrivilegedIdentityPool().GetIdentityConfidential(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	c, ok := ic.GetCredentials(s.ID())
+	if !ok {
+		return errors.WithStack(herodot.ErrInternalServerError.WithReasonf("The identity's password credentials could not be found."))
+	}
+
+	c.Config = co
+	ic.SetCredentials(s.ID(), *c)
+	return s.d.PrivilegedIdentityPool().UpdateIdentity(ctx, ic)
+}
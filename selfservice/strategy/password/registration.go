@@ -33,15 +33,16 @@ const (
 )
 
 type RegistrationFormPayload struct {
-	Password  string          `json:"password"`
-	Traits    json.RawMessage `json:"traits"`
-	CSRFToken string          `json:"csrf_token"`
+	Password       string          `json:"password"`
+	Traits         json.RawMessage `json:"traits"`
+	CSRFToken      string          `json:"csrf_token"`
+	TermsOfService string          `json:"terms_of_service"`
 }
 
 func (s *Strategy) RegisterRegistrationRoutes(public *x.RouterPublic) {
 	s.d.CSRFHandler().IgnorePath(RouteRegistration)
 
-	wrappedHandleRegistration := strategy.IsDisabled(s.d, s.ID().String(), s.handleRegistration)
+	wrappedHandleRegistration := strategy.IsRegistrationDisabled(s.d, s.ID().String(), s.handleRegistration)
 	public.POST(RouteRegistration, s.d.SessionHandler().IsNotAuthenticated(wrappedHandleRegistration, func(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
 		handler := session.RedirectOnAuthenticated(s.d)
 		if x.IsJSONRequest(r) {
@@ -56,6 +57,7 @@ func (s *Strategy) handleRegistrationError(w http.ResponseWriter, r *http.Reques
 	if rr != nil {
 		if method, ok := rr.Methods[identity.CredentialsTypePassword]; ok {
 			method.Config.Reset()
+			strategy.SetTermsOfServiceField(s.d.Config(r.Context()), method.Config)
 
 			if p != nil {
 				for _, field := range form.NewHTMLFormFromJSON("", p.Traits, "traits").Fields {
@@ -149,7 +151,7 @@ func (s *Strategy) handleRegistration(w http.ResponseWriter, r *http.Request, _
 		return
 	}
 
-	if err := ar.Valid(); err != nil {
+	if err := ar.Valid(s.d.Clock()); err != nil {
 		s.handleRegistrationError(w, r, ar, nil, err)
 		return
 	}
@@ -195,6 +197,11 @@ func (s *Strategy) handleRegistration(w http.ResponseWriter, r *http.Request, _
 		return
 	}
 
+	if _, err := strategy.AcceptTermsOfService(s.d.Config(r.Context()), s.d.Clock().Now(), i, p.TermsOfService); err != nil {
+		s.handleRegistrationError(w, r, ar, &p, err)
+		return
+	}
+
 	if err := s.d.RegistrationExecutor().PostRegistrationHook(w, r, identity.CredentialsTypePassword, ar, i); err != nil {
 		s.handleRegistrationError(w, r, ar, &p, err)
 		return
@@ -202,7 +209,7 @@ func (s *Strategy) handleRegistration(w http.ResponseWriter, r *http.Request, _
 }
 
 func (s *Strategy) validateCredentials(ctx context.Context, i *identity.Identity, pw string) error {
-	if err := s.d.IdentityValidator().Validate(ctx, i); err != nil {
+	if err := s.d.IdentityValidator().Validate(ctx, i, identity.ValidatorEnforceUsernamePolicy); err != nil {
 		return err
 	}
 
@@ -241,6 +248,7 @@ func (s *Strategy) PopulateRegistrationMethod(r *http.Request, sr *registration.
 	if err := htmlf.SortFields(s.d.Config(r.Context()).DefaultIdentityTraitsSchemaURL().String()); err != nil {
 		return err
 	}
+	strategy.SetTermsOfServiceField(s.d.Config(r.Context()), htmlf)
 
 	sr.Methods[identity.CredentialsTypePassword] = &registration.FlowMethod{
 		Method: identity.CredentialsTypePassword,
@@ -0,0 +1,108 @@
+package password
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+
+	"github.com/pkg/errors"
+
+	"github.com/ory/x/sqlcon"
+
+	"github.com/ory/kratos/driver/config"
+	"github.com/ory/kratos/hash"
+	"github.com/ory/kratos/identity"
+	"github.com/ory/kratos/x"
+)
+
+type (
+	seedDependencies interface {
+		x.LoggingProvider
+		hash.HashProvider
+		identity.ManagementProvider
+	}
+
+	// SeedIdentity is an identity with a cleartext password as found in a seed file.
+	SeedIdentity struct {
+		// SchemaID is the identity's schema ID. Defaults to the default identity schema.
+		SchemaID string `json:"schema_id"`
+
+		// Traits are the identity's traits.
+		Traits json.RawMessage `json:"traits"`
+
+		// Password is the cleartext password which will be hashed before storing it.
+		Password string `json:"password"`
+	}
+)
+
+// ReadSeedFile reads a JSON file containing an array of identities to seed.
+func ReadSeedFile(path string) ([]SeedIdentity, error) {
+	raw, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	var seeds []SeedIdentity
+	if err := json.Unmarshal(raw, &seeds); err != nil {
+		return nil, errors.Wrapf(err, "unable to decode seed file %s", path)
+	}
+
+	return seeds, nil
+}
+
+// NewIdentityWithPassword returns a new identity with password credentials. If schemaID is empty, the default
+// identity schema is used. The credentials identifiers are set once the identity is validated, for example
+// when it is created using the identity manager.
+func NewIdentityWithPassword(ctx context.Context, h hash.Hasher, schemaID string, traits json.RawMessage, password string) (*identity.Identity, error) {
+	hpw, err := h.Generate(ctx, []byte(password))
+	if err != nil {
+		return nil, err
+	}
+
+	co, err := json.Marshal(&CredentialsConfig{HashedPassword: string(hpw)})
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	if len(schemaID) == 0 {
+		schemaID = config.DefaultIdentityTraitsSchemaID
+	}
+
+	i := identity.NewIdentity(schemaID)
+	i.Traits = identity.Traits(traits)
+	i.SetCredentials(identity.CredentialsTypePassword, identity.Credentials{
+		Type: identity.CredentialsTypePassword, Identifiers: []string{}, Config: co})
+	return i, nil
+}
+
+// SeedIdentities creates the identities from the seed file at path including their password credentials.
+// Identities whose credentials identifiers exist already are skipped, which makes seeding on every
+// startup safe.
+func SeedIdentities(ctx context.Context, d seedDependencies, path string) error {
+	seeds, err := ReadSeedFile(path)
+	if err != nil {
+		return err
+	}
+
+	for k, seed := range seeds {
+		if len(seed.Password) == 0 {
+			return errors.Errorf("seed identity #%d in %s has no password set", k, path)
+		}
+
+		i, err := NewIdentityWithPassword(ctx, d.Hasher(), seed.SchemaID, seed.Traits, seed.Password)
+		if err != nil {
+			return err
+		}
+
+		if err := d.IdentityManager().Create(ctx, i); errors.Is(err, sqlcon.ErrUniqueViolation) {
+			d.Logger().WithField("seed", k).Debug("Skipping seed identity because it exists already.")
+			continue
+		} else if err != nil {
+			return errors.Wrapf(err, "unable to create seed identity #%d from %s", k, path)
+		}
+
+		d.Logger().WithField("identity_id", i.ID).Info("Created identity from seed file.")
+	}
+
+	return nil
+}
@@ -0,0 +1,65 @@
+package password_test
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ory/kratos/identity"
+	"github.com/ory/kratos/internal"
+	"github.com/ory/kratos/internal/testhelpers"
+	"github.com/ory/kratos/selfservice/strategy/password"
+)
+
+func TestSeedIdentities(t *testing.T) {
+	conf, reg := internal.NewFastRegistryWithMocks(t)
+	testhelpers.SetDefaultIdentitySchema(t, conf, "file://./stub/registration.schema.json")
+
+	var writeSeed = func(t *testing.T, content string) string {
+		path := filepath.Join(t.TempDir(), "seed.json")
+		require.NoError(t, ioutil.WriteFile(path, []byte(content), 0600))
+		return path
+	}
+
+	t.Run("case=creates identities with password credentials", func(t *testing.T) {
+		path := writeSeed(t, `[
+  {"traits": {"username": "seed-alice", "foobar": "bar"}, "password": "ohTh5ahg9eoneiGh"},
+  {"schema_id": "default", "traits": {"username": "seed-bob", "foobar": "bar"}, "password": "shu0aiPhaxee4Coo"}
+]`)
+		require.NoError(t, password.SeedIdentities(context.Background(), reg, path))
+
+		for username, pw := range map[string]string{"seed-alice": "ohTh5ahg9eoneiGh", "seed-bob": "shu0aiPhaxee4Coo"} {
+			i, c, err := reg.PrivilegedIdentityPool().FindByCredentialsIdentifier(context.Background(), identity.CredentialsTypePassword, username)
+			require.NoError(t, err, username)
+			assert.Equal(t, "default", i.SchemaID)
+
+			var o password.CredentialsConfig
+			require.NoError(t, json.Unmarshal(c.Config, &o))
+			require.NoError(t, reg.Hasher().Compare(context.Background(), []byte(pw), []byte(o.HashedPassword)))
+		}
+
+		t.Run("case=is idempotent", func(t *testing.T) {
+			require.NoError(t, password.SeedIdentities(context.Background(), reg, path))
+		})
+	})
+
+	t.Run("case=fails on invalid traits", func(t *testing.T) {
+		path := writeSeed(t, `[{"traits": {"username": "seed-carol"}, "password": "ohTh5ahg9eoneiGh"}]`)
+		require.Error(t, password.SeedIdentities(context.Background(), reg, path))
+	})
+
+	t.Run("case=fails on missing password", func(t *testing.T) {
+		path := writeSeed(t, `[{"traits": {"username": "seed-dave", "foobar": "bar"}}]`)
+		require.Error(t, password.SeedIdentities(context.Background(), reg, path))
+	})
+
+	t.Run("case=fails on malformed file", func(t *testing.T) {
+		require.Error(t, password.SeedIdentities(context.Background(), reg, writeSeed(t, `{`)))
+		require.Error(t, password.SeedIdentities(context.Background(), reg, filepath.Join(t.TempDir(), "does-not-exist.json")))
+	})
+}
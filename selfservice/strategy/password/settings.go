@@ -4,7 +4,6 @@ import (
 	"encoding/json"
 	"net/http"
 	"net/url"
-	"time"
 
 	"github.com/gofrs/uuid"
 	"github.com/julienschmidt/httprouter"
@@ -30,7 +29,7 @@ const (
 func (s *Strategy) RegisterSettingsRoutes(router *x.RouterPublic) {
 	s.d.CSRFHandler().IgnorePath(RouteSettings)
 
-	wrappedSubmmitSettingsFlow := strategy.IsDisabled(s.d, s.SettingsStrategyID(), s.submitSettingsFlow)
+	wrappedSubmmitSettingsFlow := strategy.IsSettingsDisabled(s.d, s.SettingsStrategyID(), s.submitSettingsFlow)
 	router.POST(RouteSettings, wrappedSubmmitSettingsFlow)
 	router.GET(RouteSettings, wrappedSubmmitSettingsFlow)
 }
@@ -160,7 +159,7 @@ func (s *Strategy) continueSettingsFlow(
 		return
 	}
 
-	if ctxUpdate.Session.AuthenticatedAt.Add(s.d.Config(r.Context()).SelfServiceFlowSettingsPrivilegedSessionMaxAge()).Before(time.Now()) {
+	if !ctxUpdate.Session.IsPrivileged(s.d.Clock().Now(), s.d.Config(r.Context()).SelfServiceFlowSettingsPrivilegedSessionMaxAge()) {
 		s.handleSettingsError(w, r, ctxUpdate, p, errors.WithStack(settings.NewFlowNeedsReAuth()))
 		return
 	}
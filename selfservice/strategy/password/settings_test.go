@@ -9,6 +9,7 @@ import (
 	"net/url"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/ory/kratos/corpx"
 
@@ -156,6 +157,19 @@ func TestSettings(t *testing.T) {
 				check(t, expectValidationError(t, false, browserUser1, payload))
 			})
 		})
+
+		t.Run("session=needs reauthentication based on the injected clock", func(t *testing.T) {
+			conf.MustSet(config.ViperKeySelfServiceSettingsPrivilegedAuthenticationAfter, "5m")
+			reg.WithClock(x.NewFakeClock(time.Now().Add(10 * time.Minute)))
+			t.Cleanup(func() {
+				reg.WithClock(x.SystemClock)
+			})
+
+			actual := testhelpers.SubmitSettingsForm(t, true, apiUser1, publicTS, func(v url.Values) {
+				v.Set("password", "123456")
+			}, identity.CredentialsTypePassword.String(), http.StatusForbidden, publicTS.URL+password.RouteSettings)
+			assertx.EqualAsJSON(t, settings.NewFlowNeedsReAuth(), json.RawMessage(gjson.Get(actual, "error").Raw))
+		})
 	})
 
 	t.Run("description=should not be able to make requests for another user", func(t *testing.T) {
@@ -2,9 +2,9 @@ package password
 
 import (
 	"encoding/json"
-	"strings"
 
 	"github.com/go-playground/validator/v10"
+	"github.com/hashicorp/go-retryablehttp"
 	"github.com/pkg/errors"
 
 	"github.com/ory/x/decoderx"
@@ -28,6 +28,8 @@ var _ identity.ActiveCredentialsCounter = new(Strategy)
 type registrationStrategyDependencies interface {
 	x.LoggingProvider
 	x.WriterProvider
+	x.ClockProvider
+	x.IDGeneratorProvider
 	x.CSRFTokenGeneratorProvider
 	x.CSRFProvider
 
@@ -67,6 +69,7 @@ type Strategy struct {
 	d  registrationStrategyDependencies
 	v  *validator.Validate
 	hd *decoderx.HTTP
+	hc *retryablehttp.Client
 }
 
 func (s *Strategy) CountActiveCredentials(cc map[identity.CredentialsType]identity.Credentials) (count int, err error) {
@@ -78,7 +81,7 @@ func (s *Strategy) CountActiveCredentials(cc map[identity.CredentialsType]identi
 			}
 
 			if len(c.Identifiers) > 0 && len(c.Identifiers[0]) > 0 &&
-				strings.HasPrefix(conf.HashedPassword, "$argon2id$") {
+				(hash.IsSupportedHash([]byte(conf.HashedPassword)) || conf.UseExternalCheck) {
 				count++
 			}
 		}
@@ -91,6 +94,7 @@ func NewStrategy(d registrationStrategyDependencies) *Strategy {
 		d:  d,
 		v:  validator.New(),
 		hd: decoderx.NewHTTP(),
+		hc: x.NewEgressHTTPClient(d, config.EgressDependencyWebHooks),
 	}
 }
 
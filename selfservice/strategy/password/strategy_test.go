@@ -160,3 +160,30 @@ func TestDisabledEndpoint(t *testing.T) {
 		})
 	})
 }
+
+func TestDisabledFlow(t *testing.T) {
+	conf, reg := internal.NewFastRegistryWithMocks(t)
+	testhelpers.StrategyEnable(t, conf, identity.CredentialsTypePassword.String(), true)
+	conf.MustSet(config.ViperKeySelfServiceLoginEnabled, false)
+	conf.MustSet(config.ViperKeySelfServiceRegistrationEnabled, false)
+	conf.MustSet(config.ViperKeySelfServiceSettingsEnabled, false)
+
+	publicTS, _ := testhelpers.NewKratosServer(t, reg)
+
+	c := testhelpers.NewClientWithCookies(t)
+	for flow, route := range map[string]string{
+		"login":        password.RouteLogin,
+		"registration": password.RouteRegistration,
+		"settings":     password.RouteSettings,
+	} {
+		t.Run("flow="+flow, func(t *testing.T) {
+			res, err := c.PostForm(publicTS.URL+route, url.Values{"identifier": []string{"identifier"}, "password": []string{"password"}})
+			require.NoError(t, err)
+			assert.Equal(t, http.StatusNotFound, res.StatusCode)
+
+			b := make([]byte, res.ContentLength)
+			_, _ = res.Body.Read(b)
+			assert.Contains(t, string(b), "The "+flow+" flow was disabled by system administrator")
+		})
+	}
+}
@@ -7,6 +7,10 @@ type (
 	CredentialsConfig struct {
 		// HashedPassword is a hash-representation of the password.
 		HashedPassword string `json:"hashed_password"`
+
+		// UseExternalCheck is set for identities imported from external systems whose password is not known
+		// yet. The password is verified using the password migration hook on the first login.
+		UseExternalCheck bool `json:"use_external_check,omitempty"`
 	}
 
 	// CompleteSelfServiceLoginFlowWithPasswordMethod is used to decode the login form payload.
@@ -19,6 +23,10 @@ type (
 
 		// Sending the anti-csrf token is only required for browser login flows.
 		CSRFToken string `form:"csrf_token" json:"csrf_token"`
+
+		// TermsOfService is the version of the terms of service the user accepts. It is only required if the
+		// user has not accepted the configured version yet.
+		TermsOfService string `form:"terms_of_service" json:"terms_of_service,omitempty"`
 	}
 )
 
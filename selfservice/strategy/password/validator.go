@@ -18,7 +18,7 @@ import (
 
 	"github.com/arbovm/levenshtein"
 
-	"github.com/ory/x/httpx"
+	"github.com/ory/kratos/x"
 
 	"github.com/pkg/errors"
 
@@ -68,7 +68,7 @@ type validatorDependencies interface {
 
 func NewDefaultPasswordValidatorStrategy(reg validatorDependencies) *DefaultPasswordValidator {
 	return &DefaultPasswordValidator{
-		Client:                    httpx.NewResilientClient(),
+		Client:                    x.NewEgressHTTPClient(reg, config.EgressDependencyHIBP),
 		reg:                       reg,
 		hashes:                    map[string]int64{},
 		minIdentifierPasswordDist: 5, maxIdentifierPasswordSubstrThreshold: 0.5}
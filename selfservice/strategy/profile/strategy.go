@@ -40,6 +40,8 @@ type (
 		x.CSRFProvider
 		x.CSRFTokenGeneratorProvider
 		x.WriterProvider
+		x.ClockProvider
+		x.IDGeneratorProvider
 		x.LoggingProvider
 
 		config.Provider
@@ -85,13 +87,18 @@ func (s *Strategy) SettingsStrategyID() string {
 func (s *Strategy) RegisterSettingsRoutes(public *x.RouterPublic) {
 	s.d.CSRFHandler().IgnorePath(RouteSettings)
 
-	wrappedHandleSubmit := strategy.IsDisabled(s.d, s.SettingsStrategyID(), s.handleSubmit)
+	wrappedHandleSubmit := strategy.IsSettingsDisabled(s.d, s.SettingsStrategyID(), s.handleSubmit)
 	public.POST(RouteSettings, s.d.SessionHandler().IsAuthenticated(wrappedHandleSubmit, settings.OnUnauthenticated(s.d)))
 	public.GET(RouteSettings, s.d.SessionHandler().IsAuthenticated(wrappedHandleSubmit, settings.OnUnauthenticated(s.d)))
 }
 
 func (s *Strategy) PopulateSettingsMethod(r *http.Request, id *identity.Identity, pr *settings.Flow) error {
-	traitsSchema, err := s.d.Config(r.Context()).IdentityTraitsSchemas().FindSchemaByID(id.SchemaID)
+	schemas, err := s.d.Config(r.Context()).IdentityTraitsSchemasOrErr()
+	if err != nil {
+		return err
+	}
+
+	traitsSchema, err := schemas.FindSchemaByID(id.SchemaID)
 	if err != nil {
 		return err
 	}
@@ -108,6 +115,9 @@ func (s *Strategy) PopulateSettingsMethod(r *http.Request, id *identity.Identity
 	}
 
 	f.SetValuesFromJSON(json.RawMessage(id.Traits), "traits")
+	for _, field := range communicationPreferencesFields(id.CommunicationPreferences) {
+		f.SetField(field)
+	}
 	f.SetCSRF(s.d.GenerateCSRFToken(r))
 
 	if err := f.SortFields(traitsSchema.URL); err != nil {
@@ -217,7 +227,7 @@ func (s *Strategy) continueFlow(w http.ResponseWriter, r *http.Request, ctxUpdat
 		return
 	}
 
-	if err := s.hydrateForm(r, ctxUpdate.Flow, ctxUpdate.Session, p.Traits); err != nil {
+	if err := s.hydrateForm(r, ctxUpdate.Flow, ctxUpdate.Session, p.Traits, p.communicationPreferences(ctxUpdate.Session.Identity)); err != nil {
 		s.d.SettingsFlowErrorHandler().WriteFlowError(w, r, settings.StrategyProfile, ctxUpdate.Flow, ctxUpdate.Session.Identity, err)
 		return
 	}
@@ -229,6 +239,14 @@ func (s *Strategy) continueFlow(w http.ResponseWriter, r *http.Request, ctxUpdat
 	}
 
 	update.Traits = identity.Traits(p.Traits)
+	if p.CommunicationPreferences != nil {
+		if err := p.CommunicationPreferences.Validate(); err != nil {
+			s.handleSettingsError(w, r, ctxUpdate, p.Traits, p, err)
+			return
+		}
+		update.CommunicationPreferences = p.CommunicationPreferences
+	}
+
 	if err := s.d.SettingsHookExecutor().PostSettingsHook(w, r,
 		settings.StrategyProfile, ctxUpdate, update); err != nil {
 		s.handleSettingsError(w, r, ctxUpdate, p.Traits, p, err)
@@ -258,6 +276,9 @@ type CompleteSelfServiceBrowserSettingsProfileStrategyFlow struct {
 	// Traits contains all of the identity's traits.
 	Traits json.RawMessage `json:"traits"`
 
+	// CommunicationPreferences are consulted by the courier before messages are sent to this identity.
+	CommunicationPreferences *identity.CommunicationPreferences `json:"communication_preferences,omitempty"`
+
 	// FlowIDRequestID is the flow ID.
 	//
 	// swagger:ignore
@@ -277,7 +298,29 @@ func (p *CompleteSelfServiceBrowserSettingsProfileStrategyFlow) SetFlowID(rid uu
 	p.FlowID = rid.String()
 }
 
-func (s *Strategy) hydrateForm(r *http.Request, ar *settings.Flow, ss *session.Session, traits json.RawMessage) error {
+// communicationPreferences returns the submitted preferences or, if none were submitted, the identity's
+// current preferences.
+func (p *CompleteSelfServiceBrowserSettingsProfileStrategyFlow) communicationPreferences(i *identity.Identity) *identity.CommunicationPreferences {
+	if p.CommunicationPreferences != nil || i == nil {
+		return p.CommunicationPreferences
+	}
+	return i.CommunicationPreferences
+}
+
+// communicationPreferencesFields returns the form fields for editing the identity's communication preferences.
+func communicationPreferencesFields(prefs *identity.CommunicationPreferences) form.Fields {
+	if prefs == nil {
+		prefs = new(identity.CommunicationPreferences)
+	}
+
+	return form.Fields{
+		{Name: "communication_preferences.no_marketing", Type: "checkbox", Value: prefs.NoMarketing},
+		{Name: "communication_preferences.no_verification_reminders", Type: "checkbox", Value: prefs.NoVerificationReminders},
+		{Name: "communication_preferences.preferred_locale", Type: "text", Value: prefs.PreferredLocale},
+	}
+}
+
+func (s *Strategy) hydrateForm(r *http.Request, ar *settings.Flow, ss *session.Session, traits json.RawMessage, prefs *identity.CommunicationPreferences) error {
 	action := urlx.CopyWithQuery(urlx.AppendPaths(s.d.Config(r.Context()).SelfPublicURL(r), RouteSettings),
 		url.Values{"flow": {ar.ID.String()}})
 
@@ -287,9 +330,17 @@ func (s *Strategy) hydrateForm(r *http.Request, ar *settings.Flow, ss *session.S
 			ar.Methods[settings.StrategyProfile].Config.SetField(field)
 		}
 	}
+	for _, field := range communicationPreferencesFields(prefs) {
+		ar.Methods[settings.StrategyProfile].Config.SetField(field)
+	}
 	ar.Methods[settings.StrategyProfile].Config.SetCSRF(s.d.GenerateCSRFToken(r))
 
-	traitsSchema, err := s.d.Config(r.Context()).IdentityTraitsSchemas().FindSchemaByID(ss.Identity.SchemaID)
+	schemas, err := s.d.Config(r.Context()).IdentityTraitsSchemasOrErr()
+	if err != nil {
+		return err
+	}
+
+	traitsSchema, err := schemas.FindSchemaByID(ss.Identity.SchemaID)
 	if err != nil {
 		return err
 	}
@@ -318,7 +369,7 @@ func (s *Strategy) handleSettingsError(w http.ResponseWriter, r *http.Request, p
 			traits = json.RawMessage(puc.GetSessionIdentity().Traits)
 		}
 
-		if err := s.hydrateForm(r, puc.Flow, puc.Session, traits); err != nil {
+		if err := s.hydrateForm(r, puc.Flow, puc.Session, traits, p.communicationPreferences(puc.GetSessionIdentity())); err != nil {
 			s.d.SettingsFlowErrorHandler().WriteFlowError(w, r, s.SettingsStrategyID(), puc.Flow, puc.GetSessionIdentity(), err)
 			return
 		}
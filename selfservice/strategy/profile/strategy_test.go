@@ -174,6 +174,9 @@ func TestStrategyTraits(t *testing.T) {
 					&models.FormField{Name: pointerx.String("traits.booly"), Type: pointerx.String("checkbox"), Value: false},
 					&models.FormField{Name: pointerx.String("traits.should_big_number"), Type: pointerx.String("number"), Value: json.Number("2048")},
 					&models.FormField{Name: pointerx.String("traits.should_long_string"), Type: pointerx.String("text"), Value: "asdfasdfasdfasdfasfdasdfasdfasdf"},
+					&models.FormField{Name: pointerx.String("communication_preferences.no_marketing"), Type: pointerx.String("checkbox"), Value: false},
+					&models.FormField{Name: pointerx.String("communication_preferences.no_verification_reminders"), Type: pointerx.String("checkbox"), Value: false},
+					&models.FormField{Name: pointerx.String("communication_preferences.preferred_locale"), Type: pointerx.String("text"), Value: ""},
 				},
 			}, f)
 		}
@@ -377,6 +380,39 @@ func TestStrategyTraits(t *testing.T) {
 		})
 	})
 
+	t.Run("flow=update communication preferences", func(t *testing.T) {
+		conf.MustSet(config.ViperKeySelfServiceSettingsPrivilegedAuthenticationAfter, "1h")
+		t.Cleanup(func() {
+			conf.MustSet(config.ViperKeySelfServiceSettingsPrivilegedAuthenticationAfter, "1ns")
+		})
+
+		var check = func(t *testing.T, id *identity.Identity, actual string) {
+			assert.EqualValues(t, settings.StateSuccess, gjson.Get(actual, "state").String(), "%s", actual)
+			assert.True(t, gjson.Get(actual, "methods.profile.config.fields.#(name==communication_preferences.no_marketing).value").Bool(), "%s", actual)
+			assert.True(t, gjson.Get(actual, "methods.profile.config.fields.#(name==communication_preferences.no_verification_reminders).value").Bool(), "%s", actual)
+			assert.Equal(t, "de-CH", gjson.Get(actual, "methods.profile.config.fields.#(name==communication_preferences.preferred_locale).value").String(), "%s", actual)
+
+			actualIdentity, err := reg.PrivilegedIdentityPool().GetIdentity(context.Background(), id.ID)
+			require.NoError(t, err)
+			assert.Equal(t, &identity.CommunicationPreferences{NoMarketing: true, NoVerificationReminders: true, PreferredLocale: "de-CH"}, actualIdentity.CommunicationPreferences)
+		}
+
+		var payload = func(v url.Values) {
+			v.Set("communication_preferences.no_marketing", "true")
+			v.Set("communication_preferences.no_verification_reminders", "true")
+			v.Set("communication_preferences.preferred_locale", "de-CH")
+		}
+
+		t.Run("type=api", func(t *testing.T) {
+			actual := expectSuccess(t, true, apiUser1, payload)
+			check(t, apiIdentity1, gjson.Get(actual, "flow").Raw)
+		})
+
+		t.Run("type=browser", func(t *testing.T) {
+			check(t, browserIdentity1, expectSuccess(t, false, browserUser1, payload))
+		})
+	})
+
 	t.Run("flow=try another update with invalid data", func(t *testing.T) {
 		var check = func(t *testing.T, actual string) {
 			assert.EqualValues(t, settings.StateShowForm, gjson.Get(actual, "state").String(), "%s", actual)
@@ -0,0 +1,92 @@
+package strategy
+
+import (
+	"context"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/ory/kratos/driver/config"
+	"github.com/ory/kratos/identity"
+	"github.com/ory/kratos/schema"
+	"github.com/ory/kratos/selfservice/form"
+	"github.com/ory/kratos/text"
+	"github.com/ory/kratos/x"
+)
+
+// TermsOfServiceFieldName is the name of the form field users check to accept the terms of service.
+const TermsOfServiceFieldName = "terms_of_service"
+
+// TermsOfServiceField returns the checkbox for accepting the configured terms of service. Its value is the
+// version which must be submitted. It returns nil if no terms of service are configured.
+func TermsOfServiceField(c *config.Config) *form.Field {
+	version := c.SelfServiceTermsOfServiceVersion()
+	if version == "" {
+		return nil
+	}
+
+	return &form.Field{
+		Name:     TermsOfServiceFieldName,
+		Type:     "checkbox",
+		Value:    version,
+		Required: true,
+		Messages: text.Messages{*text.NewInfoSelfServiceTermsOfServiceAccept(version, c.SelfServiceTermsOfServiceURL())},
+	}
+}
+
+// SetTermsOfServiceField adds the terms of service checkbox to the form if terms of service are configured.
+func SetTermsOfServiceField(c *config.Config, f form.FieldSetter) {
+	if field := TermsOfServiceField(c); field != nil {
+		f.SetField(*field)
+	}
+}
+
+// AcceptTermsOfService records the acceptance of the configured terms of service on the identity if accepted is
+// the configured version. It returns true if the acceptance was recorded and a validation error if the identity
+// has not accepted the configured version yet and accepted does not match it either.
+func AcceptTermsOfService(c *config.Config, now time.Time, i *identity.Identity, accepted string) (bool, error) {
+	version := c.SelfServiceTermsOfServiceVersion()
+	if version == "" || i.TermsOfService.HasAccepted(version) {
+		return false, nil
+	}
+
+	if accepted != version {
+		return false, schema.NewTermsOfServiceNotAcceptedError(version, c.SelfServiceTermsOfServiceURL())
+	}
+
+	i.TermsOfService = &identity.TermsOfServiceAcceptance{Version: version, AcceptedAt: now.UTC()}
+	return true, nil
+}
+
+type termsOfServiceDependencies interface {
+	config.Provider
+	x.ClockProvider
+	identity.PrivilegedPoolProvider
+}
+
+// AcceptTermsOfServiceOnLogin behaves like AcceptTermsOfService for identities which exist already and stores the
+// acceptance if it was recorded.
+func AcceptTermsOfServiceOnLogin(ctx context.Context, d termsOfServiceDependencies, i *identity.Identity, accepted string) error {
+	recorded, err := AcceptTermsOfService(d.Config(ctx), d.Clock().Now(), i, accepted)
+	if err != nil || !recorded {
+		return err
+	}
+
+	ic, err := d.PrivilegedIdentityPool().GetIdentityConfidential(ctx, i.ID)
+	if err != nil {
+		return err
+	}
+
+	ic.TermsOfService = i.TermsOfService
+	return d.PrivilegedIdentityPool().UpdateIdentity(ctx, ic)
+}
+
+// IsTermsOfServiceNotAcceptedError returns true if the error was returned by AcceptTermsOfService.
+func IsTermsOfServiceNotAcceptedError(err error) bool {
+	var e *schema.ValidationError
+	if !errors.As(err, &e) {
+		return false
+	}
+	_, ok := e.Context.(*schema.ValidationErrorContextTermsOfServiceNotAcceptedError)
+	return ok
+}
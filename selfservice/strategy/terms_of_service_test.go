@@ -0,0 +1,92 @@
+package strategy_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ory/kratos/driver/config"
+	"github.com/ory/kratos/identity"
+	"github.com/ory/kratos/internal"
+	"github.com/ory/kratos/selfservice/form"
+	"github.com/ory/kratos/selfservice/strategy"
+)
+
+func TestTermsOfService(t *testing.T) {
+	ctx := context.Background()
+	conf, reg := internal.NewFastRegistryWithMocks(t)
+	conf.MustSet(config.ViperKeyDefaultIdentitySchemaURL, "file://./stub/identity.schema.json")
+
+	t.Run("case=disabled", func(t *testing.T) {
+		conf.MustSet(config.ViperKeySelfServiceTermsOfServiceVersion, "")
+		assert.Nil(t, strategy.TermsOfServiceField(conf))
+
+		f := form.NewHTMLForm("")
+		strategy.SetTermsOfServiceField(conf, f)
+		assert.Empty(t, f.Fields)
+
+		recorded, err := strategy.AcceptTermsOfService(conf, time.Now(), identity.NewIdentity(""), "")
+		require.NoError(t, err)
+		assert.False(t, recorded)
+	})
+
+	conf.MustSet(config.ViperKeySelfServiceTermsOfServiceVersion, "v2")
+	conf.MustSet(config.ViperKeySelfServiceTermsOfServiceURL, "https://www.ory.sh/terms")
+
+	t.Run("case=field", func(t *testing.T) {
+		f := strategy.TermsOfServiceField(conf)
+		require.NotNil(t, f)
+		assert.Equal(t, strategy.TermsOfServiceFieldName, f.Name)
+		assert.Equal(t, "checkbox", f.Type)
+		assert.Equal(t, "v2", f.Value)
+		assert.True(t, f.Required)
+		require.Len(t, f.Messages, 1)
+		assert.Contains(t, string(f.Messages[0].Context), "https://www.ory.sh/terms")
+	})
+
+	t.Run("case=accept", func(t *testing.T) {
+		i := identity.NewIdentity("")
+		now := time.Now()
+
+		_, err := strategy.AcceptTermsOfService(conf, now, i, "")
+		require.Error(t, err)
+		assert.True(t, strategy.IsTermsOfServiceNotAcceptedError(err))
+
+		_, err = strategy.AcceptTermsOfService(conf, now, i, "v1")
+		assert.True(t, strategy.IsTermsOfServiceNotAcceptedError(err))
+
+		recorded, err := strategy.AcceptTermsOfService(conf, now, i, "v2")
+		require.NoError(t, err)
+		assert.True(t, recorded)
+		assert.Equal(t, &identity.TermsOfServiceAcceptance{Version: "v2", AcceptedAt: now.UTC()}, i.TermsOfService)
+
+		recorded, err = strategy.AcceptTermsOfService(conf, now, i, "")
+		require.NoError(t, err, "accepting the same version again is not required")
+		assert.False(t, recorded)
+	})
+
+	t.Run("case=accept on login", func(t *testing.T) {
+		i := identity.NewIdentity(config.DefaultIdentityTraitsSchemaID)
+		i.Traits = identity.Traits(`{}`)
+		i.TermsOfService = &identity.TermsOfServiceAcceptance{Version: "v1", AcceptedAt: time.Now().UTC()}
+		require.NoError(t, reg.PrivilegedIdentityPool().CreateIdentity(ctx, i))
+
+		err := strategy.AcceptTermsOfServiceOnLogin(ctx, reg, i, "")
+		assert.True(t, strategy.IsTermsOfServiceNotAcceptedError(err), "%+v", err)
+
+		require.NoError(t, strategy.AcceptTermsOfServiceOnLogin(ctx, reg, i, "v2"))
+
+		actual, err := reg.PrivilegedIdentityPool().GetIdentity(ctx, i.ID)
+		require.NoError(t, err)
+		assert.True(t, actual.TermsOfService.HasAccepted("v2"))
+	})
+
+	t.Run("case=is not a terms of service error", func(t *testing.T) {
+		assert.False(t, strategy.IsTermsOfServiceNotAcceptedError(nil))
+		assert.False(t, strategy.IsTermsOfServiceNotAcceptedError(errors.New("some error")))
+	})
+}
@@ -0,0 +1,49 @@
+package session
+
+import (
+	"net"
+	"net/http"
+
+	"github.com/ory/kratos/driver/config"
+)
+
+// matchesBinding returns false if the request was sent from a different IP range or user agent family than the
+// device the session was issued to. Sessions issued before devices were recorded always match.
+func (d *Device) matchesBinding(r *http.Request, b *config.SessionBinding) bool {
+	if d == nil || (len(d.IPAddress) == 0 && len(d.UserAgent) == 0) {
+		return true
+	}
+
+	if b.IP && !sameIPRange(d.IPAddress, clientIP(r), b) {
+		return false
+	}
+
+	if b.UserAgent {
+		browser, os := parseUserAgent(d.UserAgent)
+		currentBrowser, currentOS := parseUserAgent(r.UserAgent())
+		if browser != currentBrowser || os != currentOS {
+			return false
+		}
+	}
+
+	return true
+}
+
+func sameIPRange(issued, current string, b *config.SessionBinding) bool {
+	a, c := net.ParseIP(issued), net.ParseIP(current)
+	if a == nil || c == nil {
+		return issued == current
+	}
+
+	bits, length := 128, b.IPv6PrefixLength
+	if a4, c4 := a.To4(), c.To4(); a4 != nil || c4 != nil {
+		if a4 == nil || c4 == nil {
+			return false
+		}
+		a, c = a4, c4
+		bits, length = 32, b.IPv4PrefixLength
+	}
+
+	mask := net.CIDRMask(length, bits)
+	return a.Mask(mask).Equal(c.Mask(mask))
+}
@@ -0,0 +1,58 @@
+package session
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/ory/kratos/driver/config"
+)
+
+func TestMatchesBinding(t *testing.T) {
+	const (
+		firefox      = "Mozilla/5.0 (X11; Ubuntu; Linux x86_64; rv:88.0) Gecko/20100101 Firefox/88.0"
+		firefoxNewer = "Mozilla/5.0 (X11; Ubuntu; Linux x86_64; rv:89.0) Gecko/20100101 Firefox/89.0"
+		chrome       = "Mozilla/5.0 (X11; Linux x86_64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/90.0.4430.85 Safari/537.36"
+	)
+
+	b := &config.SessionBinding{Mode: config.SessionBindingModeStrict, IP: true, UserAgent: true, IPv4PrefixLength: 24, IPv6PrefixLength: 64}
+	d := &Device{IPAddress: "192.0.2.1", UserAgent: firefox}
+
+	for k, tc := range []struct {
+		ip, ua   string
+		b        *config.SessionBinding
+		d        *Device
+		expected bool
+	}{
+		{ip: "192.0.2.1", ua: firefox, expected: true},
+		{ip: "192.0.2.200", ua: firefoxNewer, expected: true},
+		{ip: "198.51.100.7", ua: firefox, expected: false},
+		{ip: "192.0.2.1", ua: chrome, expected: false},
+		{ip: "2001:db8::1", ua: firefox, expected: false},
+		{ip: "198.51.100.7", ua: firefox, b: &config.SessionBinding{UserAgent: true}, expected: true},
+		{ip: "192.0.2.1", ua: chrome, b: &config.SessionBinding{IP: true, IPv4PrefixLength: 24}, expected: true},
+		{ip: "198.51.100.7", ua: chrome, d: &Device{}, expected: true},
+		{ip: "2001:db8::ffff", ua: firefox, d: &Device{IPAddress: "2001:db8::1", UserAgent: firefox}, expected: true},
+		{ip: "2001:db8:1::1", ua: firefox, d: &Device{IPAddress: "2001:db8::1", UserAgent: firefox}, expected: false},
+	} {
+		r, _ := http.NewRequest("GET", "https://www.ory.sh/", nil)
+		r.RemoteAddr = tc.ip + ":1234"
+		if tc.ip[0] != '1' {
+			r.RemoteAddr = "[" + tc.ip + "]:1234"
+		}
+		r.Header.Set("User-Agent", tc.ua)
+
+		bb, dd := b, d
+		if tc.b != nil {
+			bb = tc.b
+		}
+		if tc.d != nil {
+			dd = tc.d
+		}
+		assert.Equal(t, tc.expected, dd.matchesBinding(r, bb), "%d", k)
+	}
+
+	var nilDevice *Device
+	assert.True(t, nilDevice.matchesBinding(new(http.Request), b))
+}
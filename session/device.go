@@ -0,0 +1,158 @@
+package session
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"net"
+	"net/http"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// Device describes the device a session was issued to.
+//
+// swagger:model sessionDevice
+type Device struct {
+	// IPAddress is the IP address of the device when the session was issued.
+	IPAddress string `json:"ip_address,omitempty"`
+
+	// UserAgent is the user agent of the device when the session was issued.
+	UserAgent string `json:"user_agent,omitempty"`
+
+	// Browser is the browser parsed from the user agent, for example "Chrome".
+	Browser string `json:"browser,omitempty"`
+
+	// OS is the operating system parsed from the user agent, for example "macOS".
+	OS string `json:"os,omitempty"`
+
+	// Location is the location of the device as reported by a trusted proxy, for example "Berlin".
+	Location string `json:"location,omitempty"`
+
+	// Description is a human readable description of the device, for example "Chrome on macOS, Berlin".
+	Description string `json:"description,omitempty"`
+}
+
+type deviceConfiguration interface {
+	SessionDeviceParseUserAgent() bool
+	SessionDeviceLocationHeader() string
+}
+
+// NewDevice returns the device the request was sent from. The browser, operating system, and description
+// are only set if user agent parsing is enabled.
+func NewDevice(r *http.Request, c deviceConfiguration) *Device {
+	d := &Device{
+		IPAddress: clientIP(r),
+		UserAgent: r.UserAgent(),
+	}
+
+	if h := c.SessionDeviceLocationHeader(); len(h) > 0 {
+		d.Location = r.Header.Get(h)
+	}
+
+	if c.SessionDeviceParseUserAgent() {
+		d.Browser, d.OS = parseUserAgent(d.UserAgent)
+		d.Description = describeDevice(d.Browser, d.OS, d.Location)
+	}
+
+	return d
+}
+
+func clientIP(r *http.Request) string {
+	if fwd := r.Header.Get("X-Forwarded-For"); len(fwd) > 0 {
+		return strings.TrimSpace(strings.Split(fwd, ",")[0])
+	}
+
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+// parseUserAgent returns the browser and operating system of the most common user agents. The order of the
+// checks matters because most user agents also claim to be other browsers or operating systems.
+func parseUserAgent(ua string) (browser, os string) {
+	has := func(tokens ...string) bool {
+		for _, t := range tokens {
+			if strings.Contains(ua, t) {
+				return true
+			}
+		}
+		return false
+	}
+
+	switch {
+	case has("Edg/", "EdgA/", "EdgiOS/", "Edge/"):
+		browser = "Edge"
+	case has("OPR/", "Opera"):
+		browser = "Opera"
+	case has("SamsungBrowser/"):
+		browser = "Samsung Internet"
+	case has("Firefox/", "FxiOS/"):
+		browser = "Firefox"
+	case has("Chrome/", "CriOS/"):
+		browser = "Chrome"
+	case has("Safari/"):
+		browser = "Safari"
+	case has("MSIE ", "Trident/"):
+		browser = "Internet Explorer"
+	}
+
+	switch {
+	case has("Windows"):
+		os = "Windows"
+	case has("iPhone", "iPad", "iPod"):
+		os = "iOS"
+	case has("Android"):
+		os = "Android"
+	case has("CrOS"):
+		os = "Chrome OS"
+	case has("Macintosh", "Mac OS X"):
+		os = "macOS"
+	case has("Linux"):
+		os = "Linux"
+	}
+
+	return browser, os
+}
+
+func describeDevice(browser, os, location string) string {
+	var description string
+	switch {
+	case len(browser) > 0 && len(os) > 0:
+		description = browser + " on " + os
+	case len(browser) > 0:
+		description = browser
+	case len(os) > 0:
+		description = os
+	default:
+		description = "Unknown device"
+	}
+
+	if len(location) > 0 {
+		description += ", " + location
+	}
+	return description
+}
+
+func (d *Device) Scan(value interface{}) error {
+	switch v := value.(type) {
+	case nil:
+		*d = Device{}
+		return nil
+	case string:
+		return errors.WithStack(json.Unmarshal([]byte(v), d))
+	case []byte:
+		return errors.WithStack(json.Unmarshal(v, d))
+	}
+	return errors.Errorf("unable to scan session device from type %T", value)
+}
+
+func (d Device) Value() (driver.Value, error) {
+	out, err := json.Marshal(d)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	return string(out), nil
+}
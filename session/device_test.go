@@ -0,0 +1,78 @@
+package session
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type deviceConfig struct {
+	parse  bool
+	header string
+}
+
+func (c deviceConfig) SessionDeviceParseUserAgent() bool   { return c.parse }
+func (c deviceConfig) SessionDeviceLocationHeader() string { return c.header }
+
+func TestParseUserAgent(t *testing.T) {
+	for k, tc := range []struct {
+		ua, browser, os string
+	}{
+		{ua: "Mozilla/5.0 (Macintosh; Intel Mac OS X 10_15_7) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/90.0.4430.85 Safari/537.36", browser: "Chrome", os: "macOS"},
+		{ua: "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/90.0.4430.85 Safari/537.36 Edg/90.0.818.46", browser: "Edge", os: "Windows"},
+		{ua: "Mozilla/5.0 (X11; Ubuntu; Linux x86_64; rv:88.0) Gecko/20100101 Firefox/88.0", browser: "Firefox", os: "Linux"},
+		{ua: "Mozilla/5.0 (iPhone; CPU iPhone OS 14_5 like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/14.1 Mobile/15E148 Safari/604.1", browser: "Safari", os: "iOS"},
+		{ua: "Mozilla/5.0 (Linux; Android 11; SM-G991B) AppleWebKit/537.36 (KHTML, like Gecko) SamsungBrowser/14.0 Chrome/87.0.4280.141 Mobile Safari/537.36", browser: "Samsung Internet", os: "Android"},
+		{ua: "curl/7.64.1"},
+	} {
+		browser, os := parseUserAgent(tc.ua)
+		assert.Equal(t, tc.browser, browser, "%d", k)
+		assert.Equal(t, tc.os, os, "%d", k)
+	}
+}
+
+func TestDescribeDevice(t *testing.T) {
+	assert.Equal(t, "Chrome on macOS, Berlin", describeDevice("Chrome", "macOS", "Berlin"))
+	assert.Equal(t, "Chrome on macOS", describeDevice("Chrome", "macOS", ""))
+	assert.Equal(t, "Linux", describeDevice("", "Linux", ""))
+	assert.Equal(t, "Unknown device, Berlin", describeDevice("", "", "Berlin"))
+}
+
+func TestNewDevice(t *testing.T) {
+	r, _ := http.NewRequest("GET", "https://www.ory.sh/", nil)
+	r.RemoteAddr = "192.0.2.1:1234"
+	r.Header.Set("User-Agent", "Mozilla/5.0 (X11; Ubuntu; Linux x86_64; rv:88.0) Gecko/20100101 Firefox/88.0")
+	r.Header.Set("X-Geo-City", "Berlin")
+
+	t.Run("case=parsing disabled", func(t *testing.T) {
+		d := NewDevice(r, deviceConfig{})
+		assert.Equal(t, &Device{IPAddress: "192.0.2.1", UserAgent: r.UserAgent()}, d)
+	})
+
+	t.Run("case=parsing enabled", func(t *testing.T) {
+		d := NewDevice(r, deviceConfig{parse: true, header: "X-Geo-City"})
+		assert.Equal(t, "Firefox", d.Browser)
+		assert.Equal(t, "Linux", d.OS)
+		assert.Equal(t, "Berlin", d.Location)
+		assert.Equal(t, "Firefox on Linux, Berlin", d.Description)
+	})
+
+	t.Run("case=forwarded for", func(t *testing.T) {
+		r := r.Clone(r.Context())
+		r.Header.Set("X-Forwarded-For", "198.51.100.7, 10.0.0.1")
+		assert.Equal(t, "198.51.100.7", NewDevice(r, deviceConfig{}).IPAddress)
+	})
+
+	t.Run("case=scan and value", func(t *testing.T) {
+		expected := Device{IPAddress: "192.0.2.1", Description: "Unknown device"}
+		v, err := expected.Value()
+		assert.NoError(t, err)
+
+		var actual Device
+		assert.NoError(t, actual.Scan(v))
+		assert.Equal(t, expected, actual)
+		assert.NoError(t, actual.Scan(nil))
+		assert.Equal(t, Device{}, actual)
+	})
+}
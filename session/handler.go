@@ -1,18 +1,29 @@
 package session
 
 import (
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
 	"net/http"
+	"net/url"
+	"strings"
 
+	"github.com/gofrs/uuid"
 	"github.com/julienschmidt/httprouter"
 	"github.com/pkg/errors"
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
 
+	"github.com/ory/nosurf"
 	"github.com/ory/x/decoderx"
 
 	"github.com/ory/x/errorsx"
+	"github.com/ory/x/urlx"
 
 	"github.com/ory/herodot"
 
 	"github.com/ory/kratos/driver/config"
+	"github.com/ory/kratos/identity"
 	"github.com/ory/kratos/x"
 )
 
@@ -20,9 +31,15 @@ type (
 	handlerDependencies interface {
 		ManagementProvider
 		PersistenceProvider
+		identity.PrivilegedPoolProvider
 		x.WriterProvider
 		x.LoggingProvider
 		x.CSRFProvider
+		x.CSRFTokenGeneratorProvider
+		x.ClockProvider
+		config.Provider
+		WebhookProvider
+		RevocationPublisherProvider
 	}
 	HandlerProvider interface {
 		SessionHandler() *Handler
@@ -43,14 +60,19 @@ func NewHandler(
 }
 
 const (
-	RouteWhoami = "/sessions/whoami"
-	RouteRevoke = "/sessions"
+	RouteWhoami           = "/sessions/whoami"
+	RouteRevoke           = "/sessions"
+	RouteList             = "/sessions"
+	RouteIdentitySessions = "/identities/:id/sessions"
+	RoutePrivileged       = "/sessions/privileged"
 	// SessionsWhoisPath  = "/sessions/whois"
 )
 
 func (h *Handler) RegisterPublicRoutes(public *x.RouterPublic) {
 	h.r.CSRFHandler().ExemptPath(RouteWhoami)
 	h.r.CSRFHandler().ExemptPath(RouteRevoke)
+	// The anti-CSRF token is verified by the handler because API clients do not have one.
+	h.r.CSRFHandler().IgnorePath(RoutePrivileged)
 
 	for _, m := range []string{http.MethodGet, http.MethodHead, http.MethodPost, http.MethodPut, http.MethodPatch,
 		http.MethodDelete, http.MethodConnect, http.MethodOptions, http.MethodTrace} {
@@ -58,10 +80,13 @@ func (h *Handler) RegisterPublicRoutes(public *x.RouterPublic) {
 	}
 
 	public.DELETE(RouteRevoke, h.revoke)
+	public.GET(RouteList, h.listOwn)
+	public.POST(RoutePrivileged, h.elevate)
 }
 
 func (h *Handler) RegisterAdminRoutes(admin *x.RouterAdmin) {
 	// admin.GET(SessionsWhoisPath, h.fromPath)
+	admin.GET(RouteIdentitySessions, h.listByIdentity)
 }
 
 // swagger:parameters revokeSession
@@ -111,7 +136,7 @@ func (h *Handler) revoke(w http.ResponseWriter, r *http.Request, _ httprouter.Pa
 		return
 	}
 
-	if err := h.r.SessionPersister().RevokeSessionByToken(r.Context(), p.SessionToken); err != nil {
+	if err := revokeSessionByToken(r.Context(), h.r, p.SessionToken); err != nil {
 		h.r.Writer().WriteError(w, r, err)
 		return
 	}
@@ -127,6 +152,13 @@ type whoamiParameters struct {
 
 	// in: authorization
 	Authorization string `json:"Authorization"`
+
+	// Require Privileged Mode
+	//
+	// If set to true, responds with 403 unless the session is elevated to the privileged mode.
+	//
+	// in: query
+	RequirePrivileged bool `json:"require_privileged"`
 }
 
 // swagger:route GET /sessions/whoami public whoami
@@ -137,6 +169,9 @@ type whoamiParameters struct {
 // Returns a session object in the body or 401 if the credentials are invalid or no credentials were sent.
 // Additionally when the request it successful it adds the user ID to the 'X-Kratos-Authenticated-Identity-Id' header in the response.
 //
+// Set the `require_privileged` query parameter to `true` to respond with 403 unless the session is elevated to the
+// privileged ("sudo") mode.
+//
 // This endpoint is useful for reverse proxies and API Gateways.
 //
 //     Produces:
@@ -150,6 +185,7 @@ type whoamiParameters struct {
 //     Responses:
 //       200: session
 //       401: genericError
+//       403: genericError
 //       500: genericError
 func (h *Handler) whoami(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
 	s, err := h.r.SessionManager().FetchFromRequest(r.Context(), r)
@@ -159,15 +195,126 @@ func (h *Handler) whoami(w http.ResponseWriter, r *http.Request, ps httprouter.P
 		return
 	}
 
-	// s.Devices = nil
+	if r.URL.Query().Get("require_privileged") == "true" && !s.IsElevated(h.r.Clock().Now()) {
+		h.r.Writer().WriteError(w, r, errors.WithStack(ErrSessionNotPrivileged))
+		return
+	}
+
+	h.recordSeen(r, s)
+
 	s.Identity = s.Identity.CopyWithoutCredentials()
+	if err := shapeWhoami(s, h.r.Config(r.Context()).SessionWhoami()); err != nil {
+		h.r.Writer().WriteError(w, r, err)
+		return
+	}
 
 	// Set userId as the X-Kratos-Authenticated-Identity-Id header.
 	w.Header().Set("X-Kratos-Authenticated-Identity-Id", s.Identity.ID.String())
 
+	body, err := json.Marshal(s)
+	if err != nil {
+		h.r.Writer().WriteError(w, r, errors.WithStack(err))
+		return
+	}
+
+	etag := fmt.Sprintf(`"%x"`, sha256.Sum256(body))
+	w.Header().Set("ETag", etag)
+	w.Header().Set("Vary", "Cookie, Authorization, X-Session-Token")
+	if maxAge := h.r.Config(r.Context()).SessionWhoamiCacheMaxAge(); maxAge > 0 {
+		if remaining := s.ExpiresAt.Sub(h.r.Clock().Now()); remaining < maxAge {
+			maxAge = remaining
+		}
+		// The response must not be cached beyond the end of the privileged mode either.
+		if s.IsElevated(h.r.Clock().Now()) {
+			if remaining := s.PrivilegedUntil.Sub(h.r.Clock().Now()); remaining < maxAge {
+				maxAge = remaining
+			}
+		}
+		w.Header().Set("Cache-Control", fmt.Sprintf("private, max-age=%d", int(maxAge.Seconds())))
+	}
+
+	if etagMatches(r.Header.Get("If-None-Match"), etag) {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+
 	h.r.Writer().Write(w, r, s)
 }
 
+// recordSeen records the time the session's identity was seen. To avoid a database write on every request, the
+// time is recorded at most once per interval.
+func (h *Handler) recordSeen(r *http.Request, s *Session) {
+	now := h.r.Clock().Now().UTC()
+	if last := s.Identity.LastSeenAt; last != nil && now.Sub(*last) < h.r.Config(r.Context()).IdentityActivityLastSeenInterval() {
+		return
+	}
+
+	if err := h.r.PrivilegedIdentityPool().RecordIdentityActivity(r.Context(), s.Identity.ID, identity.ActivitySeen, now); err != nil {
+		h.r.Logger().
+			WithRequest(r).
+			WithError(err).
+			WithField("identity_id", s.Identity.ID).
+			Warn("Unable to record the time the identity was last seen.")
+	}
+}
+
+// etagMatches returns true if the If-None-Match header contains the entity tag. Weak entity tags match as
+// well because the comparison is weak as per RFC 7232.
+func etagMatches(ifNoneMatch, etag string) bool {
+	for _, candidate := range strings.Split(ifNoneMatch, ",") {
+		candidate = strings.TrimPrefix(strings.TrimSpace(candidate), "W/")
+		if candidate == "*" || candidate == etag {
+			return true
+		}
+	}
+	return false
+}
+
+// shapeWhoami removes the parts of the session and its identity which should not be included in the
+// whoami response. The session's identity must be a copy because it is modified.
+func shapeWhoami(s *Session, c *config.SessionWhoami) error {
+	// Activity timestamps are only exposed using the admin API.
+	s.Identity.LastLoginAt = nil
+	s.Identity.LastSeenAt = nil
+	s.Identity.PasswordChangedAt = nil
+	s.Identity.StateChangedAt = nil
+	s.Identity.DormancyNotifiedAt = nil
+
+	if !c.Device {
+		s.Device = nil
+	}
+	if !c.VerifiableAddresses {
+		s.Identity.VerifiableAddresses = nil
+	}
+	if !c.RecoveryAddresses {
+		s.Identity.RecoveryAddresses = nil
+	}
+	if !c.CommunicationPreferences {
+		s.Identity.CommunicationPreferences = nil
+	}
+
+	if len(c.Traits) == 0 {
+		return nil
+	}
+
+	traits := []byte("{}")
+	for _, path := range c.Traits {
+		v := gjson.GetBytes(s.Identity.Traits, path)
+		if !v.Exists() {
+			continue
+		}
+
+		var err error
+		traits, err = sjson.SetRawBytes(traits, path, []byte(v.Raw))
+		if err != nil {
+			return errors.WithStack(err)
+		}
+	}
+
+	s.Identity.Traits = identity.Traits(traits)
+	return nil
+}
+
 func (h *Handler) IsAuthenticated(wrap httprouter.Handle, onUnauthenticated httprouter.Handle) httprouter.Handle {
 	return func(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
 		if _, err := h.r.SessionManager().FetchFromRequest(r.Context(), r); err != nil {
@@ -184,6 +331,24 @@ func (h *Handler) IsAuthenticated(wrap httprouter.Handle, onUnauthenticated http
 	}
 }
 
+// IsPrivileged only calls wrap if the session is elevated to the privileged mode.
+func (h *Handler) IsPrivileged(wrap httprouter.Handle, onUnprivileged httprouter.Handle) httprouter.Handle {
+	return func(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+		s, err := h.r.SessionManager().FetchFromRequest(r.Context(), r)
+		if err != nil || !s.IsElevated(h.r.Clock().Now()) {
+			if onUnprivileged != nil {
+				onUnprivileged(w, r, ps)
+				return
+			}
+
+			h.r.Writer().WriteError(w, r, errors.WithStack(ErrSessionNotPrivileged))
+			return
+		}
+
+		wrap(w, r, ps)
+	}
+}
+
 func (h *Handler) IsNotAuthenticated(wrap httprouter.Handle, onAuthenticated httprouter.Handle) httprouter.Handle {
 	return func(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
 		if _, err := h.r.SessionManager().FetchFromRequest(r.Context(), r); err != nil {
@@ -204,11 +369,20 @@ func (h *Handler) IsNotAuthenticated(wrap httprouter.Handle, onAuthenticated htt
 	}
 }
 
-func RedirectOnAuthenticated(d interface{ config.Provider }) httprouter.Handle {
+func RedirectOnAuthenticated(d interface {
+	config.Provider
+	x.WriterProvider
+}) httprouter.Handle {
 	return func(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
-		returnTo, err := x.SecureRedirectTo(r, d.Config(r.Context()).SelfServiceBrowserDefaultReturnTo(), x.SecureRedirectAllowSelfServiceURLs(d.Config(r.Context()).SelfPublicURL(r)))
+		defaultReturnTo, err := d.Config(r.Context()).SelfServiceBrowserDefaultReturnToOrErr()
+		if err != nil {
+			d.Writer().WriteError(w, r, err)
+			return
+		}
+
+		returnTo, err := x.SecureRedirectTo(r, defaultReturnTo, x.SecureRedirectAllowSelfServiceURLs(d.Config(r.Context()).SelfPublicURL(r)))
 		if err != nil {
-			http.Redirect(w, r, d.Config(r.Context()).SelfServiceBrowserDefaultReturnTo().String(), http.StatusFound)
+			http.Redirect(w, r, defaultReturnTo.String(), http.StatusFound)
 			return
 		}
 
@@ -227,3 +401,227 @@ func RespondWithJSONErrorOnAuthenticated(h herodot.Writer, err error) httprouter
 		h.WriteError(w, r, err)
 	}
 }
+
+// swagger:parameters elevateSession
+// nolint:deadcode,unused
+type elevateSessionParameters struct {
+	// in: body
+	Body elevateSession
+}
+
+type elevateSession struct {
+	// Sending the anti-csrf token is only required for browser clients.
+	CSRFToken string `json:"csrf_token"`
+}
+
+// swagger:route POST /sessions/privileged public elevateSession
+//
+// Elevate the Current Session to the Privileged Mode
+//
+// Marks the session as privileged ("sudo" mode) for the duration configured in `session.privileged.lifespan`.
+// The user must have re-authenticated recently, for example by completing a login flow with `refresh=true`.
+// Other services can check whether a session is privileged by calling `/sessions/whoami?require_privileged=true`.
+//
+// Browser clients must send the anti-CSRF token.
+//
+//     Consumes:
+//     - application/json
+//
+//     Produces:
+//     - application/json
+//
+//     Schemes: http, https
+//
+//     Security:
+//       sessionToken:
+//
+//     Responses:
+//       200: session
+//       400: genericError
+//       401: genericError
+//       403: genericError
+//       500: genericError
+func (h *Handler) elevate(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+	s, err := h.r.SessionManager().FetchFromRequest(r.Context(), r)
+	if err != nil {
+		h.r.Writer().WriteError(w, r, herodot.ErrUnauthorized.WithWrap(err).WithReasonf("No valid session cookie found."))
+		return
+	}
+
+	if !isTokenRequest(r) {
+		var p elevateSession
+		if err := h.dx.Decode(r, &p,
+			decoderx.HTTPJSONDecoder(),
+			decoderx.HTTPDecoderAllowedMethods("POST")); err != nil {
+			h.r.Writer().WriteError(w, r, err)
+			return
+		}
+
+		if !nosurf.VerifyToken(h.r.GenerateCSRFToken(r), p.CSRFToken) {
+			h.r.Writer().WriteError(w, r, errors.WithStack(x.ErrInvalidCSRFToken))
+			return
+		}
+	}
+
+	now := h.r.Clock().Now()
+	if s.bindingBroken || !s.AuthenticatedAt.Add(h.r.Config(r.Context()).SessionPrivilegedReauthenticationMaxAge()).After(now) {
+		h.r.Writer().WriteError(w, r, errors.WithStack(ErrReauthenticationRequired))
+		return
+	}
+
+	until := now.Add(h.r.Config(r.Context()).SessionPrivilegedLifespan()).UTC()
+	if err := h.r.SessionPersister().ElevateSession(r.Context(), s.ID, until); err != nil {
+		h.r.Writer().WriteError(w, r, err)
+		return
+	}
+	s.PrivilegedUntil = &until
+
+	h.r.Audit().
+		WithRequest(r).
+		WithField("identity_id", s.IdentityID).
+		WithField("session_id", s.ID).
+		WithField("privileged_until", until).
+		Info("A session was elevated to the privileged mode.")
+
+	s.Identity = s.Identity.CopyWithoutCredentials()
+	if err := shapeWhoami(s, h.r.Config(r.Context()).SessionWhoami()); err != nil {
+		h.r.Writer().WriteError(w, r, err)
+		return
+	}
+
+	h.r.Writer().Write(w, r, s)
+}
+
+// isTokenRequest returns true if the session token was sent in a header instead of a cookie.
+func isTokenRequest(r *http.Request) bool {
+	if _, ok := bearerTokenFromRequest(r); ok {
+		return true
+	}
+	return len(r.Header.Get("X-Session-Token")) > 0
+}
+
+// A list of sessions.
+// swagger:response sessionList
+// nolint:deadcode,unused
+type sessionList struct {
+	// in: body
+	// type: array
+	Body []Session
+}
+
+// nolint:deadcode,unused
+// swagger:parameters listSessions
+type listSessionsParameters struct {
+	// Items per Page
+	//
+	// This is the number of items per page.
+	//
+	// required: false
+	// in: query
+	// default: 100
+	// min: 1
+	// max: 500
+	PerPage int `json:"per_page"`
+
+	// Pagination Page
+	//
+	// required: false
+	// in: query
+	// default: 0
+	// min: 0
+	Page int `json:"page"`
+}
+
+// swagger:route GET /sessions public listSessions
+//
+// List the Sessions of the Current Identity
+//
+// Uses the session cookie or token to list all sessions of the identity it belongs to, most recent first.
+// Each session includes the device it was issued to.
+//
+//     Produces:
+//     - application/json
+//
+//     Schemes: http, https
+//
+//     Security:
+//       sessionToken:
+//
+//     Responses:
+//       200: sessionList
+//       401: genericError
+//       500: genericError
+func (h *Handler) listOwn(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+	s, err := h.r.SessionManager().FetchFromRequest(r.Context(), r)
+	if err != nil {
+		h.r.Writer().WriteError(w, r, herodot.ErrUnauthorized.WithWrap(err).WithReasonf("No valid session cookie found."))
+		return
+	}
+
+	h.list(w, r, s.IdentityID, urlx.AppendPaths(h.r.Config(r.Context()).SelfPublicURL(r), RouteList))
+}
+
+// nolint:deadcode,unused
+// swagger:parameters listIdentitySessions
+type listIdentitySessionsParameters struct {
+	// Items per Page
+	//
+	// This is the number of items per page.
+	//
+	// required: false
+	// in: query
+	// default: 100
+	// min: 1
+	// max: 500
+	PerPage int `json:"per_page"`
+
+	// Pagination Page
+	//
+	// required: false
+	// in: query
+	// default: 0
+	// min: 0
+	Page int `json:"page"`
+
+	// ID is the identity's ID.
+	//
+	// required: true
+	// in: path
+	ID string `json:"id"`
+}
+
+// swagger:route GET /identities/{id}/sessions admin listIdentitySessions
+//
+// List the Sessions of an Identity
+//
+// Lists all sessions of an identity, most recent first. Each session includes the device it was issued to.
+//
+//     Produces:
+//     - application/json
+//
+//     Schemes: http, https
+//
+//     Responses:
+//       200: sessionList
+//       404: genericError
+//       500: genericError
+func (h *Handler) listByIdentity(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	id := x.ParseUUID(ps.ByName("id"))
+	h.list(w, r, id, urlx.AppendPaths(h.r.Config(r.Context()).SelfAdminURL(), "identities", id.String(), "sessions"))
+}
+
+func (h *Handler) list(w http.ResponseWriter, r *http.Request, identityID uuid.UUID, base *url.URL) {
+	page, itemsPerPage := x.ParsePagination(r)
+	ss, total, err := h.r.SessionPersister().ListSessionsByIdentity(r.Context(), identityID, page, itemsPerPage)
+	if err != nil {
+		h.r.Writer().WriteError(w, r, err)
+		return
+	}
+
+	for k := range ss {
+		ss[k].Declassify()
+	}
+
+	x.PaginationHeader(w, base, total, page, itemsPerPage)
+	h.r.Writer().Write(w, r, ss)
+}
@@ -3,6 +3,7 @@ package session_test
 import (
 	"context"
 	"fmt"
+	"io/ioutil"
 	"net/http"
 	"net/http/httptest"
 	"testing"
@@ -13,6 +14,7 @@ import (
 	"github.com/julienschmidt/httprouter"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"github.com/tidwall/gjson"
 
 	"github.com/ory/x/pointerx"
 
@@ -80,6 +82,68 @@ func TestSessionWhoAmI(t *testing.T) {
 				assert.NotEmpty(t, res.Header.Get("X-Kratos-Authenticated-Identity-Id"))
 			})
 		}
+
+		t.Run("case=shapes response", func(t *testing.T) {
+			conf.MustSet(config.ViperKeySessionWhoamiIdentityTraits, []string{"baz", "unknown"})
+			conf.MustSet(config.ViperKeySessionWhoamiIdentityVerifiableAddresses, false)
+			conf.MustSet(config.ViperKeySessionWhoamiDevice, false)
+			t.Cleanup(func() {
+				conf.MustSet(config.ViperKeySessionWhoamiIdentityTraits, []string{})
+				conf.MustSet(config.ViperKeySessionWhoamiIdentityVerifiableAddresses, true)
+				conf.MustSet(config.ViperKeySessionWhoamiDevice, true)
+			})
+
+			res, err := client.Get(ts.URL + RouteWhoami)
+			require.NoError(t, err)
+			defer res.Body.Close()
+			require.EqualValues(t, http.StatusOK, res.StatusCode)
+
+			body, err := ioutil.ReadAll(res.Body)
+			require.NoError(t, err)
+			assert.JSONEq(t, `{"baz":"bar"}`, gjson.GetBytes(body, "identity.traits").Raw, "%s", body)
+			assert.False(t, gjson.GetBytes(body, "identity.verifiable_addresses").Exists(), "%s", body)
+			assert.False(t, gjson.GetBytes(body, "device").Exists(), "%s", body)
+			assert.True(t, gjson.GetBytes(body, "identity.id").Exists(), "%s", body)
+		})
+
+		t.Run("case=conditional request", func(t *testing.T) {
+			res, err := client.Get(ts.URL + RouteWhoami)
+			require.NoError(t, err)
+			require.EqualValues(t, http.StatusOK, res.StatusCode)
+			etag := res.Header.Get("ETag")
+			require.NotEmpty(t, etag)
+			assert.Contains(t, res.Header.Get("Cache-Control"), "no-store")
+
+			for _, inm := range []string{etag, "W/" + etag, `"foo", ` + etag, "*"} {
+				req, err := http.NewRequest("GET", ts.URL+RouteWhoami, nil)
+				require.NoError(t, err)
+				req.Header.Set("If-None-Match", inm)
+
+				res, err = client.Do(req)
+				require.NoError(t, err)
+				assert.EqualValues(t, http.StatusNotModified, res.StatusCode, inm)
+				assert.Equal(t, etag, res.Header.Get("ETag"))
+			}
+
+			req, err := http.NewRequest("GET", ts.URL+RouteWhoami, nil)
+			require.NoError(t, err)
+			req.Header.Set("If-None-Match", `"foo"`)
+			res, err = client.Do(req)
+			require.NoError(t, err)
+			assert.EqualValues(t, http.StatusOK, res.StatusCode)
+		})
+
+		t.Run("case=cache max age", func(t *testing.T) {
+			conf.MustSet(config.ViperKeySessionWhoamiCacheMaxAge, "1m")
+			t.Cleanup(func() {
+				conf.MustSet(config.ViperKeySessionWhoamiCacheMaxAge, "0s")
+			})
+
+			res, err := client.Get(ts.URL + RouteWhoami)
+			require.NoError(t, err)
+			assert.EqualValues(t, http.StatusOK, res.StatusCode)
+			assert.Equal(t, "private, max-age=60", res.Header.Get("Cache-Control"))
+		})
 	})
 }
 
@@ -89,7 +153,7 @@ func TestSessionRevoke(t *testing.T) {
 	conf.MustSet(config.ViperKeyDefaultIdentitySchemaURL, "file://stub/identity.schema.json")
 	i := &identity.Identity{Traits: identity.Traits(`{"baz":"bar"}`)}
 	require.NoError(t, reg.PrivilegedIdentityPool().CreateIdentity(context.Background(), i))
-	sess := NewActiveSession(i, conf, time.Now())
+	sess := NewActiveSession(x.SystemClock, x.RandomIDGenerator, i, conf, time.Now())
 	require.NoError(t, reg.SessionPersister().CreateSession(context.Background(), sess))
 
 	sdk := testhelpers.NewSDKClient(publicTS)
@@ -100,7 +164,101 @@ func TestSessionRevoke(t *testing.T) {
 	actual, err := reg.SessionPersister().GetSession(context.Background(), sess.ID)
 	require.NoError(t, err)
 	assert.False(t, actual.Active)
-	assert.False(t, actual.IsActive())
+	assert.False(t, actual.IsActive(x.SystemClock))
+}
+
+func TestSessionElevate(t *testing.T) {
+	conf, reg := internal.NewFastRegistryWithMocks(t)
+	publicTS, _ := testhelpers.NewKratosServer(t, reg)
+	conf.MustSet(config.ViperKeyDefaultIdentitySchemaURL, "file://stub/identity.schema.json")
+	conf.MustSet(config.ViperKeySessionPrivilegedLifespan, "10m")
+	conf.MustSet(config.ViperKeySessionPrivilegedReauthenticationMaxAge, "5m")
+
+	newSession := func(t *testing.T, authenticatedAt time.Time) *Session {
+		i := &identity.Identity{Traits: identity.Traits(`{"baz":"bar"}`)}
+		require.NoError(t, reg.PrivilegedIdentityPool().CreateIdentity(context.Background(), i))
+		sess := NewActiveSession(x.SystemClock, x.RandomIDGenerator, i, conf, authenticatedAt)
+		require.NoError(t, reg.SessionPersister().CreateSession(context.Background(), sess))
+		return sess
+	}
+
+	do := func(t *testing.T, method, path string, sess *Session) (*http.Response, []byte) {
+		req, err := http.NewRequest(method, publicTS.URL+path, nil)
+		require.NoError(t, err)
+		req.Header.Set("X-Session-Token", sess.Token)
+		res, err := publicTS.Client().Do(req)
+		require.NoError(t, err)
+		defer res.Body.Close()
+		body, err := ioutil.ReadAll(res.Body)
+		require.NoError(t, err)
+		return res, body
+	}
+
+	t.Run("case=elevates a recently authenticated session", func(t *testing.T) {
+		sess := newSession(t, time.Now())
+
+		res, body := do(t, "GET", RouteWhoami+"?require_privileged=true", sess)
+		assert.EqualValues(t, http.StatusForbidden, res.StatusCode, "%s", body)
+
+		res, body = do(t, "POST", RoutePrivileged, sess)
+		require.EqualValues(t, http.StatusOK, res.StatusCode, "%s", body)
+		until := gjson.GetBytes(body, "privileged_until").Time()
+		assert.WithinDuration(t, time.Now().Add(10*time.Minute), until, time.Minute)
+
+		actual, err := reg.SessionPersister().GetSession(context.Background(), sess.ID)
+		require.NoError(t, err)
+		require.NotNil(t, actual.PrivilegedUntil)
+		assert.True(t, actual.IsElevated(time.Now()))
+
+		res, body = do(t, "GET", RouteWhoami+"?require_privileged=true", sess)
+		assert.EqualValues(t, http.StatusOK, res.StatusCode, "%s", body)
+	})
+
+	t.Run("case=requires re-authentication", func(t *testing.T) {
+		sess := newSession(t, time.Now().Add(-time.Hour))
+
+		res, body := do(t, "POST", RoutePrivileged, sess)
+		assert.EqualValues(t, http.StatusForbidden, res.StatusCode, "%s", body)
+		assert.Contains(t, gjson.GetBytes(body, "error.reason").String(), "refresh=true", "%s", body)
+
+		actual, err := reg.SessionPersister().GetSession(context.Background(), sess.ID)
+		require.NoError(t, err)
+		assert.Nil(t, actual.PrivilegedUntil)
+	})
+
+	t.Run("case=requires a session", func(t *testing.T) {
+		res, err := publicTS.Client().Post(publicTS.URL+RoutePrivileged, "application/json", nil)
+		require.NoError(t, err)
+		defer res.Body.Close()
+		assert.EqualValues(t, http.StatusUnauthorized, res.StatusCode)
+	})
+}
+
+func TestIsPrivileged(t *testing.T) {
+	conf, reg := internal.NewFastRegistryWithMocks(t)
+	r := x.NewRouterPublic()
+	conf.MustSet(config.ViperKeyPublicBaseURL, "http://example.com")
+
+	h, sess := testhelpers.MockSessionCreateHandler(t, reg)
+	r.GET("/set", h)
+	r.GET("/privileged", reg.SessionHandler().IsPrivileged(send(http.StatusOK), send(http.StatusBadRequest)))
+
+	ts := httptest.NewServer(r)
+	defer ts.Close()
+	conf.MustSet(config.ViperKeyPublicBaseURL, ts.URL)
+
+	c := testhelpers.NewClientWithCookies(t)
+	testhelpers.MockHydrateCookieClient(t, c, ts.URL+"/set")
+
+	res, err := c.Get(ts.URL + "/privileged")
+	require.NoError(t, err)
+	assert.EqualValues(t, http.StatusBadRequest, res.StatusCode)
+
+	require.NoError(t, reg.SessionPersister().ElevateSession(context.Background(), sess.ID, time.Now().Add(time.Minute)))
+
+	res, err = c.Get(ts.URL + "/privileged")
+	require.NoError(t, err)
+	assert.EqualValues(t, http.StatusOK, res.StatusCode)
 }
 
 func TestIsNotAuthenticatedSecurecookie(t *testing.T) {
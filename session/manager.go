@@ -4,12 +4,20 @@ import (
 	"context"
 	"net/http"
 
+	"github.com/gofrs/uuid"
+
 	"github.com/ory/herodot"
 )
 
 var (
 	// ErrNoActiveSessionFound is returned when no active cookie session could be found in the request.
 	ErrNoActiveSessionFound = herodot.ErrUnauthorized.WithError("request does not have a valid authentication session").WithReason("No active session was found in this request.")
+
+	// ErrSessionNotPrivileged is returned when the session is not elevated to the privileged mode.
+	ErrSessionNotPrivileged = herodot.ErrForbidden.WithError("session is not privileged").WithReason("The session is not elevated to the privileged mode.")
+
+	// ErrReauthenticationRequired is returned when a session can not be elevated because the user did not re-authenticate recently.
+	ErrReauthenticationRequired = herodot.ErrForbidden.WithError("re-authentication required").WithReason("Please re-authenticate using a login flow with refresh=true before elevating the session.")
 )
 
 // Manager handles identity sessions.
@@ -29,6 +37,9 @@ type Manager interface {
 
 	// PurgeFromRequest removes an HTTP session.
 	PurgeFromRequest(context.Context, http.ResponseWriter, *http.Request) error
+
+	// RevokeOtherSessions revokes all active sessions of the identity except the given one.
+	RevokeOtherSessions(ctx context.Context, identityID, except uuid.UUID) error
 }
 
 type ManagementProvider interface {
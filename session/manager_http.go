@@ -4,6 +4,7 @@ import (
 	"context"
 	"net/http"
 
+	"github.com/gofrs/uuid"
 	"github.com/pkg/errors"
 
 	"github.com/ory/kratos/driver/config"
@@ -22,7 +23,11 @@ type (
 		identity.PoolProvider
 		x.CookieProvider
 		x.CSRFProvider
+		x.ClockProvider
+		x.LoggingProvider
 		PersistenceProvider
+		WebhookProvider
+		RevocationPublisherProvider
 	}
 	ManagerHTTP struct {
 		cookieName func(ctx context.Context) string
@@ -127,17 +132,38 @@ func (s *ManagerHTTP) FetchFromRequest(ctx context.Context, r *http.Request) (*S
 		return nil, err
 	}
 
-	if !se.IsActive() {
+	if !se.IsActive(s.r.Clock()) {
 		return nil, errors.WithStack(ErrNoActiveSessionFound)
 	}
 
+	if se.Identity != nil && !se.Identity.IsActive() {
+		return nil, errors.WithStack(ErrNoActiveSessionFound)
+	}
+
+	if b := s.r.Config(ctx).SessionBinding(); b.Mode != config.SessionBindingModeOff && !se.Device.matchesBinding(r, b) {
+		s.r.Audit().
+			WithRequest(r).
+			WithField("identity_id", se.IdentityID).
+			WithField("session_id", se.ID).
+			WithField("binding_mode", b.Mode).
+			Info("A session was used from a different IP range or user agent family than it was issued to.")
+
+		if b.Mode == config.SessionBindingModeStrict {
+			if err := revokeSessionByToken(ctx, s.r, token); err != nil {
+				return nil, err
+			}
+			return nil, errors.WithStack(ErrNoActiveSessionFound)
+		}
+		se.bindingBroken = true
+	}
+
 	se.Identity = se.Identity.CopyWithoutCredentials()
 	return se, nil
 }
 
 func (s *ManagerHTTP) PurgeFromRequest(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
 	if token, ok := bearerTokenFromRequest(r); ok {
-		return errors.WithStack(s.r.SessionPersister().RevokeSessionByToken(ctx, token))
+		return revokeSessionByToken(ctx, s.r, token)
 	}
 
 	cookie, _ := s.r.CookieManager(r.Context()).Get(r, s.cookieName(ctx))
@@ -146,8 +172,8 @@ func (s *ManagerHTTP) PurgeFromRequest(ctx context.Context, w http.ResponseWrite
 		return nil
 	}
 
-	if err := s.r.SessionPersister().RevokeSessionByToken(ctx, token); err != nil {
-		return errors.WithStack(err)
+	if err := revokeSessionByToken(ctx, s.r, token); err != nil {
+		return err
 	}
 
 	cookie.Options.MaxAge = -1
@@ -156,3 +182,32 @@ func (s *ManagerHTTP) PurgeFromRequest(ctx context.Context, w http.ResponseWrite
 	}
 	return nil
 }
+
+func (s *ManagerHTTP) RevokeOtherSessions(ctx context.Context, identityID, except uuid.UUID) error {
+	// Revoking a session does not remove it from the list, which is why the pages are stable.
+	var tokens []string
+	for page, seen := 1, 0; ; page++ {
+		ss, total, err := s.r.SessionPersister().ListSessionsByIdentity(ctx, identityID, page, 500)
+		if err != nil {
+			return err
+		}
+
+		for k := range ss {
+			if ss[k].ID != except && ss[k].Active {
+				tokens = append(tokens, ss[k].Token)
+			}
+		}
+
+		seen += len(ss)
+		if len(ss) == 0 || int64(seen) >= total {
+			break
+		}
+	}
+
+	for _, token := range tokens {
+		if err := revokeSessionByToken(ctx, s.r, token); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -87,7 +87,7 @@ func TestManagerHTTP(t *testing.T) {
 
 			i := identity.Identity{Traits: []byte("{}")}
 			require.NoError(t, reg.PrivilegedIdentityPool().CreateIdentity(context.Background(), &i))
-			s = session.NewActiveSession(&i, conf, time.Now())
+			s = session.NewActiveSession(x.SystemClock, x.RandomIDGenerator, &i, conf, time.Now())
 
 			c := testhelpers.NewClientWithCookies(t)
 			testhelpers.MockHydrateCookieClient(t, c, pts.URL+"/session/set")
@@ -105,7 +105,7 @@ func TestManagerHTTP(t *testing.T) {
 
 			i := identity.Identity{Traits: []byte("{}")}
 			require.NoError(t, reg.PrivilegedIdentityPool().CreateIdentity(context.Background(), &i))
-			s = session.NewActiveSession(&i, conf, time.Now())
+			s = session.NewActiveSession(x.SystemClock, x.RandomIDGenerator, &i, conf, time.Now())
 
 			c := testhelpers.NewClientWithCookies(t)
 			testhelpers.MockHydrateCookieClient(t, c, pts.URL+"/session/set")
@@ -120,9 +120,9 @@ func TestManagerHTTP(t *testing.T) {
 		t.Run("case=revoked", func(t *testing.T) {
 			i := identity.Identity{Traits: []byte("{}")}
 			require.NoError(t, reg.PrivilegedIdentityPool().CreateIdentity(context.Background(), &i))
-			s = session.NewActiveSession(&i, conf, time.Now())
+			s = session.NewActiveSession(x.SystemClock, x.RandomIDGenerator, &i, conf, time.Now())
 
-			s = session.NewActiveSession(&i, conf, time.Now())
+			s = session.NewActiveSession(x.SystemClock, x.RandomIDGenerator, &i, conf, time.Now())
 
 			c := testhelpers.NewClientWithCookies(t)
 			testhelpers.MockHydrateCookieClient(t, c, pts.URL+"/session/set")
@@ -135,5 +135,86 @@ func TestManagerHTTP(t *testing.T) {
 			require.NoError(t, err)
 			assert.EqualValues(t, http.StatusUnauthorized, res.StatusCode)
 		})
+
+		t.Run("case=revoke other sessions", func(t *testing.T) {
+			i := identity.Identity{Traits: []byte("{}")}
+			require.NoError(t, reg.PrivilegedIdentityPool().CreateIdentity(context.Background(), &i))
+
+			ss := make([]*session.Session, 3)
+			for k := range ss {
+				ss[k] = session.NewActiveSession(x.SystemClock, x.RandomIDGenerator, &i, conf, time.Now())
+				require.NoError(t, reg.SessionPersister().CreateSession(context.Background(), ss[k]))
+			}
+
+			require.NoError(t, reg.SessionManager().RevokeOtherSessions(context.Background(), i.ID, ss[0].ID))
+
+			for k, expected := range []bool{true, false, false} {
+				actual, err := reg.SessionPersister().GetSession(context.Background(), ss[k].ID)
+				require.NoError(t, err)
+				assert.Equal(t, expected, actual.Active, "%d", k)
+			}
+		})
+
+		t.Run("case=binding", func(t *testing.T) {
+			const ua = "Mozilla/5.0 (X11; Ubuntu; Linux x86_64; rv:88.0) Gecko/20100101 Firefox/88.0"
+			t.Cleanup(func() {
+				conf.MustSet(config.ViperKeySessionBindingMode, config.SessionBindingModeOff)
+			})
+
+			newSession := func(t *testing.T) *session.Session {
+				i := identity.Identity{Traits: []byte("{}")}
+				require.NoError(t, reg.PrivilegedIdentityPool().CreateIdentity(context.Background(), &i))
+				s := session.NewActiveSession(x.SystemClock, x.RandomIDGenerator, &i, conf, time.Now())
+				s.Device = &session.Device{IPAddress: "192.0.2.1", UserAgent: ua}
+				require.NoError(t, reg.SessionPersister().CreateSession(context.Background(), s))
+				return s
+			}
+
+			fetch := func(s *session.Session, ip string) (*session.Session, error) {
+				r := httptest.NewRequest("GET", "/", nil)
+				r.RemoteAddr = ip + ":1234"
+				r.Header.Set("User-Agent", ua)
+				r.Header.Set("X-Session-Token", s.Token)
+				return reg.SessionManager().FetchFromRequest(context.Background(), r)
+			}
+
+			t.Run("mode=off", func(t *testing.T) {
+				conf.MustSet(config.ViperKeySessionBindingMode, config.SessionBindingModeOff)
+				actual, err := fetch(newSession(t), "198.51.100.7")
+				require.NoError(t, err)
+				assert.True(t, actual.IsPrivileged(time.Now(), time.Hour))
+			})
+
+			t.Run("mode=lax", func(t *testing.T) {
+				conf.MustSet(config.ViperKeySessionBindingMode, config.SessionBindingModeLax)
+				s := newSession(t)
+
+				actual, err := fetch(s, "192.0.2.77")
+				require.NoError(t, err)
+				assert.True(t, actual.IsPrivileged(time.Now(), time.Hour))
+
+				actual, err = fetch(s, "198.51.100.7")
+				require.NoError(t, err)
+				assert.False(t, actual.IsPrivileged(time.Now(), time.Hour))
+			})
+
+			t.Run("mode=strict", func(t *testing.T) {
+				conf.MustSet(config.ViperKeySessionBindingMode, config.SessionBindingModeStrict)
+				s := newSession(t)
+
+				_, err := fetch(s, "192.0.2.77")
+				require.NoError(t, err)
+
+				_, err = fetch(s, "198.51.100.7")
+				require.ErrorIs(t, err, session.ErrNoActiveSessionFound)
+
+				actual, err := reg.SessionPersister().GetSession(context.Background(), s.ID)
+				require.NoError(t, err)
+				assert.False(t, actual.Active)
+
+				_, err = fetch(s, "192.0.2.1")
+				require.Error(t, err)
+			})
+		})
 	})
 }
@@ -3,6 +3,7 @@ package session
 import (
 	"context"
 	"testing"
+	"time"
 
 	"github.com/bxcodec/faker/v3"
 	"github.com/gofrs/uuid"
@@ -28,6 +29,13 @@ type Persister interface {
 	// DeleteSession removes a session from the store.
 	DeleteSession(ctx context.Context, id uuid.UUID) error
 
+	// ListSessionsByIdentity returns the sessions of the given identity, most recent first, and the total
+	// number of sessions of the identity.
+	ListSessionsByIdentity(ctx context.Context, identity uuid.UUID, page, perPage int) ([]Session, int64, error)
+
+	// CountActiveSessions counts the sessions which are active and not expired.
+	CountActiveSessions(ctx context.Context) (int64, error)
+
 	// DeleteSessionsByIdentity removes all active session from the store for the given identity.
 	DeleteSessionsByIdentity(ctx context.Context, identity uuid.UUID) error
 
@@ -45,6 +53,9 @@ type Persister interface {
 
 	// RevokeSessionByToken marks a session inactive with the given token.
 	RevokeSessionByToken(ctx context.Context, token string) error
+
+	// ElevateSession marks the session as privileged until the given time.
+	ElevateSession(ctx context.Context, sid uuid.UUID, until time.Time) error
 }
 
 func TestPersister(ctx context.Context, conf *config.Config, p interface {
@@ -80,6 +91,7 @@ func TestPersister(ctx context.Context, conf *config.Config, p interface {
 				assert.EqualValues(t, expected.ExpiresAt.Unix(), actual.ExpiresAt.Unix())
 				assert.Equal(t, expected.AuthenticatedAt.Unix(), actual.AuthenticatedAt.Unix())
 				assert.Equal(t, expected.IssuedAt.Unix(), actual.IssuedAt.Unix())
+				assert.Equal(t, expected.Device, actual.Device)
 			}
 
 			t.Run("method=get by id", func(t *testing.T) {
@@ -91,6 +103,39 @@ func TestPersister(ctx context.Context, conf *config.Config, p interface {
 			})
 		})
 
+		t.Run("case=list sessions by identity", func(t *testing.T) {
+			var i identity.Identity
+			require.NoError(t, faker.FakeData(&i))
+			require.NoError(t, p.CreateIdentity(ctx, &i))
+
+			created := make([]uuid.UUID, 3)
+			for k := range created {
+				var s Session
+				require.NoError(t, faker.FakeData(&s))
+				s.Identity = &i
+				s.IdentityID = i.ID
+				require.NoError(t, p.CreateSession(ctx, &s))
+				created[k] = s.ID
+			}
+
+			actual, total, err := p.ListSessionsByIdentity(ctx, i.ID, 0, 2)
+			require.NoError(t, err)
+			assert.EqualValues(t, 3, total)
+			require.Len(t, actual, 2)
+			for _, s := range actual {
+				assert.Equal(t, i.ID, s.Identity.ID)
+				assert.Contains(t, created, s.ID)
+			}
+
+			actual, total, err = p.ListSessionsByIdentity(ctx, i.ID, 2, 2)
+			require.NoError(t, err)
+			assert.EqualValues(t, 3, total)
+			require.Len(t, actual, 1)
+
+			_, _, err = p.ListSessionsByIdentity(ctx, x.NewUUID(), 0, 2)
+			require.Error(t, err)
+		})
+
 		t.Run("case=delete session", func(t *testing.T) {
 			var expected Session
 			require.NoError(t, faker.FakeData(&expected))
@@ -131,6 +176,48 @@ func TestPersister(ctx context.Context, conf *config.Config, p interface {
 			assert.False(t, actual.Active)
 		})
 
+		t.Run("case=elevate session", func(t *testing.T) {
+			var expected Session
+			require.NoError(t, faker.FakeData(&expected))
+			require.NoError(t, p.CreateIdentity(ctx, expected.Identity))
+			require.NoError(t, p.CreateSession(ctx, &expected))
+
+			actual, err := p.GetSession(ctx, expected.ID)
+			require.NoError(t, err)
+			assert.Nil(t, actual.PrivilegedUntil)
+
+			until := time.Now().Add(time.Minute).UTC().Round(time.Second)
+			require.NoError(t, p.ElevateSession(ctx, expected.ID, until))
+
+			actual, err = p.GetSession(ctx, expected.ID)
+			require.NoError(t, err)
+			require.NotNil(t, actual.PrivilegedUntil)
+			assert.Equal(t, until, actual.PrivilegedUntil.UTC().Round(time.Second))
+
+			require.Error(t, p.ElevateSession(ctx, x.NewUUID(), until))
+		})
+
+		t.Run("case=count active sessions", func(t *testing.T) {
+			before, err := p.CountActiveSessions(ctx)
+			require.NoError(t, err)
+
+			var expected Session
+			require.NoError(t, faker.FakeData(&expected))
+			expected.Active = true
+			expected.ExpiresAt = time.Now().UTC().Add(time.Hour)
+			require.NoError(t, p.CreateIdentity(ctx, expected.Identity))
+			require.NoError(t, p.CreateSession(ctx, &expected))
+
+			after, err := p.CountActiveSessions(ctx)
+			require.NoError(t, err)
+			assert.EqualValues(t, before+1, after)
+
+			require.NoError(t, p.RevokeSessionByToken(ctx, expected.Token))
+			after, err = p.CountActiveSessions(ctx)
+			require.NoError(t, err)
+			assert.EqualValues(t, before, after)
+		})
+
 		t.Run("case=delete session for", func(t *testing.T) {
 			var expected1 Session
 			var expected2 Session
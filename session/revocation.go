@@ -0,0 +1,166 @@
+package session
+
+import (
+	"bufio"
+	"context"
+	"crypto/sha256"
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/gofrs/uuid"
+	"github.com/pkg/errors"
+
+	"github.com/ory/kratos/driver/config"
+	"github.com/ory/kratos/x"
+)
+
+type (
+	// RevocationEvent is published whenever a session is revoked so that caches of the whoami response
+	// can be invalidated.
+	RevocationEvent struct {
+		// SessionID is the ID of the revoked session. It is empty if all sessions of the identity were revoked.
+		SessionID *uuid.UUID `json:"session_id,omitempty"`
+
+		// IdentityID is the ID of the identity the session belongs to.
+		IdentityID uuid.UUID `json:"identity_id"`
+
+		// TokenHash is the hex encoded SHA-256 hash of the revoked session token. Caches keyed by the
+		// session token or cookie can use it to find the cached response without knowing the token.
+		TokenHash string `json:"token_hash,omitempty"`
+
+		// Timestamp is the time the session was revoked at.
+		Timestamp time.Time `json:"timestamp"`
+	}
+
+	revocationPublisherDependencies interface {
+		config.Provider
+		x.LoggingProvider
+		x.ClockProvider
+	}
+	RevocationPublisherProvider interface {
+		SessionRevocationPublisher() *RevocationPublisher
+	}
+	// RevocationPublisher publishes session revocations to the configured Redis channel.
+	RevocationPublisher struct {
+		d revocationPublisherDependencies
+	}
+)
+
+func NewRevocationPublisher(d revocationPublisherDependencies) *RevocationPublisher {
+	return &RevocationPublisher{d: d}
+}
+
+// TokenHash returns the hex encoded SHA-256 hash of the session token.
+func TokenHash(token string) string {
+	return fmt.Sprintf("%x", sha256.Sum256([]byte(token)))
+}
+
+// SessionRevoked publishes the revocation of the session.
+func (p *RevocationPublisher) SessionRevoked(ctx context.Context, s *Session) {
+	p.publish(ctx, &RevocationEvent{
+		SessionID:  &s.ID,
+		IdentityID: s.IdentityID,
+		TokenHash:  TokenHash(s.Token),
+	})
+}
+
+// IdentitySessionsRevoked publishes the revocation of all sessions of the identity.
+func (p *RevocationPublisher) IdentitySessionsRevoked(ctx context.Context, identityID uuid.UUID) {
+	p.publish(ctx, &RevocationEvent{IdentityID: identityID})
+}
+
+// publish sends the event in the background so that an unavailable Redis server does not delay sign outs.
+// Failures are logged but never returned to the caller.
+func (p *RevocationPublisher) publish(ctx context.Context, e *RevocationEvent) {
+	endpoint := p.d.Config(ctx).SessionRevocationRedisURL()
+	if endpoint == nil {
+		return
+	}
+
+	channel := p.d.Config(ctx).SessionRevocationRedisChannel()
+	e.Timestamp = p.d.Clock().Now().UTC()
+	l := p.d.Logger().
+		WithField("identity_id", e.IdentityID).
+		WithField("channel", channel)
+
+	message, err := json.Marshal(e)
+	if err != nil {
+		l.WithError(err).Error("Unable to encode the session revocation event.")
+		return
+	}
+
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+
+		if err := publishRedis(ctx, endpoint, channel, message); err != nil {
+			l.WithError(err).Error("Unable to publish the session revocation event to Redis.")
+		}
+	}()
+}
+
+// publishRedis sends a PUBLISH command to the Redis server at the given redis:// or rediss:// URL. Only the
+// password of the URL's user info is used for authentication.
+func publishRedis(ctx context.Context, u *url.URL, channel string, message []byte) error {
+	var conn net.Conn
+	var err error
+
+	dialer := &net.Dialer{}
+	switch u.Scheme {
+	case "redis":
+		conn, err = dialer.DialContext(ctx, "tcp", u.Host)
+	case "rediss":
+		conn, err = (&tls.Dialer{NetDialer: dialer, Config: &tls.Config{ServerName: u.Hostname()}}).DialContext(ctx, "tcp", u.Host)
+	default:
+		return errors.Errorf("unsupported Redis URL scheme %q", u.Scheme)
+	}
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	defer conn.Close()
+
+	if deadline, ok := ctx.Deadline(); ok {
+		if err := conn.SetDeadline(deadline); err != nil {
+			return errors.WithStack(err)
+		}
+	}
+
+	rw := bufio.NewReadWriter(bufio.NewReader(conn), bufio.NewWriter(conn))
+	if password, ok := u.User.Password(); ok {
+		if err := redisCommand(rw, "AUTH", password); err != nil {
+			return err
+		}
+	}
+
+	return redisCommand(rw, "PUBLISH", channel, string(message))
+}
+
+// redisCommand writes the command using the Redis serialization protocol and returns an error if the
+// server replies with one.
+func redisCommand(rw *bufio.ReadWriter, args ...string) error {
+	if _, err := fmt.Fprintf(rw, "*%d\r\n", len(args)); err != nil {
+		return errors.WithStack(err)
+	}
+	for _, arg := range args {
+		if _, err := fmt.Fprintf(rw, "$%d\r\n%s\r\n", len(arg), arg); err != nil {
+			return errors.WithStack(err)
+		}
+	}
+	if err := rw.Flush(); err != nil {
+		return errors.WithStack(err)
+	}
+
+	reply, err := rw.ReadString('\n')
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	if strings.HasPrefix(reply, "-") {
+		return errors.Errorf("redis replied to %s with: %s", args[0], strings.TrimSpace(reply[1:]))
+	}
+	return nil
+}
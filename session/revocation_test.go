@@ -0,0 +1,92 @@
+package session
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeRedis accepts a single connection, records the received commands, and replies with the given replies.
+func fakeRedis(t *testing.T, replies ...string) (*url.URL, <-chan []string) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = l.Close() })
+
+	commands := make(chan []string, len(replies))
+	go func() {
+		conn, err := l.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+
+		r := bufio.NewReader(conn)
+		for _, reply := range replies {
+			var n int
+			if _, err := fmt.Fscanf(r, "*%d\r\n", &n); err != nil {
+				return
+			}
+
+			args := make([]string, n)
+			for k := range args {
+				line, _ := r.ReadString('\n')
+				size, _ := strconv.Atoi(strings.TrimSpace(strings.TrimPrefix(line, "$")))
+				buf := make([]byte, size+2)
+				if _, err := io.ReadFull(r, buf); err != nil {
+					return
+				}
+				args[k] = string(buf[:size])
+			}
+
+			commands <- args
+			_, _ = conn.Write([]byte(reply))
+		}
+	}()
+
+	return &url.URL{Scheme: "redis", Host: l.Addr().String()}, commands
+}
+
+func TestPublishRedis(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	t.Run("case=publishes", func(t *testing.T) {
+		u, commands := fakeRedis(t, ":1\r\n")
+		require.NoError(t, publishRedis(ctx, u, "revoked", []byte(`{"identity_id":"foo"}`)))
+		assert.Equal(t, []string{"PUBLISH", "revoked", `{"identity_id":"foo"}`}, <-commands)
+	})
+
+	t.Run("case=authenticates", func(t *testing.T) {
+		u, commands := fakeRedis(t, "+OK\r\n", ":1\r\n")
+		u.User = url.UserPassword("", "secret")
+		require.NoError(t, publishRedis(ctx, u, "revoked", []byte("{}")))
+		assert.Equal(t, []string{"AUTH", "secret"}, <-commands)
+		assert.Equal(t, []string{"PUBLISH", "revoked", "{}"}, <-commands)
+	})
+
+	t.Run("case=returns error reply", func(t *testing.T) {
+		u, _ := fakeRedis(t, "-WRONGPASS invalid password\r\n")
+		u.User = url.UserPassword("", "wrong")
+		err := publishRedis(ctx, u, "revoked", []byte("{}"))
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "WRONGPASS")
+	})
+
+	t.Run("case=rejects unknown scheme", func(t *testing.T) {
+		require.Error(t, publishRedis(ctx, &url.URL{Scheme: "http", Host: "127.0.0.1:1"}, "revoked", []byte("{}")))
+	})
+}
+
+func TestTokenHash(t *testing.T) {
+	assert.Equal(t, "2cf24dba5fb0a30e26e83b2ac5b9e29e1b161e5c1fa7425e73043362938b9824", TokenHash("hello"))
+}
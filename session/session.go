@@ -30,9 +30,15 @@ type Session struct {
 	// required: true
 	IssuedAt time.Time `json:"issued_at" db:"issued_at" faker:"time_type"`
 
+	// PrivilegedUntil is the time until which the session was elevated to the privileged ("sudo") mode.
+	PrivilegedUntil *time.Time `json:"privileged_until,omitempty" db:"privileged_until" faker:"-"`
+
 	// required: true
 	Identity *identity.Identity `json:"identity" faker:"identity" db:"-" belongs_to:"identities" fk_id:"IdentityID"`
 
+	// Device is the device the session was issued to.
+	Device *Device `json:"device,omitempty" db:"device"`
+
 	// IdentityID is a helper struct field for gobuffalo.pop.
 	IdentityID uuid.UUID `json:"-" faker:"-" db:"identity_id"`
 	// CreatedAt is a helper struct field for gobuffalo.pop.
@@ -41,20 +47,24 @@ type Session struct {
 	UpdatedAt time.Time `json:"-" faker:"-" db:"updated_at"`
 
 	Token string `json:"-" db:"token"`
+
+	// bindingBroken is true if the session is used from a different IP range or user agent family than the
+	// device it was issued to and the user must re-authenticate before privileged actions.
+	bindingBroken bool
 }
 
 func (s Session) TableName(ctx context.Context) string {
 	return corp.ContextualizeTableName(ctx, "sessions")
 }
 
-func NewActiveSession(i *identity.Identity, c interface {
+func NewActiveSession(clock x.Clock, ids x.IDGenerator, i *identity.Identity, c interface {
 	SessionLifespan() time.Duration
 }, authenticatedAt time.Time) *Session {
 	return &Session{
-		ID:              x.NewUUID(),
+		ID:              ids.NewUUID(),
 		ExpiresAt:       authenticatedAt.Add(c.SessionLifespan()),
 		AuthenticatedAt: authenticatedAt,
-		IssuedAt:        time.Now().UTC(),
+		IssuedAt:        clock.Now().UTC(),
 		Identity:        i,
 		IdentityID:      i.ID,
 		Token:           randx.MustString(32, randx.AlphaNum),
@@ -62,16 +72,26 @@ func NewActiveSession(i *identity.Identity, c interface {
 	}
 }
 
-type Device struct {
-	UserAgent string      `json:"user_agent"`
-	SeenAt    []time.Time `json:"seen_at" faker:"time_types"`
-}
-
 func (s *Session) Declassify() *Session {
 	s.Identity = s.Identity.CopyWithoutCredentials()
 	return s
 }
 
-func (s *Session) IsActive() bool {
-	return s.Active && s.ExpiresAt.After(time.Now())
+func (s *Session) IsActive(c x.Clock) bool {
+	return s.Active && s.ExpiresAt.After(c.Now())
+}
+
+// IsElevated returns true if the session was elevated to the privileged mode and the privileged window
+// has not ended yet.
+func (s *Session) IsElevated(now time.Time) bool {
+	return !s.bindingBroken && s.PrivilegedUntil != nil && s.PrivilegedUntil.After(now)
+}
+
+// IsPrivileged returns true if the session was authenticated within maxAge or is elevated. Sessions used from
+// a different device than they were issued to are never privileged.
+func (s *Session) IsPrivileged(now time.Time, maxAge time.Duration) bool {
+	if s.bindingBroken {
+		return false
+	}
+	return s.AuthenticatedAt.Add(maxAge).After(now) || s.IsElevated(now)
 }
@@ -9,15 +9,27 @@ import (
 	"github.com/ory/kratos/identity"
 	"github.com/ory/kratos/internal"
 	"github.com/ory/kratos/session"
+	"github.com/ory/kratos/x"
 )
 
 func TestSession(t *testing.T) {
 	conf, _ := internal.NewFastRegistryWithMocks(t)
 	authAt := time.Now()
 
-	s := session.NewActiveSession(new(identity.Identity), conf, authAt)
-	assert.True(t, s.IsActive())
+	s := session.NewActiveSession(x.SystemClock, x.RandomIDGenerator, new(identity.Identity), conf, authAt)
+	assert.True(t, s.IsActive(x.SystemClock))
 
-	assert.False(t, (&session.Session{ExpiresAt: time.Now().Add(time.Hour)}).IsActive())
-	assert.False(t, (&session.Session{Active: true}).IsActive())
+	assert.False(t, (&session.Session{ExpiresAt: time.Now().Add(time.Hour)}).IsActive(x.SystemClock))
+	assert.False(t, (&session.Session{Active: true}).IsActive(x.SystemClock))
+
+	t.Run("case=expires according to the clock", func(t *testing.T) {
+		clock := x.NewFakeClock(authAt)
+		s := session.NewActiveSession(clock, x.NewSequentialIDGenerator(), new(identity.Identity), conf, clock.Now())
+		assert.Equal(t, "00000000-0000-0000-0000-000000000001", s.ID.String())
+		assert.Equal(t, authAt.UTC(), s.IssuedAt)
+		assert.True(t, s.IsActive(clock))
+
+		clock.Add(conf.SessionLifespan() + time.Second)
+		assert.False(t, s.IsActive(clock))
+	})
 }
@@ -0,0 +1,160 @@
+package session
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/gofrs/uuid"
+	"github.com/hashicorp/go-retryablehttp"
+	"github.com/pkg/errors"
+
+	"github.com/ory/kratos/driver/config"
+	"github.com/ory/kratos/x"
+)
+
+const (
+	// WebhookEventSessionCreated is sent when a session was issued to an identity.
+	WebhookEventSessionCreated WebhookEvent = "session.created"
+
+	// WebhookEventSessionRevoked is sent when a session was revoked. If the session ID is empty, all
+	// sessions of the identity were revoked.
+	WebhookEventSessionRevoked WebhookEvent = "session.revoked"
+)
+
+type (
+	// WebhookEvent is the type of a session webhook event.
+	WebhookEvent string
+
+	// WebhookPayload is sent to the session webhook whenever a session is created or revoked.
+	WebhookPayload struct {
+		// Event is the event type, either "session.created" or "session.revoked".
+		Event WebhookEvent `json:"event"`
+
+		// SessionID is the ID of the session. It is empty if all sessions of the identity were revoked.
+		SessionID *uuid.UUID `json:"session_id,omitempty"`
+
+		// IdentityID is the ID of the identity the session belongs to.
+		IdentityID uuid.UUID `json:"identity_id"`
+
+		// Method is the method used to authenticate, for example "password". It is only set for
+		// created sessions.
+		Method string `json:"method,omitempty"`
+
+		// TokenHash is the hex encoded SHA-256 hash of the session token. It is only set for revoked
+		// sessions so that caches keyed by the session token can be invalidated.
+		TokenHash string `json:"token_hash,omitempty"`
+
+		// Device is the device the session was issued to.
+		Device *Device `json:"device,omitempty"`
+
+		// Timestamp is the time the event occurred at.
+		Timestamp time.Time `json:"timestamp"`
+	}
+
+	webhookDependencies interface {
+		config.Provider
+		x.LoggingProvider
+		x.ClockProvider
+	}
+	WebhookProvider interface {
+		SessionWebhook() *Webhook
+	}
+	// Webhook notifies the configured session webhook about created and revoked sessions.
+	Webhook struct {
+		d  webhookDependencies
+		hc *retryablehttp.Client
+	}
+)
+
+func NewWebhook(d webhookDependencies) *Webhook {
+	return &Webhook{d: d, hc: x.NewEgressHTTPClient(d, config.EgressDependencyWebHooks)}
+}
+
+// SessionCreated notifies the webhook that the session was issued using the given authentication method.
+func (w *Webhook) SessionCreated(ctx context.Context, s *Session, method string) {
+	w.send(ctx, &WebhookPayload{
+		Event:      WebhookEventSessionCreated,
+		SessionID:  &s.ID,
+		IdentityID: s.IdentityID,
+		Method:     method,
+		Device:     s.Device,
+	})
+}
+
+// SessionRevoked notifies the webhook that the session was revoked.
+func (w *Webhook) SessionRevoked(ctx context.Context, s *Session) {
+	w.send(ctx, &WebhookPayload{
+		Event:      WebhookEventSessionRevoked,
+		SessionID:  &s.ID,
+		IdentityID: s.IdentityID,
+		TokenHash:  TokenHash(s.Token),
+		Device:     s.Device,
+	})
+}
+
+// IdentitySessionsRevoked notifies the webhook that all sessions of the identity were revoked.
+func (w *Webhook) IdentitySessionsRevoked(ctx context.Context, identityID uuid.UUID) {
+	w.send(ctx, &WebhookPayload{
+		Event:      WebhookEventSessionRevoked,
+		IdentityID: identityID,
+	})
+}
+
+// revokeSessionByToken revokes the session and notifies the session webhook and revocation channel about it.
+func revokeSessionByToken(ctx context.Context, d interface {
+	PersistenceProvider
+	WebhookProvider
+	RevocationPublisherProvider
+}, token string) error {
+	if err := d.SessionPersister().RevokeSessionByToken(ctx, token); err != nil {
+		return errors.WithStack(err)
+	}
+
+	if s, err := d.SessionPersister().GetSessionByToken(ctx, token); err == nil {
+		d.SessionWebhook().SessionRevoked(ctx, s)
+		d.SessionRevocationPublisher().SessionRevoked(ctx, s)
+	}
+	return nil
+}
+
+// send delivers the payload in the background so that slow or unavailable endpoints do not delay
+// sign ins or sign outs. Failed deliveries are retried and logged but never returned to the caller.
+func (w *Webhook) send(ctx context.Context, p *WebhookPayload) {
+	endpoint := w.d.Config(ctx).SessionWebhookURL()
+	if endpoint == nil {
+		return
+	}
+
+	p.Timestamp = w.d.Clock().Now().UTC()
+	l := w.d.Logger().
+		WithField("event", p.Event).
+		WithField("identity_id", p.IdentityID)
+
+	body, err := json.Marshal(p)
+	if err != nil {
+		l.WithError(err).Error("Unable to encode the session webhook payload.")
+		return
+	}
+
+	req, err := retryablehttp.NewRequest("POST", endpoint.String(), bytes.NewReader(body))
+	if err != nil {
+		l.WithError(err).Error("Unable to create the session webhook request.")
+		return
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	go func() {
+		res, err := w.hc.Do(req)
+		if err != nil {
+			l.WithError(err).Error("Unable to deliver the session webhook.")
+			return
+		}
+		defer res.Body.Close()
+
+		if res.StatusCode < 200 || res.StatusCode > 299 {
+			l.WithField("status_code", res.StatusCode).Error("The session webhook responded with an unexpected status code.")
+		}
+	}()
+}
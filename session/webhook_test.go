@@ -0,0 +1,89 @@
+package session_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ory/kratos/driver/config"
+	"github.com/ory/kratos/identity"
+	"github.com/ory/kratos/internal"
+	"github.com/ory/kratos/session"
+	"github.com/ory/kratos/x"
+)
+
+func TestWebhook(t *testing.T) {
+	conf, reg := internal.NewFastRegistryWithMocks(t)
+
+	payloads := make(chan session.WebhookPayload, 1)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "POST", r.Method)
+		assert.Equal(t, "application/json", r.Header.Get("Content-Type"))
+
+		var p session.WebhookPayload
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&p))
+		payloads <- p
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	t.Cleanup(ts.Close)
+
+	receive := func(t *testing.T) session.WebhookPayload {
+		select {
+		case p := <-payloads:
+			return p
+		case <-time.After(5 * time.Second):
+			t.Fatal("the session webhook was not called")
+		}
+		return session.WebhookPayload{}
+	}
+
+	i := identity.NewIdentity(config.DefaultIdentityTraitsSchemaID)
+	s := session.NewActiveSession(x.SystemClock, x.RandomIDGenerator, i, conf, time.Now())
+	s.Device = &session.Device{IPAddress: "192.0.2.1"}
+
+	t.Run("case=does nothing without url", func(t *testing.T) {
+		reg.SessionWebhook().SessionCreated(context.Background(), s, "password")
+		select {
+		case <-payloads:
+			t.Fatal("the session webhook must not be called")
+		case <-time.After(100 * time.Millisecond):
+		}
+	})
+
+	conf.MustSet(config.ViperKeySessionWebhookURL, ts.URL)
+
+	t.Run("case=session created", func(t *testing.T) {
+		reg.SessionWebhook().SessionCreated(context.Background(), s, "password")
+		p := receive(t)
+		assert.Equal(t, session.WebhookEventSessionCreated, p.Event)
+		require.NotNil(t, p.SessionID)
+		assert.Equal(t, s.ID, *p.SessionID)
+		assert.Equal(t, i.ID, p.IdentityID)
+		assert.Equal(t, "password", p.Method)
+		assert.Equal(t, s.Device, p.Device)
+		assert.False(t, p.Timestamp.IsZero())
+	})
+
+	t.Run("case=session revoked", func(t *testing.T) {
+		reg.SessionWebhook().SessionRevoked(context.Background(), s)
+		p := receive(t)
+		assert.Equal(t, session.WebhookEventSessionRevoked, p.Event)
+		require.NotNil(t, p.SessionID)
+		assert.Equal(t, s.ID, *p.SessionID)
+		assert.Empty(t, p.Method)
+	})
+
+	t.Run("case=all sessions revoked", func(t *testing.T) {
+		reg.SessionWebhook().IdentitySessionsRevoked(context.Background(), i.ID)
+		p := receive(t)
+		assert.Equal(t, session.WebhookEventSessionRevoked, p.Event)
+		assert.Nil(t, p.SessionID)
+		assert.Equal(t, i.ID, p.IdentityID)
+	})
+}
@@ -24,15 +24,20 @@ func TestIDs(t *testing.T) {
 
 	assert.Equal(t, 1070000, int(InfoSelfServiceVerification))
 
+	assert.Equal(t, 1080000, int(InfoSelfServiceTermsOfService))
+	assert.Equal(t, 1080001, int(InfoSelfServiceTermsOfServiceAccept))
+
 	assert.Equal(t, 4000000, int(ErrorValidation))
 	assert.Equal(t, 4000001, int(ErrorValidationGeneric))
 	assert.Equal(t, 4000002, int(ErrorValidationRequired))
+	assert.Equal(t, 4000008, int(ErrorValidationAddressNotVerified))
 
 	assert.Equal(t, 4010000, int(ErrorValidationLogin))
 	assert.Equal(t, 4010001, int(ErrorValidationLoginFlowExpired))
 
 	assert.Equal(t, 4040000, int(ErrorValidationRegistration))
 	assert.Equal(t, 4040001, int(ErrorValidationRegistrationFlowExpired))
+	assert.Equal(t, 4040002, int(ErrorValidationRegistrationMinimumAge))
 
 	assert.Equal(t, 4050000, int(ErrorValidationSettings))
 	assert.Equal(t, 4050001, int(ErrorValidationSettingsFlowExpired))
@@ -44,5 +49,8 @@ func TestIDs(t *testing.T) {
 	assert.Equal(t, 4070000, int(ErrorValidationVerification))
 	assert.Equal(t, 4070001, int(ErrorValidationVerificationTokenInvalidOrAlreadyUsed))
 
+	assert.Equal(t, 4080000, int(ErrorValidationTermsOfService))
+	assert.Equal(t, 4080001, int(ErrorValidationTermsOfServiceNotAccepted))
+
 	assert.Equal(t, 5000000, int(ErrorSystem))
 }
@@ -0,0 +1,208 @@
+package text
+
+import (
+	"bytes"
+	"embed"
+	"encoding/json"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"text/template"
+)
+
+// DefaultLocale is the locale the message texts are written in.
+const DefaultLocale = "en"
+
+//go:embed .translations/*.json
+var translationFiles embed.FS
+
+// translations maps locales to the text templates of the translated messages. The templates are executed with the
+// message's context.
+var translations = loadTranslations()
+
+func loadTranslations() map[string]map[ID]*template.Template {
+	files, err := translationFiles.ReadDir(".translations")
+	if err != nil {
+		panic(err)
+	}
+
+	locales := make(map[string]map[ID]*template.Template, len(files))
+	for _, f := range files {
+		raw, err := translationFiles.ReadFile(path.Join(".translations", f.Name()))
+		if err != nil {
+			panic(err)
+		}
+
+		var texts map[ID]string
+		if err := json.Unmarshal(raw, &texts); err != nil {
+			panic(err)
+		}
+
+		locale := strings.TrimSuffix(f.Name(), path.Ext(f.Name()))
+		locales[locale] = make(map[ID]*template.Template, len(texts))
+		for id, text := range texts {
+			locales[locale][id] = template.Must(template.New(strconv.Itoa(int(id))).Option("missingkey=error").Parse(text))
+		}
+	}
+	return locales
+}
+
+// Locales returns all locales messages can be translated into, including the default locale.
+func Locales() []string {
+	locales := []string{DefaultLocale}
+	for locale := range translations {
+		locales = append(locales, locale)
+	}
+	sort.Strings(locales[1:])
+	return locales
+}
+
+// Localize returns the message with its text translated into the locale. The message is returned as is if no
+// translation exists.
+func (m Message) Localize(locale string) Message {
+	t, ok := translations[locale][m.ID]
+	if !ok {
+		return m
+	}
+
+	var ctx map[string]interface{}
+	if len(m.Context) > 0 {
+		if err := json.Unmarshal(m.Context, &ctx); err != nil {
+			return m
+		}
+	}
+
+	var b bytes.Buffer
+	if err := t.Execute(&b, ctx); err != nil {
+		return m
+	}
+
+	m.Text = b.String()
+	return m
+}
+
+// Localize returns a copy of the messages with their texts translated into the locale.
+func (h Messages) Localize(locale string) Messages {
+	if h == nil {
+		return nil
+	}
+
+	localized := make(Messages, len(h))
+	for k, m := range h {
+		localized[k] = m.Localize(locale)
+	}
+	return localized
+}
+
+// LocalizeJSON translates the texts of all messages contained in the JSON document into the locale. Messages are
+// recognized by their numeric "id" and their "text" and "type" properties. IDs and contexts are never changed.
+func LocalizeJSON(raw []byte, locale string) ([]byte, error) {
+	if _, ok := translations[locale]; !ok {
+		return raw, nil
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(raw))
+	dec.UseNumber()
+
+	var doc interface{}
+	if err := dec.Decode(&doc); err != nil {
+		return nil, err
+	}
+
+	localizeValue(doc, locale)
+	return json.Marshal(doc)
+}
+
+func localizeValue(v interface{}, locale string) {
+	switch v := v.(type) {
+	case []interface{}:
+		for _, item := range v {
+			localizeValue(item, locale)
+		}
+	case map[string]interface{}:
+		if m, ok := messageFromJSON(v); ok {
+			v["text"] = m.Localize(locale).Text
+			return
+		}
+		for _, item := range v {
+			localizeValue(item, locale)
+		}
+	}
+}
+
+func messageFromJSON(v map[string]interface{}) (m Message, ok bool) {
+	id, ok := v["id"].(json.Number)
+	if !ok {
+		return m, false
+	}
+	i, err := id.Int64()
+	if err != nil {
+		return m, false
+	}
+	if m.Text, ok = v["text"].(string); !ok {
+		return m, false
+	}
+	if _, ok = v["type"].(string); !ok {
+		return m, false
+	}
+	if ctx, found := v["context"]; found {
+		if m.Context, err = json.Marshal(ctx); err != nil {
+			return m, false
+		}
+	}
+
+	m.ID = ID(i)
+	return m, true
+}
+
+// NegotiateLocale returns the supported locale preferred by the Accept-Language header. Locales match exactly or by
+// their base language, so that "de-CH" matches the supported locale "de". The fallback is returned if no supported
+// locale is acceptable.
+func NegotiateLocale(acceptLanguage string, supported []string, fallback string) string {
+	type preference struct {
+		tag     string
+		quality float64
+	}
+
+	var preferences []preference
+	for _, part := range strings.Split(acceptLanguage, ",") {
+		params := strings.Split(part, ";")
+		p := preference{tag: strings.ToLower(strings.TrimSpace(params[0])), quality: 1}
+		for _, param := range params[1:] {
+			param = strings.TrimSpace(param)
+			if !strings.HasPrefix(param, "q=") {
+				continue
+			}
+			q, err := strconv.ParseFloat(strings.TrimPrefix(param, "q="), 64)
+			if err != nil {
+				q = 0
+			}
+			p.quality = q
+		}
+		if len(p.tag) > 0 && p.quality > 0 {
+			preferences = append(preferences, p)
+		}
+	}
+	sort.SliceStable(preferences, func(i, j int) bool {
+		return preferences[i].quality > preferences[j].quality
+	})
+
+	for _, p := range preferences {
+		if p.tag == "*" {
+			return fallback
+		}
+		for _, s := range supported {
+			if strings.EqualFold(s, p.tag) {
+				return s
+			}
+		}
+		base := strings.SplitN(p.tag, "-", 2)[0]
+		for _, s := range supported {
+			if strings.EqualFold(s, base) {
+				return s
+			}
+		}
+	}
+	return fallback
+}
@@ -0,0 +1,107 @@
+package text
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLocales(t *testing.T) {
+	assert.Equal(t, []string{"en", "de"}, Locales())
+}
+
+func TestMessageLocalize(t *testing.T) {
+	m := NewValidationErrorRequired("traits.email")
+
+	localized := m.Localize("de")
+	assert.Equal(t, "Die Eigenschaft traits.email fehlt.", localized.Text)
+	assert.Equal(t, m.ID, localized.ID)
+	assert.Equal(t, m.Context, localized.Context)
+	assert.Equal(t, "Property traits.email is missing.", m.Text, "the original message must not be changed")
+
+	t.Run("case=keeps the text without translation", func(t *testing.T) {
+		assert.Equal(t, *m, m.Localize("fr"))
+		assert.Equal(t, *m, m.Localize(DefaultLocale))
+
+		generic := NewValidationErrorGeneric("some reason")
+		assert.Equal(t, *generic, generic.Localize("de"))
+	})
+
+	t.Run("case=keeps the text if the context is incomplete", func(t *testing.T) {
+		m := Message{ID: ErrorValidationRequired, Text: "Property is missing.", Type: Error}
+		assert.Equal(t, m, m.Localize("de"))
+	})
+}
+
+func TestLocalizeJSON(t *testing.T) {
+	raw, err := json.Marshal(map[string]interface{}{
+		"id":       "9f425a8d-7efc-4768-8f23-7647a74fdf13",
+		"messages": Messages{*NewErrorValidationInvalidCredentials()},
+		"methods": map[string]interface{}{
+			"password": map[string]interface{}{
+				"config": map[string]interface{}{
+					"fields": []map[string]interface{}{
+						{"name": "identifier", "messages": Messages{*NewValidationErrorRequired("identifier")}},
+					},
+				},
+			},
+		},
+	})
+	require.NoError(t, err)
+
+	localized, err := LocalizeJSON(raw, "de")
+	require.NoError(t, err)
+
+	var actual struct {
+		ID       string   `json:"id"`
+		Messages Messages `json:"messages"`
+		Methods  struct {
+			Password struct {
+				Config struct {
+					Fields []struct {
+						Messages Messages `json:"messages"`
+					} `json:"fields"`
+				} `json:"config"`
+			} `json:"password"`
+		} `json:"methods"`
+	}
+	require.NoError(t, json.Unmarshal(localized, &actual))
+
+	assert.Equal(t, "9f425a8d-7efc-4768-8f23-7647a74fdf13", actual.ID)
+	require.Len(t, actual.Messages, 1)
+	assert.Equal(t, ErrorValidationInvalidCredentials, actual.Messages[0].ID)
+	assert.Contains(t, actual.Messages[0].Text, "Die Anmeldedaten sind ungültig.")
+	require.Len(t, actual.Methods.Password.Config.Fields, 1)
+	require.Len(t, actual.Methods.Password.Config.Fields[0].Messages, 1)
+	assert.Equal(t, "Die Eigenschaft identifier fehlt.", actual.Methods.Password.Config.Fields[0].Messages[0].Text)
+	assert.JSONEq(t, `{"property":"identifier"}`, string(actual.Methods.Password.Config.Fields[0].Messages[0].Context))
+
+	t.Run("case=returns unknown locales as is", func(t *testing.T) {
+		localized, err := LocalizeJSON(raw, "fr")
+		require.NoError(t, err)
+		assert.Equal(t, raw, localized)
+	})
+}
+
+func TestNegotiateLocale(t *testing.T) {
+	supported := []string{"en", "de"}
+	for k, tc := range []struct {
+		header   string
+		expected string
+	}{
+		{header: "", expected: "en"},
+		{header: "de", expected: "de"},
+		{header: "DE", expected: "de"},
+		{header: "de-CH", expected: "de"},
+		{header: "fr-CH, fr;q=0.9, de;q=0.8, en;q=0.7", expected: "de"},
+		{header: "en;q=0.5, de;q=0.8", expected: "de"},
+		{header: "de;q=0, en", expected: "en"},
+		{header: "fr, *;q=0.5", expected: "en"},
+		{header: "fr", expected: "en"},
+		{header: "de;q=invalid", expected: "en"},
+	} {
+		assert.Equal(t, tc.expected, NegotiateLocale(tc.header, supported, "en"), "%d: %s", k, tc.header)
+	}
+}
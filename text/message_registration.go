@@ -12,6 +12,7 @@ const (
 const (
 	ErrorValidationRegistration ID = 4040000 + iota
 	ErrorValidationRegistrationFlowExpired
+	ErrorValidationRegistrationMinimumAge
 )
 
 func NewErrorValidationRegistrationFlowExpired(ago time.Duration) *Message {
@@ -24,3 +25,14 @@ func NewErrorValidationRegistrationFlowExpired(ago time.Duration) *Message {
 		}),
 	}
 }
+
+func NewErrorValidationRegistrationMinimumAge(minimumAge int) *Message {
+	return &Message{
+		ID:   ErrorValidationRegistrationMinimumAge,
+		Text: fmt.Sprintf("You must be at least %d years old to sign up.", minimumAge),
+		Type: Error,
+		Context: context(map[string]interface{}{
+			"minimum_age": minimumAge,
+		}),
+	}
+}
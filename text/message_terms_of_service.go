@@ -0,0 +1,39 @@
+package text
+
+import (
+	"fmt"
+)
+
+const (
+	InfoSelfServiceTermsOfService       ID = 1080000 + iota // 1080000
+	InfoSelfServiceTermsOfServiceAccept                     // 1080001
+)
+
+const (
+	ErrorValidationTermsOfService            ID = 4080000 + iota // 4080000
+	ErrorValidationTermsOfServiceNotAccepted                     // 4080001
+)
+
+func NewInfoSelfServiceTermsOfServiceAccept(version, url string) *Message {
+	return &Message{
+		ID:   InfoSelfServiceTermsOfServiceAccept,
+		Text: "I accept the terms of service.",
+		Type: Info,
+		Context: context(map[string]interface{}{
+			"version": version,
+			"url":     url,
+		}),
+	}
+}
+
+func NewErrorValidationTermsOfServiceNotAccepted(version, url string) *Message {
+	return &Message{
+		ID:   ErrorValidationTermsOfServiceNotAccepted,
+		Text: fmt.Sprintf("Please accept the terms of service (version %s) to continue.", version),
+		Type: Error,
+		Context: context(map[string]interface{}{
+			"version": version,
+			"url":     url,
+		}),
+	}
+}
@@ -13,6 +13,7 @@ const (
 	ErrorValidationPasswordPolicyViolation
 	ErrorValidationInvalidCredentials
 	ErrorValidationDuplicateCredentials
+	ErrorValidationAddressNotVerified
 )
 
 func NewValidationErrorGeneric(reason string) *Message {
@@ -87,3 +88,12 @@ func NewErrorValidationDuplicateCredentials() *Message {
 		Context: context(nil),
 	}
 }
+
+func NewErrorValidationAddressNotVerified() *Message {
+	return &Message{
+		ID:      ErrorValidationAddressNotVerified,
+		Text:    "Please verify your email address or phone number before signing in. Check your inbox for the verification message.",
+		Type:    Error,
+		Context: context(nil),
+	}
+}
@@ -0,0 +1,40 @@
+package x
+
+import (
+	"context"
+)
+
+type adminPrincipalContextKey struct{}
+
+// AdminPrincipal is the principal which called the admin API. ORY Kratos does not authenticate calls to the admin
+// API itself; middleware which does, for example one added using daemon.WithRootMiddleware, attaches the principal
+// to the request's context using WithAdminPrincipal so that it is recorded in the audit log and on identities.
+type AdminPrincipal struct {
+	// APIKeyID is the ID of the API key which was used to call the admin API.
+	APIKeyID string `json:"api_key_id,omitempty"`
+
+	// Subject is the subject, for example the user or service, the API key belongs to.
+	Subject string `json:"subject,omitempty"`
+}
+
+// WithAdminPrincipal returns a copy of ctx which carries the principal.
+func WithAdminPrincipal(ctx context.Context, p AdminPrincipal) context.Context {
+	return context.WithValue(ctx, adminPrincipalContextKey{}, p)
+}
+
+// AdminPrincipalFromContext returns the principal attached using WithAdminPrincipal, if any.
+func AdminPrincipalFromContext(ctx context.Context) (AdminPrincipal, bool) {
+	p, ok := ctx.Value(adminPrincipalContextKey{}).(AdminPrincipal)
+	return p, ok
+}
+
+// String returns the subject and, if known, the API key ID, for example "alice (key 1234)".
+func (p AdminPrincipal) String() string {
+	switch {
+	case len(p.Subject) == 0:
+		return "key " + p.APIKeyID
+	case len(p.APIKeyID) == 0:
+		return p.Subject
+	}
+	return p.Subject + " (key " + p.APIKeyID + ")"
+}
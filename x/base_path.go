@@ -0,0 +1,58 @@
+package x
+
+import (
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/urfave/negroni"
+)
+
+// StripBasePath serves all routes under the base path, for example /kratos, by removing it from the request's path.
+// Requests outside of the base path are answered with 404 Not Found. An empty base path serves all routes at the
+// root.
+func StripBasePath(basePath string) negroni.HandlerFunc {
+	return func(rw http.ResponseWriter, r *http.Request, next http.HandlerFunc) {
+		if len(basePath) == 0 {
+			next(rw, r)
+			return
+		}
+
+		p, ok := trimBasePath(r.URL.Path, basePath)
+		if !ok {
+			http.NotFound(rw, r)
+			return
+		}
+
+		rp := ""
+		if len(r.URL.RawPath) > 0 {
+			if rp, ok = trimBasePath(r.URL.RawPath, basePath); !ok {
+				http.NotFound(rw, r)
+				return
+			}
+		}
+
+		r2 := new(http.Request)
+		*r2 = *r
+		r2.URL = new(url.URL)
+		*r2.URL = *r.URL
+		r2.URL.Path = p
+		r2.URL.RawPath = rp
+		next(rw, r2)
+	}
+}
+
+// trimBasePath removes the base path from path if path is the base path or a path below it.
+func trimBasePath(path, basePath string) (string, bool) {
+	if !strings.HasPrefix(path, basePath) {
+		return "", false
+	}
+
+	trimmed := path[len(basePath):]
+	if len(trimmed) == 0 {
+		return "/", true
+	} else if trimmed[0] != '/' {
+		return "", false
+	}
+	return trimmed, true
+}
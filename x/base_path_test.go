@@ -0,0 +1,47 @@
+package x
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/urfave/negroni"
+)
+
+func TestStripBasePath(t *testing.T) {
+	n := negroni.New(StripBasePath("/kratos"))
+	n.UseHandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(r.URL.Path))
+	})
+	ts := httptest.NewServer(n)
+	defer ts.Close()
+
+	for k, tc := range []struct {
+		path   string
+		status int
+		body   string
+	}{
+		{path: "/kratos/self-service/login/browser", status: http.StatusOK, body: "/self-service/login/browser"},
+		{path: "/kratos/", status: http.StatusOK, body: "/"},
+		{path: "/kratos", status: http.StatusOK, body: "/"},
+		{path: "/kratosx/self-service/login/browser", status: http.StatusNotFound},
+		{path: "/self-service/login/browser", status: http.StatusNotFound},
+	} {
+		t.Run(fmt.Sprintf("case=%d", k), func(t *testing.T) {
+			res, err := ts.Client().Get(ts.URL + tc.path)
+			require.NoError(t, err)
+			defer res.Body.Close()
+
+			assert.Equal(t, tc.status, res.StatusCode)
+			if tc.status == http.StatusOK {
+				body, err := ioutil.ReadAll(res.Body)
+				require.NoError(t, err)
+				assert.Equal(t, tc.body, string(body))
+			}
+		})
+	}
+}
@@ -0,0 +1,129 @@
+package x
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/hashicorp/go-retryablehttp"
+	"github.com/pkg/errors"
+
+	"github.com/ory/kratos/driver/config"
+)
+
+// ErrCircuitOpen is returned for requests to a host which failed repeatedly until the circuit breaker closes again.
+var ErrCircuitOpen = errors.New("the circuit breaker is open because the host failed repeatedly")
+
+// breakers are shared by all clients of this process because a host which is down is down for all of them.
+var breakers = newCircuitBreakers()
+
+type (
+	circuitBreaker struct {
+		sync.Mutex
+		failures  int
+		openUntil time.Time
+	}
+	circuitBreakers struct {
+		sync.Mutex
+		m map[string]*circuitBreaker
+	}
+	// dependencyTransport applies the timeout and circuit breaker of a dependency to all requests.
+	dependencyTransport struct {
+		next       http.RoundTripper
+		dependency string
+		conf       func(ctx context.Context) config.EgressDependency
+		breakers   *circuitBreakers
+		now        func() time.Time
+	}
+	cancelOnClose struct {
+		io.ReadCloser
+		cancel context.CancelFunc
+	}
+)
+
+func newCircuitBreakers() *circuitBreakers {
+	return &circuitBreakers{m: map[string]*circuitBreaker{}}
+}
+
+func (b *circuitBreakers) get(key string) *circuitBreaker {
+	b.Lock()
+	defer b.Unlock()
+	cb, ok := b.m[key]
+	if !ok {
+		cb = new(circuitBreaker)
+		b.m[key] = cb
+	}
+	return cb
+}
+
+func (cb *circuitBreaker) allow(c config.EgressDependency, now time.Time) bool {
+	cb.Lock()
+	defer cb.Unlock()
+	return cb.failures < c.FailureThreshold || !now.Before(cb.openUntil)
+}
+
+func (cb *circuitBreaker) record(c config.EgressDependency, now time.Time, failed bool) {
+	cb.Lock()
+	defer cb.Unlock()
+	if !failed {
+		cb.failures = 0
+		return
+	}
+
+	// Once the threshold is reached, every failure keeps the circuit breaker open for another period.
+	cb.failures++
+	if cb.failures >= c.FailureThreshold {
+		cb.openUntil = now.Add(c.OpenFor)
+	}
+}
+
+// WithDependency returns a transport which cancels requests exceeding the timeout of the dependency and fails fast
+// while the circuit breaker of the requested host is open.
+func WithDependency(next http.RoundTripper, dependency string, conf func(ctx context.Context) config.EgressDependency) http.RoundTripper {
+	return &dependencyTransport{next: next, dependency: dependency, conf: conf, breakers: breakers, now: time.Now}
+}
+
+func (t *dependencyTransport) RoundTrip(r *http.Request) (*http.Response, error) {
+	c := t.conf(r.Context())
+
+	var cb *circuitBreaker
+	if c.FailureThreshold > 0 {
+		cb = t.breakers.get(t.dependency + "|" + r.URL.Host)
+		if !cb.allow(c, t.now()) {
+			return nil, errors.Wrapf(ErrCircuitOpen, "refusing to send request to %s of %s", r.URL.Host, t.dependency)
+		}
+	}
+
+	ctx, cancel := r.Context(), context.CancelFunc(func() {})
+	if c.Timeout > 0 {
+		ctx, cancel = context.WithTimeout(r.Context(), c.Timeout)
+	}
+
+	res, err := t.next.RoundTrip(r.WithContext(ctx))
+	if cb != nil && r.Context().Err() == nil {
+		// Requests canceled by the caller do not tell anything about the health of the host.
+		cb.record(c, t.now(), err != nil || res.StatusCode >= http.StatusInternalServerError)
+	}
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+
+	res.Body = &cancelOnClose{ReadCloser: res.Body, cancel: cancel}
+	return res, nil
+}
+
+func (b *cancelOnClose) Close() error {
+	defer b.cancel()
+	return b.ReadCloser.Close()
+}
+
+// retryUnlessCircuitOpen retries like retryablehttp does, except for requests refused by a circuit breaker.
+func retryUnlessCircuitOpen(ctx context.Context, res *http.Response, err error) (bool, error) {
+	if errors.Is(err, ErrCircuitOpen) {
+		return false, err
+	}
+	return retryablehttp.DefaultRetryPolicy(ctx, res, err)
+}
@@ -0,0 +1,92 @@
+package x
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ory/kratos/driver/config"
+)
+
+func TestDependencyTransport(t *testing.T) {
+	var status, requests int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		if r.URL.Path == "/slow" {
+			time.Sleep(100 * time.Millisecond)
+		}
+		w.WriteHeader(int(atomic.LoadInt32(&status)))
+	}))
+	t.Cleanup(ts.Close)
+
+	now := time.Now()
+	newTransport := func(c config.EgressDependency) *dependencyTransport {
+		return &dependencyTransport{
+			next:       http.DefaultTransport,
+			dependency: config.EgressDependencyWebHooks,
+			conf:       func(context.Context) config.EgressDependency { return c },
+			breakers:   newCircuitBreakers(),
+			now:        func() time.Time { return now },
+		}
+	}
+
+	call := func(t *testing.T, rt http.RoundTripper, path string, code int32) error {
+		atomic.StoreInt32(&status, code)
+		req, err := http.NewRequest("GET", ts.URL+path, nil)
+		require.NoError(t, err)
+
+		res, err := rt.RoundTrip(req)
+		if err != nil {
+			return err
+		}
+		return res.Body.Close()
+	}
+
+	t.Run("case=cancels slow requests", func(t *testing.T) {
+		rt := newTransport(config.EgressDependency{Timeout: 10 * time.Millisecond})
+		require.Error(t, call(t, rt, "/slow", http.StatusOK))
+		require.NoError(t, call(t, rt, "/", http.StatusOK))
+	})
+
+	t.Run("case=opens after consecutive failures", func(t *testing.T) {
+		rt := newTransport(config.EgressDependency{Timeout: time.Second, FailureThreshold: 2, OpenFor: time.Minute})
+
+		require.NoError(t, call(t, rt, "/", http.StatusInternalServerError))
+		require.NoError(t, call(t, rt, "/", http.StatusOK))
+		require.NoError(t, call(t, rt, "/", http.StatusInternalServerError))
+		require.NoError(t, call(t, rt, "/", http.StatusBadGateway))
+
+		atomic.StoreInt32(&requests, 0)
+		err := call(t, rt, "/", http.StatusOK)
+		require.ErrorIs(t, err, ErrCircuitOpen)
+		assert.EqualValues(t, 0, atomic.LoadInt32(&requests))
+
+		now = now.Add(time.Minute)
+		require.NoError(t, call(t, rt, "/", http.StatusOK))
+		require.NoError(t, call(t, rt, "/", http.StatusOK))
+		assert.EqualValues(t, 2, atomic.LoadInt32(&requests))
+	})
+
+	t.Run("case=reopens if the host still fails", func(t *testing.T) {
+		rt := newTransport(config.EgressDependency{Timeout: time.Second, FailureThreshold: 1, OpenFor: time.Minute})
+
+		require.NoError(t, call(t, rt, "/", http.StatusServiceUnavailable))
+		require.ErrorIs(t, call(t, rt, "/", http.StatusOK), ErrCircuitOpen)
+
+		now = now.Add(time.Minute)
+		require.NoError(t, call(t, rt, "/", http.StatusServiceUnavailable))
+		require.ErrorIs(t, call(t, rt, "/", http.StatusOK), ErrCircuitOpen)
+	})
+
+	t.Run("case=does not retry while open", func(t *testing.T) {
+		retry, err := retryUnlessCircuitOpen(context.Background(), nil, ErrCircuitOpen)
+		assert.False(t, retry)
+		assert.ErrorIs(t, err, ErrCircuitOpen)
+	})
+}
@@ -0,0 +1,101 @@
+package x
+
+import (
+	"encoding/binary"
+	"sync"
+	"time"
+
+	"github.com/gofrs/uuid"
+)
+
+type (
+	// Clock returns the current time. It is used when issuing flows and sessions and when scheduling
+	// messages so that tests can control time instead of sleeping.
+	Clock interface {
+		Now() time.Time
+	}
+
+	ClockProvider interface {
+		Clock() Clock
+	}
+
+	// IDGenerator returns the IDs of new flows and sessions.
+	IDGenerator interface {
+		NewUUID() uuid.UUID
+	}
+
+	IDGeneratorProvider interface {
+		IDGenerator() IDGenerator
+	}
+
+	systemClock struct{}
+
+	randomIDGenerator struct{}
+)
+
+var (
+	// SystemClock is the wall clock.
+	SystemClock Clock = systemClock{}
+
+	// RandomIDGenerator returns random (version 4) UUIDs.
+	RandomIDGenerator IDGenerator = randomIDGenerator{}
+)
+
+func (systemClock) Now() time.Time {
+	return time.Now()
+}
+
+func (randomIDGenerator) NewUUID() uuid.UUID {
+	return NewUUID()
+}
+
+// FakeClock is a clock which only advances when told to. It is safe for concurrent use.
+type FakeClock struct {
+	sync.RWMutex
+	now time.Time
+}
+
+func NewFakeClock(now time.Time) *FakeClock {
+	return &FakeClock{now: now}
+}
+
+func (c *FakeClock) Now() time.Time {
+	c.RLock()
+	defer c.RUnlock()
+	return c.now
+}
+
+// Set sets the clock to now.
+func (c *FakeClock) Set(now time.Time) {
+	c.Lock()
+	defer c.Unlock()
+	c.now = now
+}
+
+// Add advances the clock by d.
+func (c *FakeClock) Add(d time.Duration) {
+	c.Lock()
+	defer c.Unlock()
+	c.now = c.now.Add(d)
+}
+
+// SequentialIDGenerator returns UUIDs counting up from 00000000-0000-0000-0000-000000000001. It is safe for
+// concurrent use.
+type SequentialIDGenerator struct {
+	sync.Mutex
+	next uint64
+}
+
+func NewSequentialIDGenerator() *SequentialIDGenerator {
+	return &SequentialIDGenerator{}
+}
+
+func (g *SequentialIDGenerator) NewUUID() uuid.UUID {
+	g.Lock()
+	defer g.Unlock()
+	g.next++
+
+	var id uuid.UUID
+	binary.BigEndian.PutUint64(id[8:], g.next)
+	return id
+}
@@ -0,0 +1,42 @@
+package x
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFakeClock(t *testing.T) {
+	now := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	c := NewFakeClock(now)
+	assert.Equal(t, now, c.Now())
+
+	c.Add(time.Hour)
+	assert.Equal(t, now.Add(time.Hour), c.Now())
+
+	c.Set(now)
+	assert.Equal(t, now, c.Now())
+
+	assert.WithinDuration(t, time.Now(), SystemClock.Now(), time.Minute)
+}
+
+func TestSequentialIDGenerator(t *testing.T) {
+	g := NewSequentialIDGenerator()
+	assert.Equal(t, "00000000-0000-0000-0000-000000000001", g.NewUUID().String())
+	assert.Equal(t, "00000000-0000-0000-0000-000000000002", g.NewUUID().String())
+
+	var wg sync.WaitGroup
+	for k := 0; k < 10; k++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			g.NewUUID()
+		}()
+	}
+	wg.Wait()
+	assert.Equal(t, "00000000-0000-0000-0000-00000000000d", g.NewUUID().String())
+
+	assert.False(t, IsZeroUUID(RandomIDGenerator.NewUUID()))
+}
@@ -0,0 +1,140 @@
+package x
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"io"
+
+	"github.com/gorilla/sessions"
+	"github.com/pkg/errors"
+
+	kratoscipher "github.com/ory/kratos/cipher"
+)
+
+var errCookieDecryption = errors.New("unable to decrypt cookie")
+
+// cookieCodec is implemented by securecookie.SecureCookie.
+type cookieCodec interface {
+	Encode(name string, value interface{}) (string, error)
+	Decode(name, value string, dst interface{}) error
+}
+
+// aeadCookieCodec encrypts the signed value of the wrapped codec with AES-256-GCM. The cookie name is used as
+// additional data so that values can not be moved between cookies.
+type aeadCookieCodec struct {
+	signer cookieCodec
+	aead   cipher.AEAD
+}
+
+// NewCookieStore returns a cookie store which signs cookies using the secrets. If encrypt is true, cookies are
+// additionally encrypted with keys derived from the secrets. The first secret is used for new cookies while all
+// secrets are used to read existing cookies, including cookies which were only signed.
+func NewCookieStore(encrypt bool, secrets ...[]byte) *sessions.CookieStore {
+	cs := sessions.NewCookieStore(secrets...)
+	if !encrypt {
+		return cs
+	}
+
+	signed := cs.Codecs
+	cs.Codecs = signed[:0:0]
+	for _, secret := range secrets {
+		cs.Codecs = append(cs.Codecs, newAEADCookieCodec(secret))
+	}
+	cs.Codecs = append(cs.Codecs, signed...)
+	return cs
+}
+
+// NewCipherCookieStore is like NewCookieStore with encryption enabled but encrypts cookies using the cipher, so
+// that the encryption keys in `secrets.cipher` can be rotated independently of the signing secrets. Cookies which
+// were encrypted with keys derived from the secrets or only signed remain readable.
+func NewCipherCookieStore(ctx context.Context, c kratoscipher.Cipher, secrets ...[]byte) *sessions.CookieStore {
+	cs := NewCookieStore(true, secrets...)
+
+	legacy := cs.Codecs
+	cs.Codecs = legacy[:0:0]
+	for _, secret := range secrets {
+		cs.Codecs = append(cs.Codecs, &cipherCookieCodec{ctx: ctx, signer: sessions.NewCookieStore(secret).Codecs[0], c: c})
+	}
+	cs.Codecs = append(cs.Codecs, legacy...)
+	return cs
+}
+
+func newAEADCookieCodec(secret []byte) *aeadCookieCodec {
+	signer := sessions.NewCookieStore(secret).Codecs[0]
+
+	key := sha256.Sum256(append([]byte("ory-kratos-cookie-encryption:"), secret...))
+	block, err := aes.NewCipher(key[:])
+	if err != nil {
+		// Can not happen because the key is always 32 bytes long.
+		panic(err)
+	}
+
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		panic(err)
+	}
+
+	return &aeadCookieCodec{signer: signer, aead: aead}
+}
+
+func (c *aeadCookieCodec) Encode(name string, value interface{}) (string, error) {
+	signed, err := c.signer.Encode(name, value)
+	if err != nil {
+		return "", err
+	}
+
+	nonce := make([]byte, c.aead.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return "", errors.WithStack(err)
+	}
+
+	return base64.RawURLEncoding.EncodeToString(c.aead.Seal(nonce, nonce, []byte(signed), []byte(name))), nil
+}
+
+func (c *aeadCookieCodec) Decode(name, value string, dst interface{}) error {
+	sealed, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil || len(sealed) < c.aead.NonceSize() {
+		return errors.WithStack(errCookieDecryption)
+	}
+
+	signed, err := c.aead.Open(nil, sealed[:c.aead.NonceSize()], sealed[c.aead.NonceSize():], []byte(name))
+	if err != nil {
+		return errors.WithStack(errCookieDecryption)
+	}
+
+	return c.signer.Decode(name, string(signed), dst)
+}
+
+// cipherCookieCodec encrypts the signed value of the wrapped codec using the cipher. The signature covers the
+// cookie name so that values can not be moved between cookies.
+type cipherCookieCodec struct {
+	ctx    context.Context
+	signer cookieCodec
+	c      kratoscipher.Cipher
+}
+
+func (c *cipherCookieCodec) Encode(name string, value interface{}) (string, error) {
+	signed, err := c.signer.Encode(name, value)
+	if err != nil {
+		return "", err
+	}
+
+	return c.c.Encrypt(c.ctx, []byte(signed))
+}
+
+func (c *cipherCookieCodec) Decode(name, value string, dst interface{}) error {
+	if !c.c.Understands(value) {
+		return errors.WithStack(errCookieDecryption)
+	}
+
+	signed, err := c.c.Decrypt(c.ctx, value)
+	if err != nil {
+		return errors.WithStack(errCookieDecryption)
+	}
+
+	return c.signer.Decode(name, string(signed), dst)
+}
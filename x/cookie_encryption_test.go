@@ -0,0 +1,140 @@
+package x
+
+import (
+	"context"
+	"encoding/base64"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/sessions"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewCookieStore(t *testing.T) {
+	const name = "ory_kratos_session"
+	secret := []byte("cyan cat walking over keyboard")
+	token := "the-session-token-must-not-be-readable"
+
+	issue := func(t *testing.T, s sessions.Store) string {
+		w := httptest.NewRecorder()
+		require.NoError(t, SessionPersistValues(w, httptest.NewRequest("GET", "/", nil), s, name, map[string]interface{}{
+			"session_token": token,
+		}))
+		cookies := w.Result().Cookies()
+		require.Len(t, cookies, 1)
+		return cookies[0].String()
+	}
+
+	read := func(t *testing.T, s sessions.Store, cookie string) (string, error) {
+		r := httptest.NewRequest("GET", "/", nil)
+		r.Header.Set("Cookie", strings.SplitN(cookie, ";", 2)[0])
+		return SessionGetString(r, s, name, "session_token")
+	}
+
+	readable := func(cookie string) bool {
+		value := strings.SplitN(strings.SplitN(cookie, ";", 2)[0], "=", 2)[1]
+		decoded, _ := base64.URLEncoding.DecodeString(value)
+		parts := strings.Split(string(decoded), "|")
+		if len(parts) != 3 {
+			return false
+		}
+		payload, _ := base64.URLEncoding.DecodeString(parts[1])
+		return strings.Contains(string(payload), "session_token")
+	}
+
+	t.Run("case=only signed", func(t *testing.T) {
+		s := NewCookieStore(false, secret)
+		cookie := issue(t, s)
+		assert.True(t, readable(cookie))
+
+		actual, err := read(t, s, cookie)
+		require.NoError(t, err)
+		assert.Equal(t, token, actual)
+	})
+
+	t.Run("case=encrypted", func(t *testing.T) {
+		s := NewCookieStore(true, secret)
+		cookie := issue(t, s)
+		assert.False(t, readable(cookie))
+		assert.NotContains(t, cookie, token)
+
+		actual, err := read(t, s, cookie)
+		require.NoError(t, err)
+		assert.Equal(t, token, actual)
+
+		_, err = read(t, NewCookieStore(false, secret), cookie)
+		assert.Error(t, err, "signing only stores can not read encrypted cookies")
+	})
+
+	t.Run("case=reads cookies issued before encryption was enabled", func(t *testing.T) {
+		actual, err := read(t, NewCookieStore(true, secret), issue(t, NewCookieStore(false, secret)))
+		require.NoError(t, err)
+		assert.Equal(t, token, actual)
+	})
+
+	t.Run("case=rotates secrets", func(t *testing.T) {
+		cookie := issue(t, NewCookieStore(true, secret))
+
+		actual, err := read(t, NewCookieStore(true, []byte("a brand new secret of the right length"), secret), cookie)
+		require.NoError(t, err)
+		assert.Equal(t, token, actual)
+
+		_, err = read(t, NewCookieStore(true, []byte("a brand new secret of the right length")), cookie)
+		assert.Error(t, err)
+	})
+
+	t.Run("case=encrypted with cipher", func(t *testing.T) {
+		s := NewCipherCookieStore(context.Background(), new(stubCipher), secret)
+		cookie := issue(t, s)
+		assert.False(t, readable(cookie))
+		assert.Contains(t, cookie, "stub")
+
+		actual, err := read(t, s, cookie)
+		require.NoError(t, err)
+		assert.Equal(t, token, actual)
+
+		_, err = read(t, NewCookieStore(true, secret), cookie)
+		assert.Error(t, err, "stores without the cipher can not read cookies encrypted with the cipher")
+
+		for _, issuer := range []sessions.Store{NewCookieStore(true, secret), NewCookieStore(false, secret)} {
+			actual, err := read(t, s, issue(t, issuer))
+			require.NoError(t, err, "reads cookies issued before the cipher was used")
+			assert.Equal(t, token, actual)
+		}
+
+		actual, err = read(t, NewCipherCookieStore(context.Background(), new(stubCipher), []byte("a brand new secret of the right length"), secret), cookie)
+		require.NoError(t, err, "rotates signing secrets")
+		assert.Equal(t, token, actual)
+	})
+
+	t.Run("case=rejects tampered cookies", func(t *testing.T) {
+		cookie := issue(t, NewCookieStore(true, secret))
+		parts := strings.SplitN(strings.SplitN(cookie, ";", 2)[0], "=", 2)
+		value := []byte(parts[1])
+		value[len(value)/2] ^= 1
+
+		_, err := read(t, NewCookieStore(true, secret), parts[0]+"="+string(value))
+		assert.Error(t, err)
+	})
+}
+
+// stubCipher "encrypts" values by encoding them with a prefix.
+type stubCipher struct{}
+
+func (*stubCipher) Encrypt(_ context.Context, plaintext []byte) (string, error) {
+	return "$stub$" + base64.RawURLEncoding.EncodeToString(plaintext), nil
+}
+
+func (*stubCipher) Decrypt(_ context.Context, ciphertext string) ([]byte, error) {
+	return base64.RawURLEncoding.DecodeString(strings.TrimPrefix(ciphertext, "$stub$"))
+}
+
+func (*stubCipher) NeedsRotation(context.Context, string) bool {
+	return false
+}
+
+func (*stubCipher) Understands(ciphertext string) bool {
+	return strings.HasPrefix(ciphertext, "$stub$")
+}
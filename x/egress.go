@@ -0,0 +1,119 @@
+package x
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/go-retryablehttp"
+
+	"github.com/ory/x/httpx"
+
+	"github.com/ory/kratos/driver/config"
+)
+
+// EgressProxy returns the proxy function of outgoing requests. If no proxy URL is configured, the proxy is read
+// from the environment.
+func EgressProxy(c config.Egress) func(*http.Request) (*url.URL, error) {
+	if c.ProxyURL == nil {
+		return http.ProxyFromEnvironment
+	}
+
+	return func(r *http.Request) (*url.URL, error) {
+		if bypassEgressProxy(r.URL, c.NoProxy) {
+			return nil, nil
+		}
+		return c.ProxyURL, nil
+	}
+}
+
+// NewEgressTransport returns a transport which sends requests through the egress proxy and applies the SSRF
+// protection configured for the context of each request. If a dependency is given, its timeout and circuit breaker
+// apply as well.
+func NewEgressTransport(r config.Provider, dependency string) http.RoundTripper {
+	t := http.DefaultTransport.(*http.Transport).Clone()
+	t.Proxy = func(req *http.Request) (*url.URL, error) {
+		return EgressProxy(r.Config(req.Context()).Egress())(req)
+	}
+	t.DialContext = ssrfDialContext(&net.Dialer{Timeout: 30 * time.Second, KeepAlive: 30 * time.Second}, func(ctx context.Context) config.Egress {
+		return r.Config(ctx).Egress()
+	})
+	if len(dependency) == 0 {
+		return t
+	}
+
+	return WithDependency(t, dependency, func(ctx context.Context) config.EgressDependency {
+		return r.Config(ctx).EgressDependency(dependency)
+	})
+}
+
+// NewEgressHTTPClient returns a resilient HTTP client which sends requests through the configured egress proxy. If a
+// dependency is given, its timeout and circuit breaker apply as well.
+func NewEgressHTTPClient(r config.Provider, dependency string) *retryablehttp.Client {
+	hc := httpx.NewResilientClient()
+	hc.HTTPClient = &http.Client{Transport: NewEgressTransport(r, dependency), Timeout: hc.HTTPClient.Timeout}
+	hc.CheckRetry = retryUnlessCircuitOpen
+	return hc
+}
+
+// bypassEgressProxy returns true if the URL matches an entry of no_proxy. Entries are matched like the NO_PROXY
+// environment variable: "*" matches all hosts, IP ranges match IP addresses, and domains match the domain and
+// all of its subdomains. Entries including a port only match that port.
+func bypassEgressProxy(u *url.URL, noProxy []string) bool {
+	host := strings.ToLower(u.Hostname())
+	port := u.Port()
+	if port == "" {
+		if u.Scheme == "https" {
+			port = "443"
+		} else {
+			port = "80"
+		}
+	}
+	ip := net.ParseIP(host)
+
+	for _, entry := range noProxy {
+		entry = strings.ToLower(strings.TrimSpace(entry))
+		switch {
+		case entry == "":
+			continue
+		case entry == "*":
+			return true
+		}
+
+		if _, n, err := net.ParseCIDR(entry); err == nil {
+			if ip != nil && n.Contains(ip) {
+				return true
+			}
+			continue
+		}
+
+		if h, p, err := net.SplitHostPort(entry); err == nil {
+			if p != port {
+				continue
+			}
+			entry = h
+		}
+
+		if e := net.ParseIP(entry); e != nil {
+			if ip != nil && e.Equal(ip) {
+				return true
+			}
+			continue
+		}
+
+		entry = strings.TrimPrefix(entry, "*")
+		if strings.HasPrefix(entry, ".") {
+			if strings.HasSuffix(host, entry) || host == entry[1:] {
+				return true
+			}
+			continue
+		}
+		if host == entry || strings.HasSuffix(host, "."+entry) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,59 @@
+package x
+
+import (
+	"net/http"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ory/kratos/driver/config"
+)
+
+func TestEgressProxy(t *testing.T) {
+	proxy, err := url.Parse("http://proxy.internal:3128")
+	require.NoError(t, err)
+
+	p := EgressProxy(config.Egress{
+		ProxyURL: proxy,
+		NoProxy:  []string{"10.0.0.0/8", ".svc.cluster.local", "hydra:4444", "example.org", "192.168.1.1"},
+	})
+
+	for k, tc := range []struct {
+		u      string
+		direct bool
+	}{
+		{u: "https://api.pwnedpasswords.com/range/ABCDE"},
+		{u: "http://10.1.2.3/hook", direct: true},
+		{u: "http://11.1.2.3/hook"},
+		{u: "http://audit.default.svc.cluster.local/events", direct: true},
+		{u: "http://hydra:4444/.well-known/openid-configuration", direct: true},
+		{u: "http://hydra:4445/admin"},
+		{u: "https://example.org/schema.json", direct: true},
+		{u: "https://www.example.org/schema.json", direct: true},
+		{u: "https://notexample.org/schema.json"},
+		{u: "http://192.168.1.1/", direct: true},
+		{u: "http://192.168.1.2/"},
+	} {
+		req, err := http.NewRequest("GET", tc.u, nil)
+		require.NoError(t, err)
+
+		actual, err := p(req)
+		require.NoError(t, err)
+		if tc.direct {
+			assert.Nil(t, actual, "%d: %s", k, tc.u)
+		} else {
+			assert.Equal(t, proxy, actual, "%d: %s", k, tc.u)
+		}
+	}
+
+	t.Run("case=wildcard bypasses the proxy", func(t *testing.T) {
+		req, err := http.NewRequest("GET", "https://www.ory.sh/", nil)
+		require.NoError(t, err)
+
+		actual, err := EgressProxy(config.Egress{ProxyURL: proxy, NoProxy: []string{"*"}})(req)
+		require.NoError(t, err)
+		assert.Nil(t, actual)
+	})
+}
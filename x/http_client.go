@@ -0,0 +1,117 @@
+package x
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"syscall"
+	"time"
+
+	"github.com/hashicorp/go-retryablehttp"
+	"github.com/pkg/errors"
+
+	"github.com/ory/x/httpx"
+
+	"github.com/ory/kratos/driver/config"
+)
+
+// ErrIPNotAllowed is returned when an outgoing connection resolves to an IP address outside of the allowed ranges.
+var ErrIPNotAllowed = errors.New("the IP address is not within the allowed IP ranges")
+
+// NewHookHTTPClient returns the resilient HTTP client which calls web hooks and fetches claims mappers. It presents
+// the configured client certificate, trusts the configured certificate authorities, and only connects to IP
+// addresses within the allowed IP ranges. Unless IP ranges are set, requests are sent through the egress proxy and
+// SSRF protection applies. The
+// timeout and circuit breaker of the web hooks dependency apply to all requests.
+func NewHookHTTPClient(c config.HTTPClient, egress config.Egress, dependency config.EgressDependency) (*retryablehttp.Client, error) {
+	tlsConfig := &tls.Config{MinVersion: tls.VersionTLS12}
+
+	if len(c.CertPath) > 0 || len(c.KeyPath) > 0 {
+		cert, err := tls.LoadX509KeyPair(c.CertPath, c.KeyPath)
+		if err != nil {
+			return nil, errors.Wrap(err, "unable to load the client certificate")
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+
+	if len(c.CAPath) > 0 {
+		pool, err := x509.SystemCertPool()
+		if err != nil {
+			pool = x509.NewCertPool()
+		}
+
+		// #nosec G304 -- the path is set by the operator
+		pem, err := ioutil.ReadFile(c.CAPath)
+		if err != nil {
+			return nil, errors.Wrap(err, "unable to read the certificate authorities")
+		}
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, errors.Errorf("unable to parse any certificate authority from %s", c.CAPath)
+		}
+		tlsConfig.RootCAs = pool
+	}
+
+	allowed := make([]*net.IPNet, len(c.AllowedIPRanges))
+	for k, r := range c.AllowedIPRanges {
+		_, n, err := net.ParseCIDR(r)
+		if err != nil {
+			return nil, errors.Wrapf(err, "unable to parse allowed IP range %s", r)
+		}
+		allowed[k] = n
+	}
+
+	dialer := &net.Dialer{Timeout: 30 * time.Second, KeepAlive: 30 * time.Second}
+	t := &http.Transport{
+		Proxy:                 EgressProxy(egress),
+		DialContext:           dialer.DialContext,
+		TLSClientConfig:       tlsConfig,
+		ForceAttemptHTTP2:     true,
+		MaxIdleConns:          100,
+		IdleConnTimeout:       90 * time.Second,
+		TLSHandshakeTimeout:   10 * time.Second,
+		ExpectContinueTimeout: time.Second,
+	}
+
+	if len(allowed) > 0 {
+		// The check runs after the host name was resolved so that it also applies to DNS rebinding. A proxy would
+		// resolve the host name itself, which is why proxies are not used.
+		dialer.Control = func(_, address string, _ syscall.RawConn) error {
+			host, _, err := net.SplitHostPort(address)
+			if err != nil {
+				return errors.WithStack(err)
+			}
+			if !ipAllowed(net.ParseIP(host), allowed) {
+				return errors.Wrapf(ErrIPNotAllowed, "refusing to connect to %s", host)
+			}
+			return nil
+		}
+		t.Proxy = nil
+	} else if egress.SSRFProtection {
+		dialer.Control = ssrfControl(egress)
+	}
+
+	hc := httpx.NewResilientClient()
+	hc.HTTPClient = &http.Client{
+		Transport: WithDependency(t, config.EgressDependencyWebHooks, func(context.Context) config.EgressDependency {
+			return dependency
+		}),
+		Timeout: hc.HTTPClient.Timeout,
+	}
+	hc.CheckRetry = retryUnlessCircuitOpen
+	return hc, nil
+}
+
+func ipAllowed(ip net.IP, allowed []*net.IPNet) bool {
+	if ip == nil {
+		return false
+	}
+	for _, n := range allowed {
+		if n.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,120 @@
+package x
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io/ioutil"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ory/kratos/driver/config"
+)
+
+func writeClientCertificate(t *testing.T, dir string) (certPath, keyPath string, pool *x509.CertPool) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	tpl := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "kratos"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+		IsCA:         true,
+
+		BasicConstraintsValid: true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tpl, tpl, &key.PublicKey, key)
+	require.NoError(t, err)
+	cert, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+	pool = x509.NewCertPool()
+	pool.AddCert(cert)
+
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	require.NoError(t, err)
+
+	certPath = filepath.Join(dir, "client.crt")
+	keyPath = filepath.Join(dir, "client.key")
+	require.NoError(t, ioutil.WriteFile(certPath, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0600))
+	require.NoError(t, ioutil.WriteFile(keyPath, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0600))
+	return certPath, keyPath, pool
+}
+
+func TestNewHookHTTPClient(t *testing.T) {
+	dir := t.TempDir()
+	certPath, keyPath, clientCAs := writeClientCertificate(t, dir)
+
+	ts := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	ts.TLS = &tls.Config{ClientAuth: tls.RequireAndVerifyClientCert, ClientCAs: clientCAs}
+	ts.StartTLS()
+	t.Cleanup(ts.Close)
+
+	caPath := filepath.Join(dir, "ca.crt")
+	require.NoError(t, ioutil.WriteFile(caPath, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: ts.Certificate().Raw}), 0600))
+
+	call := func(t *testing.T, c config.HTTPClient) (*http.Response, error) {
+		hc, err := NewHookHTTPClient(c, config.Egress{}, config.EgressDependency{})
+		require.NoError(t, err)
+		hc.RetryMax = 0
+		res, err := hc.Get(ts.URL)
+		if err == nil {
+			_ = res.Body.Close()
+		}
+		return res, err
+	}
+
+	t.Run("case=presents the client certificate", func(t *testing.T) {
+		res, err := call(t, config.HTTPClient{CertPath: certPath, KeyPath: keyPath, CAPath: caPath})
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusNoContent, res.StatusCode)
+	})
+
+	t.Run("case=fails without the client certificate", func(t *testing.T) {
+		_, err := call(t, config.HTTPClient{CAPath: caPath})
+		require.Error(t, err)
+	})
+
+	t.Run("case=fails without trusting the certificate authority", func(t *testing.T) {
+		_, err := call(t, config.HTTPClient{CertPath: certPath, KeyPath: keyPath})
+		require.Error(t, err)
+	})
+
+	t.Run("case=connects within the allowed IP ranges", func(t *testing.T) {
+		res, err := call(t, config.HTTPClient{CertPath: certPath, KeyPath: keyPath, CAPath: caPath, AllowedIPRanges: []string{"127.0.0.0/8", "::1/128"}})
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusNoContent, res.StatusCode)
+	})
+
+	t.Run("case=refuses to connect outside the allowed IP ranges", func(t *testing.T) {
+		_, err := call(t, config.HTTPClient{CertPath: certPath, KeyPath: keyPath, CAPath: caPath, AllowedIPRanges: []string{"10.0.0.0/8"}})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), ErrIPNotAllowed.Error())
+	})
+
+	t.Run("case=rejects invalid configuration", func(t *testing.T) {
+		for _, c := range []config.HTTPClient{
+			{AllowedIPRanges: []string{"not-a-range"}},
+			{CertPath: filepath.Join(dir, "missing.crt"), KeyPath: keyPath},
+			{CAPath: keyPath},
+		} {
+			_, err := NewHookHTTPClient(c, config.Egress{}, config.EgressDependency{})
+			assert.Error(t, err)
+		}
+	})
+}
@@ -19,6 +19,7 @@ type secureRedirectOptions struct {
 	whitelist       []url.URL
 	defaultReturnTo *url.URL
 	sourceURL       string
+	egress          *config.Egress
 }
 
 type SecureRedirectOption func(*secureRedirectOptions)
@@ -55,6 +56,14 @@ func SecureRedirectOverrideDefaultReturnTo(defaultReturnTo *url.URL) SecureRedir
 	}
 }
 
+// SecureRedirectRejectPrivateHosts rejects `?return_to=` values which resolve to private IP addresses if SSRF
+// protection is enabled.
+func SecureRedirectRejectPrivateHosts(c config.Egress) SecureRedirectOption {
+	return func(o *secureRedirectOptions) {
+		o.egress = &c
+	}
+}
+
 // SecureRedirectTo implements a HTTP redirector who mitigates open redirect vulnerabilities by
 // working with whitelisting.
 func SecureRedirectTo(r *http.Request, defaultReturnTo *url.URL, opts ...SecureRedirectOption) (returnTo *url.URL, err error) {
@@ -101,6 +110,14 @@ func SecureRedirectTo(r *http.Request, defaultReturnTo *url.URL, opts ...SecureR
 			WithDebugf("Whitelisted domains are: %v", o.whitelist))
 	}
 
+	if o.egress != nil && len(returnTo.Hostname()) > 0 {
+		if err := ValidateHost(r.Context(), *o.egress, returnTo.Hostname()); err != nil {
+			return nil, errors.WithStack(herodot.ErrBadRequest.
+				WithReasonf("Requested return_to URL \"%s\" does not resolve to an allowed IP address.", returnTo).
+				WithDebug(err.Error()))
+		}
+	}
+
 	return returnTo, nil
 }
 
@@ -118,11 +135,17 @@ func SecureContentNegotiationRedirection(
 	case "text/html":
 		fallthrough
 	default:
-		ret, err := SecureRedirectTo(r, c.SelfServiceBrowserDefaultReturnTo(),
+		defaultReturnTo, err := c.SelfServiceBrowserDefaultReturnToOrErr()
+		if err != nil {
+			return err
+		}
+
+		ret, err := SecureRedirectTo(r, defaultReturnTo,
 			append([]SecureRedirectOption{
 				SecureRedirectUseSourceURL(requestURL),
 				SecureRedirectAllowURLs(c.SelfServiceBrowserWhitelistedReturnToDomains()),
 				SecureRedirectAllowSelfServiceURLs(c.SelfPublicURL(r)),
+				SecureRedirectRejectPrivateHosts(c.Egress()),
 			}, opts...)...,
 		)
 		if err != nil {
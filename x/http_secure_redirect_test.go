@@ -206,4 +206,26 @@ func TestSecureRedirectTo(t *testing.T) {
 		_, body := makeRequest(t, s, "?return_to=/original")
 		assert.Equal(t, body, s.URL+"/override")
 	})
+
+	t.Run("case=rejects private hosts if SSRF protection is enabled", func(t *testing.T) {
+		s := newServer(t, false, false, true, func(ts *httptest.Server) []x.SecureRedirectOption {
+			return []x.SecureRedirectOption{
+				x.SecureRedirectAllowURLs([]url.URL{*urlx.ParseOrPanic(ts.URL)}),
+				x.SecureRedirectRejectPrivateHosts(config.Egress{SSRFProtection: true}),
+			}
+		})
+		_, body := makeRequest(t, s, "?return_to=/foo")
+		assert.Equal(t, body, "error")
+	})
+
+	t.Run("case=allows private hosts within the allowed ranges", func(t *testing.T) {
+		s := newServer(t, false, false, false, func(ts *httptest.Server) []x.SecureRedirectOption {
+			return []x.SecureRedirectOption{
+				x.SecureRedirectAllowURLs([]url.URL{*urlx.ParseOrPanic(ts.URL)}),
+				x.SecureRedirectRejectPrivateHosts(config.Egress{SSRFProtection: true, SSRFAllowedRanges: []string{"127.0.0.0/8"}}),
+			}
+		})
+		_, body := makeRequest(t, s, "?return_to=/foo")
+		assert.Equal(t, body, s.URL+"/foo")
+	})
 }
@@ -0,0 +1,90 @@
+package x
+
+import (
+	"net"
+	"os"
+	"os/user"
+	"strconv"
+
+	"github.com/pkg/errors"
+
+	"github.com/ory/kratos/driver/config"
+)
+
+// Listen listens on the TCP address or UNIX domain socket. A socket file left over from a previous run is removed
+// first, and the socket's owner, group, and mode are set as configured.
+func Listen(l config.Listener) (net.Listener, error) {
+	if l.Network != "unix" {
+		listener, err := net.Listen("tcp", l.Address)
+		return listener, errors.WithStack(err)
+	}
+
+	if fi, err := os.Lstat(l.Address); err == nil && fi.Mode()&os.ModeSocket != 0 {
+		if err := os.Remove(l.Address); err != nil {
+			return nil, errors.WithStack(err)
+		}
+	}
+
+	listener, err := net.Listen("unix", l.Address)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	if err := setSocketPermissions(l); err != nil {
+		_ = listener.Close()
+		return nil, err
+	}
+	return listener, nil
+}
+
+func setSocketPermissions(l config.Listener) error {
+	uid, gid := -1, -1
+	if len(l.Owner) > 0 {
+		id, err := lookupID(l.Owner, func(name string) (string, error) {
+			u, err := user.Lookup(name)
+			if err != nil {
+				return "", err
+			}
+			return u.Uid, nil
+		})
+		if err != nil {
+			return errors.Wrapf(err, "unable to look up the socket owner %s", l.Owner)
+		}
+		uid = id
+	}
+
+	if len(l.Group) > 0 {
+		id, err := lookupID(l.Group, func(name string) (string, error) {
+			g, err := user.LookupGroup(name)
+			if err != nil {
+				return "", err
+			}
+			return g.Gid, nil
+		})
+		if err != nil {
+			return errors.Wrapf(err, "unable to look up the socket group %s", l.Group)
+		}
+		gid = id
+	}
+
+	if uid != -1 || gid != -1 {
+		if err := os.Chown(l.Address, uid, gid); err != nil {
+			return errors.WithStack(err)
+		}
+	}
+
+	return errors.WithStack(os.Chmod(l.Address, l.Mode))
+}
+
+// lookupID returns the numeric ID of a user or group, which is either given as a number or looked up by name.
+func lookupID(nameOrID string, lookup func(name string) (string, error)) (int, error) {
+	if id, err := strconv.Atoi(nameOrID); err == nil {
+		return id, nil
+	}
+
+	id, err := lookup(nameOrID)
+	if err != nil {
+		return 0, err
+	}
+	return strconv.Atoi(id)
+}
@@ -0,0 +1,55 @@
+package x
+
+import (
+	"io/ioutil"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ory/kratos/driver/config"
+)
+
+func TestListen(t *testing.T) {
+	t.Run("case=tcp", func(t *testing.T) {
+		l, err := Listen(config.Listener{Network: "tcp", Address: "127.0.0.1:0"})
+		require.NoError(t, err)
+		require.NoError(t, l.Close())
+	})
+
+	t.Run("case=unix", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "kratos.sock")
+
+		// Simulate a socket left over from a previous run which was not shut down gracefully.
+		stale, err := net.Listen("unix", path)
+		require.NoError(t, err)
+		stale.(*net.UnixListener).SetUnlinkOnClose(false)
+		require.NoError(t, stale.Close())
+
+		l, err := Listen(config.Listener{Network: "unix", Address: path, Group: strconv.Itoa(os.Getgid()), Mode: 0660})
+		require.NoError(t, err)
+		t.Cleanup(func() { _ = l.Close() })
+
+		fi, err := os.Stat(path)
+		require.NoError(t, err)
+		assert.Equal(t, os.FileMode(0660), fi.Mode().Perm())
+
+		go func() { _ = http.Serve(l, http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {})) }()
+		conn, err := net.Dial("unix", path)
+		require.NoError(t, err)
+		require.NoError(t, conn.Close())
+	})
+
+	t.Run("case=fails if the path is not a socket", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "kratos.sock")
+		require.NoError(t, ioutil.WriteFile(path, []byte("not a socket"), 0600))
+
+		_, err := Listen(config.Listener{Network: "unix", Address: path, Mode: 0660})
+		require.Error(t, err)
+	})
+}
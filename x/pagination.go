@@ -111,3 +111,16 @@ func PaginationHeader(w http.ResponseWriter, u *url.URL, total int64, page, item
 		header(u, "last", itemsPerPage64, lastOffset),
 	}, ","))
 }
+
+// ForEachPage calls list for every page, starting with page 1, until list returns fewer than perPage items.
+func ForEachPage(perPage int, list func(page, perPage int) (items int, err error)) error {
+	for page := 1; ; page++ {
+		n, err := list(page, perPage)
+		if err != nil {
+			return err
+		}
+		if n < perPage {
+			return nil
+		}
+	}
+}
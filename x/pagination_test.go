@@ -20,6 +20,7 @@
 package x
 
 import (
+	"errors"
 	"fmt"
 	"net/http"
 	"net/http/httptest"
@@ -28,6 +29,7 @@ import (
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 
 	"github.com/ory/x/urlx"
 )
@@ -131,3 +133,20 @@ func TestParsePagination(t *testing.T) {
 		})
 	}
 }
+
+func TestForEachPage(t *testing.T) {
+	var pages []int
+	require.NoError(t, ForEachPage(2, func(page, perPage int) (int, error) {
+		pages = append(pages, page)
+		if page < 3 {
+			return perPage, nil
+		}
+		return 1, nil
+	}))
+	assert.Equal(t, []int{1, 2, 3}, pages)
+
+	expected := errors.New("foo")
+	assert.Equal(t, expected, ForEachPage(2, func(page, perPage int) (int, error) {
+		return 0, expected
+	}))
+}
@@ -0,0 +1,25 @@
+package x
+
+import (
+	"context"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// RunPeriodically calls run until the context is canceled, waiting for the duration returned by run in between.
+// It returns nil if the context was canceled and the context error otherwise.
+func RunPeriodically(ctx context.Context, run func(ctx context.Context) time.Duration) error {
+	for {
+		wait := run(ctx)
+
+		select {
+		case <-ctx.Done():
+			if errors.Is(ctx.Err(), context.Canceled) {
+				return nil
+			}
+			return ctx.Err()
+		case <-time.After(wait):
+		}
+	}
+}
@@ -0,0 +1,33 @@
+package x
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRunPeriodically(t *testing.T) {
+	t.Run("case=runs until the context is canceled", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		var runs int
+		require.NoError(t, RunPeriodically(ctx, func(context.Context) time.Duration {
+			runs++
+			if runs == 3 {
+				cancel()
+			}
+			return time.Millisecond
+		}))
+		assert.Equal(t, 3, runs)
+	})
+
+	t.Run("case=returns the error if the deadline is exceeded", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		defer cancel()
+		assert.ErrorIs(t, RunPeriodically(ctx, func(context.Context) time.Duration {
+			return time.Millisecond
+		}), context.DeadlineExceeded)
+	})
+}
@@ -0,0 +1,121 @@
+package x
+
+import (
+	"context"
+	"net"
+	"syscall"
+
+	"github.com/pkg/errors"
+
+	"github.com/ory/kratos/driver/config"
+)
+
+// ErrPrivateIP is returned if SSRF protection is enabled and a connection or URL targets a private IP address.
+var ErrPrivateIP = errors.New("the IP address is private and not within the allowed IP ranges")
+
+var privateIPRanges = mustParseCIDRs(
+	"0.0.0.0/8",
+	"10.0.0.0/8",
+	"100.64.0.0/10",
+	"127.0.0.0/8",
+	"169.254.0.0/16",
+	"172.16.0.0/12",
+	"192.168.0.0/16",
+	"198.18.0.0/15",
+	"224.0.0.0/4",
+	"::/128",
+	"::1/128",
+	"64:ff9b::/96",
+	"fc00::/7",
+	"fe80::/10",
+	"ff00::/8",
+)
+
+func mustParseCIDRs(ranges ...string) []*net.IPNet {
+	nets := make([]*net.IPNet, len(ranges))
+	for k, r := range ranges {
+		_, n, err := net.ParseCIDR(r)
+		if err != nil {
+			panic(err)
+		}
+		nets[k] = n
+	}
+	return nets
+}
+
+// CheckPrivateIP returns ErrPrivateIP if SSRF protection is enabled and the IP address is private, loopback,
+// link-local, multicast, reserved for benchmarking, or a NAT64 address which may translate to any of those, unless
+// it is within the allowed IP ranges.
+func CheckPrivateIP(c config.Egress, ip net.IP) error {
+	if !c.SSRFProtection {
+		return nil
+	}
+	if ip == nil {
+		return errors.WithStack(ErrPrivateIP)
+	}
+
+	for _, r := range c.SSRFAllowedRanges {
+		_, n, err := net.ParseCIDR(r)
+		if err != nil {
+			return errors.Wrapf(err, "unable to parse allowed IP range %s", r)
+		}
+		if n.Contains(ip) {
+			return nil
+		}
+	}
+
+	if !ipAllowed(ip, privateIPRanges) {
+		return nil
+	}
+	return errors.Wrapf(ErrPrivateIP, "refusing to connect to %s", ip)
+}
+
+// ValidateHost resolves the host and returns ErrPrivateIP if SSRF protection is enabled and any of its IP
+// addresses is private.
+func ValidateHost(ctx context.Context, c config.Egress, host string) error {
+	if !c.SSRFProtection {
+		return nil
+	}
+
+	if ip := net.ParseIP(host); ip != nil {
+		return CheckPrivateIP(c, ip)
+	}
+
+	addrs, err := net.DefaultResolver.LookupIPAddr(ctx, host)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	for _, addr := range addrs {
+		if err := CheckPrivateIP(c, addr.IP); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ssrfControl checks the IP address a connection is about to be made to. As the check runs after the host name
+// was resolved, it also applies to DNS rebinding.
+func ssrfControl(c config.Egress) func(network, address string, _ syscall.RawConn) error {
+	return func(_, address string, _ syscall.RawConn) error {
+		host, _, err := net.SplitHostPort(address)
+		if err != nil {
+			return errors.WithStack(err)
+		}
+		return CheckPrivateIP(c, net.ParseIP(host))
+	}
+}
+
+// ssrfDialContext dials using the dialer and refuses connections to private IP addresses if SSRF protection is
+// enabled in the configuration of the context.
+func ssrfDialContext(dialer *net.Dialer, conf func(ctx context.Context) config.Egress) func(ctx context.Context, network, address string) (net.Conn, error) {
+	return func(ctx context.Context, network, address string) (net.Conn, error) {
+		c := conf(ctx)
+		if !c.SSRFProtection {
+			return dialer.DialContext(ctx, network, address)
+		}
+
+		d := *dialer
+		d.Control = ssrfControl(c)
+		return d.DialContext(ctx, network, address)
+	}
+}
@@ -0,0 +1,94 @@
+package x
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ory/kratos/driver/config"
+)
+
+func TestCheckPrivateIP(t *testing.T) {
+	enabled := config.Egress{SSRFProtection: true, SSRFAllowedRanges: []string{"10.12.0.0/16"}}
+
+	for _, tc := range []struct {
+		ip      string
+		private bool
+	}{
+		{ip: "8.8.8.8"},
+		{ip: "2001:4860:4860::8888"},
+		{ip: "127.0.0.1", private: true},
+		{ip: "10.0.0.1", private: true},
+		{ip: "10.12.1.1"},
+		{ip: "172.20.0.1", private: true},
+		{ip: "192.168.1.1", private: true},
+		{ip: "169.254.169.254", private: true},
+		{ip: "100.64.0.1", private: true},
+		{ip: "0.0.0.0", private: true},
+		{ip: "::1", private: true},
+		{ip: "::ffff:127.0.0.1", private: true},
+		{ip: "fd00::1", private: true},
+		{ip: "fe80::1", private: true},
+		{ip: "224.0.0.1", private: true},
+		{ip: "239.255.255.250", private: true},
+		{ip: "198.18.0.1", private: true},
+		{ip: "198.19.255.255", private: true},
+		{ip: "198.20.0.1"},
+		{ip: "ff02::1", private: true},
+		{ip: "64:ff9b::7f00:1", private: true},
+	} {
+		t.Run("ip="+tc.ip, func(t *testing.T) {
+			ip := net.ParseIP(tc.ip)
+			require.NoError(t, CheckPrivateIP(config.Egress{}, ip))
+
+			err := CheckPrivateIP(enabled, ip)
+			if tc.private {
+				assert.ErrorIs(t, err, ErrPrivateIP)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+
+	assert.Error(t, CheckPrivateIP(config.Egress{SSRFProtection: true, SSRFAllowedRanges: []string{"not-a-range"}}, net.ParseIP("10.0.0.1")))
+}
+
+func TestValidateHost(t *testing.T) {
+	ctx := context.Background()
+	enabled := config.Egress{SSRFProtection: true}
+
+	require.NoError(t, ValidateHost(ctx, config.Egress{}, "127.0.0.1"))
+	assert.ErrorIs(t, ValidateHost(ctx, enabled, "127.0.0.1"), ErrPrivateIP)
+	assert.ErrorIs(t, ValidateHost(ctx, enabled, "localhost"), ErrPrivateIP)
+	assert.NoError(t, ValidateHost(ctx, enabled, "8.8.8.8"))
+	assert.NoError(t, ValidateHost(ctx, config.Egress{SSRFProtection: true, SSRFAllowedRanges: []string{"127.0.0.0/8", "::1/128"}}, "localhost"))
+}
+
+func TestSSRFDialContext(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	t.Cleanup(ts.Close)
+
+	get := func(t *testing.T, c config.Egress) error {
+		tr := http.DefaultTransport.(*http.Transport).Clone()
+		tr.DialContext = ssrfDialContext(new(net.Dialer), func(context.Context) config.Egress { return c })
+		res, err := (&http.Client{Transport: tr}).Get(ts.URL)
+		if err != nil {
+			return err
+		}
+		return res.Body.Close()
+	}
+
+	require.NoError(t, get(t, config.Egress{}))
+	require.NoError(t, get(t, config.Egress{SSRFProtection: true, SSRFAllowedRanges: []string{"127.0.0.0/8"}}))
+
+	err := get(t, config.Egress{SSRFProtection: true})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), ErrPrivateIP.Error())
+}
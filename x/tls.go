@@ -0,0 +1,134 @@
+package x
+
+import (
+	"crypto/tls"
+	"encoding/base64"
+	"io/ioutil"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/ory/x/logrusx"
+
+	"github.com/ory/kratos/driver/config"
+)
+
+// CertificateReloadInterval is the interval in which certificate files are checked for changes.
+const CertificateReloadInterval = 10 * time.Second
+
+// CertificateReloader serves a TLS certificate and reloads it once its files change, for example when a
+// certificate manager rotates it, so that ORY Kratos does not need to be restarted. If reloading fails, the
+// previous certificate is served.
+type CertificateReloader struct {
+	sync.RWMutex
+	c         config.TLS
+	l         *logrusx.Logger
+	cert      *tls.Certificate
+	modTimes  [2]time.Time
+	checkedAt time.Time
+	now       func() time.Time
+}
+
+// NewCertificateReloader loads the certificate and returns an error if it can not be loaded.
+func NewCertificateReloader(c config.TLS, l *logrusx.Logger) (*CertificateReloader, error) {
+	r := &CertificateReloader{c: c, l: l, now: time.Now}
+	if err := r.load(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// GetCertificate implements tls.Config.GetCertificate.
+func (r *CertificateReloader) GetCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	r.RLock()
+	cert, checkedAt := r.cert, r.checkedAt
+	r.RUnlock()
+
+	if (len(r.c.CertPath) == 0 && len(r.c.KeyPath) == 0) || r.now().Sub(checkedAt) < CertificateReloadInterval {
+		return cert, nil
+	}
+
+	r.Lock()
+	defer r.Unlock()
+	if r.now().Sub(r.checkedAt) < CertificateReloadInterval {
+		return r.cert, nil
+	}
+	r.checkedAt = r.now()
+
+	modTimes, err := r.fileModTimes()
+	if err != nil {
+		r.l.WithError(err).Error("Unable to check the TLS certificate for changes, the previous certificate is served.")
+		return r.cert, nil
+	} else if modTimes == r.modTimes {
+		return r.cert, nil
+	}
+
+	cert, err = r.read()
+	if err != nil {
+		r.l.WithError(err).Error("Unable to reload the changed TLS certificate, the previous certificate is served.")
+		return r.cert, nil
+	}
+
+	r.cert, r.modTimes = cert, modTimes
+	r.l.Info("Reloaded the changed TLS certificate.")
+	return r.cert, nil
+}
+
+func (r *CertificateReloader) load() error {
+	modTimes, err := r.fileModTimes()
+	if err != nil {
+		return err
+	}
+
+	cert, err := r.read()
+	if err != nil {
+		return err
+	}
+
+	r.cert, r.modTimes, r.checkedAt = cert, modTimes, r.now()
+	return nil
+}
+
+// fileModTimes returns the modification times of the certificate and key files. Symlinks are followed, so that
+// Kubernetes updating the symlinks of a mounted Secret counts as a change.
+func (r *CertificateReloader) fileModTimes() (modTimes [2]time.Time, err error) {
+	for k, path := range []string{r.c.CertPath, r.c.KeyPath} {
+		if len(path) == 0 {
+			continue
+		}
+		fi, err := os.Stat(path)
+		if err != nil {
+			return modTimes, errors.WithStack(err)
+		}
+		modTimes[k] = fi.ModTime()
+	}
+	return modTimes, nil
+}
+
+func (r *CertificateReloader) read() (*tls.Certificate, error) {
+	certPEM, err := readPEM(r.c.CertPath, r.c.CertBase64)
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to read the TLS certificate")
+	}
+
+	keyPEM, err := readPEM(r.c.KeyPath, r.c.KeyBase64)
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to read the TLS private key")
+	}
+
+	cert, err := tls.X509KeyPair(certPEM, keyPEM)
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to parse the TLS certificate and private key")
+	}
+	return &cert, nil
+}
+
+func readPEM(path, encoded string) ([]byte, error) {
+	if len(path) > 0 {
+		// #nosec G304 -- the path is set by the operator
+		return ioutil.ReadFile(path)
+	}
+	return base64.StdEncoding.DecodeString(encoded)
+}
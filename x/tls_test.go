@@ -0,0 +1,90 @@
+package x
+
+import (
+	"encoding/base64"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ory/x/logrusx"
+
+	"github.com/ory/kratos/driver/config"
+)
+
+func TestCertificateReloader(t *testing.T) {
+	dir := t.TempDir()
+	certPath, keyPath, _ := writeClientCertificate(t, dir)
+
+	r, err := NewCertificateReloader(config.TLS{CertPath: certPath, KeyPath: keyPath}, logrusx.New("", ""))
+	require.NoError(t, err)
+	clock := NewFakeClock(time.Now())
+	r.now = clock.Now
+
+	first, err := r.GetCertificate(nil)
+	require.NoError(t, err)
+	require.NotNil(t, first)
+
+	// rotate replaces the certificate files and makes sure the modification time changes.
+	rotate := func(t *testing.T, certPEM, keyPEM []byte) {
+		require.NoError(t, ioutil.WriteFile(certPath, certPEM, 0600))
+		require.NoError(t, ioutil.WriteFile(keyPath, keyPEM, 0600))
+		future := clock.Now().Add(time.Hour)
+		require.NoError(t, os.Chtimes(certPath, future, future))
+		require.NoError(t, os.Chtimes(keyPath, future, future))
+	}
+
+	rotatedDir := t.TempDir()
+	rotatedCertPath, rotatedKeyPath, _ := writeClientCertificate(t, rotatedDir)
+	rotatedCert, err := ioutil.ReadFile(rotatedCertPath)
+	require.NoError(t, err)
+	rotatedKey, err := ioutil.ReadFile(rotatedKeyPath)
+	require.NoError(t, err)
+
+	t.Run("case=changed files are reloaded after the interval", func(t *testing.T) {
+		rotate(t, rotatedCert, rotatedKey)
+
+		cert, err := r.GetCertificate(nil)
+		require.NoError(t, err)
+		assert.Equal(t, first.Certificate, cert.Certificate)
+
+		clock.Add(CertificateReloadInterval)
+		cert, err = r.GetCertificate(nil)
+		require.NoError(t, err)
+		assert.NotEqual(t, first.Certificate, cert.Certificate)
+	})
+
+	t.Run("case=invalid files are ignored", func(t *testing.T) {
+		previous, err := r.GetCertificate(nil)
+		require.NoError(t, err)
+
+		clock.Add(time.Hour)
+		rotate(t, []byte("not a certificate"), rotatedKey)
+		clock.Add(CertificateReloadInterval)
+
+		cert, err := r.GetCertificate(nil)
+		require.NoError(t, err)
+		assert.Equal(t, previous.Certificate, cert.Certificate)
+	})
+
+	t.Run("case=inline certificates", func(t *testing.T) {
+		r, err := NewCertificateReloader(config.TLS{
+			CertBase64: base64.StdEncoding.EncodeToString(rotatedCert),
+			KeyBase64:  base64.StdEncoding.EncodeToString(rotatedKey),
+		}, logrusx.New("", ""))
+		require.NoError(t, err)
+
+		cert, err := r.GetCertificate(nil)
+		require.NoError(t, err)
+		assert.NotNil(t, cert)
+	})
+
+	t.Run("case=fails if the certificate can not be loaded", func(t *testing.T) {
+		_, err := NewCertificateReloader(config.TLS{CertPath: filepath.Join(dir, "missing.crt"), KeyPath: keyPath}, logrusx.New("", ""))
+		require.Error(t, err)
+	})
+}